@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/functions/system"
+	"github.com/spf13/cobra"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Capture and compare network tuning snapshots",
+	Long: `Record the host's network tuning before making changes, then see what
+changed since.
+
+Unlike check_sysctl_drift, which compares against a desired config, a
+baseline is the full observed state: socket buffers, backlogs, TCP
+options, and the inspect_network_buffers analysis.`,
+}
+
+var baselineCaptureCmd = &cobra.Command{
+	Use:   "capture <file>",
+	Short: "Write the current network tuning to a JSON baseline",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runBaselineCapture(args[0]) {
+			os.Exit(1)
+		}
+	},
+}
+
+var baselineDiffCmd = &cobra.Command{
+	Use:   "diff <file>",
+	Short: "Show what changed since a baseline was captured",
+	Long: `Re-read the current network tuning and list every parameter whose value
+differs from the baseline. Exits non-zero if anything changed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runBaselineDiff(args[0]) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	baselineCmd.AddCommand(baselineCaptureCmd)
+	baselineCmd.AddCommand(baselineDiffCmd)
+}
+
+// runBaselineCapture snapshots the host and writes it to path.
+func runBaselineCapture(path string) bool {
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	b, err := system.CaptureNetworkBaseline()
+	if err == nil {
+		err = system.WriteNetworkBaseline(path, b)
+	}
+	if err != nil {
+		fmt.Println(failStyle.Render(fmt.Sprintf("✗ Baseline capture failed: %v", err)))
+		return false
+	}
+
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).
+		Render(fmt.Sprintf("✓ Captured %d parameters to %s", len(b.Sysctls), path)))
+	fmt.Println(labelStyle.Render(fmt.Sprintf("  %s at %s", b.Hostname, b.CapturedAt.Format("2006-01-02 15:04:05 MST"))))
+	for _, w := range b.Warnings {
+		fmt.Printf("  %s %s\n", warnStyle.Render("!"), labelStyle.Render(w))
+	}
+	return true
+}
+
+// runBaselineDiff compares the host with the baseline at path and returns
+// true when nothing changed.
+func runBaselineDiff(path string) bool {
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	before, err := system.LoadNetworkBaseline(path)
+	if err != nil {
+		fmt.Println(failStyle.Render(fmt.Sprintf("✗ %v", err)))
+		return false
+	}
+	after, err := system.CaptureNetworkBaselineFrom("/proc")
+	if err != nil {
+		fmt.Println(failStyle.Render(fmt.Sprintf("✗ Failed to read current state: %v", err)))
+		return false
+	}
+
+	diff := system.DiffNetworkBaselines(before, after)
+
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true).
+		Render(fmt.Sprintf("Changes since %s:\n", before.CapturedAt.Local().Format("2006-01-02 15:04:05 MST"))))
+
+	if diff.Unchanged {
+		fmt.Printf("  %s %s\n", passStyle.Render("✓"),
+			labelStyle.Render(fmt.Sprintf("%d parameters unchanged", diff.Checked)))
+		return true
+	}
+
+	for _, c := range diff.Changed {
+		fmt.Printf("  %s %-36s %s → %s\n", failStyle.Render("~"), c.Parameter,
+			labelStyle.Render(baselineValue(c.Before)), baselineValue(c.After))
+	}
+	fmt.Println()
+	fmt.Println(labelStyle.Render(fmt.Sprintf("%d of %d parameters changed", len(diff.Changed), diff.Checked)))
+	return false
+}
+
+// baselineValue renders a value that was unreadable on one side.
+func baselineValue(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/bench"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchIterations int
+	benchQueries    []string
+	benchJSON       bool
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure retrieval, LLM and execution latency",
+	Long: `Run a fixed set of queries repeatedly and report latency percentiles for
+each stage of answering them: embedding the query, searching the vector
+store, LLM generation and running the proposed functions, plus overall
+throughput. Use it to tell whether the LLM or the vector store is the
+bottleneck of a deployment.
+
+Only read and analyze functions run; pass --plan-only to leave execution
+out entirely. Each query starts from an empty conversation and the
+history file is neither read nor written.
+
+Examples:
+  friday bench
+  friday bench --iterations 20 --json
+  friday bench --plan-only --query "Check TCP health on port 50051"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if benchIterations <= 0 {
+			printError("Invalid --iterations", fmt.Errorf("must be positive, got %d", benchIterations))
+			os.Exit(1)
+		}
+
+		readOnly = true
+		noHistory = true
+		agentInstance := initAgent()
+		defer agentInstance.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		report, err := bench.Run(ctx, agentInstance, bench.Options{
+			Queries:    benchQueries,
+			Iterations: benchIterations,
+		})
+		if err != nil {
+			printError("Benchmark failed", err)
+			agentInstance.Close()
+			os.Exit(1)
+		}
+
+		if benchJSON {
+			out, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+		printBench(report)
+	},
+}
+
+func init() {
+	benchCmd.Flags().IntVar(&benchIterations, "iterations", 5, "Times to run the query set")
+	benchCmd.Flags().StringArrayVar(&benchQueries, "query", nil, "Query to run (repeatable; default: a built-in read-only set)")
+	benchCmd.Flags().BoolVar(&planOnly, "plan-only", false, "Stop after the LLM proposes functions, without running them")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "Print the report as JSON")
+}
+
+func printBench(report *bench.Report) {
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7C3AED")).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("%d queries over %d iteration(s), %.2f queries/s",
+		report.Queries, report.Iterations, report.Throughput)))
+	if report.Errors > 0 {
+		fmt.Println(failStyle.Render(fmt.Sprintf("%d queries failed; their stages are included below", report.Errors)))
+	}
+	fmt.Println()
+	fmt.Println(labelStyle.Render(fmt.Sprintf("  %-14s %6s %10s %10s %10s %10s %10s",
+		"stage", "count", "p50 ms", "p90 ms", "p99 ms", "mean ms", "max ms")))
+	for _, s := range append(report.Stages, report.Total) {
+		fmt.Printf("  %-14s %6d %10.1f %10.1f %10.1f %10.1f %10.1f\n",
+			s.Stage, s.Count, s.P50Ms, s.P90Ms, s.P99Ms, s.MeanMs, s.MaxMs)
+	}
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/friday/internal/config"
@@ -17,6 +21,32 @@ var configCmd = &cobra.Command{
 	Run:   runConfig,
 }
 
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration",
+	Long:  "Print the merged configuration (file values over defaults) with secrets redacted.",
+	Run: func(cmd *cobra.Command, args []string) {
+		showConfig()
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the effective configuration",
+	Long: `Validate the effective configuration and check that its dependencies are reachable.
+
+Checks:
+  - Config values are well-formed (endpoint URLs, positive timeouts)
+  - Qdrant accepts TCP connections
+  - ONNX model and vocabulary files exist
+  - LLM endpoint responds over HTTP`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runConfigValidate() {
+			os.Exit(1)
+		}
+	},
+}
+
 var (
 	configInit bool
 	configShow bool
@@ -25,6 +55,9 @@ var (
 func init() {
 	configCmd.Flags().BoolVar(&configInit, "init", false, "Create default config file")
 	configCmd.Flags().BoolVar(&configShow, "show", true, "Show current configuration")
+
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
 }
 
 func runConfig(cmd *cobra.Command, args []string) {
@@ -76,7 +109,7 @@ func showConfig() {
 	}
 
 	// Pretty print config
-	data, err := yaml.Marshal(cfg)
+	data, err := yaml.Marshal(cfg.Redacted())
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
@@ -91,3 +124,86 @@ func showConfig() {
 	fmt.Println("  2. ./config.yaml")
 	fmt.Println("  3. ~/.telemetry-debugger/config.yaml")
 }
+
+// configCheck is a single line in the `config validate` report.
+type configCheck struct {
+	name string
+	err  error
+}
+
+// runConfigValidate prints a pass/fail report for the effective configuration
+// and returns true when every check passed.
+func runConfigValidate() bool {
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Println(failStyle.Render(fmt.Sprintf("✗ Failed to load config: %v", err)))
+		return false
+	}
+
+	checks := []configCheck{{name: "config values", err: cfg.Validate()}}
+
+	// Reachability checks only make sense once the values themselves are sane.
+	if checks[0].err == nil {
+		checks = append(checks,
+			configCheck{name: "qdrant", err: checkTCP(cfg.Qdrant.Host, cfg.Qdrant.Port)},
+			configCheck{name: "embedding model", err: checkFiles(cfg.ONNX.ModelPath, cfg.ONNX.VocabPath)},
+			configCheck{name: "llm endpoint", err: checkHTTP(cfg.LLM.Endpoint)},
+		)
+		if cfg.RAG.KnowledgeDir != "" {
+			checks = append(checks,
+				configCheck{name: "knowledge dir", err: checkFiles(cfg.RAG.KnowledgeDir)})
+		}
+	}
+
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true).
+		Render("Configuration Check:\n"))
+
+	ok := true
+	for _, c := range checks {
+		if c.err != nil {
+			ok = false
+			fmt.Printf("  %s %-16s %s\n", failStyle.Render("✗"), c.name, labelStyle.Render(c.err.Error()))
+			continue
+		}
+		fmt.Printf("  %s %s\n", passStyle.Render("✓"), c.name)
+	}
+
+	fmt.Println()
+	if ok {
+		fmt.Println(passStyle.Render("PASS"))
+	} else {
+		fmt.Println(failStyle.Render("FAIL"))
+	}
+	return ok
+}
+
+func checkTCP(host string, port int) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprint(port)), 3*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func checkFiles(paths ...string) error {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkHTTP treats any HTTP response as reachable; only transport errors fail.
+func checkHTTP(endpoint string) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(endpoint, "/") + "/models")
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/diagnostics"
+	"github.com/spf13/cobra"
+)
+
+var depsJSON bool
+
+var depsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "Check the health of the service's configured dependencies",
+	Long: `Work with the dependencies listed under "dependencies" in the config.
+
+Each dependency has a name, a type (tcp, http, https, grpc, unix), and
+the host, port, or path to reach it:
+
+  dependencies:
+    - name: postgres
+      type: tcp
+      host: db.internal
+      port: 5432
+    - name: auth
+      type: grpc
+      host: auth.internal
+      port: 50051
+    - name: cache-sidecar
+      type: unix
+      path: /run/cache.sock
+      optional: true`,
+}
+
+var depsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Run each dependency's health check and report a rollup verdict",
+	Long: `Check every configured dependency at once with the check matching its
+type: a TCP connect, an HTTP GET, a gRPC health check, or a unix socket
+connect.
+
+A dependency is healthy, degraded (answering but reporting a problem, such
+as HTTP 503 or gRPC NOT_SERVING), or down. The verdict is unhealthy if a
+required dependency is down, degraded if anything else is wrong, and
+healthy otherwise. The command exits non-zero when unhealthy.
+
+Examples:
+  friday deps check
+  friday deps check --json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runDepsCheck() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	depsCheckCmd.Flags().BoolVar(&depsJSON, "json", false, "Print the result as JSON")
+	depsCmd.AddCommand(depsCheckCmd)
+}
+
+// runDepsCheck checks the configured dependencies and reports false if the
+// verdict is unhealthy.
+func runDepsCheck() bool {
+	cfg, err := loadConfig()
+	if err != nil {
+		printError("Failed to load config", err)
+		return false
+	}
+	if len(cfg.Dependencies) == 0 {
+		printError("Nothing to check", errors.New("no dependencies configured"))
+		return false
+	}
+
+	report := diagnostics.CheckDependencies(context.Background(), cfg.Dependencies)
+	if depsJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printDependencyReport(report)
+	}
+	return report.Verdict != diagnostics.VerdictUnhealthy
+}
+
+func printDependencyReport(report *diagnostics.DependencyReport) {
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true).
+		Render("Dependencies:\n"))
+
+	for _, d := range report.Dependencies {
+		var mark string
+		switch {
+		case d.Status == diagnostics.DepHealthy:
+			mark = passStyle.Render("✓")
+		case d.Status == diagnostics.DepDegraded || d.Optional:
+			mark = warnStyle.Render("⚠")
+		default:
+			mark = failStyle.Render("✗")
+		}
+		detail := fmt.Sprintf("%s %s (%.1fms) %s", d.Type, d.Target, d.LatencyMs, d.Detail)
+		if d.Optional {
+			detail += " [optional]"
+		}
+		fmt.Printf("  %s %-18s %s\n", mark, d.Name, labelStyle.Render(detail))
+		if d.Explanation != nil && d.Explanation.Suggestion != "" {
+			fmt.Printf("    %s\n", labelStyle.Render("→ "+d.Explanation.Suggestion))
+		}
+	}
+
+	fmt.Println()
+	summary := fmt.Sprintf("%d healthy, %d degraded, %d down", report.Healthy, report.Degraded, report.Down)
+	switch report.Verdict {
+	case diagnostics.VerdictHealthy:
+		fmt.Println(passStyle.Render("HEALTHY") + "  " + labelStyle.Render(summary))
+	case diagnostics.VerdictDegraded:
+		fmt.Println(warnStyle.Render("DEGRADED") + "  " + labelStyle.Render(summary))
+	default:
+		fmt.Println(failStyle.Render("UNHEALTHY") + "  " + labelStyle.Render(summary))
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/config"
+	"github.com/qdrant/go-client/qdrant"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that external tools and services are available",
+	Long: `Run a self-test of everything the diagnostic functions depend on.
+
+Checks:
+  - External binaries (gdb/lldb, ss, sysctl, ping, traceroute) and their versions
+  - LLM endpoint responds over HTTP
+  - ONNX embedding model and vocabulary files exist
+  - Qdrant accepts connections and the knowledge collection is ready
+  - Whether the process has root for privileged (modify) functions
+
+Each check reports pass, warn, or fail; the command exits non-zero on any fail.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runDoctor() {
+			os.Exit(1)
+		}
+	},
+}
+
+// doctorStatus is the outcome of a single doctor check.
+type doctorStatus string
+
+const (
+	doctorPass doctorStatus = "pass"
+	doctorWarn doctorStatus = "warn"
+	doctorFail doctorStatus = "fail"
+)
+
+// doctorCheck is a single line in the doctor report.
+type doctorCheck struct {
+	name   string
+	status doctorStatus
+	detail string
+}
+
+// doctorDeps are the system probes the doctor relies on, injectable so tests
+// do not depend on the host.
+type doctorDeps struct {
+	lookPath         func(file string) (string, error)
+	version          func(path string, args ...string) string
+	isRoot           func() bool
+	collectionStatus func(cfg *config.Config) (string, error)
+}
+
+func defaultDoctorDeps() doctorDeps {
+	return doctorDeps{
+		lookPath:         exec.LookPath,
+		version:          binaryVersion,
+		isRoot:           func() bool { return os.Geteuid() == 0 },
+		collectionStatus: qdrantCollectionStatus,
+	}
+}
+
+// doctorBinary is an external tool used by one or more functions. Any one of
+// names satisfies it.
+type doctorBinary struct {
+	names       []string
+	versionArgs []string
+	usedBy      string
+}
+
+func doctorBinaries() []doctorBinary {
+	debuggers := []string{"gdb", "lldb"}
+	if runtime.GOOS == "darwin" {
+		debuggers = []string{"lldb", "gdb"}
+	}
+	return []doctorBinary{
+		{names: debuggers, versionArgs: []string{"--version"}, usedBy: "analyze_core_dump"},
+		{names: []string{"ss"}, versionArgs: []string{"-V"}, usedBy: "check_tcp_health"},
+		{names: []string{"sysctl"}, versionArgs: []string{"--version"}, usedBy: "execute_sysctl_command"},
+		{names: []string{"ping"}, versionArgs: []string{"-V"}, usedBy: "ping"},
+		{names: []string{"traceroute"}, versionArgs: []string{"--version"}, usedBy: "traceroute"},
+		{names: []string{"journalctl"}, versionArgs: []string{"--version"}, usedBy: "service_logs"},
+	}
+}
+
+func runDoctor() bool {
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	deps := defaultDoctorDeps()
+	checks := checkDoctorBinaries(deps)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		checks = append(checks, doctorCheck{name: "config", status: doctorFail, detail: err.Error()})
+	} else {
+		checks = append(checks, checkDoctorServices(cfg, deps)...)
+	}
+	checks = append(checks, checkDoctorPrivileges(deps))
+
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true).
+		Render("Doctor:\n"))
+
+	ok := true
+	for _, c := range checks {
+		var mark string
+		switch c.status {
+		case doctorPass:
+			mark = passStyle.Render("✓")
+		case doctorWarn:
+			mark = warnStyle.Render("⚠")
+		default:
+			mark = failStyle.Render("✗")
+			ok = false
+		}
+		fmt.Printf("  %s %-18s %s\n", mark, c.name, labelStyle.Render(c.detail))
+	}
+
+	fmt.Println()
+	if ok {
+		fmt.Println(passStyle.Render("PASS"))
+	} else {
+		fmt.Println(failStyle.Render("FAIL"))
+	}
+	return ok
+}
+
+// checkDoctorBinaries reports each external tool's path and version. A
+// missing tool is a warning: only the functions that use it are affected.
+func checkDoctorBinaries(deps doctorDeps) []doctorCheck {
+	var checks []doctorCheck
+	for _, b := range doctorBinaries() {
+		name := strings.Join(b.names, "/")
+
+		var path, found string
+		for _, n := range b.names {
+			if p, err := deps.lookPath(n); err == nil {
+				path, found = p, n
+				break
+			}
+		}
+		if path == "" {
+			checks = append(checks, doctorCheck{
+				name:   name,
+				status: doctorWarn,
+				detail: fmt.Sprintf("not found in PATH; %s will fail", b.usedBy),
+			})
+			continue
+		}
+
+		detail := path
+		if v := deps.version(path, b.versionArgs...); v != "" {
+			detail = fmt.Sprintf("%s (%s)", path, v)
+		}
+		checks = append(checks, doctorCheck{name: found, status: doctorPass, detail: detail})
+	}
+	return checks
+}
+
+// checkDoctorServices checks the LLM endpoint, embedding model files, and
+// Qdrant connectivity and collection status.
+func checkDoctorServices(cfg *config.Config, deps doctorDeps) []doctorCheck {
+	checks := []doctorCheck{
+		resultCheck("llm endpoint", cfg.LLM.Endpoint, checkHTTP(cfg.LLM.Endpoint)),
+		resultCheck("embedding model", cfg.ONNX.ModelPath, checkFiles(cfg.ONNX.ModelPath, cfg.ONNX.VocabPath)),
+	}
+
+	addr := fmt.Sprintf("%s:%d", cfg.Qdrant.Host, cfg.Qdrant.Port)
+	if err := checkTCP(cfg.Qdrant.Host, cfg.Qdrant.Port); err != nil {
+		return append(checks, doctorCheck{name: "qdrant", status: doctorFail, detail: err.Error()})
+	}
+	checks = append(checks, doctorCheck{name: "qdrant", status: doctorPass, detail: addr})
+
+	collection := doctorCheck{name: "qdrant collection"}
+	status, err := deps.collectionStatus(cfg)
+	switch {
+	case err != nil:
+		collection.status, collection.detail = doctorWarn, err.Error()
+	case status == "":
+		collection.status = doctorWarn
+		collection.detail = fmt.Sprintf("'%s' does not exist; retrieval will return no context", cfg.Qdrant.Collection)
+	case status != "green":
+		collection.status = doctorWarn
+		collection.detail = fmt.Sprintf("'%s' status is %s", cfg.Qdrant.Collection, status)
+	default:
+		collection.status = doctorPass
+		collection.detail = fmt.Sprintf("'%s' is %s", cfg.Qdrant.Collection, status)
+	}
+	return append(checks, collection)
+}
+
+// checkDoctorPrivileges warns when modify functions will lack permission.
+func checkDoctorPrivileges(deps doctorDeps) doctorCheck {
+	if deps.isRoot() {
+		return doctorCheck{name: "privileges", status: doctorPass, detail: "running as root"}
+	}
+	return doctorCheck{
+		name:   "privileges",
+		status: doctorWarn,
+		detail: "not root; modify functions such as execute_sysctl_command will fail",
+	}
+}
+
+func resultCheck(name, detail string, err error) doctorCheck {
+	if err != nil {
+		return doctorCheck{name: name, status: doctorFail, detail: err.Error()}
+	}
+	return doctorCheck{name: name, status: doctorPass, detail: detail}
+}
+
+// binaryVersion returns the first non-empty line a tool prints for its
+// version flag, or "" if it prints nothing useful.
+func binaryVersion(path string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, _ := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// qdrantCollectionStatus returns the configured collection's status in lower
+// case ("green", "yellow", ...), or "" if the collection does not exist.
+func qdrantCollectionStatus(cfg *config.Config) (string, error) {
+	client, err := qdrant.NewClient(&qdrant.Config{Host: cfg.Qdrant.Host, Port: cfg.Qdrant.Port})
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exists, err := client.CollectionExists(ctx, cfg.Qdrant.Collection)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+
+	info, err := client.GetCollectionInfo(ctx, cfg.Qdrant.Collection)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(info.GetStatus().String()), nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/config"
+)
+
+func fakeDoctorDeps(available map[string]string) doctorDeps {
+	return doctorDeps{
+		lookPath: func(file string) (string, error) {
+			if p, ok := available[file]; ok {
+				return p, nil
+			}
+			return "", errors.New("executable file not found in $PATH")
+		},
+		version: func(path string, args ...string) string {
+			return filepath.Base(path) + " 1.0"
+		},
+		isRoot:           func() bool { return false },
+		collectionStatus: func(cfg *config.Config) (string, error) { return "green", nil },
+	}
+}
+
+func findDoctorCheck(t *testing.T, checks []doctorCheck, name string) doctorCheck {
+	t.Helper()
+	for _, c := range checks {
+		if c.name == name {
+			return c
+		}
+	}
+	t.Fatalf("no check named %q in %+v", name, checks)
+	return doctorCheck{}
+}
+
+func TestCheckDoctorBinaries(t *testing.T) {
+	deps := fakeDoctorDeps(map[string]string{
+		"lldb":   "/usr/bin/lldb",
+		"ss":     "/usr/sbin/ss",
+		"sysctl": "/usr/sbin/sysctl",
+		"ping":   "/bin/ping",
+	})
+
+	checks := checkDoctorBinaries(deps)
+	if len(checks) != len(doctorBinaries()) {
+		t.Fatalf("expected %d checks, got %d", len(doctorBinaries()), len(checks))
+	}
+
+	// gdb is missing but lldb satisfies the debugger requirement.
+	dbg := findDoctorCheck(t, checks, "lldb")
+	if dbg.status != doctorPass {
+		t.Errorf("debugger status = %s, want pass", dbg.status)
+	}
+	if !strings.Contains(dbg.detail, "/usr/bin/lldb") || !strings.Contains(dbg.detail, "lldb 1.0") {
+		t.Errorf("debugger detail = %q, want path and version", dbg.detail)
+	}
+
+	tr := findDoctorCheck(t, checks, "traceroute")
+	if tr.status != doctorWarn {
+		t.Errorf("traceroute status = %s, want warn", tr.status)
+	}
+	if !strings.Contains(tr.detail, "not found") {
+		t.Errorf("traceroute detail = %q", tr.detail)
+	}
+}
+
+func TestCheckDoctorBinaries_NoDebugger(t *testing.T) {
+	checks := checkDoctorBinaries(fakeDoctorDeps(map[string]string{}))
+
+	var dbg doctorCheck
+	for _, c := range checks {
+		if strings.Contains(c.name, "gdb") {
+			dbg = c
+		}
+	}
+	if dbg.status != doctorWarn {
+		t.Fatalf("debugger check = %+v, want warn", dbg)
+	}
+	if !strings.Contains(dbg.name, "lldb") {
+		t.Errorf("missing debugger check should name both alternatives, got %q", dbg.name)
+	}
+}
+
+func TestCheckDoctorBinaries_VersionOptional(t *testing.T) {
+	deps := fakeDoctorDeps(map[string]string{"ss": "/usr/sbin/ss"})
+	deps.version = func(path string, args ...string) string { return "" }
+
+	c := findDoctorCheck(t, checkDoctorBinaries(deps), "ss")
+	if c.status != doctorPass || c.detail != "/usr/sbin/ss" {
+		t.Errorf("ss check = %+v, want pass with bare path", c)
+	}
+}
+
+// doctorTestConfig points the LLM at an httptest server, Qdrant at a local
+// listener, and the ONNX model at real temp files.
+func doctorTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(llm.Close)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	dir := t.TempDir()
+	model := filepath.Join(dir, "model.onnx")
+	vocab := filepath.Join(dir, "vocab.txt")
+	for _, p := range []string{model, vocab} {
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.LLM.Endpoint = llm.URL + "/v1"
+	cfg.Qdrant.Host = host
+	cfg.Qdrant.Port = port
+	cfg.Qdrant.Collection = "friday_docs"
+	cfg.ONNX.ModelPath = model
+	cfg.ONNX.VocabPath = vocab
+	return cfg
+}
+
+func TestCheckDoctorServices_AllReachable(t *testing.T) {
+	cfg := doctorTestConfig(t)
+
+	checks := checkDoctorServices(cfg, fakeDoctorDeps(nil))
+	for _, name := range []string{"llm endpoint", "embedding model", "qdrant", "qdrant collection"} {
+		if c := findDoctorCheck(t, checks, name); c.status != doctorPass {
+			t.Errorf("%s = %+v, want pass", name, c)
+		}
+	}
+}
+
+func TestCheckDoctorServices_LLMDown(t *testing.T) {
+	cfg := doctorTestConfig(t)
+	down := httptest.NewServer(http.NotFoundHandler())
+	cfg.LLM.Endpoint = down.URL
+	down.Close()
+
+	c := findDoctorCheck(t, checkDoctorServices(cfg, fakeDoctorDeps(nil)), "llm endpoint")
+	if c.status != doctorFail {
+		t.Errorf("llm endpoint = %+v, want fail", c)
+	}
+}
+
+func TestCheckDoctorServices_QdrantDown(t *testing.T) {
+	cfg := doctorTestConfig(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg.Qdrant.Port = ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	deps := fakeDoctorDeps(nil)
+	deps.collectionStatus = func(cfg *config.Config) (string, error) {
+		t.Error("collection status should not be queried when Qdrant is unreachable")
+		return "", nil
+	}
+
+	checks := checkDoctorServices(cfg, deps)
+	if c := findDoctorCheck(t, checks, "qdrant"); c.status != doctorFail {
+		t.Errorf("qdrant = %+v, want fail", c)
+	}
+	for _, c := range checks {
+		if c.name == "qdrant collection" {
+			t.Errorf("unexpected collection check %+v", c)
+		}
+	}
+}
+
+func TestCheckDoctorServices_CollectionStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		err    error
+		want   doctorStatus
+		detail string
+	}{
+		{"green", "green", nil, doctorPass, "is green"},
+		{"yellow", "yellow", nil, doctorWarn, "status is yellow"},
+		{"missing", "", nil, doctorWarn, "does not exist"},
+		{"error", "", errors.New("rpc error"), doctorWarn, "rpc error"},
+	}
+
+	cfg := doctorTestConfig(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := fakeDoctorDeps(nil)
+			deps.collectionStatus = func(*config.Config) (string, error) { return tt.status, tt.err }
+
+			c := findDoctorCheck(t, checkDoctorServices(cfg, deps), "qdrant collection")
+			if c.status != tt.want {
+				t.Errorf("status = %s, want %s", c.status, tt.want)
+			}
+			if !strings.Contains(c.detail, tt.detail) {
+				t.Errorf("detail = %q, want it to contain %q", c.detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestCheckDoctorServices_MissingModel(t *testing.T) {
+	cfg := doctorTestConfig(t)
+	cfg.ONNX.VocabPath = filepath.Join(t.TempDir(), "missing.txt")
+
+	c := findDoctorCheck(t, checkDoctorServices(cfg, fakeDoctorDeps(nil)), "embedding model")
+	if c.status != doctorFail {
+		t.Errorf("embedding model = %+v, want fail", c)
+	}
+}
+
+func TestCheckDoctorPrivileges(t *testing.T) {
+	deps := fakeDoctorDeps(nil)
+	if c := checkDoctorPrivileges(deps); c.status != doctorWarn {
+		t.Errorf("non-root = %+v, want warn", c)
+	}
+
+	deps.isRoot = func() bool { return true }
+	if c := checkDoctorPrivileges(deps); c.status != doctorPass {
+		t.Errorf("root = %+v, want pass", c)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/friday/internal/agent"
+	"github.com/friday/internal/config"
+	"go.uber.org/zap"
+)
+
+// startConfigReload reloads a's configuration from the active config file
+// whenever the file changes or the process receives SIGHUP. A file that fails
+// to load or validate is logged and the running configuration is kept. The
+// returned func stops watching.
+func startConfigReload(a *agent.Agent) func() {
+	path := activeConfigPath()
+	if path == "" {
+		return func() {}
+	}
+
+	logger := createLogger()
+	reload := func(cfg *config.Config, err error) {
+		if err != nil {
+			logger.Warn("Config reload failed, keeping current settings",
+				zap.String("path", path), zap.Error(err))
+			return
+		}
+		// Reload logs what changed, or why the new config was rejected.
+		_ = a.Reload(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := config.Watch(ctx, path, reload); err != nil {
+		logger.Warn("Not watching config file for changes; send SIGHUP to reload",
+			zap.String("path", path), zap.Error(err))
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				reload(config.Load(path))
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(hup)
+		cancel()
+	}
+}
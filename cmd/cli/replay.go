@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/agent"
+	"github.com/friday/internal/config"
+	"github.com/friday/internal/executor"
+	"github.com/friday/internal/functions"
+	"github.com/friday/internal/types"
+	"github.com/friday/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var replayDryRun bool
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <plan.json>",
+	Short: "Re-run a saved plan without calling the LLM",
+	Long: `Run a plan saved with --save-plan or the interactive /save command straight
+through the transaction engine, so a validated chain of functions becomes a
+repeatable runbook.
+
+${function.field} references between steps are resolved against this run's
+results. Modify functions still go through the dry-run check and the
+confirmation prompt, and safe_mode still refuses them.
+
+Examples:
+  friday "why is port 50051 slow?" --save-plan slow-port.json
+  friday replay slow-port.json
+  friday replay slow-port.json --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runReplay(args[0], replayDryRun) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	replayCmd.Flags().BoolVar(&replayDryRun, "dry-run", false, "Run read and analyze steps and validate modify steps without applying them")
+}
+
+// runReplay executes the plan at path and returns true when every function
+// succeeded.
+func runReplay(path string, dryRun bool) bool {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	plan, err := executor.LoadPlan(path)
+	if err != nil {
+		printError("Cannot replay", err)
+		return false
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Warning: Could not load config: %v\n", err)
+		cfg = config.DefaultConfig()
+	}
+
+	registry, err := functions.LoadRegistry("functions.yaml")
+	if err != nil {
+		printError("Failed to load functions", err)
+		return false
+	}
+	for _, fn := range plan.Functions {
+		if _, ok := registry.Get(fn.Name); !ok {
+			printError("Cannot replay", fmt.Errorf("plan calls unknown function '%s'", fn.Name))
+			return false
+		}
+	}
+
+	_, engine := agent.NewTransactionEngine(cfg, registry, createLogger())
+
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true).
+		Render(fmt.Sprintf("Replaying %d function(s) from %s", len(plan.Functions), path)))
+	if plan.Query != "" {
+		fmt.Println(labelStyle.Render(fmt.Sprintf("  saved from: %q", plan.Query)))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	req := plan.Request()
+	req.DryRunOnly = dryRun
+	agent.ConfigureRequest(cfg, &req)
+	txResults, summary, execErr := engine.ExecuteTransactionWithSummary(ctx, req)
+
+	results := agent.DisplayResults(cfg, txResults)
+	fmt.Println()
+	ui.PrintEvent(&types.AgentEvent{
+		State:       types.StateResponding,
+		AllResults:  results,
+		Transaction: summary,
+	})
+
+	if execErr != nil {
+		printError("Replay failed", execErr)
+		return false
+	}
+	for _, r := range results {
+		if !r.Success {
+			return false
+		}
+	}
+	return true
+}
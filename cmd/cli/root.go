@@ -19,6 +19,14 @@ var (
 	configPath  string
 	verbose     bool
 	interactive bool
+	planOnly    bool
+	readOnly    bool
+	debugLLM    bool
+	noColor     bool
+	savePlanTo  string
+	// noHistory keeps the agent from loading or saving the conversation
+	// history file, for commands whose queries are not a conversation.
+	noHistory bool
 )
 
 var rootCmd = &cobra.Command{
@@ -38,6 +46,10 @@ Usage:
   friday "Check gRPC health on port 50051"
   friday --it`,
 
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		ui.ConfigureOutput(noColor)
+	},
+
 	Run: func(cmd *cobra.Command, args []string) {
 		if interactive {
 			runInteractive()
@@ -59,17 +71,32 @@ func Execute() {
 
 func init() {
 	rootCmd.Flags().BoolVar(&interactive, "it", false, "Start interactive mode")
+	rootCmd.Flags().BoolVar(&planOnly, "plan-only", false, "Show the functions the LLM proposes without executing them")
+	rootCmd.Flags().BoolVar(&debugLLM, "debug-llm", false, "Show the prompt sent to the LLM and its raw reply with each answer")
+	rootCmd.Flags().StringVar(&savePlanTo, "save-plan", "", "Save the executed functions to this file for 'friday replay'")
 	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path to config file")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colors and box drawing (also off when NO_COLOR is set or stdout is not a terminal)")
 
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(toolsCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(triageCmd)
+	rootCmd.AddCommand(triageHostCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(baselineCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(depsCmd)
+	rootCmd.AddCommand(benchCmd)
 }
 
 func runInteractive() {
 	agentInstance := initAgent()
 	defer agentInstance.Close()
+	stopReload := startConfigReload(agentInstance)
+	defer stopReload()
 	ui.Run(agentInstance)
 }
 
@@ -78,6 +105,16 @@ func runOneShot(args []string) {
 	agentInstance := initAgent()
 	defer agentInstance.Close()
 	ui.RunOneShot(agentInstance, query)
+
+	if savePlanTo != "" {
+		if err := agentInstance.SavePlan(savePlanTo); err != nil {
+			printError("Failed to save plan", err)
+			agentInstance.Close()
+			os.Exit(1)
+		}
+		fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF")).
+			Render("Plan saved to " + savePlanTo))
+	}
 }
 
 // initAgent loads config, checks LLM connectivity, and returns a ready agent.
@@ -87,12 +124,18 @@ func initAgent() *agent.Agent {
 		fmt.Printf("Warning: Could not load config: %v\n", err)
 		cfg = config.DefaultConfig()
 	}
+	if noHistory {
+		cfg.Conversation.HistoryFile = ""
+	}
 
 	logger := createLogger()
 
 	agentCfg := agent.Config{
 		AppConfig:     cfg,
 		FunctionsPath: "functions.yaml",
+		PlanOnly:      planOnly,
+		ReadOnly:      readOnly,
+		DebugLLM:      debugLLM,
 		Logger:        logger,
 	}
 
@@ -119,14 +162,28 @@ func initAgent() *agent.Agent {
 	return agentInstance
 }
 
+// defaultConfigPaths are tried in order when --config is not given.
+var defaultConfigPaths = []string{"config.local.yaml", "config.yaml"}
+
 func loadConfig() (*config.Config, error) {
 	if configPath != "" {
 		return config.Load(configPath)
 	}
-	return config.LoadFromPaths(
-		"config.local.yaml",
-		"config.yaml",
-	)
+	return config.LoadFromPaths(defaultConfigPaths...)
+}
+
+// activeConfigPath returns the config file loadConfig reads, or "" when it
+// falls back to defaults.
+func activeConfigPath() string {
+	if configPath != "" {
+		return configPath
+	}
+	for _, path := range defaultConfigPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
 }
 
 func createLogger() *zap.Logger {
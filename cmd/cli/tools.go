@@ -1,13 +1,24 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/friday/internal/functions"
+	"github.com/friday/internal/types"
 	"github.com/spf13/cobra"
 )
 
+var (
+	toolsCategory string
+	toolsPhase    string
+	toolsJSON     bool
+)
+
 var toolsCmd = &cobra.Command{
 	Use:   "tools",
 	Short: "List available tools",
@@ -17,14 +28,25 @@ These tools are automatically used by the AI agent when analyzing
 your infrastructure. You can also reference them directly in queries.
 
 Examples:
-  friday tools           # List all tools
-  friday tools --verbose # Show detailed info`,
+  friday tools                   # List all tools
+  friday tools --verbose         # Show detailed info
+  friday tools --category network
+  friday tools --phase read      # Only tools that change nothing
+  friday tools --json            # Name, description, category, phase, parameters`,
 	Run: func(cmd *cobra.Command, args []string) {
-		runTools()
+		if !runTools(types.ToolFilter{Category: toolsCategory, Phase: toolsPhase}, toolsJSON) {
+			os.Exit(1)
+		}
 	},
 }
 
-func runTools() {
+func init() {
+	toolsCmd.Flags().StringVar(&toolsCategory, "category", "", "Only list tools in this category")
+	toolsCmd.Flags().StringVar(&toolsPhase, "phase", "", "Only list tools in this phase (read, analyze, modify)")
+	toolsCmd.Flags().BoolVar(&toolsJSON, "json", false, "Print tool metadata as JSON")
+}
+
+func runTools(filter types.ToolFilter, asJSON bool) bool {
 	headerStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#7C3AED")).
 		Bold(true)
@@ -43,56 +65,78 @@ func runTools() {
 		Foreground(lipgloss.Color("#10B981")).
 		Bold(true)
 
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+
+	if filter.Phase != "" && !types.ValidPhase(strings.ToLower(filter.Phase)) {
+		fmt.Println(failStyle.Render(fmt.Sprintf("Unknown phase '%s' (want read, analyze, or modify)", filter.Phase)))
+		return false
+	}
+
 	// Load function registry from YAML
 	registry, err := functions.LoadRegistry("functions.yaml")
 	if err != nil {
-		fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444")).
-			Render(fmt.Sprintf("Failed to load functions: %v", err)))
+		fmt.Println(failStyle.Render(fmt.Sprintf("Failed to load functions: %v", err)))
 		fmt.Println(descStyle.Render("\nMake sure functions.yaml exists in the current directory."))
-		return
+		return false
+	}
+
+	tools := registry.Tools(filter)
+
+	if asJSON {
+		if err := writeToolsJSON(os.Stdout, tools); err != nil {
+			fmt.Fprintln(os.Stderr, failStyle.Render(fmt.Sprintf("Failed to encode tools: %v", err)))
+			return false
+		}
+		return true
 	}
 
 	fmt.Println(headerStyle.Render("Available Tools"))
 	fmt.Println()
 
-	// Group functions by category
-	categories := make(map[string][]string)
-	for name := range registry.Functions {
-		fn := registry.Functions[name]
-		categories[fn.Category] = append(categories[fn.Category], name)
+	if len(tools) == 0 {
+		fmt.Println(descStyle.Render("  No tools match the filter"))
+		return true
 	}
 
-	// Display by category
-	for category, names := range categories {
-		fmt.Printf("  %s\n", categoryStyle.Render(category))
-
-		for _, name := range names {
-			fn, _ := registry.Get(name)
-
-			fmt.Printf("    %s\n", toolStyle.Render(fn.Name))
-			fmt.Printf("      %s\n", descStyle.Render(fn.Description))
-
-			if verbose && len(fn.Parameters) > 0 {
-				fmt.Println("      Parameters:")
-				for _, p := range fn.Parameters {
-					req := ""
-					if p.Required {
-						req = " (required)"
-					}
-					fmt.Printf("        %s%s\n", paramStyle.Render(p.Name), req)
-					if p.Description != "" {
-						fmt.Printf("          %s\n", descStyle.Render(p.Description))
-					}
+	// Tools arrive sorted by category, so each category is one run.
+	for i, fn := range tools {
+		if i == 0 || fn.Category != tools[i-1].Category {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("  %s\n", categoryStyle.Render(fn.Category))
+		}
+
+		fmt.Printf("    %s %s\n", toolStyle.Render(fn.Name), descStyle.Render("("+fn.Phase+")"))
+		fmt.Printf("      %s\n", descStyle.Render(fn.Description))
+
+		if verbose && len(fn.Parameters) > 0 {
+			fmt.Println("      Parameters:")
+			for _, p := range fn.Parameters {
+				req := ""
+				if p.Required {
+					req = " (required)"
+				}
+				fmt.Printf("        %s%s\n", paramStyle.Render(p.Name), req)
+				if p.Description != "" {
+					fmt.Printf("          %s\n", descStyle.Render(p.Description))
 				}
 			}
 		}
-		fmt.Println()
 	}
+	fmt.Println()
 
-	totalCount := len(registry.Functions)
-	fmt.Println(descStyle.Render(fmt.Sprintf("  Total: %d tools available", totalCount)))
+	fmt.Println(descStyle.Render(fmt.Sprintf("  Total: %d of %d tools", len(tools), len(registry.Functions))))
 
 	if !verbose {
 		fmt.Println(descStyle.Render("  Use --verbose for parameter details"))
 	}
+	return true
+}
+
+// writeToolsJSON writes tools to w as an indented JSON array.
+func writeToolsJSON(w io.Writer, tools []types.ToolInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tools)
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/friday/internal/functions"
+	"github.com/friday/internal/types"
+)
+
+func TestWriteToolsJSON_Shape(t *testing.T) {
+	registry, err := functions.LoadRegistry("../../functions.yaml")
+	if err != nil {
+		t.Fatalf("failed to load registry: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeToolsJSON(&buf, registry.Tools(types.ToolFilter{Phase: types.PhaseModify})); err != nil {
+		t.Fatalf("writeToolsJSON returned error: %v", err)
+	}
+
+	var tools []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &tools); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(tools) == 0 {
+		t.Fatal("expected at least one modify tool")
+	}
+	for _, tool := range tools {
+		for _, key := range []string{"name", "description", "category", "phase", "parameters"} {
+			if _, ok := tool[key]; !ok {
+				t.Errorf("tool %v missing key %q", tool["name"], key)
+			}
+		}
+		if tool["phase"] != types.PhaseModify {
+			t.Errorf("expected only modify tools, got %v", tool["phase"])
+		}
+	}
+
+	params, _ := tools[0]["parameters"].([]interface{})
+	if len(params) == 0 {
+		t.Fatalf("expected parameters on %v", tools[0]["name"])
+	}
+	param, _ := params[0].(map[string]interface{})
+	for _, key := range []string{"name", "type", "required", "description"} {
+		if _, ok := param[key]; !ok {
+			t.Errorf("parameter %v missing key %q", param, key)
+		}
+	}
+}
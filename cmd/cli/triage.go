@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/functions/debugging"
+	"github.com/spf13/cobra"
+)
+
+var (
+	triageBinary      string
+	triageConcurrency int
+	triageJSON        bool
+)
+
+var triageCmd = &cobra.Command{
+	Use:   "triage <dir>",
+	Short: "Group a directory of core dumps by crash signature",
+	Long: `Analyze every core dump in a directory and group them by crash signature
+(signal, top three frames, and detected crash patterns), so a pile of cores
+becomes a ranked list of distinct bugs.
+
+Files named "core", "core.*", or "*.core" are analyzed.
+
+Examples:
+  friday triage /var/crash
+  friday triage ./cores --binary ./bin/server --concurrency 8
+  friday triage ./cores --json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := debugging.TriageCoreDir(args[0], triageBinary, triageConcurrency)
+		if err != nil {
+			printError("Triage failed", err)
+			os.Exit(1)
+		}
+
+		if triageJSON {
+			out, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+		printTriage(result)
+	},
+}
+
+func init() {
+	triageCmd.Flags().StringVar(&triageBinary, "binary", "", "Binary that produced the cores (improves symbolication)")
+	triageCmd.Flags().IntVar(&triageConcurrency, "concurrency", 4, "Maximum cores analyzed at once")
+	triageCmd.Flags().BoolVar(&triageJSON, "json", false, "Print the result as JSON")
+}
+
+func printTriage(result *debugging.TriageResult) {
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7C3AED")).Bold(true)
+	countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B")).Bold(true)
+	sigStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+
+	fmt.Println(headerStyle.Render(fmt.Sprintf("Core Triage: %s", result.Dir)))
+	fmt.Println(labelStyle.Render(fmt.Sprintf("%d core(s), %d analyzed, %d distinct crash(es)",
+		result.Total, result.Analyzed, len(result.Groups))))
+	fmt.Println()
+
+	for i, g := range result.Groups {
+		fmt.Printf("  %d. %s %s\n", i+1, countStyle.Render(fmt.Sprintf("%d×", g.Count)), sigStyle.Render(g.Signature))
+		fmt.Printf("     %s %s\n", labelStyle.Render("reason: "), g.CrashReason)
+		fmt.Printf("     %s %s\n", labelStyle.Render("example:"), g.ExampleCore)
+	}
+
+	if len(result.Failures) > 0 {
+		fmt.Println()
+		fmt.Println(failStyle.Render(fmt.Sprintf("Failed to analyze %d core(s):", len(result.Failures))))
+		for _, f := range result.Failures {
+			fmt.Printf("  %s %s\n", f.CorePath, labelStyle.Render(f.Error))
+		}
+	}
+}
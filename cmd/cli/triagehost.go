@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/diagnostics"
+	"github.com/spf13/cobra"
+)
+
+var triageHostJSON bool
+
+var triageHostCmd = &cobra.Command{
+	Use:   "triage-host",
+	Short: "Run a battery of read-only checks and list what is wrong with this host",
+	Long: `Check this host's interface counters, ephemeral port (socket) pressure,
+memory, root filesystem, network buffers, and conntrack table at once,
+and list anything abnormal, most urgent first.
+
+Nothing is changed. The command exits non-zero when a finding is
+critical.
+
+Examples:
+  friday triage-host
+  friday triage-host --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		report := diagnostics.TriageHost(diagnostics.DefaultHostSources())
+		if triageHostJSON {
+			out, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(out))
+		} else {
+			printHostTriage(report)
+		}
+		if report.Status == diagnostics.SeverityCritical {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	triageHostCmd.Flags().BoolVar(&triageHostJSON, "json", false, "Print the result as JSON")
+}
+
+func printHostTriage(report *diagnostics.HostTriageReport) {
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true).
+		Render("Host triage:\n"))
+
+	if len(report.Findings) == 0 {
+		fmt.Println("  " + passStyle.Render("✓") + " nothing abnormal found")
+	}
+	for _, f := range report.Findings {
+		var mark string
+		switch f.Severity {
+		case diagnostics.SeverityCritical:
+			mark = failStyle.Render("✗")
+		case diagnostics.SeverityWarning:
+			mark = warnStyle.Render("⚠")
+		default:
+			mark = labelStyle.Render("•")
+		}
+		fmt.Printf("  %s %-16s %s\n", mark, f.Check, f.Summary)
+	}
+
+	fmt.Println()
+	for _, c := range report.Checks {
+		if c.Status == diagnostics.CheckFailed || c.Status == diagnostics.CheckSkipped {
+			fmt.Printf("  %s\n", labelStyle.Render(fmt.Sprintf("%s %s: %s", c.Name, c.Status, c.Error)))
+		}
+	}
+
+	switch report.Status {
+	case diagnostics.SeverityCritical:
+		fmt.Println(failStyle.Render("CRITICAL"))
+	case diagnostics.SeverityWarning:
+		fmt.Println(warnStyle.Render("WARNING"))
+	default:
+		fmt.Println(passStyle.Render("OK"))
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/config"
+	"github.com/friday/internal/functions"
+	"github.com/friday/internal/types"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <file>...",
+	Short: "Check functions.yaml or config.yaml for schema errors",
+	Long: `Parse one or more YAML files and check them against their schema without
+starting the agent, reporting each problem with its line number.
+
+A file with a top-level "functions" key is checked as a function registry
+(name, category, phase, parameter names and types, rollback references);
+anything else is checked as a config file (unknown keys, endpoints,
+timeouts, required values).
+
+Exits non-zero if any file has a problem.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runValidate(args) {
+			os.Exit(1)
+		}
+	},
+}
+
+// runValidate prints a report per file and returns true when all are clean.
+func runValidate(paths []string) bool {
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+
+	ok := true
+	for _, path := range paths {
+		kind, issues, err := validateFile(path)
+		if err != nil {
+			ok = false
+			fmt.Printf("%s %s %s\n", failStyle.Render("✗"), path, labelStyle.Render(err.Error()))
+			continue
+		}
+		if len(issues) == 0 {
+			fmt.Printf("%s %s %s\n", passStyle.Render("✓"), path, labelStyle.Render("valid "+kind))
+			continue
+		}
+
+		ok = false
+		fmt.Printf("%s %s %s\n", failStyle.Render("✗"), path,
+			labelStyle.Render(fmt.Sprintf("%d problem(s) in %s", len(issues), kind)))
+		for _, issue := range issues {
+			fmt.Printf("    %s\n", issue)
+		}
+	}
+	return ok
+}
+
+// validateFile picks the schema for path from its contents and validates it.
+func validateFile(path string) (string, []types.SchemaIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if isFunctionsFile(data) {
+		issues, err := functions.ValidateFile(path)
+		return "function registry", issues, err
+	}
+	issues, err := config.ValidateFile(path)
+	return "config", issues, err
+}
+
+// isFunctionsFile reports whether data has a top-level "functions" key.
+func isFunctionsFile(data []byte) bool {
+	var top map[string]interface{}
+	if yaml.Unmarshal(data, &top) != nil {
+		return false
+	}
+	_, ok := top["functions"]
+	return ok
+}
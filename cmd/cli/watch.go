@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/friday/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchInterval time.Duration
+	watchCount    int
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [query]",
+	Short: "Re-run a query on a schedule and highlight changes",
+	Long: `Re-run the same query at a fixed interval and show what changed in the
+results since the previous run. Only read and analyze functions run in
+watch mode; a proposal containing a modify function is refused.
+
+Examples:
+  friday watch "Check gRPC health on port 50051" --interval 30s --count 10
+  friday watch "Analyze TCP connections on port 8080"   # until Ctrl+C`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if watchInterval <= 0 {
+			printError("Invalid --interval", fmt.Errorf("must be positive, got %s", watchInterval))
+			os.Exit(1)
+		}
+
+		readOnly = true
+		agentInstance := initAgent()
+		defer agentInstance.Close()
+		stopReload := startConfigReload(agentInstance)
+		defer stopReload()
+		ui.RunWatch(agentInstance, strings.Join(args, " "), watchInterval, watchCount)
+	},
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 30*time.Second, "Time between runs")
+	watchCmd.Flags().IntVar(&watchCount, "count", 0, "Number of runs (0 = until Ctrl+C)")
+}
@@ -4,8 +4,11 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,8 +16,10 @@ import (
 	ctxmgr "github.com/friday/internal/context"
 	"github.com/friday/internal/executor"
 	"github.com/friday/internal/functions"
+	"github.com/friday/internal/functions/network"
 	"github.com/friday/internal/llm"
 	"github.com/friday/internal/rag"
+	"github.com/friday/internal/truncate"
 	"github.com/friday/internal/types"
 	"github.com/friday/internal/validator"
 	"go.uber.org/zap"
@@ -22,8 +27,16 @@ import (
 
 // Agent orchestrates the interaction between user, LLM, RAG, and tool execution.
 type Agent struct {
+	// mu guards the settings Reload swaps: cfg, llmClient, txExecutor, and
+	// the retriever's search parameters. A query holds it for reading from
+	// start to finish, so a reload waits for queries in flight.
+	mu sync.RWMutex
+	// stateMu guards planOnly, lastPlan and lastResults, which concurrent
+	// queries holding mu for reading may change.
+	stateMu sync.Mutex
+
 	cfg              *config.Config
-	ragPipeline      *rag.Pipeline
+	ragPipeline      Retriever
 	llmClient        *llm.Client
 	executor         *executor.Executor
 	txExecutor       *executor.TransactionEngine
@@ -32,15 +45,54 @@ type Agent struct {
 	inputValidator   *validator.InputValidator
 	outputValidator  *validator.OutputValidator
 	masterPromptPath string
+	planOnly         bool
+	readOnly         bool
+	debugLLM         bool
+	confirmer        executor.Confirmer
+	lastPlan         *executor.Plan
+	lastResults      []types.ExecutionResult
+	session          *executor.SessionStore
 	logger           *zap.Logger
 }
 
+// Retriever supplies knowledge-base context for a query.
+// *rag.Pipeline satisfies this.
+type Retriever interface {
+	Retrieve(ctx context.Context, query string) ([]types.RetrievedChunk, error)
+	Close() error
+}
+
+// searchTuner is implemented by retrievers whose search parameters can be
+// changed by Reload. *rag.Pipeline satisfies this.
+type searchTuner interface {
+	SetSearchParams(topK int, minSimilarity float32)
+}
+
+// rankTuner is implemented by retrievers whose ranking weights can be
+// changed by Reload. *rag.Pipeline satisfies this.
+type rankTuner interface {
+	SetRanking(ranking config.RankingConfig)
+}
+
+// restartSettings are config sections Reload cannot apply to a running
+// agent; changes to them are logged and take effect on the next start.
+var restartSettings = []string{"qdrant.", "onnx.", "rag.knowledge_dir", "rag.max_context_length", "rag.embed_", "conversation.", "ui.", "logging."}
+
 // Config holds agent configuration.
 type Config struct {
 	AppConfig        *config.Config
 	FunctionsPath    string
 	MasterPromptPath string
-	Logger           *zap.Logger
+	// PlanOnly stops each query after the LLM proposes functions, without
+	// executing any of them.
+	PlanOnly bool
+	// ReadOnly refuses any proposal containing a modify-phase function, so
+	// only read and analyze functions ever run.
+	ReadOnly bool
+	// DebugLLM attaches the prompt and raw LLM reply to each AgentEvent, as
+	// llm.debug does in the config file.
+	DebugLLM bool
+	Logger   *zap.Logger
 }
 
 // New creates a new agent with all components initialized.
@@ -76,21 +128,44 @@ func New(cfg Config) (*Agent, error) {
 		ragPipeline = nil
 	}
 
+	// Make sure the collection exists with the embedding model's vector size.
+	// A collection built for a different model can never match a query, so
+	// retrieval is disabled rather than left to silently return nothing.
+	if ragPipeline != nil {
+		ensureCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := ragPipeline.EnsureCollection(ensureCtx, cfg.AppConfig.ONNX.EmbeddingDim, "cosine")
+		switch {
+		case errors.Is(err, rag.ErrCollectionMismatch):
+			cfg.Logger.Warn("RAG collection unusable, continuing without retrieval",
+				zap.String("collection", ragPipeline.CollectionName()),
+				zap.Error(err))
+			ragPipeline.Close()
+			ragPipeline = nil
+		case err != nil:
+			cfg.Logger.Warn("Could not verify RAG collection", zap.Error(err))
+		}
+		cancel()
+	}
+
+	// Index the user's runbook folder if configured. Also non-fatal: a failed
+	// sync leaves whatever is already in the collection searchable.
+	if ragPipeline != nil && cfg.AppConfig.RAG.KnowledgeDir != "" {
+		syncCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		if _, err := ragPipeline.SyncKnowledgeDir(syncCtx, cfg.AppConfig.RAG.KnowledgeDir); err != nil {
+			cfg.Logger.Warn("Knowledge directory ingestion failed",
+				zap.String("dir", cfg.AppConfig.RAG.KnowledgeDir),
+				zap.Error(err))
+		}
+		cancel()
+	}
+
 	// Initialize LLM client (vLLM) — pass temperature and max_tokens from config.
-	llmClient := llm.NewClient(
-		cfg.AppConfig.LLM.Endpoint,
-		cfg.AppConfig.LLM.Model,
-		time.Duration(cfg.AppConfig.LLM.TimeoutSeconds)*time.Second,
-		cfg.AppConfig.LLM.Temperature,
-		cfg.AppConfig.LLM.MaxTokens,
-	)
+	llmClient := newLLMClient(cfg.AppConfig)
 
 	// Initialize executor components.
-	exec := executor.NewExecutor(cfg.Logger)
-	vRes := executor.NewVariableResolver()
-	snapM := executor.NewSnapshotManager()
-
-	txExec := executor.NewTransactionEngine(exec, vRes, snapM, funcRegistry)
+	exec, txExec := NewTransactionEngine(cfg.AppConfig, funcRegistry, cfg.Logger)
+	session := executor.NewSessionStore()
+	txExec.SetSession(session)
 
 	// Initialize context manager.
 	ctxManager := ctxmgr.NewManager(cfg.AppConfig.Conversation.MaxMessages)
@@ -99,9 +174,8 @@ func New(cfg Config) (*Agent, error) {
 	inputValidator := validator.NewInputValidator()
 	outputValidator := validator.NewOutputValidator()
 
-	return &Agent{
+	a := &Agent{
 		cfg:              cfg.AppConfig,
-		ragPipeline:      ragPipeline,
 		llmClient:        llmClient,
 		executor:         exec,
 		txExecutor:       txExec,
@@ -110,8 +184,169 @@ func New(cfg Config) (*Agent, error) {
 		inputValidator:   inputValidator,
 		outputValidator:  outputValidator,
 		masterPromptPath: cfg.MasterPromptPath,
+		planOnly:         cfg.PlanOnly,
+		readOnly:         cfg.ReadOnly,
+		debugLLM:         cfg.DebugLLM,
+		session:          session,
 		logger:           cfg.Logger,
-	}, nil
+	}
+	// Assigned separately so a nil pipeline stays a nil interface.
+	if ragPipeline != nil {
+		a.ragPipeline = ragPipeline
+	}
+
+	// Non-fatal: an unreadable history starts the conversation afresh.
+	if err := a.loadHistory(); err != nil {
+		cfg.Logger.Warn("Failed to load history, starting empty", zap.Error(err))
+	}
+
+	return a, nil
+}
+
+// NewTransactionEngine wires an executor and transaction engine for cfg:
+// the HTTP User-Agent, schema validation against registry, and safe mode.
+// Replay uses it so saved plans run under the same settings as live queries.
+func NewTransactionEngine(cfg *config.Config, registry *functions.Registry, logger *zap.Logger) (*executor.Executor, *executor.TransactionEngine) {
+	exec := executor.NewExecutorWithRegistry(logger, registry)
+	return exec, newTransactionEngine(cfg, exec, registry)
+}
+
+// ConfigureRequest applies cfg's per-transaction executor limits and retry
+// policies to req, so a replayed plan runs under the same settings as a
+// query.
+func ConfigureRequest(cfg *config.Config, req *executor.TransactionRequest) {
+	req.MaxTransactionSeconds = cfg.Executor.MaxTransactionSeconds
+	req.RetryPolicies = executor.ReadRetryPolicies(cfg.Executor.MaxRetries,
+		time.Duration(cfg.Executor.RetryBackoffSeconds)*time.Second)
+}
+
+// DisplayResults converts an engine's results for display and history,
+// dropping the unredacted copies of outputs unless cfg shows them.
+func DisplayResults(cfg *config.Config, txResults []executor.FunctionResult) []types.ExecutionResult {
+	results := executor.ExecutionResults(txResults)
+	if !cfg.Executor.Redaction.ShowUnredacted {
+		for i := range results {
+			results[i].Unredacted = ""
+		}
+	}
+	return results
+}
+
+// newTransactionEngine applies cfg's executor settings to exec and wraps it
+// in a transaction engine.
+func newTransactionEngine(cfg *config.Config, exec *executor.Executor, registry *functions.Registry) *executor.TransactionEngine {
+	network.SetUserAgent(cfg.Executor.UserAgent)
+	// config.Validate has already rejected malformed entries.
+	_ = network.SetTargetAllowlist(cfg.Executor.TargetAllowlist)
+
+	var breaker *executor.CircuitBreaker
+	if cb := cfg.Executor.CircuitBreaker; cb.Enabled {
+		breaker = executor.NewCircuitBreaker(executor.BreakerConfig{
+			FailureThreshold: cb.FailureThreshold,
+			Window:           time.Duration(cb.WindowSeconds) * time.Second,
+			Cooldown:         time.Duration(cb.CooldownSeconds) * time.Second,
+		})
+	}
+	exec.SetCircuitBreaker(breaker)
+
+	var limiter *executor.TargetLimiter
+	if n := cfg.Executor.MaxConcurrentPerTarget; n > 0 {
+		limiter = executor.NewTargetLimiter(n)
+	}
+	exec.SetTargetLimiter(limiter)
+
+	// Safe mode never runs a modify function, so there is nothing to snapshot.
+	var snapM *executor.SnapshotManager
+	if !cfg.Executor.SafeMode {
+		snapM = executor.NewSnapshotManager()
+	}
+
+	txExec := executor.NewTransactionEngine(exec, executor.NewVariableResolver(), snapM, registry)
+	txExec.SetSafeMode(cfg.Executor.SafeMode)
+	if rc := cfg.Executor.Redaction; rc.Enabled {
+		// config.Validate has already rejected patterns that do not compile.
+		if redactor, err := validator.NewRedactor(rc.Patterns); err == nil {
+			txExec.SetRedactor(redactor)
+		}
+	}
+	return txExec
+}
+
+// newLLMClient creates the LLM client described by cfg.
+func newLLMClient(cfg *config.Config) *llm.Client {
+	return llm.NewClient(
+		cfg.LLM.Endpoint,
+		cfg.LLM.Model,
+		time.Duration(cfg.LLM.TimeoutSeconds)*time.Second,
+		cfg.LLM.Temperature,
+		cfg.LLM.MaxTokens,
+	)
+}
+
+// Reload applies cfg to the running agent without a restart. An invalid cfg
+// is rejected and the current configuration stays in effect. LLM settings,
+// executor settings (user agent, safe mode, circuit breaker), and the
+// retrieval top_k and min_similarity take effect for the next query;
+// settings in restartSettings are logged as needing a restart.
+// Reload waits for a query in progress to finish.
+func (a *Agent) Reload(cfg *config.Config) error {
+	if cfg == nil {
+		return errors.New("no configuration to reload")
+	}
+	if err := cfg.Validate(); err != nil {
+		a.logger.Warn("Rejected configuration reload, keeping current settings", zap.Error(err))
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	changed := config.Diff(a.cfg, cfg)
+	if len(changed) == 0 {
+		a.logger.Info("Configuration reloaded, nothing changed")
+		return nil
+	}
+
+	var applied, needRestart []string
+	for _, field := range changed {
+		if requiresRestart(field) {
+			needRestart = append(needRestart, field)
+		} else {
+			applied = append(applied, field)
+		}
+	}
+
+	if cfg.LLM != a.cfg.LLM {
+		a.llmClient = newLLMClient(cfg)
+	}
+	if !reflect.DeepEqual(cfg.Executor, a.cfg.Executor) && a.executor != nil {
+		a.txExecutor = newTransactionEngine(cfg, a.executor, a.functionRegistry)
+		a.txExecutor.SetSession(a.session)
+	}
+	if tuner, ok := a.ragPipeline.(searchTuner); ok {
+		tuner.SetSearchParams(cfg.RAG.TopK, cfg.RAG.MinSimilarity)
+	}
+	if tuner, ok := a.ragPipeline.(rankTuner); ok {
+		tuner.SetRanking(cfg.RAG.Ranking)
+	}
+
+	a.cfg = cfg
+
+	a.logger.Info("Configuration reloaded", zap.Strings("applied", applied))
+	if len(needRestart) > 0 {
+		a.logger.Warn("Some configuration changes need a restart", zap.Strings("settings", needRestart))
+	}
+	return nil
+}
+
+// requiresRestart reports whether the setting at field is in restartSettings.
+func requiresRestart(field string) bool {
+	for _, prefix := range restartSettings {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // ProcessQueryCmd returns a Bubble Tea command that processes a query.
@@ -142,6 +377,14 @@ func (a *Agent) ProcessQuery(ctx context.Context, query string) (*types.AgentEve
 
 // process handles the actual query processing.
 func (a *Agent) process(ctx context.Context, query string) (types.AgentEvent, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// Tag this query's log lines, here and in the executor, with one ID.
+	correlationID := executor.NewCorrelationID()
+	ctx = executor.WithCorrelationID(ctx, correlationID)
+	logger := a.logger.With(zap.String("correlation_id", correlationID))
+
 	// Validate input.
 	if err := a.inputValidator.Validate(query); err != nil {
 		return types.AgentEvent{
@@ -152,63 +395,140 @@ func (a *Agent) process(ctx context.Context, query string) (types.AgentEvent, er
 
 	sanitizedQuery := a.inputValidator.Sanitize(query)
 
+	// "save this as LABEL" is handled here; the LLM has nothing to add.
+	if label := saveRequestLabel(sanitizedQuery); label != "" {
+		return a.labelResultsEvent(label), nil
+	}
+
+	// Queries matching a fast_path route skip the LLM entirely.
+	if routed := a.routeQuery(sanitizedQuery); routed != nil {
+		logger.Info("Query matched a fast-path route, skipping the LLM",
+			zap.String("function", routed.Functions[0].Name))
+		return a.runProposal(ctx, sanitizedQuery, routed, nil, nil)
+	}
+
 	// Retrieve context from RAG.
 	var chunks []types.RetrievedChunk
 	if a.ragPipeline != nil {
 		var ragErr error
 		chunks, ragErr = a.ragPipeline.Retrieve(ctx, sanitizedQuery)
 		if ragErr != nil {
-			a.logger.Warn("RAG retrieval failed, continuing without context", zap.Error(ragErr))
+			logger.Warn("RAG retrieval failed, continuing without context", zap.Error(ragErr))
 			chunks = nil
 		}
 	}
 
-	a.logger.Info("Retrieved context", zap.Int("chunks_found", len(chunks)))
-
-	// Build full function definition slice from registry.
-	funcDefs := make([]types.FunctionDefinition, 0, len(a.functionRegistry.Functions))
-	for _, fn := range a.functionRegistry.Functions {
-		funcDefs = append(funcDefs, fn)
-	}
+	logger.Info("Retrieved context", zap.Int("chunks_found", len(chunks)))
 
 	// Build prompt using master_prompt.txt with all template variables substituted.
+	history := a.ctxManager.GetMessages()
 	prompt := llm.BuildPrompt(
 		sanitizedQuery,
 		chunks,
-		funcDefs,
-		a.ctxManager.GetMessages(),
+		a.promptFunctions(),
+		history,
 		a.masterPromptPath,
 	)
 
+	// A question about results already gathered should be answered from them
+	// rather than by re-running the same tools.
+	if isFollowUp(sanitizedQuery, history) {
+		logger.Info("Query refers to prior results, answering from context")
+		prompt += llm.BuildFollowUpHint(history)
+	}
+	prompt += llm.BuildSessionHint(a.savedResults())
+
 	// Call LLM.
+	start := time.Now()
 	response, err := a.llmClient.Generate(ctx, prompt)
+	types.ObserveStage(ctx, types.StageLLMGenerate, start)
 	if err != nil {
 		return types.AgentEvent{
 			State: types.StateError,
 			Error: fmt.Errorf("LLM generation failed: %w", err),
+			Debug: a.llmDebug(prompt, ""),
 		}, nil
 	}
+	debug := a.llmDebug(prompt, response)
 
 	// Parse and validate LLM response.
 	llmResp, err := a.outputValidator.Validate(response, a.functionRegistry.Functions)
 	if err != nil {
-		a.logger.Warn("LLM response validation failed",
+		logger.Warn("LLM response validation failed",
 			zap.Error(err),
-			zap.String("raw_response", truncate(response, 200)))
+			zap.String("raw_response", truncate.String(response, 200)))
+
+		// A well-formed proposal naming a tool that doesn't exist gets a
+		// specific explanation instead of the raw JSON.
+		var unknownErr *validator.UnknownFunctionError
+		if errors.As(err, &unknownErr) {
+			return types.AgentEvent{
+				State:       types.StateResponding,
+				FinalAnswer: unknownErr.UserMessage(),
+				ChunksFound: len(chunks),
+				Sources:     chunks,
+				Debug:       debug,
+			}, nil
+		}
 
 		return types.AgentEvent{
 			State:       types.StateResponding,
 			FinalAnswer: response,
 			ChunksFound: len(chunks),
+			Sources:     chunks,
+			Debug:       debug,
 		}, nil
 	}
 
+	return a.runProposal(ctx, sanitizedQuery, llmResp, chunks, debug)
+}
+
+// runProposal executes the functions proposed for query, or only reports
+// them in plan-only mode, and records the query in the conversation.
+func (a *Agent) runProposal(ctx context.Context, sanitizedQuery string, llmResp *types.LLMResponse, chunks []types.RetrievedChunk, debug *types.LLMDebug) (types.AgentEvent, error) {
 	// If no functions to execute, return explanation directly.
 	if len(llmResp.Functions) == 0 {
 		return types.AgentEvent{
 			State:       types.StateResponding,
 			FinalAnswer: llmResp.Explanation,
 			ChunksFound: len(chunks),
+			Sources:     chunks,
+			Debug:       debug,
+		}, nil
+	}
+
+	// Read-only mode: refuse the whole proposal rather than run part of it.
+	if a.readOnly {
+		for _, fn := range llmResp.Functions {
+			if a.functionRegistry.Phase(fn.Name) == types.PhaseModify {
+				return types.AgentEvent{
+					State: types.StateError,
+					Error: fmt.Errorf("refusing to run modify function '%s' in read-only mode", fn.Name),
+					Debug: debug,
+				}, nil
+			}
+		}
+	}
+
+	// Plan-only mode: report the proposal and stop. Unlike dry-run, nothing
+	// is executed, not even read-phase functions.
+	if a.PlanOnly() {
+		a.ctxManager.AddMessage(types.Message{
+			Role:      "user",
+			Content:   sanitizedQuery,
+			Timestamp: time.Now(),
+		})
+		a.saveHistory()
+
+		return types.AgentEvent{
+			State:             types.StateResponding,
+			ToolCall:          &llmResp.Functions[0],
+			FinalAnswer:       a.buildPlanAnswer(llmResp),
+			ChunksFound:       len(chunks),
+			Sources:           chunks,
+			PlanOnly:          true,
+			ProposedFunctions: llmResp.Functions,
+			Debug:             debug,
 		}, nil
 	}
 
@@ -216,28 +536,21 @@ func (a *Agent) process(ctx context.Context, query string) (types.AgentEvent, er
 	txReq := executor.TransactionRequest{
 		Functions: llmResp.Functions,
 		Strategy:  executor.ExecutionStrategy(llmResp.ExecutionStrategy),
+		Confirmer: a.confirmer,
 	}
-	txResults, execErr := a.txExecutor.ExecuteTransaction(ctx, txReq)
+	ConfigureRequest(a.cfg, &txReq)
+	start := time.Now()
+	txResults, txSummary, execErr := a.txExecutor.ExecuteTransactionWithSummary(ctx, txReq)
+	types.ObserveStage(ctx, types.StageToolExecute, start)
 
-	// Flatten []executor.FunctionResult → []types.ExecutionResult.
-	// Index is set explicitly so the UI skip-dedup logic works correctly.
-	var results []types.ExecutionResult
-	for i, fr := range txResults {
-		outputStr := ""
-		if fr.Output != nil {
-			if b, jsonErr := json.Marshal(fr.Output); jsonErr == nil {
-				outputStr = string(b)
-			}
-		}
-		results = append(results, types.ExecutionResult{
-			Index:    i,
-			Function: types.FunctionCall{Name: fr.FunctionName},
-			Output:   outputStr,
-			Success:  fr.Success,
-			Error:    errorString(fr.Error),
-			Duration: fr.Duration,
-		})
-	}
+	results := DisplayResults(a.cfg, txResults)
+
+	// Keep the proposal as run, before variable resolution, so SavePlan can
+	// export it for replay.
+	plan := executor.NewPlan(sanitizedQuery, txReq.Strategy, llmResp.Functions)
+	a.stateMu.Lock()
+	a.lastPlan, a.lastResults = plan, results
+	a.stateMu.Unlock()
 
 	// Add sanitized query (not raw input) to conversation context.
 	a.ctxManager.AddMessage(types.Message{
@@ -246,6 +559,7 @@ func (a *Agent) process(ctx context.Context, query string) (types.AgentEvent, er
 		Timestamp: time.Now(),
 		Functions: results,
 	})
+	a.saveHistory()
 
 	finalAnswer := a.buildFinalAnswer(llmResp, results, execErr)
 
@@ -254,6 +568,9 @@ func (a *Agent) process(ctx context.Context, query string) (types.AgentEvent, er
 		AllResults:  results,
 		FinalAnswer: finalAnswer,
 		ChunksFound: len(chunks),
+		Sources:     chunks,
+		Transaction: txSummary,
+		Debug:       debug,
 	}
 
 	if len(llmResp.Functions) > 0 {
@@ -266,6 +583,36 @@ func (a *Agent) process(ctx context.Context, query string) (types.AgentEvent, er
 	return event, nil
 }
 
+// promptFunctions returns the function definitions advertised to the LLM:
+// the whole registry, less modify-phase functions in safe mode and
+// deprecated functions unless executor.include_deprecated is set.
+func (a *Agent) promptFunctions() []types.FunctionDefinition {
+	funcDefs := make([]types.FunctionDefinition, 0, len(a.functionRegistry.Functions))
+	for _, fn := range a.functionRegistry.Functions {
+		if a.cfg.Executor.SafeMode && a.functionRegistry.Phase(fn.Name) == types.PhaseModify {
+			continue
+		}
+		if !a.cfg.Executor.IncludeDeprecated && a.functionRegistry.Stability(fn.Name) == types.StabilityDeprecated {
+			continue
+		}
+		funcDefs = append(funcDefs, fn)
+	}
+	return funcDefs
+}
+
+// defaultMaxOutputChars bounds function output in answers when no
+// configuration is loaded.
+const defaultMaxOutputChars = 500
+
+// maxOutputChars is how much of each function's output buildFinalAnswer
+// shows.
+func (a *Agent) maxOutputChars() int {
+	if a.cfg == nil || a.cfg.UI.MaxOutputChars <= 0 {
+		return defaultMaxOutputChars
+	}
+	return a.cfg.UI.MaxOutputChars
+}
+
 // buildFinalAnswer constructs a human-readable summary of the execution results.
 func (a *Agent) buildFinalAnswer(llmResp *types.LLMResponse, results []types.ExecutionResult, execErr error) string {
 	var sb strings.Builder
@@ -290,10 +637,7 @@ func (a *Agent) buildFinalAnswer(llmResp *types.LLMResponse, results []types.Exe
 			sb.WriteString("\n")
 
 			if result.Success && result.Output != "" {
-				output := result.Output
-				if len(output) > 500 {
-					output = output[:500] + "..."
-				}
+				output := truncate.Output(result.Output, a.maxOutputChars())
 				sb.WriteString(fmt.Sprintf("   %s\n", output))
 			} else if !result.Success {
 				sb.WriteString(fmt.Sprintf("   Error: %s\n", result.Error))
@@ -314,43 +658,127 @@ func (a *Agent) buildFinalAnswer(llmResp *types.LLMResponse, results []types.Exe
 	return sb.String()
 }
 
+// buildPlanAnswer describes the functions the LLM proposed without running them.
+func (a *Agent) buildPlanAnswer(llmResp *types.LLMResponse) string {
+	var sb strings.Builder
+
+	if llmResp.Reasoning != "" {
+		sb.WriteString("**Reasoning:**\n")
+		sb.WriteString(llmResp.Reasoning)
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString("**Proposed Functions (not executed):**\n")
+	for i, fn := range llmResp.Functions {
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, fn.Name))
+		if a.functionRegistry != nil {
+			sb.WriteString(fmt.Sprintf(" [%s]", a.functionRegistry.Phase(fn.Name)))
+		}
+		sb.WriteString("\n")
+		if len(fn.Params) > 0 {
+			if b, err := json.Marshal(fn.Params); err == nil {
+				sb.WriteString(fmt.Sprintf("   %s\n", b))
+			}
+		}
+	}
+	if llmResp.ExecutionStrategy != "" {
+		sb.WriteString(fmt.Sprintf("Strategy: %s\n", llmResp.ExecutionStrategy))
+	}
+	sb.WriteString("\n")
+
+	if llmResp.Explanation != "" {
+		sb.WriteString("**Explanation:**\n")
+		sb.WriteString(llmResp.Explanation)
+	}
+
+	return sb.String()
+}
+
+// PlanOnly reports whether plan-only mode is enabled.
+func (a *Agent) PlanOnly() bool {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	return a.planOnly
+}
+
+// SetPlanOnly enables or disables plan-only mode for subsequent queries.
+func (a *Agent) SetPlanOnly(enabled bool) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.planOnly = enabled
+}
+
+// SetConfirmer sets how modify operations are approved. Nil restores the
+// default terminal prompt.
+func (a *Agent) SetConfirmer(c executor.Confirmer) {
+	a.confirmer = c
+}
+
+// SavePlan writes the functions from the most recent executed query to path,
+// for replay with "friday replay" without calling the LLM.
+func (a *Agent) SavePlan(path string) error {
+	a.stateMu.Lock()
+	plan := a.lastPlan
+	a.stateMu.Unlock()
+	if plan == nil {
+		return errors.New("no executed plan to save yet")
+	}
+	return executor.SavePlan(path, plan)
+}
+
+// SetProgressHandler registers fn to receive status updates from
+// long-running functions as StateToolExecuting events with ToolCall and
+// Progress set. fn may be called from another goroutine and must not block.
+// Nil stops the updates.
+func (a *Agent) SetProgressHandler(fn func(types.AgentEvent)) {
+	if fn == nil {
+		a.executor.SetProgress(nil)
+		return
+	}
+	a.executor.SetProgress(func(function, line string) {
+		fn(types.AgentEvent{
+			State:    types.StateToolExecuting,
+			ToolCall: &types.FunctionCall{Name: function},
+			Progress: line,
+		})
+	})
+}
+
 // Ping checks if the LLM is reachable.
 func (a *Agent) Ping(ctx context.Context) error {
-	_, err := a.llmClient.Generate(ctx, "Respond with OK")
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// Only reachability matters here, so keep the reply as short as possible.
+	_, err := a.llmClient.Generate(ctx, "Respond with OK", llm.GenerateOptions{MaxTokens: 5})
 	if err != nil {
 		return fmt.Errorf("LLM not reachable: %w", err)
 	}
 	return nil
 }
 
-// ListTools returns available tool information.
-func (a *Agent) ListTools() []types.ToolInfo {
-	funcNames := a.functionRegistry.List()
-	tools := make([]types.ToolInfo, 0, len(funcNames))
-
-	for _, name := range funcNames {
-		fn, exists := a.functionRegistry.Get(name)
-		if !exists {
-			continue
-		}
-		tools = append(tools, types.ToolInfo{
-			Name:        fn.Name,
-			Description: fn.Description,
-			Category:    fn.Category,
-			Parameters:  fn.Parameters,
-		})
-	}
-
-	return tools
+// ListTools returns metadata for the registered tools matching filter,
+// sorted by category and then name.
+func (a *Agent) ListTools(filter types.ToolFilter) []types.ToolInfo {
+	return a.functionRegistry.Tools(filter)
 }
 
-// ClearHistory clears the conversation history.
-func (a *Agent) ClearHistory() {
+// ClearHistory clears the conversation history and labeled results, and
+// deletes the persisted history file so they are not restored next run.
+func (a *Agent) ClearHistory() error {
 	a.ctxManager.Clear()
+	if a.session != nil {
+		a.session.Clear()
+	}
+	a.stateMu.Lock()
+	a.lastResults = nil
+	a.stateMu.Unlock()
+	return a.removeHistory()
 }
 
-// Close releases agent resources.
+// Close saves the history and releases agent resources.
 func (a *Agent) Close() error {
+	a.saveHistory()
 	if a.ragPipeline != nil {
 		return a.ragPipeline.Close()
 	}
@@ -359,21 +787,7 @@ func (a *Agent) Close() error {
 
 // LLMInfo returns information about the configured LLM.
 func (a *Agent) LLMInfo() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return fmt.Sprintf("%s @ %s", a.cfg.LLM.Model, a.cfg.LLM.Endpoint)
 }
-
-// ─── helpers ─────────────────────────────────────────────────────────────────
-
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen] + "..."
-}
-
-func errorString(err error) string {
-	if err == nil {
-		return ""
-	}
-	return err.Error()
-}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/bench"
+	"github.com/friday/internal/llm"
+	"github.com/friday/internal/types"
+)
+
+// slowRetriever reports an embedding and a search stage of the given
+// lengths, as rag.Retriever does, for each query.
+type slowRetriever struct {
+	fakeRetriever
+	embed, search time.Duration
+}
+
+func (r *slowRetriever) Retrieve(ctx context.Context, query string) ([]types.RetrievedChunk, error) {
+	start := time.Now()
+	time.Sleep(r.embed)
+	types.ObserveStage(ctx, types.StageRAGEmbed, start)
+	start = time.Now()
+	time.Sleep(r.search)
+	types.ObserveStage(ctx, types.StageRAGSearch, start)
+	return r.chunks, nil
+}
+
+func TestBench_AgentReportsStages(t *testing.T) {
+	const llmDelay = 30 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(llmDelay)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": tcpHealthProposal}},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	a := newPlanTestAgent(t)
+	a.llmClient = llm.NewClient(srv.URL, "test", 5*time.Second, 0, 256)
+	a.ragPipeline = &slowRetriever{embed: 10 * time.Millisecond, search: 2 * time.Millisecond}
+
+	report, err := bench.Run(context.Background(), a, bench.Options{
+		Queries:    []string{"is port 50051 healthy?", "why is grpc slow?"},
+		Iterations: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Queries != 4 || report.Errors != 0 {
+		t.Fatalf("expected 4 successful queries, got %+v", report)
+	}
+
+	// Plan-only runs nothing, so there is no execution stage.
+	want := []string{types.StageRAGEmbed, types.StageRAGSearch, types.StageLLMGenerate}
+	if len(report.Stages) != len(want) {
+		t.Fatalf("expected stages %v, got %+v", want, report.Stages)
+	}
+	for i, stage := range want {
+		if s := report.Stages[i]; s.Stage != stage || s.Count != 4 {
+			t.Errorf("expected 4 samples of %s, got %+v", stage, s)
+		}
+	}
+	if llmStats := report.Stages[2]; llmStats.P50Ms < 30 || llmStats.P50Ms > report.Total.P50Ms {
+		t.Errorf("expected llm_generate p50 of at least 30ms and within the total, got %+v / %+v", llmStats, report.Total)
+	}
+	if embed := report.Stages[0]; embed.P50Ms < 10 || embed.P50Ms > report.Stages[2].P50Ms {
+		t.Errorf("expected rag_embed to be slower than 10ms but faster than the LLM, got %+v", embed)
+	}
+	if len(a.ctxManager.GetMessages()) != 1 {
+		t.Errorf("expected the conversation cleared between queries, got %d messages", len(a.ctxManager.GetMessages()))
+	}
+}
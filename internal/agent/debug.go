@@ -0,0 +1,18 @@
+package agent
+
+import (
+	"github.com/friday/internal/types"
+	"github.com/friday/internal/validator"
+)
+
+// llmDebug returns the redacted exchange for an AgentEvent, or nil when LLM
+// debugging is off. The caller holds a.mu.
+func (a *Agent) llmDebug(prompt, response string) *types.LLMDebug {
+	if !a.debugLLM && !a.cfg.LLM.Debug {
+		return nil
+	}
+	return &types.LLMDebug{
+		Prompt:      validator.RedactSecrets(prompt),
+		RawResponse: validator.RedactSecrets(response),
+	}
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/friday/internal/types"
+)
+
+// followUpPhrases mark a query asking for interpretation rather than new data.
+var followUpPhrases = []string{
+	"what does", "what do", "what is", "what's", "what are",
+	"explain", "mean", "interpret", "is that", "is this", "are those",
+	"are these", "why is", "why are", "is it normal", "is it bad", "should i worry",
+}
+
+// followUpReferents point back at earlier output without naming a field.
+var followUpReferents = []string{"that", "this", "these", "those", "it", "result", "results", "output", "above"}
+
+// isFollowUp reports whether query asks about results already in history,
+// e.g. "what does retransmits:47 mean?" after check_tcp_health ran. It needs
+// an interpretive phrase plus either a pronoun-style referent or a word that
+// names a prior function or one of its output fields.
+func isFollowUp(query string, history []types.Message) bool {
+	known := priorResultTerms(history)
+	if len(known) == 0 {
+		return false
+	}
+
+	lower := strings.ToLower(query)
+	phrase := false
+	for _, p := range followUpPhrases {
+		if strings.Contains(lower, p) {
+			phrase = true
+			break
+		}
+	}
+	if !phrase {
+		return false
+	}
+
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, w := range words {
+		if known[w] {
+			return true
+		}
+		for _, r := range followUpReferents {
+			if w == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// priorResultTerms collects the names of functions that ran in history and the
+// top-level field names of their JSON outputs, lower-cased.
+func priorResultTerms(history []types.Message) map[string]bool {
+	terms := make(map[string]bool)
+	for _, msg := range history {
+		for _, fn := range msg.Functions {
+			terms[strings.ToLower(fn.Function.Name)] = true
+
+			var fields map[string]interface{}
+			if json.Unmarshal([]byte(fn.Output), &fields) != nil {
+				continue
+			}
+			for k := range fields {
+				terms[strings.ToLower(k)] = true
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/friday/internal/types"
+	"go.uber.org/zap"
+)
+
+// history is what is kept in conversation.history_file between runs.
+type history struct {
+	SavedAt  time.Time              `json:"saved_at"`
+	Messages []types.Message        `json:"messages"`
+	Labels   map[string]interface{} `json:"labels,omitempty"`
+}
+
+// loadHistory restores the conversation and labeled results persisted in
+// conversation.history_file, dropping messages older than
+// conversation.history_max_age_hours. A missing file is a first run, not
+// an error.
+func (a *Agent) loadHistory() error {
+	path := a.historyFile()
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read history '%s': %w", path, err)
+	}
+	var h history
+	if err := json.Unmarshal(data, &h); err != nil {
+		return fmt.Errorf("cannot parse history '%s': %w", path, err)
+	}
+
+	var cutoff time.Time
+	if hours := a.cfg.Conversation.HistoryMaxAgeHours; hours > 0 {
+		cutoff = time.Now().Add(-time.Duration(hours) * time.Hour)
+	}
+	// AddMessage keeps only the most recent conversation.max_messages.
+	for _, m := range h.Messages {
+		if m.Timestamp.Before(cutoff) {
+			continue
+		}
+		a.ctxManager.AddMessage(m)
+	}
+	if a.session != nil {
+		a.session.Restore(h.Labels)
+	}
+	return nil
+}
+
+// saveHistory writes the conversation and labeled results to
+// conversation.history_file, if set. It runs after every change rather
+// than only on exit, so nothing is lost if the process is killed. Failures
+// are logged, not returned: a query should not fail because its history
+// could not be saved.
+func (a *Agent) saveHistory() {
+	path := a.historyFile()
+	if path == "" {
+		return
+	}
+	h := history{SavedAt: time.Now(), Messages: a.ctxManager.GetMessages()}
+	if a.session != nil {
+		h.Labels = a.session.All()
+	}
+	if err := writeHistory(path, h); err != nil {
+		a.logger.Warn("Failed to save history", zap.String("path", path), zap.Error(err))
+	}
+}
+
+// writeHistory replaces path with h through a temporary file, so a crash
+// mid-write leaves the previous history intact.
+func writeHistory(path string, h history) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// historyFile returns the configured history file, or "" if history is
+// not persisted.
+func (a *Agent) historyFile() string {
+	if a.cfg == nil {
+		return ""
+	}
+	return a.cfg.Conversation.HistoryFile
+}
+
+// removeHistory deletes the persisted history, if any.
+func (a *Agent) removeHistory() error {
+	path := a.historyFile()
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot remove history '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/executor"
+	"github.com/friday/internal/types"
+)
+
+// newHistoryTestAgent is a plan-only test agent with a session that keeps
+// its history in path.
+func newHistoryTestAgent(t *testing.T, path string) *Agent {
+	t.Helper()
+	a := newPlanTestAgent(t)
+	a.cfg.Conversation.HistoryFile = path
+	a.session = executor.NewSessionStore()
+	return a
+}
+
+func TestHistory_WrittenAndRestored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	a := newHistoryTestAgent(t, path)
+	if _, err := a.ProcessQuery(context.Background(), "is port 50051 healthy?"); err != nil {
+		t.Fatalf("ProcessQuery returned error: %v", err)
+	}
+	a.session.Restore(map[string]interface{}{"baseline": map[string]interface{}{"retransmits": 47.0}})
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected history written on close: %v", err)
+	}
+
+	b := newHistoryTestAgent(t, path)
+	if err := b.loadHistory(); err != nil {
+		t.Fatalf("loadHistory returned error: %v", err)
+	}
+	messages := b.ctxManager.GetMessages()
+	if len(messages) != 1 || messages[0].Content != "is port 50051 healthy?" {
+		t.Errorf("Expected the earlier query restored, got %+v", messages)
+	}
+	v, ok := b.session.Get("baseline")
+	if !ok || v.(map[string]interface{})["retransmits"] != 47.0 {
+		t.Errorf("Expected the baseline label restored, got %v", v)
+	}
+}
+
+func TestHistory_SavedAfterEachQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	a := newHistoryTestAgent(t, path)
+
+	if _, err := a.ProcessQuery(context.Background(), "is port 50051 healthy?"); err != nil {
+		t.Fatalf("ProcessQuery returned error: %v", err)
+	}
+
+	// Saved without Close, so an "exit" that skips it loses nothing.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected history written after the query: %v", err)
+	}
+	var h history
+	if err := json.Unmarshal(data, &h); err != nil || len(h.Messages) != 1 {
+		t.Errorf("Expected one persisted message, got %s (%v)", data, err)
+	}
+}
+
+func TestHistory_PrunedOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now()
+	old := types.Message{Role: "user", Content: "stale", Timestamp: now.Add(-48 * time.Hour)}
+	var recent []types.Message
+	for _, c := range []string{"one", "two", "three", "four"} {
+		recent = append(recent, types.Message{Role: "user", Content: c, Timestamp: now})
+	}
+	if err := writeHistory(path, history{Messages: append([]types.Message{old}, recent...)}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newHistoryTestAgent(t, path)
+	if err := a.loadHistory(); err != nil {
+		t.Fatalf("loadHistory returned error: %v", err)
+	}
+
+	// Older than 24 hours is dropped, then only the last 3 are kept.
+	messages := a.ctxManager.GetMessages()
+	if len(messages) != 3 || messages[0].Content != "two" || messages[2].Content != "four" {
+		t.Errorf("Expected the 3 most recent messages, got %+v", messages)
+	}
+}
+
+func TestHistory_MissingFileStartsEmpty(t *testing.T) {
+	a := newHistoryTestAgent(t, filepath.Join(t.TempDir(), "none", "history.json"))
+	if err := a.loadHistory(); err != nil {
+		t.Fatalf("Expected no error for a first run, got %v", err)
+	}
+	if len(a.ctxManager.GetMessages()) != 0 {
+		t.Error("Expected an empty conversation")
+	}
+}
+
+func TestHistory_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := newHistoryTestAgent(t, path).loadHistory(); err == nil {
+		t.Error("Expected an error for an unparseable history")
+	}
+}
+
+func TestClearHistory_RemovesPersistedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	a := newHistoryTestAgent(t, path)
+	if _, err := a.ProcessQuery(context.Background(), "is port 50051 healthy?"); err != nil {
+		t.Fatalf("ProcessQuery returned error: %v", err)
+	}
+	a.session.Restore(map[string]interface{}{"baseline": 1.0})
+
+	if err := a.ClearHistory(); err != nil {
+		t.Fatalf("ClearHistory returned error: %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the history file removed, got %v", err)
+	}
+	if len(a.ctxManager.GetMessages()) != 0 || len(a.session.Labels()) != 0 {
+		t.Error("Expected the conversation and labels cleared")
+	}
+
+	b := newHistoryTestAgent(t, path)
+	if err := b.loadHistory(); err != nil || len(b.ctxManager.GetMessages()) != 0 {
+		t.Errorf("Expected nothing restored after clear, got %v", err)
+	}
+}
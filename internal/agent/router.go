@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/friday/internal/config"
+	"github.com/friday/internal/types"
+)
+
+// routeQuery returns the call a fast_path route makes for query, as a
+// proposal the LLM could have made, or nil if the query should go to the
+// LLM: fast_path is off, no route or more than one route matches, or the
+// matched route names a function that is not offered or whose params do
+// not fit its schema. Only read and analyze functions are routed, so a
+// change is never made without the LLM's reasoning.
+func (a *Agent) routeQuery(query string) *types.LLMResponse {
+	if !a.cfg.FastPath.Enabled {
+		return nil
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), ".!?")
+
+	var (
+		matched *config.FastPathRoute
+		call    types.FunctionCall
+	)
+	for i, route := range a.cfg.FastPath.Routes {
+		fn, ok := a.matchRoute(route, query)
+		if !ok {
+			continue
+		}
+		if matched != nil {
+			return nil // ambiguous
+		}
+		matched, call = &a.cfg.FastPath.Routes[i], fn
+	}
+	if matched == nil {
+		return nil
+	}
+	return &types.LLMResponse{
+		Functions:   []types.FunctionCall{call},
+		Explanation: fmt.Sprintf("Ran %s directly: the query matched the fast_path pattern %q, so the LLM was not consulted.", call.Name, matched.Pattern),
+	}
+}
+
+// matchRoute builds route's call if it matches the whole of query.
+func (a *Agent) matchRoute(route config.FastPathRoute, query string) (types.FunctionCall, bool) {
+	re, err := regexp.Compile(`(?i)^(?:` + route.Pattern + `)$`)
+	if err != nil {
+		return types.FunctionCall{}, false
+	}
+	m := re.FindStringSubmatchIndex(query)
+	if m == nil {
+		return types.FunctionCall{}, false
+	}
+	def, ok := a.functionRegistry.Get(route.Function)
+	if !ok || a.functionRegistry.Phase(def.Name) == types.PhaseModify || !containsFunction(a.promptFunctions(), def.Name) {
+		return types.FunctionCall{}, false
+	}
+
+	params := make(map[string]interface{}, len(route.Params))
+	for name, template := range route.Params {
+		value := string(re.ExpandString(nil, template, query, m))
+		typed, err := routeParam(def, name, value)
+		if err != nil {
+			return types.FunctionCall{}, false
+		}
+		params[name] = typed
+	}
+	return types.FunctionCall{Name: def.Name, Params: params}, true
+}
+
+// routeParam converts value to the type def declares for the param name.
+func routeParam(def types.FunctionDefinition, name, value string) (interface{}, error) {
+	for _, p := range def.Parameters {
+		if p.Name != name {
+			continue
+		}
+		switch p.Type {
+		case "integer":
+			return strconv.Atoi(value)
+		case "float", "number":
+			return strconv.ParseFloat(value, 64)
+		case "boolean":
+			return strconv.ParseBool(value)
+		case "string":
+			return value, nil
+		}
+		return nil, fmt.Errorf("param %s of type %s cannot be routed", name, p.Type)
+	}
+	return nil, fmt.Errorf("%s has no param %s", def.Name, name)
+}
+
+func containsFunction(defs []types.FunctionDefinition, name string) bool {
+	for _, fn := range defs {
+		if fn.Name == name {
+			return true
+		}
+	}
+	return false
+}
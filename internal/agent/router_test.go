@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/friday/internal/config"
+	"github.com/friday/internal/types"
+)
+
+// newRouterTestAgent is a plan-only agent with the default fast_path
+// routes enabled. Its LLM always proposes check_tcp_health, so a routed
+// query is told apart by the function it proposes.
+func newRouterTestAgent(t *testing.T) *Agent {
+	t.Helper()
+	a := newPlanTestAgent(t)
+	a.cfg.FastPath.Enabled = true
+	return a
+}
+
+func proposed(t *testing.T, a *Agent, query string) []types.FunctionCall {
+	t.Helper()
+	event, err := a.ProcessQuery(context.Background(), query)
+	if err != nil || event.Error != nil {
+		t.Fatalf("ProcessQuery(%q) failed: %v %v", query, err, event.Error)
+	}
+	return event.ProposedFunctions
+}
+
+func TestRouteQuery_PingSkipsLLM(t *testing.T) {
+	a := newRouterTestAgent(t)
+
+	fns := proposed(t, a, "ping 1.1.1.1")
+	if len(fns) != 1 || fns[0].Name != "ping" || fns[0].Params["host"] != "1.1.1.1" || len(fns[0].Params) != 1 {
+		t.Fatalf("expected a direct ping of 1.1.1.1, got %+v", fns)
+	}
+
+	fns = proposed(t, a, "ping [2001:db8::1]")
+	if len(fns) != 1 || fns[0].Name != "ping" || fns[0].Params["host"] != "[2001:db8::1]" {
+		t.Errorf("expected a direct ping of a bracketed IPv6 address, got %+v", fns)
+	}
+
+	fns = proposed(t, a, "Check port 8080 on host db.internal?")
+	if len(fns) != 1 || fns[0].Name != "port_scan" || fns[0].Params["host"] != "db.internal" || fns[0].Params["ports"] != "8080" {
+		t.Errorf("expected a direct port_scan, got %+v", fns)
+	}
+}
+
+func TestRouteQuery_FallsThroughToLLM(t *testing.T) {
+	a := newRouterTestAgent(t)
+
+	for _, query := range []string{
+		"ping 1.1.1.1 and then check port 443 on it",
+		"why is my grpc service slow?",
+		// A leading "-" is a flag, not a host.
+		"ping -flood",
+		"check port 22 on -oProxy",
+	} {
+		if fns := proposed(t, a, query); len(fns) != 1 || fns[0].Name != "check_tcp_health" {
+			t.Errorf("%q: expected the LLM's proposal, got %+v", query, fns)
+		}
+	}
+
+	// Two routes matching the same query is ambiguous.
+	a.cfg.FastPath.Routes = append(a.cfg.FastPath.Routes, config.FastPathRoute{
+		Pattern: `ping\s+(?P<target>\S+)`, Function: "tcp_connect_timing",
+		Params: map[string]string{"host": "${target}", "port": "443"},
+	})
+	if fns := proposed(t, a, "ping 1.1.1.1"); fns[0].Name != "check_tcp_health" {
+		t.Errorf("expected an ambiguous query to go to the LLM, got %+v", fns)
+	}
+}
+
+func TestRouteQuery_Disabled(t *testing.T) {
+	a := newPlanTestAgent(t)
+	if fns := proposed(t, a, "ping 1.1.1.1"); fns[0].Name != "check_tcp_health" {
+		t.Errorf("expected the LLM's proposal with fast_path off, got %+v", fns)
+	}
+}
+
+func TestRouteQuery_TypesParamsAndRefusesModify(t *testing.T) {
+	a := newRouterTestAgent(t)
+	a.cfg.FastPath.Routes = []config.FastPathRoute{
+		{Pattern: `time\s+(?P<host>\S+):(?P<port>\d+)`, Function: "tcp_connect_timing",
+			Params: map[string]string{"host": "${host}", "port": "${port}"}},
+		{Pattern: `set\s+(?P<p>\S+)\s+to\s+(?P<v>\S+)`, Function: "execute_sysctl_command",
+			Params: map[string]string{"parameter": "${p}", "value": "${v}"}},
+	}
+
+	resp := a.routeQuery("time db.internal:5432")
+	if resp == nil || resp.Functions[0].Params["port"] != 5432 {
+		t.Fatalf("expected port typed as an integer, got %+v", resp)
+	}
+	if resp := a.routeQuery("set net.core.somaxconn to 4096"); resp != nil {
+		t.Errorf("expected a modify function never to be routed, got %+v", resp)
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/friday/internal/types"
+)
+
+// saveRequestPattern matches a request to label the previous query's
+// results, e.g. "save this as baseline" or "label the results as before-fix".
+var saveRequestPattern = regexp.MustCompile(
+	`(?i)^(?:please\s+)?(?:save|label|tag|store|keep)\s+(?:this|that|it|these|those|the\s+(?:last\s+)?results?)\s+as\s+["']?([A-Za-z_][A-Za-z0-9_-]*)["']?\s*[.!]?$`)
+
+// saveRequestLabel returns the label a "save this as LABEL" query asks for,
+// or "" if query is not one.
+func saveRequestLabel(query string) string {
+	m := saveRequestPattern.FindStringSubmatch(query)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// LabelResults saves the results of the most recent executed query under
+// label, so later queries can compare against them and pass their fields
+// to functions as ${session.LABEL.field}. Saving again under the same label
+// replaces the earlier result.
+func (a *Agent) LabelResults(label string) error {
+	if a.session == nil {
+		return errors.New("no session to save results in")
+	}
+	results := a.recentResults()
+	if len(results) == 0 {
+		return errors.New("no executed query results to save yet")
+	}
+	if err := a.session.Label(label, results); err != nil {
+		return err
+	}
+	a.saveHistory()
+	return nil
+}
+
+// recentResults returns the results of the most recent executed query.
+func (a *Agent) recentResults() []types.ExecutionResult {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	return a.lastResults
+}
+
+// labelResultsEvent answers a "save this as LABEL" query without calling
+// the LLM.
+func (a *Agent) labelResultsEvent(label string) types.AgentEvent {
+	if err := a.LabelResults(label); err != nil {
+		return types.AgentEvent{
+			State: types.StateError,
+			Error: fmt.Errorf("could not save results: %w", err),
+		}
+	}
+
+	var names []string
+	for _, r := range a.recentResults() {
+		if r.Success {
+			names = append(names, r.Function.Name)
+		}
+	}
+	return types.AgentEvent{
+		State: types.StateResponding,
+		FinalAnswer: fmt.Sprintf("Saved the results of %s as '%s'. Refer to it in a later query, "+
+			"or in a function parameter as ${session.%s.<field>}.", strings.Join(names, ", "), label, label),
+	}
+}
+
+// savedResults returns each labeled result as JSON for the prompt.
+func (a *Agent) savedResults() map[string]string {
+	if a.session == nil {
+		return nil
+	}
+	saved := make(map[string]string)
+	for _, label := range a.session.Labels() {
+		v, _ := a.session.Get(label)
+		out, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		saved[label] = string(out)
+	}
+	return saved
+}
@@ -0,0 +1,194 @@
+// Package bench measures where the time goes in answering a query: the
+// embedding and vector search behind retrieval, the LLM call, and the
+// functions run, over repeated runs of a fixed set of queries.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/friday/internal/percentile"
+	"github.com/friday/internal/types"
+)
+
+// DefaultQueries are read-only questions covering the common function
+// categories, used when no queries are given.
+var DefaultQueries = []string{
+	"Check TCP health on port 50051",
+	"Resolve the DNS records for localhost",
+	"Show listening sockets on this host",
+	"Check gRPC health on localhost:50051",
+}
+
+// stageOrder is the order stages are reported in; stages not listed here
+// follow in name order.
+var stageOrder = []string{
+	types.StageRAGEmbed,
+	types.StageRAGSearch,
+	types.StageLLMGenerate,
+	types.StageToolExecute,
+}
+
+// StageTotal names the end-to-end latency of each query in a Report.
+const StageTotal = "total"
+
+// Processor answers a query. *agent.Agent satisfies this.
+type Processor interface {
+	ProcessQuery(ctx context.Context, query string) (*types.AgentEvent, error)
+}
+
+// historyClearer is implemented by processors that keep a conversation;
+// Run clears it before each query so every query sends the same prompt.
+type historyClearer interface {
+	ClearHistory() error
+}
+
+// Options controls a benchmark run.
+type Options struct {
+	// Queries are run in order, Iterations times over; nil runs
+	// DefaultQueries.
+	Queries []string
+	// Iterations is how many times the queries are run; 0 is once.
+	Iterations int
+}
+
+// StageStats summarizes the latencies recorded for one stage, in
+// milliseconds.
+type StageStats struct {
+	Stage  string  `json:"stage"`
+	Count  int     `json:"count"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MeanMs float64 `json:"mean_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// Report is the result of Run.
+type Report struct {
+	Queries    int `json:"queries"`
+	Iterations int `json:"iterations"`
+	// Errors counts queries that failed or answered with an error.
+	Errors int `json:"errors"`
+	// Stages holds one entry per stage seen, in pipeline order.
+	Stages []StageStats `json:"stages"`
+	// Total is the end-to-end latency of each query.
+	Total     StageStats `json:"total"`
+	ElapsedMs float64    `json:"elapsed_ms"`
+	// Throughput is queries answered per second over the whole run.
+	Throughput float64 `json:"throughput_qps"`
+}
+
+// Run sends each query to p, Iterations times over, one at a time, and
+// reports the latency percentiles of each stage the queries went through.
+// A stage a query skipped, such as retrieval when no vector store is
+// configured, is absent from the report rather than counted as zero.
+func Run(ctx context.Context, p Processor, opts Options) (*Report, error) {
+	queries := opts.Queries
+	if queries == nil {
+		queries = DefaultQueries
+	}
+	if len(queries) == 0 {
+		return nil, errors.New("no queries to run")
+	}
+	iterations := opts.Iterations
+	if iterations <= 0 {
+		iterations = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		samples = make(map[string][]time.Duration)
+	)
+	ctx = types.WithStageObserver(ctx, func(stage string, elapsed time.Duration) {
+		mu.Lock()
+		samples[stage] = append(samples[stage], elapsed)
+		mu.Unlock()
+	})
+
+	report := &Report{Iterations: iterations}
+	var totals []time.Duration
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		for _, query := range queries {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("benchmark stopped: %w", err)
+			}
+			if c, ok := p.(historyClearer); ok {
+				if err := c.ClearHistory(); err != nil {
+					return nil, fmt.Errorf("cannot clear history between queries: %w", err)
+				}
+			}
+			queryStart := time.Now()
+			event, err := p.ProcessQuery(ctx, query)
+			totals = append(totals, time.Since(queryStart))
+			report.Queries++
+			if err != nil || (event != nil && event.Error != nil) {
+				report.Errors++
+			}
+		}
+	}
+	elapsed := time.Since(start)
+
+	report.ElapsedMs = ms(elapsed)
+	if elapsed > 0 {
+		report.Throughput = float64(report.Queries) / elapsed.Seconds()
+	}
+	report.Total = Summarize(StageTotal, totals)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, stage := range orderedStages(samples) {
+		report.Stages = append(report.Stages, Summarize(stage, samples[stage]))
+	}
+	return report, nil
+}
+
+// Summarize computes the count, mean, maximum and nearest-rank
+// percentiles of samples.
+func Summarize(stage string, samples []time.Duration) StageStats {
+	stats := StageStats{Stage: stage, Count: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	stats.P50Ms = ms(percentile.NearestRank(sorted, 50))
+	stats.P90Ms = ms(percentile.NearestRank(sorted, 90))
+	stats.P99Ms = ms(percentile.NearestRank(sorted, 99))
+	stats.MeanMs = ms(sum / time.Duration(len(sorted)))
+	stats.MaxMs = ms(sorted[len(sorted)-1])
+	return stats
+}
+
+// orderedStages lists the stages in samples in pipeline order.
+func orderedStages(samples map[string][]time.Duration) []string {
+	var stages, others []string
+	known := make(map[string]bool, len(stageOrder))
+	for _, stage := range stageOrder {
+		known[stage] = true
+		if len(samples[stage]) > 0 {
+			stages = append(stages, stage)
+		}
+	}
+	for stage := range samples {
+		if !known[stage] {
+			others = append(others, stage)
+		}
+	}
+	sort.Strings(others)
+	return append(stages, others...)
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,115 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/types"
+)
+
+// scriptedProcessor reports the stage latencies in delays for each query,
+// as if each stage had just taken that long, and fails the queries in
+// fail.
+type scriptedProcessor struct {
+	delays  map[string][]time.Duration
+	fail    map[string]bool
+	calls   int
+	clears  int
+	queries []string
+}
+
+func (p *scriptedProcessor) ProcessQuery(ctx context.Context, query string) (*types.AgentEvent, error) {
+	for _, stage := range []string{types.StageRAGEmbed, types.StageLLMGenerate} {
+		durations := p.delays[stage]
+		if len(durations) == 0 {
+			continue
+		}
+		d := durations[p.calls%len(durations)]
+		types.ObserveStage(ctx, stage, time.Now().Add(-d))
+	}
+	p.calls++
+	p.queries = append(p.queries, query)
+	if p.fail[query] {
+		return &types.AgentEvent{State: types.StateError, Error: errors.New("llm down")}, nil
+	}
+	return &types.AgentEvent{State: types.StateResponding}, nil
+}
+
+func (p *scriptedProcessor) ClearHistory() error {
+	p.clears++
+	return nil
+}
+
+func msDurations(values ...int) []time.Duration {
+	var out []time.Duration
+	for _, v := range values {
+		out = append(out, time.Duration(v)*time.Millisecond)
+	}
+	return out
+}
+
+// near reports whether got is within a millisecond of want: ObserveStage
+// adds the few microseconds between computing start and observing it.
+func near(got, want float64) bool {
+	return got >= want && got < want+1
+}
+
+func TestRun_RecordsPerStageTimings(t *testing.T) {
+	p := &scriptedProcessor{
+		delays: map[string][]time.Duration{
+			types.StageRAGEmbed:    msDurations(5),
+			types.StageLLMGenerate: msDurations(100, 200, 300, 400, 500, 600, 700, 800, 900, 1000),
+		},
+		fail: map[string]bool{"b": true},
+	}
+
+	report, err := Run(context.Background(), p, Options{Queries: []string{"a", "b"}, Iterations: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Queries != 10 || report.Errors != 5 || report.Iterations != 5 {
+		t.Errorf("expected 10 queries, 5 failed, over 5 iterations, got %+v", report)
+	}
+	if p.clears != 10 {
+		t.Errorf("expected the history cleared before each query, got %d clears", p.clears)
+	}
+	if len(report.Stages) != 2 || report.Stages[0].Stage != types.StageRAGEmbed || report.Stages[1].Stage != types.StageLLMGenerate {
+		t.Fatalf("expected rag_embed then llm_generate, got %+v", report.Stages)
+	}
+
+	llm := report.Stages[1]
+	if llm.Count != 10 || !near(llm.P50Ms, 500) || !near(llm.P90Ms, 900) || !near(llm.P99Ms, 1000) ||
+		!near(llm.MeanMs, 550) || !near(llm.MaxMs, 1000) {
+		t.Errorf("unexpected llm_generate stats %+v", llm)
+	}
+	if embed := report.Stages[0]; embed.Count != 10 || !near(embed.P99Ms, 5) {
+		t.Errorf("unexpected rag_embed stats %+v", embed)
+	}
+	if report.Total.Stage != StageTotal || report.Total.Count != 10 || report.Throughput <= 0 {
+		t.Errorf("unexpected totals %+v, %v q/s", report.Total, report.Throughput)
+	}
+}
+
+func TestRun_DefaultsAndCancellation(t *testing.T) {
+	p := &scriptedProcessor{}
+	report, err := Run(context.Background(), p, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Queries != len(DefaultQueries) || len(report.Stages) != 0 {
+		t.Errorf("expected one pass of the default queries and no stages, got %+v", report)
+	}
+
+	if _, err := Run(context.Background(), p, Options{Queries: []string{}}); err == nil {
+		t.Error("expected an error for an empty query list")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Run(ctx, p, Options{Iterations: 3}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled run to stop, got %v", err)
+	}
+}
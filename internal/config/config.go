@@ -2,10 +2,20 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/friday/internal/types"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +30,10 @@ type Config struct {
 	Conversation ConversationConfig `mapstructure:"conversation" yaml:"conversation"`
 	UI           UIConfig           `mapstructure:"ui" yaml:"ui"`
 	Logging      LoggingConfig      `mapstructure:"logging" yaml:"logging"`
+	FastPath     FastPathConfig     `mapstructure:"fast_path" yaml:"fast_path"`
+	// Dependencies are the services this host relies on, checked together
+	// by "friday deps check".
+	Dependencies []DependencyConfig `mapstructure:"dependencies" yaml:"dependencies,omitempty"`
 }
 
 // QdrantConfig holds vector database settings.
@@ -34,6 +48,33 @@ type RAGConfig struct {
 	TopK             int     `mapstructure:"top_k" yaml:"top_k"`
 	MinSimilarity    float32 `mapstructure:"min_similarity" yaml:"min_similarity"`
 	MaxContextLength int     `mapstructure:"max_context_length" yaml:"max_context_length"`
+	// KnowledgeDir, when set, is a folder of markdown runbooks indexed into
+	// the Qdrant collection at startup whenever its contents change.
+	KnowledgeDir string `mapstructure:"knowledge_dir" yaml:"knowledge_dir,omitempty"`
+	// EmbedRetries is how many times a failed query embedding is retried, and
+	// EmbedTimeoutSeconds bounds each attempt (0 means no per-attempt limit).
+	EmbedRetries        int `mapstructure:"embed_retries" yaml:"embed_retries"`
+	EmbedTimeoutSeconds int `mapstructure:"embed_timeout_seconds" yaml:"embed_timeout_seconds"`
+	// Ranking blends the vector score with document metadata before the
+	// top chunks are used.
+	Ranking RankingConfig `mapstructure:"ranking" yaml:"ranking"`
+}
+
+// RankingConfig weighs the signals a retrieved chunk is ranked by. Each
+// chunk's score is the weighted mean of the signals it has, so a chunk
+// with no ingest time or a source without a priority is ranked on the
+// remaining signals alone, and with only vector_weight set the ranking is
+// pure vector similarity.
+type RankingConfig struct {
+	VectorWeight   float64 `mapstructure:"vector_weight" yaml:"vector_weight"`
+	RecencyWeight  float64 `mapstructure:"recency_weight" yaml:"recency_weight"`
+	PriorityWeight float64 `mapstructure:"priority_weight" yaml:"priority_weight"`
+	// RecencyHalfLifeDays is the age at which a chunk's recency signal,
+	// 1 when just ingested, halves.
+	RecencyHalfLifeDays float64 `mapstructure:"recency_half_life_days" yaml:"recency_half_life_days"`
+	// SourcePriority maps a source, or a prefix of one such as
+	// "runbooks/", to a priority from 0 to 1; the longest match wins.
+	SourcePriority map[string]float64 `mapstructure:"source_priority" yaml:"source_priority,omitempty"`
 }
 
 // ONNXConfig holds ONNX embedding model settings.
@@ -51,25 +92,87 @@ type LLMConfig struct {
 	Temperature    float32 `mapstructure:"temperature" yaml:"temperature"`
 	MaxTokens      int     `mapstructure:"max_tokens" yaml:"max_tokens"`
 	TimeoutSeconds int     `mapstructure:"timeout_seconds" yaml:"timeout_seconds"`
+	// Debug attaches the prompt and raw reply to every answer, for prompt
+	// development. Same as the --debug-llm flag.
+	Debug bool `mapstructure:"debug" yaml:"debug"`
 }
 
 // ExecutorConfig holds function execution settings.
 type ExecutorConfig struct {
-	DefaultStrategy     string `mapstructure:"default_strategy" yaml:"default_strategy"`
-	MaxRetries          int    `mapstructure:"max_retries" yaml:"max_retries"`
-	RetryBackoffSeconds int    `mapstructure:"retry_backoff_seconds" yaml:"retry_backoff_seconds"`
+	DefaultStrategy string `mapstructure:"default_strategy" yaml:"default_strategy"`
+	// MaxRetries is how often a read or analyze function that fails with
+	// a transient error is retried, waiting RetryBackoffSeconds before the
+	// first retry and doubling after. Modify functions are never retried.
+	MaxRetries          int `mapstructure:"max_retries" yaml:"max_retries"`
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds" yaml:"retry_backoff_seconds"`
+	// UserAgent is sent by HTTP functions alongside a per-request
+	// X-Request-ID, so probes can be picked out of server logs.
+	UserAgent string `mapstructure:"user_agent" yaml:"user_agent"`
+	// SafeMode refuses every modify-phase function, takes no snapshots, and
+	// hides modify functions from the LLM so it does not propose them.
+	SafeMode bool `mapstructure:"safe_mode" yaml:"safe_mode"`
+	// IncludeDeprecated advertises deprecated functions to the LLM. They
+	// run either way, with a warning, so existing plans keep working.
+	IncludeDeprecated bool `mapstructure:"include_deprecated" yaml:"include_deprecated"`
+	// CircuitBreaker stops calling a function against a target that keeps
+	// failing until a cooldown has passed.
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker" yaml:"circuit_breaker"`
+	// MaxTransactionSeconds bounds each query's transaction; at the
+	// deadline completed modify operations are rolled back and the partial
+	// results returned. 0 means no limit.
+	MaxTransactionSeconds int `mapstructure:"max_transaction_seconds" yaml:"max_transaction_seconds"`
+	// MaxConcurrentPerTarget caps the calls running at once against the
+	// same host; further calls wait for one to finish. 0 means no limit.
+	MaxConcurrentPerTarget int `mapstructure:"max_concurrent_per_target" yaml:"max_concurrent_per_target"`
+	// TargetAllowlist limits the hosts network functions may probe to
+	// these CIDR ranges, addresses and hostname patterns ("*.internal").
+	// Empty allows every host.
+	TargetAllowlist []string `mapstructure:"target_allowlist" yaml:"target_allowlist"`
+	// Redaction masks credentials in function output before it is passed
+	// to later steps or shown to the LLM.
+	Redaction RedactionConfig `mapstructure:"redaction" yaml:"redaction"`
+}
+
+// RedactionConfig holds function output redaction settings.
+type RedactionConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Patterns are regular expressions matched in addition to the built-in
+	// secret patterns. A pattern's first capture group, if any, is kept.
+	Patterns []string `mapstructure:"patterns" yaml:"patterns"`
+	// ShowUnredacted displays the original output locally, with a warning;
+	// the LLM only ever sees the redacted copy.
+	ShowUnredacted bool `mapstructure:"show_unredacted" yaml:"show_unredacted"`
+}
+
+// CircuitBreakerConfig holds per function+target circuit breaker settings.
+type CircuitBreakerConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// FailureThreshold consecutive failures, each within WindowSeconds of
+	// the previous one, open the circuit for CooldownSeconds.
+	FailureThreshold int `mapstructure:"failure_threshold" yaml:"failure_threshold"`
+	WindowSeconds    int `mapstructure:"window_seconds" yaml:"window_seconds"`
+	CooldownSeconds  int `mapstructure:"cooldown_seconds" yaml:"cooldown_seconds"`
 }
 
 // ConversationConfig holds conversation context settings.
 type ConversationConfig struct {
 	MaxMessages int `mapstructure:"max_messages" yaml:"max_messages"`
 	MaxTokens   int `mapstructure:"max_tokens" yaml:"max_tokens"`
+	// HistoryFile, when set, is where the conversation and labeled results
+	// are kept between runs.
+	HistoryFile string `mapstructure:"history_file" yaml:"history_file"`
+	// HistoryMaxAgeHours drops persisted messages older than this when the
+	// history is loaded; 0 keeps them regardless of age.
+	HistoryMaxAgeHours int `mapstructure:"history_max_age_hours" yaml:"history_max_age_hours"`
 }
 
 // UIConfig holds UI settings.
 type UIConfig struct {
 	ShowToolOutput bool `mapstructure:"show_tool_output" yaml:"show_tool_output"`
 	Verbose        bool `mapstructure:"verbose" yaml:"verbose"`
+	// MaxOutputChars bounds each function's output in the answer; longer
+	// JSON output is summarized field by field.
+	MaxOutputChars int `mapstructure:"max_output_chars" yaml:"max_output_chars"`
 }
 
 // LoggingConfig holds logging settings.
@@ -78,6 +181,46 @@ type LoggingConfig struct {
 	Format string `mapstructure:"format" yaml:"format"`
 }
 
+// Dependency types accepted in DependencyConfig.Type.
+const (
+	DependencyTCP   = "tcp"
+	DependencyHTTP  = "http"
+	DependencyHTTPS = "https"
+	DependencyGRPC  = "grpc"
+	DependencyUnix  = "unix"
+)
+
+// FastPathConfig routes queries that match a pattern straight to one
+// function, skipping the LLM.
+type FastPathConfig struct {
+	Enabled bool            `mapstructure:"enabled" yaml:"enabled"`
+	Routes  []FastPathRoute `mapstructure:"routes" yaml:"routes"`
+}
+
+// FastPathRoute runs Function for a query matching Pattern. Pattern is a
+// regular expression matched case-insensitively against the whole query,
+// and ${group} in Params values is replaced by its named group's match.
+type FastPathRoute struct {
+	Pattern  string            `mapstructure:"pattern" yaml:"pattern"`
+	Function string            `mapstructure:"function" yaml:"function"`
+	Params   map[string]string `mapstructure:"params" yaml:"params,omitempty"`
+}
+
+// DependencyConfig is one service dependency and how to check it: a TCP
+// connect, an HTTP(S) GET of Path, a gRPC health check (over the socket at
+// Path when set), or a connect to the unix socket at Path.
+type DependencyConfig struct {
+	Name string `mapstructure:"name" yaml:"name"`
+	Type string `mapstructure:"type" yaml:"type"`
+	Host string `mapstructure:"host" yaml:"host,omitempty"`
+	Port int    `mapstructure:"port" yaml:"port,omitempty"`
+	Path string `mapstructure:"path" yaml:"path,omitempty"`
+	// Optional dependencies that are down degrade the verdict instead of
+	// failing it.
+	Optional       bool `mapstructure:"optional" yaml:"optional,omitempty"`
+	TimeoutSeconds int  `mapstructure:"timeout_seconds" yaml:"timeout_seconds,omitempty"`
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() *Config {
 	return &Config{
@@ -87,9 +230,15 @@ func DefaultConfig() *Config {
 			Collection: "telemetry_docs",
 		},
 		RAG: RAGConfig{
-			TopK:             5,
-			MinSimilarity:    0.7,
-			MaxContextLength: 4000,
+			TopK:                5,
+			MinSimilarity:       0.7,
+			MaxContextLength:    4000,
+			EmbedRetries:        2,
+			EmbedTimeoutSeconds: 5,
+			Ranking: RankingConfig{
+				VectorWeight:        1,
+				RecencyHalfLifeDays: 90,
+			},
 		},
 		ONNX: ONNXConfig{
 			ModelPath:         "./models/minilm-l6-v2.onnx",
@@ -108,14 +257,47 @@ func DefaultConfig() *Config {
 			DefaultStrategy:     "stop_on_error",
 			MaxRetries:          2,
 			RetryBackoffSeconds: 1,
+			UserAgent:           "telemetry-debugger/1.0",
+			CircuitBreaker: CircuitBreakerConfig{
+				Enabled:          true,
+				FailureThreshold: 3,
+				WindowSeconds:    60,
+				CooldownSeconds:  30,
+			},
+			MaxConcurrentPerTarget: 4,
+			Redaction: RedactionConfig{
+				Enabled:        true,
+				ShowUnredacted: true,
+			},
 		},
 		Conversation: ConversationConfig{
-			MaxMessages: 10,
-			MaxTokens:   4000,
+			MaxMessages:        10,
+			MaxTokens:          4000,
+			HistoryMaxAgeHours: 24,
 		},
 		UI: UIConfig{
 			ShowToolOutput: true,
 			Verbose:        false,
+			MaxOutputChars: 500,
+		},
+		FastPath: FastPathConfig{
+			Routes: []FastPathRoute{
+				{
+					Pattern:  `ping\s+(?P<host>[A-Za-z0-9\[][\w.:\[\]-]*)`,
+					Function: "ping",
+					Params:   map[string]string{"host": "${host}"},
+				},
+				{
+					Pattern:  `(?:check|test|scan)\s+port\s+(?P<port>\d+)\s+on\s+(?:host\s+)?(?P<host>[A-Za-z0-9\[][\w.:\[\]-]*)`,
+					Function: "port_scan",
+					Params:   map[string]string{"host": "${host}", "ports": "${port}"},
+				},
+				{
+					Pattern:  `(?:resolve|dns\s+lookup|look\s*up)\s+(?P<domain>[A-Za-z0-9][\w.-]*\.[a-z]{2,})`,
+					Function: "dns_lookup",
+					Params:   map[string]string{"domain": "${domain}"},
+				},
+			},
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -198,31 +380,235 @@ func UserConfigPath() (string, error) {
 	return filepath.Join(dir, "config.yaml"), nil
 }
 
-// Validate validates the configuration values.
+// Validate validates the configuration values, returning the first problem.
 func (c *Config) Validate() error {
+	if errs := c.fieldErrors(); len(errs) > 0 {
+		return fmt.Errorf("%s %w", errs[0].field, errs[0].err)
+	}
+	return nil
+}
+
+// fieldError is a validation failure for a single dotted config key.
+type fieldError struct {
+	field string
+	err   error
+}
+
+// fieldErrors returns every invalid value in the configuration, in the order
+// Validate has always reported them.
+func (c *Config) fieldErrors() []fieldError {
+	var errs []fieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, fieldError{field: field, err: fmt.Errorf(format, args...)})
+	}
+
 	if c.Qdrant.Host == "" {
-		return fmt.Errorf("qdrant.host is required")
+		add("qdrant.host", "is required")
 	}
 	if c.Qdrant.Port <= 0 || c.Qdrant.Port > 65535 {
-		return fmt.Errorf("qdrant.port must be between 1 and 65535")
+		add("qdrant.port", "must be between 1 and 65535")
 	}
 	if c.Qdrant.Collection == "" {
-		return fmt.Errorf("qdrant.collection is required")
+		add("qdrant.collection", "is required")
 	}
 	if c.ONNX.ModelPath == "" {
-		return fmt.Errorf("onnx.model_path is required")
+		add("onnx.model_path", "is required")
 	}
 	if c.ONNX.VocabPath == "" {
-		return fmt.Errorf("onnx.vocab_path is required")
+		add("onnx.vocab_path", "is required")
 	}
 	if c.ONNX.EmbeddingDim <= 0 {
-		return fmt.Errorf("onnx.embedding_dim must be positive")
+		add("onnx.embedding_dim", "must be positive")
 	}
 	if c.LLM.Endpoint == "" {
-		return fmt.Errorf("llm.endpoint is required")
+		add("llm.endpoint", "is required")
+	} else if err := validateEndpoint(c.LLM.Endpoint); err != nil {
+		add("llm.endpoint", "is invalid: %w", err)
 	}
 	if c.LLM.Model == "" {
-		return fmt.Errorf("llm.model is required")
+		add("llm.model", "is required")
+	}
+	if c.LLM.TimeoutSeconds <= 0 {
+		add("llm.timeout_seconds", "must be positive")
+	}
+	if c.Conversation.HistoryMaxAgeHours < 0 {
+		add("conversation.history_max_age_hours", "must not be negative")
+	}
+	if r := c.RAG.Ranking; r.VectorWeight < 0 || r.RecencyWeight < 0 || r.PriorityWeight < 0 {
+		add("rag.ranking", "weights must not be negative")
+	} else if r.VectorWeight+r.RecencyWeight+r.PriorityWeight == 0 {
+		add("rag.ranking", "needs at least one positive weight")
+	}
+	if c.RAG.Ranking.RecencyWeight > 0 && c.RAG.Ranking.RecencyHalfLifeDays <= 0 {
+		add("rag.ranking.recency_half_life_days", "must be positive when recency_weight is set")
+	}
+	sources := make([]string, 0, len(c.RAG.Ranking.SourcePriority))
+	for source := range c.RAG.Ranking.SourcePriority {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		if priority := c.RAG.Ranking.SourcePriority[source]; priority < 0 || priority > 1 {
+			add("rag.ranking.source_priority", "for '%s' must be between 0 and 1", source)
+		}
+	}
+	if c.UI.MaxOutputChars <= 0 {
+		add("ui.max_output_chars", "must be positive")
+	}
+	if c.Executor.MaxRetries < 0 {
+		add("executor.max_retries", "must not be negative")
+	}
+	if c.Executor.RetryBackoffSeconds < 0 {
+		add("executor.retry_backoff_seconds", "must not be negative")
+	}
+	if c.Executor.MaxTransactionSeconds < 0 {
+		add("executor.max_transaction_seconds", "must not be negative")
+	}
+	if c.Executor.MaxConcurrentPerTarget < 0 {
+		add("executor.max_concurrent_per_target", "must not be negative")
+	}
+	for i, entry := range c.Executor.TargetAllowlist {
+		field := fmt.Sprintf("executor.target_allowlist[%d]", i)
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			add(field, "must not be empty")
+		case strings.Contains(entry, "/"):
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				add(field, "is not a valid CIDR: '%s'", entry)
+			}
+		default:
+			if _, err := path.Match(entry, ""); err != nil {
+				add(field, "is not a valid hostname pattern: '%s'", entry)
+			}
+		}
+	}
+	for i, pattern := range c.Executor.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			add(fmt.Sprintf("executor.redaction.patterns[%d]", i), "is not a valid regular expression: '%s'", pattern)
+		}
+	}
+	if cb := c.Executor.CircuitBreaker; cb.Enabled {
+		if cb.FailureThreshold <= 0 {
+			add("executor.circuit_breaker.failure_threshold", "must be positive")
+		}
+		if cb.WindowSeconds <= 0 {
+			add("executor.circuit_breaker.window_seconds", "must be positive")
+		}
+		if cb.CooldownSeconds <= 0 {
+			add("executor.circuit_breaker.cooldown_seconds", "must be positive")
+		}
+	}
+	for i, r := range c.FastPath.Routes {
+		field := fmt.Sprintf("fast_path.routes[%d]", i)
+		if _, err := regexp.Compile(r.Pattern); err != nil || r.Pattern == "" {
+			add(field+".pattern", "is not a valid regular expression: '%s'", r.Pattern)
+		}
+		if r.Function == "" {
+			add(field+".function", "is required")
+		}
+	}
+	seen := make(map[string]bool)
+	for i, d := range c.Dependencies {
+		field := fmt.Sprintf("dependencies[%d]", i)
+		if d.Name == "" {
+			add(field+".name", "is required")
+		} else if seen[d.Name] {
+			add(field+".name", "duplicates '%s'", d.Name)
+		}
+		seen[d.Name] = true
+
+		switch d.Type {
+		case DependencyTCP:
+			if d.Host == "" {
+				add(field+".host", "is required for tcp")
+			}
+			if d.Port <= 0 || d.Port > 65535 {
+				add(field+".port", "must be between 1 and 65535")
+			}
+		case DependencyHTTP, DependencyHTTPS:
+			if d.Host == "" {
+				add(field+".host", "is required for %s", d.Type)
+			}
+			if d.Port < 0 || d.Port > 65535 {
+				add(field+".port", "must be between 1 and 65535")
+			}
+		case DependencyGRPC:
+			if d.Path == "" && d.Host == "" {
+				add(field+".host", "is required for grpc unless path names a unix socket")
+			}
+			if d.Path == "" && (d.Port <= 0 || d.Port > 65535) {
+				add(field+".port", "must be between 1 and 65535")
+			}
+		case DependencyUnix:
+			if d.Path == "" {
+				add(field+".path", "is required for unix")
+			}
+		default:
+			add(field+".type", "must be one of tcp, http, https, grpc, unix, got '%s'", d.Type)
+		}
+		if d.TimeoutSeconds < 0 {
+			add(field+".timeout_seconds", "must not be negative")
+		}
+	}
+	return errs
+}
+
+// ValidateFile checks a config file on its own, without merging it into the
+// running configuration: unknown keys and mistyped values are reported, then
+// the values (over defaults) go through the same checks as Validate. Issues
+// carry the line they refer to where it can be determined. The error is
+// non-nil only when the file cannot be read.
+func ValidateFile(path string) ([]types.SchemaIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return types.YAMLErrorIssues(err), nil
+	}
+
+	cfg := DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return types.YAMLErrorIssues(err), nil
+	}
+
+	var issues []types.SchemaIssue
+	for _, fe := range cfg.fieldErrors() {
+		issues = append(issues, types.SchemaIssue{
+			Line:    types.YAMLLine(&root, fe.field),
+			Field:   fe.field,
+			Message: fe.err.Error(),
+		})
+	}
+	return issues, nil
+}
+
+// validateEndpoint checks that an endpoint is an absolute http(s) URL with a host.
+func validateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %q", endpoint)
 	}
 	return nil
 }
+
+// Redacted returns a copy of the configuration that is safe to print.
+// Credentials embedded in endpoint URLs are masked.
+func (c *Config) Redacted() *Config {
+	out := *c
+	if u, err := url.Parse(out.LLM.Endpoint); err == nil && u.User != nil {
+		out.LLM.Endpoint = u.Redacted()
+	}
+	return &out
+}
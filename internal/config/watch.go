@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce collapses the burst of events an editor produces for one
+// save (truncate, write, rename) into a single reload.
+const watchDebounce = 250 * time.Millisecond
+
+// Watch calls onChange with the freshly loaded configuration each time the
+// file at path is written, created, or replaced, until ctx is done. A file
+// that fails to load is reported through onChange's error instead; the
+// caller decides whether to keep its current configuration.
+//
+// The parent directory is watched rather than the file itself so that
+// editors which save by renaming a temporary file are still seen.
+func Watch(ctx context.Context, path string, onChange func(*Config, error)) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path %s: %w", path, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(abs)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", filepath.Dir(abs), err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != abs || !ev.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+				debounce = time.After(watchDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onChange(nil, fmt.Errorf("config watcher: %w", err))
+			case <-debounce:
+				debounce = nil
+				onChange(Load(abs))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Diff lists the settings that differ between old and new by their YAML
+// path, e.g. "llm.endpoint", in field order.
+func Diff(old, new *Config) []string {
+	var changed []string
+	diffValues("", reflect.ValueOf(*old), reflect.ValueOf(*new), &changed)
+	return changed
+}
+
+func diffValues(prefix string, a, b reflect.Value, changed *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct {
+			diffValues(name, fa, fb, changed)
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			*changed = append(*changed, name)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package diagnostics
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ipPattern finds IPv4 and IPv6 address candidates; each is checked with
+// net.ParseIP, so timestamps such as 10:00:02 are left alone.
+var ipPattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:\b[0-9A-Fa-f]{1,4}|)(?::[0-9A-Fa-f]{0,4}){2,7}\b`)
+
+// hostnamePattern finds dotted hostnames. Only names whose last label is in
+// internalSuffixes or publicTLDs are replaced, so file names like
+// config.yaml and identifiers like ctx.Err are left alone.
+var hostnamePattern = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9-]{0,62}\b`)
+
+// hostFieldPattern finds the values of host fields, "host": "db01" in JSON
+// output or host=db01 in text, so single-label names are masked where they
+// are known to be hosts. A bare db01 elsewhere in prose cannot be told from
+// an ordinary word and is left alone.
+var hostFieldPattern = regexp.MustCompile(`(?i)("(?:host|hostname|server|target|node)"\s*:\s*"|\b(?:host|hostname|server|target|node)=)([a-z0-9][a-z0-9.:-]*)`)
+
+// internalSuffixes are the last labels of names that only resolve inside a
+// private network; these are always replaced.
+var internalSuffixes = map[string]bool{
+	"internal": true, "local": true, "lan": true, "corp": true, "home": true,
+	"intranet": true, "private": true, "localdomain": true, "svc": true,
+	"cluster": true, "consul": true,
+}
+
+// publicTLDs are the last labels recognised as public domains. They are
+// replaced unless public names are preserved.
+var publicTLDs = map[string]bool{
+	"com": true, "net": true, "org": true, "io": true, "dev": true,
+	"app": true, "cloud": true, "co": true, "ai": true, "edu": true,
+	"gov": true, "info": true, "biz": true, "me": true, "us": true,
+	"uk": true, "de": true, "fr": true, "eu": true, "in": true,
+	"jp": true, "cn": true, "au": true, "ca": true, "nl": true,
+}
+
+// Anonymizer replaces hostnames and IP addresses in text with stable
+// pseudonyms, host-1 and ip-1 and so on, so a transcript can be shared
+// without its network details: every occurrence of a name or address gets
+// the same pseudonym, across all the text passed through one Anonymizer,
+// keeping the relationships between results readable. Single-label names
+// such as db01 are replaced only as the value of a host field (see
+// hostFieldPattern). Loopback and unspecified addresses and "localhost" are
+// never replaced. It is safe for concurrent use.
+type Anonymizer struct {
+	// PreservePublic leaves public IP addresses and names under public
+	// top-level domains unchanged, masking only private-range addresses
+	// and internal names.
+	PreservePublic bool
+
+	mu    sync.Mutex
+	hosts map[string]string
+	ips   map[string]string
+}
+
+// NewAnonymizer creates an Anonymizer with no pseudonyms assigned yet.
+func NewAnonymizer(preservePublic bool) *Anonymizer {
+	return &Anonymizer{
+		PreservePublic: preservePublic,
+		hosts:          make(map[string]string),
+		ips:            make(map[string]string),
+	}
+}
+
+// Anonymize returns text with its hostnames and IP addresses replaced.
+func (a *Anonymizer) Anonymize(text string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	text = hostFieldPattern.ReplaceAllStringFunc(text, a.replaceHostField)
+	text = ipPattern.ReplaceAllStringFunc(text, a.replaceIP)
+	return hostnamePattern.ReplaceAllStringFunc(text, a.replaceHost)
+}
+
+// Mapping returns each replaced hostname and address with its pseudonym.
+func (a *Anonymizer) Mapping() map[string]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	mapping := make(map[string]string, len(a.hosts)+len(a.ips))
+	for k, v := range a.hosts {
+		mapping[k] = v
+	}
+	for k, v := range a.ips {
+		mapping[k] = v
+	}
+	return mapping
+}
+
+func (a *Anonymizer) replaceIP(match string) string {
+	ip := net.ParseIP(match)
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return match
+	}
+	private := ip.IsPrivate() || ip.IsLinkLocalUnicast()
+	if !private && a.PreservePublic {
+		return match
+	}
+	// Key by the parsed form so 2001:db8::1 and 2001:0db8::1 match.
+	key := ip.String()
+	if p, ok := a.ips[key]; ok {
+		return p
+	}
+	p := fmt.Sprintf("ip-%d", len(a.ips)+1)
+	a.ips[key] = p
+	return p
+}
+
+// replaceHostField masks a single-label host field value, keeping any
+// :port. Dotted names and addresses are left for the hostname and IP
+// patterns.
+func (a *Anonymizer) replaceHostField(match string) string {
+	m := hostFieldPattern.FindStringSubmatch(match)
+	prefix, value := m[1], m[2]
+	host, port := value, ""
+	if i := strings.IndexByte(value, ':'); i >= 0 {
+		host, port = value[:i], value[i:]
+	}
+	name := strings.ToLower(host)
+	if strings.Contains(name, ".") || strings.Count(port, ":") > 1 ||
+		strings.Trim(port, ":0123456789") != "" || !strings.ContainsAny(name, "abcdefghijklmnopqrstuvwxyz") ||
+		name == "localhost" {
+		return match
+	}
+	return prefix + a.hostPseudonym(name) + port
+}
+
+func (a *Anonymizer) replaceHost(match string) string {
+	name := strings.ToLower(match)
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return match
+	}
+	tld := name[strings.LastIndex(name, ".")+1:]
+	switch {
+	case internalSuffixes[tld]:
+	case publicTLDs[tld]:
+		if a.PreservePublic {
+			return match
+		}
+	default:
+		return match
+	}
+	return a.hostPseudonym(name)
+}
+
+// hostPseudonym returns name's pseudonym, assigning the next one if it has
+// none yet.
+func (a *Anonymizer) hostPseudonym(name string) string {
+	if p, ok := a.hosts[name]; ok {
+		return p
+	}
+	p := fmt.Sprintf("host-%d", len(a.hosts)+1)
+	a.hosts[name] = p
+	return p
+}
@@ -0,0 +1,76 @@
+package diagnostics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizer_StablePseudonyms(t *testing.T) {
+	a := NewAnonymizer(false)
+
+	first := a.Anonymize("dial 10.0.3.7:50051 from api.prod.internal failed")
+	second := a.Anonymize(`{"host":"api.prod.internal","peer":"10.0.3.7","other":"10.0.3.8"}`)
+
+	if first != "dial ip-1:50051 from host-1 failed" {
+		t.Errorf("unexpected first text %q", first)
+	}
+	if second != `{"host":"host-1","peer":"ip-1","other":"ip-2"}` {
+		t.Errorf("expected the same pseudonyms reused, got %q", second)
+	}
+
+	mapping := a.Mapping()
+	if mapping["10.0.3.7"] != "ip-1" || mapping["api.prod.internal"] != "host-1" || len(mapping) != 3 {
+		t.Errorf("unexpected mapping %v", mapping)
+	}
+}
+
+func TestAnonymizer_MasksPublicByDefault(t *testing.T) {
+	a := NewAnonymizer(false)
+	got := a.Anonymize("resolved db.example.com to 93.184.216.34 and 192.168.1.20")
+	if strings.Contains(got, "example.com") || strings.Contains(got, "93.184.216.34") || strings.Contains(got, "192.168") {
+		t.Errorf("expected every name and address masked, got %q", got)
+	}
+}
+
+func TestAnonymizer_PreservePublic(t *testing.T) {
+	a := NewAnonymizer(true)
+	got := a.Anonymize("resolved db.example.com to 93.184.216.34, then 172.16.4.2 and fd00::12 via gw.corp")
+	want := "resolved db.example.com to 93.184.216.34, then ip-1 and ip-2 via host-1"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAnonymizer_LeavesOtherTextAlone(t *testing.T) {
+	a := NewAnonymizer(false)
+	text := "localhost:8080 127.0.0.1 ::1 0.0.0.0 at 2024-05-01T10:00:02Z in config.yaml, v1.2.3, mac aa:bb:cc:dd:ee:ff"
+	if got := a.Anonymize(text); got != text {
+		t.Errorf("expected text unchanged, got %q", got)
+	}
+	if len(a.Mapping()) != 0 {
+		t.Errorf("expected no pseudonyms, got %v", a.Mapping())
+	}
+}
+
+func TestAnonymizer_IPv6Forms(t *testing.T) {
+	a := NewAnonymizer(false)
+	got := a.Anonymize("2001:db8::1 and 2001:0db8:0:0:0:0:0:1")
+	if got != "ip-1 and ip-1" {
+		t.Errorf("expected both forms of one address to share a pseudonym, got %q", got)
+	}
+}
+
+func TestAnonymizer_SingleLabelHostFields(t *testing.T) {
+	a := NewAnonymizer(false)
+	got := a.Anonymize(`{"host":"db01","target": "DB01:5432","peer":"fe80::1"} then host=cache-2 and server=localhost`)
+	want := `{"host":"host-1","target": "host-1:5432","peer":"ip-1"} then host=host-2 and server=localhost`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Outside a host field a single label is indistinguishable from a
+	// word, so it is not masked.
+	if got := a.Anonymize("db01 restarted"); got != "db01 restarted" {
+		t.Errorf("expected a bare single-label name left alone, got %q", got)
+	}
+}
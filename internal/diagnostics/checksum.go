@@ -0,0 +1,70 @@
+package diagnostics
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChecksumGroup is the hosts that reported one checksum.
+type ChecksumGroup struct {
+	Checksum string   `json:"checksum"`
+	Hosts    []string `json:"hosts"`
+}
+
+// ChecksumComparison is the output of CompareChecksums.
+type ChecksumComparison struct {
+	// Identical is true when every host reported the same checksum.
+	Identical bool `json:"identical"`
+	// Consensus is the checksum most hosts reported, or empty when no
+	// single checksum has the most hosts.
+	Consensus string `json:"consensus,omitempty"`
+	// Diverging are the hosts whose checksum differs from Consensus, or
+	// every host when there is none.
+	Diverging []string `json:"diverging"`
+	// Missing are the hosts that reported no checksum.
+	Missing []string `json:"missing"`
+	// Groups are the distinct checksums, most hosts first.
+	Groups []ChecksumGroup `json:"groups"`
+}
+
+// CompareChecksums takes each host's checksum of a file that should be the
+// same everywhere, as collected by running file_checksum on each host,
+// and reports which hosts diverge from the checksum most of them share.
+// Checksums are compared case-insensitively.
+func CompareChecksums(checksums map[string]string) *ChecksumComparison {
+	cmp := &ChecksumComparison{Diverging: []string{}, Missing: []string{}, Groups: []ChecksumGroup{}}
+
+	byChecksum := make(map[string][]string)
+	for host, sum := range checksums {
+		sum = strings.ToLower(strings.TrimSpace(sum))
+		if sum == "" {
+			cmp.Missing = append(cmp.Missing, host)
+			continue
+		}
+		byChecksum[sum] = append(byChecksum[sum], host)
+	}
+	sort.Strings(cmp.Missing)
+
+	for sum, hosts := range byChecksum {
+		sort.Strings(hosts)
+		cmp.Groups = append(cmp.Groups, ChecksumGroup{Checksum: sum, Hosts: hosts})
+	}
+	sort.Slice(cmp.Groups, func(i, j int) bool {
+		if len(cmp.Groups[i].Hosts) != len(cmp.Groups[j].Hosts) {
+			return len(cmp.Groups[i].Hosts) > len(cmp.Groups[j].Hosts)
+		}
+		return cmp.Groups[i].Checksum < cmp.Groups[j].Checksum
+	})
+
+	cmp.Identical = len(cmp.Groups) == 1 && len(cmp.Missing) == 0
+	if len(cmp.Groups) == 1 || (len(cmp.Groups) > 1 && len(cmp.Groups[0].Hosts) > len(cmp.Groups[1].Hosts)) {
+		cmp.Consensus = cmp.Groups[0].Checksum
+	}
+	for _, g := range cmp.Groups {
+		if g.Checksum != cmp.Consensus {
+			cmp.Diverging = append(cmp.Diverging, g.Hosts...)
+		}
+	}
+	sort.Strings(cmp.Diverging)
+	return cmp
+}
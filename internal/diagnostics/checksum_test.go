@@ -0,0 +1,45 @@
+package diagnostics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareChecksums_Identical(t *testing.T) {
+	cmp := CompareChecksums(map[string]string{"web-1": "abc123", "web-2": "ABC123", "web-3": "abc123"})
+	if !cmp.Identical || cmp.Consensus != "abc123" || len(cmp.Diverging) != 0 {
+		t.Errorf("expected identical checksums, got %+v", cmp)
+	}
+}
+
+func TestCompareChecksums_OneHostDiverges(t *testing.T) {
+	cmp := CompareChecksums(map[string]string{
+		"web-1": "abc123",
+		"web-2": "abc123",
+		"web-3": "fff999",
+		"web-4": "abc123",
+		"web-5": "",
+	})
+	if cmp.Identical || cmp.Consensus != "abc123" {
+		t.Fatalf("expected abc123 as the consensus, got %+v", cmp)
+	}
+	if strings.Join(cmp.Diverging, ",") != "web-3" {
+		t.Errorf("expected web-3 to diverge, got %v", cmp.Diverging)
+	}
+	if strings.Join(cmp.Missing, ",") != "web-5" {
+		t.Errorf("expected web-5 missing, got %v", cmp.Missing)
+	}
+	if len(cmp.Groups) != 2 || strings.Join(cmp.Groups[0].Hosts, ",") != "web-1,web-2,web-4" {
+		t.Errorf("expected the consensus group first, got %+v", cmp.Groups)
+	}
+}
+
+func TestCompareChecksums_NoConsensus(t *testing.T) {
+	cmp := CompareChecksums(map[string]string{"a": "111", "b": "222"})
+	if cmp.Identical || cmp.Consensus != "" {
+		t.Fatalf("expected no consensus on a split, got %+v", cmp)
+	}
+	if strings.Join(cmp.Diverging, ",") != "a,b" {
+		t.Errorf("expected every host to diverge without a consensus, got %v", cmp.Diverging)
+	}
+}
@@ -0,0 +1,221 @@
+// Package diagnostics combines individual network functions into
+// service-level checks.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/friday/internal/config"
+	"github.com/friday/internal/functions/network"
+)
+
+// Dependency statuses reported in DependencyStatus.Status.
+const (
+	DepHealthy  = "healthy"
+	DepDegraded = "degraded"
+	DepDown     = "down"
+)
+
+// Verdicts reported in DependencyReport.Verdict.
+const (
+	VerdictHealthy   = "healthy"
+	VerdictDegraded  = "degraded"
+	VerdictUnhealthy = "unhealthy"
+)
+
+// defaultDependencyTimeout applies when a dependency sets no timeout.
+const defaultDependencyTimeout = 3 * time.Second
+
+// DependencyStatus is the outcome of checking one dependency.
+type DependencyStatus struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Target   string `json:"target"`
+	Optional bool   `json:"optional,omitempty"`
+	// Status is healthy, degraded (answering but reporting a problem, such
+	// as HTTP 503 or gRPC NOT_SERVING), or down (not answering at all).
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Detail    string  `json:"detail"`
+
+	Explanation *network.ConnErrorExplanation `json:"explanation,omitempty"`
+}
+
+// DependencyReport rolls up the checks of every configured dependency.
+type DependencyReport struct {
+	// Verdict is unhealthy if a required dependency is down, degraded if any
+	// dependency is degraded or an optional one is down, and healthy
+	// otherwise.
+	Verdict      string             `json:"verdict"`
+	Healthy      int                `json:"healthy"`
+	Degraded     int                `json:"degraded"`
+	Down         int                `json:"down"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// CheckDependencies runs the health check matching each dependency's type,
+// all at once, and returns the results in the order of deps with an overall
+// verdict. A check that cannot run counts as down.
+func CheckDependencies(ctx context.Context, deps []config.DependencyConfig) *DependencyReport {
+	report := &DependencyReport{Dependencies: make([]DependencyStatus, len(deps))}
+
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func(i int, dep config.DependencyConfig) {
+			defer wg.Done()
+			report.Dependencies[i] = checkDependency(ctx, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	report.Verdict = VerdictHealthy
+	for _, d := range report.Dependencies {
+		switch d.Status {
+		case DepHealthy:
+			report.Healthy++
+		case DepDegraded:
+			report.Degraded++
+		default:
+			report.Down++
+		}
+		switch {
+		case d.Status == DepDown && !d.Optional:
+			report.Verdict = VerdictUnhealthy
+		case d.Status != DepHealthy && report.Verdict == VerdictHealthy:
+			report.Verdict = VerdictDegraded
+		}
+	}
+	return report
+}
+
+// checkDependency checks one dependency within its timeout.
+func checkDependency(ctx context.Context, dep config.DependencyConfig) DependencyStatus {
+	timeout := defaultDependencyTimeout
+	if dep.TimeoutSeconds > 0 {
+		timeout = time.Duration(dep.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status := DependencyStatus{Name: dep.Name, Type: dep.Type, Optional: dep.Optional}
+	switch dep.Type {
+	case config.DependencyTCP:
+		checkTCPDependency(dep, timeout, &status)
+	case config.DependencyHTTP, config.DependencyHTTPS:
+		checkHTTPDependency(ctx, dep, &status)
+	case config.DependencyGRPC:
+		checkGRPCDependency(ctx, dep, timeout, &status)
+	case config.DependencyUnix:
+		checkUnixDependency(dep, timeout, &status)
+	default:
+		status.Status = DepDown
+		status.Detail = fmt.Sprintf("unknown dependency type '%s'", dep.Type)
+	}
+	return status
+}
+
+func checkTCPDependency(dep config.DependencyConfig, timeout time.Duration, status *DependencyStatus) {
+	status.Target = net.JoinHostPort(dep.Host, strconv.Itoa(dep.Port))
+
+	result, err := network.ConnectivityMatrix([]string{status.Target}, timeoutSeconds(timeout))
+	if err != nil {
+		markDown(status, err)
+		return
+	}
+	check := result.Results[0]
+	status.LatencyMs = check.LatencyMs
+	if !check.Reachable {
+		status.Status = DepDown
+		status.Detail = check.Error
+		explanation := result.Explanations[check.ErrorClass]
+		status.Explanation = &explanation
+		return
+	}
+	status.Status = DepHealthy
+	status.Detail = "accepting connections"
+}
+
+func checkHTTPDependency(ctx context.Context, dep config.DependencyConfig, status *DependencyStatus) {
+	host := dep.Host
+	if dep.Port > 0 {
+		host = net.JoinHostPort(dep.Host, strconv.Itoa(dep.Port))
+	}
+	status.Target = fmt.Sprintf("%s://%s%s", dep.Type, host, dep.Path)
+
+	result, err := network.HTTPRequestSampled(ctx, status.Target, "GET", "", network.DefaultMaxRedirects, 1, network.HTTPRequestOptions{})
+	if err != nil {
+		markDown(status, err)
+		return
+	}
+	status.LatencyMs = float64(result.ResponseTimeMs)
+	status.Detail = result.StatusText
+	if result.Success {
+		status.Status = DepHealthy
+	} else {
+		status.Status = DepDegraded
+	}
+}
+
+func checkGRPCDependency(ctx context.Context, dep config.DependencyConfig, timeout time.Duration, status *DependencyStatus) {
+	host, port := dep.Host, dep.Port
+	status.Target = net.JoinHostPort(host, strconv.Itoa(port))
+	if dep.Path != "" {
+		host, port = "unix://"+dep.Path, 0
+		status.Target = host
+	}
+
+	start := time.Now()
+	result, err := network.CheckGRPCHealthSampled(ctx, host, port, timeoutSeconds(timeout), 1, false)
+	status.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		markDown(status, err)
+		return
+	}
+	serving, _ := result["status"].(string)
+	status.Detail = serving
+	if serving == "SERVING" {
+		status.Status = DepHealthy
+	} else {
+		status.Status = DepDegraded
+	}
+}
+
+func checkUnixDependency(dep config.DependencyConfig, timeout time.Duration, status *DependencyStatus) {
+	status.Target = dep.Path
+
+	result, err := network.CheckUnixSocket(dep.Path, timeoutSeconds(timeout))
+	if err != nil {
+		markDown(status, err)
+		return
+	}
+	status.LatencyMs = result.ConnectTimeMs
+	if !result.Accepting {
+		status.Status = DepDown
+		status.Detail = result.Error
+		status.Explanation = result.Explanation
+		return
+	}
+	status.Status = DepHealthy
+	status.Detail = "accepting connections"
+}
+
+// markDown records err as the reason a dependency is down.
+func markDown(status *DependencyStatus, err error) {
+	status.Status = DepDown
+	status.Detail = err.Error()
+	if explanation := network.ClassifyConnError(err); explanation.Category != network.ConnErrUnknown {
+		status.Explanation = &explanation
+	}
+}
+
+// timeoutSeconds rounds timeout up to whole seconds for the network
+// functions that take seconds.
+func timeoutSeconds(timeout time.Duration) int {
+	return int((timeout + time.Second - 1) / time.Second)
+}
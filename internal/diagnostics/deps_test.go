@@ -0,0 +1,220 @@
+package diagnostics
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/friday/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// hostPort splits a listener address into the host and port of a
+// DependencyConfig.
+func hostPort(t *testing.T, addr string) (string, int) {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, port
+}
+
+// listenTCP starts a TCP listener that accepts and closes connections until
+// the test ends.
+func listenTCP(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln
+}
+
+// closedPort returns a local port that nothing listens on.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	_, port := hostPort(t, ln.Addr().String())
+	ln.Close()
+	return port
+}
+
+// startHealthServer serves the gRPC health service with status on a local
+// port until the test ends.
+func startHealthServer(t *testing.T, status grpc_health_v1.HealthCheckResponse_ServingStatus) (string, int) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus("", status)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return hostPort(t, lis.Addr().String())
+}
+
+// listenUnix starts a unix socket listener in a short temp directory, since
+// socket paths are limited to about 100 bytes.
+func listenUnix(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "friday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "app.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", path, err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return path
+}
+
+func httpServer(t *testing.T, code int) (string, int) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return hostPort(t, srv.Listener.Addr().String())
+}
+
+func TestCheckDependencies_MixedTypes(t *testing.T) {
+	dbHost, dbPort := hostPort(t, listenTCP(t).Addr().String())
+	apiHost, apiPort := httpServer(t, http.StatusOK)
+	grpcHost, grpcPort := startHealthServer(t, grpc_health_v1.HealthCheckResponse_SERVING)
+	sock := listenUnix(t)
+
+	deps := []config.DependencyConfig{
+		{Name: "db", Type: config.DependencyTCP, Host: dbHost, Port: dbPort},
+		{Name: "api", Type: config.DependencyHTTP, Host: apiHost, Port: apiPort, Path: "/healthz"},
+		{Name: "auth", Type: config.DependencyGRPC, Host: grpcHost, Port: grpcPort},
+		{Name: "sidecar", Type: config.DependencyUnix, Path: sock},
+	}
+
+	report := CheckDependencies(context.Background(), deps)
+
+	if report.Verdict != VerdictHealthy {
+		t.Errorf("expected %s verdict, got %s: %+v", VerdictHealthy, report.Verdict, report.Dependencies)
+	}
+	if report.Healthy != len(deps) || report.Degraded != 0 || report.Down != 0 {
+		t.Errorf("expected %d healthy, got %+v", len(deps), report)
+	}
+	for i, d := range report.Dependencies {
+		if d.Name != deps[i].Name {
+			t.Errorf("expected results in config order, got %q at %d", d.Name, i)
+		}
+		if d.Status != DepHealthy {
+			t.Errorf("expected %s healthy, got %s: %s", d.Name, d.Status, d.Detail)
+		}
+	}
+}
+
+func TestCheckDependencies_Verdicts(t *testing.T) {
+	okHost, okPort := httpServer(t, http.StatusOK)
+	badHost, badPort := httpServer(t, http.StatusServiceUnavailable)
+	notServingHost, notServingPort := startHealthServer(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	closed := closedPort(t)
+	missing := filepath.Join(t.TempDir(), "gone.sock")
+
+	healthy := config.DependencyConfig{Name: "api", Type: config.DependencyHTTP, Host: okHost, Port: okPort, Path: "/healthz"}
+
+	tests := []struct {
+		name    string
+		dep     config.DependencyConfig
+		status  string
+		verdict string
+	}{
+		{
+			name:    "http 503 is degraded",
+			dep:     config.DependencyConfig{Name: "search", Type: config.DependencyHTTP, Host: badHost, Port: badPort, Path: "/healthz"},
+			status:  DepDegraded,
+			verdict: VerdictDegraded,
+		},
+		{
+			name:    "grpc not serving is degraded",
+			dep:     config.DependencyConfig{Name: "auth", Type: config.DependencyGRPC, Host: notServingHost, Port: notServingPort},
+			status:  DepDegraded,
+			verdict: VerdictDegraded,
+		},
+		{
+			name:    "closed tcp port is down",
+			dep:     config.DependencyConfig{Name: "db", Type: config.DependencyTCP, Host: "127.0.0.1", Port: closed, TimeoutSeconds: 1},
+			status:  DepDown,
+			verdict: VerdictUnhealthy,
+		},
+		{
+			name:    "missing socket is down",
+			dep:     config.DependencyConfig{Name: "sidecar", Type: config.DependencyUnix, Path: missing},
+			status:  DepDown,
+			verdict: VerdictUnhealthy,
+		},
+		{
+			name:    "optional dependency down is degraded",
+			dep:     config.DependencyConfig{Name: "cache", Type: config.DependencyTCP, Host: "127.0.0.1", Port: closed, Optional: true, TimeoutSeconds: 1},
+			status:  DepDown,
+			verdict: VerdictDegraded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := CheckDependencies(context.Background(), []config.DependencyConfig{healthy, tt.dep})
+
+			if report.Dependencies[0].Status != DepHealthy {
+				t.Errorf("expected %s healthy, got %+v", healthy.Name, report.Dependencies[0])
+			}
+			got := report.Dependencies[1]
+			if got.Status != tt.status {
+				t.Errorf("expected %s %s, got %s: %s", got.Name, tt.status, got.Status, got.Detail)
+			}
+			if report.Verdict != tt.verdict {
+				t.Errorf("expected %s verdict, got %s", tt.verdict, report.Verdict)
+			}
+			if got.Status == DepDown && (got.Detail == "" || got.Explanation == nil) {
+				t.Errorf("expected a reason and explanation for a down dependency, got %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// Finding severities, most urgent first.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Statuses of a triage check in HostCheckStatus.Status.
+const (
+	CheckOK       = "ok"
+	CheckAbnormal = "abnormal"
+	CheckSkipped  = "skipped"
+	CheckFailed   = "failed"
+)
+
+// Usage thresholds, in percent, at which TriageHost reports a finding.
+const (
+	usageWarnPercent             = 85
+	usageCriticalPercent         = 95
+	swapWarnPercent              = 50
+	conntrackWarnPercent         = 75
+	conntrackCriticalPercent     = 90
+	interfaceDropWarnPercent     = 1.0
+	interfaceDropCriticalPercent = 5.0
+)
+
+var severityRank = map[string]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+
+// Finding is one abnormal result of a triage check.
+type Finding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+}
+
+// HostCheckStatus is the outcome of one triage check.
+type HostCheckStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HostTriageReport is the output of TriageHost.
+type HostTriageReport struct {
+	// Status is the severity of the most urgent finding, or ok when
+	// nothing is worse than info.
+	Status string `json:"status"`
+	// Findings are ordered most urgent first, then by check.
+	Findings []Finding         `json:"findings"`
+	Checks   []HostCheckStatus `json:"checks"`
+}
+
+// HostSources supplies the results TriageHost examines, one function per
+// check; DefaultHostSources reads the live system. A nil function skips
+// its check.
+type HostSources struct {
+	InterfaceStats func() (*system.InterfaceStatsResult, error)
+	Sockets        func() (*system.EphemeralPortDiagnosis, error)
+	Memory         func() (*system.MemoryUsage, error)
+	Disk           func() (*system.DiskUsage, error)
+	NetworkBuffers func() (map[string]interface{}, error)
+	Conntrack      func() (*system.ConntrackUsage, error)
+}
+
+// DefaultHostSources reads each check's result from the live system, with
+// disk usage taken for the root filesystem.
+func DefaultHostSources() HostSources {
+	return HostSources{
+		InterfaceStats: func() (*system.InterfaceStatsResult, error) { return system.InterfaceStats("", 0) },
+		Sockets:        system.DiagnoseEphemeralPorts,
+		Memory:         func() (*system.MemoryUsage, error) { return system.MemoryUsageFrom("/proc") },
+		Disk:           func() (*system.DiskUsage, error) { return system.DiskUsageOf("/") },
+		NetworkBuffers: system.InspectNetworkBuffers,
+		Conntrack:      func() (*system.ConntrackUsage, error) { return system.ConntrackUsageFrom("/proc") },
+	}
+}
+
+// hostCheck is one triage check: a name and a function that returns its
+// findings.
+type hostCheck struct {
+	name string
+	run  func() ([]Finding, error)
+}
+
+// TriageHost runs every read-only check in src at once and rolls their
+// abnormal results into one list of findings, most urgent first. A check
+// that fails is reported in Checks and does not stop the others; a missing
+// conntrack table is skipped rather than failed.
+func TriageHost(src HostSources) *HostTriageReport {
+	checks := hostChecks(src)
+	statuses := make([]HostCheckStatus, len(checks))
+	findings := make([][]Finding, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c hostCheck) {
+			defer wg.Done()
+			statuses[i] = HostCheckStatus{Name: c.name, Status: CheckOK}
+			found, err := c.run()
+			switch {
+			case errors.Is(err, fs.ErrNotExist) && c.name == "conntrack":
+				statuses[i].Status = CheckSkipped
+				statuses[i].Error = "connection tracking is not loaded"
+			case err != nil:
+				statuses[i].Status = CheckFailed
+				statuses[i].Error = err.Error()
+			case len(found) > 0:
+				statuses[i].Status = CheckAbnormal
+				findings[i] = found
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &HostTriageReport{Status: CheckOK, Findings: []Finding{}, Checks: statuses}
+	for _, found := range findings {
+		report.Findings = append(report.Findings, found...)
+	}
+	// Stable, so findings of equal severity keep the order of the checks.
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank[report.Findings[i].Severity] < severityRank[report.Findings[j].Severity]
+	})
+	if len(report.Findings) > 0 && report.Findings[0].Severity != SeverityInfo {
+		report.Status = report.Findings[0].Severity
+	}
+	return report
+}
+
+// hostChecks pairs each source in src with the function that judges its
+// result, in report order.
+func hostChecks(src HostSources) []hostCheck {
+	var checks []hostCheck
+	if src.InterfaceStats != nil {
+		checks = append(checks, hostCheck{"interface_stats", func() ([]Finding, error) {
+			r, err := src.InterfaceStats()
+			if err != nil {
+				return nil, err
+			}
+			return interfaceFindings(r), nil
+		}})
+	}
+	if src.Sockets != nil {
+		checks = append(checks, hostCheck{"sockets", func() ([]Finding, error) {
+			r, err := src.Sockets()
+			if err != nil {
+				return nil, err
+			}
+			return socketFindings(r), nil
+		}})
+	}
+	if src.Memory != nil {
+		checks = append(checks, hostCheck{"memory", func() ([]Finding, error) {
+			r, err := src.Memory()
+			if err != nil {
+				return nil, err
+			}
+			return memoryFindings(r), nil
+		}})
+	}
+	if src.Disk != nil {
+		checks = append(checks, hostCheck{"disk", func() ([]Finding, error) {
+			r, err := src.Disk()
+			if err != nil {
+				return nil, err
+			}
+			return diskFindings(r), nil
+		}})
+	}
+	if src.NetworkBuffers != nil {
+		checks = append(checks, hostCheck{"network_buffers", func() ([]Finding, error) {
+			r, err := src.NetworkBuffers()
+			if err != nil {
+				return nil, err
+			}
+			return bufferFindings(r), nil
+		}})
+	}
+	if src.Conntrack != nil {
+		checks = append(checks, hostCheck{"conntrack", func() ([]Finding, error) {
+			r, err := src.Conntrack()
+			if err != nil {
+				return nil, err
+			}
+			return conntrackFindings(r), nil
+		}})
+	}
+	return checks
+}
+
+// interfaceFindings flags interfaces with errors or a share of dropped
+// packets above interfaceDropWarnPercent since boot.
+func interfaceFindings(r *system.InterfaceStatsResult) []Finding {
+	var found []Finding
+	for _, s := range r.Interfaces {
+		if errs := s.RxErrors + s.TxErrors; errs > 0 {
+			found = append(found, Finding{"interface_stats", SeverityWarning, fmt.Sprintf(
+				"%s has %d receive and %d transmit errors (%d carrier); check the cable, NIC and driver",
+				s.Interface, s.RxErrors, s.TxErrors, s.TxCarrier)})
+		}
+		packets := s.RxPackets + s.TxPackets
+		if packets == 0 {
+			continue
+		}
+		dropped := s.RxDropped + s.TxDropped
+		share := float64(dropped) * 100 / float64(packets)
+		severity := usageSeverity(share, interfaceDropWarnPercent, interfaceDropCriticalPercent)
+		if severity == "" {
+			continue
+		}
+		found = append(found, Finding{"interface_stats", severity, fmt.Sprintf(
+			"%s dropped %.1f%% of packets (%d of %d); the ring buffer or socket buffers may be too small",
+			s.Interface, share, dropped, packets)})
+	}
+	return found
+}
+
+// socketFindings reports ephemeral port pressure at the severity
+// DiagnoseEphemeralPorts graded it.
+func socketFindings(d *system.EphemeralPortDiagnosis) []Finding {
+	if d.Status != "critical" && d.Status != "warning" {
+		return nil
+	}
+	return []Finding{{"sockets", d.Status, fmt.Sprintf(
+		"%d sockets in TIME-WAIT hold %.0f%% of the ephemeral port range; outbound connects may fail",
+		d.TimeWait, d.UsedFraction*100)}}
+}
+
+func memoryFindings(m *system.MemoryUsage) []Finding {
+	var found []Finding
+	if severity := usageSeverity(m.UsedPercent, usageWarnPercent, usageCriticalPercent); severity != "" {
+		found = append(found, Finding{"memory", severity, fmt.Sprintf(
+			"memory is %.0f%% used (%d MB available); the OOM killer may start ending processes",
+			m.UsedPercent, m.AvailableKB/1024)})
+	}
+	if m.SwapTotalKB > 0 && m.SwapUsedPercent >= swapWarnPercent {
+		found = append(found, Finding{"memory", SeverityWarning, fmt.Sprintf(
+			"swap is %.0f%% used; swapping slows everything on the host", m.SwapUsedPercent)})
+	}
+	return found
+}
+
+func diskFindings(d *system.DiskUsage) []Finding {
+	var found []Finding
+	if severity := usageSeverity(d.UsedPercent, usageWarnPercent, usageCriticalPercent); severity != "" {
+		found = append(found, Finding{"disk", severity, fmt.Sprintf(
+			"%s is %.0f%% full (%d MB available); writes and logging will fail when it fills",
+			d.Path, d.UsedPercent, d.AvailableBytes>>20)})
+	}
+	if severity := usageSeverity(d.InodesUsedPercent, usageWarnPercent, usageCriticalPercent); severity != "" {
+		found = append(found, Finding{"disk", severity, fmt.Sprintf(
+			"%s has used %.0f%% of its inodes; file creation fails when they run out",
+			d.Path, d.InodesUsedPercent)})
+	}
+	return found
+}
+
+// bufferFindings reports undersized network buffers as info: they limit
+// throughput on fast links but are the kernel defaults on most hosts.
+func bufferFindings(r map[string]interface{}) []Finding {
+	warnings, _ := r["warnings"].([]string)
+	found := make([]Finding, 0, len(warnings))
+	for _, w := range warnings {
+		found = append(found, Finding{"network_buffers", SeverityInfo, w})
+	}
+	return found
+}
+
+func conntrackFindings(c *system.ConntrackUsage) []Finding {
+	severity := usageSeverity(c.UsedPercent, conntrackWarnPercent, conntrackCriticalPercent)
+	if severity == "" {
+		return nil
+	}
+	return []Finding{{"conntrack", severity, fmt.Sprintf(
+		"the conntrack table is %.0f%% full (%d of %d); new connections are dropped when it fills",
+		c.UsedPercent, c.Count, c.Max)}}
+}
+
+// usageSeverity grades a percentage against warn and critical thresholds,
+// returning "" below both.
+func usageSeverity(used, warn, critical float64) string {
+	switch {
+	case used >= critical:
+		return SeverityCritical
+	case used >= warn:
+		return SeverityWarning
+	}
+	return ""
+}
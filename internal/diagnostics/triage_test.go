@@ -0,0 +1,141 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// healthySources returns sources whose results are all normal.
+func healthySources() HostSources {
+	return HostSources{
+		InterfaceStats: func() (*system.InterfaceStatsResult, error) {
+			return &system.InterfaceStatsResult{Interfaces: []system.InterfaceStat{{
+				InterfaceCounters: system.InterfaceCounters{Interface: "eth0", RxPackets: 10000, TxPackets: 10000},
+			}}}, nil
+		},
+		Sockets: func() (*system.EphemeralPortDiagnosis, error) {
+			return &system.EphemeralPortDiagnosis{Status: "ok", UsedFraction: 0.05}, nil
+		},
+		Memory: func() (*system.MemoryUsage, error) {
+			return &system.MemoryUsage{TotalKB: 8 << 20, AvailableKB: 6 << 20, UsedPercent: 25}, nil
+		},
+		Disk: func() (*system.DiskUsage, error) {
+			return &system.DiskUsage{Path: "/", UsedPercent: 40, InodesUsedPercent: 10}, nil
+		},
+		NetworkBuffers: func() (map[string]interface{}, error) {
+			return map[string]interface{}{"warnings": []string{}}, nil
+		},
+		Conntrack: func() (*system.ConntrackUsage, error) {
+			return &system.ConntrackUsage{Count: 100, Max: 65536, UsedPercent: 0.2}, nil
+		},
+	}
+}
+
+func TestTriageHost_Healthy(t *testing.T) {
+	report := TriageHost(healthySources())
+	if report.Status != CheckOK || len(report.Findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", report)
+	}
+	if len(report.Checks) != 6 {
+		t.Fatalf("expected 6 checks, got %+v", report.Checks)
+	}
+	for _, c := range report.Checks {
+		if c.Status != CheckOK {
+			t.Errorf("expected %s ok, got %+v", c.Name, c)
+		}
+	}
+}
+
+func TestTriageHost_AbnormalFindingsByPriority(t *testing.T) {
+	src := healthySources()
+	src.InterfaceStats = func() (*system.InterfaceStatsResult, error) {
+		return &system.InterfaceStatsResult{Interfaces: []system.InterfaceStat{{
+			InterfaceCounters: system.InterfaceCounters{
+				Interface: "eth0", RxPackets: 10000, TxPackets: 10000, RxErrors: 12,
+			},
+		}}}, nil
+	}
+	src.Disk = func() (*system.DiskUsage, error) {
+		return &system.DiskUsage{Path: "/", UsedPercent: 97, InodesUsedPercent: 10}, nil
+	}
+	src.Memory = func() (*system.MemoryUsage, error) {
+		return &system.MemoryUsage{TotalKB: 8 << 20, AvailableKB: 1 << 20, UsedPercent: 88}, nil
+	}
+	src.NetworkBuffers = func() (map[string]interface{}, error) {
+		return map[string]interface{}{"warnings": []string{"rmem_max is too low"}}, nil
+	}
+	src.Conntrack = func() (*system.ConntrackUsage, error) {
+		return &system.ConntrackUsage{Count: 63000, Max: 65536, UsedPercent: 96.1}, nil
+	}
+
+	report := TriageHost(src)
+	if report.Status != SeverityCritical {
+		t.Errorf("expected status critical, got %q", report.Status)
+	}
+
+	// Critical first, then warnings, then info, each in check order.
+	want := []struct{ check, severity string }{
+		{"disk", SeverityCritical},
+		{"conntrack", SeverityCritical},
+		{"interface_stats", SeverityWarning},
+		{"memory", SeverityWarning},
+		{"network_buffers", SeverityInfo},
+	}
+	if len(report.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), report.Findings)
+	}
+	for i, w := range want {
+		f := report.Findings[i]
+		if f.Check != w.check || f.Severity != w.severity {
+			t.Errorf("finding %d: expected %s %s, got %+v", i, w.severity, w.check, f)
+		}
+	}
+	if !strings.Contains(report.Findings[0].Summary, "97% full") {
+		t.Errorf("expected the disk summary to give the usage, got %q", report.Findings[0].Summary)
+	}
+
+	for _, c := range report.Checks {
+		abnormal := c.Name != "sockets"
+		if (c.Status == CheckAbnormal) != abnormal {
+			t.Errorf("unexpected status for %s: %+v", c.Name, c)
+		}
+	}
+}
+
+func TestTriageHost_InfoOnlyIsOK(t *testing.T) {
+	src := healthySources()
+	src.NetworkBuffers = func() (map[string]interface{}, error) {
+		return map[string]interface{}{"warnings": []string{"rmem_max is too low"}}, nil
+	}
+	report := TriageHost(src)
+	if report.Status != CheckOK || len(report.Findings) != 1 {
+		t.Errorf("expected status ok with one info finding, got %+v", report)
+	}
+}
+
+func TestTriageHost_FailedAndSkippedChecks(t *testing.T) {
+	src := healthySources()
+	src.Sockets = func() (*system.EphemeralPortDiagnosis, error) {
+		return nil, errors.New("cannot read /proc/net/sockstat")
+	}
+	src.Conntrack = func() (*system.ConntrackUsage, error) {
+		return nil, fmt.Errorf("cannot read nf_conntrack_count: %w", fs.ErrNotExist)
+	}
+
+	report := TriageHost(src)
+	if report.Status != CheckOK {
+		t.Errorf("expected a failed check not to raise the status, got %q", report.Status)
+	}
+	statuses := make(map[string]string)
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	if statuses["sockets"] != CheckFailed || statuses["conntrack"] != CheckSkipped || statuses["memory"] != CheckOK {
+		t.Errorf("unexpected check statuses: %v", statuses)
+	}
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/friday/internal/types"
+)
+
+// ErrCircuitOpen is returned, wrapped, for a call short-circuited by an open
+// circuit. Its text starts with "circuit_open" so the LLM sees it verbatim.
+var ErrCircuitOpen = errors.New("circuit_open")
+
+// circuitTargetParams are the params, in order of preference, that name what
+// a function acts on. Calls are tracked per function and target, so a down
+// host does not block the same function against a healthy one.
+var circuitTargetParams = []string{"host", "url", "domain", "interface", "source_ip", "path", "parameter"}
+
+// BreakerConfig sets when a circuit opens and how long it stays open.
+type BreakerConfig struct {
+	// FailureThreshold consecutive failures open the circuit.
+	FailureThreshold int
+	// Window is the longest gap between failures that still counts as
+	// consecutive; an older failure starts the count again.
+	Window time.Duration
+	// Cooldown is how long an open circuit refuses calls before letting a
+	// trial call through.
+	Cooldown time.Duration
+}
+
+// CircuitBreaker short-circuits calls to a function+target pair that keeps
+// failing. After the cooldown the circuit is half-open: one trial call is
+// let through and the rest are refused until it is recorded. A success
+// closes the circuit, a failure reopens it for another cooldown.
+type CircuitBreaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures    int
+	lastFailure time.Time
+	openUntil   time.Time
+	// probing is set while the half-open trial call is running.
+	probing bool
+}
+
+// NewCircuitBreaker creates a breaker with cfg.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		cfg:      cfg,
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// Allow returns an error wrapping ErrCircuitOpen, with a retry-after hint,
+// if calls to key are currently refused.
+func (b *CircuitBreaker) Allow(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[key]
+	if c == nil || c.openUntil.IsZero() {
+		return nil
+	}
+	wait := c.openUntil.Sub(b.now())
+	if wait <= 0 {
+		if c.probing {
+			return fmt.Errorf("%w: %s failed %d times in a row; a trial call is in progress",
+				ErrCircuitOpen, key, c.failures)
+		}
+		c.probing = true
+		return nil
+	}
+	return fmt.Errorf("%w: %s failed %d times in a row; retry after %s",
+		ErrCircuitOpen, key, c.failures, wait.Round(time.Second))
+}
+
+// Record updates key's circuit with the outcome of a call.
+func (b *CircuitBreaker) Record(key string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		delete(b.circuits, key)
+		return
+	}
+
+	now := b.now()
+	c := b.circuits[key]
+	if c == nil {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	if !c.lastFailure.IsZero() && now.Sub(c.lastFailure) > b.cfg.Window && c.openUntil.IsZero() {
+		c.failures = 0
+	}
+	c.probing = false
+	c.failures++
+	c.lastFailure = now
+	if c.failures >= b.cfg.FailureThreshold {
+		c.openUntil = now.Add(b.cfg.Cooldown)
+	}
+}
+
+// circuitKey identifies fn's circuit as "name" or "name(target)".
+func circuitKey(fn types.FunctionCall) string {
+	for _, param := range circuitTargetParams {
+		if v, ok := fn.Params[param]; ok {
+			return fmt.Sprintf("%s(%v)", fn.Name, v)
+		}
+	}
+	return fn.Name
+}
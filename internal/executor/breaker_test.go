@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/types"
+	"go.uber.org/zap"
+)
+
+// testBreaker returns a breaker driven by the returned clock.
+func testBreaker(threshold int) (*CircuitBreaker, *time.Time) {
+	clock := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(BreakerConfig{
+		FailureThreshold: threshold,
+		Window:           time.Minute,
+		Cooldown:         30 * time.Second,
+	})
+	b.now = func() time.Time { return clock }
+	return b, &clock
+}
+
+func TestExecute_CircuitBreakerTripsAndRecovers(t *testing.T) {
+	b, clock := testBreaker(3)
+	exec := NewExecutor(zap.NewNop())
+	exec.SetCircuitBreaker(b)
+
+	// The capture does not exist yet, so every call fails.
+	path := filepath.Join(t.TempDir(), "capture.pcap")
+	call := types.FunctionCall{Name: "analyze_pcap", Params: map[string]interface{}{"path": path}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := exec.Execute(call); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: expected the function's own failure, got %v", i+1, err)
+		}
+		*clock = clock.Add(time.Second)
+	}
+
+	_, err := exec.Execute(call)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit_open after 3 failures, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "circuit_open") || !strings.Contains(err.Error(), "retry after 29s") {
+		t.Errorf("expected circuit_open error with retry-after hint, got %q", err)
+	}
+
+	// Another target for the same function is unaffected.
+	other := types.FunctionCall{Name: "analyze_pcap", Params: map[string]interface{}{"path": path + ".other"}}
+	if _, err := exec.Execute(other); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected separate circuit for another target, got %v", err)
+	}
+
+	// The target comes back and the cooldown passes: the trial call runs
+	// and its success closes the circuit.
+	fixture, err := os.ReadFile("../../tests/internal/functions/network/testdata/handshake.pcap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+	*clock = clock.Add(30 * time.Second)
+	if _, err := exec.Execute(call); err != nil {
+		t.Fatalf("expected trial call to succeed after cooldown, got %v", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exec.Execute(call); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected closed circuit to run the call again, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_FailedTrialReopens(t *testing.T) {
+	b, clock := testBreaker(2)
+	fail := errors.New("connection refused")
+
+	b.Record("ping(10.0.0.9)", fail)
+	b.Record("ping(10.0.0.9)", fail)
+	*clock = clock.Add(31 * time.Second)
+	if err := b.Allow("ping(10.0.0.9)"); err != nil {
+		t.Fatalf("expected trial call allowed after cooldown, got %v", err)
+	}
+
+	b.Record("ping(10.0.0.9)", fail)
+	if err := b.Allow("ping(10.0.0.9)"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected failed trial to reopen the circuit, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenAdmitsOneTrial(t *testing.T) {
+	b, clock := testBreaker(2)
+	fail := errors.New("connection refused")
+	key := "ping(10.0.0.9)"
+
+	b.Record(key, fail)
+	b.Record(key, fail)
+	*clock = clock.Add(31 * time.Second)
+	if err := b.Allow(key); err != nil {
+		t.Fatalf("expected trial call allowed after cooldown, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		err := b.Allow(key)
+		if !errors.Is(err, ErrCircuitOpen) || !strings.Contains(err.Error(), "trial call is in progress") {
+			t.Fatalf("expected concurrent call %d refused while the trial runs, got %v", i+1, err)
+		}
+	}
+
+	b.Record(key, nil)
+	if err := b.Allow(key); err != nil {
+		t.Errorf("expected closed circuit after a successful trial, got %v", err)
+	}
+	if err := b.Allow(key); err != nil {
+		t.Errorf("expected closed circuit to admit every call, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_FailuresOutsideWindowDoNotCount(t *testing.T) {
+	b, clock := testBreaker(2)
+	fail := errors.New("timeout")
+
+	b.Record("check_grpc_health(db)", fail)
+	*clock = clock.Add(2 * time.Minute)
+	b.Record("check_grpc_health(db)", fail)
+
+	if err := b.Allow("check_grpc_health(db)"); err != nil {
+		t.Errorf("expected failures a window apart to stay closed, got %v", err)
+	}
+}
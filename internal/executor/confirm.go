@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// OperationPreview describes one modify operation awaiting confirmation.
+// Params have already been resolved and exclude internal "__" flags.
+type OperationPreview struct {
+	FunctionName string
+	Params       map[string]interface{}
+	Critical     bool
+}
+
+// Confirmer decides whether pending modify operations may run. Returning
+// false aborts the transaction with ErrUserDeclined; returning an error aborts
+// it with that error.
+type Confirmer interface {
+	Confirm(ops []OperationPreview) (bool, error)
+}
+
+// PromptConfirmer asks the operator on a terminal, answering y/yes to proceed.
+type PromptConfirmer struct {
+	In  *bufio.Reader
+	Out io.Writer
+}
+
+// NewStdinConfirmer prompts on stdout and reads the answer from stdin.
+func NewStdinConfirmer() *PromptConfirmer {
+	return &PromptConfirmer{In: bufio.NewReader(os.Stdin), Out: os.Stdout}
+}
+
+// Confirm prints each pending operation and reads a y/N answer.
+func (p *PromptConfirmer) Confirm(ops []OperationPreview) (bool, error) {
+	fmt.Fprintln(p.Out, "┌─────────────────────────────────────────────────────────┐")
+	fmt.Fprintln(p.Out, "│  ⚠   DESTRUCTIVE OPERATIONS PENDING                    │")
+	fmt.Fprintln(p.Out, "└─────────────────────────────────────────────────────────┘")
+
+	for i, op := range ops {
+		fmt.Fprintf(p.Out, "\n  [%d] %s\n", i+1, op.FunctionName)
+		keys := make([]string, 0, len(op.Params))
+		for k := range op.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(p.Out, "      %-24s %v\n", k+":", op.Params[k])
+		}
+		critical := "no"
+		if op.Critical {
+			critical = "yes failure triggers rollback"
+		}
+		fmt.Fprintf(p.Out, "      %-24s %s\n", "critical:", critical)
+	}
+
+	fmt.Fprintf(p.Out, "\n  All operations are reversible via automatic rollback on failure.\n")
+	fmt.Fprintf(p.Out, "\nProceed with %d destructive operation(s)? [y/N]: ", len(ops))
+
+	line, err := p.In.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("could not read confirmation: %w", err)
+	}
+	answer := strings.TrimSpace(strings.ToLower(line))
+	return answer == "y" || answer == "yes", nil
+}
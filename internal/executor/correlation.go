@@ -0,0 +1,34 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// correlationKey is the context key under which WithCorrelationID stores
+// the ID.
+type correlationKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying id, which ExecuteContext
+// adds to every log line it writes so the lines for one query can be found
+// together.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the ID set by WithCorrelationID, or "" if there is
+// none.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// NewCorrelationID returns a random RFC 4122 version 4 UUID.
+func NewCorrelationID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/friday/internal/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestExecuteTransaction_LogsCorrelationID(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	te := NewTransactionEngine(NewExecutor(zap.New(core)), NewVariableResolver(), NewSnapshotManager(), stubPhases{})
+
+	for _, id := range []string{"query-1", "query-2"} {
+		ctx := WithCorrelationID(context.Background(), id)
+		_, err := te.ExecuteTransaction(ctx, []types.FunctionCall{
+			{Name: "interface_stats", Params: map[string]interface{}{"interface": "lo"}},
+			{Name: "netinfo", Params: map[string]interface{}{"interface": "lo"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for _, msg := range []string{"Executing function", "Function started", "Function finished"} {
+		entries := logs.FilterMessage(msg).All()
+		if len(entries) != 4 {
+			t.Fatalf("expected 4 %q lines, got %d", msg, len(entries))
+		}
+		for i, entry := range entries {
+			want := "query-1"
+			if i >= 2 {
+				want = "query-2"
+			}
+			if got := entry.ContextMap()["correlation_id"]; got != want {
+				t.Errorf("%q line %d: expected correlation_id %q, got %v", msg, i, want, got)
+			}
+		}
+	}
+
+	finished := logs.FilterMessage("Function finished").All()[0].ContextMap()
+	if _, ok := finished["duration"]; !ok || finished["success"] != true {
+		t.Errorf("expected duration and success on the finished line, got %v", finished)
+	}
+}
+
+func TestExecuteContext_NoCorrelationID(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	e := NewExecutor(zap.New(core))
+
+	if _, err := e.ExecuteContext(context.Background(), types.FunctionCall{Name: "no_such_function"}); err == nil {
+		t.Fatal("expected an error for an unknown function")
+	}
+	for _, entry := range logs.All() {
+		if _, ok := entry.ContextMap()["correlation_id"]; ok {
+			t.Errorf("expected no correlation_id without one in the context, got %v", entry.ContextMap())
+		}
+	}
+	finished := logs.FilterMessage("Function finished").All()
+	if len(finished) != 1 || finished[0].ContextMap()["success"] != false {
+		t.Errorf("expected one failed finished line, got %+v", finished)
+	}
+}
+
+func TestNewCorrelationID_Unique(t *testing.T) {
+	a, b := NewCorrelationID(), NewCorrelationID()
+	if a == b || len(a) != 36 {
+		t.Errorf("expected two distinct UUIDs, got %q and %q", a, b)
+	}
+}
@@ -2,12 +2,16 @@
 package executor
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/friday/internal/diagnostics"
 	"github.com/friday/internal/functions/debugging"
 	"github.com/friday/internal/functions/network"
 	"github.com/friday/internal/functions/system"
@@ -17,9 +21,21 @@ import (
 
 // Executor dispatches function calls to their implementations.
 type Executor struct {
-	logger *zap.Logger
+	logger   *zap.Logger
+	registry SchemaRegistry
+	breaker  *CircuitBreaker
+	limiter  *TargetLimiter
+
+	// mu guards progress, which the UI swaps per query while functions
+	// from an earlier query may still be reading it.
+	mu       sync.RWMutex
+	progress ProgressFunc
 }
 
+// ProgressFunc receives status lines from long-running functions while they
+// execute. It may be called from another goroutine and must not block.
+type ProgressFunc func(function, line string)
+
 // NewExecutor creates a new function executor.
 func NewExecutor(logger *zap.Logger) *Executor {
 	if logger == nil {
@@ -30,12 +46,118 @@ func NewExecutor(logger *zap.Logger) *Executor {
 	}
 }
 
+// NewExecutorWithRegistry creates an executor that validates every call's
+// params against the registry's declared schema before dispatch.
+func NewExecutorWithRegistry(logger *zap.Logger, registry SchemaRegistry) *Executor {
+	e := NewExecutor(logger)
+	e.registry = registry
+	return e
+}
+
+// SetProgress registers fn to receive progress from functions that report
+// it, currently traceroute, analyze_grpc_stream and grpc_health_profile.
+// Nil disables reporting.
+func (e *Executor) SetProgress(fn ProgressFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.progress = fn
+}
+
+// SetCircuitBreaker makes Execute refuse calls to a function+target pair
+// whose circuit b has opened. Nil disables the breaker.
+func (e *Executor) SetCircuitBreaker(b *CircuitBreaker) {
+	e.breaker = b
+}
+
+// SetTargetLimiter makes Execute wait when l's cap of calls against the
+// same host are already running. Nil disables the limit.
+func (e *Executor) SetTargetLimiter(l *TargetLimiter) {
+	e.limiter = l
+}
+
+// progressFor adapts the registered ProgressFunc for one function, or
+// returns nil when none is registered.
+func (e *Executor) progressFor(function string) network.ProgressFunc {
+	e.mu.RLock()
+	report := e.progress
+	e.mu.RUnlock()
+	if report == nil {
+		return nil
+	}
+	return func(line string) {
+		report(function, line)
+	}
+}
+
 // Execute runs a function call and returns the JSON result.
 func (e *Executor) Execute(fn types.FunctionCall) (string, error) {
-	e.logger.Info("Executing function",
+	return e.ExecuteContext(context.Background(), fn)
+}
+
+// ExecuteContext is Execute for functions that can stop early, such as
+// repeated probes, which check ctx between steps.
+func (e *Executor) ExecuteContext(ctx context.Context, fn types.FunctionCall) (result string, err error) {
+	logger := e.logger
+	if id := CorrelationID(ctx); id != "" {
+		logger = logger.With(zap.String("correlation_id", id))
+	}
+	logger.Info("Executing function",
 		zap.String("name", fn.Name),
 		zap.Any("params", fn.Params))
 
+	if err := e.validateParamsWith(logger, fn); err != nil {
+		return "", err
+	}
+	e.warnIfDeprecated(logger, fn)
+
+	start := time.Now()
+	logger.Debug("Function started", zap.String("name", fn.Name))
+	defer func() {
+		fields := []zap.Field{
+			zap.String("name", fn.Name),
+			zap.Duration("duration", time.Since(start)),
+			zap.Bool("success", err == nil),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		logger.Debug("Function finished", fields...)
+	}()
+
+	if e.limiter != nil {
+		var def *types.FunctionDefinition
+		if e.registry != nil {
+			if d, ok := e.registry.Get(fn.Name); ok {
+				def = &d
+			}
+		}
+		if targets := limitTargets(fn, def); len(targets) > 0 {
+			release, err := e.limiter.AcquireAll(ctx, targets)
+			if err != nil {
+				return "", err
+			}
+			defer release()
+		}
+	}
+
+	if e.breaker == nil {
+		result, err = e.dispatch(ctx, fn)
+		return e.withSideEffect(fn.Name, result), err
+	}
+	key := circuitKey(fn)
+	if err = e.breaker.Allow(key); err != nil {
+		logger.Warn("Circuit open, skipping function",
+			zap.String("name", fn.Name),
+			zap.Error(err))
+		return "", err
+	}
+	result, err = e.dispatch(ctx, fn)
+	e.breaker.Record(key, err)
+	return e.withSideEffect(fn.Name, result), err
+}
+
+// dispatch runs fn's implementation.
+func (e *Executor) dispatch(ctx context.Context, fn types.FunctionCall) (string, error) {
 	switch fn.Name {
 	// ==================== Basic Network Tools ====================
 	case "ping":
@@ -48,7 +170,13 @@ func (e *Executor) Execute(fn types.FunctionCall) (string, error) {
 		return e.executePortScan(fn.Params)
 
 	case "http_request":
-		return e.executeHTTPRequest(fn.Params)
+		return e.executeHTTPRequest(ctx, fn.Params)
+	case "check_cert_revocation":
+		return e.executeCheckCertRevocation(ctx, fn.Params)
+	case "check_all_endpoints":
+		return e.executeCheckAllEndpoints(ctx, fn.Params)
+	case "http_probe_multi":
+		return e.executeHTTPProbeMulti(ctx, fn.Params)
 
 	case "traceroute":
 		return e.executeTraceroute(fn.Params)
@@ -56,15 +184,49 @@ func (e *Executor) Execute(fn types.FunctionCall) (string, error) {
 	case "netinfo":
 		return e.executeNetInfo(fn.Params)
 
+	case "connect_from":
+		return e.executeConnectFrom(fn.Params)
+
+	case "analyze_pcap":
+		return e.executeAnalyzePcap(fn.Params)
+
+	case "connectivity_matrix":
+		return e.executeConnectivityMatrix(fn.Params)
+
+	case "check_unix_socket":
+		return e.executeCheckUnixSocket(fn.Params)
+
 	// ==================== TCP/gRPC Tools ====================
 	case "check_tcp_health":
 		return e.executeCheckTCPHealth(fn.Params)
 
 	case "check_grpc_health":
-		return e.executeCheckGRPCHealth(fn.Params)
+		return e.executeCheckGRPCHealth(ctx, fn.Params)
+	case "tcp_connect_timing":
+		return e.executeTCPConnectTiming(ctx, fn.Params)
+
+	case "neighbor_table":
+		return e.executeNeighborTable(ctx, fn.Params)
+
+	case "check_external_reachability":
+		return e.executeCheckExternalReachability(ctx, fn.Params)
+
+	case "probe_udp_service":
+		return e.executeProbeUDPService(ctx, fn.Params)
+
+	case "dns_latency":
+		return e.executeDNSLatency(ctx, fn.Params)
+
+	case "tls_scan":
+		return e.executeTLSScan(ctx, fn.Params)
+
+	case "diagnose_slow_connection":
+		return e.executeDiagnoseSlowConnection(ctx, fn.Params)
 
 	case "analyze_grpc_stream":
 		return e.executeAnalyzeGRPCStream(fn.Params)
+	case "grpc_health_profile":
+		return e.executeGRPCHealthProfile(fn.Params)
 
 	// ==================== System Tools ====================
 	case "inspect_network_buffers":
@@ -75,6 +237,35 @@ func (e *Executor) Execute(fn types.FunctionCall) (string, error) {
 
 	case "restore_sysctl_value":
 		return e.executeRestoreSysctlValue(fn.Params)
+
+	case "recommend_buffer_tuning":
+		return e.executeRecommendBufferTuning(fn.Params)
+
+	case "diagnose_ephemeral_ports":
+		return e.executeDiagnoseEphemeralPorts(fn.Params)
+
+	case "check_sysctl_drift":
+		return e.executeCheckSysctlDrift(fn.Params)
+
+	case "interface_stats":
+		return e.executeInterfaceStats(fn.Params)
+
+	case "top_processes":
+		return e.executeTopProcesses(fn.Params)
+
+	case "service_logs":
+		return e.executeServiceLogs(fn.Params)
+
+	case "validate_config_file":
+		return e.executeValidateConfigFile(fn.Params)
+	case "file_checksum":
+		return e.executeFileChecksum(fn.Params)
+	case "listening_sockets":
+		return e.executeListeningSockets(ctx, fn.Params)
+	case "triage_host":
+		return e.executeTriageHost(fn.Params)
+	case "scan_log":
+		return e.executeScanLog(fn.Params)
 	
 	case "read_sysctl_param":
     	return e.executeReadSysctl(fn.Params)
@@ -154,6 +345,90 @@ func getBool(params map[string]interface{}, key string, required bool, defaultVa
 	}
 }
 
+// getStringSlice reads an optional list of strings. A single string is
+// treated as a one-element list.
+func getStringSlice(params map[string]interface{}, key string) ([]string, error) {
+	v, ok := params[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	switch t := v.(type) {
+	case []string:
+		return t, nil
+	case string:
+		return []string{t}, nil
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported element type for list param %s: %T", key, item)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported type for list param %s: %T", key, v)
+	}
+}
+
+// getIntSlice reads an optional list of integers. A single number is
+// treated as a one-element list.
+func getIntSlice(params map[string]interface{}, key string) ([]int, error) {
+	v, ok := params[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		switch t := v.(type) {
+		case []int:
+			return t, nil
+		case int, int64, float64, float32, string:
+			items = []interface{}{t}
+		default:
+			return nil, fmt.Errorf("unsupported type for list param %s: %T", key, v)
+		}
+	}
+	out := make([]int, 0, len(items))
+	for _, item := range items {
+		i, err := getInt(map[string]interface{}{key: item}, key, true, 0)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, i)
+	}
+	return out, nil
+}
+
+func getFloat(params map[string]interface{}, key string, required bool, defaultVal float64) (float64, error) {
+	v, ok := params[key]
+	if !ok {
+		if required {
+			return 0, errors.New("missing required parameter: " + key)
+		}
+		return defaultVal, nil
+	}
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case float32:
+		return float64(t), nil
+	case int:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number for %s: %v", key, err)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("unsupported type for float param %s: %T", key, v)
+	}
+}
+
 // ============================================================================
 // Basic Network Tool Implementations
 // ============================================================================
@@ -167,8 +442,12 @@ func (e *Executor) executePing(params map[string]interface{}) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	payloadSizes, err := getIntSlice(params, "payload_sizes")
+	if err != nil {
+		return "", err
+	}
 
-	result, err := network.Ping(host, count)
+	result, err := network.PingWithOptions(host, count, network.PingOptions{PayloadSizes: payloadSizes})
 	if err != nil {
 		return "", err
 	}
@@ -203,8 +482,12 @@ func (e *Executor) executePortScan(params map[string]interface{}) (string, error
 	if err != nil {
 		return "", err
 	}
+	retries, err := getInt(params, "retries", false, 0)
+	if err != nil {
+		return "", err
+	}
 
-	result, err := network.PortScan(host, ports)
+	result, err := network.PortScanWithOptions(host, ports, network.PortScanOptions{Retries: retries})
 	if err != nil {
 		return "", err
 	}
@@ -212,7 +495,7 @@ func (e *Executor) executePortScan(params map[string]interface{}) (string, error
 	return toJSON(result)
 }
 
-func (e *Executor) executeHTTPRequest(params map[string]interface{}) (string, error) {
+func (e *Executor) executeHTTPRequest(ctx context.Context, params map[string]interface{}) (string, error) {
 	url, err := getString(params, "url", true, "")
 	if err != nil {
 		return "", err
@@ -222,7 +505,47 @@ func (e *Executor) executeHTTPRequest(params map[string]interface{}) (string, er
 		return "", err
 	}
 
-	result, err := network.HTTPRequest(url, method)
+	proxy, err := getString(params, "proxy", false, "")
+	if err != nil {
+		return "", err
+	}
+	maxRedirects, err := getInt(params, "max_redirects", false, network.DefaultMaxRedirects)
+	if err != nil {
+		return "", err
+	}
+	samples, err := getInt(params, "samples", false, 1)
+	if err != nil {
+		return "", err
+	}
+	checkRevocation, err := getBool(params, "check_revocation", false, false)
+	if err != nil {
+		return "", err
+	}
+	coldStart, err := getBool(params, "cold_start", false, false)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.HTTPRequestSampled(ctx, url, method, proxy, maxRedirects, samples,
+		network.HTTPRequestOptions{CheckRevocation: checkRevocation, ColdStart: coldStart})
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeHTTPProbeMulti(ctx context.Context, params map[string]interface{}) (string, error) {
+	url, err := getString(params, "url", true, "")
+	if err != nil {
+		return "", err
+	}
+	count, err := getInt(params, "count", false, 10)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.HTTPProbeMultiContext(ctx, url, count)
 	if err != nil {
 		return "", err
 	}
@@ -239,11 +562,21 @@ func (e *Executor) executeTraceroute(params map[string]interface{}) (string, err
 	if err != nil {
 		return "", err
 	}
+	rawLines, err := getInt(params, "raw_output_lines", false, 0)
+	if err != nil {
+		return "", err
+	}
+
+	returnPath, err := getStringSlice(params, "return_path")
+	if err != nil {
+		return "", err
+	}
 
-	result, err := network.Traceroute(host, maxHops)
+	result, err := network.TracerouteWithProgress(host, maxHops, rawLines, e.progressFor("traceroute"))
 	if err != nil {
 		return "", err
 	}
+	result.CompareReturnPath(returnPath)
 
 	return toJSON(result)
 }
@@ -266,8 +599,12 @@ func (e *Executor) executeNetInfo(params map[string]interface{}) (string, error)
 // TCP/gRPC Tool Implementations
 // ============================================================================
 
-func (e *Executor) executeCheckTCPHealth(params map[string]interface{}) (string, error) {
-	iface, err := getString(params, "interface", true, "")
+func (e *Executor) executeConnectFrom(params map[string]interface{}) (string, error) {
+	sourceIP, err := getString(params, "source_ip", true, "")
+	if err != nil {
+		return "", err
+	}
+	host, err := getString(params, "host", true, "")
 	if err != nil {
 		return "", err
 	}
@@ -276,7 +613,7 @@ func (e *Executor) executeCheckTCPHealth(params map[string]interface{}) (string,
 		return "", err
 	}
 
-	result, err := network.CheckTCPHealth(iface, port)
+	result, err := network.ConnectFrom(sourceIP, host, port)
 	if err != nil {
 		return "", err
 	}
@@ -284,21 +621,35 @@ func (e *Executor) executeCheckTCPHealth(params map[string]interface{}) (string,
 	return toJSON(result)
 }
 
-func (e *Executor) executeCheckGRPCHealth(params map[string]interface{}) (string, error) {
-	host, err := getString(params, "host", false, "localhost")
+func (e *Executor) executeConnectivityMatrix(params map[string]interface{}) (string, error) {
+	targets, err := getStringSlice(params, "targets")
 	if err != nil {
 		return "", err
 	}
-	port, err := getInt(params, "port", true, 0)
+	timeout, err := getInt(params, "timeout", false, 3)
 	if err != nil {
 		return "", err
 	}
-	timeout, err := getInt(params, "timeout", false, 5)
+
+	result, err := network.ConnectivityMatrix(targets, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeCheckUnixSocket(params map[string]interface{}) (string, error) {
+	path, err := getString(params, "path", true, "")
+	if err != nil {
+		return "", err
+	}
+	timeout, err := getInt(params, "timeout", false, 3)
 	if err != nil {
 		return "", err
 	}
 
-	result, err := network.CheckGRPCHealth(host, port, timeout)
+	result, err := network.CheckUnixSocket(path, timeout)
 	if err != nil {
 		return "", err
 	}
@@ -306,21 +657,21 @@ func (e *Executor) executeCheckGRPCHealth(params map[string]interface{}) (string
 	return toJSON(result)
 }
 
-func (e *Executor) executeAnalyzeGRPCStream(params map[string]interface{}) (string, error) {
-	host, err := getString(params, "host", false, "localhost")
+func (e *Executor) executeAnalyzePcap(params map[string]interface{}) (string, error) {
+	path, err := getString(params, "path", true, "")
 	if err != nil {
 		return "", err
 	}
-	port, err := getInt(params, "port", true, 0)
+	filter, err := getString(params, "filter", false, "")
 	if err != nil {
 		return "", err
 	}
-	duration, err := getInt(params, "duration", false, 10)
+	maxPackets, err := getInt(params, "max_packets", false, network.DefaultPcapMaxPackets)
 	if err != nil {
 		return "", err
 	}
 
-	result, err := network.AnalyzeGRPCStream(host, port, duration)
+	result, err := network.AnalyzePcap(path, filter, maxPackets)
 	if err != nil {
 		return "", err
 	}
@@ -328,46 +679,48 @@ func (e *Executor) executeAnalyzeGRPCStream(params map[string]interface{}) (stri
 	return toJSON(result)
 }
 
-// ============================================================================
-// System Tool Implementations
-// ============================================================================
+func (e *Executor) executeCheckTCPHealth(params map[string]interface{}) (string, error) {
+	iface, err := getString(params, "interface", true, "")
+	if err != nil {
+		return "", err
+	}
+	port, err := getInt(params, "port", true, 0)
+	if err != nil {
+		return "", err
+	}
 
-func (e *Executor) executeInspectNetworkBuffers(params map[string]interface{}) (string, error) {
-	result, err := system.InspectNetworkBuffers()
+	result, err := network.CheckTCPHealth(iface, port)
 	if err != nil {
 		return "", err
 	}
+	result["tuning"] = system.InspectTCPTuning()
 
 	return toJSON(result)
 }
 
-func (e *Executor) executeExecuteSysctl(params map[string]interface{}) (string, error) {
-	parameter, err := getString(params, "parameter", true, "")
+func (e *Executor) executeCheckGRPCHealth(ctx context.Context, params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", false, "localhost")
 	if err != nil {
 		return "", err
 	}
-	value, err := getString(params, "value", true, "")
+	port, err := getInt(params, "port", false, 0)
 	if err != nil {
 		return "", err
 	}
-	persist, err := getBool(params, "persist", false, false)
+	timeout, err := getInt(params, "timeout", false, 5)
 	if err != nil {
 		return "", err
 	}
-
-	// Bug 1 fix: dry-run validates inputs only; does not execute the real sysctl command.
-	isDryRun, _ := getBool(params, "__dry_run", false, false)
-	if isDryRun {
-		return toJSON(map[string]interface{}{
-			"parameter": parameter,
-			"value":     value,
-			"persist":   persist,
-			"dry_run":   true,
-			"success":   true,
-		})
+	samples, err := getInt(params, "samples", false, 1)
+	if err != nil {
+		return "", err
+	}
+	coldStart, err := getBool(params, "cold_start", false, false)
+	if err != nil {
+		return "", err
 	}
 
-	result, err := system.ExecuteSysctl(parameter, value, persist)
+	result, err := network.CheckGRPCHealthSampled(ctx, host, port, timeout, samples, coldStart)
 	if err != nil {
 		return "", err
 	}
@@ -375,57 +728,61 @@ func (e *Executor) executeExecuteSysctl(params map[string]interface{}) (string,
 	return toJSON(result)
 }
 
-func (e *Executor) executeReadSysctl(params map[string]interface{}) (string, error) {
-    parameter, err := getString(params, "parameter", true, "")
-    if err != nil {
-        return "", err
-    }
-    result, err := system.ReadSysctl(parameter)
-    if err != nil {
-        return "", err
-    }
-    return toJSON(result)
+func (e *Executor) executeTCPConnectTiming(ctx context.Context, params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", false, "localhost")
+	if err != nil {
+		return "", err
+	}
+	port, err := getInt(params, "port", true, 0)
+	if err != nil {
+		return "", err
+	}
+	samples, err := getInt(params, "samples", false, 5)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.TCPConnectTimingContext(ctx, host, port, samples)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
 }
 
-// executeRestoreSysctlValue restores a sysctl parameter to a previous value.
-// Used internally by the transaction rollback mechanism.
-func (e *Executor) executeRestoreSysctlValue(params map[string]interface{}) (string, error) {
-	parameter, err := getString(params, "parameter", true, "")
+func (e *Executor) executeDNSLatency(ctx context.Context, params map[string]interface{}) (string, error) {
+	domain, err := getString(params, "domain", true, "")
 	if err != nil {
 		return "", err
 	}
-	value, err := getString(params, "value", true, "")
+	samples, err := getInt(params, "samples", false, 5)
+	if err != nil {
+		return "", err
+	}
+	resolver, err := getString(params, "resolver", false, "")
 	if err != nil {
 		return "", err
 	}
 
-	if err := system.RestoreSysctlValue(parameter, value); err != nil {
+	result, err := network.DNSLatencyContext(ctx, domain, samples, resolver)
+	if err != nil {
 		return "", err
 	}
 
-	return toJSON(map[string]interface{}{
-		"parameter":      parameter,
-		"restored_value": value,
-		"success":        true,
-	})
+	return toJSON(result)
 }
 
-// ============================================================================
-// Debugging Tool Implementations (Placeholder)
-// ============================================================================
-
-func (e *Executor) executeAnalyzeCoreDump(params map[string]interface{}) (string, error) {
-	corePath, err := getString(params, "core_path", true, "")
+func (e *Executor) executeTLSScan(ctx context.Context, params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", true, "")
 	if err != nil {
 		return "", err
 	}
-	binaryPath, err := getString(params, "binary_path", false, "")
+	port, err := getInt(params, "port", false, 443)
 	if err != nil {
 		return "", err
 	}
 
-	// Import from debugging package
-	result, err := debugging.AnalyzeCoreDump(corePath, binaryPath)
+	result, err := network.TLSScanContext(ctx, host, port)
 	if err != nil {
 		return "", err
 	}
@@ -433,9 +790,518 @@ func (e *Executor) executeAnalyzeCoreDump(params map[string]interface{}) (string
 	return toJSON(result)
 }
 
-// ============================================================================
-// Utilities
-// ============================================================================
+func (e *Executor) executeDiagnoseSlowConnection(ctx context.Context, params map[string]interface{}) (string, error) {
+	url, err := getString(params, "url", true, "")
+	if err != nil {
+		return "", err
+	}
+	samples, err := getInt(params, "samples", false, 3)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.DiagnoseSlowConnectionContext(ctx, url, samples)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeNeighborTable(ctx context.Context, params map[string]interface{}) (string, error) {
+	iface, err := getString(params, "interface", false, "")
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.NeighborTableContext(ctx, iface, network.NeighborOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeCheckExternalReachability(ctx context.Context, params map[string]interface{}) (string, error) {
+	port, err := getInt(params, "port", true, 0)
+	if err != nil {
+		return "", err
+	}
+	publicIP, err := getString(params, "public_ip", false, "")
+	if err != nil {
+		return "", err
+	}
+
+	var opts network.ReachabilityOptions
+	if publicIP != "" {
+		opts.PublicIP = func(context.Context) (string, error) { return publicIP, nil }
+	}
+	result, err := network.CheckExternalReachabilityContext(ctx, port, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeProbeUDPService(ctx context.Context, params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", true, "")
+	if err != nil {
+		return "", err
+	}
+	service, err := getString(params, "service", true, "")
+	if err != nil {
+		return "", err
+	}
+	port, err := getInt(params, "port", false, 0)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.ProbeUDPServiceContext(ctx, host, port, service, network.UDPProbeOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeCheckCertRevocation(ctx context.Context, params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", true, "")
+	if err != nil {
+		return "", err
+	}
+	port, err := getInt(params, "port", false, 443)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.CheckCertRevocationContext(ctx, host, port)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeCheckAllEndpoints(ctx context.Context, params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", true, "")
+	if err != nil {
+		return "", err
+	}
+	port, err := getInt(params, "port", true, 0)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.CheckAllEndpointsContext(ctx, host, port, network.EndpointsOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeGRPCHealthProfile(params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", false, "localhost")
+	if err != nil {
+		return "", err
+	}
+	port, err := getInt(params, "port", true, 0)
+	if err != nil {
+		return "", err
+	}
+	duration, err := getInt(params, "duration", false, 10)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.GRPCHealthProfileWithProgress(host, port, duration, e.progressFor("grpc_health_profile"))
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeAnalyzeGRPCStream(params map[string]interface{}) (string, error) {
+	host, err := getString(params, "host", false, "localhost")
+	if err != nil {
+		return "", err
+	}
+	port, err := getInt(params, "port", true, 0)
+	if err != nil {
+		return "", err
+	}
+	duration, err := getInt(params, "duration", false, 10)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := network.AnalyzeGRPCStreamWithProgress(host, port, duration, e.progressFor("analyze_grpc_stream"))
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+// ============================================================================
+// System Tool Implementations
+// ============================================================================
+
+func (e *Executor) executeScanLog(params map[string]interface{}) (string, error) {
+	path, err := getString(params, "path", true, "")
+	if err != nil {
+		return "", err
+	}
+	patterns, err := getStringSlice(params, "patterns")
+	if err != nil {
+		return "", err
+	}
+	lines, err := getInt(params, "lines", false, 1000)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := system.ScanLog(path, patterns, lines)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+// executeTriageHost runs the host triage battery against the live system.
+func (e *Executor) executeTriageHost(params map[string]interface{}) (string, error) {
+	return toJSON(diagnostics.TriageHost(diagnostics.DefaultHostSources()))
+}
+
+func (e *Executor) executeInspectNetworkBuffers(params map[string]interface{}) (string, error) {
+	result, err := system.InspectNetworkBuffers()
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeExecuteSysctl(params map[string]interface{}) (string, error) {
+	parameter, err := getString(params, "parameter", true, "")
+	if err != nil {
+		return "", err
+	}
+	value, err := getString(params, "value", true, "")
+	if err != nil {
+		return "", err
+	}
+	persist, err := getBool(params, "persist", false, false)
+	if err != nil {
+		return "", err
+	}
+
+	// Bug 1 fix: dry-run validates inputs only; does not execute the real sysctl command.
+	isDryRun, _ := getBool(params, "__dry_run", false, false)
+	if isDryRun {
+		return toJSON(map[string]interface{}{
+			"parameter": parameter,
+			"value":     value,
+			"persist":     persist,
+			"dry_run":     true,
+			"success":     true,
+			"side_effect": false,
+		})
+	}
+
+	result, err := system.ExecuteSysctl(parameter, value, persist)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeReadSysctl(params map[string]interface{}) (string, error) {
+    parameter, err := getString(params, "parameter", true, "")
+    if err != nil {
+        return "", err
+    }
+    result, err := system.ReadSysctl(parameter)
+    if err != nil {
+        return "", err
+    }
+    return toJSON(result)
+}
+
+// executeRestoreSysctlValue restores a sysctl parameter to a previous value.
+// Used internally by the transaction rollback mechanism.
+func (e *Executor) executeRestoreSysctlValue(params map[string]interface{}) (string, error) {
+	parameter, err := getString(params, "parameter", true, "")
+	if err != nil {
+		return "", err
+	}
+	value, err := getString(params, "value", true, "")
+	if err != nil {
+		return "", err
+	}
+
+	if err := system.RestoreSysctlValue(parameter, value); err != nil {
+		return "", err
+	}
+
+	return toJSON(map[string]interface{}{
+		"parameter":      parameter,
+		"restored_value": value,
+		"success":        true,
+		"side_effect":    true,
+	})
+}
+
+// executeRecommendBufferTuning reads the current buffer settings and proposes
+// the sysctl changes needed to cover the bandwidth-delay product for rtt_ms.
+func (e *Executor) executeRecommendBufferTuning(params map[string]interface{}) (string, error) {
+	rttMs, err := getFloat(params, "rtt_ms", false, 0)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := system.InspectNetworkBuffers()
+	if err != nil {
+		return "", err
+	}
+
+	recs := system.RecommendBufferTuning(current, rttMs)
+	return toJSON(map[string]interface{}{
+		"rtt_ms":          rttMs,
+		"recommendations": recs,
+		"count":           len(recs),
+	})
+}
+
+// executeDiagnoseEphemeralPorts reports how much of the ephemeral port range
+// is held in TIME-WAIT and what to change if it is close to exhaustion.
+func (e *Executor) executeDiagnoseEphemeralPorts(params map[string]interface{}) (string, error) {
+	result, err := system.DiagnoseEphemeralPorts()
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+// executeCheckSysctlDrift compares the running kernel parameters with a
+// sysctl.conf-format baseline file.
+func (e *Executor) executeCheckSysctlDrift(params map[string]interface{}) (string, error) {
+	baselinePath, err := getString(params, "baseline_path", true, "")
+	if err != nil {
+		return "", err
+	}
+
+	result, err := system.CheckSysctlDrift(baselinePath)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+// executeInterfaceStats reports per-interface traffic and error counters,
+// optionally sampling twice to derive rates.
+func (e *Executor) executeInterfaceStats(params map[string]interface{}) (string, error) {
+	iface, err := getString(params, "interface", false, "")
+	if err != nil {
+		return "", err
+	}
+	sampleSeconds, err := getInt(params, "sample_seconds", false, 0)
+	if err != nil {
+		return "", err
+	}
+	if sampleSeconds < 0 || sampleSeconds > 10 {
+		return "", fmt.Errorf("sample_seconds must be between 0 and 10, got %d", sampleSeconds)
+	}
+
+	result, err := system.InterfaceStats(iface, time.Duration(sampleSeconds)*time.Second)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+// executeTopProcesses lists the heaviest processes by CPU or memory.
+func (e *Executor) executeTopProcesses(params map[string]interface{}) (string, error) {
+	sortBy, err := getString(params, "sort_by", false, system.SortByCPU)
+	if err != nil {
+		return "", err
+	}
+	limit, err := getInt(params, "limit", false, 10)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := system.TopProcesses(strings.ToLower(sortBy), limit)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+// ============================================================================
+// Debugging Tool Implementations (Placeholder)
+// ============================================================================
+
+func (e *Executor) executeServiceLogs(params map[string]interface{}) (string, error) {
+	service, err := getString(params, "service", true, "")
+	if err != nil {
+		return "", err
+	}
+	lines, err := getInt(params, "lines", false, 100)
+	if err != nil {
+		return "", err
+	}
+	since, err := getString(params, "since", false, "1 hour ago")
+	if err != nil {
+		return "", err
+	}
+
+	result, err := system.ServiceLogs(service, lines, since)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeValidateConfigFile(params map[string]interface{}) (string, error) {
+	path, err := getString(params, "path", true, "")
+	if err != nil {
+		return "", err
+	}
+	format, err := getString(params, "format", false, "")
+	if err != nil {
+		return "", err
+	}
+
+	result, err := system.ValidateConfigFile(path, format)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeFileChecksum(params map[string]interface{}) (string, error) {
+	path, err := getString(params, "path", true, "")
+	if err != nil {
+		return "", err
+	}
+	algorithm, err := getString(params, "algorithm", false, "sha256")
+	if err != nil {
+		return "", err
+	}
+
+	result, err := system.FileChecksum(path, algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeListeningSockets(ctx context.Context, params map[string]interface{}) (string, error) {
+	port, err := getInt(params, "port", false, 0)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := system.ListeningSocketsContext(ctx, port)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+func (e *Executor) executeAnalyzeCoreDump(params map[string]interface{}) (string, error) {
+	corePath, err := getString(params, "core_path", true, "")
+	if err != nil {
+		return "", err
+	}
+	binaryPath, err := getString(params, "binary_path", false, "")
+	if err != nil {
+		return "", err
+	}
+	rawLines, err := getInt(params, "raw_output_lines", false, 0)
+	if err != nil {
+		return "", err
+	}
+	extraCommands, err := getStringSlice(params, "extra_commands")
+	if err != nil {
+		return "", err
+	}
+	maxThreads, err := getInt(params, "max_threads", false, 0)
+	if err != nil {
+		return "", err
+	}
+	maxFrames, err := getInt(params, "max_frames_per_thread", false, 0)
+	if err != nil {
+		return "", err
+	}
+	maxBytes, err := getInt(params, "max_parse_bytes", false, 0)
+	if err != nil {
+		return "", err
+	}
+
+	// Import from debugging package
+	result, err := debugging.AnalyzeCoreDumpWithOptions(corePath, binaryPath, debugging.CoreDumpOptions{
+		RawOutputLines: rawLines,
+		ExtraCommands:  extraCommands,
+		Limits: debugging.ParseLimits{
+			MaxThreads:         maxThreads,
+			MaxFramesPerThread: maxFrames,
+			MaxBytes:           maxBytes,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(result)
+}
+
+// ============================================================================
+// Utilities
+// ============================================================================
+
+// withSideEffect adds "side_effect" to a JSON object result that does not
+// report one, so every result says whether the system was changed. The
+// default comes from the function's registry phase: a modify function that
+// forgets to report is assumed to have changed the system. The field is
+// appended to the original text so the function's key order is kept.
+func (e *Executor) withSideEffect(function, out string) string {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(out), &obj); err != nil || obj == nil {
+		return out
+	}
+	if _, ok := obj["side_effect"]; ok {
+		return out
+	}
+
+	field := `"side_effect":false`
+	if e.registry != nil {
+		if def, ok := e.registry.Get(function); ok && def.Phase == types.PhaseModify {
+			field = `"side_effect":true`
+		}
+	}
+
+	body := strings.TrimRight(out, " \t\r\n")
+	body = strings.TrimSuffix(body, "}")
+	if len(obj) > 0 {
+		field = "," + field
+	}
+	return body + field + "}"
+}
 
 func toJSON(v interface{}) (string, error) {
 	b, err := json.Marshal(v)
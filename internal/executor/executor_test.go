@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSetProgress_ConcurrentWithRunningFunctions(t *testing.T) {
+	exec := NewExecutor(zap.NewNop())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				exec.SetProgress(func(function, line string) {})
+				exec.SetProgress(nil)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if report := exec.progressFor("traceroute"); report != nil {
+					report("hop 1")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,209 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/friday/internal/types"
+)
+
+// ErrPhaseVetoed is returned when a Hooks.BeforePhase callback refuses to
+// let a phase start. The callback's own error is wrapped alongside it.
+var ErrPhaseVetoed = errors.New("phase vetoed")
+
+// Hooks lets a caller follow a transaction phase by phase and function by
+// function, and stop it before a phase starts. Any callback may be nil.
+//
+// The read phase is always reported; analyze and modify only when they
+// have functions.
+type Hooks struct {
+	// BeforePhase is called as a phase is about to start, with the calls it
+	// will run. For the modify phase this is after the pre-modify gate has
+	// validated the calls and before the operator is asked to approve them,
+	// and is not done for a dry run. Returning an error aborts the
+	// transaction with ErrPhaseVetoed; nothing in that or any later phase
+	// runs.
+	BeforePhase func(phase string, fns []types.FunctionCall) error
+
+	// AfterPhase is called when a phase has run, with its results, whether
+	// or not it succeeded and before any rollback. It is not called for a
+	// modify phase that a dry run or the gate stopped short of running.
+	AfterPhase func(phase string, results []FunctionResult)
+
+	// FunctionDone is called as each function in a phase finishes, fails
+	// or is skipped, with its 1-based position in the phase.
+	FunctionDone func(index int, result FunctionResult)
+
+	// SnapshotFailed is called when the state a modify function is about
+	// to change could not be captured, so the change will not be reversible.
+	SnapshotFailed func(index int, name string, err error)
+
+	// BeforeGate is called before the pre-modify gate dry-runs the modify
+	// calls.
+	BeforeGate func(fns []types.FunctionCall)
+
+	// GatePassed is called when every modify call has passed its dry run,
+	// with the operations the operator will be asked to approve.
+	GatePassed func(previews []OperationPreview)
+
+	// Finished is called once however the transaction ends, with its
+	// summary and the error ExecuteTransaction returns.
+	Finished func(summary *types.TransactionSummary, err error)
+}
+
+// BannerHooks returns the hooks used when a TransactionRequest has none:
+// they print a banner as each phase and the pre-modify gate starts, a line
+// per function, a one-line outcome as each phase ends, and how the
+// transaction finished.
+func BannerHooks(out io.Writer) *Hooks {
+	return &Hooks{
+		BeforePhase: func(phase string, _ []types.FunctionCall) error {
+			fmt.Fprintln(out, phaseBanners[phase])
+			return nil
+		},
+		AfterPhase: func(phase string, results []FunctionResult) {
+			name := phaseNames[phase]
+			if failed := countFailed(results); failed > 0 {
+				fmt.Fprintf(out, "⚠  %s phase had %d failure(s)\n", name, failed)
+				return
+			}
+			fmt.Fprintf(out, " %s phase complete (%d function(s))\n", name, len(results))
+		},
+		FunctionDone: func(index int, r FunctionResult) {
+			switch {
+			case r.Skipped:
+				fmt.Fprintf(out, "  ↷ [%d] %s (skipped dependency failed)\n", index, r.FunctionName)
+			case r.Success:
+				fmt.Fprintf(out, "   [%d] %s  (%.2fs)\n", index, r.FunctionName, r.Duration.Seconds())
+			default:
+				fmt.Fprintf(out, "  [%d] %s FAILED (%v)\n", index, r.FunctionName, r.Error)
+			}
+		},
+		SnapshotFailed: func(index int, name string, err error) {
+			fmt.Fprintf(out, "  ⚠  [%d] %s snapshot failed (%v); operation will not be reversible\n",
+				index, name, err)
+		},
+		BeforeGate: func(_ []types.FunctionCall) {
+			fmt.Fprintln(out, "\n── Gate 4: PRE-MODIFY VALIDATION ────────────────────────────")
+			fmt.Fprintln(out, "Validating modify operations …")
+		},
+		GatePassed: func(_ []OperationPreview) {
+			fmt.Fprint(out, " Dry-run validation passed.\n\n")
+		},
+		Finished: func(summary *types.TransactionSummary, err error) {
+			if summary.TimedOut {
+				fmt.Fprintf(out, "⚠  %v; returning partial results\n", err)
+			}
+			switch summary.Status {
+			case types.TxCommitted:
+				fmt.Fprintln(out, "\n Transaction committed successfully.")
+			case types.TxDryRun:
+				fmt.Fprintln(out, " Dry-run complete. No changes were made (--dry-run mode).")
+			case types.TxDeclined:
+				fmt.Fprintln(out, "Aborted by operator no changes were made.")
+			case types.TxRolledBack:
+				fmt.Fprintln(out, "\n⚠  Modify phase failed; changes were rolled back.")
+				fmt.Fprintln(out, " Rollback complete system restored to previous state.")
+			case types.TxRollbackFailed:
+				fmt.Fprintln(out, "\n⚠  Modify phase failed and rollback did not complete.")
+				fmt.Fprintf(out, "⚠  Rollback error (manual intervention may be required): %s\n", summary.RollbackError)
+			}
+		},
+	}
+}
+
+var phaseBanners = map[string]string{
+	PhaseRead:    "\n── Phase 1: READ ─────────────────────────────────────────────",
+	PhaseAnalyze: "\n── Phase 2: ANALYZE ──────────────────────────────────────────",
+	PhaseModify:  "\n── Phase 3: MODIFY ───────────────────────────────────────────",
+}
+
+var phaseNames = map[string]string{
+	PhaseRead:    "Read",
+	PhaseAnalyze: "Analyze",
+	PhaseModify:  "Modify",
+}
+
+func countFailed(results []FunctionResult) int {
+	failed := 0
+	for _, r := range results {
+		if !r.Success && !r.Skipped {
+			failed++
+		}
+	}
+	return failed
+}
+
+// hooksFor returns req's hooks, or banners on stdout when it has none.
+func hooksFor(req TransactionRequest) *Hooks {
+	if req.Hooks == nil {
+		return BannerHooks(os.Stdout)
+	}
+	return req.Hooks
+}
+
+// beforePhase asks h whether phase may start.
+func (h *Hooks) beforePhase(phase string, fns []phasedCall) error {
+	if h.BeforePhase == nil {
+		return nil
+	}
+	calls := make([]types.FunctionCall, len(fns))
+	for i, pc := range fns {
+		calls[i] = pc.FunctionCall
+	}
+	if err := h.BeforePhase(phase, calls); err != nil {
+		return fmt.Errorf("%w: %s phase: %w", ErrPhaseVetoed, phase, err)
+	}
+	return nil
+}
+
+// beforeGate tells h the pre-modify gate is starting.
+func (h *Hooks) beforeGate(fns []phasedCall) {
+	if h.BeforeGate == nil {
+		return
+	}
+	calls := make([]types.FunctionCall, len(fns))
+	for i, pc := range fns {
+		calls[i] = pc.FunctionCall
+	}
+	h.BeforeGate(calls)
+}
+
+// functionDone tells h that the function at index (0-based) in its phase
+// has finished.
+func (h *Hooks) functionDone(index int, result FunctionResult) {
+	if h.FunctionDone != nil {
+		h.FunctionDone(index+1, result)
+	}
+}
+
+// snapshotFailed tells h that the modify function at index (0-based) has no
+// snapshot.
+func (h *Hooks) snapshotFailed(index int, name string, err error) {
+	if h.SnapshotFailed != nil {
+		h.SnapshotFailed(index+1, name, err)
+	}
+}
+
+// gatePassed tells h that the modify calls passed their dry runs.
+func (h *Hooks) gatePassed(previews []OperationPreview) {
+	if h.GatePassed != nil {
+		h.GatePassed(previews)
+	}
+}
+
+// finished tells h how the transaction ended.
+func (h *Hooks) finished(summary *types.TransactionSummary, err error) {
+	if h.Finished != nil {
+		h.Finished(summary, err)
+	}
+}
+
+// afterPhase tells h that phase has run.
+func (h *Hooks) afterPhase(phase string, results []FunctionResult) {
+	if h.AfterPhase != nil {
+		h.AfterPhase(phase, results)
+	}
+}
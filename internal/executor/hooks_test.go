@@ -0,0 +1,221 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/types"
+)
+
+// hookRecorder builds hooks that log each callback as "before:phase:fns",
+// "after:phase:results", "gate:fns" or "finished:status", and veto the
+// phases listed in veto.
+type hookRecorder struct {
+	events []string
+	veto   map[string]error
+}
+
+func (r *hookRecorder) hooks() *Hooks {
+	return &Hooks{
+		BeforePhase: func(phase string, fns []types.FunctionCall) error {
+			names := make([]string, len(fns))
+			for i, fn := range fns {
+				names[i] = fn.Name
+			}
+			r.events = append(r.events, fmt.Sprintf("before:%s:%s", phase, strings.Join(names, ",")))
+			return r.veto[phase]
+		},
+		AfterPhase: func(phase string, results []FunctionResult) {
+			r.events = append(r.events, fmt.Sprintf("after:%s:%d", phase, len(results)))
+		},
+		BeforeGate: func(fns []types.FunctionCall) {
+			names := make([]string, len(fns))
+			for i, fn := range fns {
+				names[i] = fn.Name
+			}
+			r.events = append(r.events, "gate:"+strings.Join(names, ","))
+		},
+		Finished: func(summary *types.TransactionSummary, _ error) {
+			r.events = append(r.events, "finished:"+summary.Status)
+		},
+	}
+}
+
+func newHooksTestEngine(runner FunctionRunner, snaps SnapshotStore) *TransactionEngine {
+	return &TransactionEngine{
+		executor:        runner,
+		resolver:        NewVariableResolver(),
+		snapshotManager: snaps,
+		registry:        stubPhases{"inspect": PhaseAnalyze, "set_a": PhaseModify},
+	}
+}
+
+func TestExecuteTransaction_HooksSeePhasesInOrder(t *testing.T) {
+	rec := &hookRecorder{}
+	te := newHooksTestEngine(&recordingRunner{}, &fakeSnapshots{})
+
+	_, err := te.ExecuteTransaction(context.Background(), TransactionRequest{
+		Functions: []types.FunctionCall{{Name: "set_a"}, {Name: "inspect"}, {Name: "read_a"}, {Name: "read_b"}},
+		Confirmer: &autoConfirmer{approve: true},
+		Hooks:     rec.hooks(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "before:read:read_a,read_b after:read:2 before:analyze:inspect after:analyze:1 gate:set_a before:modify:set_a after:modify:1 finished:committed"
+	if got := strings.Join(rec.events, " "); got != want {
+		t.Errorf("expected hooks called as\n  %s\ngot\n  %s", want, got)
+	}
+}
+
+func TestExecuteTransaction_HookVetoesModify(t *testing.T) {
+	policy := errors.New("changes are frozen")
+	rec := &hookRecorder{veto: map[string]error{PhaseModify: policy}}
+	runner := &recordingRunner{}
+	snaps := &fakeSnapshots{}
+	confirmer := &autoConfirmer{approve: true}
+	te := newHooksTestEngine(runner, snaps)
+
+	results, summary, err := te.ExecuteTransactionWithSummary(context.Background(), TransactionRequest{
+		Functions: []types.FunctionCall{{Name: "read_a"}, {Name: "set_a"}},
+		Confirmer: confirmer,
+		Hooks:     rec.hooks(),
+	})
+
+	if !errors.Is(err, ErrPhaseVetoed) || !errors.Is(err, policy) {
+		t.Fatalf("expected the veto to abort the transaction, got %v", err)
+	}
+	if summary.Status != types.TxAborted {
+		t.Errorf("expected an aborted transaction, got %q", summary.Status)
+	}
+	if len(results) != 1 || results[0].FunctionName != "read_a" {
+		t.Errorf("expected only the read's result, got %+v", results)
+	}
+	if strings.Join(runner.calls, ",") != "read_a" || len(snaps.taken) != 0 {
+		t.Errorf("expected nothing modified, got calls %v, snapshots %v", runner.calls, snaps.taken)
+	}
+	if confirmer.seen != nil {
+		t.Error("expected the operator not to be asked once the modify phase was vetoed")
+	}
+	if got := strings.Join(rec.events[len(rec.events)-2:], " "); got != "before:modify:set_a finished:aborted" {
+		t.Errorf("expected no after-modify callback, got %v", rec.events)
+	}
+}
+
+func TestExecuteTransaction_FinishedOnEveryExit(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    TransactionRequest
+		runner FunctionRunner
+		veto   map[string]error
+		status string
+	}{
+		{
+			name:   "veto",
+			req:    TransactionRequest{Functions: []types.FunctionCall{{Name: "read_a"}}},
+			runner: &recordingRunner{},
+			veto:   map[string]error{PhaseRead: errors.New("no")},
+			status: types.TxAborted,
+		},
+		{
+			name: "declined",
+			req: TransactionRequest{
+				Functions: []types.FunctionCall{{Name: "set_a"}},
+				Confirmer: &autoConfirmer{approve: false},
+			},
+			runner: &recordingRunner{},
+			status: types.TxDeclined,
+		},
+		{
+			name: "stop_on_error",
+			req: TransactionRequest{
+				Functions: []types.FunctionCall{{Name: "read_a"}, {Name: "read_b"}},
+				Strategy:  StrategyStopOnError,
+			},
+			runner: &flakyRunner{failures: map[string]int{"read_a": 1}, err: errors.New("boom")},
+			status: types.TxAborted,
+		},
+		{
+			name: "timeout",
+			req: TransactionRequest{
+				Functions:             []types.FunctionCall{{Name: "slow_read"}, {Name: "inspect"}},
+				MaxTransactionSeconds: 1,
+			},
+			runner: &slowRunner{delays: map[string]time.Duration{"slow_read": 2 * time.Second}},
+			status: types.TxAborted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var finished []string
+			rec := &hookRecorder{veto: tt.veto}
+			hooks := rec.hooks()
+			hooks.Finished = func(summary *types.TransactionSummary, err error) {
+				finished = append(finished, fmt.Sprintf("%s:%t", summary.Status, err != nil))
+			}
+			tt.req.Hooks = hooks
+
+			_, _, err := newHooksTestEngine(tt.runner, &fakeSnapshots{}).
+				ExecuteTransactionWithSummary(context.Background(), tt.req)
+			if err == nil {
+				t.Fatal("expected the transaction to fail")
+			}
+			if want := tt.status + ":true"; len(finished) != 1 || finished[0] != want {
+				t.Errorf("expected Finished called once with %s, got %v", want, finished)
+			}
+		})
+	}
+}
+
+func TestExecuteTransaction_PartialHooks(t *testing.T) {
+	var phases []string
+	te := newHooksTestEngine(&recordingRunner{}, &fakeSnapshots{})
+
+	_, err := te.ExecuteTransaction(context.Background(), TransactionRequest{
+		Functions: []types.FunctionCall{{Name: "read_a"}, {Name: "inspect"}},
+		Hooks: &Hooks{AfterPhase: func(phase string, _ []FunctionResult) {
+			phases = append(phases, phase)
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(phases, ",") != "read,analyze" {
+		t.Errorf("expected after-phase callbacks for read and analyze, got %v", phases)
+	}
+}
+
+func TestBannerHooks(t *testing.T) {
+	var out bytes.Buffer
+	hooks := BannerHooks(&out)
+
+	if err := hooks.BeforePhase(PhaseRead, nil); err != nil {
+		t.Fatalf("banner hook vetoed a phase: %v", err)
+	}
+	hooks.AfterPhase(PhaseRead, []FunctionResult{{Success: true}, {Success: true}})
+	hooks.FunctionDone(1, FunctionResult{FunctionName: "inspect", Error: errors.New("boom")})
+	hooks.FunctionDone(2, FunctionResult{FunctionName: "summarise", Skipped: true})
+	hooks.AfterPhase(PhaseAnalyze, []FunctionResult{{Success: true}, {Error: errors.New("boom")}, {Skipped: true}})
+	hooks.BeforeGate(nil)
+	hooks.GatePassed(nil)
+	hooks.Finished(&types.TransactionSummary{Status: types.TxRolledBack}, errors.New("modify phase failed"))
+
+	got := out.String()
+	for _, want := range []string{
+		"Phase 1: READ", " Read phase complete (2 function(s))", "[1] inspect FAILED (boom)",
+		"↷ [2] summarise (skipped dependency failed)", "Analyze phase had 1 failure(s)",
+		"Gate 4: PRE-MODIFY VALIDATION", "Dry-run validation passed.",
+		"Modify phase failed; changes were rolled back.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in banner output, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/friday/internal/types"
+)
+
+// limitTargetParams are the params, in order of preference, that name the
+// host a function probes: a bare host, a URL, a host:port target, or a
+// list of host:port targets. Calls without one are not limited.
+var limitTargetParams = []string{"host", "url", "target", "targets"}
+
+// TargetLimiter caps how many calls run at once against the same host,
+// so parallel reads do not hammer one target. Calls over the cap wait for
+// a slot.
+type TargetLimiter struct {
+	max int
+
+	mu      sync.Mutex
+	targets map[string]*targetSlots
+}
+
+type targetSlots struct {
+	sem   chan struct{}
+	users int
+}
+
+// NewTargetLimiter creates a limiter allowing max concurrent calls per
+// target.
+func NewTargetLimiter(max int) *TargetLimiter {
+	return &TargetLimiter{max: max, targets: make(map[string]*targetSlots)}
+}
+
+// Acquire waits for a slot for target and returns the function that frees
+// it, or an error if ctx is done first.
+func (l *TargetLimiter) Acquire(ctx context.Context, target string) (release func(), err error) {
+	l.mu.Lock()
+	t := l.targets[target]
+	if t == nil {
+		t = &targetSlots{sem: make(chan struct{}, l.max)}
+		l.targets[target] = t
+	}
+	t.users++
+	l.mu.Unlock()
+
+	done := func() {
+		l.mu.Lock()
+		t.users--
+		if t.users == 0 {
+			delete(l.targets, target)
+		}
+		l.mu.Unlock()
+	}
+
+	select {
+	case t.sem <- struct{}{}:
+		return func() {
+			<-t.sem
+			done()
+		}, nil
+	case <-ctx.Done():
+		done()
+		return nil, fmt.Errorf("waiting for a free slot for %s: %w", target, ctx.Err())
+	}
+}
+
+// AcquireAll acquires a slot for each of targets, in sorted order so two
+// calls sharing hosts cannot each hold one the other waits for, and
+// returns the function that frees them all.
+func (l *TargetLimiter) AcquireAll(ctx context.Context, targets []string) (release func(), err error) {
+	sorted := slices.Clone(targets)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	releases := make([]func(), 0, len(sorted))
+	release = func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+	for _, target := range sorted {
+		r, err := l.Acquire(ctx, target)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return release, nil
+}
+
+// limitTargets returns the lowercased hosts fn probes, or none if it names
+// none. A param fn leaves out falls back to its default in def, which may
+// be nil.
+func limitTargets(fn types.FunctionCall, def *types.FunctionDefinition) []string {
+	for _, param := range limitTargetParams {
+		v, ok := fn.Params[param]
+		if !ok && def != nil {
+			v = paramDefault(def, param)
+		}
+		var hosts []string
+		switch param {
+		case "host":
+			if s, _ := v.(string); s != "" {
+				hosts = append(hosts, s)
+			}
+		case "url":
+			hosts = appendURLHost(hosts, v)
+		case "target":
+			hosts = appendAddrHost(hosts, v)
+		case "targets":
+			switch list := v.(type) {
+			case []interface{}:
+				for _, t := range list {
+					hosts = appendAddrHost(hosts, t)
+				}
+			case []string:
+				for _, t := range list {
+					hosts = appendAddrHost(hosts, t)
+				}
+			}
+		}
+		if len(hosts) > 0 {
+			for i, h := range hosts {
+				hosts[i] = strings.ToLower(h)
+			}
+			return hosts
+		}
+	}
+	return nil
+}
+
+// paramDefault returns the declared default of def's param, or nil.
+func paramDefault(def *types.FunctionDefinition, param string) interface{} {
+	for _, p := range def.Parameters {
+		if p.Name == param {
+			return p.Default
+		}
+	}
+	return nil
+}
+
+// appendURLHost appends the host of v, a URL with or without a scheme.
+func appendURLHost(hosts []string, v interface{}) []string {
+	s, _ := v.(string)
+	if s == "" {
+		return hosts
+	}
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	if u, err := url.Parse(s); err == nil && u.Hostname() != "" {
+		return append(hosts, u.Hostname())
+	}
+	return hosts
+}
+
+// appendAddrHost appends the host of v, a host:port address or bare host.
+// unix:// sockets are local and not limited.
+func appendAddrHost(hosts []string, v interface{}) []string {
+	s, _ := v.(string)
+	if s == "" || strings.HasPrefix(s, "unix://") {
+		return hosts
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	if s == "" {
+		return hosts
+	}
+	return append(hosts, s)
+}
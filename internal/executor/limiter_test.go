@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/types"
+	"go.uber.org/zap"
+)
+
+func TestTargetLimiter_CapsInFlightPerTarget(t *testing.T) {
+	l := NewTargetLimiter(4)
+	var inFlight, peak, otherPeak int32
+	var otherInFlight int32
+
+	// handle is a counting fake handler: it records how many calls against
+	// its target overlap.
+	handle := func(target string) {
+		release, err := l.Acquire(context.Background(), target)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer release()
+		counter, max := &inFlight, &peak
+		if target != "10.0.0.5" {
+			counter, max = &otherInFlight, &otherPeak
+		}
+		n := atomic.AddInt32(counter, 1)
+		for {
+			p := atomic.LoadInt32(max)
+			if n <= p || atomic.CompareAndSwapInt32(max, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(counter, -1)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		target := "10.0.0.5"
+		if i%5 == 0 {
+			target = "10.0.0.6"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handle(target)
+		}()
+	}
+	wg.Wait()
+
+	if peak > 4 {
+		t.Errorf("expected up to 4 concurrent calls to 10.0.0.5, peaked at %d", peak)
+	}
+	if otherPeak > 4 {
+		t.Errorf("expected up to 4 concurrent calls to 10.0.0.6, peaked at %d", otherPeak)
+	}
+	if len(l.targets) != 0 {
+		t.Errorf("expected idle targets forgotten, got %d", len(l.targets))
+	}
+}
+
+func TestExecute_WaitsForTargetSlot(t *testing.T) {
+	l := NewTargetLimiter(1)
+	exec := NewExecutor(zap.NewNop())
+	exec.SetTargetLimiter(l)
+
+	// Hold 127.0.0.1's only slot, so a call against it must queue.
+	release, err := l.Acquire(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = exec.ExecuteContext(ctx, types.FunctionCall{
+		Name:   "tcp_connect_timing",
+		Params: map[string]interface{}{"host": "127.0.0.1", "port": 1, "samples": 1},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call to wait for the held slot, got %v", err)
+	}
+
+	release()
+	if _, err := l.Acquire(context.Background(), "127.0.0.1"); err != nil {
+		t.Errorf("expected the slot free after release, got %v", err)
+	}
+}
+
+func TestLimitTargets(t *testing.T) {
+	def := &types.FunctionDefinition{Parameters: []types.ParameterDefinition{
+		{Name: "host", Default: "localhost"},
+	}}
+	tests := []struct {
+		params map[string]interface{}
+		def    *types.FunctionDefinition
+		want   string
+	}{
+		{map[string]interface{}{"host": "db.internal", "port": 5432}, nil, "db.internal"},
+		{map[string]interface{}{"host": "DB.Internal"}, nil, "db.internal"},
+		{map[string]interface{}{"url": "https://api.example.com:8443/health"}, nil, "api.example.com"},
+		{map[string]interface{}{"url": "api.example.com/health"}, nil, "api.example.com"},
+		{map[string]interface{}{"target": "Router1:57400"}, nil, "router1"},
+		{map[string]interface{}{"targets": []interface{}{"db:5432", "[::1]:80", "unix:///run/docker.sock"}}, nil, "db,::1"},
+		{map[string]interface{}{"port": 22}, def, "localhost"},
+		{map[string]interface{}{"interface": "eth0"}, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(limitTargets(types.FunctionCall{Name: "f", Params: tt.params}, tt.def), ","); got != tt.want {
+			t.Errorf("limitTargets(%v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestTargetLimiter_AcquireAllReleasesOnError(t *testing.T) {
+	l := NewTargetLimiter(1)
+	held, err := l.Acquire(context.Background(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.AcquireAll(ctx, []string{"b", "a", "a"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected to wait for b's held slot, got %v", err)
+	}
+	held()
+
+	release, err := l.AcquireAll(context.Background(), []string{"b", "a"})
+	if err != nil {
+		t.Fatalf("expected a's slot freed after the failed call, got %v", err)
+	}
+	release()
+	if len(l.targets) != 0 {
+		t.Errorf("expected idle targets forgotten, got %d", len(l.targets))
+	}
+}
@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/friday/internal/types"
+)
+
+// PlanVersion is the plan file format written by SavePlan.
+const PlanVersion = 1
+
+// Plan is a chain of function calls saved for replay without the LLM.
+//
+// Params are stored as proposed, before variable resolution, so a value that
+// came from an earlier step stays a ${function.field} reference and is
+// resolved afresh against each replay's own results.
+type Plan struct {
+	Version   int                  `json:"version"`
+	CreatedAt time.Time            `json:"created_at"`
+	Query     string               `json:"query,omitempty"`
+	Strategy  ExecutionStrategy    `json:"strategy,omitempty"`
+	Functions []types.FunctionCall `json:"functions"`
+}
+
+// NewPlan records fns as a plan. Params are copied, and internal "__" params
+// such as __dry_run are dropped.
+func NewPlan(query string, strategy ExecutionStrategy, fns []types.FunctionCall) *Plan {
+	p := &Plan{
+		Version:   PlanVersion,
+		CreatedAt: time.Now().UTC(),
+		Query:     query,
+		Strategy:  strategy,
+		Functions: make([]types.FunctionCall, 0, len(fns)),
+	}
+	for _, fn := range fns {
+		params := make(map[string]interface{}, len(fn.Params))
+		for k, v := range fn.Params {
+			if !strings.HasPrefix(k, "__") {
+				params[k] = v
+			}
+		}
+		fn.Params = params
+		fn.DependsOn = append([]int(nil), fn.DependsOn...)
+		p.Functions = append(p.Functions, fn)
+	}
+	return p
+}
+
+// Request returns the transaction request that replays p.
+func (p *Plan) Request() TransactionRequest {
+	return TransactionRequest{Functions: p.Functions, Strategy: p.Strategy}
+}
+
+// SavePlan writes p to path as indented JSON.
+func SavePlan(path string, p *Plan) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadPlan reads a plan written by SavePlan and checks that it can be run:
+// a known version, at least one named function, a known strategy, and
+// depends_on indexes that point at another function in the plan.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read plan %s: %w", path, err)
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid plan %s: %w", path, err)
+	}
+
+	if p.Version != PlanVersion {
+		return nil, fmt.Errorf("plan %s has unsupported version %d (want %d)", path, p.Version, PlanVersion)
+	}
+	if len(p.Functions) == 0 {
+		return nil, fmt.Errorf("plan %s has no functions", path)
+	}
+	switch p.Strategy {
+	case "", StrategyStopOnError, StrategySkipOnError, StrategyRetryWithLLM, StrategyAskUser:
+	default:
+		return nil, fmt.Errorf("plan %s has unknown strategy '%s'", path, p.Strategy)
+	}
+	for i, fn := range p.Functions {
+		if fn.Name == "" {
+			return nil, fmt.Errorf("plan %s: function %d has no name", path, i)
+		}
+		for _, dep := range fn.DependsOn {
+			if dep < 0 || dep >= len(p.Functions) || dep == i {
+				return nil, fmt.Errorf("plan %s: function %d (%s) has invalid depends_on %d",
+					path, i, fn.Name, dep)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// ExecutionResults flattens transaction results into the form shown to the
+// user, numbering them in execution order.
+func ExecutionResults(txResults []FunctionResult) []types.ExecutionResult {
+	var results []types.ExecutionResult
+	for i, fr := range txResults {
+		outputStr := ""
+		if fr.Output != nil {
+			if b, jsonErr := json.Marshal(fr.Output); jsonErr == nil {
+				outputStr = string(b)
+			}
+		}
+		errStr := ""
+		if fr.Error != nil {
+			errStr = fr.Error.Error()
+		}
+		retries := 0
+		if fr.Attempts > 1 {
+			retries = fr.Attempts - 1
+		}
+		results = append(results, types.ExecutionResult{
+			Index:      i,
+			Function:   types.FunctionCall{Name: fr.FunctionName, Params: fr.ResolvedParams},
+			Output:     outputStr,
+			Success:    fr.Success,
+			Error:      errStr,
+			Duration:   fr.Duration,
+			RetryCount: retries,
+			SideEffect: fr.SideEffect,
+			Unredacted: fr.Unredacted,
+		})
+	}
+	return results
+}
@@ -0,0 +1,147 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/types"
+	"go.uber.org/zap"
+)
+
+// twoStepChain looks up a port and then probes it, the second step taking
+// its port from the first step's output.
+func twoStepChain() []types.FunctionCall {
+	return []types.FunctionCall{
+		{Name: "lookup_service", Params: map[string]interface{}{"service": "orders"}},
+		{
+			Name: "probe_port",
+			Params: map[string]interface{}{
+				"host":      "localhost",
+				"port":      "${lookup_service.port}",
+				"__dry_run": true,
+			},
+			Critical:  true,
+			DependsOn: []int{0},
+		},
+	}
+}
+
+func TestPlan_RoundTrip(t *testing.T) {
+	fns := twoStepChain()
+	plan := NewPlan("is the orders service reachable?", StrategyStopOnError, fns)
+
+	if _, ok := plan.Functions[1].Params["__dry_run"]; ok {
+		t.Error("expected internal __dry_run param to be dropped")
+	}
+	if _, ok := fns[1].Params["__dry_run"]; !ok {
+		t.Error("expected NewPlan to leave the caller's params untouched")
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := SavePlan(path, plan); err != nil {
+		t.Fatalf("SavePlan returned error: %v", err)
+	}
+	loaded, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan returned error: %v", err)
+	}
+
+	if loaded.Query != plan.Query || loaded.Strategy != StrategyStopOnError || !loaded.CreatedAt.Equal(plan.CreatedAt) {
+		t.Errorf("metadata changed in round trip: %+v", loaded)
+	}
+	if !reflect.DeepEqual(loaded.Functions, plan.Functions) {
+		t.Errorf("functions changed in round trip:\nsaved  %+v\nloaded %+v", plan.Functions, loaded.Functions)
+	}
+	if got := loaded.Functions[1].Params["port"]; got != "${lookup_service.port}" {
+		t.Errorf("expected the reference to stay templated, got %v", got)
+	}
+}
+
+func TestLoadPlan_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not json":         `functions: []`,
+		"future version":   `{"version":2,"functions":[{"name":"ping"}]}`,
+		"no functions":     `{"version":1,"functions":[]}`,
+		"unnamed function": `{"version":1,"functions":[{"params":{}}]}`,
+		"unknown strategy": `{"version":1,"strategy":"yolo","functions":[{"name":"ping"}]}`,
+		"self dependency":  `{"version":1,"functions":[{"name":"ping","depends_on":[0]}]}`,
+		"missing target":   `{"version":1,"functions":[{"name":"ping"},{"name":"netinfo","depends_on":[5]}]}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plan.json")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPlan(path); err == nil {
+				t.Error("expected LoadPlan to reject the plan")
+			}
+		})
+	}
+}
+
+// stubRunner returns canned JSON per function and records the params each
+// call arrived with.
+type stubRunner struct {
+	outputs map[string]string
+	calls   []types.FunctionCall
+}
+
+func (s *stubRunner) Execute(fn types.FunctionCall) (string, error) {
+	s.calls = append(s.calls, fn)
+	out, ok := s.outputs[fn.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown function: %s", fn.Name)
+	}
+	return out, nil
+}
+
+func TestReplayPlan_TwoStepChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := SavePlan(path, NewPlan("", StrategyStopOnError, twoStepChain())); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan returned error: %v", err)
+	}
+
+	runner := &stubRunner{outputs: map[string]string{
+		"lookup_service": `{"service":"orders","port":50051}`,
+		"probe_port":     `{"open":true}`,
+	}}
+	te := NewTransactionExecutor(NewExecutor(zap.NewNop()))
+	te.executor = runner
+
+	// Replay twice: each run resolves the reference against its own results.
+	for run := 1; run <= 2; run++ {
+		results, summary, err := te.ExecuteTransactionWithSummary(context.Background(), plan.Request())
+		if err != nil {
+			t.Fatalf("run %d: replay failed: %v", run, err)
+		}
+		if summary.Status != types.TxCommitted || len(results) != 2 {
+			t.Fatalf("run %d: expected 2 committed results, got %s with %+v", run, summary.Status, results)
+		}
+	}
+
+	if len(runner.calls) != 4 {
+		t.Fatalf("expected 4 executions over two replays, got %d", len(runner.calls))
+	}
+	probe := runner.calls[3]
+	if probe.Name != "probe_port" || fmt.Sprint(probe.Params["port"]) != "50051" {
+		t.Errorf("expected probe_port with the resolved port 50051, got %s %v", probe.Name, probe.Params)
+	}
+	if got := plan.Functions[1].Params["port"]; got != "${lookup_service.port}" {
+		t.Errorf("expected the plan to keep its reference after replay, got %v", got)
+	}
+
+	view := ExecutionResults([]FunctionResult{{FunctionName: "probe_port", Error: fmt.Errorf("refused")}})
+	if view[0].Function.Name != "probe_port" || !strings.Contains(view[0].Error, "refused") {
+		t.Errorf("unexpected flattened result %+v", view[0])
+	}
+}
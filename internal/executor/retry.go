@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// RetryPolicy says how a phase retries a function that failed with a
+// transient error (see IsTransient).
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt; 0
+	// never retries.
+	MaxRetries int
+	// Backoff is the wait before the first retry; it doubles before each
+	// one after.
+	Backoff time.Duration
+}
+
+// DefaultRetryPolicies retries read and analyze functions twice, since
+// they are idempotent, and never retries modify functions.
+func DefaultRetryPolicies() map[string]RetryPolicy {
+	return map[string]RetryPolicy{
+		PhaseRead:    {MaxRetries: 2, Backoff: 250 * time.Millisecond},
+		PhaseAnalyze: {MaxRetries: 2, Backoff: 250 * time.Millisecond},
+	}
+}
+
+// ReadRetryPolicies applies one policy to the read and analyze phases.
+func ReadRetryPolicies(maxRetries int, backoff time.Duration) map[string]RetryPolicy {
+	policy := RetryPolicy{MaxRetries: maxRetries, Backoff: backoff}
+	return map[string]RetryPolicy{PhaseRead: policy, PhaseAnalyze: policy}
+}
+
+// retryPolicy returns the policy for phase from policies, or from
+// DefaultRetryPolicies when policies is nil. Modify functions are not
+// idempotent, so they never retry whatever policies says.
+func retryPolicy(policies map[string]RetryPolicy, phase string) RetryPolicy {
+	if phase == PhaseModify {
+		return RetryPolicy{}
+	}
+	if policies == nil {
+		policies = DefaultRetryPolicies()
+	}
+	return policies[phase]
+}
+
+// IsTransient reports whether err is a failure that may well not recur:
+// a timeout, a reset connection, or a temporary DNS failure. Refused
+// connections, missing routes, bad input and open circuits are not.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	switch network.ClassifyConnError(err).Category {
+	case network.ConnErrTimeout, network.ConnErrReset:
+		return true
+	case network.ConnErrDNS:
+		var dnsErr *net.DNSError
+		return errors.As(err, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+	}
+	return false
+}
+
+// runWithRetry runs pc, retrying under policy while it fails with a
+// transient error and ctx allows. The result's Attempts counts every run
+// and its Duration covers them all, backoff included.
+func (te *TransactionEngine) runWithRetry(ctx context.Context, pc phasedCall, policy RetryPolicy) (FunctionResult, error) {
+	start := time.Now()
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		fr, err := te.runOne(ctx, pc)
+		fr.Attempts = attempt
+		if err == nil || attempt > policy.MaxRetries || !IsTransient(err) || ctx.Err() != nil {
+			fr.Duration = time.Since(start)
+			return fr, err
+		}
+
+		fmt.Printf("  ↻ [%s] transient failure (%v); retry %d/%d in %s\n",
+			pc.Name, err, attempt, policy.MaxRetries, backoff)
+		select {
+		case <-ctx.Done():
+			fr.Duration = time.Since(start)
+			return fr, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
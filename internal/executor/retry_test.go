@@ -0,0 +1,137 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/types"
+)
+
+// flakyRunner fails each function its first failures[name] runs with err,
+// then succeeds.
+type flakyRunner struct {
+	failures map[string]int
+	err      error
+	calls    map[string]int
+}
+
+func (r *flakyRunner) Execute(fn types.FunctionCall) (string, error) {
+	if fn.Params["__dry_run"] == true {
+		return `{"dry_run":true}`, nil
+	}
+	if r.calls == nil {
+		r.calls = make(map[string]int)
+	}
+	r.calls[fn.Name]++
+	if r.calls[fn.Name] <= r.failures[fn.Name] {
+		return "", r.err
+	}
+	return `{"ok":true}`, nil
+}
+
+func newFlakyTestEngine(runner *flakyRunner, phases stubPhases) *TransactionEngine {
+	return &TransactionEngine{
+		executor:        runner,
+		resolver:        NewVariableResolver(),
+		snapshotManager: NewSnapshotManager(),
+		registry:        phases,
+	}
+}
+
+var errTransientTimeout = fmt.Errorf("dial tcp 10.0.0.5:443: %w", os.ErrDeadlineExceeded)
+
+func TestRetry_ReadRetriedAfterTransientFailure(t *testing.T) {
+	runner := &flakyRunner{failures: map[string]int{"check_tcp": 1}, err: errTransientTimeout}
+	te := newFlakyTestEngine(runner, stubPhases{})
+
+	results, err := te.ExecuteTransaction(context.Background(), TransactionRequest{
+		Functions:     []types.FunctionCall{{Name: "check_tcp"}},
+		RetryPolicies: map[string]RetryPolicy{PhaseRead: {MaxRetries: 2, Backoff: time.Millisecond}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !results[0].Success || results[0].Attempts != 2 || runner.calls["check_tcp"] != 2 {
+		t.Errorf("expected success on the second attempt, got %+v after %d calls", results[0], runner.calls["check_tcp"])
+	}
+	if got := ExecutionResults(results)[0].RetryCount; got != 1 {
+		t.Errorf("expected RetryCount 1, got %d", got)
+	}
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	runner := &flakyRunner{failures: map[string]int{"check_tcp": 5}, err: errTransientTimeout}
+	te := newFlakyTestEngine(runner, stubPhases{})
+
+	results, _ := te.ExecuteTransaction(context.Background(), TransactionRequest{
+		Functions:     []types.FunctionCall{{Name: "check_tcp"}},
+		RetryPolicies: map[string]RetryPolicy{PhaseRead: {MaxRetries: 2, Backoff: time.Millisecond}},
+	})
+	if results[0].Success || results[0].Attempts != 3 || runner.calls["check_tcp"] != 3 {
+		t.Errorf("expected 3 failed attempts, got %+v", results[0])
+	}
+}
+
+func TestRetry_PermanentFailureNotRetried(t *testing.T) {
+	runner := &flakyRunner{
+		failures: map[string]int{"check_tcp": 1},
+		err:      fmt.Errorf("dial tcp 10.0.0.5:443: %w", syscall.ECONNREFUSED),
+	}
+	te := newFlakyTestEngine(runner, stubPhases{})
+
+	results, _ := te.ExecuteTransaction(context.Background(), TransactionRequest{
+		Functions: []types.FunctionCall{{Name: "check_tcp"}},
+	})
+	if results[0].Success || results[0].Attempts != 1 {
+		t.Errorf("expected a refused connection not to be retried, got %+v", results[0])
+	}
+}
+
+func TestRetry_ModifyNeverRetried(t *testing.T) {
+	runner := &flakyRunner{failures: map[string]int{"set_a": 1}, err: errTransientTimeout}
+	te := newFlakyTestEngine(runner, stubPhases{"set_a": PhaseModify})
+
+	results, err := te.ExecuteTransaction(context.Background(), TransactionRequest{
+		Functions: []types.FunctionCall{{Name: "set_a"}},
+		Strategy:  StrategyStopOnError,
+		Confirmer: &autoConfirmer{approve: true},
+		// Asking for modify retries has no effect.
+		RetryPolicies: map[string]RetryPolicy{PhaseModify: {MaxRetries: 3, Backoff: time.Millisecond}},
+	})
+	if err == nil {
+		t.Fatal("expected the modify failure to fail the transaction")
+	}
+	if runner.calls["set_a"] != 1 || results[0].Attempts != 1 {
+		t.Errorf("expected set_a to run once, got %d calls: %+v", runner.calls["set_a"], results[0])
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", errTransientTimeout, true},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"temporary dns", &net.DNSError{Err: "server misbehaving", Name: "db.internal", IsTemporary: true}, true},
+		{"no such host", &net.DNSError{Err: "no such host", Name: "db.internal", IsNotFound: true}, false},
+		{"refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), false},
+		{"circuit open", fmt.Errorf("%w: check_tcp failed 5 times", ErrCircuitOpen), false},
+		{"bad input", errors.New("missing required parameter: host"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/friday/internal/types"
+	"github.com/friday/internal/validator"
+	"go.uber.org/zap"
+)
+
+// SchemaRegistry abstracts looking up a function's declared parameter schema.
+// *functions.Registry satisfies this.
+type SchemaRegistry interface {
+	Get(name string) (types.FunctionDefinition, bool)
+}
+
+// warnIfDeprecated logs a warning when fn is declared deprecated. The call
+// still runs, so plans and scripts written against it keep working.
+func (e *Executor) warnIfDeprecated(logger *zap.Logger, fn types.FunctionCall) {
+	if e.registry == nil {
+		return
+	}
+	if def, ok := e.registry.Get(fn.Name); ok && def.Stability == types.StabilityDeprecated {
+		logger.Warn("Executing deprecated function", zap.String("name", fn.Name))
+	}
+}
+
+// validateParams checks a call's params against the registry definition
+// (names, required-ness, types, and enum membership) before dispatch so
+// mistakes surface with a precise message rather than as a generic
+// missing-parameter error from inside the handler.
+//
+// Unknown params that look like a typo of a declared param are rejected with
+// a suggestion; other unknown params are logged and ignored. Keys starting with
+// "__" are internal flags (e.g. __dry_run) and are never validated.
+func (e *Executor) validateParams(fn types.FunctionCall) error {
+	return e.validateParamsWith(e.logger, fn)
+}
+
+// validateParamsWith is validateParams logging to logger.
+func (e *Executor) validateParamsWith(logger *zap.Logger, fn types.FunctionCall) error {
+	if e.registry == nil {
+		return nil
+	}
+	def, ok := e.registry.Get(fn.Name)
+	if !ok {
+		return nil
+	}
+
+	declared := make(map[string]types.ParameterDefinition, len(def.Parameters))
+	names := make([]string, 0, len(def.Parameters))
+	for _, p := range def.Parameters {
+		declared[p.Name] = p
+		names = append(names, p.Name)
+	}
+
+	keys := make([]string, 0, len(fn.Params))
+	for k := range fn.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, "__") {
+			continue
+		}
+		if _, ok := declared[key]; ok {
+			continue
+		}
+		if suggestion := validator.ClosestName(key, names, 2); suggestion != "" {
+			return fmt.Errorf("unknown parameter '%s' for %s; did you mean '%s'?", key, fn.Name, suggestion)
+		}
+		logger.Warn("Ignoring unknown parameter",
+			zap.String("function", fn.Name),
+			zap.String("param", key))
+	}
+
+	for _, p := range def.Parameters {
+		v, present := fn.Params[p.Name]
+		if !present {
+			if p.Required {
+				return fmt.Errorf("missing required parameter '%s' for %s", p.Name, fn.Name)
+			}
+			continue
+		}
+		if err := checkParamType(p, v); err != nil {
+			return fmt.Errorf("parameter '%s' for %s: %w", p.Name, fn.Name, err)
+		}
+		if err := checkParamEnum(p, v); err != nil {
+			return fmt.Errorf("parameter '%s' for %s: %w", p.Name, fn.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkParamType verifies v is acceptable for the declared parameter type.
+// It is deliberately as lenient as the getString/getInt/getBool helpers:
+// numeric strings are valid integers because interpolated ${...} values
+// arrive as strings.
+func checkParamType(p types.ParameterDefinition, v interface{}) error {
+	switch p.Type {
+	case "integer":
+		switch t := v.(type) {
+		case int, int64:
+			return nil
+		case float64:
+			if t != math.Trunc(t) {
+				return fmt.Errorf("expected integer, got %v", t)
+			}
+			return nil
+		case string:
+			if _, err := strconv.Atoi(strings.TrimSpace(t)); err != nil {
+				return fmt.Errorf("expected integer, got %q", t)
+			}
+			return nil
+		}
+		return fmt.Errorf("expected integer, got %T", v)
+
+	case "float", "number":
+		switch t := v.(type) {
+		case int, int64, float32, float64:
+			return nil
+		case string:
+			if _, err := strconv.ParseFloat(strings.TrimSpace(t), 64); err != nil {
+				return fmt.Errorf("expected number, got %q", t)
+			}
+			return nil
+		}
+		return fmt.Errorf("expected number, got %T", v)
+
+	case "boolean":
+		switch t := v.(type) {
+		case bool:
+			return nil
+		case string:
+			switch strings.ToLower(t) {
+			case "true", "false", "1", "0":
+				return nil
+			}
+			return fmt.Errorf("expected boolean, got %q", t)
+		}
+		return fmt.Errorf("expected boolean, got %T", v)
+
+	case "string":
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return fmt.Errorf("expected string, got %T", v)
+		}
+		return nil
+
+	case "array":
+		switch v.(type) {
+		case []interface{}, []string, string:
+			return nil
+		}
+		return fmt.Errorf("expected array, got %T", v)
+	}
+
+	// Undeclared or unfamiliar types are not enforced.
+	return nil
+}
+
+// checkParamEnum rejects values outside a parameter's declared Enum. Matching
+// is case-insensitive because handlers normalise case themselves (e.g. the
+// HTTP method is upper-cased before use).
+func checkParamEnum(p types.ParameterDefinition, v interface{}) error {
+	if len(p.Enum) == 0 {
+		return nil
+	}
+	s := fmt.Sprintf("%v", v)
+	for _, allowed := range p.Enum {
+		if strings.EqualFold(s, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("value %q is not allowed; must be one of [%s]", s, strings.Join(p.Enum, ", "))
+}
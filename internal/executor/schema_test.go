@@ -0,0 +1,225 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// mapRegistry is a minimal SchemaRegistry for tests.
+type mapRegistry map[string]types.FunctionDefinition
+
+func (m mapRegistry) Get(name string) (types.FunctionDefinition, bool) {
+	fn, ok := m[name]
+	return fn, ok
+}
+
+func newSchemaTestExecutor() *Executor {
+	return NewExecutorWithRegistry(zap.NewNop(), mapRegistry{
+		"check_tcp_health": {
+			Name: "check_tcp_health",
+			Parameters: []types.ParameterDefinition{
+				{Name: "interface", Type: "string", Required: true},
+				{Name: "port", Type: "integer", Required: true},
+			},
+		},
+		"execute_sysctl_command": {
+			Name: "execute_sysctl_command",
+			Parameters: []types.ParameterDefinition{
+				{Name: "parameter", Type: "string", Required: true},
+				{Name: "value", Type: "string", Required: true},
+				{Name: "persist", Type: "boolean"},
+			},
+		},
+	})
+}
+
+func TestValidateParams_UnknownParamSuggestion(t *testing.T) {
+	ex := newSchemaTestExecutor()
+
+	_, err := ex.Execute(types.FunctionCall{
+		Name:   "check_tcp_health",
+		Params: map[string]interface{}{"interface": "eth0", "portt": 50051},
+	})
+	if err == nil {
+		t.Fatal("expected error for unknown parameter, got nil")
+	}
+	want := "unknown parameter 'portt' for check_tcp_health; did you mean 'port'?"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestValidateParams_WrongType(t *testing.T) {
+	ex := newSchemaTestExecutor()
+
+	_, err := ex.Execute(types.FunctionCall{
+		Name:   "check_tcp_health",
+		Params: map[string]interface{}{"interface": "eth0", "port": "not-a-port"},
+	})
+	if err == nil {
+		t.Fatal("expected error for wrong parameter type, got nil")
+	}
+	if !strings.Contains(err.Error(), "parameter 'port' for check_tcp_health: expected integer") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateParams_MissingRequiredBeforeHandler(t *testing.T) {
+	ex := newSchemaTestExecutor()
+
+	_, err := ex.Execute(types.FunctionCall{
+		Name:   "check_tcp_health",
+		Params: map[string]interface{}{"interface": "eth0"},
+	})
+	if err == nil {
+		t.Fatal("expected error for missing required parameter, got nil")
+	}
+	// The handler's own message is "missing required parameter: port"; the
+	// schema message proves validation ran first.
+	want := "missing required parameter 'port' for check_tcp_health"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestValidateParams_LenientTypes(t *testing.T) {
+	def := types.ParameterDefinition{Name: "port", Type: "integer"}
+	for _, v := range []interface{}{50051, int64(50051), float64(50051), "50051"} {
+		if err := checkParamType(def, v); err != nil {
+			t.Errorf("checkParamType(%v) returned error: %v", v, err)
+		}
+	}
+	if err := checkParamType(def, 1.5); err == nil {
+		t.Error("expected error for non-integral float")
+	}
+
+	boolDef := types.ParameterDefinition{Name: "persist", Type: "boolean"}
+	if err := checkParamType(boolDef, "yes please"); err == nil {
+		t.Error("expected error for non-boolean string")
+	}
+}
+
+func TestValidateParams_InternalFlagsIgnored(t *testing.T) {
+	ex := newSchemaTestExecutor()
+
+	err := ex.validateParams(types.FunctionCall{
+		Name: "execute_sysctl_command",
+		Params: map[string]interface{}{
+			"parameter": "net.core.rmem_max",
+			"value":     "212992",
+			"__dry_run": true,
+		},
+	})
+	if err != nil {
+		t.Errorf("expected __dry_run to be ignored, got: %v", err)
+	}
+}
+
+func TestValidateParams_NoRegistry(t *testing.T) {
+	ex := NewExecutor(zap.NewNop())
+	if err := ex.validateParams(types.FunctionCall{Name: "check_tcp_health"}); err != nil {
+		t.Errorf("expected no validation without a registry, got: %v", err)
+	}
+}
+
+func TestValidateParams_Enum(t *testing.T) {
+	ex := NewExecutorWithRegistry(zap.NewNop(), mapRegistry{
+		"http_request": {
+			Name: "http_request",
+			Parameters: []types.ParameterDefinition{
+				{Name: "url", Type: "string", Required: true},
+				{Name: "method", Type: "string", Enum: []string{"GET", "HEAD", "POST"}},
+			},
+		},
+	})
+
+	t.Run("in enum accepted", func(t *testing.T) {
+		for _, m := range []string{"GET", "head", "POST"} {
+			err := ex.validateParams(types.FunctionCall{
+				Name:   "http_request",
+				Params: map[string]interface{}{"url": "http://localhost", "method": m},
+			})
+			if err != nil {
+				t.Errorf("method %q: unexpected error: %v", m, err)
+			}
+		}
+	})
+
+	t.Run("out of enum rejected", func(t *testing.T) {
+		err := ex.validateParams(types.FunctionCall{
+			Name:   "http_request",
+			Params: map[string]interface{}{"url": "http://localhost", "method": "PATCH"},
+		})
+		if err == nil {
+			t.Fatal("expected error for out-of-enum value, got nil")
+		}
+		if !strings.Contains(err.Error(), "must be one of [GET, HEAD, POST]") {
+			t.Errorf("expected allowed values in error, got: %v", err)
+		}
+	})
+
+	t.Run("no enum unconstrained", func(t *testing.T) {
+		err := ex.validateParams(types.FunctionCall{
+			Name:   "http_request",
+			Params: map[string]interface{}{"url": "anything-goes://at all"},
+		})
+		if err != nil {
+			t.Errorf("expected param without enum to be unconstrained, got: %v", err)
+		}
+	})
+}
+
+func TestExecuteContext_WarnsOnDeprecatedFunction(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	ex := NewExecutorWithRegistry(zap.New(core), mapRegistry{
+		"interface_stats": {Name: "interface_stats", Stability: types.StabilityDeprecated},
+		"netinfo":         {Name: "netinfo", Stability: types.StabilityExperimental},
+	})
+
+	if _, err := ex.ExecuteContext(context.Background(), types.FunctionCall{
+		Name: "interface_stats", Params: map[string]interface{}{"interface": "lo"},
+	}); err != nil {
+		t.Fatalf("expected a deprecated function to still run, got %v", err)
+	}
+	entries := logs.FilterMessage("Executing deprecated function").All()
+	if len(entries) != 1 || entries[0].ContextMap()["name"] != "interface_stats" {
+		t.Fatalf("expected one deprecation warning for interface_stats, got %v", logs.All())
+	}
+
+	ex.ExecuteContext(context.Background(), types.FunctionCall{
+		Name: "netinfo", Params: map[string]interface{}{"interface": "lo"},
+	})
+	if n := logs.FilterMessage("Executing deprecated function").Len(); n != 1 {
+		t.Errorf("expected no warning for an experimental function, got %d warnings", n)
+	}
+}
+
+func TestWithSideEffect_DefaultsFromPhaseAndKeepsKeyOrder(t *testing.T) {
+	ex := NewExecutorWithRegistry(zap.NewNop(), mapRegistry{
+		"apply_change": {Name: "apply_change", Phase: types.PhaseModify},
+		"read_state":   {Name: "read_state", Phase: types.PhaseRead},
+	})
+
+	tests := []struct {
+		function string
+		out      string
+		want     string
+	}{
+		{"read_state", `{"z":1,"a":2}`, `{"z":1,"a":2,"side_effect":false}`},
+		{"apply_change", `{"z":1,"a":2}`, `{"z":1,"a":2,"side_effect":true}`},
+		{"apply_change", `{"side_effect":false,"z":1}`, `{"side_effect":false,"z":1}`},
+		{"unknown", "{}\n", `{"side_effect":false}`},
+		{"read_state", `[1,2]`, `[1,2]`},
+	}
+	for _, tt := range tests {
+		if got := ex.withSideEffect(tt.function, tt.out); got != tt.want {
+			t.Errorf("withSideEffect(%s, %s) = %s, want %s", tt.function, tt.out, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/friday/internal/types"
+)
+
+// sessionPrefix marks a reference to a labeled result saved earlier in the
+// session, e.g. ${session.baseline.retransmits}.
+const sessionPrefix = "session."
+
+// labelPattern is the form a session label must take: it becomes a path
+// segment in ${session.LABEL.field}, so it cannot contain dots.
+var labelPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// SessionStore keeps function results under user-chosen labels for the life
+// of a session, so a later query can reference a result from an earlier one
+// as ${session.LABEL.field}. Unlike the per-transaction results, labels
+// survive across queries and configuration reloads. It is safe for
+// concurrent use.
+type SessionStore struct {
+	mu      sync.RWMutex
+	results map[string]interface{}
+}
+
+// NewSessionStore creates an empty store.
+func NewSessionStore() *SessionStore {
+	return &SessionStore{results: make(map[string]interface{})}
+}
+
+// Label saves the successful results of one query under label, replacing
+// any result already saved there. A single result is stored as its parsed
+// output, so ${session.LABEL.field} reads its fields directly; several are
+// stored by function name, as ${session.LABEL.function.field}.
+func (s *SessionStore) Label(label string, results []types.ExecutionResult) error {
+	if !labelPattern.MatchString(label) {
+		return fmt.Errorf("invalid label '%s': use letters, digits, '_' or '-', starting with a letter", label)
+	}
+
+	byFunction := make(map[string]interface{})
+	var last interface{}
+	for _, r := range results {
+		if !r.Success {
+			continue
+		}
+		last = parseOutput(r.Output)
+		byFunction[r.Function.Name] = last
+	}
+	if len(byFunction) == 0 {
+		return fmt.Errorf("no successful results to save as '%s'", label)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(byFunction) == 1 {
+		s.results[label] = last
+	} else {
+		s.results[label] = byFunction
+	}
+	return nil
+}
+
+// Get returns the result saved under label.
+func (s *SessionStore) Get(label string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.results[label]
+	return v, ok
+}
+
+// Labels returns the saved labels in sorted order.
+func (s *SessionStore) Labels() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	labels := make([]string, 0, len(s.results))
+	for label := range s.results {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// All returns a copy of every saved result by label, for persisting the
+// session.
+func (s *SessionStore) All() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	all := make(map[string]interface{}, len(s.results))
+	for label, v := range s.results {
+		all[label] = v
+	}
+	return all
+}
+
+// Restore adds results saved by All, replacing any already saved under the
+// same labels. Invalid labels are skipped.
+func (s *SessionStore) Restore(results map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for label, v := range results {
+		if labelPattern.MatchString(label) {
+			s.results[label] = v
+		}
+	}
+}
+
+// Clear removes every saved result.
+func (s *SessionStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = make(map[string]interface{})
+}
@@ -39,12 +39,15 @@ type SnapshotManager struct {
 	mu        sync.Mutex
 	snapshots []*Snapshot
 	counter   int
+	// restore applies a snapshot on rollback; restoreSnapshot outside tests.
+	restore func(*Snapshot) error
 }
 
 // NewSnapshotManager creates a ready-to-use SnapshotManager.
 func NewSnapshotManager() *SnapshotManager {
 	return &SnapshotManager{
 		snapshots: make([]*Snapshot, 0),
+		restore:   restoreSnapshot,
 	}
 }
 
@@ -121,7 +124,7 @@ func (sm *SnapshotManager) Rollback() error {
 			continue
 		}
 
-		if err := restoreSnapshot(snap); err != nil {
+		if err := sm.restore(snap); err != nil {
 			errs = append(errs, fmt.Sprintf("snapshot %s (%s/%s): rollback failed: %v",
 				snap.ID, snap.FunctionName, snap.Parameter, err))
 		}
@@ -1,11 +1,10 @@
 package executor
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -14,9 +13,9 @@ import (
 
 // Phase constants matching functions.yaml phase field values.
 const (
-	PhaseRead    = "read"
-	PhaseAnalyze = "analyze"
-	PhaseModify  = "modify"
+	PhaseRead    = types.PhaseRead
+	PhaseAnalyze = types.PhaseAnalyze
+	PhaseModify  = types.PhaseModify
 )
 
 // ExecutionStrategy controls behaviour when a function fails.
@@ -45,29 +44,114 @@ type FunctionResult struct {
 	Duration     time.Duration
 	Skipped      bool
 	Success      bool
+	// Attempts counts the runs, retries included; 0 if it never ran.
+	Attempts int
+	// SideEffect is true when the function reported that it changed the
+	// system (see the side_effect result field); reads and dry runs never do.
+	SideEffect bool
+	// ResolvedParams are the params the function ran with, after ${...}
+	// references were resolved, so a chain can be audited value by value.
+	ResolvedParams map[string]interface{}
+	// Unredacted is the function's output as it was before the engine's
+	// OutputRedactor masked something in it, for local display only. It
+	// is empty when nothing was redacted.
+	Unredacted string
 }
 
 // TransactionRequest is the structured form used when extra options are needed.
 type TransactionRequest struct {
-	Functions         []types.FunctionCall
-	Strategy          ExecutionStrategy
-	ExecutionContext  map[string]interface{}
-	DryRunOnly        bool
-	ConfirmationInput *bufio.Reader
+	Functions        []types.FunctionCall
+	Strategy         ExecutionStrategy
+	ExecutionContext map[string]interface{}
+	DryRunOnly       bool
+
+	// Confirmer approves modify operations before they run. Nil prompts on
+	// the terminal via NewStdinConfirmer.
+	Confirmer Confirmer
+
+	// MaxTransactionSeconds bounds the whole transaction; 0 means no limit.
+	// At the deadline the running function is cancelled, nothing further
+	// starts, completed modify operations are rolled back, and the results
+	// so far are returned with ErrTransactionTimeout.
+	MaxTransactionSeconds int
+
+	// RetryPolicies maps the read and analyze phases to how they retry
+	// functions that fail with a transient error; nil uses
+	// DefaultRetryPolicies. Modify functions are never retried.
+	RetryPolicies map[string]RetryPolicy
+
+	// Hooks are told as each phase starts and ends and may veto a phase.
+	// Nil prints phase banners on stdout via BannerHooks.
+	Hooks *Hooks
+}
+
+// ErrTransactionTimeout is returned when a transaction reaches its
+// MaxTransactionSeconds deadline.
+var ErrTransactionTimeout = errors.New("transaction deadline exceeded")
+
+// FunctionRunner executes one function call and returns its JSON output.
+// *Executor satisfies this.
+type FunctionRunner interface {
+	Execute(fn types.FunctionCall) (string, error)
+}
+
+// contextRunner is implemented by runners that stop early when the
+// transaction's context is cancelled. *Executor satisfies this.
+type contextRunner interface {
+	ExecuteContext(ctx context.Context, fn types.FunctionCall) (string, error)
 }
 
 // PhaseRegistry abstracts looking up a function's declared phase.
-// *functions.Registry satisfies this once Phase(string) string is added to it.
+// *functions.Registry satisfies this.
 type PhaseRegistry interface {
 	Phase(functionName string) string
 }
 
+// SnapshotStore captures state before each modify function and restores
+// it when the modify phase fails. *SnapshotManager satisfies this.
+type SnapshotStore interface {
+	TakeSnapshot(functionName string, params map[string]interface{}) (*Snapshot, error)
+	Rollback() error
+}
+
+// FaultInjector forces modify functions to fail, so the rollback path can
+// be exercised without a system to break. Fault is asked after a
+// function's snapshot is taken; a non-nil error is recorded as that
+// function's failure and the function is not run. Production engines have
+// none.
+type FaultInjector interface {
+	Fault(functionName string) error
+}
+
+// OutputRedactor masks credentials in a function's raw output before the
+// engine parses it, records it for ${...} references, or returns it, and in
+// the message of a function's error.
+// Outputs are JSON, so the redactor must keep them valid JSON; the
+// *validator.Redactor RedactJSON method satisfies this.
+type OutputRedactor interface {
+	RedactJSON(output string) string
+}
+
+// redactedError is a function error whose message had credentials masked
+// by the OutputRedactor. It still unwraps to the original error so callers
+// can match it with errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
 // TransactionEngine orchestrates three-phase atomic execution.
 type TransactionEngine struct {
-	executor        *Executor
+	executor        FunctionRunner
 	resolver        *VariableResolver
-	snapshotManager *SnapshotManager
+	snapshotManager SnapshotStore
 	registry        PhaseRegistry
+	faults          FaultInjector
+	redactor        OutputRedactor
+	safeMode        bool
 }
 
 // NewTransactionEngine constructs a TransactionEngine with all dependencies.
@@ -95,6 +179,32 @@ func NewTransactionExecutor(executor *Executor) *TransactionEngine {
 	}
 }
 
+// SetSafeMode enables or disables safe mode. In safe mode any request
+// containing a modify-phase function is refused before anything runs, so
+// the snapshot manager is never used and may be nil.
+func (te *TransactionEngine) SetSafeMode(enabled bool) {
+	te.safeMode = enabled
+}
+
+// SetFaultInjector makes the modify phase consult faults before running
+// each function. It is for tests; nil disables injection.
+func (te *TransactionEngine) SetFaultInjector(faults FaultInjector) {
+	te.faults = faults
+}
+
+// SetRedactor makes the engine pass every function's output through
+// redactor, so later steps and the caller see only the redacted copy; nil
+// disables redaction.
+func (te *TransactionEngine) SetRedactor(redactor OutputRedactor) {
+	te.redactor = redactor
+}
+
+// SetSession attaches the labeled results that ${session.LABEL.field}
+// parameters resolve against.
+func (te *TransactionEngine) SetSession(store *SessionStore) {
+	te.resolver.SetSession(store)
+}
+
 // defaultRegistry treats every function as "read" (safe default for tests).
 type defaultRegistry struct{}
 
@@ -106,6 +216,17 @@ func (te *TransactionEngine) ExecuteTransaction(
 	ctx context.Context,
 	input interface{},
 ) ([]FunctionResult, error) {
+	results, _, err := te.ExecuteTransactionWithSummary(ctx, input)
+	return results, err
+}
+
+// ExecuteTransactionWithSummary is ExecuteTransaction that also reports
+// per-phase counts and timing and the transaction's final status. The summary
+// is nil only when input is not a supported type.
+func (te *TransactionEngine) ExecuteTransactionWithSummary(
+	ctx context.Context,
+	input interface{},
+) ([]FunctionResult, *types.TransactionSummary, error) {
 	var req TransactionRequest
 
 	switch v := input.(type) {
@@ -114,86 +235,180 @@ func (te *TransactionEngine) ExecuteTransaction(
 	case TransactionRequest:
 		req = v
 	default:
-		return nil, fmt.Errorf("ExecuteTransaction: unsupported input type %T", input)
+		return nil, nil, fmt.Errorf("ExecuteTransaction: unsupported input type %T", input)
 	}
 
-	return te.execute(ctx, req)
+	hooks := hooksFor(req)
+	start := time.Now()
+	summary := &types.TransactionSummary{Phases: []types.PhaseSummary{}}
+	results, err := te.execute(ctx, req, hooks, summary)
+	summary.DurationMs = durationMs(time.Since(start))
+	hooks.finished(summary, err)
+	return results, summary, err
 }
 
 func (te *TransactionEngine) execute(
 	ctx context.Context,
 	req TransactionRequest,
+	hooks *Hooks,
+	summary *types.TransactionSummary,
 ) ([]FunctionResult, error) {
 
 	if req.Strategy == "" {
 		req.Strategy = StrategySkipOnError
 	}
 
-	confirmInput := req.ConfirmationInput
-	if confirmInput == nil {
-		confirmInput = bufio.NewReader(os.Stdin)
+	confirmer := req.Confirmer
+	if confirmer == nil {
+		confirmer = NewStdinConfirmer()
+	}
+
+	// The deadline gets its own context so that reaching it can be told
+	// apart from the caller cancelling.
+	parent := ctx
+	if req.MaxTransactionSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, time.Duration(req.MaxTransactionSeconds)*time.Second)
+		defer cancel()
+	}
+	timedOut := func() bool {
+		return errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil
 	}
 
 	var allResults []FunctionResult
+	summary.Status = types.TxAborted
+
+	timeoutErr := func(phase string) error {
+		summary.TimedOut = true
+		return fmt.Errorf("%w: %ds limit reached in %s phase",
+			ErrTransactionTimeout, req.MaxTransactionSeconds, phase)
+	}
 
 	reads, analyses, modifies := te.categorise(req.Functions)
 
+	// Safe mode refuses the whole request rather than run its reads and
+	// then stop at the modify phase.
+	if te.safeMode && len(modifies) > 0 {
+		return nil, fmt.Errorf("%w: refusing modify function '%s'", ErrSafeMode, modifies[0].Name)
+	}
+
 	// ── PHASE 1: READ ─────────────────────────────────────────────────────────
-	fmt.Println("\n── Phase 1: READ ─────────────────────────────────────────────")
-	results, err := te.executePhase(ctx, reads, req.Strategy)
+	if err := hooks.beforePhase(PhaseRead, reads); err != nil {
+		return allResults, err
+	}
+	phaseStart := time.Now()
+	results, err := te.executePhase(ctx, reads, req.Strategy, retryPolicy(req.RetryPolicies, PhaseRead), hooks)
 	allResults = append(allResults, results...)
-	if err != nil {
-		if req.Strategy == StrategyStopOnError {
-			return allResults, fmt.Errorf("read phase failed: %w", err)
-		}
-		fmt.Printf("⚠  Read phase had failures, continuing to next phase (skip_on_error)\n")
+	addPhaseSummary(summary, PhaseRead, len(reads), results, time.Since(phaseStart))
+	hooks.afterPhase(PhaseRead, results)
+	if timedOut() && (err != nil || len(analyses)+len(modifies) > 0) {
+		return allResults, timeoutErr(PhaseRead)
+	}
+	if err != nil && req.Strategy == StrategyStopOnError {
+		return allResults, fmt.Errorf("read phase failed: %w", err)
 	}
-	fmt.Printf(" Read phase complete (%d function(s))\n", len(reads))
 
 	// ── PHASE 2: ANALYZE ──────────────────────────────────────────────────────
 	if len(analyses) > 0 {
-		fmt.Println("\n── Phase 2: ANALYZE ──────────────────────────────────────────")
-		results, err = te.executePhase(ctx, analyses, req.Strategy)
+		if err := hooks.beforePhase(PhaseAnalyze, analyses); err != nil {
+			return allResults, err
+		}
+		phaseStart = time.Now()
+		results, err = te.executePhase(ctx, analyses, req.Strategy, retryPolicy(req.RetryPolicies, PhaseAnalyze), hooks)
 		allResults = append(allResults, results...)
-		if err != nil {
-			if req.Strategy == StrategyStopOnError {
-				return allResults, fmt.Errorf("analyze phase failed: %w", err)
-			}
-			fmt.Printf("⚠  Analyze phase had failures, continuing to next phase (skip_on_error)\n")
+		addPhaseSummary(summary, PhaseAnalyze, len(analyses), results, time.Since(phaseStart))
+		hooks.afterPhase(PhaseAnalyze, results)
+		if timedOut() && (err != nil || len(modifies) > 0) {
+			return allResults, timeoutErr(PhaseAnalyze)
+		}
+		if err != nil && req.Strategy == StrategyStopOnError {
+			return allResults, fmt.Errorf("analyze phase failed: %w", err)
 		}
-		fmt.Printf(" Analyze phase complete (%d function(s))\n", len(analyses))
 	}
 
 	// ── GATE + PHASE 3: MODIFY ────────────────────────────────────────────────
 	if len(modifies) > 0 {
-		fmt.Println("\n── Gate 4: PRE-MODIFY VALIDATION ────────────────────────────")
-		if err := te.preModifyGate(ctx, modifies, confirmInput, req.DryRunOnly); err != nil {
+		hooks.beforeGate(modifies)
+		previews, err := te.preModifyGate(ctx, modifies)
+		if err != nil {
+			if timedOut() {
+				return allResults, timeoutErr("pre-modify validation")
+			}
 			return allResults, err
 		}
+		hooks.gatePassed(previews)
 		if req.DryRunOnly {
-			fmt.Println(" Dry-run complete. No changes were made (--dry-run mode).")
+			summary.Status = types.TxDryRun
 			return allResults, nil
 		}
+		if err := hooks.beforePhase(PhaseModify, modifies); err != nil {
+			return allResults, err
+		}
+		if err := confirm(confirmer, previews); err != nil {
+			if timedOut() {
+				return allResults, timeoutErr("pre-modify validation")
+			}
+			if errors.Is(err, ErrUserDeclined) {
+				summary.Status = types.TxDeclined
+			}
+			return allResults, err
+		}
 
-		fmt.Println("\n── Phase 3: MODIFY ───────────────────────────────────────────")
-		results, err = te.executeModifyPhase(ctx, modifies, req.Strategy)
+		phaseStart = time.Now()
+		results, err = te.executeModifyPhase(ctx, modifies, req.Strategy, hooks)
 		allResults = append(allResults, results...)
+		addPhaseSummary(summary, PhaseModify, len(modifies), results, time.Since(phaseStart))
+		hooks.afterPhase(PhaseModify, results)
+		if err != nil && timedOut() {
+			err = timeoutErr(PhaseModify)
+		}
 		if err != nil {
-			fmt.Println("\n⚠  Failure detected initiating rollback …")
 			if rbErr := te.snapshotManager.Rollback(); rbErr != nil {
-				fmt.Printf("⚠  Rollback error (manual intervention may be required): %v\n", rbErr)
+				summary.Status = types.TxRollbackFailed
+				summary.RollbackError = rbErr.Error()
 			} else {
-				fmt.Println(" Rollback complete system restored to previous state.")
+				summary.Status = types.TxRolledBack
 			}
 			return allResults, fmt.Errorf("modify phase failed (rolled back): %w", err)
 		}
-		fmt.Printf(" Modify phase complete (%d function(s))\n", len(modifies))
 	}
 
-	fmt.Println("\n Transaction committed successfully.")
+	summary.Status = types.TxCommitted
 	return allResults, nil
 }
 
+// addPhaseSummary records a phase that had planned functions. Functions a
+// stop_on_error failure prevented from running count as skipped.
+func addPhaseSummary(
+	summary *types.TransactionSummary,
+	phase string,
+	planned int,
+	results []FunctionResult,
+	elapsed time.Duration,
+) {
+	if planned == 0 {
+		return
+	}
+	ps := types.PhaseSummary{Phase: phase, Functions: planned, DurationMs: durationMs(elapsed)}
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			ps.Skipped++
+		case r.Success:
+			ps.Succeeded++
+		default:
+			ps.Failed++
+		}
+	}
+	ps.Skipped += planned - len(results)
+	summary.Phases = append(summary.Phases, ps)
+}
+
+// durationMs converts d to fractional milliseconds for the *_ms fields.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Internal helpers
 // ─────────────────────────────────────────────────────────────────────────────
@@ -222,6 +437,8 @@ func (te *TransactionEngine) executePhase(
 	ctx context.Context,
 	fns []phasedCall,
 	strategy ExecutionStrategy,
+	policy RetryPolicy,
+	hooks *Hooks,
 ) ([]FunctionResult, error) {
 	skipped := make(map[int]bool)
 	var results []FunctionResult
@@ -231,16 +448,16 @@ func (te *TransactionEngine) executePhase(
 			return results, fmt.Errorf("context cancelled: %w", err)
 		}
 		if skipped[i] {
-			results = append(results, FunctionResult{
-				FunctionName: pc.Name, Phase: pc.phase, Skipped: true,
-			})
-			fmt.Printf("  ↷ [%d] %s (skipped dependency failed)\n", i+1, pc.Name)
+			fr := FunctionResult{FunctionName: pc.Name, Phase: pc.phase, Skipped: true}
+			results = append(results, fr)
+			hooks.functionDone(i, fr)
 			continue
 		}
 
 		if err := te.resolveParams(&pc); err != nil {
 			fr := FunctionResult{FunctionName: pc.Name, Phase: pc.phase, Error: err}
 			results = append(results, fr)
+			hooks.functionDone(i, fr)
 			if strategy == StrategySkipOnError {
 				te.markDependentsSkipped(i, fns, skipped)
 				continue
@@ -248,18 +465,17 @@ func (te *TransactionEngine) executePhase(
 			return results, fmt.Errorf("[%s] %w", pc.Name, err)
 		}
 
-		fr, err := te.runOne(pc)
+		fr, err := te.runWithRetry(ctx, pc, policy)
 		results = append(results, fr)
+		hooks.functionDone(i, fr)
 
 		if err != nil {
 			if strategy == StrategySkipOnError {
-				fmt.Printf("  [%d] %s FAILED (%v) skipping dependents\n", i+1, pc.Name, err)
 				te.markDependentsSkipped(i, fns, skipped)
 				continue
 			}
 			return results, fmt.Errorf("[%s] %w", pc.Name, err)
 		}
-		fmt.Printf("   [%d] %s  (%.2fs)\n", i+1, pc.Name, fr.Duration.Seconds())
 	}
 	return results, nil
 }
@@ -268,6 +484,7 @@ func (te *TransactionEngine) executeModifyPhase(
 	ctx context.Context,
 	fns []phasedCall,
 	strategy ExecutionStrategy,
+	hooks *Hooks,
 ) ([]FunctionResult, error) {
 	skipped := make(map[int]bool)
 	var results []FunctionResult
@@ -277,16 +494,16 @@ func (te *TransactionEngine) executeModifyPhase(
 			return results, fmt.Errorf("context cancelled: %w", err)
 		}
 		if skipped[i] {
-			results = append(results, FunctionResult{
-				FunctionName: pc.Name, Phase: pc.phase, Skipped: true,
-			})
-			fmt.Printf("  ↷ [%d] %s (skipped dependency failed)\n", i+1, pc.Name)
+			fr := FunctionResult{FunctionName: pc.Name, Phase: pc.phase, Skipped: true}
+			results = append(results, fr)
+			hooks.functionDone(i, fr)
 			continue
 		}
 
 		if err := te.resolveParams(&pc); err != nil {
 			fr := FunctionResult{FunctionName: pc.Name, Phase: pc.phase, Error: err}
 			results = append(results, fr)
+			hooks.functionDone(i, fr)
 			if pc.Critical || strategy == StrategyStopOnError {
 				return results, fmt.Errorf("[%s] variable resolution failed: %w", pc.Name, err)
 			}
@@ -296,46 +513,36 @@ func (te *TransactionEngine) executeModifyPhase(
 
 		// Snapshot BEFORE change. TakeSnapshot(name, params) → (*Snapshot, error)
 		if _, snapErr := te.snapshotManager.TakeSnapshot(pc.Name, pc.Params); snapErr != nil {
-			fmt.Printf("  ⚠  [%d] %s snapshot failed (%v); operation will not be reversible\n",
-				i+1, pc.Name, snapErr)
+			hooks.snapshotFailed(i, pc.Name, snapErr)
 		}
 
-		fr, err := te.runOne(pc)
+		fr, err := te.runModify(ctx, pc)
+		fr.Attempts = 1
 		results = append(results, fr)
+		hooks.functionDone(i, fr)
 
 		if err != nil {
 			if pc.Critical || strategy == StrategyStopOnError {
 				return results, fmt.Errorf("[%s] %w", pc.Name, err)
 			}
 			if strategy == StrategySkipOnError {
-				fmt.Printf("  [%d] %s FAILED (%v) skipping dependents\n", i+1, pc.Name, err)
 				te.markDependentsSkipped(i, fns, skipped)
 				continue
 			}
 			return results, fmt.Errorf("[%s] %w", pc.Name, err)
 		}
-		fmt.Printf("   [%d] %s  (%.2fs)\n", i+1, pc.Name, fr.Duration.Seconds())
 	}
 	return results, nil
 }
 
-func (te *TransactionEngine) preModifyGate(
-	ctx context.Context,
-	fns []phasedCall,
-	input *bufio.Reader,
-	dryRunOnly bool,
-) error {
-	fmt.Println("Validating modify operations …")
-
-	type preview struct {
-		pc     phasedCall
-		params map[string]interface{}
-	}
-	previews := make([]preview, 0, len(fns))
+// preModifyGate dry-runs each modify call and returns the operations the
+// operator will be asked to approve.
+func (te *TransactionEngine) preModifyGate(ctx context.Context, fns []phasedCall) ([]OperationPreview, error) {
+	previews := make([]OperationPreview, 0, len(fns))
 
 	for _, pc := range fns {
 		if err := te.resolveParams(&pc); err != nil {
-			return fmt.Errorf("dry-run: [%s] variable resolution failed: %w", pc.Name, err)
+			return nil, fmt.Errorf("dry-run: [%s] variable resolution failed: %w", pc.Name, err)
 		}
 
 		dryPc := pc
@@ -344,73 +551,86 @@ func (te *TransactionEngine) preModifyGate(
 		}
 		dryPc.Params["__dry_run"] = true
 
-		if _, err := te.runOne(dryPc); err != nil {
-			return fmt.Errorf("dry-run: [%s] failed pre-flight check: %w", pc.Name, err)
-		}
-		previews = append(previews, preview{pc: pc, params: pc.Params})
-	}
-
-	fmt.Println(" Dry-run validation passed.\n")
-	if dryRunOnly {
-		return nil
-	}
-
-	fmt.Println("┌─────────────────────────────────────────────────────────┐")
-	fmt.Println("│  ⚠   DESTRUCTIVE OPERATIONS PENDING                    │")
-	fmt.Println("└─────────────────────────────────────────────────────────┘")
-
-	for i, p := range previews {
-		fmt.Printf("\n  [%d] %s\n", i+1, p.pc.Name)
-		for k, v := range p.params {
-			if strings.HasPrefix(k, "__") {
-				continue
-			}
-			fmt.Printf("      %-24s %v\n", k+":", v)
-		}
-		critical := "no"
-		if p.pc.Critical {
-			critical = "yes failure triggers rollback"
+		if _, err := te.runOne(ctx, dryPc); err != nil {
+			return nil, fmt.Errorf("dry-run: [%s] failed pre-flight check: %w", pc.Name, err)
 		}
-		fmt.Printf("      %-24s %s\n", "critical:", critical)
+		previews = append(previews, OperationPreview{
+			FunctionName: pc.Name,
+			Params:       visibleParams(pc.Params),
+			Critical:     pc.Critical,
+		})
 	}
+	return previews, nil
+}
 
-	fmt.Printf("\n  All operations are reversible via automatic rollback on failure.\n")
-	fmt.Printf("\nProceed with %d destructive operation(s)? [y/N]: ", len(previews))
-
-	line, err := input.ReadString('\n')
+// confirm asks the operator to approve the validated modify operations and
+// returns ErrUserDeclined if they do not.
+func confirm(confirmer Confirmer, previews []OperationPreview) error {
+	approved, err := confirmer.Confirm(previews)
 	if err != nil {
-		return fmt.Errorf("could not read confirmation: %w", err)
+		return err
 	}
-	if answer := strings.TrimSpace(strings.ToLower(line)); answer != "y" && answer != "yes" {
-		fmt.Println("Aborted by operator no changes were made.")
+	if !approved {
 		return ErrUserDeclined
 	}
 	return nil
 }
 
+// runModify runs a modify function whose snapshot has been taken, unless
+// the fault injector fails it first.
+func (te *TransactionEngine) runModify(ctx context.Context, pc phasedCall) (FunctionResult, error) {
+	if te.faults != nil {
+		if err := te.faults.Fault(pc.Name); err != nil {
+			return FunctionResult{FunctionName: pc.Name, Phase: pc.phase, Error: err}, err
+		}
+	}
+	return te.runOne(ctx, pc)
+}
+
 // runOne executes a single phasedCall via the dispatcher.
 // executor.Execute(types.FunctionCall) → (string, error)
-func (te *TransactionEngine) runOne(pc phasedCall) (FunctionResult, error) {
+func (te *TransactionEngine) runOne(ctx context.Context, pc phasedCall) (FunctionResult, error) {
 	start := time.Now()
-	rawOutput, err := te.executor.Execute(pc.FunctionCall)
+	var (
+		rawOutput string
+		err       error
+	)
+	if cr, ok := te.executor.(contextRunner); ok {
+		rawOutput, err = cr.ExecuteContext(ctx, pc.FunctionCall)
+	} else {
+		rawOutput, err = te.executor.Execute(pc.FunctionCall)
+	}
 	elapsed := time.Since(start)
+	if err != nil && te.redactor != nil {
+		if msg := te.redactor.RedactJSON(err.Error()); msg != err.Error() {
+			err = &redactedError{msg: msg, err: err}
+		}
+	}
 
 	fr := FunctionResult{
-		FunctionName: pc.Name,
-		Phase:        pc.phase,
-		Error:        err,
-		Duration:     elapsed,
-		Success:      err == nil,
+		FunctionName:   pc.Name,
+		Phase:          pc.phase,
+		Error:          err,
+		Duration:       elapsed,
+		Success:        err == nil,
+		ResolvedParams: visibleParams(pc.Params),
 	}
 	if err != nil {
 		return fr, err
 	}
+	if te.redactor != nil {
+		if redacted := te.redactor.RedactJSON(rawOutput); redacted != rawOutput {
+			fr.Unredacted = rawOutput
+			rawOutput = redacted
+		}
+	}
 
 	var outputMap map[string]interface{}
 	if decErr := json.Unmarshal([]byte(rawOutput), &outputMap); decErr != nil {
 		outputMap = map[string]interface{}{"output": rawOutput}
 	}
 	fr.Output = outputMap
+	fr.SideEffect = outputMap["side_effect"] == true
 
 	// Feed into resolver so ${functionName.field} works for subsequent calls.
 	te.resolver.AddResult(pc.Name, rawOutput)
@@ -418,6 +638,18 @@ func (te *TransactionEngine) runOne(pc phasedCall) (FunctionResult, error) {
 	return fr, nil
 }
 
+// visibleParams copies params without the internal "__" flags, such as
+// __dry_run, that the engine adds for the executor.
+func visibleParams(params map[string]interface{}) map[string]interface{} {
+	visible := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if !strings.HasPrefix(k, "__") {
+			visible[k] = v
+		}
+	}
+	return visible
+}
+
 // resolveParams resolves ${…} references in pc.Params in-place, preserving
 // native types (int, float64, bool) via ResolveParams/tryResolveNative.
 //
@@ -457,3 +689,6 @@ func (te *TransactionEngine) markDependentsSkipped(
 
 // ErrUserDeclined is returned when the operator answers "N" at the prompt.
 var ErrUserDeclined = fmt.Errorf("transaction declined by operator")
+
+// ErrSafeMode is returned when safe mode refuses a modify-phase function.
+var ErrSafeMode = fmt.Errorf("safe mode is enabled")
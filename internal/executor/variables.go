@@ -3,6 +3,8 @@ package executor
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,8 +14,31 @@ import (
 // Supports dotted paths of arbitrary depth, e.g. ${grpc.latency_ms} or ${tcp.nested.value}.
 var varPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
 
+// envPrefix marks a reference to the host environment, e.g. ${env.TARGET_HOST}
+// or ${env.TARGET_PORT:-50051} with a default for when it is unset or empty.
+const envPrefix = "env."
+
+// envDefaultSep separates an env reference from its default value.
+const envDefaultSep = ":-"
+
+// DefaultEnvAllowlist lists the environment variable name patterns (path.Match
+// syntax) that ${env.NAME} may read. It is deliberately narrow so a plan can
+// not pull credentials such as AWS_SECRET_ACCESS_KEY into a parameter.
+var DefaultEnvAllowlist = []string{
+	"FRIDAY_*",
+	"TARGET_*",
+	"*_HOST",
+	"*_PORT",
+	"*_ADDR",
+	"*_INTERFACE",
+	"*_IFACE",
+	"HOSTNAME",
+}
+
 // VariableResolver stores JSON outputs from already-executed functions and resolves
 // ${function_name.field_path} references in subsequent function parameters.
+// ${env.NAME} references read allowlisted host environment variables, and
+// ${session.LABEL.field} references read results labeled in earlier queries.
 //
 // Usage:
 //
@@ -23,15 +48,33 @@ var varPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
 type VariableResolver struct {
 	// results maps function name -> parsed JSON (map or scalar).
 	results map[string]interface{}
+	// envAllowlist holds the name patterns ${env.NAME} may resolve.
+	envAllowlist []string
+	// session holds the labeled results ${session.LABEL.field} reads; nil
+	// disables session references.
+	session *SessionStore
 }
 
 // NewVariableResolver creates an empty resolver.
 func NewVariableResolver() *VariableResolver {
 	return &VariableResolver{
-		results: make(map[string]interface{}),
+		results:      make(map[string]interface{}),
+		envAllowlist: DefaultEnvAllowlist,
 	}
 }
 
+// SetEnvAllowlist replaces the environment variable name patterns that
+// ${env.NAME} references may read. An empty list disables env references.
+func (vr *VariableResolver) SetEnvAllowlist(patterns []string) {
+	vr.envAllowlist = patterns
+}
+
+// SetSession attaches the store that ${session.LABEL.field} references
+// resolve against.
+func (vr *VariableResolver) SetSession(store *SessionStore) {
+	vr.session = store
+}
+
 // AddResult stores the JSON output of a completed function execution.
 // The output is parsed eagerly so resolution is fast.
 // Non-JSON output (plain strings) is stored as a raw string under the key "value".
@@ -40,17 +83,21 @@ func (vr *VariableResolver) AddResult(functionName string, jsonOutput string) {
 		return
 	}
 
+	vr.results[functionName] = parseOutput(jsonOutput)
+}
+
+// parseOutput parses a function's JSON output. Non-JSON output (plain
+// strings) becomes a map holding it under "value" and "output", so
+// ${func.value} still works.
+func parseOutput(output string) interface{} {
 	var parsed interface{}
-	if err := json.Unmarshal([]byte(jsonOutput), &parsed); err != nil {
-		// Not JSON — store as a plain string so ${func.value} still works.
-		vr.results[functionName] = map[string]interface{}{
-			"value":  jsonOutput,
-			"output": jsonOutput,
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		return map[string]interface{}{
+			"value":  output,
+			"output": output,
 		}
-		return
 	}
-
-	vr.results[functionName] = parsed
+	return parsed
 }
 
 // HasResult reports whether a result exists for the given function name.
@@ -101,7 +148,7 @@ func (vr *VariableResolver) Resolve(value string) (string, error) {
 			resolveErr = err
 			return match
 		}
-		return fmt.Sprintf("%v", resolved)
+		return formatResolved(resolved)
 	})
 
 	if resolveErr != nil {
@@ -114,6 +161,20 @@ func (vr *VariableResolver) Resolve(value string) (string, error) {
 // Internal helpers
 // ============================================================================
 
+// formatResolved renders a resolved value for string interpolation. JSON
+// numbers decode as float64, which %v prints in exponent form once they are
+// large (1e+06); floats are instead printed in plain decimal with the fewest
+// digits that round-trip, so 50051 stays "50051" and 3.14 stays "3.14".
+func formatResolved(v interface{}) string {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', -1, 32)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
 // resolveValue resolves placeholders in a single parameter value.
 // Handles string, map, and slice values recursively.
 func (vr *VariableResolver) resolveValue(val interface{}) (interface{}, error) {
@@ -174,6 +235,13 @@ func (vr *VariableResolver) tryResolveNative(value string) (interface{}, bool) {
 // resolveReference resolves a dotted path like "function_name.field.subfield"
 // against the stored results.
 func (vr *VariableResolver) resolveReference(ref string) (interface{}, error) {
+	if strings.HasPrefix(ref, envPrefix) {
+		return vr.resolveEnv(ref)
+	}
+	if strings.HasPrefix(ref, sessionPrefix) {
+		return vr.resolveSession(ref)
+	}
+
 	parts := strings.SplitN(ref, ".", 2)
 	if len(parts) == 0 || parts[0] == "" {
 		return nil, fmt.Errorf("empty reference %q", ref)
@@ -195,6 +263,70 @@ func (vr *VariableResolver) resolveReference(ref string) (interface{}, error) {
 	return walkPath(result, fieldPath, ref)
 }
 
+// resolveEnv resolves "env.NAME" or "env.NAME:-default" from the process
+// environment. An unset or empty variable takes the default when one is
+// given; an unset variable without a default is an error.
+func (vr *VariableResolver) resolveEnv(ref string) (interface{}, error) {
+	name := strings.TrimPrefix(ref, envPrefix)
+	def, hasDefault := "", false
+	if i := strings.Index(name, envDefaultSep); i >= 0 {
+		name, def, hasDefault = name[:i], name[i+len(envDefaultSep):], true
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("empty environment variable name in ${%s}", ref)
+	}
+	if !vr.envAllowed(name) {
+		return nil, fmt.Errorf(
+			"environment variable %q is not allowed in ${%s}; allowed patterns: [%s]",
+			name, ref, strings.Join(vr.envAllowlist, ", "),
+		)
+	}
+
+	val, ok := os.LookupEnv(name)
+	if hasDefault && val == "" {
+		return def, nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("environment variable %q is not set (reference: ${%s})", name, ref)
+	}
+	return val, nil
+}
+
+// resolveSession resolves "session.LABEL" or "session.LABEL.field.path"
+// against the labeled results in the session store.
+func (vr *VariableResolver) resolveSession(ref string) (interface{}, error) {
+	parts := strings.SplitN(strings.TrimPrefix(ref, sessionPrefix), ".", 2)
+	label := parts[0]
+	if label == "" {
+		return nil, fmt.Errorf("empty session label in ${%s}", ref)
+	}
+	if vr.session == nil {
+		return nil, fmt.Errorf("no session results available (reference: ${%s})", ref)
+	}
+
+	result, ok := vr.session.Get(label)
+	if !ok {
+		return nil, fmt.Errorf(
+			"no result saved as %q (reference: ${%s}); saved labels: [%s]",
+			label, ref, strings.Join(vr.session.Labels(), ", "),
+		)
+	}
+	if len(parts) == 1 || parts[1] == "" {
+		return result, nil
+	}
+	return walkPath(result, parts[1], ref)
+}
+
+func (vr *VariableResolver) envAllowed(name string) bool {
+	for _, pattern := range vr.envAllowlist {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // walkPath traverses a dotted field path on a parsed JSON value.
 // Supports map keys and array indices (e.g. "items.0.name").
 func walkPath(current interface{}, path string, originalRef string) (interface{}, error) {
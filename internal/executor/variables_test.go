@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -202,6 +203,194 @@ func TestResolve_FloatValue(t *testing.T) {
 	}
 }
 
+func TestResolve_FloatFormatting(t *testing.T) {
+	vr := NewVariableResolver()
+	vr.AddResult("stats", `{"port":50051,"ratio":3.14,"small":0.000123,"bytes":12345678901,"mixed":2.5e7}`)
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"${stats.port}", "50051"},
+		{"${stats.ratio}", "3.14"},
+		{"${stats.small}", "0.000123"},
+		{"${stats.bytes}", "12345678901"},
+		{"${stats.mixed}", "25000000"},
+		{"localhost:${stats.port}", "localhost:50051"},
+	}
+	for _, tt := range tests {
+		val, err := vr.Resolve(tt.ref)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.ref, err)
+		}
+		if val != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.ref, tt.want, val)
+		}
+	}
+}
+
+func TestResolve_EnvVariable_Set(t *testing.T) {
+	t.Setenv("TARGET_HOST", "10.0.0.5")
+	vr := NewVariableResolver()
+
+	val, err := vr.Resolve("${env.TARGET_HOST}:50051")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val != "10.0.0.5:50051" {
+		t.Errorf("expected '10.0.0.5:50051', got %q", val)
+	}
+}
+
+func TestResolve_EnvVariable_Unset_Error(t *testing.T) {
+	vr := NewVariableResolver()
+
+	_, err := vr.Resolve("${env.FRIDAY_TEST_UNSET_VAR}")
+	if err == nil {
+		t.Fatal("expected error for unset environment variable")
+	}
+	if !strings.Contains(err.Error(), "not set") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolve_EnvVariable_Default(t *testing.T) {
+	t.Setenv("TARGET_PORT", "")
+	vr := NewVariableResolver()
+
+	val, err := vr.Resolve("${env.FRIDAY_TEST_UNSET_VAR:-localhost}:${env.TARGET_PORT:-50051}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val != "localhost:50051" {
+		t.Errorf("expected 'localhost:50051', got %q", val)
+	}
+}
+
+func TestResolve_EnvVariable_NotAllowlisted(t *testing.T) {
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	vr := NewVariableResolver()
+
+	_, err := vr.Resolve("${env.AWS_SECRET_ACCESS_KEY:-fallback}")
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected allowlist error, got %v", err)
+	}
+
+	vr.SetEnvAllowlist([]string{"AWS_*"})
+	val, err := vr.Resolve("${env.AWS_SECRET_ACCESS_KEY}")
+	if err != nil || val != "secret" {
+		t.Errorf("custom allowlist: got %q, %v", val, err)
+	}
+}
+
+func TestResolveParams_EnvVariable_Native(t *testing.T) {
+	t.Setenv("TARGET_HOST", "db.internal")
+	vr := NewVariableResolver()
+
+	out, err := vr.ResolveParams(map[string]interface{}{"host": "${env.TARGET_HOST}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out["host"] != "db.internal" {
+		t.Errorf("expected 'db.internal', got %v", out["host"])
+	}
+}
+
+// ─── Session labels ───────────────────────────────────────────────────────────
+
+func sessionResult(name, output string) types.ExecutionResult {
+	return types.ExecutionResult{Function: types.FunctionCall{Name: name}, Success: true, Output: output}
+}
+
+func TestResolve_SessionLabel_SingleResult(t *testing.T) {
+	store := NewSessionStore()
+	if err := store.Label("baseline", []types.ExecutionResult{
+		sessionResult("check_tcp_health", `{"retransmits":47,"state":"ESTABLISHED"}`),
+	}); err != nil {
+		t.Fatalf("Label: %v", err)
+	}
+	vr := NewVariableResolver()
+	vr.SetSession(store)
+
+	val, err := vr.Resolve("before=${session.baseline.retransmits}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val != "before=47" {
+		t.Errorf("expected %q, got %q", "before=47", val)
+	}
+
+	params, err := vr.ResolveParams(map[string]interface{}{"threshold": "${session.baseline.retransmits}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params["threshold"] != float64(47) {
+		t.Errorf("expected native 47, got %v (%T)", params["threshold"], params["threshold"])
+	}
+}
+
+func TestResolve_SessionLabel_SeveralResults(t *testing.T) {
+	store := NewSessionStore()
+	err := store.Label("before", []types.ExecutionResult{
+		sessionResult("check_tcp_health", `{"retransmits":47}`),
+		{Function: types.FunctionCall{Name: "ping"}, Success: false, Error: "timeout"},
+		sessionResult("interface_stats", `{"rx_dropped":3}`),
+	})
+	if err != nil {
+		t.Fatalf("Label: %v", err)
+	}
+	vr := NewVariableResolver()
+	vr.SetSession(store)
+
+	val, err := vr.Resolve("${session.before.check_tcp_health.retransmits}/${session.before.interface_stats.rx_dropped}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val != "47/3" {
+		t.Errorf("expected %q, got %q", "47/3", val)
+	}
+	if _, err := vr.Resolve("${session.before.ping}"); err == nil {
+		t.Error("expected failed result not to be saved")
+	}
+}
+
+func TestResolve_SessionLabel_Errors(t *testing.T) {
+	vr := NewVariableResolver()
+	if _, err := vr.Resolve("${session.baseline.retransmits}"); err == nil {
+		t.Error("expected error without a session store")
+	}
+
+	store := NewSessionStore()
+	store.Label("baseline", []types.ExecutionResult{sessionResult("check_tcp_health", `{"retransmits":47}`)})
+	vr.SetSession(store)
+
+	_, err := vr.Resolve("${session.after.retransmits}")
+	if err == nil || !containsStr(err.Error(), "saved labels: [baseline]") {
+		t.Errorf("expected unknown label error listing saved labels, got %v", err)
+	}
+	if _, err := vr.Resolve("${session.baseline.rtt_ms}"); err == nil {
+		t.Error("expected error for a missing field")
+	}
+}
+
+func TestSessionStore_LabelValidation(t *testing.T) {
+	store := NewSessionStore()
+	ok := []types.ExecutionResult{sessionResult("ping", `{"reachable":true}`)}
+
+	for _, label := range []string{"", "two words", "a.b", "9lives"} {
+		if err := store.Label(label, ok); err == nil {
+			t.Errorf("expected error for label %q", label)
+		}
+	}
+	failed := []types.ExecutionResult{{Function: types.FunctionCall{Name: "ping"}, Error: "timeout"}}
+	if err := store.Label("baseline", failed); err == nil {
+		t.Error("expected error when no result succeeded")
+	}
+	if len(store.Labels()) != 0 {
+		t.Errorf("expected nothing saved, got %v", store.Labels())
+	}
+}
+
 // ─── ResolveParams ────────────────────────────────────────────────────────────
 
 func TestResolveParams_Nil_ReturnsNil(t *testing.T) {
@@ -0,0 +1,196 @@
+package debugging
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxExtraCommands caps how many extra debugger commands one analysis runs.
+const maxExtraCommands = 10
+
+// extraCommandMarker is echoed by GDB before each extra command's output so
+// the output can be split per command.
+const extraCommandMarker = "@@friday-extra-%d@@"
+
+// allowedCommandWords are the debugger commands extra_commands may start
+// with. All of them only read state; "x" also covers "x/16xw $sp". Commands
+// that take a subcommand are further restricted by allowedSubcommands.
+var allowedCommandWords = map[string]bool{
+	"print": true,
+	"x":     true,
+	"info":  true,
+	"frame": true,
+	"bt":    true,
+}
+
+// allowedSubcommands lists the exact subcommands info and frame may use, so
+// that wrappers such as "frame apply all shell ..." cannot run other commands.
+var allowedSubcommands = map[string]map[string]bool{
+	"info": {
+		"registers": true, "all-registers": true, "frame": true, "locals": true,
+		"args": true, "threads": true, "sharedlibrary": true, "symbol": true,
+		"line": true, "source": true, "signals": true, "auxv": true,
+	},
+	"frame": {
+		"info": true, "level": true, "address": true, "function": true,
+		"variable": true, "select": true,
+	},
+}
+
+// convenienceCall matches a debugger convenience function call such as
+// $_shell("id") or $_as_string(x); some of them run shell commands.
+var convenienceCall = regexp.MustCompile(`\$\w+\s*\(`)
+
+// ValidateDebuggerCommands checks that every command is a single read-only
+// debugger command: it must start with print, x/, info, frame, or bt, stay on
+// one line, and not assign to anything (e.g. "print counter = 0").
+func ValidateDebuggerCommands(cmds []string) error {
+	if len(cmds) > maxExtraCommands {
+		return fmt.Errorf("too many extra commands: %d (max %d)", len(cmds), maxExtraCommands)
+	}
+	for _, cmd := range cmds {
+		if err := validateDebuggerCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateDebuggerCommand(cmd string) error {
+	trimmed := strings.TrimSpace(cmd)
+	if trimmed == "" {
+		return fmt.Errorf("empty debugger command")
+	}
+	if strings.ContainsAny(trimmed, "\n\r;") {
+		return fmt.Errorf("debugger command '%s' must be a single command", cmd)
+	}
+
+	word := strings.Fields(trimmed)[0]
+	if i := strings.Index(word, "/"); i >= 0 {
+		word = word[:i]
+	}
+	if !allowedCommandWords[word] {
+		return fmt.Errorf("debugger command '%s' is not allowed; use print, x/, info, frame, or bt", cmd)
+	}
+	if err := validateArgs(word, strings.Fields(trimmed)[1:]); err != nil {
+		return fmt.Errorf("debugger command '%s' is not allowed: %w", cmd, err)
+	}
+	if convenienceCall.MatchString(trimmed) {
+		return fmt.Errorf("debugger command '%s' calls a convenience function", cmd)
+	}
+	if assigns(trimmed) {
+		return fmt.Errorf("debugger command '%s' modifies state", cmd)
+	}
+	return nil
+}
+
+// validateArgs checks the arguments of commands that take subcommands or
+// counts rather than expressions: bt accepts only "full" and frame counts,
+// and info and frame accept only the subcommands in allowedSubcommands (frame
+// also accepts a bare frame number).
+func validateArgs(word string, args []string) error {
+	switch word {
+	case "bt":
+		for _, arg := range args {
+			if arg == "full" || arg == "-full" || isInteger(arg) {
+				continue
+			}
+			return fmt.Errorf("unsupported bt argument '%s'", arg)
+		}
+	case "info":
+		if len(args) == 0 || !allowedSubcommands["info"][args[0]] {
+			return fmt.Errorf("unsupported info subcommand")
+		}
+	case "frame":
+		if len(args) == 0 || (len(args) == 1 && isInteger(args[0])) {
+			return nil
+		}
+		if !allowedSubcommands["frame"][args[0]] {
+			return fmt.Errorf("unsupported frame subcommand '%s'", args[0])
+		}
+	}
+	return nil
+}
+
+func isInteger(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// assigns reports whether expr contains an assignment or increment operator
+// (=, +=, ++, ...) as opposed to a comparison (==, !=, <=, >=).
+func assigns(expr string) bool {
+	if strings.Contains(expr, "++") || strings.Contains(expr, "--") {
+		return true
+	}
+	for i := 0; i < len(expr); i++ {
+		if expr[i] != '=' {
+			continue
+		}
+		if i+1 < len(expr) && expr[i+1] == '=' {
+			i++
+			continue
+		}
+		if i > 0 && strings.ContainsRune("=!<>", rune(expr[i-1])) {
+			// "<=" and ">=" compare, but "<<=" and ">>=" assign.
+			if i > 1 && (expr[i-2] == '<' || expr[i-2] == '>') && expr[i-2] == expr[i-1] {
+				return true
+			}
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// splitExtraOutput separates debugger output into the main analysis output,
+// which ends at the first marker line, and each extra command's output, which
+// runs from its marker line to the next marker or the end line. markers[i]
+// is the line that precedes the output of cmds[i].
+func splitExtraOutput(output string, cmds, markers []string, end string) (string, map[string]string) {
+	if len(cmds) == 0 {
+		return output, nil
+	}
+
+	lines := strings.Split(output, "\n")
+	extra := make(map[string]string, len(cmds))
+	mainEnd := len(lines)
+
+	pos := 0
+	for i, marker := range markers {
+		start := indexLine(lines, marker, pos)
+		if start < 0 {
+			extra[cmds[i]] = ""
+			continue
+		}
+		mainEnd = min(mainEnd, start)
+
+		stop := len(lines)
+		if i+1 < len(markers) {
+			if next := indexLine(lines, markers[i+1], start+1); next >= 0 {
+				stop = next
+			}
+		} else if end != "" {
+			if next := indexLine(lines, end, start+1); next >= 0 {
+				stop = next
+			}
+		}
+		extra[cmds[i]] = strings.TrimSpace(strings.Join(lines[start+1:stop], "\n"))
+		pos = start + 1
+	}
+
+	return strings.Join(lines[:mainEnd], "\n"), extra
+}
+
+// indexLine returns the index of the first line at or after from whose
+// trimmed text equals want, or -1.
+func indexLine(lines []string, want string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == want {
+			return i
+		}
+	}
+	return -1
+}
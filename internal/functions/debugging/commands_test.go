@@ -0,0 +1,127 @@
+package debugging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDebuggerCommands_Allowed(t *testing.T) {
+	cmds := []string{
+		"print global_config",
+		"print/x flags",
+		"print count == 0",
+		"x/16xw $sp",
+		"info registers",
+		"frame 2",
+		"frame variable",
+		"bt 5",
+		"bt full",
+	}
+	if err := ValidateDebuggerCommands(cmds); err != nil {
+		t.Errorf("expected commands to be allowed, got %v", err)
+	}
+}
+
+func TestValidateDebuggerCommands_Rejected(t *testing.T) {
+	for _, cmd := range []string{
+		"set var counter = 0",
+		"call exit(0)",
+		"print counter = 0",
+		"print counter++",
+		"print mask <<= 1",
+		"info registers; kill",
+		"shell rm -rf /",
+		"frame apply all shell touch /tmp/pwned",
+		"frame apply 1 print $_shell(\"id\")",
+		"info frame-apply",
+		"bt -frame-arguments all shell id",
+		`print $_shell("id")`,
+		`print $_as_string(buf)`,
+		`x/s $_shell ("id")`,
+		"",
+	} {
+		if err := ValidateDebuggerCommands([]string{cmd}); err == nil {
+			t.Errorf("expected %q to be rejected", cmd)
+		}
+	}
+
+	many := make([]string, maxExtraCommands+1)
+	for i := range many {
+		many[i] = "bt"
+	}
+	if err := ValidateDebuggerCommands(many); err == nil {
+		t.Error("expected too many commands to be rejected")
+	}
+}
+
+func TestGDBArgs_AppendsExtraCommands(t *testing.T) {
+	args := strings.Join(gdbArgs("/cores/core.1", "/bin/server", []string{"print global_config", "x/16xw $sp"}), " ")
+
+	want := "-ex thread apply all bt full -ex echo \\n@@friday-extra-0@@\\n -ex print global_config " +
+		"-ex echo \\n@@friday-extra-1@@\\n -ex x/16xw $sp /bin/server -c /cores/core.1"
+	if !strings.HasSuffix(args, want) {
+		t.Errorf("expected extra commands after the analysis, got %q", args)
+	}
+}
+
+func TestLLDBArgs_AppendsExtraCommandsBeforeQuit(t *testing.T) {
+	args := strings.Join(lldbArgs("/cores/core.1", "", []string{"frame variable"}), " ")
+	if !strings.HasSuffix(args, "-o thread list -o frame variable -o quit") {
+		t.Errorf("expected extra command before quit, got %q", args)
+	}
+}
+
+func TestSplitExtraOutput_GDB(t *testing.T) {
+	cmds := []string{"print global_config", "bt 1"}
+	output := strings.Join([]string{
+		"Program terminated with signal SIGSEGV, Segmentation fault.",
+		"#0  0x0000000000401136 in parse_header (buf=0x0) at parser.c:42",
+		"",
+		"@@friday-extra-0@@",
+		"$1 = {port = 8080, verbose = 0}",
+		"",
+		"@@friday-extra-1@@",
+		"#0  0x0000000000401136 in parse_header (buf=0x0) at parser.c:42",
+		"(More stack frames follow...)",
+	}, "\n")
+
+	analysis, extra := splitExtraOutput(output, cmds, gdbMarkers(cmds), "")
+
+	if strings.Contains(analysis, "@@") || strings.Contains(analysis, "$1") {
+		t.Errorf("expected extra output removed from analysis, got %q", analysis)
+	}
+	if got := extra["print global_config"]; got != "$1 = {port = 8080, verbose = 0}" {
+		t.Errorf("unexpected print output %q", got)
+	}
+	if got := extra["bt 1"]; !strings.HasPrefix(got, "#0") || !strings.HasSuffix(got, "follow...)") {
+		t.Errorf("unexpected bt output %q", got)
+	}
+
+	parsed, err := parseGDBOutput(analysis, ParseLimits{})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+	if bt := parsed["backtrace"].([]string); len(bt) != 1 {
+		t.Errorf("expected extra bt frames kept out of the backtrace, got %v", bt)
+	}
+}
+
+func TestSplitExtraOutput_LLDB(t *testing.T) {
+	cmds := []string{"frame variable"}
+	output := strings.Join([]string{
+		"(lldb) thread list",
+		"* thread #1: tid = 0x1",
+		"(lldb) frame variable",
+		"(char *) buf = 0x0000000000000000",
+		"(lldb) quit",
+	}, "\n")
+
+	analysis, extra := splitExtraOutput(output, cmds, lldbMarkers(cmds), "(lldb) quit")
+
+	if strings.Contains(analysis, "buf =") {
+		t.Errorf("expected extra output removed from analysis, got %q", analysis)
+	}
+	if got := extra["frame variable"]; got != "(char *) buf = 0x0000000000000000" {
+		t.Errorf("unexpected frame variable output %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package debugging
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/friday/internal/truncate"
 )
 
 const analyzerTimeout = 60 * time.Second
@@ -47,6 +50,21 @@ var (
 	// Matches the "info threads" section header line.
 	// GDB always prints "  Id   Target Id ..." as the first line.
 	reGDBThreadListHdr = regexp.MustCompile(`Target Id`)
+
+	// Matches the current (crashing) thread in the "info threads" table:
+	// "* 1    Thread 0x7f... (LWP 12345) 0x... in func () at file.c:10"
+	reGDBCurrentThread = regexp.MustCompile(`^\*\s*(\d+)\s`)
+
+	// Matches the lines GDB prints for each thread found in the core while
+	// loading it: "[New LWP 12345]" or "[New Thread 0x7f... (LWP 12345)]".
+	reGDBNewLWP = regexp.MustCompile(`^\[New (?:LWP (\d+)|Thread .*\(LWP (\d+)\))\]`)
+
+	// Matches GDB's warnings that libthread_db failed, after which the
+	// thread list can be missing threads:
+	// "Cannot find new threads: generic error" or "warning: Unable to find
+	// libthread_db matching inferior's thread library, thread debugging
+	// will not be available."
+	reGDBThreadDBFailure = regexp.MustCompile(`Cannot find new threads|Unable to find libthread_db|thread debugging will not be available`)
 )
 
 // --- LLDB compiled regexes ---
@@ -71,6 +89,65 @@ var (
 type threadData struct {
 	id     int
 	frames []string
+	// frameCount is the number of frames seen, which exceeds len(frames)
+	// when the thread was cut at MaxFramesPerThread.
+	frameCount int
+}
+
+// ParseLimits bounds how much of a backtrace is kept, so a hung process with
+// thousands of deep threads cannot exhaust memory. The crashing thread is
+// always kept in full and does not count against the limits. Zero fields
+// take the DefaultParseLimits value.
+type ParseLimits struct {
+	// MaxThreads is the number of other threads kept.
+	MaxThreads int
+	// MaxFramesPerThread is the number of frames kept from each other thread.
+	MaxFramesPerThread int
+	// MaxBytes is the total size of frame text kept across other threads.
+	// At most MaxBytes plus debuggerOutputSlack of debugger output is kept;
+	// see combinedOutput.
+	MaxBytes int
+}
+
+// debuggerOutputSlack is how much debugger output is read beyond
+// ParseLimits.MaxBytes, leaving room for the crashing thread, the thread
+// list and extra command output, which MaxBytes does not count.
+const debuggerOutputSlack = 1 << 20
+
+// DefaultParseLimits returns the limits used when none are given.
+func DefaultParseLimits() ParseLimits {
+	return ParseLimits{
+		MaxThreads:         256,
+		MaxFramesPerThread: 128,
+		MaxBytes:           4 << 20,
+	}
+}
+
+// withDefaults fills zero fields from DefaultParseLimits.
+func (l ParseLimits) withDefaults() ParseLimits {
+	d := DefaultParseLimits()
+	if l.MaxThreads <= 0 {
+		l.MaxThreads = d.MaxThreads
+	}
+	if l.MaxFramesPerThread <= 0 {
+		l.MaxFramesPerThread = d.MaxFramesPerThread
+	}
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = d.MaxBytes
+	}
+	return l
+}
+
+// CoreDumpOptions holds the optional settings for AnalyzeCoreDumpWithOptions.
+type CoreDumpOptions struct {
+	// RawOutputLines, when positive, includes the last RawOutputLines lines
+	// of debugger output as raw_output.
+	RawOutputLines int
+	// ExtraCommands are run after the standard analysis; see
+	// AnalyzeCoreDumpWithCommands.
+	ExtraCommands []string
+	// Limits caps the threads and frames parsed.
+	Limits ParseLimits
 }
 
 // AnalyzeCoreDump uses GDB (Linux/other) or LLDB (macOS) to analyze a core
@@ -82,10 +159,39 @@ type threadData struct {
 //   - the debugger times out (60 s)
 //   - the signal cannot be determined from the output (corrupt core or missing
 //     debug info)
-func AnalyzeCoreDump(corePath string, binaryPath string) (map[string]interface{}, error) {
+//
+// When rawOutputLines is positive the last rawOutputLines lines of debugger
+// output are included as raw_output; parsing always uses the full output.
+//
+// crash_signature is a stable hash of the signal and top frames for grouping
+// recurring crashes; see CrashSignatureHash.
+//
+// Threads are parsed within DefaultParseLimits; total_thread_count is the
+// number seen and threads_truncated reports whether any were left out.
+func AnalyzeCoreDump(corePath string, binaryPath string, rawOutputLines int) (map[string]interface{}, error) {
+	return AnalyzeCoreDumpWithOptions(corePath, binaryPath, CoreDumpOptions{RawOutputLines: rawOutputLines})
+}
+
+// AnalyzeCoreDumpWithCommands is AnalyzeCoreDump that also runs extraCommands
+// in the debugger after the standard analysis and returns each command's
+// output under extra_command_output, keyed by command. Commands must pass
+// ValidateDebuggerCommands.
+func AnalyzeCoreDumpWithCommands(corePath string, binaryPath string, rawOutputLines int, extraCommands []string) (map[string]interface{}, error) {
+	return AnalyzeCoreDumpWithOptions(corePath, binaryPath, CoreDumpOptions{
+		RawOutputLines: rawOutputLines,
+		ExtraCommands:  extraCommands,
+	})
+}
+
+// AnalyzeCoreDumpWithOptions is AnalyzeCoreDump with every optional setting.
+func AnalyzeCoreDumpWithOptions(corePath string, binaryPath string, opts CoreDumpOptions) (map[string]interface{}, error) {
 	if corePath == "" {
 		return nil, errors.New("core_path is required")
 	}
+	extraCommands := opts.ExtraCommands
+	if err := ValidateDebuggerCommands(extraCommands); err != nil {
+		return nil, err
+	}
 
 	var (
 		rawOutput string
@@ -93,42 +199,56 @@ func AnalyzeCoreDump(corePath string, binaryPath string) (map[string]interface{}
 		err       error
 	)
 
+	maxOutput := opts.Limits.withDefaults().MaxBytes + debuggerOutputSlack
 	if runtime.GOOS == "darwin" {
 		debugger = "lldb"
-		rawOutput, err = runLLDB(corePath, binaryPath)
+		rawOutput, err = runLLDB(corePath, binaryPath, extraCommands, maxOutput)
 	} else {
 		debugger = "gdb"
-		rawOutput, err = runGDB(corePath, binaryPath)
+		rawOutput, err = runGDB(corePath, binaryPath, extraCommands, maxOutput)
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	// Extra command output is split off first so that, for example, an extra
+	// "bt" cannot add frames to the parsed threads.
+	var (
+		analysisOutput string
+		extraOutput    map[string]string
+	)
+	switch debugger {
+	case "gdb":
+		analysisOutput, extraOutput = splitExtraOutput(rawOutput, extraCommands, gdbMarkers(extraCommands), "")
+	case "lldb":
+		analysisOutput, extraOutput = splitExtraOutput(rawOutput, extraCommands, lldbMarkers(extraCommands), "(lldb) quit")
+	}
+
 	var parsed map[string]interface{}
 	switch debugger {
 	case "gdb":
-		parsed, err = parseGDBOutput(rawOutput)
+		parsed, err = parseGDBOutput(analysisOutput, opts.Limits)
 	case "lldb":
-		parsed, err = parseLLDBOutput(rawOutput)
+		parsed, err = parseLLDBOutput(analysisOutput, opts.Limits)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if len(extraCommands) > 0 {
+		parsed["extra_command_output"] = extraOutput
+	}
 
 	// Enrich with metadata and derived fields.
-	signal, _ := parsed["signal"].(string)
-	bt, _ := parsed["backtrace"].([]string)
-
-	patterns := detectCrashPatterns(signal, bt)
-	parsed["crash_patterns"] = patterns
-
-	sigDesc, _ := parsed["signal_description"].(string)
-	parsed["crash_reason"] = buildCrashReason(signal, sigDesc, bt, patterns)
+	addCrashAnalysis(parsed)
 
 	parsed["debugger"] = debugger
 	parsed["core_path"] = corePath
 	parsed["binary_path"] = binaryPath
 
+	if opts.RawOutputLines > 0 {
+		parsed["raw_output"] = truncate.TailLines(rawOutput, opts.RawOutputLines)
+	}
+
 	return parsed, nil
 }
 
@@ -136,12 +256,10 @@ func AnalyzeCoreDump(corePath string, binaryPath string) (map[string]interface{}
 // Debugger runners
 // ============================================================================
 
-func runGDB(corePath, binaryPath string) (string, error) {
-	// Build argument list.
-	// Order: options, then optional binary, then "-c corefile".
-	// GDB batch mode exits with the inferior's exit status, which is non-zero
-	// for signal-terminated programs. We therefore ignore the exit code and
-	// only treat missing output as an error.
+// gdbArgs builds the GDB command line. Each extra command is preceded by an
+// echoed marker line (see gdbMarkers) so its output can be told apart.
+// Order: options, then optional binary, then "-c corefile".
+func gdbArgs(corePath, binaryPath string, extraCommands []string) []string {
 	args := []string{
 		"--batch",
 		"-ex", "set pagination off",
@@ -149,16 +267,36 @@ func runGDB(corePath, binaryPath string) (string, error) {
 		"-ex", "info threads",
 		"-ex", "thread apply all bt full",
 	}
+	for i, c := range extraCommands {
+		args = append(args,
+			"-ex", "echo \\n"+fmt.Sprintf(extraCommandMarker, i)+"\\n",
+			"-ex", strings.TrimSpace(c),
+		)
+	}
 	if binaryPath != "" {
 		args = append(args, binaryPath)
 	}
-	args = append(args, "-c", corePath)
+	return append(args, "-c", corePath)
+}
 
+// gdbMarkers returns the lines gdbArgs echoes before each extra command.
+func gdbMarkers(extraCommands []string) []string {
+	markers := make([]string, len(extraCommands))
+	for i := range extraCommands {
+		markers[i] = fmt.Sprintf(extraCommandMarker, i)
+	}
+	return markers
+}
+
+func runGDB(corePath, binaryPath string, extraCommands []string, maxOutput int) (string, error) {
+	// GDB batch mode exits with the inferior's exit status, which is non-zero
+	// for signal-terminated programs. We therefore ignore the exit code and
+	// only treat missing output as an error.
 	ctx, cancel := context.WithTimeout(context.Background(), analyzerTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "gdb", args...)
-	out, runErr := cmd.CombinedOutput()
+	cmd := exec.CommandContext(ctx, "gdb", gdbArgs(corePath, binaryPath, extraCommands)...)
+	out, runErr := combinedOutput(cmd, maxOutput)
 
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("gdb timed out after %s", analyzerTimeout)
@@ -176,10 +314,11 @@ func runGDB(corePath, binaryPath string) (string, error) {
 	return string(out), nil
 }
 
-func runLLDB(corePath, binaryPath string) (string, error) {
-	// LLDB argument order: options, then "-c corefile", then optional binary,
-	// then "-o" command strings. We include "quit" so lldb exits cleanly
-	// without waiting for stdin.
+// lldbArgs builds the LLDB command line.
+// Argument order: options, then "-c corefile", then optional binary, then
+// "-o" command strings. Extra commands run after the standard ones, and
+// "quit" comes last so lldb exits cleanly without waiting for stdin.
+func lldbArgs(corePath, binaryPath string, extraCommands []string) []string {
 	args := []string{"-c", corePath}
 	if binaryPath != "" {
 		args = append(args, binaryPath)
@@ -187,14 +326,29 @@ func runLLDB(corePath, binaryPath string) (string, error) {
 	args = append(args,
 		"-o", "thread backtrace all",
 		"-o", "thread list",
-		"-o", "quit",
 	)
+	for _, c := range extraCommands {
+		args = append(args, "-o", strings.TrimSpace(c))
+	}
+	return append(args, "-o", "quit")
+}
 
+// lldbMarkers returns the prompt lines lldb echoes before each extra
+// command's output.
+func lldbMarkers(extraCommands []string) []string {
+	markers := make([]string, len(extraCommands))
+	for i, c := range extraCommands {
+		markers[i] = "(lldb) " + strings.TrimSpace(c)
+	}
+	return markers
+}
+
+func runLLDB(corePath, binaryPath string, extraCommands []string, maxOutput int) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), analyzerTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "lldb", args...)
-	out, runErr := cmd.CombinedOutput()
+	cmd := exec.CommandContext(ctx, "lldb", lldbArgs(corePath, binaryPath, extraCommands)...)
+	out, runErr := combinedOutput(cmd, maxOutput)
 
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("lldb timed out after %s", analyzerTimeout)
@@ -212,6 +366,66 @@ func runLLDB(corePath, binaryPath string) (string, error) {
 	return string(out), nil
 }
 
+// combinedOutput is cmd.CombinedOutput keeping at most about limit bytes:
+// the start, which has the signal, and the end, where GDB prints the
+// crashing thread and both debuggers print extra command output. The middle
+// is read and discarded, so the debugger is not blocked on a full pipe, and
+// replaced by a line saying how much was left out.
+func combinedOutput(cmd *exec.Cmd, limit int) ([]byte, error) {
+	out := &headTailBuffer{headLimit: limit / 2, tailLimit: limit - limit/2}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	return out.bytes(), err
+}
+
+// headTailBuffer is an io.Writer that keeps the first headLimit and the
+// last tailLimit bytes written.
+type headTailBuffer struct {
+	headLimit, tailLimit int
+	head, tail           []byte
+	dropped              int
+}
+
+func (b *headTailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.headLimit - len(b.head); room > 0 {
+		k := min(room, len(p))
+		b.head = append(b.head, p[:k]...)
+		p = p[k:]
+	}
+	b.tail = append(b.tail, p...)
+	if excess := len(b.tail) - b.tailLimit; excess > b.tailLimit {
+		b.dropped += excess
+		b.tail = append(b.tail[:0], b.tail[excess:]...)
+	}
+	return n, nil
+}
+
+// bytes returns what was kept. When output was dropped the tail starts at
+// its first full line.
+func (b *headTailBuffer) bytes() []byte {
+	tail := b.tail
+	dropped := b.dropped
+	if excess := len(tail) - b.tailLimit; excess > 0 {
+		dropped += excess
+		tail = tail[excess:]
+	}
+	if dropped == 0 {
+		return append(b.head, tail...)
+	}
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+		dropped += i + 1
+		tail = tail[i+1:]
+	}
+	out := append([]byte{}, b.head...)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	out = append(out, fmt.Sprintf("... %d bytes of debugger output omitted ...\n", dropped)...)
+	return append(out, tail...)
+}
+
 // isNotFound returns true if err indicates the executable was not found.
 func isNotFound(err error) bool {
 	if err == nil {
@@ -233,7 +447,16 @@ func isNotFound(err error) bool {
 //  3. Primary backtrace from "bt full"  (frame lines "#N ...")
 //  4. "info threads" section (header + per-thread one-liners)
 //  5. "thread apply all bt full" section (per-thread full backtraces)
-func parseGDBOutput(output string) (map[string]interface{}, error) {
+//
+// Threads are kept within limits. The thread marked current in "info
+// threads" (thread 1 if there is no table) is the crashing thread and is
+// always kept in full, as is the primary backtrace.
+//
+// When libthread_db fails, GDB can report a multi-threaded crash as a
+// single thread. thread_info_incomplete is set, with a note saying why, if
+// GDB printed such a failure or listed fewer threads than the LWPs it
+// found in the core.
+func parseGDBOutput(output string, limits ParseLimits) (map[string]interface{}, error) {
 	const (
 		stateSearch     = iota
 		statePrimaryBT  // after signal line, collecting primary backtrace
@@ -244,18 +467,29 @@ func parseGDBOutput(output string) (map[string]interface{}, error) {
 	lines := strings.Split(output, "\n")
 
 	var (
-		signal  string
-		sigDesc string
-		primary = make([]string, 0)
-		threads []threadData
+		signal    string
+		sigDesc   string
+		primary   = make([]string, 0)
+		primaryID = 1
+		threads   = newThreadCollector(limits)
+		lwps      = make(map[string]bool)
+		dbFailure string
 	)
 
 	state := stateSearch
-	var cur *threadData
 
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
 
+		// These can appear anywhere GDB touches the thread list, so they are
+		// noted whatever the state.
+		if m := reGDBNewLWP.FindStringSubmatch(trimmed); m != nil {
+			lwps[m[1]+m[2]] = true
+		} else if dbFailure == "" && reGDBThreadDBFailure.MatchString(trimmed) {
+			dbFailure = trimmed
+			continue
+		}
+
 		switch state {
 		// -------------------------------------------------------------------
 		case stateSearch:
@@ -279,7 +513,7 @@ func parseGDBOutput(output string) (map[string]interface{}, error) {
 				// "info threads" table, or if the table was already printed).
 				state = stateAllThreads
 				id, _ := strconv.Atoi(m[1])
-				cur = &threadData{id: id}
+				threads.start(id, id == primaryID)
 			}
 
 		// -------------------------------------------------------------------
@@ -289,34 +523,26 @@ func parseGDBOutput(output string) (map[string]interface{}, error) {
 			if m := reGDBThreadHdr.FindStringSubmatch(trimmed); m != nil {
 				state = stateAllThreads
 				id, _ := strconv.Atoi(m[1])
-				cur = &threadData{id: id}
+				threads.start(id, id == primaryID)
+			} else if m := reGDBCurrentThread.FindStringSubmatch(trimmed); m != nil {
+				primaryID, _ = strconv.Atoi(m[1])
 			}
 
 		// -------------------------------------------------------------------
 		case stateAllThreads:
 			if m := reGDBThreadHdr.FindStringSubmatch(trimmed); m != nil {
-				// New thread block: save the previous one first.
-				if cur != nil {
-					threads = append(threads, *cur)
-				}
+				// New thread block.
 				id, _ := strconv.Atoi(m[1])
-				cur = &threadData{id: id}
+				threads.start(id, id == primaryID)
 			} else if reGDBFrame.MatchString(line) {
 				// Frame line belonging to the current thread.
-				if cur != nil {
-					cur.frames = append(cur.frames, trimmed)
-				}
+				threads.addFrame(trimmed)
 			}
 			// Any other lines (variable values from "bt full", blank lines,
 			// continuation lines) are intentionally ignored.
 		}
 	}
 
-	// Flush the last thread.
-	if cur != nil && state == stateAllThreads {
-		threads = append(threads, *cur)
-	}
-
 	if signal == "" {
 		return nil, errors.New(
 			"could not determine signal from core dump; " +
@@ -324,11 +550,25 @@ func parseGDBOutput(output string) (map[string]interface{}, error) {
 		)
 	}
 
+	note := ""
+	switch {
+	case dbFailure != "":
+		note = fmt.Sprintf("GDB could not read the thread list (%q), so threads may be missing; "+
+			"load the core with the libpthread and libthread_db it was produced with, e.g. via set sysroot", dbFailure)
+	case len(lwps) > threads.total:
+		note = fmt.Sprintf("the core holds %d LWPs but GDB reported %d thread(s); "+
+			"libthread_db likely does not match the process's thread library", len(lwps), threads.total)
+	}
+
 	return map[string]interface{}{
-		"signal":             signal,
-		"signal_description": sigDesc,
-		"backtrace":          primary,
-		"threads":            threadsToMaps(threads),
+		"signal":                 signal,
+		"signal_description":     sigDesc,
+		"backtrace":              primary,
+		"threads":                threadsToMaps(threads.finish()),
+		"total_thread_count":     threads.total,
+		"threads_truncated":      threads.truncated,
+		"thread_info_incomplete": note != "",
+		"thread_info_note":       note,
 	}, nil
 }
 
@@ -340,15 +580,18 @@ func parseGDBOutput(output string) (map[string]interface{}, error) {
 // "thread backtrace all".  It stops consuming thread/frame data when it
 // encounters the "(lldb) thread list" prompt so the thread list section
 // does not produce duplicate entries.
-func parseLLDBOutput(output string) (map[string]interface{}, error) {
+//
+// Threads are kept within limits. The thread marked "*" (the first thread if
+// none is) is the crashing thread and is always kept in full.
+func parseLLDBOutput(output string, limits ParseLimits) (map[string]interface{}, error) {
 	lines := strings.Split(output, "\n")
 
 	var (
-		signal  string
-		threads []threadData
+		signal    string
+		threads   = newThreadCollector(limits)
+		primaryID = -1
+		starred   bool // primaryID is a thread marked "*"
 	)
-
-	var cur *threadData
 	// inBTSection is true while we are inside the "thread backtrace all"
 	// output and false once we hit the next "(lldb) " prompt.
 	inBTSection := false
@@ -374,11 +617,13 @@ func parseLLDBOutput(output string) (map[string]interface{}, error) {
 
 		// New thread block.
 		if m := reLLDBThreadHdr.FindStringSubmatch(trimmed); m != nil {
-			if cur != nil {
-				threads = append(threads, *cur)
-			}
 			id, _ := strconv.Atoi(m[1])
-			cur = &threadData{id: id}
+			selected := strings.HasPrefix(trimmed, "*")
+			if !starred && (selected || threads.total == 0) {
+				primaryID = id
+				starred = selected
+			}
+			threads.start(id, selected || threads.total == 0)
 
 			// Capture signal from the first thread that carries "stop reason".
 			if signal == "" {
@@ -390,16 +635,11 @@ func parseLLDBOutput(output string) (map[string]interface{}, error) {
 		}
 
 		// Frame line.
-		if cur != nil && reLLDBFrame.MatchString(line) {
-			cur.frames = append(cur.frames, trimmed)
+		if reLLDBFrame.MatchString(line) {
+			threads.addFrame(trimmed)
 		}
 	}
 
-	// Flush last thread.
-	if cur != nil {
-		threads = append(threads, *cur)
-	}
-
 	if signal == "" {
 		return nil, errors.New(
 			"could not determine signal from core dump; " +
@@ -407,19 +647,27 @@ func parseLLDBOutput(output string) (map[string]interface{}, error) {
 		)
 	}
 
-	// Primary backtrace = first thread's frames (the crashing thread).
+	// Primary backtrace = the crashing thread's frames.
+	kept := threads.finish()
 	primary := make([]string, 0)
-	if len(threads) > 0 && threads[0].frames != nil {
-		primary = threads[0].frames
+	for _, t := range kept {
+		if t.id == primaryID && t.frames != nil {
+			primary = t.frames
+			break
+		}
 	}
 
 	sigDesc := signalDescriptions[signal] // empty string if unknown signal
 
 	return map[string]interface{}{
-		"signal":             signal,
-		"signal_description": sigDesc,
-		"backtrace":          primary,
-		"threads":            threadsToMaps(threads),
+		"signal":                 signal,
+		"signal_description":     sigDesc,
+		"backtrace":              primary,
+		"threads":                threadsToMaps(kept),
+		"total_thread_count":     threads.total,
+		"threads_truncated":      threads.truncated,
+		"thread_info_incomplete": false,
+		"thread_info_note":       "",
 	}, nil
 }
 
@@ -427,6 +675,34 @@ func parseLLDBOutput(output string) (map[string]interface{}, error) {
 // Crash pattern detection
 // ============================================================================
 
+// addCrashAnalysis adds the fields derived from a parsed signal and
+// backtrace: crash_patterns, secondary_signal, crash_reason and
+// crash_signature.
+func addCrashAnalysis(parsed map[string]interface{}) {
+	signal, _ := parsed["signal"].(string)
+	bt, _ := parsed["backtrace"].([]string)
+
+	patterns := detectCrashPatterns(signal, bt)
+	parsed["crash_patterns"] = patterns
+
+	// The backtrace shows the handler only when the fatal signal arrived
+	// while another was being handled; that one is the secondary signal. A
+	// handler that re-raises its own signal has no secondary one.
+	secondary := ""
+	if _, handled, ok := signalHandlerFrame(bt); ok && handled != signal {
+		secondary = handled
+	}
+	parsed["secondary_signal"] = secondary
+
+	sigDesc, _ := parsed["signal_description"].(string)
+	reason := buildCrashReason(signal, sigDesc, bt, patterns)
+	if secondary != "" {
+		reason += " while handling " + secondary
+	}
+	parsed["crash_reason"] = reason
+	parsed["crash_signature"] = CrashSignatureHash(signal, bt)
+}
+
 // detectCrashPatterns identifies well-known crash patterns from the signal
 // name and the primary thread's backtrace frames. The returned slice may be
 // empty if no recognised pattern is found.
@@ -481,9 +757,92 @@ func detectCrashPatterns(signal string, bt []string) []string {
 		patterns = append(patterns, "stack_overflow")
 	}
 
+	// So is a fault inside a signal handler, unless the handler re-raised
+	// the signal it was handling, which is how a handler ends the process.
+	if idx, handled, ok := signalHandlerFrame(bt); ok && idx > 0 && handled != signal {
+		patterns = append(patterns, "crash_in_signal_handler")
+	}
+
 	return patterns
 }
 
+// signalHandlerFrame finds the frame where the kernel entered a signal
+// handler, GDB's "<signal handler called>" or LLDB's _sigtramp, and the
+// signal being handled there. Frames above it ran in the handler; frames
+// below it are the code the signal interrupted. The handled signal is read
+// from the handler's signal argument (sig=6), numbered as on the platform
+// the trampoline belongs to, or else from a raise() or abort() in the
+// interrupted code; it is "" if neither says.
+func signalHandlerFrame(bt []string) (int, string, bool) {
+	idx := -1
+	var numbers map[int]string
+	for i, frame := range bt {
+		if strings.Contains(frame, "<signal handler called>") {
+			idx, numbers = i, linuxSignalNumbers
+			break
+		}
+		if strings.Contains(frame, "`_sigtramp") {
+			idx, numbers = i, darwinSignalNumbers
+			break
+		}
+	}
+	if idx < 0 {
+		return -1, "", false
+	}
+
+	// The handler is called with the signal number, so the frame just
+	// above the trampoline shows it.
+	for i := idx - 1; i >= 0; i-- {
+		if m := reSignalArg.FindStringSubmatch(bt[i]); m != nil {
+			if name := signalName(numbers, m[1]); name != "" {
+				return idx, name, true
+			}
+		}
+	}
+	for _, frame := range bt[idx+1:] {
+		if m := reSignalArg.FindStringSubmatch(frame); m != nil {
+			if name := signalName(numbers, m[1]); name != "" {
+				return idx, name, true
+			}
+		}
+		if extractFuncName(frame) == "abort" || strings.Contains(frame, " abort (") {
+			return idx, "SIGABRT", true
+		}
+	}
+	return idx, "", true
+}
+
+// reSignalArg matches a signal-number argument in a frame: "sig=6",
+// "signo=11", "signum=7".
+var reSignalArg = regexp.MustCompile(`\b(?:sig|signo|signum|signal)=(\d+)\b`)
+
+// linuxSignalNumbers maps Linux signal numbers to names for the handler
+// frames GDB prints.
+var linuxSignalNumbers = map[int]string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP",
+	6: "SIGABRT", 7: "SIGBUS", 8: "SIGFPE", 9: "SIGKILL", 10: "SIGUSR1",
+	11: "SIGSEGV", 12: "SIGUSR2", 13: "SIGPIPE", 14: "SIGALRM", 15: "SIGTERM",
+}
+
+// darwinSignalNumbers maps macOS signal numbers to names for the
+// _sigtramp frames LLDB prints. They differ from Linux at 7, 10, 12 and
+// the user signals.
+var darwinSignalNumbers = map[int]string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP",
+	6: "SIGABRT", 7: "SIGEMT", 8: "SIGFPE", 9: "SIGKILL", 10: "SIGBUS",
+	11: "SIGSEGV", 12: "SIGSYS", 13: "SIGPIPE", 14: "SIGALRM", 15: "SIGTERM",
+	30: "SIGUSR1", 31: "SIGUSR2",
+}
+
+// signalName returns the name of signal number n in numbers, or "".
+func signalName(numbers map[int]string, n string) string {
+	num, err := strconv.Atoi(n)
+	if err != nil {
+		return ""
+	}
+	return numbers[num]
+}
+
 // isStackOverflow returns true when three or more consecutive backtrace frames
 // resolve to the same function name, which is a reliable indicator of
 // unbounded recursion.
@@ -576,6 +935,8 @@ func buildCrashReason(signal, sigDesc string, bt []string, patterns []string) st
 			sb.WriteString(" (abort() called)")
 		case "stack_overflow":
 			sb.WriteString(" (recursive stack overflow)")
+		case "crash_in_signal_handler":
+			sb.WriteString(" (in a signal handler)")
 		}
 	}
 
@@ -594,7 +955,8 @@ func crashSite(frame string) string {
 }
 
 // threadsToMaps converts the internal threadData slice to the
-// []map[string]interface{} format expected by callers.
+// []map[string]interface{} format expected by callers. A thread cut short
+// carries frames_truncated and its full frame_count.
 func threadsToMaps(threads []threadData) []map[string]interface{} {
 	out := make([]map[string]interface{}, len(threads))
 	for i, t := range threads {
@@ -606,6 +968,80 @@ func threadsToMaps(threads []threadData) []map[string]interface{} {
 			"id":     t.id,
 			"frames": frames,
 		}
+		if t.frameCount > len(frames) {
+			out[i]["frames_truncated"] = true
+			out[i]["frame_count"] = t.frameCount
+		}
 	}
 	return out
 }
+
+// ============================================================================
+// Thread collection
+// ============================================================================
+
+// threadCollector accumulates parsed threads within ParseLimits. Threads and
+// frames past the limits are counted but not stored.
+type threadCollector struct {
+	limits  ParseLimits
+	threads []threadData
+	// cur is the thread being parsed; nil while skipping a dropped thread.
+	cur        *threadData
+	curPrimary bool
+	// others is the number of non-primary threads kept and bytes the size
+	// of their frames.
+	others int
+	bytes  int
+
+	total     int
+	truncated bool
+}
+
+func newThreadCollector(limits ParseLimits) *threadCollector {
+	return &threadCollector{limits: limits.withDefaults()}
+}
+
+// start begins a thread block. A primary thread is kept whole regardless of
+// the limits; any other is dropped once MaxThreads or MaxBytes is reached.
+func (c *threadCollector) start(id int, primary bool) {
+	c.flush()
+	c.total++
+	c.curPrimary = primary
+	if !primary && (c.others >= c.limits.MaxThreads || c.bytes >= c.limits.MaxBytes) {
+		c.truncated = true
+		return
+	}
+	if !primary {
+		c.others++
+	}
+	c.cur = &threadData{id: id}
+}
+
+// addFrame adds a frame to the current thread.
+func (c *threadCollector) addFrame(frame string) {
+	if c.cur == nil {
+		return
+	}
+	c.cur.frameCount++
+	if !c.curPrimary {
+		if len(c.cur.frames) >= c.limits.MaxFramesPerThread || c.bytes+len(frame) > c.limits.MaxBytes {
+			c.truncated = true
+			return
+		}
+		c.bytes += len(frame)
+	}
+	c.cur.frames = append(c.cur.frames, frame)
+}
+
+func (c *threadCollector) flush() {
+	if c.cur != nil {
+		c.threads = append(c.threads, *c.cur)
+		c.cur = nil
+	}
+}
+
+// finish flushes the last thread and returns the threads kept, in order.
+func (c *threadCollector) finish() []threadData {
+	c.flush()
+	return c.threads
+}
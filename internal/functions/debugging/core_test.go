@@ -0,0 +1,413 @@
+package debugging
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// syntheticGDBOutput builds GDB batch output for a process with threads
+// threads of depth frames each. GDB lists "thread apply all" in descending
+// order, so the crashing thread 1 comes last; its backtrace is primaryDepth
+// frames deep.
+func syntheticGDBOutput(threads, depth, primaryDepth int) string {
+	var sb strings.Builder
+	sb.WriteString("Program terminated with signal SIGSEGV, Segmentation fault.\n")
+	sb.WriteString("#0  0x0000000000401136 in parse_header (buf=0x0) at parser.c:42\n")
+	sb.WriteString("  Id   Target Id                          Frame\n")
+	sb.WriteString("* 1    Thread 0x7f0000000001 (LWP 1000) 0x0000000000401136 in parse_header ()\n")
+	for id := 2; id <= threads; id++ {
+		fmt.Fprintf(&sb, "  %d    Thread 0x7f%010x (LWP %d) 0x00007f0000001000 in futex_wait ()\n", id, id, 1000+id)
+	}
+	for id := threads; id >= 1; id-- {
+		fmt.Fprintf(&sb, "\nThread %d (Thread 0x7f%010x (LWP %d)):\n", id, id, 1000+id)
+		n := depth
+		if id == 1 {
+			n = primaryDepth
+		}
+		for f := 0; f < n; f++ {
+			fmt.Fprintf(&sb, "#%d  0x00007f00000%05x in worker_loop_%d (arg=0x0) at worker.c:%d\n", f, f, id, f+1)
+			sb.WriteString("        local = 0\n")
+		}
+	}
+	return sb.String()
+}
+
+func threadByID(t *testing.T, threads []map[string]interface{}, id int) map[string]interface{} {
+	t.Helper()
+	for _, th := range threads {
+		if th["id"] == id {
+			return th
+		}
+	}
+	t.Fatalf("thread %d not in result", id)
+	return nil
+}
+
+func TestParseGDBOutput_TruncatesThreadsAndFrames(t *testing.T) {
+	output := syntheticGDBOutput(2000, 60, 400)
+
+	parsed, err := parseGDBOutput(output, ParseLimits{MaxThreads: 10, MaxFramesPerThread: 5})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+
+	if parsed["total_thread_count"] != 2000 {
+		t.Errorf("expected total_thread_count 2000, got %v", parsed["total_thread_count"])
+	}
+	if parsed["threads_truncated"] != true {
+		t.Error("expected threads_truncated")
+	}
+
+	threads := parsed["threads"].([]map[string]interface{})
+	if len(threads) != 11 {
+		t.Fatalf("expected 10 threads plus the crashing thread, got %d", len(threads))
+	}
+
+	other := threadByID(t, threads, 2000)
+	if frames := other["frames"].([]string); len(frames) != 5 {
+		t.Errorf("expected 5 frames for thread 2000, got %d", len(frames))
+	}
+	if other["frames_truncated"] != true || other["frame_count"] != 60 {
+		t.Errorf("expected frame truncation noted on thread 2000, got %v", other)
+	}
+
+	crashing := threadByID(t, threads, 1)
+	if frames := crashing["frames"].([]string); len(frames) != 400 {
+		t.Errorf("expected crashing thread kept in full (400 frames), got %d", len(frames))
+	}
+	if _, ok := crashing["frames_truncated"]; ok {
+		t.Error("expected no truncation on the crashing thread")
+	}
+	if bt := parsed["backtrace"].([]string); len(bt) != 1 {
+		t.Errorf("expected primary backtrace untouched, got %d frames", len(bt))
+	}
+}
+
+func TestParseGDBOutput_ByteLimit(t *testing.T) {
+	output := syntheticGDBOutput(100, 10, 10)
+
+	parsed, err := parseGDBOutput(output, ParseLimits{MaxBytes: 2048})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+
+	if parsed["threads_truncated"] != true {
+		t.Error("expected threads_truncated when the byte limit is reached")
+	}
+	threads := parsed["threads"].([]map[string]interface{})
+	size := 0
+	for _, th := range threads {
+		if th["id"] == 1 {
+			continue
+		}
+		for _, f := range th["frames"].([]string) {
+			size += len(f)
+		}
+	}
+	if size > 2048 {
+		t.Errorf("expected at most 2048 bytes of frames, got %d", size)
+	}
+	if frames := threadByID(t, threads, 1)["frames"].([]string); len(frames) != 10 {
+		t.Errorf("expected crashing thread kept in full, got %d frames", len(frames))
+	}
+}
+
+func TestParseGDBOutput_WithinLimits(t *testing.T) {
+	parsed, err := parseGDBOutput(syntheticGDBOutput(4, 3, 3), ParseLimits{})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+
+	if parsed["threads_truncated"] != false || parsed["total_thread_count"] != 4 {
+		t.Errorf("expected 4 threads and no truncation, got %v / %v",
+			parsed["total_thread_count"], parsed["threads_truncated"])
+	}
+	for _, th := range parsed["threads"].([]map[string]interface{}) {
+		if len(th["frames"].([]string)) != 3 {
+			t.Errorf("expected 3 frames for thread %v, got %v", th["id"], th["frames"])
+		}
+	}
+}
+
+func TestParseLLDBOutput_KeepsSelectedThread(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("(lldb) thread backtrace all\n")
+	for id := 1; id <= 50; id++ {
+		if id == 30 {
+			fmt.Fprintf(&sb, "* thread #%d, stop reason = signal SIGSEGV\n", id)
+		} else {
+			fmt.Fprintf(&sb, "  thread #%d\n", id)
+		}
+		for f := 0; f < 20; f++ {
+			fmt.Fprintf(&sb, "    frame #%d: 0x%016x app`handler_%d + 12 at app.c:%d\n", f, f, id, f+1)
+		}
+	}
+	sb.WriteString("(lldb) thread list\n")
+
+	parsed, err := parseLLDBOutput(sb.String(), ParseLimits{MaxThreads: 5, MaxFramesPerThread: 4})
+	if err != nil {
+		t.Fatalf("parseLLDBOutput returned error: %v", err)
+	}
+
+	if parsed["total_thread_count"] != 50 || parsed["threads_truncated"] != true {
+		t.Errorf("expected 50 threads with truncation, got %v / %v",
+			parsed["total_thread_count"], parsed["threads_truncated"])
+	}
+	threads := parsed["threads"].([]map[string]interface{})
+	if frames := threadByID(t, threads, 30)["frames"].([]string); len(frames) != 20 {
+		t.Errorf("expected selected thread kept in full, got %d frames", len(frames))
+	}
+	bt := parsed["backtrace"].([]string)
+	if len(bt) != 20 || !strings.Contains(bt[0], "handler_30") {
+		t.Errorf("expected backtrace from selected thread 30, got %v", bt)
+	}
+}
+
+// nestedSignalGDBOutput is a SIGSEGV raised in a SIGABRT handler: frames
+// above "<signal handler called>" ran in the handler, frames below it are
+// the abort() it interrupted.
+const nestedSignalGDBOutput = `Program terminated with signal SIGSEGV, Segmentation fault.
+#0  0x0000555555555189 in write_crash_report (path=0x0) at crash.c:21
+#1  0x00005555555551c2 in on_fatal_signal (sig=6) at crash.c:40
+#2  <signal handler called>
+#3  __pthread_kill_implementation (no_tid=0, signo=6, threadid=140737351481152) at pthread_kill.c:44
+#4  0x00007ffff7e1b476 in __GI_raise (sig=sig@entry=6) at ../sysdeps/posix/raise.c:26
+#5  0x00007ffff7e017f3 in __GI_abort () at abort.c:79
+#6  0x0000555555555210 in main () at app.c:12
+`
+
+func TestAddCrashAnalysis_SignalHandlerCrash(t *testing.T) {
+	parsed, err := parseGDBOutput(nestedSignalGDBOutput, ParseLimits{})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+	addCrashAnalysis(parsed)
+
+	if parsed["signal"] != "SIGSEGV" || parsed["secondary_signal"] != "SIGABRT" {
+		t.Errorf("expected SIGSEGV while handling SIGABRT, got %v and %v", parsed["signal"], parsed["secondary_signal"])
+	}
+	patterns := parsed["crash_patterns"].([]string)
+	found := false
+	for _, p := range patterns {
+		if p == "crash_in_signal_handler" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected crash_in_signal_handler, got %v", patterns)
+	}
+	reason := parsed["crash_reason"].(string)
+	if !strings.Contains(reason, "crash.c:21") || !strings.HasSuffix(reason, "while handling SIGABRT") {
+		t.Errorf("unexpected crash reason %q", reason)
+	}
+}
+
+func TestSignalHandlerFrame(t *testing.T) {
+	tests := []struct {
+		name      string
+		bt        []string
+		wantIdx   int
+		wantSig   string
+		wantFound bool
+	}{
+		{
+			name: "handled signal from interrupted abort",
+			bt: []string{
+				"#0  0x1 in handler () at h.c:3",
+				"#1  <signal handler called>",
+				"#2  0x2 in abort () at abort.c:79",
+			},
+			wantIdx: 1, wantSig: "SIGABRT", wantFound: true,
+		},
+		{
+			name: "lldb trampoline",
+			bt: []string{
+				"frame #0: 0x1 app`on_signal(signo=11) + 8 at app.c:5",
+				"frame #1: 0x2 libsystem_platform.dylib`_sigtramp + 29",
+				"frame #2: 0x3 app`main + 12 at app.c:20",
+			},
+			wantIdx: 1, wantSig: "SIGSEGV", wantFound: true,
+		},
+		{
+			name: "lldb trampoline numbers signals as macOS",
+			bt: []string{
+				"frame #0: 0x1 app`on_signal(signo=10) + 8 at app.c:5",
+				"frame #1: 0x2 libsystem_platform.dylib`_sigtramp + 29",
+				"frame #2: 0x3 app`main + 12 at app.c:20",
+			},
+			wantIdx: 1, wantSig: "SIGBUS", wantFound: true,
+		},
+		{
+			name: "gdb handler numbers signals as Linux",
+			bt: []string{
+				"#0  0x1 in on_signal (sig=10) at h.c:3",
+				"#1  <signal handler called>",
+				"#2  0x2 in main () at app.c:20",
+			},
+			wantIdx: 1, wantSig: "SIGUSR1", wantFound: true,
+		},
+		{
+			name:    "no handler",
+			bt:      []string{"#0  0x1 in main () at app.c:3"},
+			wantIdx: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, sig, found := signalHandlerFrame(tt.bt)
+			if idx != tt.wantIdx || sig != tt.wantSig || found != tt.wantFound {
+				t.Errorf("got (%d, %q, %v), want (%d, %q, %v)", idx, sig, found, tt.wantIdx, tt.wantSig, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestAddCrashAnalysis_NoSignalHandler(t *testing.T) {
+	parsed, err := parseGDBOutput(syntheticGDBOutput(1, 3, 3), ParseLimits{})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+	addCrashAnalysis(parsed)
+	if parsed["secondary_signal"] != "" {
+		t.Errorf("expected no secondary signal, got %v", parsed["secondary_signal"])
+	}
+	for _, p := range parsed["crash_patterns"].([]string) {
+		if p == "crash_in_signal_handler" {
+			t.Error("did not expect crash_in_signal_handler")
+		}
+	}
+}
+
+func TestAddCrashAnalysis_HandlerOfSameSignalIsNotSecondary(t *testing.T) {
+	parsed := map[string]interface{}{
+		"signal": "SIGSEGV",
+		"backtrace": []string{
+			"#0  0x1 in raise (sig=11) at raise.c:51",
+			"#1  0x2 in on_segv (sig=11) at app.c:9",
+			"#2  <signal handler called>",
+			"#3  0x3 in deref (p=0x0) at app.c:4",
+		},
+	}
+	addCrashAnalysis(parsed)
+	if parsed["secondary_signal"] != "" {
+		t.Errorf("expected no secondary signal for a handler re-raising SIGSEGV, got %v", parsed["secondary_signal"])
+	}
+	if strings.Contains(parsed["crash_reason"].(string), "while handling") {
+		t.Errorf("unexpected crash reason %q", parsed["crash_reason"])
+	}
+}
+
+func TestDetectCrashPatterns_ReRaisingHandlerIsNotACrashInHandler(t *testing.T) {
+	bt := []string{
+		"#0  0x1 in raise (sig=6) at raise.c:51",
+		"#1  0x2 in on_abort (sig=6) at app.c:9",
+		"#2  <signal handler called>",
+		"#3  0x3 in abort () at abort.c:79",
+	}
+	if patterns := detectCrashPatterns("SIGABRT", bt); slices.Contains(patterns, "crash_in_signal_handler") {
+		t.Errorf("expected a handler re-raising SIGABRT not to count as a crash in the handler, got %v", patterns)
+	}
+
+	// A different fatal signal inside the handler still counts.
+	patterns := detectCrashPatterns("SIGSEGV", bt)
+	if !slices.Contains(patterns, "crash_in_signal_handler") {
+		t.Errorf("expected a SIGSEGV inside the SIGABRT handler to be flagged, got %v", patterns)
+	}
+}
+
+func TestParseGDBOutput_ThreadDBFailure(t *testing.T) {
+	output := `[New LWP 4101]
+[New LWP 4102]
+[New LWP 4103]
+Cannot find new threads: generic error
+Core was generated by ` + "`./server`" + `.
+Program terminated with signal SIGABRT, Aborted.
+#0  0x00007f1c2a0b0e97 in raise () from /lib/x86_64-linux-gnu/libc.so.6
+#1  0x00007f1c2a0b2801 in abort () from /lib/x86_64-linux-gnu/libc.so.6
+`
+	parsed, err := parseGDBOutput(output, ParseLimits{})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+
+	if parsed["signal"] != "SIGABRT" || len(parsed["backtrace"].([]string)) != 2 {
+		t.Errorf("expected the signal and backtrace still extracted, got %v / %v", parsed["signal"], parsed["backtrace"])
+	}
+	if parsed["thread_info_incomplete"] != true {
+		t.Fatal("expected thread_info_incomplete after 'Cannot find new threads'")
+	}
+	if note := parsed["thread_info_note"].(string); !strings.Contains(note, "Cannot find new threads") {
+		t.Errorf("expected the note to quote GDB's error, got %q", note)
+	}
+}
+
+func TestParseGDBOutput_FewerThreadsThanLWPs(t *testing.T) {
+	output := `[New LWP 4101]
+[New Thread 0x7f1c29fff700 (LWP 4102)]
+[Thread debugging using libthread_db enabled]
+Program terminated with signal SIGSEGV, Segmentation fault.
+#0  0x0000000000401136 in parse_header (buf=0x0) at parser.c:42
+
+Thread 1 (Thread 0x7f1c2a3c1740 (LWP 4101)):
+#0  0x0000000000401136 in parse_header (buf=0x0) at parser.c:42
+`
+	parsed, err := parseGDBOutput(output, ParseLimits{})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+	if parsed["thread_info_incomplete"] != true || parsed["total_thread_count"] != 1 {
+		t.Fatalf("expected one thread reported as incomplete, got %v / %v",
+			parsed["total_thread_count"], parsed["thread_info_incomplete"])
+	}
+	if note := parsed["thread_info_note"].(string); !strings.Contains(note, "2 LWPs") {
+		t.Errorf("expected the note to give the LWP count, got %q", note)
+	}
+}
+
+func TestParseGDBOutput_CompleteThreadInfo(t *testing.T) {
+	output := "[New LWP 1001]\n[New LWP 1002]\n" + syntheticGDBOutput(2, 2, 2)
+	parsed, err := parseGDBOutput(output, ParseLimits{})
+	if err != nil {
+		t.Fatalf("parseGDBOutput returned error: %v", err)
+	}
+	if parsed["thread_info_incomplete"] != false || parsed["thread_info_note"] != "" {
+		t.Errorf("expected complete thread info, got %v / %q", parsed["thread_info_incomplete"], parsed["thread_info_note"])
+	}
+}
+
+func TestCombinedOutput_KeepsHeadAndTail(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	cmd := exec.Command("sh", "-c", `echo first; i=0; while [ $i -lt 1000 ]; do echo 0123456789; i=$((i+1)); done; echo last >&2`)
+	out, err := combinedOutput(cmd, 128)
+	if err != nil {
+		t.Fatalf("combinedOutput: %v", err)
+	}
+	got := string(out)
+	if len(out) > 128+64 {
+		t.Errorf("expected about 128 bytes kept, got %d", len(out))
+	}
+	if !strings.HasPrefix(got, "first\n") || !strings.HasSuffix(got, "0123456789\nlast\n") {
+		t.Errorf("expected the start and end of the output, got %q", got)
+	}
+	if !strings.Contains(got, "bytes of debugger output omitted") {
+		t.Errorf("expected an omission marker, got %q", got)
+	}
+}
+
+func TestCombinedOutput_UnderLimitUnchanged(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out, err := combinedOutput(exec.Command("sh", "-c", "echo one; echo two >&2"), 1024)
+	if err != nil {
+		t.Fatalf("combinedOutput: %v", err)
+	}
+	if string(out) != "one\ntwo\n" {
+		t.Errorf("expected output unchanged, got %q", out)
+	}
+}
@@ -0,0 +1,251 @@
+package debugging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// signatureFrames is how many top backtrace frames identify a crash site.
+const signatureFrames = 3
+
+// reCloneSuffix matches the suffixes compilers append to specialised or
+// split copies of a function, e.g. "parse.isra.0", "run.part.3", "main.cold".
+var reCloneSuffix = regexp.MustCompile(`(\.(isra|constprop|part|cold|lto_priv)(\.\d+)?)+$`)
+
+// reFrameOffset matches a trailing "+0x1a" offset into a function. Only a
+// hex offset at the end is matched, so names like "operator+" are kept.
+var reFrameOffset = regexp.MustCompile(`\+0x[0-9a-fA-F]+$`)
+
+// CrashGroup is a set of core dumps that share a crash signature, i.e. are
+// most likely the same bug. Signature is the readable form of the first
+// core's crash, CrashSignature the hash the cores were grouped by.
+type CrashGroup struct {
+	Signature      string   `json:"signature"`
+	CrashSignature string   `json:"crash_signature"`
+	Count       int      `json:"count"`
+	ExampleCore string   `json:"example_core"`
+	CrashReason string   `json:"crash_reason"`
+	Cores       []string `json:"cores"`
+}
+
+// TriageFailure records a core that could not be analyzed.
+type TriageFailure struct {
+	CorePath string `json:"core_path"`
+	Error    string `json:"error"`
+}
+
+// TriageResult summarizes a directory of core dumps.
+type TriageResult struct {
+	Dir      string          `json:"dir"`
+	Total    int             `json:"total"`
+	Analyzed int             `json:"analyzed"`
+	Groups   []CrashGroup    `json:"groups"`
+	Failures []TriageFailure `json:"failures"`
+}
+
+// TriageCoreDir runs AnalyzeCoreDump over every core file in dir, at most
+// concurrency at a time, and groups the results by crash signature. Files
+// named "core", "core.*", or "*.core" are treated as cores.
+func TriageCoreDir(dir string, binaryPath string, concurrency int) (*TriageResult, error) {
+	cores, err := findCoreFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	results := make([]map[string]interface{}, len(cores))
+	errs := make([]error, len(cores))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, core := range cores {
+		wg.Add(1)
+		go func(i int, core string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = AnalyzeCoreDump(core, binaryPath, 0)
+		}(i, core)
+	}
+	wg.Wait()
+
+	triage := &TriageResult{
+		Dir:      dir,
+		Total:    len(cores),
+		Failures: make([]TriageFailure, 0),
+	}
+	analyzed := make([]map[string]interface{}, 0, len(cores))
+	for i, core := range cores {
+		if errs[i] != nil {
+			triage.Failures = append(triage.Failures, TriageFailure{CorePath: core, Error: errs[i].Error()})
+			continue
+		}
+		analyzed = append(analyzed, results[i])
+	}
+	triage.Analyzed = len(analyzed)
+	triage.Groups = GroupCrashes(analyzed)
+
+	return triage, nil
+}
+
+// GroupCrashes buckets AnalyzeCoreDump results by their crash_signature
+// hash (see CrashSignatureHash), largest group first. Cores within a group
+// keep their input order, and the first is used as the example.
+func GroupCrashes(results []map[string]interface{}) []CrashGroup {
+	index := make(map[string]int)
+	groups := make([]CrashGroup, 0)
+
+	for _, r := range results {
+		hash, _ := r["crash_signature"].(string)
+		if hash == "" {
+			signal, _ := r["signal"].(string)
+			hash = CrashSignatureHash(signal, stringSlice(r["backtrace"]))
+		}
+		core, _ := r["core_path"].(string)
+
+		i, ok := index[hash]
+		if !ok {
+			reason, _ := r["crash_reason"].(string)
+			index[hash] = len(groups)
+			groups = append(groups, CrashGroup{
+				Signature:      CrashSignature(r),
+				CrashSignature: hash,
+				ExampleCore:    core,
+				CrashReason:    reason,
+				Cores:          make([]string, 0),
+			})
+			i = len(groups) - 1
+		}
+		groups[i].Count++
+		groups[i].Cores = append(groups[i].Cores, core)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		if groups[a].Count != groups[b].Count {
+			return groups[a].Count > groups[b].Count
+		}
+		return groups[a].Signature < groups[b].Signature
+	})
+	return groups
+}
+
+// CrashSignature identifies a crash by its signal, the function names of the
+// top backtrace frames, and the detected crash patterns, e.g.
+// "SIGSEGV | parse_header < read_frame < main | null_pointer_dereference".
+// Addresses and line numbers are left out so rebuilt binaries still match.
+func CrashSignature(result map[string]interface{}) string {
+	signal, _ := result["signal"].(string)
+	if signal == "" {
+		signal = "unknown"
+	}
+
+	names := signatureFrameNames(stringSlice(result["backtrace"]))
+
+	patterns := stringSlice(result["crash_patterns"])
+	sorted := make([]string, len(patterns))
+	copy(sorted, patterns)
+	sort.Strings(sorted)
+
+	return fmt.Sprintf("%s | %s | %s", signal, strings.Join(names, " < "), strings.Join(sorted, ","))
+}
+
+// CrashSignatureHash returns a short stable hash of signal and the
+// normalized names of the top backtrace frames, reported by AnalyzeCoreDump
+// as crash_signature. Two cores of the same bug hash alike even when they
+// come from rebuilt binaries or differently laid out processes.
+//
+// Normalization, per frame (see signatureFrameNames):
+//   - only the function name is kept; addresses, arguments, modules, and
+//     file:line locations are dropped
+//   - "+0x1a" style offsets are stripped
+//   - compiler clone suffixes (.isra.N, .constprop.N, .part.N, .cold,
+//     .lto_priv.N) are stripped
+//   - frames with no recoverable name become "??"
+func CrashSignatureHash(signal string, backtrace []string) string {
+	if signal == "" {
+		signal = "unknown"
+	}
+	sum := sha256.Sum256([]byte(signal + "\n" + strings.Join(signatureFrameNames(backtrace), "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// signatureFrameNames returns the normalized function names of the top
+// signatureFrames frames of backtrace.
+func signatureFrameNames(backtrace []string) []string {
+	names := make([]string, 0, signatureFrames)
+	for _, frame := range backtrace {
+		if len(names) == signatureFrames {
+			break
+		}
+		names = append(names, normalizeFrameName(frame))
+	}
+	return names
+}
+
+// normalizeFrameName reduces a GDB or LLDB frame line to a bare function
+// name, handling GDB frames without an address ("#0  func (args) at f.c:1").
+func normalizeFrameName(frame string) string {
+	name := extractFuncName(frame)
+	if name == "" {
+		if fields := strings.Fields(frame); len(fields) >= 2 && strings.HasPrefix(fields[0], "#") {
+			name = fields[1]
+			if i := strings.Index(name, "("); i >= 0 {
+				name = name[:i]
+			}
+		}
+	}
+	name = reFrameOffset.ReplaceAllString(name, "")
+	name = reCloneSuffix.ReplaceAllString(name, "")
+	if name == "" {
+		return "??"
+	}
+	return name
+}
+
+// stringSlice accepts a []string from AnalyzeCoreDump or the []interface{}
+// it becomes after a JSON round trip.
+func stringSlice(v interface{}) []string {
+	switch t := v.(type) {
+	case []string:
+		return t
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// findCoreFiles lists core dump files directly inside dir in lexical order.
+func findCoreFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read core directory %s: %w", dir, err)
+	}
+
+	var cores []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if name == "core" || strings.HasPrefix(name, "core.") || strings.HasSuffix(name, ".core") {
+			cores = append(cores, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(cores)
+	return cores, nil
+}
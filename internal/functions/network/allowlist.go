@@ -0,0 +1,148 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTargetNotAllowed is returned when a probe's target is outside the
+// configured allowlist.
+var ErrTargetNotAllowed = errors.New("target not allowed")
+
+// allowlistResolveTimeout bounds the lookup of a hostname checked against
+// the allowlist's networks.
+const allowlistResolveTimeout = 2 * time.Second
+
+// TargetAllowlist is the set of hosts and networks the network functions
+// may probe.
+type TargetAllowlist struct {
+	nets     []*net.IPNet
+	patterns []string
+}
+
+var (
+	allowlistMu sync.RWMutex
+	allowlist   *TargetAllowlist
+)
+
+// ParseTargetAllowlist parses allowlist entries: CIDR ranges
+// ("10.0.0.0/8"), single addresses ("192.0.2.10"), and hostname patterns
+// with * wildcards ("*.internal", "api.example.com"). An empty list allows
+// every target.
+func ParseTargetAllowlist(entries []string) (*TargetAllowlist, error) {
+	a := &TargetAllowlist{}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			return nil, errors.New("empty target allowlist entry")
+		case strings.Contains(entry, "/"):
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR '%s' in target allowlist", entry)
+			}
+			a.nets = append(a.nets, ipNet)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			a.nets = append(a.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			if _, err := path.Match(entry, ""); err != nil {
+				return nil, fmt.Errorf("invalid hostname pattern '%s' in target allowlist", entry)
+			}
+			a.patterns = append(a.patterns, entry)
+		}
+	}
+	if len(a.nets) == 0 && len(a.patterns) == 0 {
+		return nil, nil
+	}
+	return a, nil
+}
+
+// SetTargetAllowlist restricts every network function to the targets in
+// entries (see ParseTargetAllowlist). An empty list lifts the restriction.
+func SetTargetAllowlist(entries []string) error {
+	a, err := ParseTargetAllowlist(entries)
+	if err != nil {
+		return err
+	}
+	allowlistMu.Lock()
+	allowlist = a
+	allowlistMu.Unlock()
+	return nil
+}
+
+// Allows reports whether host, a hostname or IP address, may be probed. A
+// hostname matching a pattern is allowed; any other hostname is allowed
+// only if every address it resolves to is inside an allowed network, so
+// a name cannot be used to reach an address outside them.
+func (a *TargetAllowlist) Allows(ctx context.Context, host string) bool {
+	if a == nil {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(strings.Trim(host, "[]"), "."))
+	if ip := net.ParseIP(host); ip != nil {
+		return a.allowsIP(ip)
+	}
+	for _, p := range a.patterns {
+		if ok, _ := path.Match(p, host); ok {
+			return true
+		}
+	}
+	if len(a.nets) == 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, allowlistResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return false
+	}
+	for _, addr := range addrs {
+		if !a.allowsIP(addr.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *TargetAllowlist) allowsIP(ip net.IP) bool {
+	for _, n := range a.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTarget returns an error wrapping ErrTargetNotAllowed if host is
+// outside the allowlist set by SetTargetAllowlist.
+func checkTarget(ctx context.Context, host string) error {
+	allowlistMu.RLock()
+	a := allowlist
+	allowlistMu.RUnlock()
+	if a.Allows(ctx, host) {
+		return nil
+	}
+	return fmt.Errorf("%w: '%s' is outside executor.target_allowlist", ErrTargetNotAllowed, host)
+}
+
+// checkURLTarget is checkTarget for the host of a URL.
+func checkURLTarget(ctx context.Context, rawURL string) error {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	return checkTarget(ctx, u.Hostname())
+}
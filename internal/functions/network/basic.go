@@ -3,15 +3,22 @@ package network
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	neturl "net/url"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/friday/internal/truncate"
 )
 
 // ============================================================================
@@ -27,11 +34,110 @@ type PingResult struct {
 	MinLatencyMs      float64 `json:"min_latency_ms"`
 	AvgLatencyMs      float64 `json:"avg_latency_ms"`
 	MaxLatencyMs      float64 `json:"max_latency_ms"`
-	RawOutput         string  `json:"raw_output"`
+	// LostSequences are the icmp_seq numbers that got no reply, and
+	// LossPattern classifies them as LossNone, LossUniform, or LossBursty.
+	LostSequences []int  `json:"lost_sequences"`
+	LossPattern   string `json:"loss_pattern"`
+	// ICMPErrors lists each ICMP error type seen in place of a reply, such
+	// as ICMPHostUnreachable, in the order first seen, and LikelyCause says
+	// what the most telling of them points to.
+	ICMPErrors  []string `json:"icmp_errors"`
+	LikelyCause string   `json:"likely_cause,omitempty"`
+	RawOutput   string   `json:"raw_output"`
+	// PayloadSweep has one entry per PingOptions.PayloadSizes, smallest
+	// first, and PathMTU the MTU it points to; see sweepPayloadSizes.
+	PayloadSweep []PayloadSizeResult `json:"payload_sweep,omitempty"`
+	PathMTU      int                 `json:"path_mtu,omitempty"`
+}
+
+// PingOptions adjusts how PingWithOptions runs.
+type PingOptions struct {
+	// PayloadSizes are ICMP payload sizes in bytes to ping with the
+	// don't-fragment bit set, after the plain ping, to find where large
+	// packets stop getting through. At most MaxPayloadSizes.
+	PayloadSizes []int
+	// Run runs a command and returns its combined output; nil uses
+	// os/exec. Tests replace it to supply canned ping output.
+	Run func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+func (o PingOptions) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if o.Run != nil {
+		return o.Run(ctx, name, args...)
+	}
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// Loss patterns reported in PingResult.LossPattern.
+const (
+	LossNone    = "none"    // every packet was answered
+	LossUniform = "uniform" // losses are isolated and spread out: sustained degradation
+	LossBursty  = "bursty"  // losses come in consecutive runs: a brief outage
+)
+
+// ICMP error types reported in PingResult.ICMPErrors.
+const (
+	ICMPNetUnreachable      = "net_unreachable"
+	ICMPHostUnreachable     = "host_unreachable"
+	ICMPPortUnreachable     = "port_unreachable"
+	ICMPProtocolUnreachable = "protocol_unreachable"
+	ICMPAdminProhibited     = "admin_prohibited"
+	ICMPFragNeeded          = "frag_needed"
+	ICMPTTLExceeded         = "ttl_exceeded"
+)
+
+// icmpErrorPatterns match the ICMP error messages of Linux (iputils), macOS
+// and Windows ping. A line counts as the first type it matches.
+var icmpErrorPatterns = []struct {
+	re   *regexp.Regexp
+	kind string
+}{
+	{regexp.MustCompile(`(?i)packet filtered|communication (?:administratively )?prohibited|destination (?:host|net) prohibited`), ICMPAdminProhibited},
+	{regexp.MustCompile(`(?i)destination net(?:work)? unreachable`), ICMPNetUnreachable},
+	{regexp.MustCompile(`(?i)destination host unreachable`), ICMPHostUnreachable},
+	{regexp.MustCompile(`(?i)destination port unreachable`), ICMPPortUnreachable},
+	{regexp.MustCompile(`(?i)destination protocol unreachable`), ICMPProtocolUnreachable},
+	{regexp.MustCompile(`(?i)frag(?:mentation)? needed|packet needs to be fragmented|message too long`), ICMPFragNeeded},
+	{regexp.MustCompile(`(?i)time to live exceeded|ttl expired in transit`), ICMPTTLExceeded},
 }
 
+// icmpLikelyCauses explains each ICMP error type, in the order LikelyCause
+// prefers them: an explicit filter says more than a missing route, which
+// says more than a silent host.
+var icmpLikelyCauses = []struct {
+	kind  string
+	cause string
+}{
+	{ICMPAdminProhibited, "a firewall or ACL on the path is rejecting the traffic; the host may well be up"},
+	{ICMPTTLExceeded, "the packets expire in transit, usually a routing loop; run traceroute to find it"},
+	{ICMPNetUnreachable, "a router has no route to the destination network; check routing tables and the default gateway"},
+	{ICMPFragNeeded, "a hop needs smaller packets than were sent with don't-fragment set; check the path MTU"},
+	{ICMPHostUnreachable, "the last router got no ARP/NDP answer for the host: it is down, disconnected, or on the wrong subnet"},
+	{ICMPProtocolUnreachable, "the host is up but does not handle the protocol"},
+	{ICMPPortUnreachable, "the host is up but nothing listens on the port"},
+}
+
+// Compiled regexes for per-reply ping output lines.
+var (
+	rePingSeq     = regexp.MustCompile(`\bicmp_seq=(\d+)`)
+	rePingWinLine = regexp.MustCompile(`^(Reply from|Request timed out|Destination host unreachable|General failure|PING: transmit failed)`)
+)
+
 // Ping sends ICMP ping packets to a host.
 func Ping(host string, count int) (*PingResult, error) {
+	return PingWithOptions(host, count, PingOptions{})
+}
+
+// PingWithOptions is Ping with a don't-fragment payload-size sweep and a
+// replaceable command runner.
+func PingWithOptions(host string, count int, opts PingOptions) (*PingResult, error) {
+	sizes, err := checkPayloadSizes(opts.PayloadSizes)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTarget(context.Background(), host); err != nil {
+		return nil, err
+	}
 	if count <= 0 {
 		count = 3
 	}
@@ -42,16 +148,13 @@ func Ping(host string, count int) (*PingResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(count*5)*time.Second)
 	defer cancel()
 
-	var cmd *exec.Cmd
 	countStr := strconv.Itoa(count)
-
+	args := []string{"-c", countStr, host}
 	if runtime.GOOS == "windows" {
-		cmd = exec.CommandContext(ctx, "ping", "-n", countStr, host)
-	} else {
-		cmd = exec.CommandContext(ctx, "ping", "-c", countStr, host)
+		args = []string{"-n", countStr, host}
 	}
 
-	output, err := cmd.CombinedOutput()
+	output, err := opts.run(ctx, "ping", args...)
 	outputStr := string(output)
 
 	result := &PingResult{
@@ -63,12 +166,18 @@ func Ping(host string, count int) (*PingResult, error) {
 		result.Reachable = false
 		result.PacketsReceived = 0
 		result.PacketLossPercent = 100
+		result.LostSequences = lostPingSequences("", count, firstPingSeq())
+		result.LossPattern = classifyLoss(result.LostSequences)
+		// The ICMP errors are what tell a dead host from a blocked one.
+		parseICMPErrors(outputStr, result)
+		sweepPayloadSizes(host, sizes, opts, result)
 		return result, nil // Return result, not error - ping failure is a valid result
 	}
 
 	// Parse output
 	result.Reachable = true
 	parsePingOutput(outputStr, result)
+	sweepPayloadSizes(host, sizes, opts, result)
 
 	return result, nil
 }
@@ -130,6 +239,106 @@ func parsePingOutput(output string, result *PingResult) {
 	if result.PacketLossPercent >= 100 {
 		result.Reachable = false
 	}
+
+	result.LostSequences = lostPingSequences(output, result.PacketsSent, firstPingSeq())
+	result.LossPattern = classifyLoss(result.LostSequences)
+	parseICMPErrors(output, result)
+}
+
+// parseICMPErrors records the ICMP error types in ping output and the
+// likely cause they point to.
+func parseICMPErrors(output string, result *PingResult) {
+	result.ICMPErrors = []string{}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		for _, p := range icmpErrorPatterns {
+			if p.re.MatchString(line) {
+				if !seen[p.kind] {
+					seen[p.kind] = true
+					result.ICMPErrors = append(result.ICMPErrors, p.kind)
+				}
+				break
+			}
+		}
+	}
+
+	result.LikelyCause = ""
+	for _, c := range icmpLikelyCauses {
+		if seen[c.kind] {
+			result.LikelyCause = c.cause
+			return
+		}
+	}
+}
+
+// firstPingSeq returns the icmp_seq of the first packet: macOS numbers from
+// 0, Linux and Windows from 1.
+func firstPingSeq() int {
+	if runtime.GOOS == "darwin" {
+		return 0
+	}
+	return 1
+}
+
+// lostPingSequences returns the sequence numbers, from firstSeq, of the sent
+// packets that got no reply. Replies are matched by icmp_seq; Windows output
+// has none, so its reply and timeout lines are numbered in order instead.
+func lostPingSequences(output string, sent, firstSeq int) []int {
+	answered := make(map[int]bool)
+	if strings.Contains(output, "icmp_seq=") {
+		for _, line := range strings.Split(output, "\n") {
+			// Only echo replies count; "Destination Host Unreachable" lines
+			// carry an icmp_seq too.
+			if !strings.Contains(line, "bytes from") {
+				continue
+			}
+			if m := rePingSeq.FindStringSubmatch(line); len(m) > 1 {
+				seq, _ := strconv.Atoi(m[1])
+				answered[seq] = true
+			}
+		}
+	} else {
+		seq := firstSeq
+		for _, line := range strings.Split(output, "\n") {
+			line = strings.TrimSpace(line)
+			if !rePingWinLine.MatchString(line) {
+				continue
+			}
+			// "Reply from X: Destination host unreachable." is not an echo reply.
+			if strings.HasPrefix(line, "Reply from") && strings.Contains(line, "bytes=") {
+				answered[seq] = true
+			}
+			seq++
+		}
+	}
+
+	lost := []int{}
+	for seq := firstSeq; seq < firstSeq+sent; seq++ {
+		if !answered[seq] {
+			lost = append(lost, seq)
+		}
+	}
+	return lost
+}
+
+// classifyLoss calls loss bursty when lost packets arrive in consecutive runs
+// averaging at least two packets, and uniform when they are mostly isolated.
+// lost must be sorted. Losing every packet is one run, so it reads as bursty;
+// Reachable=false tells that apart from a brief outage.
+func classifyLoss(lost []int) string {
+	if len(lost) == 0 {
+		return LossNone
+	}
+	runs := 1
+	for i := 1; i < len(lost); i++ {
+		if lost[i] != lost[i-1]+1 {
+			runs++
+		}
+	}
+	if len(lost) >= 2*runs {
+		return LossBursty
+	}
+	return LossUniform
 }
 
 // ============================================================================
@@ -218,10 +427,7 @@ func DNSLookup(domain string, recordType string) (*DNSResult, error) {
 		txts, err := net.LookupTXT(domain)
 		if err == nil {
 			for _, txt := range txts {
-				value := txt
-				if len(value) > 100 {
-					value = value[:100] + "..."
-				}
+				value := truncate.String(txt, 100)
 				result.Records = append(result.Records, DNSRecord{
 					Type:  "TXT",
 					Value: value,
@@ -249,6 +455,35 @@ type PortScanResult struct {
 	ClosedPorts  []int `json:"closed_ports"`
 	TotalScanned int   `json:"total_scanned"`
 	OpenCount    int   `json:"open_count"`
+
+	// ClosedReasons maps each closed port to its failure category; each
+	// category seen is explained once in Explanations.
+	ClosedReasons map[int]string                  `json:"closed_reasons,omitempty"`
+	Explanations  map[string]ConnErrorExplanation `json:"explanations,omitempty"`
+
+	// Retried maps each port that was re-dialed to the number of retries
+	// made. An open port listed here only answered after a retry.
+	Retried map[int]int `json:"retried,omitempty"`
+}
+
+// MaxPortScanRetries caps PortScanOptions.Retries.
+const MaxPortScanRetries = 5
+
+// defaultPortScanRetryDelay is the pause before each re-dial when
+// PortScanOptions.RetryDelay is unset.
+const defaultPortScanRetryDelay = 100 * time.Millisecond
+
+// PortScanOptions holds the optional settings for PortScanWithOptions.
+type PortScanOptions struct {
+	// Retries is how many more times a port whose dial was refused or reset
+	// is dialed before it is reported closed, so a dial dropped under load
+	// does not hide an open port. Timed-out dials are not retried.
+	Retries int
+	// RetryDelay is the pause before each retry (default 100ms).
+	RetryDelay time.Duration
+	// Dial opens the connection (default net.DialTimeout); tests inject a
+	// flaky dialer here.
+	Dial func(network, address string, timeout time.Duration) (net.Conn, error)
 }
 
 // CommonPorts is a list of commonly used ports.
@@ -256,6 +491,25 @@ var CommonPorts = []int{22, 80, 443, 3000, 3306, 5432, 6379, 8000, 8080, 8443, 9
 
 // PortScan checks if TCP ports are open on a host.
 func PortScan(host string, portsParam string) (*PortScanResult, error) {
+	return PortScanWithOptions(host, portsParam, PortScanOptions{})
+}
+
+// PortScanWithOptions is PortScan with retries of ports that appear closed;
+// see PortScanOptions.
+func PortScanWithOptions(host string, portsParam string, opts PortScanOptions) (*PortScanResult, error) {
+	if opts.Retries < 0 || opts.Retries > MaxPortScanRetries {
+		return nil, fmt.Errorf("retries must be between 0 and %d, got %d", MaxPortScanRetries, opts.Retries)
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultPortScanRetryDelay
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	if err := checkTarget(context.Background(), host); err != nil {
+		return nil, err
+	}
+
 	var ports []int
 
 	if portsParam == "" || portsParam == "common" {
@@ -274,20 +528,25 @@ func PortScan(host string, portsParam string) (*PortScanResult, error) {
 	}
 
 	result := &PortScanResult{
-		OpenPorts:    make([]int, 0),
-		ClosedPorts:  make([]int, 0),
-		TotalScanned: len(ports),
+		OpenPorts:     make([]int, 0),
+		ClosedPorts:   make([]int, 0),
+		TotalScanned:  len(ports),
+		ClosedReasons: make(map[int]string),
+		Explanations:  make(map[string]ConnErrorExplanation),
+		Retried:       make(map[int]int),
 	}
 
-	timeout := 2 * time.Second
-
 	for _, port := range ports {
-		addr := fmt.Sprintf("%s:%d", host, port)
-		conn, err := net.DialTimeout("tcp", addr, timeout)
+		retries, err := scanPort(host, port, opts)
+		if retries > 0 {
+			result.Retried[port] = retries
+		}
 		if err != nil {
 			result.ClosedPorts = append(result.ClosedPorts, port)
+			explanation := ClassifyConnError(err)
+			result.ClosedReasons[port] = explanation.Category
+			result.Explanations[explanation.Category] = explanation
 		} else {
-			conn.Close()
 			result.OpenPorts = append(result.OpenPorts, port)
 		}
 	}
@@ -297,6 +556,133 @@ func PortScan(host string, portsParam string) (*PortScanResult, error) {
 	return result, nil
 }
 
+// scanPort dials host:port, retrying refused or reset dials up to
+// opts.Retries times, and returns the retries made and the last dial error
+// if the port never answered. A dial that timed out is not retried: the
+// scan is sequential, and re-dialing a filtered port would spend another
+// full timeout per retry.
+func scanPort(host string, port int, opts PortScanOptions) (int, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	timeout := 2 * time.Second
+
+	var err error
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(opts.RetryDelay)
+		}
+		var conn net.Conn
+		conn, err = opts.Dial("tcp", addr, timeout)
+		if err == nil {
+			conn.Close()
+			return attempt, nil
+		}
+		if !retryableDialError(err) {
+			return attempt, err
+		}
+	}
+	return opts.Retries, err
+}
+
+// retryableDialError reports whether a failed dial is worth repeating: a
+// refusal or reset from a host that may be momentarily overloaded.
+func retryableDialError(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// ============================================================================
+// Source-bound Connect
+// ============================================================================
+
+// ConnectFromResult holds the result of a TCP connect from a chosen source address.
+type ConnectFromResult struct {
+	SourceIP      string `json:"source_ip"`
+	Interface     string `json:"interface"`
+	Target        string `json:"target"`
+	LocalAddr     string `json:"local_addr,omitempty"`
+	RemoteAddr    string `json:"remote_addr,omitempty"`
+	Success       bool   `json:"success"`
+	ConnectTimeMs int64  `json:"connect_time_ms"`
+	Error         string `json:"error,omitempty"`
+
+	Explanation *ConnErrorExplanation `json:"explanation,omitempty"`
+}
+
+// ConnectFrom opens a TCP connection to host:port with the local socket bound
+// to sourceIP, answering whether a particular interface can reach the target.
+// A failed connection is reported in the result; an error is returned only
+// when sourceIP is invalid or not assigned to a local interface, or host is
+// a unix:// socket, which has no source address to bind.
+func ConnectFrom(sourceIP string, host string, port int) (*ConnectFromResult, error) {
+	if _, ok := UnixSocketPath(host); ok {
+		return nil, fmt.Errorf("cannot bind source IP for unix socket '%s'; use check_unix_socket instead", host)
+	}
+	ip := net.ParseIP(sourceIP)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid source IP '%s'", sourceIP)
+	}
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	if err := checkTarget(context.Background(), host); err != nil {
+		return nil, err
+	}
+
+	iface, err := interfaceForIP(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConnectFromResult{
+		SourceIP:  ip.String(),
+		Interface: iface,
+		Target:    net.JoinHostPort(host, strconv.Itoa(port)),
+	}
+
+	dialer := net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: ip},
+		Timeout:   5 * time.Second,
+	}
+
+	start := time.Now()
+	conn, err := dialer.Dial("tcp", result.Target)
+	result.ConnectTimeMs = time.Since(start).Milliseconds()
+	if err != nil {
+		explanation := ClassifyConnError(err)
+		result.Error = err.Error()
+		result.Explanation = &explanation
+		return result, nil
+	}
+	defer conn.Close()
+
+	result.Success = true
+	result.LocalAddr = conn.LocalAddr().String()
+	result.RemoteAddr = conn.RemoteAddr().String()
+
+	return result, nil
+}
+
+// interfaceForIP returns the name of the local interface that has ip assigned.
+func interfaceForIP(ip net.IP) (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("failed to get interfaces: %w", err)
+	}
+
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.Name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("source IP '%s' is not assigned to any local interface", ip)
+}
+
 // ============================================================================
 // HTTP Request
 // ============================================================================
@@ -309,44 +695,168 @@ type HTTPResult struct {
 	Headers        map[string]string `json:"headers"`
 	Protocol       string            `json:"protocol"`
 	Success        bool              `json:"success"`
+	Proxy          string            `json:"proxy,omitempty"`
+	// RequestID is the X-Request-ID sent with the request, for finding it
+	// in the target's logs.
+	RequestID string `json:"request_id"`
+	// RedirectChain lists each redirect followed, as "status from -> to".
+	RedirectChain []string `json:"redirect_chain"`
+	// Latency is the distribution over repeated requests; the other fields
+	// then describe the last successful one. Nil for a single request.
+	Latency *LatencyStats `json:"latency,omitempty"`
+	// Revocation is the OCSP status of the server certificate, set only
+	// for HTTPS when the check was asked for.
+	Revocation *CertRevocation `json:"revocation,omitempty"`
 }
 
+// DefaultMaxRedirects is the number of redirects http_request follows when
+// the caller does not say otherwise.
+const DefaultMaxRedirects = 5
+
 // HTTPRequest makes an HTTP/HTTPS request and returns response info.
-func HTTPRequest(url string, method string) (*HTTPResult, error) {
+// proxy may be an http://, https://, or socks5:// URL with optional
+// credentials; when empty, HTTP_PROXY/HTTPS_PROXY/NO_PROXY are honored.
+// The request carries the configured User-Agent and a generated X-Request-ID.
+// Up to maxRedirects redirects are followed and recorded in RedirectChain;
+// with 0 the first response is returned as-is, even if it is a 3xx.
+func HTTPRequest(url string, method string, proxy string, maxRedirects int) (*HTTPResult, error) {
+	return httpRequest(context.Background(), url, method, proxy, maxRedirects, false)
+}
+
+// HTTPRequestOptions adds optional checks to HTTPRequestSampled.
+type HTTPRequestOptions struct {
+	// CheckRevocation looks up the OCSP status of an HTTPS server's
+	// certificate once, after the last request, and reports it in
+	// Revocation; this can mean a request to the CA's OCSP responder.
+	CheckRevocation bool
+	// ColdStart sends every sample over one connection pool, as a real
+	// client would, and compares the first request, which sets up the
+	// connection, with the ones after it in Latency.ColdStart. It needs at
+	// least 2 samples.
+	ColdStart bool
+}
+
+// HTTPRequestSampled is HTTPRequest repeated samples times in sequence, with
+// the latency distribution in Latency. With one sample it is HTTPRequest.
+// Failed requests are counted in Latency.Failed; an error is returned only
+// when every request fails or ctx is cancelled between samples. Without
+// opts.ColdStart each sample opens its own connection.
+func HTTPRequestSampled(ctx context.Context, url string, method string, proxy string, maxRedirects int, samples int, opts HTTPRequestOptions) (*HTTPResult, error) {
+	if err := checkSamples(samples); err != nil {
+		return nil, err
+	}
+	if samples == 1 && !opts.ColdStart {
+		return httpRequest(ctx, url, method, proxy, maxRedirects, opts.CheckRevocation)
+	}
+
+	sample := sampleLatency
+	var shared *http.Transport
+	if opts.ColdStart {
+		sample = sampleColdStart
+		transport, _, err := newProxyTransport(proxy)
+		if err != nil {
+			return nil, err
+		}
+		defer transport.CloseIdleConnections()
+		shared = transport
+	}
+
+	var last *HTTPResult
+	var tlsState *tls.ConnectionState
+	stats, err := sample(ctx, samples, func(ctx context.Context) error {
+		result, state, err := doHTTPRequest(ctx, shared, url, method, proxy, maxRedirects)
+		if err == nil {
+			last, tlsState = result, state
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	last.Latency = stats
+	if opts.CheckRevocation && tlsState != nil {
+		last.Revocation = lookupRevocation(ctx, *tlsState)
+	}
+	return last, nil
+}
+
+// withScheme adds https:// to url if it has no http or https scheme.
+func withScheme(url string) string {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "https://" + url
+	}
+	return url
+}
+
+func httpRequest(ctx context.Context, url string, method string, proxy string, maxRedirects int, checkRevocation bool) (*HTTPResult, error) {
+	result, state, err := doHTTPRequest(ctx, nil, url, method, proxy, maxRedirects)
+	if err != nil {
+		return nil, err
+	}
+	if checkRevocation && state != nil {
+		result.Revocation = lookupRevocation(ctx, *state)
+	}
+	return result, nil
+}
+
+// doHTTPRequest makes one request and returns, alongside the result, the
+// TLS state of the final response, which is nil over plain HTTP. The
+// request goes over shared when it is non-nil, so that connections are
+// reused between calls, and over a transport of its own otherwise.
+func doHTTPRequest(ctx context.Context, shared *http.Transport, url string, method string, proxy string, maxRedirects int) (*HTTPResult, *tls.ConnectionState, error) {
+	if maxRedirects < 0 {
+		return nil, nil, fmt.Errorf("max_redirects must not be negative, got %d", maxRedirects)
+	}
 	method = strings.ToUpper(method)
 	if method == "" {
 		method = "GET"
 	}
 
-	// Ensure URL has scheme
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		url = "https://" + url
+	url = withScheme(url)
+	if err := checkURLTarget(ctx, url); err != nil {
+		return nil, nil, err
+	}
+
+	transport, proxyDisplay, err := newProxyTransport(proxy)
+	if err != nil {
+		return nil, nil, err
+	}
+	if shared != nil {
+		transport = shared
 	}
 
+	chain := []string{}
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Transport: transport,
+		Timeout:   10 * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 5 {
-				return fmt.Errorf("too many redirects")
+			if maxRedirects == 0 {
+				return http.ErrUseLastResponse
 			}
-			return nil
+			chain = append(chain, fmt.Sprintf("%d %s -> %s",
+				req.Response.StatusCode, via[len(via)-1].URL, req.URL))
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects: %s", maxRedirects, strings.Join(chain, ", "))
+			}
+			// A redirect must not lead outside the allowlist either.
+			return checkTarget(req.Context(), req.URL.Hostname())
 		},
 	}
 
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, nil, fmt.Errorf("invalid request: %w", err)
 	}
-	req.Header.Set("User-Agent", "telemetry-debugger/1.0")
+	requestID := setProbeHeaders(req)
 
 	start := time.Now()
 	resp, err := client.Do(req)
 	elapsed := time.Since(start)
 
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, nil, fmt.Errorf("request %s failed: %w", requestID, explainConnError(err))
 	}
-	defer resp.Body.Close()
+	defer drainAndClose(resp.Body)
 
 	result := &HTTPResult{
 		StatusCode:     resp.StatusCode,
@@ -355,6 +865,9 @@ func HTTPRequest(url string, method string) (*HTTPResult, error) {
 		Protocol:       resp.Proto,
 		Headers:        make(map[string]string),
 		Success:        resp.StatusCode >= 200 && resp.StatusCode < 400,
+		Proxy:          proxyDisplay,
+		RequestID:      requestID,
+		RedirectChain:  chain,
 	}
 
 	// Extract interesting headers
@@ -368,7 +881,41 @@ func HTTPRequest(url string, method string) (*HTTPResult, error) {
 		}
 	}
 
-	return result, nil
+	return result, resp.TLS, nil
+}
+
+// drainAndClose reads what is left of body, up to 1 MiB, before closing
+// it, so the transport can reuse the connection for the next request.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 1<<20))
+	body.Close()
+}
+
+// newProxyTransport builds a transport that routes through proxy, or through
+// the environment's proxy settings when proxy is empty. It also returns the
+// proxy URL with any password redacted, for reporting.
+func newProxyTransport(proxy string) (*http.Transport, string, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy == "" {
+		transport.Proxy = http.ProxyFromEnvironment
+		return transport, "", nil
+	}
+
+	proxyURL, err := neturl.Parse(proxy)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, "", fmt.Errorf("unsupported proxy scheme '%s' (use http, https, or socks5)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, "", fmt.Errorf("invalid proxy URL: missing host")
+	}
+
+	transport.Proxy = http.ProxyURL(proxyURL)
+	return transport, proxyURL.Redacted(), nil
 }
 
 // ============================================================================
@@ -381,16 +928,30 @@ type TracerouteResult struct {
 	DestinationReached bool     `json:"destination_reached"`
 	TotalHops          int      `json:"total_hops"`
 	RawOutput          string   `json:"raw_output"`
+	// RoutingAnomalies are best-effort signs of a routing loop or an
+	// asymmetric path; see routingAnomalies and CompareReturnPath.
+	RoutingAnomalies []string `json:"routing_anomalies"`
+}
+
+// Traceroute traces the network path to a host. When rawOutputLines is
+// positive, RawOutput keeps only that many trailing lines; hops are always
+// parsed from the full output.
+func Traceroute(host string, maxHops int, rawOutputLines int) (*TracerouteResult, error) {
+	return TracerouteWithProgress(host, maxHops, rawOutputLines, nil)
 }
 
-// Traceroute traces the network path to a host.
-func Traceroute(host string, maxHops int) (*TracerouteResult, error) {
+// TracerouteWithProgress is Traceroute reporting each hop to progress as
+// traceroute prints it. A nil progress behaves like Traceroute.
+func TracerouteWithProgress(host string, maxHops int, rawOutputLines int, progress ProgressFunc) (*TracerouteResult, error) {
 	if maxHops <= 0 {
 		maxHops = 15
 	}
 	if maxHops > 64 {
 		maxHops = 64
 	}
+	if err := checkTarget(context.Background(), host); err != nil {
+		return nil, err
+	}
 
 	maxHopsStr := strconv.Itoa(maxHops)
 
@@ -406,15 +967,28 @@ func Traceroute(host string, maxHops int) (*TracerouteResult, error) {
 		cmd = exec.CommandContext(ctx, "traceroute", "-m", maxHopsStr, "-w", "2", host)
 	}
 
-	output, _ := cmd.CombinedOutput()
-	outputStr := string(output)
+	if progress == nil {
+		output, _ := cmd.CombinedOutput()
+		return parseTracerouteOutput(host, string(output), rawOutputLines), nil
+	}
 
+	hops := &hopReporter{maxHops: maxHops, progress: progress}
+	cmd.Stdout = hops
+	cmd.Stderr = hops
+	_ = cmd.Run()
+
+	return parseTracerouteOutput(host, hops.String(), rawOutputLines), nil
+}
+
+// parseTracerouteOutput extracts hops from traceroute/tracert output.
+func parseTracerouteOutput(host, outputStr string, rawOutputLines int) *TracerouteResult {
 	result := &TracerouteResult{
 		Hops:      make([]string, 0),
-		RawOutput: outputStr,
+		RawOutput: truncate.TailLines(outputStr, rawOutputLines),
 	}
 
 	// Parse hops from output
+	var parsed []tracerouteHop
 	lines := strings.Split(outputStr, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -430,14 +1004,18 @@ func Traceroute(host string, maxHops int) (*TracerouteResult, error) {
 		// Check if line starts with a hop number
 		if len(line) > 0 && (line[0] >= '0' && line[0] <= '9' || line[0] == ' ') {
 			result.Hops = append(result.Hops, line)
+			if hop, ok := parseTracerouteHop(line); ok {
+				parsed = append(parsed, hop)
+			}
 		}
 	}
+	result.RoutingAnomalies = routingAnomalies(parsed)
 
 	result.TotalHops = len(result.Hops)
 	result.DestinationReached = strings.Contains(outputStr, host) &&
 		!strings.Contains(outputStr, "* * *")
 
-	return result, nil
+	return result
 }
 
 // ============================================================================
@@ -2,10 +2,14 @@ package network
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -47,6 +51,172 @@ func TestPing_Unreachable(t *testing.T) {
 	}
 }
 
+// pingReplies builds Linux ping output answering every sequence in 1..sent
+// except those in lost.
+func pingReplies(sent int, lost ...int) string {
+	skip := make(map[int]bool)
+	for _, seq := range lost {
+		skip[seq] = true
+	}
+	var sb strings.Builder
+	sb.WriteString("PING 10.0.0.9 (10.0.0.9) 56(84) bytes of data.\n")
+	for seq := 1; seq <= sent; seq++ {
+		if !skip[seq] {
+			fmt.Fprintf(&sb, "64 bytes from 10.0.0.9: icmp_seq=%d ttl=64 time=0.412 ms\n", seq)
+		}
+	}
+	return sb.String()
+}
+
+func TestLostPingSequences_Classification(t *testing.T) {
+	tests := []struct {
+		name    string
+		lost    []int
+		pattern string
+	}{
+		{"no loss", nil, LossNone},
+		{"one outage", []int{4, 5, 6, 7}, LossBursty},
+		{"two outages", []int{2, 3, 9, 10, 11}, LossBursty},
+		{"spread out", []int{2, 6, 10, 14}, LossUniform},
+		{"single drop", []int{8}, LossUniform},
+		{"mostly isolated", []int{3, 4, 9, 15}, LossUniform},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lost := lostPingSequences(pingReplies(16, tt.lost...), 16, 1)
+			if fmt.Sprint(lost) != fmt.Sprint(append([]int{}, tt.lost...)) {
+				t.Errorf("Expected lost %v, got %v", tt.lost, lost)
+			}
+			if got := classifyLoss(lost); got != tt.pattern {
+				t.Errorf("Expected %s, got %s", tt.pattern, got)
+			}
+		})
+	}
+}
+
+func TestLostPingSequences_IgnoresUnreachableReplies(t *testing.T) {
+	output := `PING 10.0.0.9 (10.0.0.9) 56(84) bytes of data.
+64 bytes from 10.0.0.9: icmp_seq=1 ttl=64 time=0.412 ms
+From 10.0.0.1 icmp_seq=2 Destination Host Unreachable
+From 10.0.0.1 icmp_seq=3 Destination Host Unreachable
+64 bytes from 10.0.0.9: icmp_seq=4 ttl=64 time=0.398 ms`
+
+	if lost := lostPingSequences(output, 4, 1); fmt.Sprint(lost) != "[2 3]" {
+		t.Errorf("Expected [2 3] lost, got %v", lost)
+	}
+}
+
+func TestLostPingSequences_WindowsOrder(t *testing.T) {
+	output := `Pinging 10.0.0.9 with 32 bytes of data:
+Reply from 10.0.0.9: bytes=32 time=1ms TTL=64
+Request timed out.
+Reply from 10.0.0.1: Destination host unreachable.
+Reply from 10.0.0.9: bytes=32 time=1ms TTL=64
+
+Ping statistics for 10.0.0.9:
+    Packets: Sent = 4, Received = 3, Lost = 1 (25% loss),`
+
+	lost := lostPingSequences(output, 4, 1)
+	if fmt.Sprint(lost) != "[2 3]" {
+		t.Errorf("Expected [2 3] lost, got %v", lost)
+	}
+	if got := classifyLoss(lost); got != LossBursty {
+		t.Errorf("Expected bursty, got %s", got)
+	}
+}
+
+func TestParsePingOutput_LossFields(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("macOS numbers icmp_seq from 0")
+	}
+	output := pingReplies(10, 5, 6, 7) + `
+--- 10.0.0.9 ping statistics ---
+10 packets transmitted, 7 received, 30% packet loss, time 9012ms
+rtt min/avg/max/mdev = 0.398/0.405/0.412/0.005 ms`
+
+	result := &PingResult{PacketsSent: 10}
+	parsePingOutput(output, result)
+
+	if fmt.Sprint(result.LostSequences) != "[5 6 7]" || result.LossPattern != LossBursty {
+		t.Errorf("Expected bursty loss of [5 6 7], got %s %v", result.LossPattern, result.LostSequences)
+	}
+	if result.PacketsReceived != 7 {
+		t.Errorf("Expected 7 received, got %d", result.PacketsReceived)
+	}
+}
+
+func TestParsePingOutput_ICMPErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+		cause  string
+	}{
+		{
+			name: "host unreachable",
+			output: `PING 10.0.0.9 (10.0.0.9) 56(84) bytes of data.
+From 10.0.0.1 icmp_seq=1 Destination Host Unreachable
+From 10.0.0.1 icmp_seq=2 Destination Host Unreachable
+From 10.0.0.1 icmp_seq=3 Destination Host Unreachable
+
+--- 10.0.0.9 ping statistics ---
+3 packets transmitted, 0 received, +3 errors, 100% packet loss, time 2031ms`,
+			want:  []string{ICMPHostUnreachable},
+			cause: "down",
+		},
+		{
+			name: "admin prohibited",
+			output: `PING 10.0.0.9 (10.0.0.9): 56 data bytes
+92 bytes from 10.0.0.1: Communication prohibited by filter
+Vr HL TOS  Len   ID Flg  off TTL Pro  cks      Src      Dst
+ 4  5  00 5400 8e2a   0 0000  3f  01 d2a7 10.0.0.5  10.0.0.9
+
+--- 10.0.0.9 ping statistics ---
+3 packets transmitted, 0 packets received, 100.0% packet loss`,
+			want:  []string{ICMPAdminProhibited},
+			cause: "firewall",
+		},
+		{
+			name: "filter outranks host unreachable",
+			output: `PING 10.0.0.9 (10.0.0.9) 56(84) bytes of data.
+From 10.0.0.1 icmp_seq=1 Destination Host Unreachable
+From 10.0.0.1 icmp_seq=2 Packet filtered
+From 10.0.0.1 icmp_seq=3 Packet filtered`,
+			want:  []string{ICMPHostUnreachable, ICMPAdminProhibited},
+			cause: "firewall",
+		},
+		{
+			name: "windows ttl expired",
+			output: `Pinging 10.0.0.9 with 32 bytes of data:
+Reply from 10.0.0.1: TTL expired in transit.
+Reply from 10.0.0.1: TTL expired in transit.`,
+			want:  []string{ICMPTTLExceeded},
+			cause: "routing loop",
+		},
+		{
+			name:   "clean replies",
+			output: pingReplies(3),
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &PingResult{PacketsSent: 3}
+			parsePingOutput(tt.output, result)
+
+			if fmt.Sprint(result.ICMPErrors) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected ICMP errors %v, got %v", tt.want, result.ICMPErrors)
+			}
+			if tt.cause == "" && result.LikelyCause != "" {
+				t.Errorf("Expected no likely cause, got %q", result.LikelyCause)
+			}
+			if !strings.Contains(result.LikelyCause, tt.cause) {
+				t.Errorf("Expected likely cause mentioning %q, got %q", tt.cause, result.LikelyCause)
+			}
+		})
+	}
+}
+
 func TestPing_CountValidation(t *testing.T) {
 	tests := []struct {
 		count    int
@@ -161,7 +331,7 @@ func TestHTTPRequest(t *testing.T) {
 	}))
 	defer server.Close()
 
-	result, err := HTTPRequest(server.URL, "GET")
+	result, err := HTTPRequest(server.URL, "GET", "", DefaultMaxRedirects)
 	if err != nil {
 		t.Fatalf("HTTPRequest error: %v", err)
 	}
@@ -191,7 +361,7 @@ func TestHTTPRequest_Methods(t *testing.T) {
 
 	methods := []string{"GET", "HEAD", "POST"}
 	for _, method := range methods {
-		result, err := HTTPRequest(server.URL, method)
+		result, err := HTTPRequest(server.URL, method, "", DefaultMaxRedirects)
 		if err != nil {
 			t.Errorf("HTTPRequest %s error: %v", method, err)
 			continue
@@ -202,6 +372,135 @@ func TestHTTPRequest_Methods(t *testing.T) {
 	}
 }
 
+func TestHTTPRequest_UserAgentAndRequestID(t *testing.T) {
+	SetUserAgent("friday-test/2.0")
+	defer SetUserAgent("")
+
+	var gotUA, gotID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+		gotID = r.Header.Get(RequestIDHeader)
+	}))
+	defer server.Close()
+
+	result, err := HTTPRequest(server.URL, "GET", "", DefaultMaxRedirects)
+	if err != nil {
+		t.Fatalf("HTTPRequest error: %v", err)
+	}
+
+	if gotUA != "friday-test/2.0" {
+		t.Errorf("expected configured User-Agent, got %q", gotUA)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(gotID) {
+		t.Errorf("expected a UUIDv4 %s header, got %q", RequestIDHeader, gotID)
+	}
+	if result.RequestID != gotID {
+		t.Errorf("expected result to echo request ID %q, got %q", gotID, result.RequestID)
+	}
+
+	// Each request gets its own ID.
+	second, err := HTTPRequest(server.URL, "GET", "", DefaultMaxRedirects)
+	if err != nil {
+		t.Fatalf("HTTPRequest error: %v", err)
+	}
+	if second.RequestID == result.RequestID {
+		t.Errorf("expected a new request ID per request, got %q twice", second.RequestID)
+	}
+}
+
+// newRedirectServer serves /start -> /hop -> /final, redirecting with 301
+// then 302, and /loop redirecting to itself.
+func newRedirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/hop", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/hop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPRequest_RedirectChain(t *testing.T) {
+	server := newRedirectServer(t)
+
+	result, err := HTTPRequest(server.URL+"/start", "GET", "", DefaultMaxRedirects)
+	if err != nil {
+		t.Fatalf("HTTPRequest error: %v", err)
+	}
+
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("expected final status 200, got %d", result.StatusCode)
+	}
+	want := []string{
+		"301 " + server.URL + "/start -> " + server.URL + "/hop",
+		"302 " + server.URL + "/hop -> " + server.URL + "/final",
+	}
+	if len(result.RedirectChain) != len(want) {
+		t.Fatalf("expected chain %v, got %v", want, result.RedirectChain)
+	}
+	for i := range want {
+		if result.RedirectChain[i] != want[i] {
+			t.Errorf("hop %d: expected %q, got %q", i, want[i], result.RedirectChain[i])
+		}
+	}
+}
+
+func TestHTTPRequest_MaxRedirectsZero(t *testing.T) {
+	server := newRedirectServer(t)
+
+	result, err := HTTPRequest(server.URL+"/start", "GET", "", 0)
+	if err != nil {
+		t.Fatalf("HTTPRequest error: %v", err)
+	}
+
+	if result.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("expected initial 301, got %d", result.StatusCode)
+	}
+	if result.Headers["Location"] != "/hop" {
+		t.Errorf("expected Location /hop, got %v", result.Headers)
+	}
+	if len(result.RedirectChain) != 0 {
+		t.Errorf("expected empty chain, got %v", result.RedirectChain)
+	}
+}
+
+func TestHTTPRequest_RedirectLoop(t *testing.T) {
+	server := newRedirectServer(t)
+
+	_, err := HTTPRequest(server.URL+"/loop", "GET", "", 3)
+	if err == nil {
+		t.Fatal("expected error for redirect loop")
+	}
+	if !strings.Contains(err.Error(), "stopped after 3 redirects") ||
+		!strings.Contains(err.Error(), "302 "+server.URL+"/loop -> "+server.URL+"/loop") {
+		t.Errorf("expected loop chain in error, got %v", err)
+	}
+}
+
+func TestHTTPRequest_NegativeMaxRedirects(t *testing.T) {
+	if _, err := HTTPRequest("http://example.invalid/", "GET", "", -1); err == nil {
+		t.Error("expected error for negative max_redirects")
+	}
+}
+
+func TestSetUserAgent_EmptyRestoresDefault(t *testing.T) {
+	SetUserAgent("custom/1.0")
+	SetUserAgent("")
+	if UserAgent() != DefaultUserAgent {
+		t.Errorf("expected %q, got %q", DefaultUserAgent, UserAgent())
+	}
+}
+
 func TestHTTPRequest_AddScheme(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -212,18 +511,195 @@ func TestHTTPRequest_AddScheme(t *testing.T) {
 	urlWithoutScheme := server.URL[7:] // Remove "http://"
 
 	// Should fail because we add https:// and the server is http
-	_, err := HTTPRequest(urlWithoutScheme, "GET")
+	_, err := HTTPRequest(urlWithoutScheme, "GET", "", DefaultMaxRedirects)
 	if err == nil {
 		t.Log("Request succeeded (may have fallen back or server supports HTTPS)")
 	}
 }
 
+func TestConnectFrom_Loopback(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	result, err := ConnectFrom("127.0.0.1", "127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("ConnectFrom error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if result.Interface == "" {
+		t.Error("expected the loopback interface to be reported")
+	}
+	if host, _, _ := net.SplitHostPort(result.LocalAddr); host != "127.0.0.1" {
+		t.Errorf("expected local address on 127.0.0.1, got %s", result.LocalAddr)
+	}
+	if result.RemoteAddr != ln.Addr().String() {
+		t.Errorf("expected remote %s, got %s", ln.Addr(), result.RemoteAddr)
+	}
+}
+
+func TestConnectFrom_ConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	result, err := ConnectFrom("127.0.0.1", "127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("expected failure in result, got error: %v", err)
+	}
+	if result.Success || result.Error == "" {
+		t.Errorf("expected unsuccessful connect with error, got %+v", result)
+	}
+}
+
+func TestConnectFrom_InvalidSource(t *testing.T) {
+	// 192.0.2.0/24 is TEST-NET-1 and never assigned to a real interface.
+	for _, source := range []string{"192.0.2.123", "not-an-ip", ""} {
+		if _, err := ConnectFrom(source, "127.0.0.1", 80); err == nil {
+			t.Errorf("expected error for source %q", source)
+		}
+	}
+}
+
+// newTestProxy starts a forward proxy that records each request it sees,
+// serves plain HTTP requests itself, and tunnels CONNECT requests.
+func newTestProxy(t *testing.T) (*httptest.Server, *[]*http.Request) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []*http.Request
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Clone(r.Context()))
+		mu.Unlock()
+
+		if r.Method != http.MethodConnect {
+			w.Header().Set("Server", "test-proxy")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, &seen
+}
+
+func TestHTTPRequest_ExplicitProxy(t *testing.T) {
+	proxy, seen := newTestProxy(t)
+
+	result, err := HTTPRequest("http://example.invalid/health", "GET", proxy.URL, DefaultMaxRedirects)
+	if err != nil {
+		t.Fatalf("HTTPRequest error: %v", err)
+	}
+	if result.Headers["Server"] != "test-proxy" {
+		t.Errorf("expected response from proxy, got headers %v", result.Headers)
+	}
+	if result.Proxy != proxy.URL {
+		t.Errorf("expected Proxy %q, got %q", proxy.URL, result.Proxy)
+	}
+	if len(*seen) != 1 || (*seen)[0].URL.String() != "http://example.invalid/health" {
+		t.Fatalf("expected proxy to see absolute request URL, got %v", *seen)
+	}
+}
+
+func TestHTTPRequest_ProxyConnect(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	proxy, seen := newTestProxy(t)
+
+	// The test server's certificate is untrusted, so the TLS handshake fails
+	// after the tunnel is established; the CONNECT is what matters here.
+	HTTPRequest(target.URL, "GET", proxy.URL, DefaultMaxRedirects)
+
+	if len(*seen) == 0 || (*seen)[0].Method != http.MethodConnect {
+		t.Fatalf("expected CONNECT through proxy, got %v", *seen)
+	}
+	if host := strings.TrimPrefix(target.URL, "https://"); (*seen)[0].Host != host {
+		t.Errorf("expected CONNECT to %s, got %s", host, (*seen)[0].Host)
+	}
+}
+
+func TestHTTPRequest_ProxyCredentials(t *testing.T) {
+	proxy, seen := newTestProxy(t)
+	proxyURL := strings.Replace(proxy.URL, "http://", "http://user:secret@", 1)
+
+	result, err := HTTPRequest("http://example.invalid/", "GET", proxyURL, DefaultMaxRedirects)
+	if err != nil {
+		t.Fatalf("HTTPRequest error: %v", err)
+	}
+	if len(*seen) != 1 || !strings.HasPrefix((*seen)[0].Header.Get("Proxy-Authorization"), "Basic ") {
+		t.Fatalf("expected Proxy-Authorization header, got %v", *seen)
+	}
+	if strings.Contains(result.Proxy, "secret") {
+		t.Errorf("expected password to be redacted, got %q", result.Proxy)
+	}
+}
+
+func TestHTTPRequest_InvalidProxy(t *testing.T) {
+	for _, proxy := range []string{"ftp://proxy:21", "http://", "://bad"} {
+		if _, err := HTTPRequest("http://example.invalid/", "GET", proxy, DefaultMaxRedirects); err == nil {
+			t.Errorf("expected error for proxy %q", proxy)
+		}
+	}
+}
+
+func TestNewProxyTransport_Socks5(t *testing.T) {
+	transport, display, err := newProxyTransport("socks5://127.0.0.1:1080")
+	if err != nil {
+		t.Fatalf("newProxyTransport error: %v", err)
+	}
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	proxyURL, err := transport.Proxy(req)
+	if err != nil || proxyURL == nil || proxyURL.Scheme != "socks5" {
+		t.Errorf("expected socks5 proxy, got %v (%v)", proxyURL, err)
+	}
+	if display != "socks5://127.0.0.1:1080" {
+		t.Errorf("unexpected display %q", display)
+	}
+}
+
 func TestTraceroute(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("Traceroute test unreliable on Windows CI")
 	}
 
-	result, err := Traceroute("127.0.0.1", 5)
+	result, err := Traceroute("127.0.0.1", 5, 0)
 	if err != nil {
 		t.Fatalf("Traceroute error: %v", err)
 	}
@@ -239,7 +715,7 @@ func TestTraceroute(t *testing.T) {
 
 func TestTraceroute_MaxHopsValidation(t *testing.T) {
 	// Test that maxHops is capped
-	result, err := Traceroute("127.0.0.1", 100)
+	result, err := Traceroute("127.0.0.1", 100, 0)
 	if err != nil {
 		t.Fatalf("Traceroute error: %v", err)
 	}
@@ -250,6 +726,189 @@ func TestTraceroute_MaxHopsValidation(t *testing.T) {
 	}
 }
 
+func TestParseTracerouteOutput_RawOutputTail(t *testing.T) {
+	output := `traceroute to 10.0.0.9 (10.0.0.9), 5 hops max, 60 byte packets
+ 1  10.0.0.1  0.412 ms  0.388 ms  0.371 ms
+ 2  10.0.0.5  1.022 ms  0.998 ms  0.981 ms
+ 3  10.0.0.7  1.611 ms  1.590 ms  1.575 ms
+ 4  10.0.0.9  2.104 ms  2.087 ms  2.070 ms
+`
+
+	full := parseTracerouteOutput("10.0.0.9", output, 0)
+	tail := parseTracerouteOutput("10.0.0.9", output, 2)
+
+	// Parsing must use the full output regardless of the tail setting.
+	if tail.TotalHops != 4 || full.TotalHops != 4 {
+		t.Errorf("Expected 4 hops, got full=%d tail=%d", full.TotalHops, tail.TotalHops)
+	}
+	if !tail.DestinationReached {
+		t.Error("Expected destination reached from full output")
+	}
+
+	if full.RawOutput != output {
+		t.Error("Expected unlimited RawOutput to be unchanged")
+	}
+	lines := strings.Split(tail.RawOutput, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines in RawOutput, got %d: %q", len(lines), tail.RawOutput)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), "3 ") {
+		t.Errorf("Expected tail to start at hop 3, got %q", lines[0])
+	}
+}
+
+func TestParseTracerouteOutput_RoutingLoop(t *testing.T) {
+	output := `traceroute to 198.51.100.9 (198.51.100.9), 8 hops max, 60 byte packets
+ 1  192.168.1.1  0.412 ms  0.388 ms  0.371 ms
+ 2  203.0.113.1  5.022 ms  4.998 ms  4.981 ms
+ 3  203.0.113.5  6.611 ms  6.590 ms  6.575 ms
+ 4  203.0.113.9  7.104 ms  7.087 ms  7.070 ms
+ 5  203.0.113.5  8.204 ms  8.187 ms  8.170 ms
+ 6  203.0.113.9  9.304 ms  9.287 ms  9.270 ms
+`
+
+	result := parseTracerouteOutput("198.51.100.9", output, 0)
+
+	want := []string{
+		"routing loop: 203.0.113.5 answered at hops 3 and 5",
+		"routing loop: 203.0.113.9 answered at hops 4 and 6",
+	}
+	if strings.Join(result.RoutingAnomalies, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected %q, got %q", want, result.RoutingAnomalies)
+	}
+}
+
+func TestParseTracerouteOutput_NetworkJump(t *testing.T) {
+	output := `traceroute to 198.51.100.9 (198.51.100.9), 8 hops max, 60 byte packets
+ 1  192.168.1.1  0.412 ms  0.388 ms  0.371 ms
+ 2  203.0.113.1  5.022 ms  4.998 ms  4.981 ms
+ 3  203.0.113.5  6.611 ms  6.590 ms  6.575 ms
+ 4  198.51.100.1  60.104 ms  60.087 ms  60.070 ms
+ 5  198.51.100.9  61.204 ms  61.187 ms  61.170 ms
+`
+
+	result := parseTracerouteOutput("198.51.100.9", output, 0)
+
+	if len(result.RoutingAnomalies) != 1 {
+		t.Fatalf("Expected one anomaly, got %q", result.RoutingAnomalies)
+	}
+	if !strings.HasPrefix(result.RoutingAnomalies[0], "network jump: hop 4 (198.51.100.1) is in a different network from hop 3 (203.0.113.5)") {
+		t.Errorf("Expected the jump between hops 3 and 4, got %q", result.RoutingAnomalies[0])
+	}
+}
+
+func TestParseTracerouteOutput_NoJumpAcrossUnansweredHop(t *testing.T) {
+	output := ` 1  203.0.113.1  5.022 ms
+ 2  * * *
+ 3  198.51.100.9  7.104 ms
+`
+	result := parseTracerouteOutput("198.51.100.9", output, 0)
+	if len(result.RoutingAnomalies) != 0 {
+		t.Errorf("Expected hops either side of a gap not to count as consecutive, got %q", result.RoutingAnomalies)
+	}
+}
+
+func TestParseTracerouteOutput_PrivateReentry(t *testing.T) {
+	output := `traceroute to app.internal (192.168.7.9), 8 hops max, 60 byte packets
+ 1  gw.lan (192.168.1.1)  0.412 ms  0.388 ms  0.371 ms
+ 2  core1.isp.net (203.0.113.1)  5.022 ms  4.998 ms  4.981 ms
+ 3  * * *
+ 4  192.168.7.1 (192.168.7.1)  40.611 ms  40.590 ms  40.575 ms
+ 5  app.internal (192.168.7.9)  41.104 ms  41.087 ms  41.070 ms
+`
+
+	result := parseTracerouteOutput("192.168.7.9", output, 0)
+
+	if len(result.RoutingAnomalies) != 1 {
+		t.Fatalf("Expected one anomaly, got %q", result.RoutingAnomalies)
+	}
+	if !strings.HasPrefix(result.RoutingAnomalies[0], "hop 4 (192.168.7.1) is back in the source's private network after public hop 2 (203.0.113.1)") {
+		t.Errorf("Expected the return into the source network at hop 4, got %q", result.RoutingAnomalies[0])
+	}
+}
+
+func TestParseTracerouteOutput_ISPPrivateHopsNotFlagged(t *testing.T) {
+	output := ` 1  192.168.1.1  0.412 ms
+ 2  203.0.113.1  5.022 ms
+ 3  10.20.0.1  6.611 ms
+ 4  100.64.3.1  7.104 ms
+ 5  203.0.113.9  8.204 ms
+`
+	result := parseTracerouteOutput("203.0.113.9", output, 0)
+	if len(result.RoutingAnomalies) != 0 {
+		t.Errorf("Expected private hops outside the source network to pass, got %q", result.RoutingAnomalies)
+	}
+}
+
+func TestParseTracerouteOutput_NoAnomalies(t *testing.T) {
+	output := `traceroute to 203.0.113.9 (203.0.113.9), 8 hops max, 60 byte packets
+ 1  192.168.1.1  0.412 ms  0.388 ms  0.371 ms
+ 2  100.64.0.1  2.022 ms  1.998 ms  1.981 ms
+ 3  203.0.113.1  5.022 ms  203.0.113.2  4.998 ms  203.0.113.1  4.981 ms
+ 4  203.0.113.9  7.104 ms  7.087 ms  7.070 ms
+`
+
+	result := parseTracerouteOutput("203.0.113.9", output, 0)
+
+	if result.RoutingAnomalies == nil || len(result.RoutingAnomalies) != 0 {
+		t.Errorf("Expected an empty anomaly list, got %#v", result.RoutingAnomalies)
+	}
+}
+
+func TestCompareReturnPath(t *testing.T) {
+	output := ` 1  192.168.1.1  0.412 ms
+ 2  203.0.113.1  5.022 ms
+ 3  203.0.113.9  7.104 ms
+`
+	symmetric := parseTracerouteOutput("203.0.113.9", output, 0)
+	symmetric.CompareReturnPath([]string{"203.0.113.1", "192.168.1.1", "192.168.1.50"})
+	if len(symmetric.RoutingAnomalies) != 0 {
+		t.Errorf("Expected a matching return path to pass, got %q", symmetric.RoutingAnomalies)
+	}
+
+	asymmetric := parseTracerouteOutput("203.0.113.9", output, 0)
+	asymmetric.CompareReturnPath([]string{"203.0.113.77", "192.168.1.1", "192.168.1.50"})
+	want := "1 of 2 return path hops are not on the forward path (203.0.113.77); routing is likely asymmetric"
+	if len(asymmetric.RoutingAnomalies) != 1 || asymmetric.RoutingAnomalies[0] != want {
+		t.Errorf("Expected %q, got %q", want, asymmetric.RoutingAnomalies)
+	}
+}
+
+func TestHopReporter_ReportsEachHop(t *testing.T) {
+	var lines []string
+	h := &hopReporter{maxHops: 15, progress: func(line string) { lines = append(lines, line) }}
+
+	// Simulate traceroute flushing output mid-line, as it does between probes.
+	chunks := []string{
+		"traceroute to 10.0.0.9 (10.0.0.9), 15 hops max, 60 byte packets\n",
+		" 1  10.0.0.1  0.412 ms",
+		"  0.388 ms  0.371 ms\n 2  * * *\n",
+		" 3  10.0.0.9  2.104 ms\n",
+	}
+	for _, c := range chunks {
+		if _, err := h.Write([]byte(c)); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+
+	want := []string{
+		"hop 1/15: 10.0.0.1  0.412 ms  0.388 ms  0.371 ms",
+		"hop 2/15: * * *",
+		"hop 3/15: 10.0.0.9  2.104 ms",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d progress lines, got %q", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], lines[i])
+		}
+	}
+	if h.String() != strings.Join(chunks, "") {
+		t.Error("Expected the full output to be kept for parsing")
+	}
+}
+
 func TestNetInfo(t *testing.T) {
 	result, err := NetInfo("all")
 	if err != nil {
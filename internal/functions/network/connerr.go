@@ -0,0 +1,174 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Connectivity error categories reported by ClassifyConnError.
+const (
+	ConnErrRefused = "refused"
+	ConnErrTimeout = "timeout"
+	ConnErrNoRoute = "no_route"
+	ConnErrDNS     = "dns"
+	ConnErrReset   = "reset"
+	ConnErrTLS     = "tls"
+	ConnErrUnknown = "unknown"
+
+	// Unix-domain socket failures.
+	ConnErrNoSocket   = "no_socket"
+	ConnErrPermission = "permission"
+)
+
+// ConnErrorExplanation turns a raw connectivity error into something a user
+// can act on.
+type ConnErrorExplanation struct {
+	Category    string `json:"category"`
+	LikelyCause string `json:"likely_cause"`
+	Suggestion  string `json:"suggestion"`
+}
+
+var connErrExplanations = map[string]ConnErrorExplanation{
+	ConnErrRefused: {
+		Category:    ConnErrRefused,
+		LikelyCause: "the host is reachable but nothing is listening on that port",
+		Suggestion:  "check the service is running and bound to the expected address and port (ss -tlnp)",
+	},
+	ConnErrTimeout: {
+		Category:    ConnErrTimeout,
+		LikelyCause: "packets are being dropped, typically by a firewall or security group, or the host is down",
+		Suggestion:  "check firewall rules on the path and the target host, and confirm the host is up",
+	},
+	ConnErrNoRoute: {
+		Category:    ConnErrNoRoute,
+		LikelyCause: "there is no route from this host to the target network",
+		Suggestion:  "check the routing table (ip route), the default gateway, and that the interface is up",
+	},
+	ConnErrDNS: {
+		Category:    ConnErrDNS,
+		LikelyCause: "the hostname could not be resolved",
+		Suggestion:  "check the hostname spelling and resolver configuration (/etc/resolv.conf), or try dns_lookup",
+	},
+	ConnErrReset: {
+		Category:    ConnErrReset,
+		LikelyCause: "the peer or a middlebox closed the connection abruptly",
+		Suggestion:  "check the server logs for crashes or connection limits, and any proxy or load balancer in between",
+	},
+	ConnErrTLS: {
+		Category:    ConnErrTLS,
+		LikelyCause: "the TLS handshake failed, usually an untrusted, expired, or mismatched certificate",
+		Suggestion:  "check the certificate chain, expiry, and that the hostname matches the certificate",
+	},
+	ConnErrNoSocket: {
+		Category:    ConnErrNoSocket,
+		LikelyCause: "the socket path does not exist, so the service is not running or listens elsewhere",
+		Suggestion:  "check the service is running and the socket path in its configuration (ss -xlp)",
+	},
+	ConnErrPermission: {
+		Category:    ConnErrPermission,
+		LikelyCause: "this user is not allowed to connect to the socket",
+		Suggestion:  "check the socket's owner, group, and mode (ls -l), and run as a user in that group",
+	},
+	ConnErrUnknown: {
+		Category:    ConnErrUnknown,
+		LikelyCause: "unrecognised connection error",
+		Suggestion:  "inspect the raw error message",
+	},
+}
+
+// ClassifyConnError maps a dial, HTTP, or gRPC error to a category with its
+// likely cause and a suggested next step. Typed errors are checked first;
+// errors that only survive as text (e.g. gRPC status messages) fall back to
+// matching well-known syscall messages.
+func ClassifyConnError(err error) ConnErrorExplanation {
+	return connErrExplanations[connErrCategory(err)]
+}
+
+func connErrCategory(err error) string {
+	if err == nil {
+		return ConnErrUnknown
+	}
+
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var authErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return ConnErrDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ConnErrRefused
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return ConnErrNoRoute
+	case errors.Is(err, syscall.ECONNRESET):
+		return ConnErrReset
+	case errors.Is(err, syscall.ENOENT):
+		return ConnErrNoSocket
+	case errors.Is(err, syscall.EACCES), errors.Is(err, syscall.EPERM):
+		return ConnErrPermission
+	case errors.As(err, &certErr), errors.As(err, &authErr), errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return ConnErrTLS
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, context.DeadlineExceeded):
+		return ConnErrTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ConnErrTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "connection refused"):
+		return ConnErrRefused
+	case strings.Contains(msg, "no such host"), strings.Contains(msg, "name resolution"):
+		return ConnErrDNS
+	case strings.Contains(msg, "no route to host"), strings.Contains(msg, "network is unreachable"):
+		return ConnErrNoRoute
+	case strings.Contains(msg, "connection reset"):
+		return ConnErrReset
+	case strings.Contains(msg, "no such file or directory"):
+		return ConnErrNoSocket
+	case strings.Contains(msg, "permission denied"):
+		return ConnErrPermission
+	case strings.Contains(msg, "certificate"), strings.Contains(msg, "tls:"):
+		return ConnErrTLS
+	case strings.Contains(msg, "i/o timeout"), strings.Contains(msg, "deadline exceeded"), strings.Contains(msg, "timed out"):
+		return ConnErrTimeout
+	}
+
+	return ConnErrUnknown
+}
+
+// ConnError is a connectivity failure annotated with its explanation.
+type ConnError struct {
+	Err         error
+	Explanation ConnErrorExplanation
+}
+
+func (e *ConnError) Error() string {
+	return fmt.Sprintf("%v (%s: %s; suggestion: %s)",
+		e.Err, e.Explanation.Category, e.Explanation.LikelyCause, e.Explanation.Suggestion)
+}
+
+func (e *ConnError) Unwrap() error { return e.Err }
+
+// explainConnError wraps err in a ConnError when it can be classified, and
+// returns it unchanged otherwise.
+func explainConnError(err error) error {
+	if err == nil {
+		return nil
+	}
+	explanation := ClassifyConnError(err)
+	if explanation.Category == ConnErrUnknown {
+		return err
+	}
+	return &ConnError{Err: err, Explanation: explanation}
+}
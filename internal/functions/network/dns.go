@@ -0,0 +1,141 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dnsLookupTimeout bounds each DNSLatency sample.
+const dnsLookupTimeout = 5 * time.Second
+
+// DNSAnswerSet is one distinct set of addresses a domain resolved to and
+// how many samples returned it.
+type DNSAnswerSet struct {
+	Addresses []string `json:"addresses"`
+	Count     int      `json:"count"`
+}
+
+// DNSLatencyResult is the output of DNSLatency.
+type DNSLatencyResult struct {
+	Domain string `json:"domain"`
+	// Resolver is the server queried, or "system" for the host's resolver.
+	Resolver string        `json:"resolver"`
+	Samples  int           `json:"samples"`
+	Latency  *LatencyStats `json:"latency"`
+	// Consistent is set when every successful sample returned the same
+	// addresses. Round-robin or load-balanced DNS shows up as several
+	// AnswerSets instead.
+	Consistent bool           `json:"consistent"`
+	AnswerSets []DNSAnswerSet `json:"answer_sets"`
+}
+
+// DNSLatency resolves domain samples times (1-100) in sequence and reports
+// the resolution latency as min/avg/p50/p90/p99/max, and whether every
+// sample returned the same addresses. resolver is a DNS server as "ip" or
+// "ip:port" (port 53 by default) to query instead of the host's resolver;
+// note that the host's resolver may answer from a local cache.
+func DNSLatency(domain string, samples int, resolver string) (*DNSLatencyResult, error) {
+	return DNSLatencyContext(context.Background(), domain, samples, resolver)
+}
+
+// DNSLatencyContext is DNSLatency that stops early, with an error, if ctx
+// is cancelled between samples.
+func DNSLatencyContext(ctx context.Context, domain string, samples int, resolver string) (*DNSLatencyResult, error) {
+	domain = strings.TrimSpace(domain)
+	if domain == "" {
+		return nil, errors.New("domain is required")
+	}
+	if net.ParseIP(domain) != nil {
+		return nil, fmt.Errorf("'%s' is an IP address; there is nothing to resolve", domain)
+	}
+	if err := checkSamples(samples); err != nil {
+		return nil, err
+	}
+
+	result := &DNSLatencyResult{
+		Domain:     domain,
+		Resolver:   "system",
+		Samples:    samples,
+		AnswerSets: []DNSAnswerSet{},
+	}
+	r := net.DefaultResolver
+	if resolver != "" {
+		addr, err := resolverAddress(resolver)
+		if err != nil {
+			return nil, err
+		}
+		host, _, _ := net.SplitHostPort(addr)
+		if err := checkTarget(ctx, host); err != nil {
+			return nil, err
+		}
+		result.Resolver = addr
+		r = newResolver(addr)
+	}
+
+	var answers []string
+	stats, err := sampleLatency(ctx, samples, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+		defer cancel()
+		addrs, err := r.LookupIPAddr(ctx, domain)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return fmt.Errorf("no addresses for '%s'", domain)
+		}
+		ips := make([]string, len(addrs))
+		for i, a := range addrs {
+			ips[i] = a.IP.String()
+		}
+		sort.Strings(ips)
+		answers = append(answers, strings.Join(ips, ","))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve '%s': %w", domain, err)
+	}
+	result.Latency = stats
+
+	index := make(map[string]int)
+	for _, a := range answers {
+		i, ok := index[a]
+		if !ok {
+			i = len(result.AnswerSets)
+			index[a] = i
+			result.AnswerSets = append(result.AnswerSets, DNSAnswerSet{Addresses: strings.Split(a, ",")})
+		}
+		result.AnswerSets[i].Count++
+	}
+	result.Consistent = len(result.AnswerSets) == 1
+	return result, nil
+}
+
+// resolverAddress normalizes a DNS server given as "ip" or "ip:port" to
+// "ip:port".
+func resolverAddress(resolver string) (string, error) {
+	host, port := resolver, "53"
+	if h, p, err := net.SplitHostPort(resolver); err == nil {
+		host, port = h, p
+	}
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("invalid resolver '%s': expected an IP address with an optional port", resolver)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// newResolver returns a resolver that sends every query to addr rather than
+// the servers in resolv.conf.
+func newResolver(addr string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
@@ -0,0 +1,158 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultEndpointTimeout bounds the lookup and each connection attempt of
+// CheckAllEndpoints.
+const defaultEndpointTimeout = 3 * time.Second
+
+// EndpointsOptions adjusts how CheckAllEndpointsContext runs.
+type EndpointsOptions struct {
+	// LookupIP returns every address host resolves to; nil uses the
+	// system resolver. Tests replace it.
+	LookupIP func(ctx context.Context, host string) ([]net.IP, error)
+	// Timeout bounds the lookup and each connection; 0 is 3 seconds.
+	Timeout time.Duration
+}
+
+// EndpointResult is the outcome of connecting to one resolved address.
+type EndpointResult struct {
+	IP string `json:"ip"`
+	// Family is ipv4 or ipv6.
+	Family    string  `json:"family"`
+	Reachable bool    `json:"reachable"`
+	LatencyMs float64 `json:"latency_ms,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// AllEndpointsResult is the output of CheckAllEndpoints.
+type AllEndpointsResult struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// Endpoints holds one entry per resolved address, in resolver order.
+	Endpoints []EndpointResult `json:"endpoints"`
+	Resolved  int              `json:"resolved"`
+	Reachable int              `json:"reachable"`
+	// Unreachable lists the addresses no connection could be made to.
+	Unreachable []string `json:"unreachable"`
+	// PartialOutage is true when some resolved addresses accept
+	// connections and others do not, so clients fail only when the
+	// resolver hands them a bad one.
+	PartialOutage bool   `json:"partial_outage"`
+	Verdict       string `json:"verdict"`
+}
+
+// CheckAllEndpoints resolves every A and AAAA record of host and tries a
+// TCP connection to each address on port, to find the one bad backend
+// behind a round-robin or multi-address name that makes connections fail
+// some of the time.
+func CheckAllEndpoints(host string, port int) (*AllEndpointsResult, error) {
+	return CheckAllEndpointsContext(context.Background(), host, port, EndpointsOptions{})
+}
+
+// CheckAllEndpointsContext is CheckAllEndpoints with opts, stopped if ctx
+// is cancelled.
+func CheckAllEndpointsContext(ctx context.Context, host string, port int, opts EndpointsOptions) (*AllEndpointsResult, error) {
+	if host == "" {
+		return nil, errors.New("host is required")
+	}
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	if err := checkTarget(ctx, host); err != nil {
+		return nil, err
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultEndpointTimeout
+	}
+	lookup := opts.LookupIP
+	if lookup == nil {
+		lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		}
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	ips, err := lookup(lookupCtx, host)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%s has no A or AAAA records", host)
+	}
+
+	result := &AllEndpointsResult{
+		Host:        host,
+		Port:        port,
+		Endpoints:   make([]EndpointResult, len(ips)),
+		Resolved:    len(ips),
+		Unreachable: []string{},
+	}
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		wg.Add(1)
+		go func(i int, ip net.IP) {
+			defer wg.Done()
+			result.Endpoints[i] = probeEndpoint(ctx, ip, port, timeout)
+		}(i, ip)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("endpoint check stopped: %w", err)
+	}
+
+	for _, ep := range result.Endpoints {
+		if ep.Reachable {
+			result.Reachable++
+		} else {
+			result.Unreachable = append(result.Unreachable, ep.IP)
+		}
+	}
+	result.PartialOutage = result.Reachable > 0 && result.Reachable < result.Resolved
+	result.Verdict = endpointsVerdict(result)
+	return result, nil
+}
+
+// probeEndpoint connects to ip:port. The addresses are not checked against
+// the allowlist again: the host already passed, and a hostname pattern such
+// as "*.internal" allows the host without allowing any of its addresses.
+func probeEndpoint(ctx context.Context, ip net.IP, port int, timeout time.Duration) EndpointResult {
+	ep := EndpointResult{IP: ip.String(), Family: "ipv6"}
+	if ip.To4() != nil {
+		ep.Family = "ipv4"
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ep.IP, strconv.Itoa(port)))
+	if err != nil {
+		ep.Error = explainConnError(err).Error()
+		return ep
+	}
+	conn.Close()
+	ep.Reachable = true
+	ep.LatencyMs = durationMs(time.Since(start))
+	return ep
+}
+
+func endpointsVerdict(r *AllEndpointsResult) string {
+	switch {
+	case r.Reachable == r.Resolved:
+		return fmt.Sprintf("all %d address(es) of %s accept connections on port %d", r.Resolved, r.Host, r.Port)
+	case r.Reachable == 0:
+		return fmt.Sprintf("none of the %d address(es) of %s accept connections on port %d: the service is down or blocked everywhere", r.Resolved, r.Host, r.Port)
+	default:
+		return fmt.Sprintf("partial outage: %d of %d addresses of %s refuse or drop connections on port %d, so about %d in %d new connections fail depending on which address a client picks",
+			r.Resolved-r.Reachable, r.Resolved, r.Host, r.Port, r.Resolved-r.Reachable, r.Resolved)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"sync"
 	"time"
 
@@ -13,28 +14,85 @@ import (
 )
 
 // CheckGRPCHealth connects to a gRPC server and checks its health status.
+// host may be a "unix:///path" socket, in which case port is ignored.
 //
 // Bug 7 fix: replaced deprecated grpc.DialContext (with grpc.WithBlock) with
 // grpc.NewClient. Connections are now established lazily; any connectivity
 // error surfaces at the RPC call level instead of the dial step.
 func CheckGRPCHealth(host string, port int, timeout int) (map[string]interface{}, error) {
-	if timeout <= 0 {
-		timeout = 5
+	return checkGRPCHealth(context.Background(), host, port, timeout)
+}
+
+// CheckGRPCHealthSampled is CheckGRPCHealth repeated samples times in
+// sequence, adding the latency distribution under "latency"; the other
+// fields describe the last successful check. With one sample it is
+// CheckGRPCHealth. An error is returned only when every check fails or ctx
+// is cancelled between samples.
+//
+// Each check opens its own connection unless coldStart is set; then all of
+// them share one, and the first check, which sets it up, is compared with
+// the rest in the latency's cold_start. coldStart needs at least 2 samples.
+func CheckGRPCHealthSampled(ctx context.Context, host string, port int, timeout int, samples int, coldStart bool) (map[string]interface{}, error) {
+	if err := checkSamples(samples); err != nil {
+		return nil, err
+	}
+	if samples == 1 && !coldStart {
+		return checkGRPCHealth(ctx, host, port, timeout)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
-	defer cancel()
+	sample := sampleLatency
+	var shared *grpc.ClientConn
+	if coldStart {
+		sample = sampleColdStart
+		conn, err := newGRPCClient(host, port)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		shared = conn
+	}
 
-	startTime := time.Now()
+	var last map[string]interface{}
+	stats, err := sample(ctx, samples, func(ctx context.Context) error {
+		var result map[string]interface{}
+		var err error
+		if shared != nil {
+			result, err = checkGRPCHealthOn(ctx, shared, host, port, timeout)
+		} else {
+			result, err = checkGRPCHealth(ctx, host, port, timeout)
+		}
+		if err == nil {
+			last = result
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	last["latency"] = stats
+	return last, nil
+}
 
-	target := fmt.Sprintf("%s:%d", host, port)
-	conn, err := grpc.NewClient(target,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+func checkGRPCHealth(ctx context.Context, host string, port int, timeout int) (map[string]interface{}, error) {
+	conn, err := newGRPCClient(host, port)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to gRPC server at %s: %w", target, err)
+		return nil, err
 	}
 	defer conn.Close()
+	return checkGRPCHealthOn(ctx, conn, host, port, timeout)
+}
+
+// checkGRPCHealthOn runs one health check over conn, which connects on
+// first use.
+func checkGRPCHealthOn(parent context.Context, conn *grpc.ClientConn, host string, port int, timeout int) (map[string]interface{}, error) {
+	if timeout <= 0 {
+		timeout = 5
+	}
+
+	ctx, cancel := context.WithTimeout(parent, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	startTime := time.Now()
 
 	client := grpc_health_v1.NewHealthClient(conn)
 
@@ -42,7 +100,7 @@ func CheckGRPCHealth(host string, port int, timeout int) (map[string]interface{}
 		Service: "", // empty service name checks overall server health
 	})
 	if err != nil {
-		return nil, fmt.Errorf("gRPC health check RPC failed: %w", err)
+		return nil, fmt.Errorf("gRPC health check RPC failed: %w", explainConnError(err))
 	}
 
 	latencyMs := time.Since(startTime).Milliseconds()
@@ -67,8 +125,41 @@ func CheckGRPCHealth(host string, port int, timeout int) (map[string]interface{}
 	}, nil
 }
 
+// newGRPCClient creates a plaintext client for host:port, or for the socket
+// when host is "unix:///path".
+func newGRPCClient(host string, port int) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+
+	target := fmt.Sprintf("%s:%d", host, port)
+	dialTarget := target
+	if path, ok := UnixSocketPath(host); ok {
+		if path == "" {
+			return nil, fmt.Errorf("invalid target '%s': missing socket path", host)
+		}
+		target = host
+		// passthrough hands the address to the dialer unresolved; the
+		// dialer ignores it and always dials the socket.
+		dialTarget = "passthrough:///localhost"
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return dialUnix(ctx, path)
+		}))
+	} else if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	} else if err := checkTarget(context.Background(), host); err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(dialTarget, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gRPC server at %s: %w", target, err)
+	}
+	return conn, nil
+}
+
 // AnalyzeGRPCStream monitors a gRPC health-watch stream for the specified
-// duration and returns message-level statistics.
+// duration and returns message-level statistics. A health Watch sends only
+// when the serving status changes, so a quiet stream that stays SERVING is
+// reported healthy; drops are only counted on continuous streams.
 //
 // Bug 4 fix: sequence tracking was split across the goroutine (incrementing
 // its own counter and writing to stats.SequenceNumbers) and the main loop
@@ -84,10 +175,20 @@ func CheckGRPCHealth(host string, port int, timeout int) (map[string]interface{}
 //
 // Bug 7 fix: uses grpc.NewClient instead of deprecated grpc.DialContext.
 func AnalyzeGRPCStream(host string, port int, duration int) (map[string]interface{}, error) {
+	return AnalyzeGRPCStreamWithProgress(host, port, duration, nil)
+}
+
+// AnalyzeGRPCStreamWithProgress is AnalyzeGRPCStream reporting elapsed time
+// and messages received to progress once a second. A nil progress behaves
+// like AnalyzeGRPCStream.
+func AnalyzeGRPCStreamWithProgress(host string, port int, duration int, progress ProgressFunc) (map[string]interface{}, error) {
 	if duration <= 0 {
 		duration = 10
 	}
 
+	if err := checkTarget(context.Background(), host); err != nil {
+		return nil, err
+	}
 	target := fmt.Sprintf("%s:%d", host, port)
 
 	// The context lifetime covers the monitoring window plus a small buffer.
@@ -132,7 +233,74 @@ func AnalyzeGRPCStream(host string, port int, duration int) (map[string]interfac
 		close(stopChan)
 	})
 
-	msgChan := make(chan *grpc_health_v1.HealthCheckResponse, 100)
+	var sp *streamProgress
+	if progress != nil {
+		sp = &streamProgress{
+			window:   time.Duration(duration) * time.Second,
+			interval: time.Second,
+			report:   progress,
+		}
+	}
+	return monitorStream(ctx, cancel, stream, stopChan, stats, sp), nil
+}
+
+// streamProgress configures periodic progress reports from monitorStream.
+type streamProgress struct {
+	window   time.Duration // the monitoring window, shown as the total
+	interval time.Duration
+	report   ProgressFunc
+}
+
+// Stream termination causes reported as terminated_by.
+const (
+	TerminatedByDuration = "duration" // the monitoring window elapsed
+	TerminatedByError    = "error"    // the stream returned an error
+	TerminatedByTimeout  = "timeout"  // the outer context deadline expired first
+)
+
+// HealthStream is the receive side of a health Watch stream.
+// grpc_health_v1.Health_WatchClient satisfies this.
+type HealthStream interface {
+	Recv() (*grpc_health_v1.HealthCheckResponse, error)
+}
+
+// SequencedStream is a HealthStream that also knows the sender's sequence
+// number of the message most recently returned by Recv. Streams that do not
+// implement it are numbered by arrival order, which cannot reveal drops.
+// A health Watch stream never implements it, since HealthCheckResponse
+// carries no sequence number, so drops are only detected for streams that
+// wrap a sender-numbered protocol; results say which via stream_type.
+type SequencedStream interface {
+	HealthStream
+	LastSequence() int64
+}
+
+// streamMessage is a received message with its sequence number (0 when the
+// stream does not report one) and the time it arrived.
+type streamMessage struct {
+	resp       *grpc_health_v1.HealthCheckResponse
+	seq        int64
+	receivedAt time.Time
+}
+
+// MonitorStream runs the monitoring loop over an established stream (exported for testing)
+func MonitorStream(ctx context.Context, cancel context.CancelFunc, stream HealthStream, stopChan <-chan struct{}, stats *StreamStats) map[string]interface{} {
+	return monitorStream(ctx, cancel, stream, stopChan, stats, nil)
+}
+
+// MonitorStreamWithProgress is MonitorStream reporting "monitoring Ns/Ms"
+// to progress every interval (exported for testing)
+func MonitorStreamWithProgress(ctx context.Context, cancel context.CancelFunc, stream HealthStream, stopChan <-chan struct{}, stats *StreamStats, window, interval time.Duration, progress ProgressFunc) map[string]interface{} {
+	return monitorStream(ctx, cancel, stream, stopChan, stats, &streamProgress{window: window, interval: interval, report: progress})
+}
+
+// monitorStream reads from stream until stopChan closes, the stream fails, or
+// ctx expires. Every exit path stops the reader, drains messages it already
+// forwarded, and runs the same finalisation so partial results are complete.
+// A non-nil progress is reported to from the main loop, so the counts it
+// sees need no locking.
+func monitorStream(ctx context.Context, cancel context.CancelFunc, stream HealthStream, stopChan <-chan struct{}, stats *StreamStats, progress *streamProgress) map[string]interface{} {
+	msgChan := make(chan streamMessage, 100)
 	errChan := make(chan error, 1)
 	var wg sync.WaitGroup
 
@@ -141,6 +309,9 @@ func AnalyzeGRPCStream(host string, port int, duration int) (map[string]interfac
 	// or writes to stats.SequenceNumbers. It only forwards raw messages.
 	// Bug 6 fix: a ctx.Err() check distinguishes an intentional cancel (clean
 	// stop) from a real network error so we don't report a spurious error.
+	sequenced, _ := stream.(SequencedStream)
+	stats.Continuous = sequenced != nil
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -157,71 +328,128 @@ func AnalyzeGRPCStream(host string, port int, duration int) (map[string]interfac
 				errChan <- err
 				return
 			}
-			msgChan <- resp
+			msg := streamMessage{resp: resp, receivedAt: time.Now()}
+			if sequenced != nil {
+				msg.seq = sequenced.LastSequence()
+			}
+			select {
+			case msgChan <- msg:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
 	// Main monitoring loop.
 	// Bug 4 fix: lastSeq is the single source of truth for sequence numbers.
-	// Each received message increments lastSeq and records that sequence in
+	// Each received message advances lastSeq and records that sequence in
 	// the map here, so the map and lastSeq are always in sync.
 	lastSeq := int64(0)
 	receiveCount := 0
 
+	if stats.ReceiveTimes == nil {
+		stats.ReceiveTimes = make(map[int64]time.Time)
+	}
+
+	record := func(msg streamMessage) {
+		resp := msg.resp
+		receiveCount++
+		// Bug 4 fix: sequence number recorded here, in the same place
+		// that advances lastSeq, so they are always equal. A sender-reported
+		// sequence may skip ahead, leaving gaps for finalize to find.
+		seq := msg.seq
+		if seq <= 0 {
+			seq = lastSeq + 1
+		}
+		if seq > lastSeq {
+			lastSeq = seq
+		}
+		stats.SequenceNumbers[seq] = true
+		stats.ReceiveTimes[seq] = msg.receivedAt
+
+		if stats.LastStatus != "" && stats.LastStatus != resp.Status.String() {
+			stats.FlowControlEvents++
+		}
+		if stats.LastStatus != resp.Status.String() {
+			stats.StatusChanges = append(stats.StatusChanges, StatusChange{
+				Status: resp.Status.String(),
+				AtMs:   durationMs(msg.receivedAt.Sub(stats.StartTime)),
+			})
+		}
+		stats.LastStatus = resp.Status.String()
+	}
+
+	finish := func(terminatedBy string) map[string]interface{} {
+		stats.EndTime = time.Now()
+
+		// Bug 6 fix: cancel the context to signal stream.Recv() to return,
+		// which unblocks the goroutine cleanly. CloseSend() is removed.
+		cancel()
+		wg.Wait()
+
+		// Messages forwarded before the reader stopped still count. The
+		// reader has exited, so the buffer can no longer grow.
+		for len(msgChan) > 0 {
+			record(<-msgChan)
+		}
+
+		stats.finalize(lastSeq, receiveCount, terminatedBy)
+		return stats.ToMap()
+	}
+
+	// A nil channel never fires, so without progress the case is inert.
+	var tick <-chan time.Time
+	if progress != nil && progress.interval > 0 {
+		ticker := time.NewTicker(progress.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
 	for {
 		select {
 		case <-stopChan:
-			stats.EndTime = time.Now()
-
-			// Bug 6 fix: cancel the context to signal stream.Recv() to return,
-			// which unblocks the goroutine cleanly. CloseSend() is removed.
-			cancel()
-			wg.Wait()
-
-			// Detect gaps in the sequence space.
-			if lastSeq > 0 {
-				for i := int64(1); i <= lastSeq; i++ {
-					if !stats.SequenceNumbers[i] {
-						stats.DroppedSequences = append(stats.DroppedSequences, i)
-					}
-				}
-			}
-
-			stats.MessagesReceived = receiveCount
-			if stats.MessagesSent > 0 {
-				stats.DropPercentage = float64(len(stats.DroppedSequences)) * 100.0 / float64(stats.MessagesSent)
-			}
-			stats.MonitoringDuration = stats.EndTime.Sub(stats.StartTime).Seconds()
+			return finish(TerminatedByDuration)
 
-			return stats.ToMap(), nil
+		case <-tick:
+			progress.report(fmt.Sprintf("monitoring %ds/%ds, %d messages received",
+				int(time.Since(stats.StartTime).Seconds()), int(progress.window.Seconds()), receiveCount))
 
 		case resp := <-msgChan:
-			receiveCount++
-			lastSeq++
-			// Bug 4 fix: sequence number recorded here, in the same select case
-			// that increments lastSeq, so they are always equal.
-			stats.SequenceNumbers[lastSeq] = true
-
-			if stats.LastStatus != "" && stats.LastStatus != resp.Status.String() {
-				stats.FlowControlEvents++
-			}
-			stats.LastStatus = resp.Status.String()
+			record(resp)
 
 		case err := <-errChan:
 			stats.Errors = append(stats.Errors, err.Error())
-			stats.EndTime = time.Now()
+			return finish(TerminatedByError)
 
-			stats.MessagesReceived = receiveCount
-			if stats.MessagesSent > 0 {
-				stats.DropPercentage = float64(len(stats.DroppedSequences)) * 100.0 / float64(stats.MessagesSent)
-			}
-			stats.MonitoringDuration = stats.EndTime.Sub(stats.StartTime).Seconds()
-
-			return stats.ToMap(), nil
+		case <-ctx.Done():
+			stats.Errors = append(stats.Errors, ctx.Err().Error())
+			return finish(TerminatedByTimeout)
 		}
 	}
 }
 
+// Stream types reported as stream_type.
+const (
+	// StreamEventDriven streams, such as a health Watch, send only when
+	// something changes, so few messages is normal and a drop cannot be
+	// told from silence.
+	StreamEventDriven = "event_driven"
+	// StreamContinuous streams number every message, so gaps are drops.
+	StreamContinuous = "continuous"
+)
+
+// Stream health verdicts reported as health.
+const (
+	StreamHealthy = "healthy"
+	// StreamDegraded is a serving continuous stream dropping over 1% of
+	// messages.
+	StreamDegraded = "degraded"
+	// StreamUnhealthy is a stream whose last status was not SERVING.
+	StreamUnhealthy = "unhealthy"
+	// StreamHealthUnknown is a stream that reported no status.
+	StreamHealthUnknown = "unknown"
+)
+
 // StreamStats holds statistics about a monitored gRPC stream.
 type StreamStats struct {
 	// Bug 5 fix: Host and Port added so ToMap() can return the actual values.
@@ -239,6 +467,97 @@ type StreamStats struct {
 	LastStatus         string
 	MonitoringDuration float64
 	Errors             []string
+	TerminatedBy       string
+
+	// ReceiveTimes records when each received sequence arrived, so drops can
+	// be placed in time.
+	ReceiveTimes map[int64]time.Time
+	// DropClusters is only ever non-empty for a Continuous stream.
+	DropClusters []DropCluster
+
+	// Continuous is set when the sender numbers its messages (a
+	// SequencedStream), so gaps are drops and DropPercentage means
+	// something. It is unset for event-driven streams like a health Watch.
+	Continuous bool
+
+	// StatusChanges are the serving statuses the stream reported, each
+	// when it first differed from the one before; the first entry is the
+	// initial status.
+	StatusChanges []StatusChange
+}
+
+// StatusChange is a serving status a health Watch stream reported, AtMs
+// milliseconds after monitoring started.
+type StatusChange struct {
+	Status string  `json:"status"`
+	AtMs   float64 `json:"at_ms"`
+}
+
+// DropCluster is a contiguous run of dropped sequence numbers. The window
+// runs from the arrival of the last message before the run to the arrival of
+// the first message after it (or the end of monitoring if none followed).
+type DropCluster struct {
+	StartSeq    int64     `json:"start_seq"`
+	EndSeq      int64     `json:"end_seq"`
+	Count       int       `json:"count"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	WindowMs    int64     `json:"window_ms"`
+}
+
+// finalize derives drop detection, drop percentage, and monitoring duration
+// from the sequences seen, and records why monitoring stopped.
+func (s *StreamStats) finalize(lastSeq int64, receiveCount int, terminatedBy string) {
+	// Detect gaps in the sequence space.
+	for i := int64(1); i <= lastSeq; i++ {
+		if !s.SequenceNumbers[i] {
+			s.DroppedSequences = append(s.DroppedSequences, i)
+		}
+	}
+
+	s.MessagesReceived = receiveCount
+	// The highest sequence seen is how many messages the sender is known
+	// to have sent. MessagesSent counts only the Watch request, so
+	// dividing by it would report hundreds of percent.
+	if s.Continuous && lastSeq > 0 {
+		s.DropPercentage = float64(len(s.DroppedSequences)) * 100.0 / float64(lastSeq)
+	}
+	s.MonitoringDuration = s.EndTime.Sub(s.StartTime).Seconds()
+	s.TerminatedBy = terminatedBy
+	s.DropClusters = s.clusterDrops()
+}
+
+// clusterDrops groups DroppedSequences into contiguous runs and bounds each
+// run by the receive times of its neighbouring sequences.
+func (s *StreamStats) clusterDrops() []DropCluster {
+	clusters := []DropCluster{}
+	for i := 0; i < len(s.DroppedSequences); {
+		j := i
+		for j+1 < len(s.DroppedSequences) && s.DroppedSequences[j+1] == s.DroppedSequences[j]+1 {
+			j++
+		}
+		start, end := s.DroppedSequences[i], s.DroppedSequences[j]
+
+		windowStart, ok := s.ReceiveTimes[start-1]
+		if !ok {
+			windowStart = s.StartTime
+		}
+		windowEnd, ok := s.ReceiveTimes[end+1]
+		if !ok {
+			windowEnd = s.EndTime
+		}
+
+		clusters = append(clusters, DropCluster{
+			StartSeq:    start,
+			EndSeq:      end,
+			Count:       int(end - start + 1),
+			WindowStart: windowStart,
+			WindowEnd:   windowEnd,
+			WindowMs:    windowEnd.Sub(windowStart).Milliseconds(),
+		})
+		i = j + 1
+	}
+	return clusters
 }
 
 // ToMap converts StreamStats to a map for JSON serialization.
@@ -250,10 +569,28 @@ func (s *StreamStats) ToMap() map[string]interface{} {
 		"messages_sent":           s.MessagesSent,
 		"messages_received":       s.MessagesReceived,
 		"dropped_count":           len(s.DroppedSequences),
-		"drop_percentage":         fmt.Sprintf("%.2f", s.DropPercentage),
+		"dropped_sequences":       s.DroppedSequences,
+		"drop_clusters":           s.dropClustersOrEmpty(),
 		"flow_control_events":     s.FlowControlEvents,
 		"monitoring_duration_sec": fmt.Sprintf("%.2f", s.MonitoringDuration),
 		"status":                  "ok",
+		"status_changes":          s.statusChangesOrEmpty(),
+		"stream_type":             StreamEventDriven,
+		"health":                  s.health(),
+	}
+	// An event-driven stream cannot show drops, so a percentage of them
+	// would only ever read 0.00.
+	if s.Continuous {
+		result["stream_type"] = StreamContinuous
+		result["drop_percentage"] = fmt.Sprintf("%.2f", s.DropPercentage)
+	}
+
+	if s.LastStatus != "" {
+		result["last_status"] = s.LastStatus
+	}
+
+	if s.TerminatedBy != "" {
+		result["terminated_by"] = s.TerminatedBy
 	}
 
 	if len(s.Errors) > 0 {
@@ -261,9 +598,38 @@ func (s *StreamStats) ToMap() map[string]interface{} {
 		result["errors"] = s.Errors
 	}
 
-	if s.DropPercentage > 1.0 {
+	if s.Continuous && s.DropPercentage > 1.0 {
 		result["status"] = "warning"
 	}
 
 	return result
 }
+
+// health judges the stream by its last serving status and, for continuous
+// streams, its drops. How many messages arrived does not matter: a health
+// Watch that stays SERVING sends just one.
+func (s *StreamStats) health() string {
+	switch {
+	case s.LastStatus == "":
+		return StreamHealthUnknown
+	case s.LastStatus != grpc_health_v1.HealthCheckResponse_SERVING.String():
+		return StreamUnhealthy
+	case s.Continuous && s.DropPercentage > 1.0:
+		return StreamDegraded
+	}
+	return StreamHealthy
+}
+
+func (s *StreamStats) statusChangesOrEmpty() []StatusChange {
+	if s.StatusChanges == nil {
+		return []StatusChange{}
+	}
+	return s.StatusChanges
+}
+
+func (s *StreamStats) dropClustersOrEmpty() []DropCluster {
+	if s.DropClusters == nil {
+		return []DropCluster{}
+	}
+	return s.DropClusters
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verdicts reported in GRPCHealthProfileResult.Verdict.
+const (
+	// HealthStable means Watch reported one status throughout and it
+	// matched the Check.
+	HealthStable = "stable"
+	// HealthFlapped means the status changed during the Watch.
+	HealthFlapped = "flapped"
+	// HealthDiverged means Watch held one status that differed from the
+	// Check: the Check does not describe what the stream reports.
+	HealthDiverged = "diverged"
+	// HealthInconclusive means Watch reported no status, e.g. because the
+	// server does not implement it.
+	HealthInconclusive = "inconclusive"
+)
+
+// GRPCHealthProfileResult compares a one-shot health Check with a Watch
+// stream that follows it.
+type GRPCHealthProfileResult struct {
+	Host            string `json:"host"`
+	Port            int    `json:"port"`
+	DurationSeconds int    `json:"duration_seconds"`
+	CheckStatus     string `json:"check_status"`
+	// WatchStatuses are the statuses Watch reported, each with when it
+	// began.
+	WatchStatuses []StatusChange `json:"watch_statuses"`
+	// Transitions counts status changes during the Watch, not counting
+	// its initial status.
+	Transitions int `json:"transitions"`
+	// Diverged is set when the Watch's first status differs from the
+	// Check, whatever happened after.
+	Diverged bool     `json:"diverged"`
+	Verdict  string   `json:"verdict"`
+	Summary  string   `json:"summary"`
+	Warnings []string `json:"warnings"`
+	// Watch is the full AnalyzeGRPCStream result.
+	Watch map[string]interface{} `json:"watch"`
+}
+
+// GRPCHealthProfile runs CheckGRPCHealth, then watches health with
+// AnalyzeGRPCStream for duration seconds (default 10), and reports whether
+// the status was stable, flapped during the watch, or diverged from what
+// the Check said. It catches servers whose Check answers SERVING while the
+// stream shows them going in and out of service.
+func GRPCHealthProfile(host string, port int, duration int) (*GRPCHealthProfileResult, error) {
+	return GRPCHealthProfileWithProgress(host, port, duration, nil)
+}
+
+// GRPCHealthProfileWithProgress is GRPCHealthProfile reporting the Watch's
+// progress as AnalyzeGRPCStreamWithProgress does.
+func GRPCHealthProfileWithProgress(host string, port int, duration int, progress ProgressFunc) (*GRPCHealthProfileResult, error) {
+	if duration <= 0 {
+		duration = 10
+	}
+
+	check, err := CheckGRPCHealth(host, port, 5)
+	if err != nil {
+		return nil, err
+	}
+	watch, err := AnalyzeGRPCStreamWithProgress(host, port, duration, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GRPCHealthProfileResult{
+		Host:            host,
+		Port:            port,
+		DurationSeconds: duration,
+		WatchStatuses:   []StatusChange{},
+		Warnings:        []string{},
+		Watch:           watch,
+	}
+	result.CheckStatus, _ = check["status"].(string)
+	if changes, ok := watch["status_changes"].([]StatusChange); ok {
+		result.WatchStatuses = changes
+	}
+	if errs, ok := watch["errors"].([]string); ok {
+		for _, e := range errs {
+			result.Warnings = append(result.Warnings, "watch stream: "+e)
+		}
+	}
+
+	classifyHealthProfile(result)
+	return result, nil
+}
+
+// classifyHealthProfile sets the verdict and summary from the Check status
+// and the Watch statuses.
+func classifyHealthProfile(r *GRPCHealthProfileResult) {
+	if len(r.WatchStatuses) == 0 {
+		r.Verdict = HealthInconclusive
+		r.Summary = fmt.Sprintf("Check reported %s but Watch reported no status; the server may not implement Watch", r.CheckStatus)
+		return
+	}
+
+	r.Transitions = len(r.WatchStatuses) - 1
+	r.Diverged = r.WatchStatuses[0].Status != r.CheckStatus
+	statuses := make([]string, len(r.WatchStatuses))
+	for i, c := range r.WatchStatuses {
+		statuses[i] = c.Status
+	}
+
+	switch {
+	case r.Transitions > 0:
+		r.Verdict = HealthFlapped
+		r.Summary = fmt.Sprintf("status changed %d times in %ds (%s) after Check reported %s",
+			r.Transitions, r.DurationSeconds, strings.Join(statuses, " -> "), r.CheckStatus)
+	case r.Diverged:
+		r.Verdict = HealthDiverged
+		r.Summary = fmt.Sprintf("Check reported %s but Watch reported %s throughout", r.CheckStatus, statuses[0])
+	default:
+		r.Verdict = HealthStable
+		r.Summary = fmt.Sprintf("%s throughout %ds, matching Check", r.CheckStatus, r.DurationSeconds)
+	}
+}
@@ -0,0 +1,183 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxHTTPProbeCount caps the concurrent requests of HTTPProbeMulti.
+const MaxHTTPProbeCount = 50
+
+// latencyOutlierFactor and latencyOutlierMinMs decide when the slowest
+// response is far enough above the median to report: at least this many
+// times the median and this many milliseconds slower.
+const (
+	latencyOutlierFactor = 3
+	latencyOutlierMinMs  = 100
+)
+
+// HTTPProbe is one request made by HTTPProbeMulti.
+type HTTPProbe struct {
+	Probe          int     `json:"probe"`
+	StatusCode     int     `json:"status_code,omitempty"`
+	ResponseTimeMs float64 `json:"response_time_ms"`
+	// Server is the response's Server header, which often names the
+	// backend behind a load balancer.
+	Server    string `json:"server,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HTTPProbeMultiResult is the output of HTTPProbeMulti.
+type HTTPProbeMultiResult struct {
+	URL       string `json:"url"`
+	Count     int    `json:"count"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	// StatusCodes counts responses by status code; DistinctStatusCodes
+	// lists those codes in ascending order.
+	StatusCodes         map[int]int `json:"status_codes"`
+	DistinctStatusCodes []int       `json:"distinct_status_codes"`
+	// Servers counts responses by Server header, when any was sent.
+	Servers map[string]int `json:"servers,omitempty"`
+	// Latency is the distribution over responses; LatencySpreadMs is its
+	// max less its min. Nil when no request got a response.
+	Latency         *LatencyStats `json:"latency"`
+	LatencySpreadMs float64       `json:"latency_spread_ms"`
+	// Consistent is set when every request got a response with the same
+	// status code and Server header and no response was a latency outlier;
+	// Inconsistencies says what differed otherwise.
+	Consistent      bool        `json:"consistent"`
+	Inconsistencies []string    `json:"inconsistencies"`
+	Probes          []HTTPProbe `json:"probes"`
+}
+
+// HTTPProbeMulti sends count (1-50) GET requests to url at once and
+// compares the responses, to find the backend that answers differently
+// behind a load balancer. Each request opens its own connection, so
+// requests can reach different backends rather than share a keep-alive
+// connection to one.
+func HTTPProbeMulti(url string, count int) (*HTTPProbeMultiResult, error) {
+	return HTTPProbeMultiContext(context.Background(), url, count)
+}
+
+// HTTPProbeMultiContext is HTTPProbeMulti that returns an error if ctx is
+// cancelled before the requests complete.
+func HTTPProbeMultiContext(ctx context.Context, url string, count int) (*HTTPProbeMultiResult, error) {
+	if url == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if count < 1 || count > MaxHTTPProbeCount {
+		return nil, fmt.Errorf("count must be between 1 and %d, got %d", MaxHTTPProbeCount, count)
+	}
+	// Checked once up front rather than failing every probe alike.
+	if err := checkURLTarget(ctx, withScheme(url)); err != nil {
+		return nil, err
+	}
+
+	probes := make([]HTTPProbe, count)
+	durations := make([]time.Duration, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			probes[i].Probe = i + 1
+			start := time.Now()
+			resp, err := httpRequest(ctx, url, "GET", "", DefaultMaxRedirects, false)
+			durations[i] = time.Since(start)
+			probes[i].ResponseTimeMs = durationMs(durations[i])
+			if err != nil {
+				probes[i].Error = err.Error()
+				return
+			}
+			probes[i].StatusCode = resp.StatusCode
+			probes[i].Server = resp.Headers["Server"]
+			probes[i].RequestID = resp.RequestID
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("probing %s: %w", url, err)
+	}
+
+	return summarizeHTTPProbes(url, probes, durations), nil
+}
+
+// summarizeHTTPProbes compares the probes' responses.
+func summarizeHTTPProbes(url string, probes []HTTPProbe, durations []time.Duration) *HTTPProbeMultiResult {
+	result := &HTTPProbeMultiResult{
+		URL:                 url,
+		Count:               len(probes),
+		StatusCodes:         make(map[int]int),
+		DistinctStatusCodes: []int{},
+		Inconsistencies:     []string{},
+		Probes:              probes,
+	}
+
+	var responded []time.Duration
+	servers := make(map[string]int)
+	errs := make(map[string]int)
+	for i, p := range probes {
+		if p.Error != "" {
+			result.Failed++
+			errs[p.Error]++
+			continue
+		}
+		result.Succeeded++
+		responded = append(responded, durations[i])
+		result.StatusCodes[p.StatusCode]++
+		if p.Server != "" {
+			servers[p.Server]++
+		}
+	}
+	for code := range result.StatusCodes {
+		result.DistinctStatusCodes = append(result.DistinctStatusCodes, code)
+	}
+	sort.Ints(result.DistinctStatusCodes)
+	if len(servers) > 0 {
+		result.Servers = servers
+	}
+
+	if result.Failed > 0 {
+		result.Inconsistencies = append(result.Inconsistencies,
+			fmt.Sprintf("%d of %d requests failed (%d distinct errors)", result.Failed, result.Count, len(errs)))
+	}
+	if len(result.DistinctStatusCodes) > 1 {
+		result.Inconsistencies = append(result.Inconsistencies,
+			fmt.Sprintf("%d different status codes: %s", len(result.DistinctStatusCodes), formatStatusCounts(result.StatusCodes, result.DistinctStatusCodes)))
+	}
+	if len(servers) > 1 {
+		result.Inconsistencies = append(result.Inconsistencies,
+			fmt.Sprintf("responses came from %d different Server headers", len(servers)))
+	}
+
+	if len(responded) > 0 {
+		result.Latency = NewLatencyStats(responded)
+		result.Latency.Failed = result.Failed
+		result.LatencySpreadMs = result.Latency.MaxMs - result.Latency.MinMs
+		if result.Latency.MaxMs >= latencyOutlierFactor*result.Latency.P50Ms &&
+			result.Latency.MaxMs-result.Latency.P50Ms >= latencyOutlierMinMs {
+			result.Inconsistencies = append(result.Inconsistencies,
+				fmt.Sprintf("slowest response took %.0fms against a median of %.0fms", result.Latency.MaxMs, result.Latency.P50Ms))
+		}
+	}
+
+	result.Consistent = len(result.Inconsistencies) == 0
+	return result
+}
+
+// formatStatusCounts renders counts as "200 x7, 502 x3" in the order of codes.
+func formatStatusCounts(counts map[int]int, codes []int) string {
+	s := ""
+	for i, code := range codes {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%d x%d", code, counts[code])
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/friday/internal/percentile"
+)
+
+// MaxLatencySamples caps the samples parameter of repeated probes.
+const MaxLatencySamples = 100
+
+// LatencyStats is the latency distribution of repeated probes to one target.
+// Percentiles use the nearest-rank method over successful probes.
+type LatencyStats struct {
+	// Samples counts successful probes and Failed the probes that errored.
+	Samples int     `json:"samples"`
+	Failed  int     `json:"failed"`
+	MinMs   float64 `json:"min_ms"`
+	AvgMs   float64 `json:"avg_ms"`
+	P50Ms   float64 `json:"p50_ms"`
+	P90Ms   float64 `json:"p90_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+	MaxMs   float64 `json:"max_ms"`
+	// SamplesMs lists each successful probe's latency in the order taken.
+	SamplesMs []float64 `json:"samples_ms"`
+	// LastError is the error from the most recent failed probe.
+	LastError string `json:"last_error,omitempty"`
+	// ColdStart compares the first probe with the rest; set only when asked
+	// for.
+	ColdStart *ColdStartStats `json:"cold_start,omitempty"`
+}
+
+// A cold start penalty is flagged when the first probe took at least
+// coldStartPenaltyRatio times the steady-state mean and at least
+// coldStartMinPenalty longer, so that sub-millisecond jitter on a fast
+// target is not mistaken for one.
+const (
+	coldStartPenaltyRatio = 2.0
+	coldStartMinPenalty   = 10 * time.Millisecond
+)
+
+// ColdStartStats sets the first of a run of probes, which pays for
+// connection setup, handshakes and cold caches, against the mean of the
+// probes after it. A large penalty on a client that reuses connections
+// points at keep-alive or connection-pool settings that drop them.
+type ColdStartStats struct {
+	FirstMs       float64 `json:"first_ms"`
+	SteadyStateMs float64 `json:"steady_state_avg_ms"`
+	PenaltyMs     float64 `json:"penalty_ms"`
+	// PenaltyRatio is FirstMs over SteadyStateMs; 0 if the steady state
+	// rounds to zero.
+	PenaltyRatio float64 `json:"penalty_ratio"`
+	// ColdStartPenalty is true when the first probe was markedly slower
+	// than the steady state.
+	ColdStartPenalty bool `json:"cold_start_penalty"`
+	// Note says why the comparison could not be made, such as the first
+	// probe failing.
+	Note string `json:"note,omitempty"`
+}
+
+// NewLatencyStats summarizes probe durations.
+func NewLatencyStats(durations []time.Duration) *LatencyStats {
+	stats := &LatencyStats{Samples: len(durations), SamplesMs: make([]float64, len(durations))}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sorted := make([]float64, len(durations))
+	var sum time.Duration
+	for i, d := range durations {
+		stats.SamplesMs[i] = durationMs(d)
+		sorted[i] = stats.SamplesMs[i]
+		sum += d
+	}
+	sort.Float64s(sorted)
+
+	stats.MinMs = sorted[0]
+	stats.AvgMs = durationMs(sum / time.Duration(len(durations)))
+	stats.P50Ms = percentile.NearestRank(sorted, 50)
+	stats.P90Ms = percentile.NearestRank(sorted, 90)
+	stats.P99Ms = percentile.NearestRank(sorted, 99)
+	stats.MaxMs = sorted[len(sorted)-1]
+	return stats
+}
+
+// durationMs converts d to milliseconds with microsecond precision.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// sampleLatency runs probe samples times in sequence, stopping early if ctx is
+// cancelled, and returns the distribution of successful probes. Callers keep
+// whatever result they need from inside probe. It fails only if every probe
+// fails or ctx is cancelled.
+func sampleLatency(ctx context.Context, samples int, probe func(context.Context) error) (*LatencyStats, error) {
+	var (
+		durations []time.Duration
+		failed    int
+		lastErr   error
+	)
+	for i := 0; i < samples; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("stopped after %d of %d samples: %w", i, samples, err)
+		}
+		start := time.Now()
+		err := probe(ctx)
+		elapsed := time.Since(start)
+		if err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		durations = append(durations, elapsed)
+	}
+	if len(durations) == 0 {
+		return nil, fmt.Errorf("all %d samples failed: %w", samples, lastErr)
+	}
+
+	stats := NewLatencyStats(durations)
+	stats.Failed = failed
+	if lastErr != nil {
+		stats.LastError = lastErr.Error()
+	}
+	return stats, nil
+}
+
+// sampleColdStart is sampleLatency with the first probe compared against
+// the rest in ColdStart. probe should share connections between calls, as
+// a real client would, or every probe pays the cold cost.
+func sampleColdStart(ctx context.Context, samples int, probe func(context.Context) error) (*LatencyStats, error) {
+	if samples < 2 {
+		return nil, fmt.Errorf("a cold start comparison needs at least 2 samples, got %d", samples)
+	}
+	attempts := 0
+	firstOK := false
+	stats, err := sampleLatency(ctx, samples, func(ctx context.Context) error {
+		attempts++
+		err := probe(ctx)
+		if attempts == 1 {
+			firstOK = err == nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats.ColdStart = newColdStartStats(stats.SamplesMs, firstOK)
+	return stats, nil
+}
+
+// newColdStartStats compares the first of samplesMs with the mean of the
+// rest. firstOK says whether the first probe attempted succeeded; if not,
+// samplesMs[0] was already warm and no comparison is made.
+func newColdStartStats(samplesMs []float64, firstOK bool) *ColdStartStats {
+	switch {
+	case !firstOK:
+		return &ColdStartStats{Note: "the first probe failed, so no cold sample was taken"}
+	case len(samplesMs) < 2:
+		return &ColdStartStats{FirstMs: samplesMs[0], Note: "no later probe succeeded to compare against"}
+	}
+
+	var sum float64
+	for _, ms := range samplesMs[1:] {
+		sum += ms
+	}
+	cs := &ColdStartStats{
+		FirstMs:       samplesMs[0],
+		SteadyStateMs: math.Round(sum/float64(len(samplesMs)-1)*1000) / 1000,
+	}
+	cs.PenaltyMs = math.Round((cs.FirstMs-cs.SteadyStateMs)*1000) / 1000
+	if cs.SteadyStateMs > 0 {
+		cs.PenaltyRatio = math.Round(cs.FirstMs/cs.SteadyStateMs*100) / 100
+	}
+	cs.ColdStartPenalty = cs.PenaltyMs >= durationMs(coldStartMinPenalty) &&
+		cs.FirstMs >= coldStartPenaltyRatio*cs.SteadyStateMs
+	return cs
+}
+
+// checkSamples validates the samples parameter of a repeated probe.
+func checkSamples(samples int) error {
+	if samples < 1 || samples > MaxLatencySamples {
+		return fmt.Errorf("samples must be between 1 and %d, got %d", MaxLatencySamples, samples)
+	}
+	return nil
+}
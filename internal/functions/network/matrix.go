@@ -0,0 +1,133 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// matrixConcurrency bounds how many connects ConnectivityMatrix runs at once.
+const matrixConcurrency = 16
+
+// maxMatrixTargets caps the targets checked in one call.
+const maxMatrixTargets = 256
+
+// ConnectivityCheck is one row of a connectivity matrix.
+type ConnectivityCheck struct {
+	Target    string  `json:"target"`
+	Reachable bool    `json:"reachable"`
+	LatencyMs float64 `json:"latency_ms"`
+	// ErrorClass is a ClassifyConnError category, empty when reachable.
+	ErrorClass string `json:"error_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ConnectivityMatrixResult holds a TCP connect check against each target.
+type ConnectivityMatrixResult struct {
+	Results     []ConnectivityCheck `json:"results"`
+	Total       int                 `json:"total"`
+	Reachable   int                 `json:"reachable"`
+	Unreachable int                 `json:"unreachable"`
+
+	// Explanations describes each error class seen once.
+	Explanations map[string]ConnErrorExplanation `json:"explanations,omitempty"`
+}
+
+// ConnectivityMatrix opens a TCP connection to every "host:port" target, or a
+// unix-domain socket connection to every "unix:///path" target, a bounded
+// number at a time, answering "can this host reach all of its
+// dependencies?". Results keep the order of targets. timeout is the per-
+// connect limit in seconds (default 3). An error is returned only for an
+// empty list, a target that is neither host:port nor unix://, or one outside
+// the target allowlist.
+func ConnectivityMatrix(targets []string, timeout int) (*ConnectivityMatrixResult, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no targets specified")
+	}
+	if len(targets) > maxMatrixTargets {
+		return nil, fmt.Errorf("too many targets: %d (max %d)", len(targets), maxMatrixTargets)
+	}
+	cleaned := make([]string, len(targets))
+	for i, t := range targets {
+		t = strings.TrimSpace(t)
+		if path, ok := UnixSocketPath(t); ok {
+			if path == "" {
+				return nil, fmt.Errorf("invalid target '%s': missing socket path", t)
+			}
+			cleaned[i] = t
+			continue
+		}
+		host, port, err := net.SplitHostPort(t)
+		if err != nil || host == "" {
+			return nil, fmt.Errorf("invalid target '%s': want host:port or unix:///path", t)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("invalid port in target '%s'", t)
+		}
+		if err := checkTarget(context.Background(), host); err != nil {
+			return nil, err
+		}
+		cleaned[i] = t
+	}
+	if timeout <= 0 {
+		timeout = 3
+	}
+	dialTimeout := time.Duration(timeout) * time.Second
+
+	result := &ConnectivityMatrixResult{
+		Results:      make([]ConnectivityCheck, len(targets)),
+		Total:        len(targets),
+		Explanations: make(map[string]ConnErrorExplanation),
+	}
+
+	sem := make(chan struct{}, matrixConcurrency)
+	var wg sync.WaitGroup
+	for i, target := range cleaned {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result.Results[i] = checkConnectivity(target, dialTimeout)
+		}(i, target)
+	}
+	wg.Wait()
+
+	for _, r := range result.Results {
+		if r.Reachable {
+			result.Reachable++
+			continue
+		}
+		result.Unreachable++
+		result.Explanations[r.ErrorClass] = connErrExplanations[r.ErrorClass]
+	}
+
+	return result, nil
+}
+
+// checkConnectivity makes one TCP or unix-domain socket connect to target.
+func checkConnectivity(target string, timeout time.Duration) ConnectivityCheck {
+	check := ConnectivityCheck{Target: target}
+
+	network, address := "tcp", target
+	if path, ok := UnixSocketPath(target); ok {
+		network, address = "unix", path
+	}
+
+	start := time.Now()
+	conn, err := net.DialTimeout(network, address, timeout)
+	check.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		check.ErrorClass = ClassifyConnError(err).Category
+		check.Error = err.Error()
+		return check
+	}
+	conn.Close()
+
+	check.Reachable = true
+	return check
+}
@@ -0,0 +1,146 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Limits for PingOptions.PayloadSizes. maxPingPayload is the largest ICMP
+// echo payload that fits in an IPv4 packet.
+const (
+	MaxPayloadSizes = 10
+	maxPingPayload  = 65507
+)
+
+// ipv4ICMPOverhead is the IPv4 and ICMP header bytes added to a ping
+// payload: an N-byte payload makes an (N+28)-byte packet.
+const ipv4ICMPOverhead = 28
+
+// payloadSweepCount is the echo requests sent at each payload size; a
+// single reply counts the size as reachable.
+const payloadSweepCount = 2
+
+// reReportedMTU matches the MTU ping reports with a too-large error:
+// "message too long, mtu=1400" (Linux), "frag needed and DF set (mtu =
+// 1400)".
+var reReportedMTU = regexp.MustCompile(`(?i)\bmtu\s*=\s*(\d+)`)
+
+// PayloadSizeResult is the outcome of pinging with one payload size and
+// the don't-fragment bit set.
+type PayloadSizeResult struct {
+	Size      int  `json:"size"`
+	Reachable bool `json:"reachable"`
+	// TooLarge is set when ping reported the packet too large to send
+	// unfragmented, rather than just getting no reply, and ReportedMTU is
+	// the MTU it gave, if any.
+	TooLarge    bool `json:"too_large,omitempty"`
+	ReportedMTU int  `json:"reported_mtu,omitempty"`
+}
+
+// checkPayloadSizes validates sizes and returns them sorted without
+// duplicates.
+func checkPayloadSizes(sizes []int) ([]int, error) {
+	if len(sizes) > MaxPayloadSizes {
+		return nil, fmt.Errorf("at most %d payload sizes can be swept, got %d", MaxPayloadSizes, len(sizes))
+	}
+	seen := make(map[int]bool, len(sizes))
+	sorted := make([]int, 0, len(sizes))
+	for _, size := range sizes {
+		if size < 0 || size > maxPingPayload {
+			return nil, fmt.Errorf("payload size must be between 0 and %d, got %d", maxPingPayload, size)
+		}
+		if !seen[size] {
+			seen[size] = true
+			sorted = append(sorted, size)
+		}
+	}
+	sort.Ints(sorted)
+	return sorted, nil
+}
+
+// sweepPayloadSizes pings host once per size with don't-fragment set and
+// records in result where replies stop. When a reachable size is followed
+// by unreachable larger ones, PathMTU is the largest reachable packet, or
+// the MTU ping reported if that is larger and still below the first
+// failing packet.
+func sweepPayloadSizes(host string, sizes []int, opts PingOptions, result *PingResult) {
+	if len(sizes) == 0 {
+		return
+	}
+
+	result.PayloadSweep = make([]PayloadSizeResult, 0, len(sizes))
+	largestOK, firstFailed, reported := -1, -1, 0
+	for _, size := range sizes {
+		probe := pingPayloadSize(host, size, opts)
+		result.PayloadSweep = append(result.PayloadSweep, probe)
+		switch {
+		case probe.Reachable:
+			largestOK = size
+		case firstFailed < 0 && largestOK >= 0:
+			firstFailed = size
+			reported = probe.ReportedMTU
+		}
+	}
+	if largestOK < 0 || firstFailed < 0 {
+		return
+	}
+
+	result.PathMTU = largestOK + ipv4ICMPOverhead
+	if reported > result.PathMTU && reported < firstFailed+ipv4ICMPOverhead {
+		result.PathMTU = reported
+	}
+	if result.LikelyCause == "" {
+		result.LikelyCause = fmt.Sprintf("packets of %d bytes get through but %d-byte packets do not with don't-fragment set: "+
+			"the path MTU is about %d, common on VPNs and tunnels; lower the interface MTU or clamp the TCP MSS",
+			largestOK+ipv4ICMPOverhead, firstFailed+ipv4ICMPOverhead, result.PathMTU)
+	}
+}
+
+// pingPayloadSize pings host with a size-byte payload and don't-fragment
+// set.
+func pingPayloadSize(host string, size int, opts PingOptions) PayloadSizeResult {
+	probe := PayloadSizeResult{Size: size}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(payloadSweepCount*5)*time.Second)
+	defer cancel()
+
+	output, err := opts.run(ctx, "ping", dfPingArgs(host, size)...)
+	var parsed PingResult
+	parsed.PacketsSent = payloadSweepCount
+	if err == nil {
+		parsed.Reachable = true
+		parsePingOutput(string(output), &parsed)
+	} else {
+		parseICMPErrors(string(output), &parsed)
+	}
+	probe.Reachable = parsed.Reachable
+
+	for _, kind := range parsed.ICMPErrors {
+		if kind == ICMPFragNeeded {
+			probe.TooLarge = true
+		}
+	}
+	if m := reReportedMTU.FindStringSubmatch(string(output)); m != nil {
+		probe.ReportedMTU, _ = strconv.Atoi(m[1])
+	}
+	return probe
+}
+
+// dfPingArgs builds the arguments to ping host with a size-byte payload and
+// the don't-fragment bit set: -M do on Linux, -D on macOS, -f on Windows.
+func dfPingArgs(host string, size int) []string {
+	count, sizeStr := strconv.Itoa(payloadSweepCount), strconv.Itoa(size)
+	switch runtime.GOOS {
+	case "windows":
+		return []string{"-n", count, "-f", "-l", sizeStr, host}
+	case "darwin":
+		return []string{"-c", count, "-D", "-s", sizeStr, host}
+	default:
+		return []string{"-c", count, "-W", "2", "-M", "do", "-s", sizeStr, host}
+	}
+}
@@ -0,0 +1,240 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Neighbor states reported by ip neigh that NeighborTable counts.
+const (
+	NeighborStale      = "STALE"
+	NeighborFailed     = "FAILED"
+	NeighborIncomplete = "INCOMPLETE"
+)
+
+// reNeighborState matches a neighbor state: REACHABLE, STALE, FAILED, ...
+var reNeighborState = regexp.MustCompile(`^[A-Z_]+$`)
+
+// Codes of the findings NeighborTable reports.
+const (
+	// FindingNoIPv6DefaultRoute is no IPv6 default route, which is usually
+	// learned from router advertisements: IPv6 beyond the local link fails
+	// while IPv4 works.
+	FindingNoIPv6DefaultRoute = "no_ipv6_default_route"
+	// FindingNDPFailures is IPv6 neighbors whose link-layer address could
+	// not be resolved by neighbor discovery.
+	FindingNDPFailures = "ndp_resolution_failures"
+)
+
+// NeighborEntry is one line of ip neigh output.
+type NeighborEntry struct {
+	Address   string `json:"address"`
+	Interface string `json:"interface"`
+	LLAddr    string `json:"lladdr,omitempty"`
+	Router    bool   `json:"router,omitempty"`
+	State     string `json:"state"`
+}
+
+// DefaultRoute is one line of ip route show default output.
+type DefaultRoute struct {
+	Via       string `json:"via,omitempty"`
+	Interface string `json:"interface,omitempty"`
+	// Proto is how the route was learned; "ra" is a router advertisement.
+	Proto string `json:"proto,omitempty"`
+}
+
+// NeighborFinding is a problem NeighborTable found, with Code one of the
+// Finding constants.
+type NeighborFinding struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// NeighborTableResult is the output of NeighborTable.
+type NeighborTableResult struct {
+	Interface         string            `json:"interface,omitempty"`
+	IPv4Neighbors     []NeighborEntry   `json:"ipv4_neighbors"`
+	IPv6Neighbors     []NeighborEntry   `json:"ipv6_neighbors"`
+	IPv6DefaultRoutes []DefaultRoute    `json:"ipv6_default_routes"`
+	IPv6StateCounts   map[string]int    `json:"ipv6_state_counts"`
+	Findings          []NeighborFinding `json:"findings"`
+	Warnings          []string          `json:"warnings"`
+}
+
+// NeighborOptions adjusts how NeighborTableContext runs.
+type NeighborOptions struct {
+	// Run runs a command and returns its combined output; nil uses
+	// os/exec. Tests replace it to supply canned ip output.
+	Run func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+func (o NeighborOptions) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if o.Run != nil {
+		return o.Run(ctx, name, args...)
+	}
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// NeighborTable reads the IPv4 and IPv6 neighbor tables with ip neigh,
+// limited to iface when set, and checks IPv6 for the problems that break
+// it silently while IPv4 works: no default route from router
+// advertisements and neighbors neighbor discovery failed to resolve.
+// Linux only.
+func NeighborTable(iface string) (*NeighborTableResult, error) {
+	return NeighborTableContext(context.Background(), iface, NeighborOptions{})
+}
+
+// NeighborTableContext is NeighborTable with opts, stopped if ctx is
+// cancelled.
+func NeighborTableContext(ctx context.Context, iface string, opts NeighborOptions) (*NeighborTableResult, error) {
+	ipArgs := func(args ...string) []string {
+		if iface != "" {
+			args = append(args, "dev", iface)
+		}
+		return args
+	}
+
+	out4, err := opts.run(ctx, "ip", ipArgs("-4", "neigh", "show")...)
+	if err != nil {
+		return nil, fmt.Errorf("ip -4 neigh failed: %w: %s", err, strings.TrimSpace(string(out4)))
+	}
+	out6, err := opts.run(ctx, "ip", ipArgs("-6", "neigh", "show")...)
+	if err != nil {
+		return nil, fmt.Errorf("ip -6 neigh failed: %w: %s", err, strings.TrimSpace(string(out6)))
+	}
+
+	result := &NeighborTableResult{
+		Interface:         iface,
+		IPv4Neighbors:     ParseNeighbors(string(out4)),
+		IPv6Neighbors:     ParseNeighbors(string(out6)),
+		IPv6DefaultRoutes: []DefaultRoute{},
+		IPv6StateCounts:   map[string]int{},
+		Findings:          []NeighborFinding{},
+		Warnings:          []string{},
+	}
+
+	// A default route on any interface serves iface, so this is not
+	// limited to it.
+	outRoute, err := opts.run(ctx, "ip", "-6", "route", "show", "default")
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("IPv6 default route not checked: ip -6 route failed: %v", err))
+	} else {
+		result.IPv6DefaultRoutes = ParseDefaultRoutes(string(outRoute))
+	}
+
+	for _, neighbors := range [][]NeighborEntry{result.IPv4Neighbors, result.IPv6Neighbors} {
+		for i := range neighbors {
+			if neighbors[i].Interface == "" {
+				neighbors[i].Interface = iface
+			}
+		}
+	}
+	for _, n := range result.IPv6Neighbors {
+		result.IPv6StateCounts[n.State]++
+	}
+	result.Findings = ipv6Findings(result, err == nil)
+	return result, nil
+}
+
+// ipv6Findings diagnoses result's IPv6 tables. routesChecked is false when
+// the default routes could not be read.
+func ipv6Findings(result *NeighborTableResult, routesChecked bool) []NeighborFinding {
+	findings := []NeighborFinding{}
+
+	if routesChecked && len(result.IPv6DefaultRoutes) == 0 {
+		detail := "no IPv6 default route: IPv6 beyond the local link will fail while IPv4 works"
+		var routers []string
+		for _, n := range result.IPv6Neighbors {
+			if n.Router {
+				routers = append(routers, n.Address)
+			}
+		}
+		if len(routers) > 0 {
+			detail += fmt.Sprintf("; router %s is a neighbor, so its advertisements are likely ignored (check net.ipv6.conf.*.accept_ra, which is off when forwarding is on)",
+				strings.Join(routers, ", "))
+		} else {
+			detail += "; no router advertisement was received (check that the router sends them and ICMPv6 is not filtered)"
+		}
+		findings = append(findings, NeighborFinding{Code: FindingNoIPv6DefaultRoute, Detail: detail})
+	}
+
+	var failed []string
+	for _, n := range result.IPv6Neighbors {
+		if n.State == NeighborFailed || n.State == NeighborIncomplete {
+			failed = append(failed, n.Address)
+		}
+	}
+	if len(failed) > 0 {
+		findings = append(findings, NeighborFinding{
+			Code: FindingNDPFailures,
+			Detail: fmt.Sprintf("neighbor discovery failed for %d neighbor(s): %s; check that ICMPv6 neighbor solicitations are not filtered and the hosts are on-link",
+				len(failed), strings.Join(failed, ", ")),
+		})
+	}
+	return findings
+}
+
+// ParseNeighbors parses ip neigh output, one entry per line, such as
+// "fe80::1 dev eth0 lladdr 00:11:22:33:44:55 router STALE". ip leaves
+// out the device when the listing is limited to one, so Interface may be
+// empty.
+func ParseNeighbors(output string) []NeighborEntry {
+	entries := []NeighborEntry{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		entry := NeighborEntry{Address: fields[0]}
+		for i := 1; i < len(fields); i++ {
+			switch fields[i] {
+			case "dev":
+				if i+1 < len(fields) {
+					entry.Interface = fields[i+1]
+					i++
+				}
+			case "lladdr":
+				if i+1 < len(fields) {
+					entry.LLAddr = fields[i+1]
+					i++
+				}
+			case "router":
+				entry.Router = true
+			default:
+				if reNeighborState.MatchString(fields[i]) {
+					entry.State = fields[i]
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ParseDefaultRoutes parses ip route show default output, such as
+// "default via fe80::1 dev eth0 proto ra metric 1024 expires 1795sec".
+func ParseDefaultRoutes(output string) []DefaultRoute {
+	routes := []DefaultRoute{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "default" {
+			continue
+		}
+		var route DefaultRoute
+		for i := 1; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "via":
+				route.Via = fields[i+1]
+			case "dev":
+				route.Interface = fields[i+1]
+			case "proto":
+				route.Proto = fields[i+1]
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
@@ -0,0 +1,464 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ocspTimeout bounds the handshake and the query to the OCSP responder.
+const ocspTimeout = 5 * time.Second
+
+// Revocation statuses reported in CertRevocation.RevocationStatus.
+const (
+	// RevocationGood means the CA vouches that the certificate is not revoked.
+	RevocationGood = "good"
+	// RevocationRevoked means the CA has revoked the certificate.
+	RevocationRevoked = "revoked"
+	// RevocationUnknown means the responder does not know the certificate.
+	RevocationUnknown = "unknown"
+	// RevocationUnavailable means no valid OCSP answer could be had: no
+	// staple and no responder URL, the responder was unreachable, or its
+	// answer failed to parse or verify.
+	RevocationUnavailable = "unavailable"
+)
+
+// CertRevocation is the OCSP revocation status of a server's leaf
+// certificate.
+type CertRevocation struct {
+	// RevocationStatus is good, revoked, unknown or unavailable.
+	RevocationStatus string `json:"revocation_status"`
+	// OCSPStapled is true when the status came from an OCSP response the
+	// server stapled to the handshake rather than from the responder.
+	OCSPStapled bool `json:"ocsp_stapled"`
+	// Responder is the OCSP URL from the certificate, queried when nothing
+	// valid was stapled.
+	Responder  string `json:"responder,omitempty"`
+	ProducedAt string `json:"produced_at,omitempty"`
+	ThisUpdate string `json:"this_update,omitempty"`
+	NextUpdate string `json:"next_update,omitempty"`
+	RevokedAt  string `json:"revoked_at,omitempty"`
+	// RevocationReason is the CRL reason code name, when the CA gave one.
+	RevocationReason string `json:"revocation_reason,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// CertRevocationResult is the output of CheckCertRevocation.
+type CertRevocationResult struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Subject  string `json:"subject"`
+	Issuer   string `json:"issuer"`
+	Serial   string `json:"serial"`
+	NotAfter string `json:"not_after"`
+	CertRevocation
+}
+
+// CheckCertRevocation completes a TLS handshake with host:port and reports
+// whether the leaf certificate has been revoked, using the OCSP response
+// the server stapled or, failing that, by asking the OCSP responder named
+// in the certificate. The chain itself is not validated; the OCSP answer
+// is checked against the issuer the server sent.
+func CheckCertRevocation(host string, port int) (*CertRevocationResult, error) {
+	return CheckCertRevocationContext(context.Background(), host, port)
+}
+
+// CheckCertRevocationContext is CheckCertRevocation stopped if ctx is
+// cancelled.
+func CheckCertRevocationContext(ctx context.Context, host string, port int) (*CertRevocationResult, error) {
+	if host == "" {
+		return nil, errors.New("host is required")
+	}
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	if err := checkTarget(ctx, host); err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	dialCtx, cancel := context.WithTimeout(ctx, ocspTimeout)
+	defer cancel()
+	dialer := tls.Dialer{Config: &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, // checking revocation, not validating the chain
+	}}
+	conn, err := dialer.DialContext(dialCtx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake with %s failed: %w", addr, explainConnError(err))
+	}
+	state := conn.(*tls.Conn).ConnectionState()
+	conn.Close()
+	if len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("%s sent no certificate", addr)
+	}
+
+	leaf := state.PeerCertificates[0]
+	return &CertRevocationResult{
+		Host:           host,
+		Port:           port,
+		Subject:        leaf.Subject.String(),
+		Issuer:         leaf.Issuer.String(),
+		Serial:         leaf.SerialNumber.Text(16),
+		NotAfter:       leaf.NotAfter.UTC().Format(time.RFC3339),
+		CertRevocation: *lookupRevocation(ctx, state),
+	}, nil
+}
+
+// lookupRevocation looks up the revocation status of the leaf certificate
+// in state. Any failure is reported as RevocationUnavailable with Error set.
+func lookupRevocation(ctx context.Context, state tls.ConnectionState) *CertRevocation {
+	rev := &CertRevocation{RevocationStatus: RevocationUnavailable}
+	if len(state.PeerCertificates) == 0 {
+		rev.Error = "no certificate was presented"
+		return rev
+	}
+	leaf := state.PeerCertificates[0]
+	if len(state.PeerCertificates) < 2 {
+		rev.Error = "the server did not send the issuer certificate needed to check OCSP"
+		return rev
+	}
+	issuer := state.PeerCertificates[1]
+
+	var stapleErr error
+	if len(state.OCSPResponse) > 0 {
+		resp, err := parseOCSPResponse(state.OCSPResponse, leaf, issuer)
+		if err == nil {
+			rev.OCSPStapled = true
+			resp.fill(rev)
+			return rev
+		}
+		stapleErr = fmt.Errorf("stapled OCSP response rejected: %w", err)
+	}
+
+	if len(leaf.OCSPServer) == 0 {
+		rev.Error = "the certificate names no OCSP responder"
+		if stapleErr != nil {
+			rev.Error = stapleErr.Error() + "; " + rev.Error
+		}
+		return rev
+	}
+	rev.Responder = leaf.OCSPServer[0]
+	resp, err := queryOCSP(ctx, rev.Responder, leaf, issuer)
+	if err != nil {
+		rev.Error = err.Error()
+		if stapleErr != nil {
+			rev.Error = stapleErr.Error() + "; " + rev.Error
+		}
+		return rev
+	}
+	resp.fill(rev)
+	return rev
+}
+
+// queryOCSP POSTs an OCSP request for leaf to responder.
+func queryOCSP(ctx context.Context, responder string, leaf, issuer *x509.Certificate) (*ocspStatus, error) {
+	if err := checkURLTarget(ctx, responder); err != nil {
+		return nil, fmt.Errorf("OCSP responder %s: %w", responder, err)
+	}
+	body, err := ocspRequest(leaf, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build OCSP request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ocspTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responder, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCSP responder URL %s: %w", responder, err)
+	}
+	setProbeHeaders(req)
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OCSP responder %s unreachable: %w", responder, explainConnError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP responder %s answered %s", responder, resp.Status)
+	}
+	der, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading OCSP response failed: %w", err)
+	}
+	status, err := parseOCSPResponse(der, leaf, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("OCSP response from %s rejected: %w", responder, err)
+	}
+	return status, nil
+}
+
+// ASN.1 structures of RFC 6960, as much of them as a client needs.
+
+var (
+	oidSHA1      = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidOCSPBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+)
+
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type ocspSingleRequest struct {
+	CertID ocspCertID
+}
+
+type ocspTBSRequest struct {
+	RequestList []ocspSingleRequest
+}
+
+type ocspRequestMsg struct {
+	TBSRequest ocspTBSRequest
+}
+
+type ocspResponseMsg struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Raw            asn1.RawContent
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []ocspSingleResponse
+	Extensions     []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID           ocspCertID
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspResponseStatuses names the non-successful OCSPResponseStatus values.
+var ocspResponseStatuses = map[asn1.Enumerated]string{
+	1: "malformed request",
+	2: "internal error",
+	3: "try later",
+	5: "signature required",
+	6: "unauthorized",
+}
+
+// crlReasons names the CRLReason codes of RFC 5280.
+var crlReasons = map[asn1.Enumerated]string{
+	0: "unspecified", 1: "key_compromise", 2: "ca_compromise", 3: "affiliation_changed",
+	4: "superseded", 5: "cessation_of_operation", 6: "certificate_hold",
+	8: "remove_from_crl", 9: "privilege_withdrawn", 10: "aa_compromise",
+}
+
+// ocspSignatureAlgorithms maps the signature algorithm OIDs OCSP
+// responders use to their crypto/x509 equivalents.
+var ocspSignatureAlgorithms = []struct {
+	oid  asn1.ObjectIdentifier
+	algo x509.SignatureAlgorithm
+}{
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}, x509.SHA1WithRSA},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, x509.SHA256WithRSA},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}, x509.SHA384WithRSA},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}, x509.SHA512WithRSA},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}, x509.ECDSAWithSHA1},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}, x509.ECDSAWithSHA256},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}, x509.ECDSAWithSHA384},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}, x509.ECDSAWithSHA512},
+	{asn1.ObjectIdentifier{1, 3, 101, 112}, x509.PureEd25519},
+}
+
+// ocspStatus is what a verified OCSP response says about one certificate.
+type ocspStatus struct {
+	status     string
+	producedAt time.Time
+	thisUpdate time.Time
+	nextUpdate time.Time
+	revokedAt  time.Time
+	reason     string
+}
+
+func (s *ocspStatus) fill(rev *CertRevocation) {
+	rev.RevocationStatus = s.status
+	rev.ProducedAt = s.producedAt.UTC().Format(time.RFC3339)
+	rev.ThisUpdate = s.thisUpdate.UTC().Format(time.RFC3339)
+	if !s.nextUpdate.IsZero() {
+		rev.NextUpdate = s.nextUpdate.UTC().Format(time.RFC3339)
+	}
+	if !s.revokedAt.IsZero() {
+		rev.RevokedAt = s.revokedAt.UTC().Format(time.RFC3339)
+	}
+	rev.RevocationReason = s.reason
+}
+
+// ocspCertIDFor identifies leaf to a responder by SHA-1 hashes of its
+// issuer's name and key, as every responder accepts.
+func ocspCertIDFor(leaf, issuer *x509.Certificate) (ocspCertID, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return ocspCertID{}, fmt.Errorf("cannot parse issuer public key: %w", err)
+	}
+	nameHash := sha1.Sum(issuer.RawSubject)
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+	return ocspCertID{
+		HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+		IssuerNameHash: nameHash[:],
+		IssuerKeyHash:  keyHash[:],
+		SerialNumber:   leaf.SerialNumber,
+	}, nil
+}
+
+// ocspRequest encodes an unsigned OCSP request for leaf.
+func ocspRequest(leaf, issuer *x509.Certificate) ([]byte, error) {
+	id, err := ocspCertIDFor(leaf, issuer)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspRequestMsg{TBSRequest: ocspTBSRequest{RequestList: []ocspSingleRequest{{CertID: id}}}})
+}
+
+// parseOCSPResponse decodes der, checks that it is signed by issuer or by a
+// responder issuer delegated OCSP signing to, and returns the status it
+// gives for leaf.
+func parseOCSPResponse(der []byte, leaf, issuer *x509.Certificate) (*ocspStatus, error) {
+	var msg ocspResponseMsg
+	if rest, err := asn1.Unmarshal(der, &msg); err != nil {
+		return nil, fmt.Errorf("malformed OCSP response: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after OCSP response")
+	}
+	if msg.Status != 0 {
+		name, ok := ocspResponseStatuses[msg.Status]
+		if !ok {
+			name = fmt.Sprintf("status %d", msg.Status)
+		}
+		return nil, fmt.Errorf("responder refused the request: %s", name)
+	}
+	if !msg.Response.ResponseType.Equal(oidOCSPBasic) {
+		return nil, fmt.Errorf("unsupported OCSP response type %s", msg.Response.ResponseType)
+	}
+
+	var basic ocspBasicResponse
+	if _, err := asn1.Unmarshal(msg.Response.Response, &basic); err != nil {
+		return nil, fmt.Errorf("malformed basic OCSP response: %w", err)
+	}
+	if err := checkOCSPSignature(&basic, issuer); err != nil {
+		return nil, err
+	}
+
+	id, err := ocspCertIDFor(leaf, issuer)
+	if err != nil {
+		return nil, err
+	}
+	for _, single := range basic.TBSResponseData.Responses {
+		if !single.CertID.matches(id) {
+			continue
+		}
+		status := &ocspStatus{
+			producedAt: basic.TBSResponseData.ProducedAt,
+			thisUpdate: single.ThisUpdate,
+			nextUpdate: single.NextUpdate,
+		}
+		switch {
+		case bool(single.Good):
+			status.status = RevocationGood
+		case bool(single.Unknown):
+			status.status = RevocationUnknown
+		default:
+			status.status = RevocationRevoked
+			status.revokedAt = single.Revoked.RevocationTime
+			status.reason = crlReasons[single.Revoked.Reason]
+		}
+		if !status.nextUpdate.IsZero() && time.Now().After(status.nextUpdate) {
+			return nil, fmt.Errorf("response expired at %s", status.nextUpdate.UTC().Format(time.RFC3339))
+		}
+		return status, nil
+	}
+	return nil, errors.New("response does not cover this certificate")
+}
+
+// matches reports whether id names the same certificate as want. Only
+// SHA-1 identifiers are compared, since those are what ocspRequest sends.
+func (id ocspCertID) matches(want ocspCertID) bool {
+	return id.HashAlgorithm.Algorithm.Equal(oidSHA1) &&
+		bytes.Equal(id.IssuerNameHash, want.IssuerNameHash) &&
+		bytes.Equal(id.IssuerKeyHash, want.IssuerKeyHash) &&
+		id.SerialNumber != nil && id.SerialNumber.Cmp(want.SerialNumber) == 0
+}
+
+// checkOCSPSignature verifies basic was signed by issuer, or by a
+// certificate it carries that issuer signed for OCSP signing.
+func checkOCSPSignature(basic *ocspBasicResponse, issuer *x509.Certificate) error {
+	var algo x509.SignatureAlgorithm
+	for _, a := range ocspSignatureAlgorithms {
+		if a.oid.Equal(basic.SignatureAlgorithm.Algorithm) {
+			algo = a.algo
+			break
+		}
+	}
+	if algo == x509.UnknownSignatureAlgorithm {
+		return fmt.Errorf("unsupported signature algorithm %s", basic.SignatureAlgorithm.Algorithm)
+	}
+
+	signer := issuer
+	if len(basic.Certificates) > 0 {
+		delegate, err := x509.ParseCertificate(basic.Certificates[0].FullBytes)
+		if err != nil {
+			return fmt.Errorf("cannot parse responder certificate: %w", err)
+		}
+		if !bytes.Equal(delegate.Raw, issuer.Raw) {
+			if err := delegate.CheckSignatureFrom(issuer); err != nil {
+				return fmt.Errorf("responder certificate not issued by the certificate's issuer: %w", err)
+			}
+			if !slices.Contains(delegate.ExtKeyUsage, x509.ExtKeyUsageOCSPSigning) {
+				return errors.New("responder certificate is not authorized for OCSP signing")
+			}
+			if now := time.Now(); now.Before(delegate.NotBefore) || now.After(delegate.NotAfter) {
+				return fmt.Errorf("responder certificate is not valid now (valid %s to %s)",
+					delegate.NotBefore.UTC().Format(time.RFC3339), delegate.NotAfter.UTC().Format(time.RFC3339))
+			}
+			signer = delegate
+		}
+	}
+	if err := signer.CheckSignature(algo, basic.TBSResponseData.Raw, basic.Signature.RightAlign()); err != nil {
+		return fmt.Errorf("bad response signature: %w", err)
+	}
+	return nil
+}
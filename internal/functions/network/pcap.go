@@ -0,0 +1,742 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// DefaultPcapMaxPackets bounds how many packets analyze_pcap reads when the
+// caller does not set a limit.
+const DefaultPcapMaxPackets = 100000
+
+// pcapTopTalkers is the number of address pairs reported in TopTalkers.
+const pcapTopTalkers = 10
+
+// maxPcapRecord rejects records larger than any real link MTU plus headers,
+// which only appear in corrupt or non-pcap files.
+const maxPcapRecord = 256 * 1024
+
+// PcapTalker is traffic from one address to another.
+type PcapTalker struct {
+	Src     string `json:"src"`
+	Dst     string `json:"dst"`
+	Packets int    `json:"packets"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// PcapSummary is the result of analyzing a capture file.
+type PcapSummary struct {
+	File            string         `json:"file"`
+	Format          string         `json:"format"`
+	Filter          string         `json:"filter,omitempty"`
+	PacketsRead     int            `json:"packets_read"`
+	PacketsMatched  int            `json:"packets_matched"`
+	BytesMatched    int64          `json:"bytes_matched"`
+	Truncated       bool           `json:"truncated"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Protocols       map[string]int `json:"protocols"`
+	TopTalkers      []PcapTalker   `json:"top_talkers"`
+
+	// TCPRetransmissions counts segments whose sequence range had already
+	// been seen on the same flow, including repeated SYNs.
+	TCPRetransmissions int `json:"tcp_retransmissions"`
+	// ConnectionAttempts counts distinct flows that sent a SYN;
+	// UnansweredSYNs those that never saw a SYN-ACK back.
+	ConnectionAttempts int      `json:"connection_attempts"`
+	UnansweredSYNs     int      `json:"unanswered_syns"`
+	UnansweredTargets  []string `json:"unanswered_targets"`
+	Warnings           []string `json:"warnings"`
+}
+
+// AnalyzePcap summarizes a pcap or pcapng file: packet and byte counts, the
+// protocol mix, the busiest address pairs, TCP retransmissions, and SYNs that
+// never got a SYN-ACK. filter is a tcpdump-style expression (see
+// compilePcapFilter); every statistic covers only matching packets, so a
+// one-directional filter such as "dst port 443" hides the SYN-ACKs. Reading
+// stops after maxPackets packets (DefaultPcapMaxPackets when 0).
+func AnalyzePcap(path string, filter string, maxPackets int) (*PcapSummary, error) {
+	if maxPackets < 0 {
+		return nil, fmt.Errorf("max_packets must not be negative, got %d", maxPackets)
+	}
+	if maxPackets == 0 {
+		maxPackets = DefaultPcapMaxPackets
+	}
+	match, err := compilePcapFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open capture: %w", err)
+	}
+	defer f.Close()
+
+	reader, format, err := newPcapReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %w", path, err)
+	}
+
+	summary := &PcapSummary{
+		File:              path,
+		Format:            format,
+		Filter:            filter,
+		Protocols:         make(map[string]int),
+		TopTalkers:        []PcapTalker{},
+		UnansweredTargets: []string{},
+		Warnings:          []string{},
+	}
+	stats := newPcapStats()
+	var first, last time.Time
+
+	for {
+		pkt, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			summary.Warnings = append(summary.Warnings,
+				fmt.Sprintf("stopped at packet %d: %v", summary.PacketsRead+1, err))
+			break
+		}
+		// Only a packet beyond the limit truncates the summary, so a file of
+		// exactly max_packets packets is read in full.
+		if summary.PacketsRead >= maxPackets {
+			summary.Truncated = true
+			summary.Warnings = append(summary.Warnings,
+				fmt.Sprintf("stopped after %d packets (max_packets); later packets are not included", maxPackets))
+			break
+		}
+
+		summary.PacketsRead++
+		if first.IsZero() {
+			first = pkt.ts
+		}
+		last = pkt.ts
+
+		d := decodePacket(pkt.linkType, pkt.data)
+		if !match(&d) {
+			continue
+		}
+		summary.PacketsMatched++
+		summary.BytesMatched += int64(pkt.origLen)
+		summary.Protocols[d.proto]++
+		stats.add(&d, pkt.origLen)
+	}
+
+	if !first.IsZero() {
+		summary.DurationSeconds = math.Round(last.Sub(first).Seconds()*1000) / 1000
+	}
+	stats.fill(summary)
+
+	if summary.PacketsRead == 0 {
+		summary.Warnings = append(summary.Warnings, "capture contains no packets")
+	} else if summary.PacketsMatched == 0 && filter != "" {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("no packets matched filter '%s'", filter))
+	}
+	if summary.UnansweredSYNs > 0 {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf(
+			"%d of %d connection attempts never received a SYN-ACK (target down, filtered, or capture missed the reply)",
+			summary.UnansweredSYNs, summary.ConnectionAttempts))
+	}
+	return summary, nil
+}
+
+// ============================================================================
+// Statistics
+// ============================================================================
+
+// pcapStats accumulates the per-flow state behind the summary.
+type pcapStats struct {
+	talkers map[[2]string]*PcapTalker
+	seqEnd  map[string]uint32 // highest sequence end seen per TCP flow
+	syns    map[string]string // flow -> "dst:port" for flows that sent a SYN
+	synAcks map[string]bool   // flows (client direction) that got a SYN-ACK
+	retrans int
+}
+
+func newPcapStats() *pcapStats {
+	return &pcapStats{
+		talkers: make(map[[2]string]*PcapTalker),
+		seqEnd:  make(map[string]uint32),
+		syns:    make(map[string]string),
+		synAcks: make(map[string]bool),
+	}
+}
+
+func (s *pcapStats) add(d *decodedPacket, wireLen int) {
+	if d.src == "" {
+		return
+	}
+	key := [2]string{d.src, d.dst}
+	t := s.talkers[key]
+	if t == nil {
+		t = &PcapTalker{Src: d.src, Dst: d.dst}
+		s.talkers[key] = t
+	}
+	t.Packets++
+	t.Bytes += int64(wireLen)
+
+	if d.proto != "tcp" || !d.hasPorts {
+		return
+	}
+	flow := flowKey(d.src, d.srcPort, d.dst, d.dstPort)
+	syn, ack := d.tcpSYN, d.tcpACK
+
+	switch {
+	case syn && !ack:
+		if _, seen := s.syns[flow]; !seen {
+			s.syns[flow] = net.JoinHostPort(d.dst, strconv.Itoa(d.dstPort))
+		}
+	case syn && ack:
+		s.synAcks[flowKey(d.dst, d.dstPort, d.src, d.srcPort)] = true
+	}
+
+	// SYN and FIN each consume one sequence number.
+	segLen := uint32(d.tcpPayload)
+	if syn {
+		segLen++
+	}
+	if d.tcpFIN {
+		segLen++
+	}
+	if segLen == 0 {
+		return
+	}
+	end := d.tcpSeq + segLen
+	if prev, seen := s.seqEnd[flow]; seen {
+		// Serial-number comparison so sequence wraparound is not a retransmit.
+		if int32(end-prev) <= 0 {
+			s.retrans++
+			return
+		}
+	}
+	s.seqEnd[flow] = end
+}
+
+func (s *pcapStats) fill(summary *PcapSummary) {
+	for _, t := range s.talkers {
+		summary.TopTalkers = append(summary.TopTalkers, *t)
+	}
+	sort.Slice(summary.TopTalkers, func(i, j int) bool {
+		a, b := summary.TopTalkers[i], summary.TopTalkers[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		if a.Packets != b.Packets {
+			return a.Packets > b.Packets
+		}
+		return a.Src+a.Dst < b.Src+b.Dst
+	})
+	if len(summary.TopTalkers) > pcapTopTalkers {
+		summary.TopTalkers = summary.TopTalkers[:pcapTopTalkers]
+	}
+
+	summary.TCPRetransmissions = s.retrans
+	summary.ConnectionAttempts = len(s.syns)
+
+	targets := make(map[string]int)
+	for flow, target := range s.syns {
+		if !s.synAcks[flow] {
+			summary.UnansweredSYNs++
+			targets[target]++
+		}
+	}
+	for target := range targets {
+		summary.UnansweredTargets = append(summary.UnansweredTargets, target)
+	}
+	sort.Slice(summary.UnansweredTargets, func(i, j int) bool {
+		a, b := summary.UnansweredTargets[i], summary.UnansweredTargets[j]
+		if targets[a] != targets[b] {
+			return targets[a] > targets[b]
+		}
+		return a < b
+	})
+}
+
+func flowKey(src string, sport int, dst string, dport int) string {
+	return net.JoinHostPort(src, strconv.Itoa(sport)) + ">" + net.JoinHostPort(dst, strconv.Itoa(dport))
+}
+
+// ============================================================================
+// Filter
+// ============================================================================
+
+// compilePcapFilter parses a subset of tcpdump filter syntax: primitives
+// joined by "and", each optionally negated with "not". Primitives are
+// tcp, udp, icmp, arp, ip, ip6, [src|dst] host ADDR, and [src|dst] port N.
+// An empty filter matches every packet.
+func compilePcapFilter(expr string) (func(*decodedPacket) bool, error) {
+	var preds []func(*decodedPacket) bool
+	tokens := strings.Fields(strings.ToLower(strings.ReplaceAll(expr, "&&", " and ")))
+
+	for i := 0; i < len(tokens); {
+		if len(preds) > 0 {
+			if tokens[i] != "and" {
+				return nil, fmt.Errorf("invalid filter '%s': expected 'and' before '%s' (only 'and' and 'not' are supported)", expr, tokens[i])
+			}
+			i++
+		}
+		negate := false
+		if i < len(tokens) && (tokens[i] == "not" || tokens[i] == "!") {
+			negate = true
+			i++
+		}
+		pred, n, err := parsePcapPrimitive(tokens[i:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter '%s': %w", expr, err)
+		}
+		i += n
+		if negate {
+			inner := pred
+			pred = func(d *decodedPacket) bool { return !inner(d) }
+		}
+		preds = append(preds, pred)
+	}
+
+	return func(d *decodedPacket) bool {
+		for _, p := range preds {
+			if !p(d) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// parsePcapPrimitive parses one primitive from the front of tokens and
+// returns how many tokens it used.
+func parsePcapPrimitive(tokens []string) (func(*decodedPacket) bool, int, error) {
+	if len(tokens) == 0 {
+		return nil, 0, fmt.Errorf("expression ends early")
+	}
+
+	switch tokens[0] {
+	case "tcp", "udp", "arp":
+		proto := tokens[0]
+		return func(d *decodedPacket) bool { return d.proto == proto }, 1, nil
+	case "icmp":
+		return func(d *decodedPacket) bool { return d.proto == "icmp" || d.proto == "icmpv6" }, 1, nil
+	case "ip":
+		return func(d *decodedPacket) bool { return d.ipVersion == 4 }, 1, nil
+	case "ip6":
+		return func(d *decodedPacket) bool { return d.ipVersion == 6 }, 1, nil
+	}
+
+	dir, used := "", 0
+	if tokens[0] == "src" || tokens[0] == "dst" {
+		dir, used = tokens[0], 1
+	}
+	if len(tokens) < used+2 {
+		return nil, 0, fmt.Errorf("'%s' needs a host or port", strings.Join(tokens, " "))
+	}
+	kind, value := tokens[used], tokens[used+1]
+	used += 2
+
+	switch kind {
+	case "host":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, 0, fmt.Errorf("'%s' is not an IP address", value)
+		}
+		host := ip.String()
+		return func(d *decodedPacket) bool {
+			return (dir != "dst" && d.src == host) || (dir != "src" && d.dst == host)
+		}, used, nil
+	case "port":
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 0 || port > 65535 {
+			return nil, 0, fmt.Errorf("'%s' is not a port number", value)
+		}
+		return func(d *decodedPacket) bool {
+			return d.hasPorts && ((dir != "dst" && d.srcPort == port) || (dir != "src" && d.dstPort == port))
+		}, used, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported primitive '%s'", tokens[0])
+}
+
+// ============================================================================
+// Decoding
+// ============================================================================
+
+// Link-layer header types from the pcap/pcapng LINKTYPE registry that
+// gopacket has no decoder for: bare IPv4 and IPv6, and Linux cooked capture
+// v2, which "tcpdump -i any" writes on current libpcap. gopacket's
+// layers.LinkType is 8 bits wide, so the file readers below keep the full
+// 32-bit value and decodePacket maps it.
+const (
+	linkTypeIPv4 = 228
+	linkTypeIPv6 = 229
+	linkTypeSLL2 = 276
+)
+
+// decodedPacket is the subset of a packet's headers the summary needs.
+type decodedPacket struct {
+	proto      string
+	ipVersion  int
+	src, dst   string
+	hasPorts   bool
+	srcPort    int
+	dstPort    int
+	tcpSeq     uint32
+	tcpSYN     bool
+	tcpACK     bool
+	tcpFIN     bool
+	tcpPayload int
+}
+
+// decodePacket extracts addresses, ports, and TCP state from a captured
+// frame. Anything it cannot parse is classified as "other" rather than
+// rejected, so damaged or unusual frames still count toward the totals.
+func decodePacket(linkType uint32, data []byte) decodedPacket {
+	var decoder gopacket.Decoder = layers.LinkType(linkType)
+	switch linkType {
+	case linkTypeIPv4, linkTypeIPv6:
+		decoder = layers.LinkTypeRaw
+	case linkTypeSLL2:
+		if len(data) < 20 {
+			return decodedPacket{proto: "other"}
+		}
+		decoder, data = layers.EthernetType(binary.BigEndian.Uint16(data[0:2])), data[20:]
+	default:
+		if linkType > math.MaxUint8 {
+			return decodedPacket{proto: "other"}
+		}
+	}
+	pkt := gopacket.NewPacket(data, decoder, gopacket.DecodeOptions{Lazy: true, NoCopy: true})
+
+	var (
+		d         decodedPacket
+		ip        gopacket.Layer
+		ipPayload int // transport length according to the IP header
+		firstFrag = true
+	)
+	switch l := pkt.NetworkLayer().(type) {
+	case *layers.IPv4:
+		d.ipVersion, d.src, d.dst = 4, l.SrcIP.String(), l.DstIP.String()
+		ip, ipPayload = l, int(l.Length)-int(l.IHL)*4
+		// Only the first fragment carries the transport header.
+		firstFrag = l.FragOffset == 0
+	case *layers.IPv6:
+		d.ipVersion, d.src, d.dst = 6, l.SrcIP.String(), l.DstIP.String()
+		ip, ipPayload = l, int(l.Length)
+		if frag, ok := pkt.Layer(layers.LayerTypeIPv6Fragment).(*layers.IPv6Fragment); ok {
+			firstFrag = frag.FragmentOffset == 0
+		}
+	default:
+		if pkt.Layer(layers.LayerTypeARP) != nil {
+			return decodedPacket{proto: "arp"}
+		}
+		return decodedPacket{proto: "other"}
+	}
+
+	switch transportProtocol(pkt) {
+	case layers.IPProtocolICMPv4:
+		d.proto = "icmp"
+	case layers.IPProtocolICMPv6:
+		d.proto = "icmpv6"
+	case layers.IPProtocolTCP:
+		d.proto = "tcp"
+		if tcp, ok := pkt.Layer(layers.LayerTypeTCP).(*layers.TCP); ok && firstFrag {
+			d.hasPorts = true
+			d.srcPort, d.dstPort = int(tcp.SrcPort), int(tcp.DstPort)
+			d.tcpSeq = tcp.Seq
+			d.tcpSYN, d.tcpACK, d.tcpFIN = tcp.SYN, tcp.ACK, tcp.FIN
+			// The IP header's length stays correct when the capture's
+			// snaplen cut the frame short, so count the payload from it.
+			headers := len(ip.LayerPayload()) - len(tcp.LayerPayload())
+			d.tcpPayload = max(ipPayload-headers, 0)
+		}
+	case layers.IPProtocolUDP:
+		d.proto = "udp"
+		if udp, ok := pkt.Layer(layers.LayerTypeUDP).(*layers.UDP); ok && firstFrag {
+			d.hasPorts = true
+			d.srcPort, d.dstPort = int(udp.SrcPort), int(udp.DstPort)
+		}
+	default:
+		d.proto = fmt.Sprintf("ipv%d-other", d.ipVersion)
+	}
+	return d
+}
+
+// transportProtocol is the protocol the last IP or IPv6 extension header
+// declares. It identifies the transport even in fragments past the first,
+// which carry no transport header.
+func transportProtocol(pkt gopacket.Packet) layers.IPProtocol {
+	var proto layers.IPProtocol
+	for _, l := range pkt.Layers() {
+		switch l := l.(type) {
+		case *layers.IPv4:
+			proto = l.Protocol
+		case *layers.IPv6:
+			proto = l.NextHeader
+		case *layers.IPv6HopByHop:
+			proto = l.NextHeader
+		case *layers.IPv6Routing:
+			proto = l.NextHeader
+		case *layers.IPv6Destination:
+			proto = l.NextHeader
+		case *layers.IPv6Fragment:
+			proto = l.NextHeader
+		}
+	}
+	return proto
+}
+
+// ============================================================================
+// File formats
+// ============================================================================
+
+// pcapPacket is one captured frame.
+type pcapPacket struct {
+	ts       time.Time
+	linkType uint32
+	data     []byte
+	origLen  int
+}
+
+// pcapReader yields packets until io.EOF.
+type pcapReader interface {
+	next() (pcapPacket, error)
+}
+
+// newPcapReader detects classic pcap or pcapng from the leading magic number.
+func newPcapReader(r *bufio.Reader) (pcapReader, string, error) {
+	magic, err := r.Peek(4)
+	if err != nil {
+		return nil, "", fmt.Errorf("not a pcap file: too short")
+	}
+
+	switch binary.LittleEndian.Uint32(magic) {
+	case 0x0a0d0d0a:
+		return &pcapngReader{r: r}, "pcapng", nil
+	case 0xa1b2c3d4, 0xd4c3b2a1, 0xa1b23c4d, 0x4d3cb2a1:
+		pr, err := newClassicPcapReader(r)
+		return pr, "pcap", err
+	}
+	return nil, "", fmt.Errorf("not a pcap or pcapng file (magic %x)", magic)
+}
+
+// classicPcapReader reads the libpcap format: a 24-byte global header
+// followed by 16-byte record headers.
+type classicPcapReader struct {
+	r        io.Reader
+	order    binary.ByteOrder
+	nano     bool
+	linkType uint32
+}
+
+func newClassicPcapReader(r io.Reader) (*classicPcapReader, error) {
+	var hdr [24]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("truncated pcap header: %w", err)
+	}
+
+	pr := &classicPcapReader{r: r, order: binary.LittleEndian}
+	switch binary.LittleEndian.Uint32(hdr[0:4]) {
+	case 0xd4c3b2a1:
+		pr.order = binary.BigEndian
+	case 0xa1b23c4d:
+		pr.nano = true
+	case 0x4d3cb2a1:
+		pr.order, pr.nano = binary.BigEndian, true
+	}
+	// The upper bits of the link type field hold FCS information.
+	pr.linkType = pr.order.Uint32(hdr[20:24]) & 0x0fffffff
+	return pr, nil
+}
+
+func (pr *classicPcapReader) next() (pcapPacket, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(pr.r, hdr[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return pcapPacket{}, fmt.Errorf("truncated record header")
+		}
+		return pcapPacket{}, err
+	}
+
+	sec := int64(pr.order.Uint32(hdr[0:4]))
+	frac := int64(pr.order.Uint32(hdr[4:8]))
+	capLen := pr.order.Uint32(hdr[8:12])
+	origLen := pr.order.Uint32(hdr[12:16])
+	if capLen > maxPcapRecord {
+		return pcapPacket{}, fmt.Errorf("record length %d exceeds %d bytes, file is corrupt", capLen, maxPcapRecord)
+	}
+
+	data := make([]byte, capLen)
+	if _, err := io.ReadFull(pr.r, data); err != nil {
+		return pcapPacket{}, fmt.Errorf("truncated packet data")
+	}
+
+	if !pr.nano {
+		frac *= 1000
+	}
+	return pcapPacket{
+		ts:       time.Unix(sec, frac).UTC(),
+		linkType: pr.linkType,
+		data:     data,
+		origLen:  int(origLen),
+	}, nil
+}
+
+// pcapngInterface is what an Interface Description Block declares.
+type pcapngInterface struct {
+	linkType uint32
+	// tsUnit is the duration of one timestamp tick.
+	tsUnit time.Duration
+}
+
+// pcapngReader reads the block-structured pcapng format, yielding packets
+// from Enhanced and Simple Packet Blocks and skipping everything else.
+type pcapngReader struct {
+	r      io.Reader
+	order  binary.ByteOrder
+	ifaces []pcapngInterface
+}
+
+// pcapng block types.
+const (
+	pcapngSectionHeader  = 0x0a0d0d0a
+	pcapngInterfaceDesc  = 0x00000001
+	pcapngSimplePacket   = 0x00000003
+	pcapngEnhancedPacket = 0x00000006
+)
+
+func (pr *pcapngReader) next() (pcapPacket, error) {
+	for {
+		blockType, body, err := pr.readBlock()
+		if err != nil {
+			return pcapPacket{}, err
+		}
+
+		switch blockType {
+		case pcapngSectionHeader:
+			// A new section starts a fresh interface list.
+			pr.ifaces = nil
+		case pcapngInterfaceDesc:
+			if len(body) < 8 {
+				return pcapPacket{}, fmt.Errorf("truncated interface description block")
+			}
+			pr.ifaces = append(pr.ifaces, pcapngInterface{
+				linkType: uint32(pr.order.Uint16(body[0:2])),
+				tsUnit:   pcapngTimestampUnit(pr.order, body[8:]),
+			})
+		case pcapngEnhancedPacket:
+			if len(body) < 20 {
+				return pcapPacket{}, fmt.Errorf("truncated enhanced packet block")
+			}
+			id := pr.order.Uint32(body[0:4])
+			if int(id) >= len(pr.ifaces) {
+				return pcapPacket{}, fmt.Errorf("packet references undeclared interface %d", id)
+			}
+			iface := pr.ifaces[id]
+			ticks := uint64(pr.order.Uint32(body[4:8]))<<32 | uint64(pr.order.Uint32(body[8:12]))
+			capLen := pr.order.Uint32(body[12:16])
+			if int(capLen) > len(body)-20 {
+				return pcapPacket{}, fmt.Errorf("enhanced packet block shorter than its captured length")
+			}
+			return pcapPacket{
+				ts:       time.Unix(0, 0).Add(time.Duration(ticks) * iface.tsUnit).UTC(),
+				linkType: iface.linkType,
+				data:     body[20 : 20+capLen],
+				origLen:  int(pr.order.Uint32(body[16:20])),
+			}, nil
+		case pcapngSimplePacket:
+			if len(body) < 4 || len(pr.ifaces) == 0 {
+				return pcapPacket{}, fmt.Errorf("invalid simple packet block")
+			}
+			origLen := int(pr.order.Uint32(body[0:4]))
+			data := body[4:]
+			if len(data) > origLen {
+				data = data[:origLen]
+			}
+			return pcapPacket{linkType: pr.ifaces[0].linkType, data: data, origLen: origLen}, nil
+		}
+	}
+}
+
+// readBlock reads one block and returns its type and body, without the
+// leading type/length and trailing length words.
+func (pr *pcapngReader) readBlock() (uint32, []byte, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(pr.r, hdr[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, fmt.Errorf("truncated block header")
+		}
+		return 0, nil, err
+	}
+
+	// The section header's byte-order magic decides how to read everything
+	// in the section, including the section header's own length.
+	if binary.LittleEndian.Uint32(hdr[0:4]) == pcapngSectionHeader {
+		var bom [4]byte
+		if _, err := io.ReadFull(pr.r, bom[:]); err != nil {
+			return 0, nil, fmt.Errorf("truncated section header")
+		}
+		switch binary.LittleEndian.Uint32(bom[:]) {
+		case 0x1a2b3c4d:
+			pr.order = binary.LittleEndian
+		case 0x4d3c2b1a:
+			pr.order = binary.BigEndian
+		default:
+			return 0, nil, fmt.Errorf("invalid pcapng byte-order magic")
+		}
+		total := pr.order.Uint32(hdr[4:8])
+		if total < 16 || total > maxPcapRecord {
+			return 0, nil, fmt.Errorf("invalid section header length %d", total)
+		}
+		if _, err := io.CopyN(io.Discard, pr.r, int64(total-12)); err != nil {
+			return 0, nil, fmt.Errorf("truncated section header")
+		}
+		return pcapngSectionHeader, nil, nil
+	}
+
+	if pr.order == nil {
+		return 0, nil, fmt.Errorf("pcapng block before section header")
+	}
+	blockType := pr.order.Uint32(hdr[0:4])
+	total := pr.order.Uint32(hdr[4:8])
+	if total < 12 || total%4 != 0 || total > maxPcapRecord {
+		return 0, nil, fmt.Errorf("invalid block length %d, file is corrupt", total)
+	}
+
+	rest := make([]byte, total-8)
+	if _, err := io.ReadFull(pr.r, rest); err != nil {
+		return 0, nil, fmt.Errorf("truncated block")
+	}
+	return blockType, rest[:len(rest)-4], nil
+}
+
+// pcapngTimestampUnit reads the if_tsresol option from an interface
+// description's options, defaulting to microseconds.
+func pcapngTimestampUnit(order binary.ByteOrder, opts []byte) time.Duration {
+	for len(opts) >= 4 {
+		code, length := order.Uint16(opts[0:2]), int(order.Uint16(opts[2:4]))
+		if code == 0 || len(opts) < 4+length {
+			break
+		}
+		if code == 9 && length >= 1 {
+			res := opts[4]
+			if res&0x80 == 0 {
+				// 10^-res seconds; finer than a nanosecond is clamped.
+				return time.Duration(math.Max(1, math.Pow10(9-int(res))))
+			}
+			return time.Duration(math.Max(1, float64(time.Second)/math.Pow(2, float64(res&0x7f))))
+		}
+		opts = opts[4+(length+3)&^3:]
+	}
+	return time.Microsecond
+}
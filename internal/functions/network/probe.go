@@ -0,0 +1,56 @@
+package network
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// DefaultUserAgent identifies outbound probes when no user agent is configured.
+const DefaultUserAgent = "telemetry-debugger/1.0"
+
+// RequestIDHeader carries the per-request ID so a probe can be found in the
+// target's access logs.
+const RequestIDHeader = "X-Request-ID"
+
+var (
+	userAgentMu sync.RWMutex
+	userAgent   = DefaultUserAgent
+)
+
+// SetUserAgent sets the User-Agent sent by every HTTP function. An empty
+// value restores DefaultUserAgent.
+func SetUserAgent(ua string) {
+	if ua == "" {
+		ua = DefaultUserAgent
+	}
+	userAgentMu.Lock()
+	userAgent = ua
+	userAgentMu.Unlock()
+}
+
+// UserAgent returns the User-Agent currently sent by HTTP functions.
+func UserAgent() string {
+	userAgentMu.RLock()
+	defer userAgentMu.RUnlock()
+	return userAgent
+}
+
+// setProbeHeaders stamps req with the configured User-Agent and a fresh
+// X-Request-ID, returning the ID so the caller can report it.
+func setProbeHeaders(req *http.Request) string {
+	id := newRequestID()
+	req.Header.Set("User-Agent", UserAgent())
+	req.Header.Set(RequestIDHeader, id)
+	return id
+}
+
+// newRequestID returns a random RFC 4122 version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
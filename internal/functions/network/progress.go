@@ -0,0 +1,54 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ProgressFunc receives one-line status updates from a long-running function,
+// such as "hop 5/15: ..." or "monitoring 3s/10s". It may be called from a
+// goroutine other than the caller's and must not block.
+type ProgressFunc func(line string)
+
+// hopReporter collects traceroute output and reports each hop line to
+// progress as soon as it is complete.
+type hopReporter struct {
+	mu       sync.Mutex
+	output   bytes.Buffer
+	partial  string
+	maxHops  int
+	progress ProgressFunc
+}
+
+func (h *hopReporter) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.output.Write(p)
+
+	lines := strings.Split(h.partial+string(p), "\n")
+	h.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		h.report(line)
+	}
+	return len(p), nil
+}
+
+// report sends line to progress if it is a hop line.
+func (h *hopReporter) report(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] < '0' || line[0] > '9' {
+		return
+	}
+	hop, rest, _ := strings.Cut(line, " ")
+	h.progress(fmt.Sprintf("hop %s/%d: %s", hop, h.maxHops, strings.TrimSpace(rest)))
+}
+
+// String returns everything written so far.
+func (h *hopReporter) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.output.String()
+}
@@ -0,0 +1,207 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPublicIPURL answers a GET with the caller's public IP as plain
+// text.
+const defaultPublicIPURL = "https://api.ipify.org"
+
+// defaultReachabilityTimeout bounds the public IP lookup and each
+// connection attempt.
+const defaultReachabilityTimeout = 5 * time.Second
+
+// ReachabilityOptions adjusts how CheckExternalReachabilityContext runs.
+type ReachabilityOptions struct {
+	// PublicIP returns this host's public IP address; nil asks an external
+	// echo service. Tests replace it.
+	PublicIP func(ctx context.Context) (string, error)
+	// Timeout bounds the lookup and each connection; 0 is 5 seconds.
+	Timeout time.Duration
+}
+
+// ExternalReachabilityResult is the output of CheckExternalReachability.
+type ExternalReachabilityResult struct {
+	Port     int      `json:"port"`
+	PublicIP string   `json:"public_ip"`
+	LocalIPs []string `json:"local_ips"`
+	// BehindNAT is true when the public IP is not one of this host's
+	// addresses, so a NAT or port forward sits between it and the
+	// internet.
+	BehindNAT bool `json:"behind_nat"`
+	// ListeningLocally is whether anything accepts connections on the
+	// port on this host at all.
+	ListeningLocally bool `json:"listening_locally"`
+	// Reachable is whether a connection to public_ip:port succeeded. It
+	// is unset when the public IP is one of this host's own addresses:
+	// connecting to it never leaves the host, so nothing external was
+	// checked.
+	Reachable *bool    `json:"reachable,omitempty"`
+	LatencyMs float64  `json:"latency_ms,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Verdict   string   `json:"verdict"`
+	Warnings  []string `json:"warnings"`
+}
+
+// CheckExternalReachability looks up this host's public IP and connects
+// back to it on port, to answer whether a service is reachable from
+// outside. Both the echo service and the public IP must be inside the
+// target allowlist. It reports whether a NAT is in play and whether the port is
+// listening locally, so a failure can be told apart from a missing port
+// forward or a service that is not running. When the public IP is one of
+// this host's own addresses, connecting to it would not leave the host, so
+// no connection is made and reachable is left unset.
+func CheckExternalReachability(port int) (*ExternalReachabilityResult, error) {
+	return CheckExternalReachabilityContext(context.Background(), port, ReachabilityOptions{})
+}
+
+// CheckExternalReachabilityContext is CheckExternalReachability with opts,
+// stopped if ctx is cancelled.
+func CheckExternalReachabilityContext(ctx context.Context, port int, opts ReachabilityOptions) (*ExternalReachabilityResult, error) {
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultReachabilityTimeout
+	}
+	lookup := opts.PublicIP
+	if lookup == nil {
+		lookup = func(ctx context.Context) (string, error) {
+			return fetchPublicIP(ctx, defaultPublicIPURL)
+		}
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	publicIP, err := lookup(lookupCtx)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine the public IP: %w", err)
+	}
+	ip := net.ParseIP(strings.TrimSpace(publicIP))
+	if ip == nil {
+		return nil, fmt.Errorf("public IP lookup returned %q, not an IP address", publicIP)
+	}
+	if err := checkTarget(ctx, ip.String()); err != nil {
+		return nil, err
+	}
+
+	result := &ExternalReachabilityResult{Port: port, PublicIP: ip.String(), LocalIPs: []string{}, Warnings: []string{}}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("cannot list local addresses, NAT not detected: %v", err))
+	}
+	var localIPs []net.IP
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			localIPs = append(localIPs, ipNet.IP)
+			result.LocalIPs = append(result.LocalIPs, ipNet.IP.String())
+		}
+	}
+	ownIP := err == nil && containsIP(localIPs, ip)
+	result.BehindNAT = err == nil && !ownIP
+
+	dial := func(host string) (time.Duration, error) {
+		dialer := net.Dialer{Timeout: timeout}
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return 0, err
+		}
+		conn.Close()
+		return time.Since(start), nil
+	}
+
+	for _, local := range append([]net.IP{net.IPv4(127, 0, 0, 1)}, localIPs...) {
+		if local.IsLinkLocalUnicast() {
+			continue
+		}
+		if _, err := dial(local.String()); err == nil {
+			result.ListeningLocally = true
+			break
+		}
+	}
+
+	if !ownIP {
+		elapsed, err := dial(result.PublicIP)
+		reachable := err == nil
+		result.Reachable = &reachable
+		if err != nil {
+			result.Error = explainConnError(err).Error()
+		} else {
+			result.LatencyMs = durationMs(elapsed)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("reachability check stopped: %w", err)
+	}
+
+	result.Verdict = reachabilityVerdict(result)
+	if result.BehindNAT && !*result.Reachable && result.ListeningLocally {
+		result.Warnings = append(result.Warnings,
+			"connecting to the public IP from behind the NAT needs hairpin NAT on the router; confirm from a host outside the network before changing the port forward")
+	}
+	return result, nil
+}
+
+// reachabilityVerdict says in a sentence what result means.
+func reachabilityVerdict(r *ExternalReachabilityResult) string {
+	switch {
+	case r.Reachable != nil && *r.Reachable:
+		return fmt.Sprintf("port %d is reachable at %s", r.Port, r.PublicIP)
+	case !r.ListeningLocally:
+		return fmt.Sprintf("nothing is listening on port %d on this host; start the service before checking the forward", r.Port)
+	case r.Reachable == nil:
+		return fmt.Sprintf("port %d is listening on %s, this host's own address; no external check was done, so confirm from a host outside the network and check the host and upstream firewalls", r.Port, r.PublicIP)
+	case r.BehindNAT:
+		return fmt.Sprintf("port %d is listening locally but not reachable at %s; check the router's port forward and firewall", r.Port, r.PublicIP)
+	default:
+		return fmt.Sprintf("port %d is listening locally but not reachable at %s; check the host and upstream firewalls", r.Port, r.PublicIP)
+	}
+}
+
+// fetchPublicIP asks the echo service at url for the caller's IP.
+func fetchPublicIP(ctx context.Context, url string) (string, error) {
+	if err := checkURLTarget(ctx, url); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	setProbeHeaders(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", err
+	}
+	if len(body) == 0 {
+		return "", errors.New("empty response from " + url)
+	}
+	return string(body), nil
+}
+
+func containsIP(list []net.IP, ip net.IP) bool {
+	for _, v := range list {
+		if v.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// cgnatNet is the RFC 6598 shared address space carriers use behind NAT.
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// tracerouteHop is the hop number of a traceroute line and the addresses
+// that answered at it, in order and without duplicates.
+type tracerouteHop struct {
+	num int
+	ips []string
+}
+
+// parseTracerouteHop reads a hop line such as
+// " 3  core1.example.net (203.0.113.1)  1.611 ms  10.0.0.7  1.590 ms" or a
+// tracert line ending in "[203.0.113.1]". ok is false if the line does not
+// start with a hop number.
+func parseTracerouteHop(line string) (tracerouteHop, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return tracerouteHop{}, false
+	}
+	num, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return tracerouteHop{}, false
+	}
+	hop := tracerouteHop{num: num}
+	seen := make(map[string]bool)
+	for _, f := range fields[1:] {
+		ip := net.ParseIP(strings.Trim(f, "()[]"))
+		if ip == nil || seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		hop.ips = append(hop.ips, ip.String())
+	}
+	return hop, true
+}
+
+// privateAddress reports whether ip is in private, shared (CGNAT),
+// loopback or link-local space, none of which is routed on the internet.
+func privateAddress(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsLinkLocalUnicast() || cgnatNet.Contains(parsed)
+}
+
+// routingAnomalies flags, best-effort, hops that suggest a routing loop or
+// an asymmetric or tunnelled path:
+//   - an address answering at more than one hop, which is a loop;
+//   - consecutive public hops in very different networks (see
+//     networkPrefix), a large jump such as between unrelated providers;
+//   - a hop back in the source's own private network after a public one,
+//     as when replies return by a different route than the probes took.
+//     Other private hops after public ones are not flagged, since many
+//     ISPs route through RFC 1918 and CGNAT space internally.
+func routingAnomalies(hops []tracerouteHop) []string {
+	anomalies := []string{}
+
+	firstHop := make(map[string]int)
+	flaggedLoop := make(map[string]bool)
+	for _, h := range hops {
+		for _, ip := range h.ips {
+			first, ok := firstHop[ip]
+			if !ok {
+				firstHop[ip] = h.num
+				continue
+			}
+			if first != h.num && !flaggedLoop[ip] {
+				flaggedLoop[ip] = true
+				anomalies = append(anomalies, fmt.Sprintf("routing loop: %s answered at hops %d and %d", ip, first, h.num))
+			}
+		}
+	}
+
+	prevPublic, prevPublicHop := "", 0
+	for _, h := range hops {
+		if len(h.ips) == 0 {
+			prevPublic = ""
+			continue
+		}
+		ip := h.ips[0]
+		if privateAddress(ip) {
+			prevPublic = ""
+			continue
+		}
+		if prevPublic != "" && prevPublicHop == h.num-1 && networkPrefix(prevPublic) != networkPrefix(ip) {
+			anomalies = append(anomalies, fmt.Sprintf(
+				"network jump: hop %d (%s) is in a different network from hop %d (%s); the path may cross providers or a tunnel",
+				h.num, ip, prevPublicHop, prevPublic))
+		}
+		prevPublic, prevPublicHop = ip, h.num
+	}
+
+	source := ""
+	lastPublic, lastPublicHop := "", 0
+	for _, h := range hops {
+		for _, ip := range h.ips {
+			if !privateAddress(ip) {
+				lastPublic, lastPublicHop = ip, h.num
+				continue
+			}
+			if lastPublic == "" {
+				if source == "" {
+					source = sitePrefix(ip)
+				}
+				continue
+			}
+			if sitePrefix(ip) == source {
+				anomalies = append(anomalies, fmt.Sprintf(
+					"hop %d (%s) is back in the source's private network after public hop %d (%s); the path may be asymmetric or tunnelled",
+					h.num, ip, lastPublicHop, lastPublic))
+				lastPublic = ""
+			}
+		}
+	}
+	return anomalies
+}
+
+// networkPrefix returns the /8 of a public IPv4 address or the /32 of a
+// public IPv6 one; hops differing here are rarely run by the same operator.
+func networkPrefix(ip string) string {
+	return maskedPrefix(ip, 8, 32)
+}
+
+// sitePrefix returns the /16 of a private IPv4 address or the /48 of a
+// private IPv6 one, which tells one private site from another.
+func sitePrefix(ip string) string {
+	return maskedPrefix(ip, 16, 48)
+}
+
+func maskedPrefix(ip string, v4Bits, v6Bits int) string {
+	parsed := net.ParseIP(ip)
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(v4Bits, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(v6Bits, 128)).String()
+}
+
+// CompareReturnPath checks the hops of a traceroute run from the target
+// back to this host, given as addresses in the order that traceroute
+// found them, against r's forward hops. The last address, this host, is
+// not compared. Return hops that never appear on the forward path are
+// added to RoutingAnomalies, as they suggest the replies take a different
+// route, which breaks stateful firewalls.
+//
+// Routers may answer each direction from a different interface, so a
+// mismatch is a hint to investigate rather than proof.
+func (r *TracerouteResult) CompareReturnPath(returnPath []string) {
+	if len(returnPath) < 2 {
+		return
+	}
+	forward := make(map[string]bool)
+	for _, line := range r.Hops {
+		if hop, ok := parseTracerouteHop(line); ok {
+			for _, ip := range hop.ips {
+				forward[ip] = true
+			}
+		}
+	}
+
+	hops := returnPath[:len(returnPath)-1]
+	var missing []string
+	for _, ip := range hops {
+		if parsed := net.ParseIP(strings.TrimSpace(ip)); parsed != nil && !forward[parsed.String()] {
+			missing = append(missing, parsed.String())
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	r.RoutingAnomalies = append(r.RoutingAnomalies, fmt.Sprintf(
+		"%d of %d return path hops are not on the forward path (%s); routing is likely asymmetric",
+		len(missing), len(hops), strings.Join(missing, ", ")))
+}
@@ -0,0 +1,194 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"time"
+)
+
+// Phases of an HTTP request reported by DiagnoseSlowConnection, besides
+// PhaseDNS and PhaseHandshake.
+const (
+	PhaseTLS = "tls"
+	// PhaseServer is from the request being written to the first byte of
+	// the response: the server's think time plus one round trip.
+	PhaseServer = "server"
+)
+
+// slowConnectionRequestTimeout bounds each DiagnoseSlowConnection sample.
+const slowConnectionRequestTimeout = 30 * time.Second
+
+// slowConnectionAdvice says where to look when a phase dominates.
+var slowConnectionAdvice = map[string]string{
+	PhaseDNS:       "DNS resolution; check the resolver with dns_latency and the search domains in resolv.conf",
+	PhaseHandshake: "the TCP handshake; check path latency with ping and the server's accept backlog for listen overflows",
+	PhaseTLS:       "the TLS handshake; check the certificate chain size, OCSP stapling and the server's CPU",
+	PhaseServer:    "waiting for the first byte; the server is slow to respond, not the network",
+}
+
+// ConnectionPhase is the time one phase of a request took.
+type ConnectionPhase struct {
+	Phase string `json:"phase"`
+	// Ms is the median over samples and Percent its share of the median
+	// total.
+	Ms      float64       `json:"ms"`
+	Percent float64       `json:"percent"`
+	Stats   *LatencyStats `json:"stats"`
+}
+
+// SlowConnectionResult is the output of DiagnoseSlowConnection.
+type SlowConnectionResult struct {
+	URL        string `json:"url"`
+	Samples    int    `json:"samples"`
+	Failed     int    `json:"failed"`
+	StatusCode int    `json:"status_code"`
+	// TotalMs is the median time to the first byte of the response.
+	TotalMs float64 `json:"total_ms"`
+	// Phases are ranked slowest first.
+	Phases   []ConnectionPhase `json:"phases"`
+	Dominant string            `json:"dominant"`
+	Verdict  string            `json:"verdict"`
+	Warnings []string          `json:"warnings"`
+}
+
+// requestTiming is one traced request split into its phases.
+type requestTiming struct {
+	dns, connect, tls, server, total time.Duration
+	statusCode                       int
+}
+
+// DiagnoseSlowConnection requests url three times, each on a new
+// connection, and splits the time to the first byte into DNS resolution,
+// TCP handshake, TLS handshake and server time, then ranks the phases and
+// says which dominates and where to look. The request goes direct, not
+// through a proxy, certificates are not verified and redirects are not
+// followed, so that only the path to url itself is timed.
+func DiagnoseSlowConnection(url string) (*SlowConnectionResult, error) {
+	return DiagnoseSlowConnectionContext(context.Background(), url, 3)
+}
+
+// DiagnoseSlowConnectionContext is DiagnoseSlowConnection with samples
+// (1-100) requests, stopping early, with an error, if ctx is cancelled
+// between them.
+func DiagnoseSlowConnectionContext(ctx context.Context, url string, samples int) (*SlowConnectionResult, error) {
+	if url == "" {
+		return nil, errors.New("url is required")
+	}
+	if err := checkSamples(samples); err != nil {
+		return nil, err
+	}
+	url = withScheme(url)
+	if err := checkURLTarget(ctx, url); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DisableKeepAlives = true
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // timing, not validating
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	result := &SlowConnectionResult{URL: url, Samples: samples, Phases: []ConnectionPhase{}, Warnings: []string{}}
+	var timings []requestTiming
+	var lastErr error
+	for i := 0; i < samples; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("stopped after %d of %d samples: %w", i, samples, err)
+		}
+		timing, err := traceRequest(ctx, client, url)
+		if err != nil {
+			result.Failed++
+			lastErr = err
+			continue
+		}
+		timings = append(timings, timing)
+	}
+	if len(timings) == 0 {
+		return nil, fmt.Errorf("all %d requests failed: %w", samples, explainConnError(lastErr))
+	}
+	if lastErr != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d of %d requests failed: %v", result.Failed, samples, lastErr))
+	}
+	result.StatusCode = timings[len(timings)-1].statusCode
+
+	phases := map[string][]time.Duration{}
+	var totals []time.Duration
+	for _, t := range timings {
+		phases[PhaseDNS] = append(phases[PhaseDNS], t.dns)
+		phases[PhaseHandshake] = append(phases[PhaseHandshake], t.connect)
+		phases[PhaseTLS] = append(phases[PhaseTLS], t.tls)
+		phases[PhaseServer] = append(phases[PhaseServer], t.server)
+		totals = append(totals, t.total)
+	}
+	result.TotalMs = NewLatencyStats(totals).P50Ms
+
+	for _, name := range []string{PhaseDNS, PhaseHandshake, PhaseTLS, PhaseServer} {
+		stats := NewLatencyStats(phases[name])
+		phase := ConnectionPhase{Phase: name, Ms: stats.P50Ms, Stats: stats}
+		if result.TotalMs > 0 {
+			phase.Percent = float64(int(phase.Ms/result.TotalMs*1000+0.5)) / 10
+		}
+		result.Phases = append(result.Phases, phase)
+	}
+	sort.SliceStable(result.Phases, func(i, j int) bool {
+		return result.Phases[i].Ms > result.Phases[j].Ms
+	})
+
+	top := result.Phases[0]
+	result.Dominant = top.Phase
+	result.Verdict = fmt.Sprintf("most time spent in %s (%.0f%% of %.1fms)",
+		slowConnectionAdvice[top.Phase], top.Percent, result.TotalMs)
+	return result, nil
+}
+
+// traceRequest makes one GET request to url and times its phases up to the
+// first response byte.
+func traceRequest(ctx context.Context, client *http.Client, url string) (requestTiming, error) {
+	ctx, cancel := context.WithTimeout(ctx, slowConnectionRequestTimeout)
+	defer cancel()
+
+	var (
+		timing                                  requestTiming
+		dnsStart, connectStart, tlsStart, wrote time.Time
+	)
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { timing.dns = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { timing.connect = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { timing.tls = time.Since(tlsStart) },
+		WroteRequest:      func(httptrace.WroteRequestInfo) { wrote = time.Now() },
+		GotFirstResponseByte: func() {
+			if !wrote.IsZero() {
+				timing.server = time.Since(wrote)
+			}
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, url, nil)
+	if err != nil {
+		return timing, fmt.Errorf("invalid request: %w", err)
+	}
+	setProbeHeaders(req)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return timing, err
+	}
+	timing.total = time.Since(start)
+	resp.Body.Close()
+	timing.statusCode = resp.StatusCode
+	return timing, nil
+}
@@ -19,8 +19,48 @@ type TCPStats struct {
 	RecvQueueBytes        int
 	RecommendedBufferSize int
 	Latency               float64 // RTT in milliseconds
+
+	// Flow-control detail from the ss -ti info line. Zero when ss omits them.
+	Cwnd              int     // congestion window, in segments
+	Ssthresh          int     // slow-start threshold, in segments
+	SndWscale         int     // send window scale shift
+	RcvWscale         int     // receive window scale shift
+	RTO               float64 // retransmission timeout in milliseconds
+	MSS               int     // maximum segment size in bytes
+	BytesRetrans      int64   // total bytes retransmitted
+	CongestionLimited bool    // cwnd is too small to drain the send queue
+
+	// Per-state socket counts across every connection ss listed for the
+	// port. The single-connection fields above describe the last one.
+	Connections            int
+	StateCounts            map[string]int
+	CloseWaitLeakSuspected bool // CLOSE-WAIT count exceeds CloseWaitLeakThreshold
+	Warnings               []string
 }
 
+// CloseWaitLeakThreshold is the number of CLOSE-WAIT sockets on one port above
+// which the application is suspected of not closing connections. The peer has
+// already closed these; only the local application can release them.
+const CloseWaitLeakThreshold = 10
+
+// defaultMSS is assumed when ss does not report mss, so the congestion
+// heuristic can still convert cwnd segments into bytes.
+const defaultMSS = 1448
+
+// Compiled regexes for the fields of the ss -ti info line. The word
+// boundaries keep rtt from matching minrtt or rcv_rtt, and retrans from
+// matching bytes_retrans.
+var (
+	reSSRTT          = regexp.MustCompile(`\brtt:([0-9.]+)`)
+	reSSRetrans      = regexp.MustCompile(`\bretrans:(\d+)`)
+	reSSCwnd         = regexp.MustCompile(`\bcwnd:(\d+)`)
+	reSSSsthresh     = regexp.MustCompile(`\bssthresh:(\d+)`)
+	reSSWscale       = regexp.MustCompile(`\bwscale:(\d+),(\d+)`)
+	reSSRTO          = regexp.MustCompile(`\brto:([0-9.]+)`)
+	reSSMSS          = regexp.MustCompile(`\bmss:(\d+)`)
+	reSSBytesRetrans = regexp.MustCompile(`\bbytes_retrans:(\d+)`)
+)
+
 // validTCPStates is the set of connection state tokens that ss can emit.
 // Used by the parser to locate the state field regardless of whether the
 // Netid column ("tcp", "udp", etc.) is present — which varies by iproute2 version.
@@ -52,14 +92,26 @@ func CheckTCPHealth(iface string, port int) (map[string]interface{}, error) {
 	recommendedBuffer := calculateRecommendedBuffer(stats.Latency)
 
 	return map[string]interface{}{
-		"state":                   stats.State,
-		"port":                    port,
-		"interface":               iface,
-		"retransmits":             stats.Retransmits,
-		"send_queue_bytes":        stats.SendQueueBytes,
-		"recv_queue_bytes":        stats.RecvQueueBytes,
-		"rtt_ms":                  stats.Latency,
-		"recommended_buffer_size": recommendedBuffer,
+		"state":                     stats.State,
+		"port":                      port,
+		"interface":                 iface,
+		"retransmits":               stats.Retransmits,
+		"send_queue_bytes":          stats.SendQueueBytes,
+		"recv_queue_bytes":          stats.RecvQueueBytes,
+		"rtt_ms":                    stats.Latency,
+		"recommended_buffer_size":   recommendedBuffer,
+		"cwnd":                      stats.Cwnd,
+		"ssthresh":                  stats.Ssthresh,
+		"snd_wscale":                stats.SndWscale,
+		"rcv_wscale":                stats.RcvWscale,
+		"rto_ms":                    stats.RTO,
+		"mss":                       stats.MSS,
+		"bytes_retrans":             stats.BytesRetrans,
+		"congestion_limited":        stats.CongestionLimited,
+		"connections":               stats.Connections,
+		"state_counts":              stats.StateCounts,
+		"close_wait_leak_suspected": stats.CloseWaitLeakSuspected,
+		"warnings":                  stats.Warnings,
 	}, nil
 }
 
@@ -112,14 +164,23 @@ func ParseSSOutput(output string, port int) (*TCPStats, error) {
 // Bug 2 fix: the original code used strings.HasPrefix(line, "ESTAB") which
 // fails on the new format because the line starts with "tcp". The parser now
 // inspects field[0] and field[1] against validTCPStates to handle both formats.
+//
+// The info line is optional. ss -t prints none, so rtt and retransmits stay
+// zero; ss -e appends uid:, ino:, and sk: fields to the connection line; and
+// ss -O prints the info on the connection line itself. An info line belongs to
+// the connection above it, so one that precedes every connection is ignored
+// and a connection without one does not inherit the previous connection's.
 func parseSSOutput(output string, port int) (*TCPStats, error) {
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 	stats := &TCPStats{
 		Port:        port,
 		State:       "UNKNOWN",
 		Retransmits: 0,
+		StateCounts: make(map[string]int),
+		Warnings:    []string{},
 	}
 
+	inConnection := false
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "State") || strings.HasPrefix(line, "Netid") {
@@ -140,6 +201,10 @@ func parseSSOutput(output string, port int) (*TCPStats, error) {
 
 		if stateIdx >= 0 {
 			stats.State = fields[stateIdx]
+			stats.StateCounts[stats.State]++
+			stats.Connections++
+			inConnection = true
+			resetConnectionInfo(stats)
 			// Recv-Q is immediately after the state token.
 			if recvQ, err := strconv.Atoi(fields[stateIdx+1]); err == nil {
 				stats.RecvQueueBytes = recvQ
@@ -148,27 +213,16 @@ func parseSSOutput(output string, port int) (*TCPStats, error) {
 			if sendQ, err := strconv.Atoi(fields[stateIdx+2]); err == nil {
 				stats.SendQueueBytes = sendQ
 			}
+			// Anything after the peer address is ss -e/-p extras or, with
+			// ss -O, the info fields.
+			parseConnectionInfo(strings.Join(fields[stateIdx+5:], " "), stats)
 			continue
 		}
 
 		// Parse TCP info line (contains rtt, retransmits, etc.)
 		// Example: "cubic wscale:7,7 rto:204 rtt:0.5/0.25 retrans:5 send 167.7Mbps rcv_space:29200"
-		if strings.Contains(line, "rtt:") || strings.Contains(line, "retrans:") {
-			// Extract RTT
-			rttRegex := regexp.MustCompile(`rtt:([0-9.]+)`)
-			if matches := rttRegex.FindStringSubmatch(line); len(matches) > 1 {
-				if rtt, err := strconv.ParseFloat(matches[1], 64); err == nil {
-					stats.Latency = rtt // in milliseconds
-				}
-			}
-
-			// Extract retransmits
-			retransRegex := regexp.MustCompile(`retrans:(\d+)`)
-			if matches := retransRegex.FindStringSubmatch(line); len(matches) > 1 {
-				if retrans, err := strconv.Atoi(matches[1]); err == nil {
-					stats.Retransmits = retrans
-				}
-			}
+		if inConnection {
+			parseConnectionInfo(line, stats)
 		}
 	}
 
@@ -176,9 +230,84 @@ func parseSSOutput(output string, port int) (*TCPStats, error) {
 		return nil, fmt.Errorf("could not parse connection state from ss output")
 	}
 
+	stats.CongestionLimited = isCongestionLimited(stats)
+
+	if n := stats.StateCounts["CLOSE-WAIT"]; n > CloseWaitLeakThreshold {
+		stats.CloseWaitLeakSuspected = true
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf(
+			"close_wait_leak_suspected: %d sockets in CLOSE-WAIT on port %d (threshold %d); "+
+				"the peers have closed but the application has not, so check that it closes connections on EOF and on error paths",
+			n, port, CloseWaitLeakThreshold))
+	}
+
 	return stats, nil
 }
 
+// resetConnectionInfo zeroes the info-line fields before a new connection is
+// parsed, so they describe that connection or nothing.
+func resetConnectionInfo(stats *TCPStats) {
+	stats.Latency = 0
+	stats.Retransmits = 0
+	stats.Cwnd = 0
+	stats.Ssthresh = 0
+	stats.SndWscale = 0
+	stats.RcvWscale = 0
+	stats.RTO = 0
+	stats.MSS = 0
+	stats.BytesRetrans = 0
+}
+
+// parseConnectionInfo extracts rtt, retransmits, and the flow-control fields
+// from ss -ti info text into stats. Fields it does not recognise are ignored.
+func parseConnectionInfo(info string, stats *TCPStats) {
+	if m := reSSRTT.FindStringSubmatch(info); len(m) > 1 {
+		stats.Latency, _ = strconv.ParseFloat(m[1], 64) // in milliseconds
+	}
+	if m := reSSRetrans.FindStringSubmatch(info); len(m) > 1 {
+		stats.Retransmits, _ = strconv.Atoi(m[1])
+	}
+	parseFlowControl(info, stats)
+}
+
+// parseFlowControl extracts cwnd, ssthresh, wscale, rto, mss, and
+// bytes_retrans from an ss -ti info line into stats.
+// Example: "cubic wscale:7,7 rto:204 rtt:0.5/0.25 mss:1448 cwnd:10 ssthresh:7 bytes_retrans:2896 retrans:0/2"
+func parseFlowControl(line string, stats *TCPStats) {
+	if m := reSSCwnd.FindStringSubmatch(line); len(m) > 1 {
+		stats.Cwnd, _ = strconv.Atoi(m[1])
+	}
+	if m := reSSSsthresh.FindStringSubmatch(line); len(m) > 1 {
+		stats.Ssthresh, _ = strconv.Atoi(m[1])
+	}
+	if m := reSSWscale.FindStringSubmatch(line); len(m) > 2 {
+		stats.SndWscale, _ = strconv.Atoi(m[1])
+		stats.RcvWscale, _ = strconv.Atoi(m[2])
+	}
+	if m := reSSRTO.FindStringSubmatch(line); len(m) > 1 {
+		stats.RTO, _ = strconv.ParseFloat(m[1], 64)
+	}
+	if m := reSSMSS.FindStringSubmatch(line); len(m) > 1 {
+		stats.MSS, _ = strconv.Atoi(m[1])
+	}
+	if m := reSSBytesRetrans.FindStringSubmatch(line); len(m) > 1 {
+		stats.BytesRetrans, _ = strconv.ParseInt(m[1], 10, 64)
+	}
+}
+
+// isCongestionLimited reports whether the send queue holds more data than one
+// congestion window can carry, i.e. throughput is bounded by cwnd rather than
+// by the application or the receiver.
+func isCongestionLimited(stats *TCPStats) bool {
+	if stats.Cwnd <= 0 || stats.SendQueueBytes <= 0 {
+		return false
+	}
+	mss := stats.MSS
+	if mss <= 0 {
+		mss = defaultMSS
+	}
+	return stats.SendQueueBytes > stats.Cwnd*mss
+}
+
 // calculateRecommendedBuffer computes recommended buffer size
 // Formula: RTT (seconds) * Bandwidth (bits/sec) / 8 (to get bytes)
 // Conservative: assume 1Gbps if RTT is very low, scale down for higher RTT
@@ -0,0 +1,176 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// tcpTimingDialTimeout bounds each sample's DNS lookup and handshake.
+const tcpTimingDialTimeout = 3 * time.Second
+
+// Phases reported in TCPConnectTimingResult.SlowestPhase.
+const (
+	PhaseDNS       = "dns"
+	PhaseHandshake = "handshake"
+)
+
+// TCPConnectSample is one connection attempt split into its phases.
+type TCPConnectSample struct {
+	Sample int `json:"sample"`
+	// Address is the IP:port dialed, from this sample's DNS answer.
+	Address string  `json:"address,omitempty"`
+	DNSMs   float64 `json:"dns_ms"`
+	// HandshakeMs runs from the socket's connect call (SYN) until the
+	// connection is established (SYN-ACK received, ACK sent).
+	HandshakeMs float64 `json:"handshake_ms"`
+	TotalMs     float64 `json:"total_ms"`
+	Error       string  `json:"error,omitempty"`
+
+	Explanation *ConnErrorExplanation `json:"explanation,omitempty"`
+}
+
+// TCPConnectTimingResult is the output of TCPConnectTiming.
+type TCPConnectTimingResult struct {
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+	Samples   int    `json:"samples"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	// DNS, Handshake and Total are distributions over successful samples;
+	// nil when none succeeded.
+	DNS       *LatencyStats `json:"dns"`
+	Handshake *LatencyStats `json:"handshake"`
+	Total     *LatencyStats `json:"total"`
+	// SlowestPhase is PhaseDNS or PhaseHandshake, whichever has the higher
+	// median.
+	SlowestPhase  string             `json:"slowest_phase,omitempty"`
+	SampleResults []TCPConnectSample `json:"sample_results"`
+}
+
+// TCPConnectTiming connects to host:port samples times (1-100) in
+// sequence and reports how long DNS resolution and the TCP handshake took
+// in each, to tell slow DNS from a slow network path or backlog. Each
+// sample resolves host afresh; an IP address has no DNS time. Failed
+// samples are reported in the result.
+func TCPConnectTiming(host string, port int, samples int) (*TCPConnectTimingResult, error) {
+	return TCPConnectTimingContext(context.Background(), host, port, samples)
+}
+
+// TCPConnectTimingContext is TCPConnectTiming that stops early, with an
+// error, if ctx is cancelled between samples.
+func TCPConnectTimingContext(ctx context.Context, host string, port int, samples int) (*TCPConnectTimingResult, error) {
+	if _, ok := UnixSocketPath(host); ok {
+		return nil, fmt.Errorf("cannot time a TCP handshake to unix socket '%s'; use check_unix_socket instead", host)
+	}
+	if host == "" {
+		return nil, errors.New("host is required")
+	}
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	if err := checkSamples(samples); err != nil {
+		return nil, err
+	}
+	if err := checkTarget(ctx, host); err != nil {
+		return nil, err
+	}
+
+	result := &TCPConnectTimingResult{
+		Host:          host,
+		Port:          port,
+		Samples:       samples,
+		SampleResults: make([]TCPConnectSample, 0, samples),
+	}
+	var dns, handshake, total []time.Duration
+
+	for i := 1; i <= samples; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("stopped after %d of %d samples: %w", i-1, samples, err)
+		}
+		s, d, h := timeTCPConnect(ctx, host, port)
+		s.Sample = i
+		result.SampleResults = append(result.SampleResults, s)
+		if s.Error != "" {
+			result.Failed++
+			continue
+		}
+		result.Succeeded++
+		dns = append(dns, d)
+		handshake = append(handshake, h)
+		total = append(total, d+h)
+	}
+
+	if result.Succeeded > 0 {
+		result.DNS = NewLatencyStats(dns)
+		result.Handshake = NewLatencyStats(handshake)
+		result.Total = NewLatencyStats(total)
+		result.SlowestPhase = PhaseHandshake
+		if result.DNS.P50Ms > result.Handshake.P50Ms {
+			result.SlowestPhase = PhaseDNS
+		}
+	}
+	return result, nil
+}
+
+// timeTCPConnect makes one connection, returning the sample and its DNS
+// and handshake durations.
+func timeTCPConnect(ctx context.Context, host string, port int) (TCPConnectSample, time.Duration, time.Duration) {
+	var s TCPConnectSample
+	ctx, cancel := context.WithTimeout(ctx, tcpTimingDialTimeout)
+	defer cancel()
+
+	start := time.Now()
+	ip := host
+	var dnsTime time.Duration
+	if net.ParseIP(host) == nil {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err == nil && len(addrs) == 0 {
+			err = fmt.Errorf("no addresses for '%s'", host)
+		}
+		if err != nil {
+			s.DNSMs = durationMs(time.Since(start))
+			s.TotalMs = s.DNSMs
+			failSample(&s, err)
+			return s, 0, 0
+		}
+		ip = addrs[0].IP.String()
+		dnsTime = time.Since(start)
+	}
+	s.DNSMs = durationMs(dnsTime)
+	s.Address = net.JoinHostPort(ip, strconv.Itoa(port))
+
+	// Control runs just before connect(2) sends the SYN, so the handshake
+	// is timed from there rather than from socket setup.
+	var synSent time.Time
+	dialer := net.Dialer{
+		Control: func(network, address string, c syscall.RawConn) error {
+			synSent = time.Now()
+			return nil
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Address)
+	established := time.Now()
+	if synSent.IsZero() {
+		synSent = established
+	}
+	handshakeTime := established.Sub(synSent)
+	s.HandshakeMs = durationMs(handshakeTime)
+	s.TotalMs = durationMs(established.Sub(start))
+	if err != nil {
+		failSample(&s, err)
+		return s, dnsTime, handshakeTime
+	}
+	conn.Close()
+	return s, dnsTime, handshakeTime
+}
+
+func failSample(s *TCPConnectSample, err error) {
+	s.Error = err.Error()
+	explanation := ClassifyConnError(err)
+	s.Explanation = &explanation
+}
@@ -0,0 +1,177 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tlsScanTimeout bounds each TLSScan handshake.
+const tlsScanTimeout = 5 * time.Second
+
+// tlsScanVersions are the protocol versions TLSScan tries, oldest first.
+// SSLv3 is not among them: crypto/tls cannot speak it.
+var tlsScanVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// TLSVersionSupport is whether a server accepted one protocol version and,
+// if so, the cipher suites it accepted with it.
+type TLSVersionSupport struct {
+	Version   string `json:"version"`
+	Supported bool   `json:"supported"`
+	// Ciphers are the suites the server accepted with this version. TLS 1.3
+	// suites cannot be offered one at a time, so for TLS 1.3 this is the
+	// suite the server chose.
+	Ciphers []string `json:"ciphers"`
+	// Error is why the handshake failed when the version is not supported.
+	Error string `json:"error,omitempty"`
+}
+
+// TLSScanResult is the output of TLSScan.
+type TLSScanResult struct {
+	Host              string              `json:"host"`
+	Port              int                 `json:"port"`
+	Versions          []TLSVersionSupport `json:"versions"`
+	SupportedVersions []string            `json:"supported_versions"`
+	// WeakProtocols are the supported versions deprecated by RFC 8996,
+	// TLS 1.0 and TLS 1.1.
+	WeakProtocols []string `json:"weak_protocols"`
+	// WeakCiphers are the accepted suites that crypto/tls lists as
+	// insecure or that lack forward secrecy (RSA key exchange).
+	WeakCiphers []string `json:"weak_ciphers"`
+	Warnings    []string `json:"warnings"`
+}
+
+// TLSScan reports which TLS versions (1.0-1.3) and cipher suites the server
+// at host:port accepts, by attempting a handshake per version and then per
+// suite within each accepted version, and flags weak protocols and
+// ciphers. Certificates are not verified. SSLv3 cannot be tested.
+func TLSScan(host string, port int) (*TLSScanResult, error) {
+	return TLSScanContext(context.Background(), host, port)
+}
+
+// TLSScanContext is TLSScan that stops early, with an error, if ctx is
+// cancelled between handshakes.
+func TLSScanContext(ctx context.Context, host string, port int) (*TLSScanResult, error) {
+	if host == "" {
+		return nil, errors.New("host is required")
+	}
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	if err := checkTarget(ctx, host); err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := net.Dialer{Timeout: tlsScanTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", addr, err)
+	}
+	conn.Close()
+
+	result := &TLSScanResult{
+		Host:              host,
+		Port:              port,
+		Versions:          make([]TLSVersionSupport, 0, len(tlsScanVersions)),
+		SupportedVersions: []string{},
+		WeakProtocols:     []string{},
+		WeakCiphers:       []string{},
+		Warnings:          []string{"SSLv3 was not tested: it is not supported by this client"},
+	}
+	weak := weakCipherSuites()
+
+	for _, version := range tlsScanVersions {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("TLS scan of %s stopped: %w", addr, err)
+		}
+		support := TLSVersionSupport{Version: tls.VersionName(version), Ciphers: []string{}}
+		state, err := tlsHandshake(ctx, addr, host, version, nil)
+		if err != nil {
+			support.Error = err.Error()
+			result.Versions = append(result.Versions, support)
+			continue
+		}
+		support.Supported = true
+		result.SupportedVersions = append(result.SupportedVersions, support.Version)
+		if version < tls.VersionTLS12 {
+			result.WeakProtocols = append(result.WeakProtocols, support.Version)
+		}
+
+		if version == tls.VersionTLS13 {
+			support.Ciphers = append(support.Ciphers, tls.CipherSuiteName(state.CipherSuite))
+		} else {
+			for _, suite := range cipherSuitesFor(version) {
+				if err := ctx.Err(); err != nil {
+					return nil, fmt.Errorf("TLS scan of %s stopped: %w", addr, err)
+				}
+				if _, err := tlsHandshake(ctx, addr, host, version, []uint16{suite.ID}); err != nil {
+					continue
+				}
+				support.Ciphers = append(support.Ciphers, suite.Name)
+				if weak[suite.ID] && !slices.Contains(result.WeakCiphers, suite.Name) {
+					result.WeakCiphers = append(result.WeakCiphers, suite.Name)
+				}
+			}
+		}
+		result.Versions = append(result.Versions, support)
+	}
+	return result, nil
+}
+
+// tlsHandshake completes a handshake with addr pinned to version and, when
+// suites is non-nil, offering only those suites.
+func tlsHandshake(ctx context.Context, addr, host string, version uint16, suites []uint16) (tls.ConnectionState, error) {
+	ctx, cancel := context.WithTimeout(ctx, tlsScanTimeout)
+	defer cancel()
+
+	dialer := tls.Dialer{Config: &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, // scanning what is offered, not validating it
+		MinVersion:         version,
+		MaxVersion:         version,
+		CipherSuites:       suites,
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
+
+// cipherSuitesFor returns the TLS 1.0-1.2 suites crypto/tls can offer with
+// version, secure ones first.
+func cipherSuitesFor(version uint16) []*tls.CipherSuite {
+	var suites []*tls.CipherSuite
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		for _, v := range s.SupportedVersions {
+			if v == version {
+				suites = append(suites, s)
+				break
+			}
+		}
+	}
+	return suites
+}
+
+// weakCipherSuites returns the IDs of suites crypto/tls considers insecure
+// or that use RSA key exchange and so lack forward secrecy.
+func weakCipherSuites() map[uint16]bool {
+	weak := make(map[uint16]bool)
+	for _, s := range tls.InsecureCipherSuites() {
+		weak[s.ID] = true
+	}
+	for _, s := range tls.CipherSuites() {
+		if strings.HasPrefix(s.Name, "TLS_RSA_") {
+			weak[s.ID] = true
+		}
+	}
+	return weak
+}
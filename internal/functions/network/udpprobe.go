@@ -0,0 +1,303 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultUDPProbeTimeout is how long ProbeUDPService waits for a reply.
+const defaultUDPProbeTimeout = 3 * time.Second
+
+// UDP services ProbeUDPService can speak.
+const (
+	UDPServiceDNS  = "dns"
+	UDPServiceNTP  = "ntp"
+	UDPServiceSTUN = "stun"
+)
+
+// States reported in UDPServiceProbeResult.State.
+const (
+	// UDPResponding means a valid reply for the service came back.
+	UDPResponding = "responding"
+	// UDPInvalidResponse means something replied, but not as the service.
+	UDPInvalidResponse = "invalid_response"
+	// UDPClosed means the host answered with ICMP port unreachable.
+	UDPClosed = "closed"
+	// UDPNoResponse means nothing came back: the port is open but not
+	// running the service, or a firewall drops the traffic.
+	UDPNoResponse = "no_response"
+)
+
+// UDPProbeOptions adjusts how ProbeUDPServiceContext runs.
+type UDPProbeOptions struct {
+	// Timeout is how long to wait for a reply; 0 is 3 seconds.
+	Timeout time.Duration
+}
+
+// UDPServiceProbeResult is the output of ProbeUDPService.
+type UDPServiceProbeResult struct {
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	Service string `json:"service"`
+	// Responding is true only when a reply valid for the service arrived.
+	Responding bool `json:"responding"`
+	// State is responding, invalid_response, closed or no_response.
+	State string  `json:"state"`
+	RTTMs float64 `json:"rtt_ms,omitempty"`
+	// Details holds what the reply said, such as a DNS rcode, an NTP
+	// stratum or a STUN mapped address.
+	Details map[string]interface{} `json:"details"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// udpProbe is the request for one service and the check of its reply,
+// which fills details and returns an error if the reply is not the
+// service's.
+type udpProbe struct {
+	port     int
+	request  func() ([]byte, error)
+	validate func(request, reply []byte, details map[string]interface{}) error
+}
+
+var udpProbes = map[string]udpProbe{
+	UDPServiceDNS:  {port: 53, request: dnsProbeRequest, validate: validateDNSReply},
+	UDPServiceNTP:  {port: 123, request: ntpProbeRequest, validate: validateNTPReply},
+	UDPServiceSTUN: {port: 3478, request: stunProbeRequest, validate: validateSTUNReply},
+}
+
+// ProbeUDPService sends service's own request (dns, ntp or stun) to
+// host:port and checks that the reply is a valid answer, telling a working
+// server apart from an open port with nothing behind it, which a plain
+// UDP scan reports the same way as a filtered one. A port of 0 uses the
+// service's standard port.
+func ProbeUDPService(host string, port int, service string) (*UDPServiceProbeResult, error) {
+	return ProbeUDPServiceContext(context.Background(), host, port, service, UDPProbeOptions{})
+}
+
+// ProbeUDPServiceContext is ProbeUDPService with opts, stopped if ctx is
+// cancelled.
+func ProbeUDPServiceContext(ctx context.Context, host string, port int, service string, opts UDPProbeOptions) (*UDPServiceProbeResult, error) {
+	service = strings.ToLower(strings.TrimSpace(service))
+	probe, ok := udpProbes[service]
+	if !ok {
+		return nil, fmt.Errorf("unsupported UDP service '%s': use dns, ntp or stun", service)
+	}
+	if host == "" {
+		return nil, errors.New("host is required")
+	}
+	if port == 0 {
+		port = probe.port
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	if err := checkTarget(ctx, host); err != nil {
+		return nil, err
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultUDPProbeTimeout
+	}
+
+	request, err := probe.request()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build %s request: %w", service, err)
+	}
+
+	result := &UDPServiceProbeResult{Host: host, Port: port, Service: service, Details: map[string]interface{}{}}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach %s: %w", host, err)
+	}
+	defer conn.Close()
+
+	// Closing the socket unblocks the read if ctx is cancelled first.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	start := time.Now()
+	conn.SetDeadline(start.Add(timeout))
+	if _, err := conn.Write(request); err != nil {
+		return nil, fmt.Errorf("cannot send %s request: %w", service, err)
+	}
+	reply := make([]byte, 2048)
+	n, err := conn.Read(reply)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("UDP probe stopped: %w", ctxErr)
+	}
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		result.State = UDPClosed
+		result.Error = "ICMP port unreachable: nothing is listening on the port"
+		return result, nil
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		result.State = UDPNoResponse
+		result.Error = fmt.Sprintf("no reply within %s: the port is filtered, or open without a %s server behind it", timeout, service)
+		return result, nil
+	case err != nil:
+		return nil, fmt.Errorf("reading %s reply failed: %w", service, err)
+	}
+	result.RTTMs = durationMs(time.Since(start))
+
+	if err := probe.validate(request, reply[:n], result.Details); err != nil {
+		result.State = UDPInvalidResponse
+		result.Error = fmt.Sprintf("reply is not a valid %s response: %v", service, err)
+		return result, nil
+	}
+	result.State = UDPResponding
+	result.Responding = true
+	return result, nil
+}
+
+// dnsRcodes names the DNS response codes reported in details.
+var dnsRcodes = map[int]string{0: "NOERROR", 1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP", 5: "REFUSED"}
+
+// dnsProbeRequest is a recursive query for the root's NS records, which
+// every resolver and authoritative server answers in some form.
+func dnsProbeRequest() ([]byte, error) {
+	req := make([]byte, 12, 17)
+	if _, err := rand.Read(req[:2]); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(req[2:], 0x0100) // RD
+	binary.BigEndian.PutUint16(req[4:], 1)      // QDCOUNT
+	req = append(req, 0)                        // root name
+	req = append(req, 0, 2, 0, 1)               // QTYPE NS, QCLASS IN
+	return req, nil
+}
+
+func validateDNSReply(request, reply []byte, details map[string]interface{}) error {
+	if len(reply) < 12 {
+		return fmt.Errorf("%d bytes is shorter than a DNS header", len(reply))
+	}
+	if reply[0] != request[0] || reply[1] != request[1] {
+		return errors.New("transaction ID does not match the query")
+	}
+	flags := binary.BigEndian.Uint16(reply[2:])
+	if flags&0x8000 == 0 {
+		return errors.New("QR bit not set")
+	}
+	rcode := int(flags & 0x000f)
+	name, ok := dnsRcodes[rcode]
+	if !ok {
+		name = strconv.Itoa(rcode)
+	}
+	details["rcode"] = name
+	details["recursion_available"] = flags&0x0080 != 0
+	details["authoritative"] = flags&0x0400 != 0
+	details["answers"] = int(binary.BigEndian.Uint16(reply[6:]))
+	return nil
+}
+
+// ntpEpochOffset is the seconds from the NTP epoch (1900) to the Unix epoch.
+const ntpEpochOffset = 2208988800
+
+// ntpProbeRequest is an NTPv4 client request with a random transmit
+// timestamp, which the server must echo as the originate timestamp.
+func ntpProbeRequest() ([]byte, error) {
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI 0, version 4, mode 3 (client)
+	if _, err := rand.Read(req[40:48]); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func validateNTPReply(request, reply []byte, details map[string]interface{}) error {
+	if len(reply) < 48 {
+		return fmt.Errorf("%d bytes is shorter than an NTP packet", len(reply))
+	}
+	if mode := reply[0] & 0x07; mode != 4 {
+		return fmt.Errorf("mode %d, not a server reply", mode)
+	}
+	if string(reply[24:32]) != string(request[40:48]) {
+		return errors.New("originate timestamp does not match the request")
+	}
+	stratum := int(reply[1])
+	details["version"] = int(reply[0]>>3) & 0x07
+	details["stratum"] = stratum
+	if stratum == 0 {
+		// A kiss-o'-death packet: the reference ID holds an ASCII code.
+		details["kiss_code"] = strings.TrimRight(string(reply[12:16]), "\x00")
+		return nil
+	}
+	if stratum == 1 {
+		details["reference_id"] = strings.TrimRight(string(reply[12:16]), "\x00")
+	} else {
+		details["reference_id"] = net.IP(reply[12:16]).String()
+	}
+	if secs := binary.BigEndian.Uint32(reply[40:]); secs > 0 {
+		details["server_time"] = time.Unix(int64(secs)-ntpEpochOffset, 0).UTC().Format(time.RFC3339)
+	}
+	return nil
+}
+
+// stunMagicCookie is the fixed value in every RFC 5389 STUN header.
+const stunMagicCookie = 0x2112A442
+
+// stunProbeRequest is a STUN Binding Request with a random transaction ID.
+func stunProbeRequest() ([]byte, error) {
+	req := make([]byte, 20)
+	binary.BigEndian.PutUint16(req[0:], 0x0001) // Binding Request
+	binary.BigEndian.PutUint32(req[4:], stunMagicCookie)
+	if _, err := rand.Read(req[8:20]); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func validateSTUNReply(request, reply []byte, details map[string]interface{}) error {
+	if len(reply) < 20 {
+		return fmt.Errorf("%d bytes is shorter than a STUN header", len(reply))
+	}
+	if binary.BigEndian.Uint32(reply[4:]) != stunMagicCookie {
+		return errors.New("magic cookie missing")
+	}
+	if string(reply[8:20]) != string(request[8:20]) {
+		return errors.New("transaction ID does not match the request")
+	}
+	switch msgType := binary.BigEndian.Uint16(reply[0:]); msgType {
+	case 0x0101:
+		details["result"] = "success"
+	case 0x0111:
+		details["result"] = "error"
+	default:
+		return fmt.Errorf("message type 0x%04x is not a Binding response", msgType)
+	}
+
+	// Walk the attributes for the address the server saw us connect from.
+	attrs := reply[20:]
+	if length := int(binary.BigEndian.Uint16(reply[2:])); length < len(attrs) {
+		attrs = attrs[:length]
+	}
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:]))
+		if 4+attrLen > len(attrs) {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+		if attrType == 0x0020 && attrLen >= 8 && value[1] == 0x01 { // XOR-MAPPED-ADDRESS, IPv4
+			port := binary.BigEndian.Uint16(value[2:]) ^ uint16(stunMagicCookie>>16)
+			var ip [4]byte
+			binary.BigEndian.PutUint32(ip[:], binary.BigEndian.Uint32(value[4:])^stunMagicCookie)
+			details["mapped_address"] = net.JoinHostPort(net.IP(ip[:]).String(), strconv.Itoa(int(port)))
+		}
+		next := 4 + ((attrLen + 3) &^ 3) // values are padded to 4 bytes
+		if next >= len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+	return nil
+}
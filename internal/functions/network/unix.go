@@ -0,0 +1,94 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// unixScheme prefixes a unix-domain socket target, as in
+// "unix:///run/containerd/containerd.sock".
+const unixScheme = "unix://"
+
+// UnixSocketPath returns the socket path of a "unix://" target and whether
+// target is one.
+func UnixSocketPath(target string) (string, bool) {
+	if !strings.HasPrefix(target, unixScheme) {
+		return "", false
+	}
+	return strings.TrimPrefix(target, unixScheme), true
+}
+
+// dialUnix connects to the socket at path.
+func dialUnix(ctx context.Context, path string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", path)
+}
+
+// UnixSocketResult holds the result of CheckUnixSocket.
+type UnixSocketResult struct {
+	Path     string `json:"path"`
+	Exists   bool   `json:"exists"`
+	IsSocket bool   `json:"is_socket"`
+	// Mode is the file mode, e.g. "Srw-rw----", for checking who may connect.
+	Mode          string  `json:"mode,omitempty"`
+	Accepting     bool    `json:"accepting"`
+	ConnectTimeMs float64 `json:"connect_time_ms"`
+	Error         string  `json:"error,omitempty"`
+
+	Explanation *ConnErrorExplanation `json:"explanation,omitempty"`
+}
+
+// CheckUnixSocket checks that path (optionally "unix://"-prefixed) exists,
+// is a socket, and accepts a connection within timeout seconds (default 3).
+// Each failed check is reported in the result; an error is returned only
+// for an empty path.
+func CheckUnixSocket(path string, timeout int) (*UnixSocketResult, error) {
+	if p, ok := UnixSocketPath(path); ok {
+		path = p
+	}
+	if path == "" {
+		return nil, errors.New("socket path is required")
+	}
+	if timeout <= 0 {
+		timeout = 3
+	}
+
+	result := &UnixSocketResult{Path: path}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		result.Error = err.Error()
+		explanation := ClassifyConnError(err)
+		result.Explanation = &explanation
+		return result, nil
+	}
+	result.Exists = true
+	result.Mode = info.Mode().String()
+	if info.Mode()&os.ModeSocket == 0 {
+		result.Error = fmt.Sprintf("'%s' is not a socket (mode %s)", path, result.Mode)
+		return result, nil
+	}
+	result.IsSocket = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := dialUnix(ctx, path)
+	result.ConnectTimeMs = durationMs(time.Since(start))
+	if err != nil {
+		result.Error = err.Error()
+		explanation := ClassifyConnError(err)
+		result.Explanation = &explanation
+		return result, nil
+	}
+	conn.Close()
+
+	result.Accepting = true
+	return result, nil
+}
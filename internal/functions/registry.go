@@ -1,7 +1,10 @@
 package functions
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/friday/internal/types"
 	"gopkg.in/yaml.v3"
@@ -12,30 +15,81 @@ type Registry struct {
 }
 
 func LoadRegistry(path string) (*Registry, error) {
-	data, err := os.ReadFile(path)
+	defs, err := readDefinitions(path)
 	if err != nil {
 		return nil, err
 	}
 
-	var config struct {
-		Functions []types.FunctionDefinition `yaml:"functions"`
+	registry := &Registry{
+		Functions: make(map[string]types.FunctionDefinition),
 	}
 
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	for _, fn := range defs {
+		registry.Functions[fn.Name] = fn
+	}
+
+	return registry, nil
+}
+
+// LoadRegistryDir merges every *.yaml file in dir into a single registry.
+// Files are read in lexical order; a function name declared in more than one
+// file is an error rather than a silent override.
+func LoadRegistryDir(dir string) (*Registry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
 		return nil, err
 	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.yaml function definition files found in %s", dir)
+	}
+	sort.Strings(paths)
 
 	registry := &Registry{
 		Functions: make(map[string]types.FunctionDefinition),
 	}
+	origin := make(map[string]string)
 
-	for _, fn := range config.Functions {
-		registry.Functions[fn.Name] = fn
+	for _, path := range paths {
+		defs, err := readDefinitions(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, fn := range defs {
+			if prev, dup := origin[fn.Name]; dup {
+				return nil, fmt.Errorf("duplicate function %q declared in %s and %s", fn.Name, prev, path)
+			}
+			origin[fn.Name] = path
+			registry.Functions[fn.Name] = fn
+		}
 	}
 
 	return registry, nil
 }
 
+// readDefinitions parses the functions list from a single registry file.
+func readDefinitions(path string) ([]types.FunctionDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Functions []types.FunctionDefinition `yaml:"functions"`
+	}
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	for _, fn := range config.Functions {
+		if issues := enumIssues(fn); len(issues) > 0 {
+			return nil, fmt.Errorf("function %q: %s %s", fn.Name, issues[0].Field, issues[0].Message)
+		}
+	}
+
+	return config.Functions, nil
+}
+
 func (r *Registry) Get(name string) (types.FunctionDefinition, bool) {
 	fn, exists := r.Functions[name]
 	return fn, exists
@@ -49,9 +103,46 @@ func (r *Registry) List() []string {
 	return names
 }
 
+// Tools returns display metadata for the functions matching filter, sorted
+// by category and then name.
+func (r *Registry) Tools(filter types.ToolFilter) []types.ToolInfo {
+	tools := make([]types.ToolInfo, 0, len(r.Functions))
+	for name, fn := range r.Functions {
+		tool := types.ToolInfo{
+			Name:        fn.Name,
+			Description: fn.Description,
+			Category:    fn.Category,
+			Phase:       r.Phase(name),
+			Stability:   r.Stability(name),
+			Parameters:  fn.Parameters,
+		}
+		if filter.Matches(tool) {
+			tools = append(tools, tool)
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		if tools[i].Category != tools[j].Category {
+			return tools[i].Category < tools[j].Category
+		}
+		return tools[i].Name < tools[j].Name
+	})
+	return tools
+}
+
+// Phase returns the declared phase of a function, defaulting to read for
+// unknown functions and definitions that omit the field.
 func (r *Registry) Phase(functionName string) string {
-	if fn, exists := r.Functions[functionName]; exists {
+	if fn, exists := r.Functions[functionName]; exists && fn.Phase != "" {
 		return fn.Phase
 	}
-	return "read"
+	return types.PhaseRead
+}
+
+// Stability returns the declared stability of a function, defaulting to
+// stable for unknown functions and definitions that omit the field.
+func (r *Registry) Stability(functionName string) string {
+	if fn, exists := r.Functions[functionName]; exists && fn.Stability != "" {
+		return fn.Stability
+	}
+	return types.StabilityStable
 }
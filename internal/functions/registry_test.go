@@ -0,0 +1,221 @@
+package functions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/types"
+)
+
+func writeRegistryFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func TestLoadRegistryDir_MergesFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "network.yaml", `
+functions:
+  - name: ping
+    category: network
+    phase: read
+  - name: traceroute
+    category: network
+    phase: read
+`)
+	writeRegistryFile(t, dir, "system.yaml", `
+functions:
+  - name: execute_sysctl_command
+    category: system
+    phase: modify
+`)
+	// Non-YAML files in the directory are ignored.
+	writeRegistryFile(t, dir, "README.md", "not a registry")
+
+	reg, err := LoadRegistryDir(dir)
+	if err != nil {
+		t.Fatalf("LoadRegistryDir returned error: %v", err)
+	}
+	if len(reg.Functions) != 3 {
+		t.Fatalf("expected 3 functions, got %d", len(reg.Functions))
+	}
+	for _, name := range []string{"ping", "traceroute", "execute_sysctl_command"} {
+		if _, ok := reg.Get(name); !ok {
+			t.Errorf("expected %s in merged registry", name)
+		}
+	}
+	if reg.Phase("execute_sysctl_command") != "modify" {
+		t.Errorf("expected execute_sysctl_command phase modify, got %q", reg.Phase("execute_sysctl_command"))
+	}
+}
+
+func TestLoadRegistryDir_DuplicateName(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "a.yaml", `
+functions:
+  - name: ping
+    phase: read
+`)
+	writeRegistryFile(t, dir, "b.yaml", `
+functions:
+  - name: ping
+    phase: read
+`)
+
+	_, err := LoadRegistryDir(dir)
+	if err == nil {
+		t.Fatal("expected error for duplicate function name, got nil")
+	}
+	if !strings.Contains(err.Error(), "duplicate function \"ping\"") {
+		t.Errorf("expected duplicate error naming ping, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "a.yaml") || !strings.Contains(err.Error(), "b.yaml") {
+		t.Errorf("expected both file names in error, got: %v", err)
+	}
+}
+
+func TestLoadRegistryDir_EmptyDirectory(t *testing.T) {
+	_, err := LoadRegistryDir(t.TempDir())
+	if err == nil {
+		t.Fatal("expected error for empty directory, got nil")
+	}
+	if !strings.Contains(err.Error(), "no *.yaml") {
+		t.Errorf("expected 'no *.yaml' in error, got: %v", err)
+	}
+}
+
+func TestLoadRegistry_SingleFileStillWorks(t *testing.T) {
+	reg, err := LoadRegistry("../../functions.yaml")
+	if err != nil {
+		t.Fatalf("LoadRegistry returned error: %v", err)
+	}
+	if _, ok := reg.Get("check_tcp_health"); !ok {
+		t.Error("expected check_tcp_health in registry")
+	}
+}
+
+func TestLoadRegistry_InvalidPhaseRejected(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "bad.yaml", `
+functions:
+  - name: ping
+    phase: write
+`)
+
+	_, err := LoadRegistry(filepath.Join(dir, "bad.yaml"))
+	if err == nil {
+		t.Fatal("expected error for invalid phase, got nil")
+	}
+	if !strings.Contains(err.Error(), "phase must be read, analyze, or modify, got 'write'") {
+		t.Errorf("expected invalid phase error, got: %v", err)
+	}
+}
+
+func TestLoadRegistry_InvalidStabilityRejected(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "bad.yaml", `
+functions:
+  - name: ping
+    stability: beta
+`)
+
+	_, err := LoadRegistry(filepath.Join(dir, "bad.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "stability must be stable, experimental, or deprecated, got 'beta'") {
+		t.Errorf("expected invalid stability error, got: %v", err)
+	}
+}
+
+func TestRegistryPhase_Defaults(t *testing.T) {
+	reg := &Registry{Functions: map[string]types.FunctionDefinition{
+		"ping":         {Name: "ping", Phase: types.PhaseRead},
+		"sysctl":       {Name: "sysctl", Phase: types.PhaseModify},
+		"no_phase_set": {Name: "no_phase_set"},
+	}}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ping", types.PhaseRead},
+		{"sysctl", types.PhaseModify},
+		{"no_phase_set", types.PhaseRead},
+		{"unknown_function", types.PhaseRead},
+	}
+	for _, tt := range tests {
+		if got := reg.Phase(tt.name); got != tt.want {
+			t.Errorf("Phase(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryStability_Defaults(t *testing.T) {
+	reg := &Registry{Functions: map[string]types.FunctionDefinition{
+		"ping":       {Name: "ping"},
+		"tls_scan":   {Name: "tls_scan", Stability: types.StabilityExperimental},
+		"traceroute": {Name: "traceroute", Stability: types.StabilityDeprecated},
+	}}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ping", types.StabilityStable},
+		{"tls_scan", types.StabilityExperimental},
+		{"traceroute", types.StabilityDeprecated},
+		{"unknown_function", types.StabilityStable},
+	}
+	for _, tt := range tests {
+		if got := reg.Stability(tt.name); got != tt.want {
+			t.Errorf("Stability(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if tools := reg.Tools(types.ToolFilter{}); tools[1].Name != "tls_scan" || tools[1].Stability != types.StabilityExperimental {
+		t.Errorf("expected tls_scan listed as experimental, got %+v", tools)
+	}
+}
+
+func TestRegistryTools_Filters(t *testing.T) {
+	reg := &Registry{Functions: map[string]types.FunctionDefinition{
+		"ping":             {Name: "ping", Category: "network", Phase: types.PhaseRead},
+		"traceroute":       {Name: "traceroute", Category: "network", Phase: types.PhaseRead},
+		"analyze_pcap":     {Name: "analyze_pcap", Category: "network", Phase: types.PhaseAnalyze},
+		"execute_sysctl":   {Name: "execute_sysctl", Category: "system", Phase: types.PhaseModify},
+		"interface_stats":  {Name: "interface_stats", Category: "system"},
+		"analyze_coredump": {Name: "analyze_coredump", Category: "debugging", Phase: types.PhaseAnalyze},
+	}}
+
+	names := func(tools []types.ToolInfo) string {
+		var out []string
+		for _, tool := range tools {
+			out = append(out, tool.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		filter types.ToolFilter
+		want   string
+	}{
+		{types.ToolFilter{}, "analyze_coredump,analyze_pcap,ping,traceroute,execute_sysctl,interface_stats"},
+		{types.ToolFilter{Category: "network"}, "analyze_pcap,ping,traceroute"},
+		{types.ToolFilter{Category: "Network"}, "analyze_pcap,ping,traceroute"},
+		{types.ToolFilter{Phase: types.PhaseRead}, "ping,traceroute,interface_stats"},
+		{types.ToolFilter{Phase: types.PhaseAnalyze}, "analyze_coredump,analyze_pcap"},
+		{types.ToolFilter{Category: "system", Phase: types.PhaseModify}, "execute_sysctl"},
+		{types.ToolFilter{Category: "yang"}, ""},
+	}
+	for _, tt := range tests {
+		if got := names(reg.Tools(tt.filter)); got != tt.want {
+			t.Errorf("Tools(%+v) = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+
+	// Phase is resolved through Phase(), so an omitted phase reads as "read".
+	if tools := reg.Tools(types.ToolFilter{Category: "system", Phase: types.PhaseRead}); tools[0].Phase != types.PhaseRead {
+		t.Errorf("expected defaulted phase %q, got %q", types.PhaseRead, tools[0].Phase)
+	}
+}
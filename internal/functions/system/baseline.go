@@ -0,0 +1,177 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BaselineSysctls is the network tuning state recorded by a baseline capture:
+// socket buffers, backlogs, TCP behaviour, and the local port range.
+var BaselineSysctls = []string{
+	"net.core.rmem_default",
+	"net.core.rmem_max",
+	"net.core.wmem_default",
+	"net.core.wmem_max",
+	"net.core.optmem_max",
+	"net.core.netdev_max_backlog",
+	"net.core.somaxconn",
+	"net.core.default_qdisc",
+	"net.ipv4.tcp_rmem",
+	"net.ipv4.tcp_wmem",
+	"net.ipv4.tcp_mem",
+	"net.ipv4.tcp_congestion_control",
+	"net.ipv4.tcp_window_scaling",
+	"net.ipv4.tcp_timestamps",
+	"net.ipv4.tcp_sack",
+	"net.ipv4.tcp_slow_start_after_idle",
+	"net.ipv4.tcp_mtu_probing",
+	"net.ipv4.tcp_max_syn_backlog",
+	"net.ipv4.tcp_syncookies",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_tw_reuse",
+	"net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_keepalive_intvl",
+	"net.ipv4.tcp_keepalive_probes",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.udp_mem",
+	"net.ipv4.udp_rmem_min",
+	"net.ipv4.udp_wmem_min",
+}
+
+// NetworkBaseline is a point-in-time snapshot of a host's network tuning.
+type NetworkBaseline struct {
+	CapturedAt time.Time         `json:"captured_at"`
+	Hostname   string            `json:"hostname"`
+	Sysctls    map[string]string `json:"sysctls"`
+	// Buffers is the inspect_network_buffers result at capture time, kept
+	// for reference; the diff compares Sysctls, which cover the same values.
+	Buffers  map[string]interface{} `json:"buffers,omitempty"`
+	Warnings []string               `json:"warnings"`
+}
+
+// BaselineChange is one parameter whose value differs between snapshots.
+// Before or After is empty when the parameter was unreadable on that side.
+type BaselineChange struct {
+	Parameter string `json:"parameter"`
+	Before    string `json:"before"`
+	After     string `json:"after"`
+}
+
+// BaselineDiff is the result of comparing a captured baseline with a later
+// snapshot.
+type BaselineDiff struct {
+	CapturedAt time.Time        `json:"captured_at"`
+	ComparedAt time.Time        `json:"compared_at"`
+	Checked    int              `json:"checked"`
+	Changed    []BaselineChange `json:"changed"`
+	Unchanged  bool             `json:"unchanged"`
+}
+
+// CaptureNetworkBaseline snapshots BaselineSysctls and the buffer analysis
+// from inspect_network_buffers on this host.
+func CaptureNetworkBaseline() (*NetworkBaseline, error) {
+	b, err := CaptureNetworkBaselineFrom("/proc")
+	if err != nil {
+		return nil, err
+	}
+	if buffers, err := InspectNetworkBuffers(); err == nil {
+		b.Buffers = buffers
+	} else {
+		b.Warnings = append(b.Warnings, fmt.Sprintf("buffer inspection failed: %v", err))
+	}
+	return b, nil
+}
+
+// CaptureNetworkBaselineFrom snapshots BaselineSysctls from the proc tree
+// rooted at procRoot. Parameters the kernel does not expose are left out
+// with a warning; it is an error only if none can be read.
+func CaptureNetworkBaselineFrom(procRoot string) (*NetworkBaseline, error) {
+	hostname, _ := os.Hostname()
+	b := &NetworkBaseline{
+		CapturedAt: time.Now().UTC(),
+		Hostname:   hostname,
+		Sysctls:    make(map[string]string),
+		Warnings:   []string{},
+	}
+
+	for _, param := range BaselineSysctls {
+		procPath := filepath.Join(procRoot, strings.TrimPrefix(ParamToProcPath(param), "/proc/"))
+		value, err := readCurrentValue(procPath)
+		if err != nil {
+			b.Warnings = append(b.Warnings, fmt.Sprintf("%s not captured: %v", param, err))
+			continue
+		}
+		b.Sysctls[param] = normalizeSysctlValue(value)
+	}
+
+	if len(b.Sysctls) == 0 {
+		return nil, fmt.Errorf("no network sysctls readable under %s", procRoot)
+	}
+	return b, nil
+}
+
+// WriteNetworkBaseline saves b to path as indented JSON.
+func WriteNetworkBaseline(path string, b *NetworkBaseline) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadNetworkBaseline reads a baseline written by WriteNetworkBaseline.
+func LoadNetworkBaseline(path string) (*NetworkBaseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read baseline %s: %w", path, err)
+	}
+	var b NetworkBaseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	if len(b.Sysctls) == 0 {
+		return nil, fmt.Errorf("baseline %s has no sysctl values", path)
+	}
+	return &b, nil
+}
+
+// DiffNetworkBaselines reports every parameter whose value differs between
+// before and after, including parameters readable in only one of them.
+// Changes are sorted by parameter name.
+func DiffNetworkBaselines(before, after *NetworkBaseline) *BaselineDiff {
+	diff := &BaselineDiff{
+		CapturedAt: before.CapturedAt,
+		ComparedAt: after.CapturedAt,
+		Changed:    []BaselineChange{},
+	}
+
+	params := make(map[string]bool)
+	for p := range before.Sysctls {
+		params[p] = true
+	}
+	for p := range after.Sysctls {
+		params[p] = true
+	}
+	diff.Checked = len(params)
+
+	for p := range params {
+		was, now := before.Sysctls[p], after.Sysctls[p]
+		if normalizeSysctlValue(was) != normalizeSysctlValue(now) {
+			diff.Changed = append(diff.Changed, BaselineChange{Parameter: p, Before: was, After: now})
+		}
+	}
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Parameter < diff.Changed[j].Parameter
+	})
+
+	diff.Unchanged = len(diff.Changed) == 0
+	return diff
+}
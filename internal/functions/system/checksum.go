@@ -0,0 +1,85 @@
+package system
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Checksum algorithms accepted by FileChecksum.
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+	ChecksumSHA1   = "sha1"
+	ChecksumMD5    = "md5"
+)
+
+var checksumHashes = map[string]func() hash.Hash{
+	ChecksumSHA256: sha256.New,
+	ChecksumSHA512: sha512.New,
+	ChecksumSHA1:   sha1.New,
+	ChecksumMD5:    md5.New,
+}
+
+// FileChecksumResult is the output of FileChecksum.
+type FileChecksumResult struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	// Checksum is lowercase hex.
+	Checksum  string    `json:"checksum"`
+	SizeBytes int64     `json:"size_bytes"`
+	ModTime   time.Time `json:"mod_time"`
+}
+
+// FileChecksum hashes the file at path with algo, sha256 when empty, and
+// reports it with the file's size and modification time, so a file that
+// should be identical across hosts can be compared by running it on each.
+// sha1 and md5 are accepted to match checksums other tools recorded.
+func FileChecksum(path string, algo string) (*FileChecksumResult, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	algo = strings.ToLower(algo)
+	if algo == "" {
+		algo = ChecksumSHA256
+	}
+	newHash, ok := checksumHashes[algo]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm '%s' (use %s, %s, %s, or %s)",
+			algo, ChecksumSHA256, ChecksumSHA512, ChecksumSHA1, ChecksumMD5)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file '%s': %w", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file '%s': %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("'%s' is not a regular file", path)
+	}
+
+	h := newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("cannot read file '%s': %w", path, err)
+	}
+	return &FileChecksumResult{
+		Path:      path,
+		Algorithm: algo,
+		Checksum:  hex.EncodeToString(h.Sum(nil)),
+		SizeBytes: info.Size(),
+		ModTime:   info.ModTime(),
+	}, nil
+}
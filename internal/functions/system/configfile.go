@@ -0,0 +1,172 @@
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/friday/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats accepted by ValidateConfigFile.
+const (
+	ConfigFormatJSON = "json"
+	ConfigFormatYAML = "yaml"
+)
+
+// maxConfigFileSize caps the file ValidateConfigFile reads.
+const maxConfigFileSize = 10 << 20
+
+// ConfigFileResult is the output of ValidateConfigFile.
+type ConfigFileResult struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	Valid  bool   `json:"valid"`
+	// Error is the parse error when Valid is false, and Line and Column
+	// locate it (1-based) when the parser reports where it was; YAML
+	// errors give a line only.
+	Error    string   `json:"error,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Warnings []string `json:"warnings"`
+}
+
+// ValidateConfigFile checks that the file at path parses as format, json or
+// yaml. An empty format is taken from the extension: .json, or .yaml and
+// .yml. Only syntax is checked, not what the consuming service expects. A
+// file that does not parse is a result with Valid false, not an error.
+func ValidateConfigFile(path string, format string) (*ConfigFileResult, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	format, err := configFormat(path, format)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file '%s': %w", path, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("'%s' is a directory, not a config file", path)
+	}
+	if info.Size() > maxConfigFileSize {
+		return nil, fmt.Errorf("config file '%s' is %d bytes; at most %d can be validated", path, info.Size(), maxConfigFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file '%s': %w", path, err)
+	}
+
+	result := &ConfigFileResult{Path: path, Format: format, Warnings: []string{}}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if format == ConfigFormatJSON {
+			result.Error = "file is empty"
+			return result, nil
+		}
+		result.Warnings = append(result.Warnings, "file is empty; YAML parses it as null")
+	}
+
+	if format == ConfigFormatJSON {
+		validateJSON(data, result)
+	} else {
+		validateYAML(data, result)
+	}
+	return result, nil
+}
+
+// configFormat normalizes format, or picks it from path's extension when
+// it is empty.
+func configFormat(path, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		return ConfigFormatJSON, nil
+	case "yaml", "yml":
+		return ConfigFormatYAML, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported format '%s' (use json or yaml)", format)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigFormatJSON, nil
+	case ".yaml", ".yml":
+		return ConfigFormatYAML, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of '%s' from its extension; pass format json or yaml", path)
+}
+
+// validateJSON parses data as a single JSON value, locating a syntax error
+// from its byte offset.
+func validateJSON(data []byte, result *ConfigFileResult) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var v interface{}
+	err := dec.Decode(&v)
+	if err == nil {
+		// Anything but whitespace after the value is an error too.
+		if _, extra := dec.Token(); extra != io.EOF {
+			offset := dec.InputOffset()
+			result.Error = "unexpected data after the top-level JSON value"
+			result.Line, result.Column = lineColumn(data, offset)
+			return
+		}
+		result.Valid = true
+		return
+	}
+
+	result.Error = err.Error()
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// Offset is just past the byte that failed to parse.
+		result.Line, result.Column = lineColumn(data, syntaxErr.Offset-1)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		result.Error = "unexpected end of file: an object, array or string is not closed"
+		result.Line, result.Column = lineColumn(data, int64(len(data)))
+	}
+}
+
+// validateYAML parses every document in data.
+func validateYAML(data []byte, result *ConfigFileResult) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			result.Valid = true
+			return
+		}
+		if err != nil {
+			result.Error = err.Error()
+			if issues := types.YAMLErrorIssues(err); len(issues) > 0 {
+				result.Line = issues[0].Line
+			}
+			if strings.Contains(result.Error, "cannot start any token") && bytes.Contains(data, []byte("\t")) {
+				result.Warnings = append(result.Warnings, "the file contains tabs; YAML indentation must use spaces")
+			}
+			return
+		}
+	}
+}
+
+// lineColumn converts a byte offset in data to a 1-based line and column.
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,117 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SysctlDrift compares one baseline parameter with its running value.
+type SysctlDrift struct {
+	Parameter string `json:"parameter"`
+	Desired   string `json:"desired"`
+	Current   string `json:"current"`
+	Drifted   bool   `json:"drifted"`
+	// Error is set when the running value could not be read; such parameters
+	// count as drifted since the host cannot be shown to match.
+	Error string `json:"error,omitempty"`
+}
+
+// SysctlDriftReport is the result of checking a host against a baseline file.
+type SysctlDriftReport struct {
+	Baseline     string        `json:"baseline"`
+	Checked      int           `json:"checked"`
+	DriftedCount int           `json:"drifted_count"`
+	InSync       bool          `json:"in_sync"`
+	Parameters   []SysctlDrift `json:"parameters"`
+	Warnings     []string      `json:"warnings"`
+}
+
+// CheckSysctlDrift reads a sysctl.conf-format baseline and compares each
+// listed parameter with its running value under /proc/sys.
+func CheckSysctlDrift(baselinePath string) (*SysctlDriftReport, error) {
+	return CheckSysctlDriftFrom(baselinePath, "/proc")
+}
+
+// CheckSysctlDriftFrom is CheckSysctlDrift against the proc tree rooted at
+// procRoot. Baseline entries whose parameter or value execute_sysctl_command
+// would reject are skipped with a warning rather than read.
+func CheckSysctlDriftFrom(baselinePath, procRoot string) (*SysctlDriftReport, error) {
+	f, err := os.Open(baselinePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open baseline %s: %w", baselinePath, err)
+	}
+	defer f.Close()
+
+	report := &SysctlDriftReport{
+		Baseline:   baselinePath,
+		Parameters: []SysctlDrift{},
+		Warnings:   []string{},
+	}
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		// A leading "-" tells sysctl --system to ignore errors; it is not
+		// part of the parameter name.
+		line = strings.TrimPrefix(line, "-")
+
+		param, value, ok := strings.Cut(line, "=")
+		if !ok {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("line %d: expected 'parameter = value', skipped", lineNo))
+			continue
+		}
+		param = strings.TrimSpace(param)
+		value = normalizeSysctlValue(value)
+
+		// Zero is a legitimate baseline value (e.g. tcp_tw_reuse = 0), so only
+		// the syntax checks from ValidateSysctl apply here.
+		if !paramValidationRegex.MatchString(param) {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("line %d: parameter %q is not a net.* parameter, skipped", lineNo, param))
+			continue
+		}
+		if !valueValidationRegex.MatchString(value) {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("line %d: value %q for %s is not numeric, skipped", lineNo, value, param))
+			continue
+		}
+
+		entry := SysctlDrift{Parameter: param, Desired: value}
+		procPath := filepath.Join(procRoot, strings.TrimPrefix(ParamToProcPath(param), "/proc/"))
+		current, err := readCurrentValue(procPath)
+		if err != nil {
+			entry.Drifted = true
+			entry.Error = err.Error()
+		} else {
+			entry.Current = normalizeSysctlValue(current)
+			entry.Drifted = entry.Current != entry.Desired
+		}
+
+		report.Checked++
+		if entry.Drifted {
+			report.DriftedCount++
+		}
+		report.Parameters = append(report.Parameters, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading baseline %s: %w", baselinePath, err)
+	}
+
+	report.InSync = report.DriftedCount == 0
+	return report, nil
+}
+
+// normalizeSysctlValue collapses whitespace so tuples compare equal whether
+// they were written with spaces or the tabs /proc uses.
+func normalizeSysctlValue(v string) string {
+	return strings.Join(strings.Fields(v), " ")
+}
@@ -0,0 +1,222 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InterfaceCounters are the cumulative counters /proc/net/dev reports for one
+// interface since boot (or since the driver was loaded).
+type InterfaceCounters struct {
+	Interface string `json:"interface"`
+
+	RxBytes   uint64 `json:"rx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	RxErrors  uint64 `json:"rx_errors"`
+	RxDropped uint64 `json:"rx_dropped"`
+	RxFIFO    uint64 `json:"rx_fifo_errors"`
+	RxFrame   uint64 `json:"rx_frame_errors"`
+
+	TxBytes      uint64 `json:"tx_bytes"`
+	TxPackets    uint64 `json:"tx_packets"`
+	TxErrors     uint64 `json:"tx_errors"`
+	TxDropped    uint64 `json:"tx_dropped"`
+	TxFIFO       uint64 `json:"tx_fifo_errors"`
+	TxCollisions uint64 `json:"tx_collisions"`
+	TxCarrier    uint64 `json:"tx_carrier_errors"`
+}
+
+// InterfaceRates are per-second rates derived from two counter samples.
+type InterfaceRates struct {
+	RxBytesPerSec   float64 `json:"rx_bytes_per_sec"`
+	TxBytesPerSec   float64 `json:"tx_bytes_per_sec"`
+	RxPacketsPerSec float64 `json:"rx_packets_per_sec"`
+	TxPacketsPerSec float64 `json:"tx_packets_per_sec"`
+}
+
+// InterfaceStat is one interface's counters, with rates when sampled.
+type InterfaceStat struct {
+	InterfaceCounters
+	Rates *InterfaceRates `json:"rates,omitempty"`
+}
+
+// InterfaceStatsResult is the output of InterfaceStats.
+type InterfaceStatsResult struct {
+	Interfaces      []InterfaceStat `json:"interfaces"`
+	IntervalSeconds float64         `json:"interval_seconds"`
+	Warnings        []string        `json:"warnings"`
+}
+
+// InterfaceStats reports traffic and error counters for iface, or for every
+// interface when iface is empty. A positive interval samples the counters
+// twice, interval apart, to add byte and packet rates.
+func InterfaceStats(iface string, interval time.Duration) (*InterfaceStatsResult, error) {
+	return InterfaceStatsFrom("/proc", iface, interval)
+}
+
+// InterfaceStatsFrom is InterfaceStats against the proc tree rooted at
+// procRoot.
+func InterfaceStatsFrom(procRoot, iface string, interval time.Duration) (*InterfaceStatsResult, error) {
+	path := filepath.Join(procRoot, "net/dev")
+
+	before, err := readInterfaceCounters(path, iface)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InterfaceStatsResult{
+		Interfaces: make([]InterfaceStat, 0, len(before)),
+		Warnings:   []string{},
+	}
+
+	var after map[string]InterfaceCounters
+	if interval > 0 {
+		start := time.Now()
+		time.Sleep(interval)
+		counters, err := readInterfaceCounters(path, iface)
+		if err != nil {
+			return nil, err
+		}
+		elapsed := time.Since(start)
+		result.IntervalSeconds = elapsed.Seconds()
+
+		after = make(map[string]InterfaceCounters, len(counters))
+		for _, c := range counters {
+			after[c.Interface] = c
+		}
+	}
+
+	for _, c := range before {
+		stat := InterfaceStat{InterfaceCounters: c}
+		if later, ok := after[c.Interface]; ok {
+			rates := InterfaceRatesBetween(c, later, time.Duration(result.IntervalSeconds*float64(time.Second)))
+			stat.Rates = &rates
+			stat.InterfaceCounters = later
+		}
+		result.Warnings = append(result.Warnings, interfaceWarnings(stat.InterfaceCounters)...)
+		result.Interfaces = append(result.Interfaces, stat)
+	}
+
+	return result, nil
+}
+
+// ParseProcNetDev parses /proc/net/dev content into per-interface counters,
+// in file order.
+func ParseProcNetDev(r io.Reader) ([]InterfaceCounters, error) {
+	var out []InterfaceCounters
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			// The two header lines have no "name:" prefix.
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("malformed /proc/net/dev line for '%s': expected 16 fields, got %d",
+				strings.TrimSpace(name), len(fields))
+		}
+
+		vals := make([]uint64, 16)
+		for i := range vals {
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed counter '%s' for '%s': %w", fields[i], strings.TrimSpace(name), err)
+			}
+			vals[i] = v
+		}
+
+		// Receive: bytes packets errs drop fifo frame compressed multicast
+		// Transmit: bytes packets errs drop fifo colls carrier compressed
+		out = append(out, InterfaceCounters{
+			Interface:    strings.TrimSpace(name),
+			RxBytes:      vals[0],
+			RxPackets:    vals[1],
+			RxErrors:     vals[2],
+			RxDropped:    vals[3],
+			RxFIFO:       vals[4],
+			RxFrame:      vals[5],
+			TxBytes:      vals[8],
+			TxPackets:    vals[9],
+			TxErrors:     vals[10],
+			TxDropped:    vals[11],
+			TxFIFO:       vals[12],
+			TxCollisions: vals[13],
+			TxCarrier:    vals[14],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InterfaceRatesBetween computes per-second rates from two samples taken
+// elapsed apart. Counters that went backwards (driver reload, wrap) yield 0.
+func InterfaceRatesBetween(before, after InterfaceCounters, elapsed time.Duration) InterfaceRates {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return InterfaceRates{}
+	}
+	rate := func(a, b uint64) float64 {
+		if b < a {
+			return 0
+		}
+		return float64(b-a) / secs
+	}
+	return InterfaceRates{
+		RxBytesPerSec:   rate(before.RxBytes, after.RxBytes),
+		TxBytesPerSec:   rate(before.TxBytes, after.TxBytes),
+		RxPacketsPerSec: rate(before.RxPackets, after.RxPackets),
+		TxPacketsPerSec: rate(before.TxPackets, after.TxPackets),
+	}
+}
+
+// readInterfaceCounters parses path and keeps only iface, if given.
+func readInterfaceCounters(path, iface string) ([]InterfaceCounters, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	all, err := ParseProcNetDev(f)
+	if err != nil {
+		return nil, err
+	}
+	if iface == "" {
+		return all, nil
+	}
+	for _, c := range all {
+		if c.Interface == iface {
+			return []InterfaceCounters{c}, nil
+		}
+	}
+	return nil, fmt.Errorf("interface '%s' not found in %s", iface, path)
+}
+
+// interfaceWarnings flags non-zero error and drop counters, which usually
+// point at the NIC, driver, or ring buffer sizing rather than the network.
+func interfaceWarnings(c InterfaceCounters) []string {
+	var warnings []string
+	add := func(count uint64, what, hint string) {
+		if count > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: %d %s; %s", c.Interface, count, what, hint))
+		}
+	}
+	add(c.RxDropped, "RX drops", "the kernel or NIC ran out of buffer space; check ring sizes (ethtool -g) and softirq load")
+	add(c.RxFIFO, "RX FIFO errors", "the NIC could not hand packets to the host fast enough; consider larger RX rings")
+	add(c.RxFrame, "RX frame errors", "possible cabling, duplex mismatch, or NIC fault")
+	add(c.RxErrors, "RX errors", "check driver and NIC health (ethtool -S)")
+	add(c.TxDropped, "TX drops", "the transmit queue overflowed; check qdisc and txqueuelen")
+	add(c.TxFIFO, "TX FIFO errors", "the NIC could not drain its transmit queue")
+	add(c.TxCarrier, "TX carrier errors", "link flapping or duplex mismatch")
+	add(c.TxErrors, "TX errors", "check driver and NIC health (ethtool -S)")
+	return warnings
+}
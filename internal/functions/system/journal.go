@@ -0,0 +1,197 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits and defaults for ServiceLogs.
+const (
+	defaultServiceLogLines = 100
+	maxServiceLogLines     = 1000
+	defaultServiceLogSince = "1 hour ago"
+	journalctlTimeout      = 30 * time.Second
+)
+
+// serviceNameRegex matches a systemd unit name. It cannot start with "-",
+// so a name is never taken for a journalctl flag.
+var serviceNameRegex = regexp.MustCompile(`^[A-Za-z0-9_@:.\\][A-Za-z0-9_@:.\\-]*$`)
+
+// sinceRegex matches the journalctl --since forms: "2024-05-01 10:00:00",
+// "yesterday", "-15m", "1 hour ago".
+var sinceRegex = regexp.MustCompile(`^[A-Za-z0-9 :.+-]+$`)
+
+// journalPriorityNames are the syslog priority names, indexed by level.
+var journalPriorityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// journalErrorPriority is the least severe priority counted as an error.
+const journalErrorPriority = 3
+
+// LogEntry is one journal entry.
+type LogEntry struct {
+	Timestamp    string `json:"timestamp"`
+	Priority     int    `json:"priority"`
+	PriorityName string `json:"priority_name"`
+	Message      string `json:"message"`
+	// Error is set for priorities err and more severe.
+	Error bool `json:"error"`
+}
+
+// ServiceLogsResult is the output of ServiceLogs.
+type ServiceLogsResult struct {
+	Service    string     `json:"service"`
+	Since      string     `json:"since"`
+	Lines      int        `json:"lines"`
+	Entries    []LogEntry `json:"entries"`
+	EntryCount int        `json:"entry_count"`
+	ErrorCount int        `json:"error_count"`
+	Warnings   []string   `json:"warnings"`
+}
+
+// ServiceLogs returns the last lines (default 100, at most 1000) journal
+// entries for a systemd service since the given time (default "1 hour
+// ago"), flagging error-priority entries.
+func ServiceLogs(service string, lines int, since string) (*ServiceLogsResult, error) {
+	if !serviceNameRegex.MatchString(service) {
+		return nil, fmt.Errorf("invalid service name '%s'", service)
+	}
+	since = strings.TrimSpace(since)
+	if since == "" {
+		since = defaultServiceLogSince
+	}
+	if !sinceRegex.MatchString(since) {
+		return nil, fmt.Errorf("invalid since '%s': use a time like '2024-05-01 10:00', 'yesterday' or '1 hour ago'", since)
+	}
+	if lines <= 0 {
+		lines = defaultServiceLogLines
+	}
+	if lines > maxServiceLogLines {
+		lines = maxServiceLogLines
+	}
+
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		return nil, errors.New("journalctl not found: service_logs needs systemd's journal")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), journalctlTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "journalctl",
+		"-u", service, "-n", strconv.Itoa(lines), "--since", since, "-o", "json", "--no-pager")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("journalctl failed: %s", msg)
+		}
+		return nil, fmt.Errorf("journalctl failed: %w", err)
+	}
+
+	entries, warnings := ParseJournalJSON(output)
+	result := &ServiceLogsResult{
+		Service:  service,
+		Since:    since,
+		Lines:    lines,
+		Entries:  entries,
+		Warnings: warnings,
+	}
+	result.EntryCount = len(entries)
+	for _, e := range entries {
+		if e.Error {
+			result.ErrorCount++
+		}
+	}
+	if result.EntryCount == 0 {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("no journal entries for '%s' since %s; check the unit name with systemctl list-units", service, since))
+	}
+	return result, nil
+}
+
+// journalRecord holds the journal fields ServiceLogs reports. MESSAGE is
+// usually a string but is an array of bytes when it is not valid UTF-8.
+type journalRecord struct {
+	RealtimeTimestamp string          `json:"__REALTIME_TIMESTAMP"`
+	Priority          string          `json:"PRIORITY"`
+	Message           json.RawMessage `json:"MESSAGE"`
+}
+
+// ParseJournalJSON parses journalctl -o json output, one JSON object per
+// line, into entries in the order given. Lines that cannot be parsed are
+// skipped and reported as warnings. Entries without a priority are taken
+// as info, the journal's default.
+func ParseJournalJSON(output []byte) ([]LogEntry, []string) {
+	entries := []LogEntry{}
+	warnings := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	// Journal entries can carry long messages.
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec journalRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			warnings = append(warnings, fmt.Sprintf("line %d: not a journal entry: %v", lineNo, err))
+			continue
+		}
+
+		priority := 6
+		if p, err := strconv.Atoi(rec.Priority); err == nil && p >= 0 && p < len(journalPriorityNames) {
+			priority = p
+		}
+		entries = append(entries, LogEntry{
+			Timestamp:    journalTimestamp(rec.RealtimeTimestamp),
+			Priority:     priority,
+			PriorityName: journalPriorityNames[priority],
+			Message:      journalMessage(rec.Message),
+			Error:        priority <= journalErrorPriority,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		warnings = append(warnings, fmt.Sprintf("reading journal output: %v", err))
+	}
+	return entries, warnings
+}
+
+// journalTimestamp converts __REALTIME_TIMESTAMP, microseconds since the
+// epoch, to RFC 3339 in UTC. An unparsable value is returned as is.
+func journalTimestamp(us string) string {
+	n, err := strconv.ParseInt(us, 10, 64)
+	if err != nil {
+		return us
+	}
+	return time.UnixMicro(n).UTC().Format(time.RFC3339Nano)
+}
+
+// journalMessage decodes MESSAGE as a string or as an array of bytes.
+func journalMessage(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err != nil {
+		return ""
+	}
+	b := make([]byte, len(ints))
+	for i, v := range ints {
+		b[i] = byte(v)
+	}
+	return strings.ToValidUTF8(string(b), "\uFFFD")
+}
@@ -0,0 +1,72 @@
+package system
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// defaultKeepaliveTime is the kernel's stock tcp_keepalive_time: two hours
+// before the first probe, far too slow to notice a dead peer in an RPC path.
+const defaultKeepaliveTime = 7200
+
+// TCPTuning holds the kernel settings that govern how quickly TCP notices a
+// dead peer or gives up on a connect. Fields that could not be read are -1.
+type TCPTuning struct {
+	KeepaliveTime   int `json:"keepalive_time"`
+	KeepaliveIntvl  int `json:"keepalive_intvl"`
+	KeepaliveProbes int `json:"keepalive_probes"`
+	SynRetries      int `json:"syn_retries"`
+
+	// KeepaliveDetectionSeconds is the worst-case time to declare an idle
+	// peer dead: time + intvl*probes.
+	KeepaliveDetectionSeconds int `json:"keepalive_detection_seconds"`
+	// SynTimeoutSeconds is how long connect() retries SYNs before failing,
+	// assuming the 1s initial RTO doubling on each retry.
+	SynTimeoutSeconds int `json:"syn_timeout_seconds"`
+
+	Warnings []string `json:"warnings"`
+}
+
+// InspectTCPTuning reads keepalive and SYN retry settings from the live system.
+func InspectTCPTuning() *TCPTuning {
+	return InspectTCPTuningFrom("/proc")
+}
+
+// InspectTCPTuningFrom reads the settings from the proc tree rooted at
+// procRoot. Unreadable values are reported as -1 rather than failing, since
+// these only add context to the per-connection stats.
+func InspectTCPTuningFrom(procRoot string) *TCPTuning {
+	read := func(name string) int {
+		val, err := readProcValue(filepath.Join(procRoot, "sys/net/ipv4", name))
+		if err != nil {
+			return -1
+		}
+		return val
+	}
+
+	t := &TCPTuning{
+		KeepaliveTime:             read("tcp_keepalive_time"),
+		KeepaliveIntvl:            read("tcp_keepalive_intvl"),
+		KeepaliveProbes:           read("tcp_keepalive_probes"),
+		SynRetries:                read("tcp_syn_retries"),
+		KeepaliveDetectionSeconds: -1,
+		SynTimeoutSeconds:         -1,
+		Warnings:                  []string{},
+	}
+
+	if t.KeepaliveTime >= 0 && t.KeepaliveIntvl >= 0 && t.KeepaliveProbes >= 0 {
+		t.KeepaliveDetectionSeconds = t.KeepaliveTime + t.KeepaliveIntvl*t.KeepaliveProbes
+	}
+	if t.SynRetries >= 0 && t.SynRetries < 31 {
+		t.SynTimeoutSeconds = 1<<(t.SynRetries+1) - 1
+	}
+
+	if t.KeepaliveTime >= defaultKeepaliveTime {
+		t.Warnings = append(t.Warnings, fmt.Sprintf(
+			"tcp_keepalive_time is %ds; a dead peer on an idle connection can go unnoticed for hours. "+
+				"Latency-sensitive services should lower it (e.g. 60-300s) or set TCP_KEEPIDLE per socket",
+			t.KeepaliveTime))
+	}
+
+	return t
+}
@@ -0,0 +1,160 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Bind scopes reported in ListeningSocket.Bind.
+const (
+	BindLoopbackOnly  = "loopback_only"
+	BindAllInterfaces = "all_interfaces"
+	BindSpecificIP    = "specific_ip"
+)
+
+// reSSProcess matches the first process in ss -p's users:(("sshd",pid=900,fd=3)).
+var reSSProcess = regexp.MustCompile(`users:\(\("([^"]*)",pid=(\d+)`)
+
+// ListeningSocket is one TCP listener.
+type ListeningSocket struct {
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	// Bind is loopback_only, all_interfaces (0.0.0.0, ::, *) or
+	// specific_ip.
+	Bind    string `json:"bind"`
+	Process string `json:"process,omitempty"`
+	PID     int    `json:"pid,omitempty"`
+	// PotentialExposure is set for listeners on all interfaces, which
+	// accept connections from any network the host is on.
+	PotentialExposure bool `json:"potential_exposure"`
+}
+
+// ListeningSocketsResult is the output of ListeningSockets.
+type ListeningSocketsResult struct {
+	Port    int               `json:"port,omitempty"`
+	Sockets []ListeningSocket `json:"sockets"`
+	// Exposed lists the listeners on all interfaces as "process addr:port".
+	Exposed  []string `json:"exposed"`
+	Warnings []string `json:"warnings"`
+}
+
+// ListeningSockets lists the TCP listeners reported by ss -tlnp, only those
+// on filterPort when it is positive, and classifies each by the addresses
+// it is bound to, flagging the ones on all interfaces. Linux only.
+func ListeningSockets(filterPort int) (*ListeningSocketsResult, error) {
+	return ListeningSocketsContext(context.Background(), filterPort)
+}
+
+// ListeningSocketsContext is ListeningSockets, stopped if ctx is cancelled.
+func ListeningSocketsContext(ctx context.Context, filterPort int) (*ListeningSocketsResult, error) {
+	if filterPort < 0 || filterPort > 65535 {
+		return nil, fmt.Errorf("invalid port %d", filterPort)
+	}
+	out, err := exec.CommandContext(ctx, "ss", "-tlnp").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ss -tlnp failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return ListeningSocketsFrom(string(out), filterPort)
+}
+
+// ListeningSocketsFrom is ListeningSockets over ss -tlnp output already
+// collected.
+func ListeningSocketsFrom(output string, filterPort int) (*ListeningSocketsResult, error) {
+	sockets, err := ParseListeningSockets(output)
+	if err != nil {
+		return nil, err
+	}
+	result := &ListeningSocketsResult{
+		Port:     filterPort,
+		Sockets:  []ListeningSocket{},
+		Exposed:  []string{},
+		Warnings: []string{},
+	}
+	unnamed := 0
+	for _, s := range sockets {
+		if filterPort > 0 && s.Port != filterPort {
+			continue
+		}
+		result.Sockets = append(result.Sockets, s)
+		if s.Process == "" {
+			unnamed++
+		}
+		if s.PotentialExposure {
+			name := s.Process
+			if name == "" {
+				name = "unknown process"
+			}
+			result.Exposed = append(result.Exposed, fmt.Sprintf("%s %s", name, net.JoinHostPort(s.Address, strconv.Itoa(s.Port))))
+		}
+	}
+	if unnamed > 0 {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("%d listener(s) have no process name; ss only shows other users' processes to root", unnamed))
+	}
+	if len(result.Exposed) > 0 {
+		result.Warnings = append(result.Warnings,
+			"listeners on all interfaces accept connections from every network the host is on; bind to 127.0.0.1 or a specific address if they are only used locally")
+	}
+	return result, nil
+}
+
+// ParseListeningSockets parses ss -tln or ss -tlnp output, such as
+// "LISTEN 0 128 0.0.0.0:22 0.0.0.0:* users:(("sshd",pid=900,fd=3))".
+// The header and any leading Netid column are skipped.
+func ParseListeningSockets(output string) ([]ListeningSocket, error) {
+	sockets := []ListeningSocket{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] != "LISTEN" {
+			fields = fields[1:] // Netid, or a header
+		}
+		if len(fields) < 4 || fields[0] != "LISTEN" {
+			continue
+		}
+
+		local := fields[3]
+		i := strings.LastIndex(local, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("unexpected local address %q in ss output", local)
+		}
+		port, err := strconv.Atoi(local[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected port in local address %q: %w", local, err)
+		}
+		addr := strings.TrimSuffix(strings.TrimPrefix(local[:i], "["), "]")
+		if zone := strings.Index(addr, "%"); zone >= 0 {
+			addr = addr[:zone] // 127.0.0.53%lo
+		}
+
+		s := ListeningSocket{Address: addr, Port: port, Bind: bindScope(addr)}
+		s.PotentialExposure = s.Bind == BindAllInterfaces
+		if m := reSSProcess.FindStringSubmatch(line); m != nil {
+			s.Process = m[1]
+			s.PID, _ = strconv.Atoi(m[2])
+		}
+		sockets = append(sockets, s)
+	}
+	return sockets, nil
+}
+
+// bindScope classifies a listener's bind address.
+func bindScope(addr string) string {
+	switch addr {
+	case "", "*", "0.0.0.0", "::":
+		return BindAllInterfaces
+	}
+	if ip := net.ParseIP(addr); ip != nil {
+		if ip.IsUnspecified() {
+			return BindAllInterfaces
+		}
+		if ip.IsLoopback() {
+			return BindLoopbackOnly
+		}
+	}
+	return BindSpecificIP
+}
@@ -0,0 +1,257 @@
+package system
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits and defaults for ScanLog.
+const (
+	defaultScanLogLines = 1000
+	maxScanLogLines     = 100000
+	maxScanLogPatterns  = 10
+	// maxLogRotations is the highest rotation suffix followed: path.1
+	// through path.9, each plain or gzipped.
+	maxLogRotations = 9
+	// logMatchContext is how many lines before and after a match are kept.
+	logMatchContext = 2
+	// maxLogMatches caps the matches returned; counts cover every match.
+	maxLogMatches = 50
+	// maxLogLineBytes caps a single log line; longer lines are an error.
+	maxLogLineBytes = 1 << 20
+)
+
+// LogMatch is one line that matched a ScanLog pattern.
+type LogMatch struct {
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Pattern string   `json:"pattern"`
+	Text    string   `json:"text"`
+	Before  []string `json:"before,omitempty"`
+	After   []string `json:"after,omitempty"`
+}
+
+// LogScanResult is the output of ScanLog.
+type LogScanResult struct {
+	Path string `json:"path"`
+	// Files are the files read, oldest first.
+	Files        []string `json:"files"`
+	LinesScanned int      `json:"lines_scanned"`
+	// Counts is the number of lines each pattern matched.
+	Counts       map[string]int `json:"counts"`
+	TotalMatches int            `json:"total_matches"`
+	// Matches are the first maxLogMatches matches, oldest first, with
+	// context; Truncated is set when there were more.
+	Matches   []LogMatch `json:"matches"`
+	Truncated bool       `json:"truncated"`
+	Warnings  []string   `json:"warnings"`
+}
+
+// logLine is a line read from a log file with its 1-based line number.
+type logLine struct {
+	file string
+	num  int
+	text string
+}
+
+// ScanLog counts the lines among the last lines (default 1000, at most
+// 100000) of the log at path that match each regex in patterns, returning
+// the first matches with surrounding context. A ".gz" file is
+// decompressed. When the file has fewer lines than asked for, older
+// rotations are read in turn: path.1, path.2.gz and so on, each plain or
+// gzipped.
+func ScanLog(path string, patterns []string, lines int) (*LogScanResult, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	if len(patterns) == 0 {
+		return nil, errors.New("at least one pattern is required")
+	}
+	if len(patterns) > maxScanLogPatterns {
+		return nil, fmt.Errorf("at most %d patterns can be scanned for, got %d", maxScanLogPatterns, len(patterns))
+	}
+	regexes := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+		regexes[i] = re
+	}
+	if lines <= 0 {
+		lines = defaultScanLogLines
+	}
+	if lines > maxScanLogLines {
+		lines = maxScanLogLines
+	}
+
+	result := &LogScanResult{
+		Path:     path,
+		Files:    []string{},
+		Counts:   make(map[string]int, len(patterns)),
+		Matches:  []LogMatch{},
+		Warnings: []string{},
+	}
+	for _, p := range patterns {
+		result.Counts[p] = 0
+	}
+
+	window, err := tailRotated(path, lines, result)
+	if err != nil {
+		return nil, err
+	}
+	result.LinesScanned = len(window)
+
+	for i, l := range window {
+		for j, re := range regexes {
+			if !re.MatchString(l.text) {
+				continue
+			}
+			result.Counts[patterns[j]]++
+			result.TotalMatches++
+			if len(result.Matches) == maxLogMatches {
+				result.Truncated = true
+				continue
+			}
+			result.Matches = append(result.Matches, LogMatch{
+				File:    l.file,
+				Line:    l.num,
+				Pattern: patterns[j],
+				Text:    l.text,
+				Before:  contextLines(window, i-logMatchContext, i),
+				After:   contextLines(window, i+1, i+1+logMatchContext),
+			})
+		}
+	}
+	return result, nil
+}
+
+// tailRotated returns the last n lines of path and, when it is too short,
+// of its rotations, oldest first. It records the files read in result.
+func tailRotated(path string, n int, result *LogScanResult) ([]logLine, error) {
+	var chunks [][]logLine
+	remaining := n
+	for rotation := 0; rotation <= maxLogRotations && remaining > 0; rotation++ {
+		file, err := rotatedLogFile(path, rotation)
+		if errors.Is(err, fs.ErrNotExist) {
+			if rotation == 0 {
+				return nil, fmt.Errorf("cannot read log '%s': %w", path, err)
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		tail, err := tailFile(file, remaining)
+		if err != nil {
+			if rotation == 0 {
+				return nil, err
+			}
+			result.Warnings = append(result.Warnings, fmt.Sprintf("stopped at rotated log: %v", err))
+			break
+		}
+		chunks = append(chunks, tail)
+		result.Files = append([]string{file}, result.Files...)
+		remaining -= len(tail)
+	}
+
+	window := make([]logLine, 0, n-remaining)
+	for i := len(chunks) - 1; i >= 0; i-- {
+		window = append(window, chunks[i]...)
+	}
+	return window, nil
+}
+
+// rotatedLogFile returns the name of rotation n of path: path itself for
+// 0, otherwise path.n or path.n.gz, whichever exists. The error wraps
+// fs.ErrNotExist when neither does.
+func rotatedLogFile(path string, n int) (string, error) {
+	candidates := []string{path}
+	if n > 0 {
+		base := path + "." + strconv.Itoa(n)
+		candidates = []string{base, base + ".gz"}
+	}
+	for _, c := range candidates {
+		info, err := os.Stat(c)
+		if err == nil {
+			if info.IsDir() {
+				return "", fmt.Errorf("'%s' is a directory, not a log file", c)
+			}
+			return c, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("cannot read log '%s': %w", c, err)
+		}
+	}
+	return "", fmt.Errorf("no rotation %d of '%s': %w", n, path, fs.ErrNotExist)
+}
+
+// tailFile returns the last n lines of file, decompressing it if its name
+// ends in ".gz".
+func tailFile(file string, n int) ([]logLine, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read log '%s': %w", file, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress '%s': %w", file, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	// A ring of the last n lines, so a large file is read in one pass
+	// without holding all of it.
+	ring := make([]logLine, 0, n)
+	next := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineBytes)
+	num := 0
+	for scanner.Scan() {
+		num++
+		l := logLine{file: file, num: num, text: scanner.Text()}
+		if len(ring) < n {
+			ring = append(ring, l)
+			continue
+		}
+		ring[next] = l
+		next = (next + 1) % n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read log '%s': %w", file, err)
+	}
+	tail := make([]logLine, 0, len(ring))
+	tail = append(tail, ring[next:]...)
+	return append(tail, ring[:next]...), nil
+}
+
+// contextLines returns the text of window[from:to], clamped to window.
+func contextLines(window []logLine, from, to int) []string {
+	if from < 0 {
+		from = 0
+	}
+	if to > len(window) {
+		to = len(window)
+	}
+	if from >= to {
+		return nil
+	}
+	out := make([]string, 0, to-from)
+	for _, l := range window[from:to] {
+		out = append(out, l.text)
+	}
+	return out
+}
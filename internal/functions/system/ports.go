@@ -0,0 +1,166 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Thresholds on the fraction of the ephemeral port range held in TIME-WAIT.
+const (
+	ephemeralWarnFraction     = 0.70
+	ephemeralCriticalFraction = 0.90
+)
+
+// Widest safe ephemeral range: everything above the privileged ports.
+const (
+	widestPortRangeLow  = 1024
+	widestPortRangeHigh = 65535
+)
+
+// EphemeralPortDiagnosis describes how much of the local port range is tied up
+// by sockets in TIME-WAIT. TWReuse is -1 when tcp_tw_reuse could not be read.
+type EphemeralPortDiagnosis struct {
+	RangeLow        int                    `json:"range_low"`
+	RangeHigh       int                    `json:"range_high"`
+	RangeSize       int                    `json:"range_size"`
+	TimeWait        int                    `json:"time_wait"`
+	InUse           int                    `json:"in_use"`
+	UsedFraction    float64                `json:"used_fraction"`
+	TWReuse         int                    `json:"tcp_tw_reuse"`
+	Status          string                 `json:"status"`
+	Warnings        []string               `json:"warnings"`
+	Recommendations []BufferRecommendation `json:"recommendations"`
+}
+
+// DiagnoseEphemeralPorts checks the live system for ephemeral port exhaustion.
+func DiagnoseEphemeralPorts() (*EphemeralPortDiagnosis, error) {
+	return DiagnoseEphemeralPortsFrom("/proc")
+}
+
+// DiagnoseEphemeralPortsFrom reads ip_local_port_range, tcp_tw_reuse, and the
+// TCP socket counters from the proc tree rooted at procRoot, so tests can point
+// it at fixtures.
+func DiagnoseEphemeralPortsFrom(procRoot string) (*EphemeralPortDiagnosis, error) {
+	portRange, err := readProcTuple(filepath.Join(procRoot, "sys/net/ipv4/ip_local_port_range"))
+	if err != nil {
+		return nil, err
+	}
+	if len(portRange) != 2 || portRange[1] < portRange[0] {
+		return nil, fmt.Errorf("unexpected ip_local_port_range: %v", portRange)
+	}
+
+	counters, err := readSockstatTCP(filepath.Join(procRoot, "net/sockstat"))
+	if err != nil {
+		return nil, err
+	}
+
+	twReuse, err := readProcValue(filepath.Join(procRoot, "sys/net/ipv4/tcp_tw_reuse"))
+	if err != nil {
+		twReuse = -1
+	}
+
+	d := &EphemeralPortDiagnosis{
+		RangeLow:        portRange[0],
+		RangeHigh:       portRange[1],
+		RangeSize:       portRange[1] - portRange[0] + 1,
+		TimeWait:        counters["tw"],
+		InUse:           counters["inuse"],
+		TWReuse:         twReuse,
+		Status:          "ok",
+		Warnings:        []string{},
+		Recommendations: []BufferRecommendation{},
+	}
+	d.UsedFraction = float64(d.TimeWait) / float64(d.RangeSize)
+
+	switch {
+	case d.UsedFraction >= ephemeralCriticalFraction:
+		d.Status = "critical"
+	case d.UsedFraction >= ephemeralWarnFraction:
+		d.Status = "warning"
+	default:
+		return d, nil
+	}
+
+	d.Warnings = append(d.Warnings, fmt.Sprintf(
+		"%d sockets in TIME-WAIT hold %.0f%% of the %d-port ephemeral range; new outbound connections may fail with EADDRNOTAVAIL",
+		d.TimeWait, d.UsedFraction*100, d.RangeSize))
+	d.Recommendations = ephemeralRecommendations(d)
+	return d, nil
+}
+
+// ephemeralRecommendations proposes widening the port range and enabling
+// TIME-WAIT reuse for outbound connections, skipping whichever is already in
+// place.
+func ephemeralRecommendations(d *EphemeralPortDiagnosis) []BufferRecommendation {
+	var recs []BufferRecommendation
+
+	if d.RangeLow > widestPortRangeLow || d.RangeHigh < widestPortRangeHigh {
+		param := "net.ipv4.ip_local_port_range"
+		value := fmt.Sprintf("%d %d", widestPortRangeLow, widestPortRangeHigh)
+		recs = append(recs, BufferRecommendation{
+			Parameter:     param,
+			Current:       fmt.Sprintf("%d %d", d.RangeLow, d.RangeHigh),
+			Recommended:   value,
+			SysctlCommand: fmt.Sprintf("sysctl -w '%s=%s'", param, value),
+			Reason: fmt.Sprintf("widens the ephemeral range from %d to %d ports",
+				d.RangeSize, widestPortRangeHigh-widestPortRangeLow+1),
+		})
+	}
+
+	if d.TWReuse != 1 {
+		param := "net.ipv4.tcp_tw_reuse"
+		current := strconv.Itoa(d.TWReuse)
+		if d.TWReuse < 0 {
+			current = "unknown"
+		}
+		recs = append(recs, BufferRecommendation{
+			Parameter:     param,
+			Current:       current,
+			Recommended:   "1",
+			SysctlCommand: fmt.Sprintf("sysctl -w %s=1", param),
+			Reason:        "lets new outbound connections reuse ports still in TIME-WAIT",
+		})
+	}
+
+	if recs == nil {
+		recs = []BufferRecommendation{}
+	}
+	return recs
+}
+
+// readSockstatTCP parses the "TCP:" line of /proc/net/sockstat, e.g.
+// "TCP: inuse 27 orphan 0 tw 14 alloc 31 mem 3", into a counter map.
+func readSockstatTCP(path string) (map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "TCP:" {
+			continue
+		}
+
+		counters := make(map[string]int)
+		for i := 1; i+1 < len(fields); i += 2 {
+			val, err := strconv.Atoi(fields[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse '%s' from %s: %w", fields[i], path, err)
+			}
+			counters[fields[i]] = val
+		}
+		return counters, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+
+	return nil, fmt.Errorf("no TCP line in %s", path)
+}
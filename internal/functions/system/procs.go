@@ -0,0 +1,307 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sort keys accepted by TopProcesses.
+const (
+	SortByCPU = "cpu"
+	SortByMem = "mem"
+)
+
+// topSampleInterval is how long TopProcesses measures CPU usage over.
+const topSampleInterval = time.Second
+
+// maxTopProcesses caps the limit accepted by TopProcesses.
+const maxTopProcesses = 100
+
+// ProcessInfo is one process's resource usage.
+type ProcessInfo struct {
+	PID  int    `json:"pid"`
+	Name string `json:"name"`
+	// CPUPercent is the share of one CPU used over the sample interval, as
+	// top reports it: a process busy on two cores shows 200.
+	CPUPercent float64 `json:"cpu_percent"`
+	RSSKB      uint64  `json:"rss_kb"`
+	User       string  `json:"user"`
+}
+
+// TopProcessesResult is the output of TopProcesses.
+type TopProcessesResult struct {
+	SortBy          string        `json:"sort_by"`
+	IntervalSeconds float64       `json:"interval_seconds"`
+	TotalProcesses  int           `json:"total_processes"`
+	Processes       []ProcessInfo `json:"processes"`
+	Warnings        []string      `json:"warnings"`
+}
+
+// ProcessSample is a snapshot of per-process CPU time and memory, plus the
+// machine-wide CPU time it is measured against.
+type ProcessSample struct {
+	procs map[int]procStat
+	// totalTicks is the sum of all CPU time in /proc/stat, across all CPUs.
+	totalTicks uint64
+	cpus       int
+}
+
+type procStat struct {
+	name  string
+	ticks uint64 // utime + stime
+	rss   uint64 // kB
+	uid   string
+}
+
+// TopProcesses returns the limit busiest processes by CPU ("cpu") or
+// resident memory ("mem"), read straight from /proc without top or ps.
+// CPU usage is measured over a one-second sample.
+func TopProcesses(sortBy string, limit int) (*TopProcessesResult, error) {
+	return TopProcessesFrom("/proc", sortBy, limit, topSampleInterval)
+}
+
+// TopProcessesFrom is TopProcesses against the proc tree rooted at procRoot,
+// sampling CPU over interval. With a zero interval every CPU percentage is 0.
+func TopProcessesFrom(procRoot, sortBy string, limit int, interval time.Duration) (*TopProcessesResult, error) {
+	if err := checkTopArgs(sortBy, limit); err != nil {
+		return nil, err
+	}
+
+	before, err := SampleProcessesFrom(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	after := before
+	var elapsed time.Duration
+	if interval > 0 {
+		start := time.Now()
+		time.Sleep(interval)
+		if after, err = SampleProcessesFrom(procRoot); err != nil {
+			return nil, err
+		}
+		elapsed = time.Since(start)
+	}
+
+	procs, err := RankProcesses(before, after, sortBy, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TopProcessesResult{
+		SortBy:          sortBy,
+		IntervalSeconds: elapsed.Seconds(),
+		TotalProcesses:  len(after.procs),
+		Processes:       procs,
+		Warnings:        []string{},
+	}
+	if len(after.procs) == 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("no readable processes under %s", procRoot))
+	}
+	return result, nil
+}
+
+// SampleProcessesFrom reads CPU time and memory for every process under
+// procRoot. Processes that exit or cannot be read mid-scan are skipped.
+func SampleProcessesFrom(procRoot string) (*ProcessSample, error) {
+	total, cpus, err := readCPUTicks(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", procRoot, err)
+	}
+
+	sample := &ProcessSample{procs: make(map[int]procStat), totalTicks: total, cpus: cpus}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		p, err := readProcStat(filepath.Join(procRoot, e.Name()))
+		if err != nil {
+			continue
+		}
+		sample.procs[pid] = p
+	}
+	return sample, nil
+}
+
+// RankProcesses computes each process's usage between two samples and
+// returns the top limit by sortBy. Processes missing from after have exited
+// and are left out. Ties are broken by the other key, then by PID.
+func RankProcesses(before, after *ProcessSample, sortBy string, limit int) ([]ProcessInfo, error) {
+	if err := checkTopArgs(sortBy, limit); err != nil {
+		return nil, err
+	}
+
+	// CPU time available to one CPU over the interval.
+	var perCPU float64
+	if after.totalTicks > before.totalTicks && after.cpus > 0 {
+		perCPU = float64(after.totalTicks-before.totalTicks) / float64(after.cpus)
+	}
+
+	names := make(map[string]string)
+	procs := make([]ProcessInfo, 0, len(after.procs))
+	for pid, p := range after.procs {
+		info := ProcessInfo{PID: pid, Name: p.name, RSSKB: p.rss, User: userName(p.uid, names)}
+		if prev, ok := before.procs[pid]; ok && perCPU > 0 && p.ticks >= prev.ticks {
+			info.CPUPercent = math.Round(float64(p.ticks-prev.ticks)/perCPU*10000) / 100
+		}
+		procs = append(procs, info)
+	}
+
+	sort.Slice(procs, func(i, j int) bool {
+		a, b := procs[i], procs[j]
+		cpu, mem := a.CPUPercent != b.CPUPercent, a.RSSKB != b.RSSKB
+		switch {
+		case sortBy == SortByCPU && cpu:
+			return a.CPUPercent > b.CPUPercent
+		case sortBy == SortByMem && mem:
+			return a.RSSKB > b.RSSKB
+		case cpu:
+			return a.CPUPercent > b.CPUPercent
+		case mem:
+			return a.RSSKB > b.RSSKB
+		}
+		return a.PID < b.PID
+	})
+
+	if len(procs) > limit {
+		procs = procs[:limit]
+	}
+	return procs, nil
+}
+
+func checkTopArgs(sortBy string, limit int) error {
+	if sortBy != SortByCPU && sortBy != SortByMem {
+		return fmt.Errorf("sort_by must be '%s' or '%s', got '%s'", SortByCPU, SortByMem, sortBy)
+	}
+	if limit <= 0 || limit > maxTopProcesses {
+		return fmt.Errorf("limit must be between 1 and %d, got %d", maxTopProcesses, limit)
+	}
+	return nil
+}
+
+// readCPUTicks returns the total CPU time from the "cpu" line of /proc/stat
+// and the number of per-CPU lines.
+func readCPUTicks(path string) (uint64, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var total uint64
+	cpus := 0
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		if fields[0] != "cpu" {
+			cpus++
+			continue
+		}
+		// user nice system idle iowait irq softirq steal; guest time is
+		// already counted in user.
+		for _, v := range fields[1:min(len(fields), 9)] {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("malformed cpu line in %s: %w", path, err)
+			}
+			total += n
+		}
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("no cpu line in %s", path)
+	}
+	return total, max(cpus, 1), nil
+}
+
+// readProcStat reads one process's CPU time from stat and its name, RSS, and
+// real UID from status.
+func readProcStat(dir string) (procStat, error) {
+	var p procStat
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return p, err
+	}
+	// The command name is in parentheses and may itself contain spaces or
+	// parentheses, so fields are counted from the last ")".
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return p, fmt.Errorf("malformed %s/stat", dir)
+	}
+	rest := strings.Fields(string(stat[end+1:]))
+	// rest[0] is field 3 (state); utime and stime are fields 14 and 15.
+	if len(rest) < 13 {
+		return p, fmt.Errorf("malformed %s/stat", dir)
+	}
+	utime, err1 := strconv.ParseUint(rest[11], 10, 64)
+	stime, err2 := strconv.ParseUint(rest[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return p, fmt.Errorf("malformed %s/stat", dir)
+	}
+	p.ticks = utime + stime
+	if start := strings.IndexByte(string(stat), '('); start >= 0 && start < end {
+		p.name = string(stat[start+1 : end])
+	}
+
+	status, err := os.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return p, err
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch key {
+		case "Name":
+			p.name = strings.TrimSpace(value)
+		case "VmRSS":
+			p.rss, _ = strconv.ParseUint(fields[0], 10, 64)
+		case "Uid":
+			p.uid = fields[0]
+		}
+	}
+	return p, nil
+}
+
+// userName resolves uid to a user name, caching lookups in cache. Unknown
+// UIDs are returned as-is.
+func userName(uid string, cache map[string]string) string {
+	if uid == "" {
+		return ""
+	}
+	if name, ok := cache[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	cache[uid] = name
+	return name
+}
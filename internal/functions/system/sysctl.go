@@ -142,6 +142,8 @@ func ExecuteSysctl(parameter string, value string, persist bool) (map[string]int
 		"new_value": newValue,
 		"success":   true,
 		"persisted": persisted,
+		// The running kernel was written, even if to the value it had.
+		"side_effect": true,
 	}
 
 	if persistErr != "" {
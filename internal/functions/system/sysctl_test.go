@@ -305,6 +305,9 @@ func TestExecuteSysctl_LiveRead_NonDestructive(t *testing.T) {
 	if persisted, ok := result["persisted"].(bool); !ok || persisted {
 		t.Errorf("expected persisted=false, got: %v", result["persisted"])
 	}
+	if sideEffect, ok := result["side_effect"].(bool); !ok || !sideEffect {
+		t.Errorf("expected side_effect=true for an applied change, got: %v", result["side_effect"])
+	}
 
 	t.Logf("old_value=%v new_value=%v", result["old_value"], result["new_value"])
 }
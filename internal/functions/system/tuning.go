@@ -0,0 +1,78 @@
+package system
+
+import (
+	"fmt"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// BufferRecommendation is a single concrete sysctl change proposed by
+// RecommendBufferTuning or DiagnoseEphemeralPorts. Current and Recommended
+// are formatted exactly as sysctl expects them, so Recommended can be
+// passed straight to execute_sysctl_command as its value. Reason, when
+// set, says why the change helps.
+type BufferRecommendation struct {
+	Parameter     string `json:"parameter"`
+	Current       string `json:"current"`
+	Recommended   string `json:"recommended"`
+	SysctlCommand string `json:"sysctl_command"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// RecommendBufferTuning compares the buffer settings reported by
+// InspectNetworkBuffers against the bandwidth-delay product for rttMs and
+// returns the sysctl changes needed to reach it. Settings that already meet
+// the target produce no entry, so an empty result means nothing to tune.
+func RecommendBufferTuning(current map[string]interface{}, rttMs float64) []BufferRecommendation {
+	target := network.CalculateRecommendedBuffer(rttMs)
+	recs := []BufferRecommendation{}
+
+	for _, key := range []string{"rmem_max", "wmem_max"} {
+		cur, ok := intValue(current[key])
+		if !ok || cur >= target {
+			continue
+		}
+		param := "net.core." + key
+		value := fmt.Sprintf("%d", target)
+		recs = append(recs, BufferRecommendation{
+			Parameter:     param,
+			Current:       fmt.Sprintf("%d", cur),
+			Recommended:   value,
+			SysctlCommand: fmt.Sprintf("sysctl -w %s=%s", param, value),
+		})
+	}
+
+	// tcp_rmem/tcp_wmem are min/default/max triples; only the max is raised.
+	for _, key := range []string{"tcp_rmem", "tcp_wmem"} {
+		minVal, okMin := intValue(current[key+"_min"])
+		defVal, okDef := intValue(current[key+"_default"])
+		maxVal, okMax := intValue(current[key+"_max"])
+		if !okMin || !okDef || !okMax || maxVal >= target {
+			continue
+		}
+		param := "net.ipv4." + key
+		value := fmt.Sprintf("%d %d %d", minVal, defVal, target)
+		recs = append(recs, BufferRecommendation{
+			Parameter:     param,
+			Current:       fmt.Sprintf("%d %d %d", minVal, defVal, maxVal),
+			Recommended:   value,
+			SysctlCommand: fmt.Sprintf("sysctl -w '%s=%s'", param, value),
+		})
+	}
+
+	return recs
+}
+
+// intValue accepts the integer encodings a buffer value may arrive in: int
+// straight from InspectNetworkBuffers, or float64 after a JSON round trip.
+func intValue(v interface{}) (int, bool) {
+	switch t := v.(type) {
+	case int:
+		return t, true
+	case int64:
+		return int(t), true
+	case float64:
+		return int(t), true
+	}
+	return 0, false
+}
@@ -0,0 +1,120 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MemoryUsage is the machine's memory and swap use from /proc/meminfo.
+type MemoryUsage struct {
+	TotalKB     uint64 `json:"total_kb"`
+	AvailableKB uint64 `json:"available_kb"`
+	// UsedPercent counts memory the kernel cannot reclaim, so page cache
+	// does not inflate it.
+	UsedPercent     float64 `json:"used_percent"`
+	SwapTotalKB     uint64  `json:"swap_total_kb"`
+	SwapUsedKB      uint64  `json:"swap_used_kb"`
+	SwapUsedPercent float64 `json:"swap_used_percent"`
+}
+
+// MemoryUsageFrom reads /proc/meminfo from the proc tree rooted at procRoot.
+func MemoryUsageFrom(procRoot string) (*MemoryUsage, error) {
+	f, err := os.Open(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read meminfo: %w", err)
+	}
+	defer f.Close()
+
+	fields := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// "MemAvailable:   12345678 kB"
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Fields(rest)
+		if len(parts) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseUint(parts[0], 10, 64); err == nil {
+			fields[key] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read meminfo: %w", err)
+	}
+
+	total, ok := fields["MemTotal"]
+	if !ok || total == 0 {
+		return nil, fmt.Errorf("meminfo has no MemTotal")
+	}
+	available, ok := fields["MemAvailable"]
+	if !ok {
+		// Kernels before 3.14 lack MemAvailable.
+		available = fields["MemFree"] + fields["Buffers"] + fields["Cached"]
+	}
+	if available > total {
+		available = total
+	}
+
+	m := &MemoryUsage{
+		TotalKB:     total,
+		AvailableKB: available,
+		UsedPercent: percent(total-available, total),
+		SwapTotalKB: fields["SwapTotal"],
+	}
+	if free := fields["SwapFree"]; free <= m.SwapTotalKB {
+		m.SwapUsedKB = m.SwapTotalKB - free
+	}
+	m.SwapUsedPercent = percent(m.SwapUsedKB, m.SwapTotalKB)
+	return m, nil
+}
+
+// DiskUsage is the space and inode use of the filesystem holding Path.
+type DiskUsage struct {
+	Path              string  `json:"path"`
+	TotalBytes        uint64  `json:"total_bytes"`
+	AvailableBytes    uint64  `json:"available_bytes"`
+	UsedPercent       float64 `json:"used_percent"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+}
+
+// ConntrackUsage is how full the netfilter connection tracking table is.
+type ConntrackUsage struct {
+	Count       int     `json:"count"`
+	Max         int     `json:"max"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// ConntrackUsageFrom reads nf_conntrack_count and nf_conntrack_max from the
+// proc tree rooted at procRoot. The error wraps fs.ErrNotExist when
+// connection tracking is not loaded.
+func ConntrackUsageFrom(procRoot string) (*ConntrackUsage, error) {
+	dir := filepath.Join(procRoot, "sys/net/netfilter")
+	count, err := readProcValue(filepath.Join(dir, "nf_conntrack_count"))
+	if err != nil {
+		return nil, err
+	}
+	max, err := readProcValue(filepath.Join(dir, "nf_conntrack_max"))
+	if err != nil {
+		return nil, err
+	}
+	return &ConntrackUsage{
+		Count:       count,
+		Max:         max,
+		UsedPercent: percent(uint64(count), uint64(max)),
+	}, nil
+}
+
+// percent is used/total as a percentage, or 0 when total is 0.
+func percent(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(total)
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package system
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// DiskUsageOf reports the use of the filesystem holding path. Space
+// reserved for root counts as used, as df shows it.
+func DiskUsageOf(path string) (*DiskUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, fmt.Errorf("cannot stat filesystem of '%s': %w", path, err)
+	}
+	bsize := uint64(st.Bsize)
+	total := st.Blocks * bsize
+	available := st.Bavail * bsize
+	return &DiskUsage{
+		Path:              path,
+		TotalBytes:        total,
+		AvailableBytes:    available,
+		UsedPercent:       percent(total-available, total),
+		InodesUsedPercent: percent(st.Files-st.Ffree, st.Files),
+	}, nil
+}
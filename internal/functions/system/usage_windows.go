@@ -0,0 +1,13 @@
+//go:build windows
+
+package system
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DiskUsageOf is not supported on Windows, which has no statfs.
+func DiskUsageOf(path string) (*DiskUsage, error) {
+	return nil, fmt.Errorf("cannot stat filesystem of '%s': disk usage is not supported on Windows: %w", path, errors.ErrUnsupported)
+}
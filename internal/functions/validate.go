@@ -0,0 +1,145 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/friday/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// validParamTypes are the parameter types the executor knows how to coerce.
+var validParamTypes = map[string]bool{
+	"string": true, "integer": true, "float": true, "number": true,
+	"boolean": true, "array": true, "object": true,
+}
+
+var functionNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ValidateDefinition checks a single function definition against the registry
+// schema. Issue fields are relative to the definition, e.g. "parameters[1].type";
+// line numbers are left for the caller to fill in.
+func ValidateDefinition(fn types.FunctionDefinition) []types.SchemaIssue {
+	var issues []types.SchemaIssue
+	add := func(field, format string, args ...interface{}) {
+		issues = append(issues, types.SchemaIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case fn.Name == "":
+		add("name", "is required")
+	case !functionNamePattern.MatchString(fn.Name):
+		add("name", "must be lower snake_case, got '%s'", fn.Name)
+	}
+	if fn.Category == "" {
+		add("category", "is required")
+	}
+	issues = append(issues, enumIssues(fn)...)
+	if fn.TimeoutSeconds < 0 {
+		add("timeout_seconds", "must not be negative")
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range fn.Parameters {
+		field := fmt.Sprintf("parameters[%d]", i)
+		if p.Name == "" {
+			add(field+".name", "is required")
+		} else if seen[p.Name] {
+			add(field+".name", "duplicates parameter '%s'", p.Name)
+		}
+		seen[p.Name] = true
+
+		switch {
+		case p.Type == "":
+			add(field+".type", "is required")
+		case !validParamTypes[p.Type]:
+			add(field+".type", "'%s' is not a valid type (string, integer, float, number, boolean, array, object)", p.Type)
+		}
+	}
+	return issues
+}
+
+// enumIssues checks phase and stability, the fields limited to a fixed set
+// of values. ValidateDefinition reports these issues and readDefinitions
+// refuses to load a file that has any.
+func enumIssues(fn types.FunctionDefinition) []types.SchemaIssue {
+	var issues []types.SchemaIssue
+	if fn.Phase != "" && !types.ValidPhase(fn.Phase) {
+		issues = append(issues, types.SchemaIssue{Field: "phase", Message: fmt.Sprintf("must be %s, %s, or %s, got '%s'",
+			types.PhaseRead, types.PhaseAnalyze, types.PhaseModify, fn.Phase)})
+	}
+	if fn.Stability != "" && !types.ValidStability(fn.Stability) {
+		issues = append(issues, types.SchemaIssue{Field: "stability", Message: fmt.Sprintf("must be %s, %s, or %s, got '%s'",
+			types.StabilityStable, types.StabilityExperimental, types.StabilityDeprecated, fn.Stability)})
+	}
+	return issues
+}
+
+// ValidateFile parses a function registry file and checks every definition
+// in it, without loading the rest of the application. Issues carry the line
+// they refer to where it can be determined. The error is non-nil only when
+// the file cannot be read.
+func ValidateFile(path string) ([]types.SchemaIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return types.YAMLErrorIssues(err), nil
+	}
+
+	var file struct {
+		Functions         []types.FunctionDefinition `yaml:"functions"`
+		RollbackFunctions []types.FunctionDefinition `yaml:"rollback_functions"`
+	}
+	if err := root.Decode(&file); err != nil {
+		return types.YAMLErrorIssues(err), nil
+	}
+
+	var issues []types.SchemaIssue
+	if len(file.Functions) == 0 {
+		issues = append(issues, types.SchemaIssue{
+			Line: types.YAMLLine(&root, "functions"), Field: "functions", Message: "must list at least one function",
+		})
+	}
+
+	// A rollback may name an internal rollback function or a regular one.
+	known := make(map[string]bool)
+	for _, fn := range append(file.RollbackFunctions, file.Functions...) {
+		known[fn.Name] = true
+	}
+
+	names := make(map[string]int)
+	for i, fn := range file.Functions {
+		prefix := fmt.Sprintf("functions[%d]", i)
+		fnIssues := ValidateDefinition(fn)
+
+		if fn.Name != "" {
+			if first, dup := names[fn.Name]; dup {
+				fnIssues = append(fnIssues, types.SchemaIssue{
+					Field: "name", Message: fmt.Sprintf("duplicates the function on line %d", first),
+				})
+			} else {
+				names[fn.Name] = types.YAMLLine(&root, prefix+".name")
+			}
+		}
+		if fn.RollbackFunction != "" && !known[fn.RollbackFunction] {
+			fnIssues = append(fnIssues, types.SchemaIssue{
+				Field: "rollback_function", Message: fmt.Sprintf("references unknown function '%s'", fn.RollbackFunction),
+			})
+		}
+
+		for _, issue := range fnIssues {
+			issue.Field = prefix + "." + issue.Field
+			issue.Line = types.YAMLLine(&root, issue.Field)
+			if fn.Name != "" {
+				issue.Message += fmt.Sprintf(" (function '%s')", fn.Name)
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,134 @@
+package functions
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/types"
+)
+
+func TestValidateDefinition_Valid(t *testing.T) {
+	fn := types.FunctionDefinition{
+		Name:     "ping",
+		Category: "network",
+		Phase:    types.PhaseRead,
+		Parameters: []types.ParameterDefinition{
+			{Name: "host", Type: "string", Required: true},
+			{Name: "count", Type: "integer"},
+		},
+	}
+	if issues := ValidateDefinition(fn); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidateDefinition_DuplicateParameter(t *testing.T) {
+	fn := types.FunctionDefinition{
+		Name:     "ping",
+		Category: "network",
+		Parameters: []types.ParameterDefinition{
+			{Name: "host", Type: "string"},
+			{Name: "host", Type: "string"},
+		},
+	}
+	issues := ValidateDefinition(fn)
+	if len(issues) != 1 || issues[0].Field != "parameters[1].name" {
+		t.Errorf("expected duplicate on parameters[1].name, got %v", issues)
+	}
+}
+
+func TestValidateDefinition_InvalidStability(t *testing.T) {
+	fn := types.FunctionDefinition{Name: "ping", Category: "network", Stability: "beta"}
+	issues := ValidateDefinition(fn)
+	if len(issues) != 1 || issues[0].Field != "stability" {
+		t.Errorf("expected an issue on stability, got %v", issues)
+	}
+}
+
+func TestValidateFile_WellFormed(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "functions.yaml", `
+functions:
+  - name: execute_sysctl_command
+    category: system
+    phase: modify
+    rollback_function: restore_sysctl_value
+    parameters:
+      - name: parameter
+        type: string
+        required: true
+rollback_functions:
+  - name: restore_sysctl_value
+    internal: true
+`)
+	issues, err := ValidateFile(filepath.Join(dir, "functions.yaml"))
+	if err != nil {
+		t.Fatalf("ValidateFile returned error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidateFile_MissingCategory(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "functions.yaml", `
+functions:
+  - name: ping
+    category: network
+  - name: traceroute
+    phase: read
+`)
+	issues, err := ValidateFile(filepath.Join(dir, "functions.yaml"))
+	if err != nil {
+		t.Fatalf("ValidateFile returned error: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %v", issues)
+	}
+	if issues[0].Field != "functions[1].category" {
+		t.Errorf("expected functions[1].category, got %q", issues[0].Field)
+	}
+	// Missing fields are anchored to the start of their function.
+	if issues[0].Line != 5 {
+		t.Errorf("expected line 5, got %d", issues[0].Line)
+	}
+}
+
+func TestValidateFile_InvalidParameterType(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "functions.yaml", `
+functions:
+  - name: ping
+    category: network
+    parameters:
+      - name: host
+        type: string
+      - name: count
+        type: int
+`)
+	issues, err := ValidateFile(filepath.Join(dir, "functions.yaml"))
+	if err != nil {
+		t.Fatalf("ValidateFile returned error: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %v", issues)
+	}
+	if issues[0].Line != 9 || !strings.Contains(issues[0].Message, "'int'") {
+		t.Errorf("expected invalid type 'int' on line 9, got %s", issues[0])
+	}
+}
+
+func TestValidateFile_SyntaxError(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryFile(t, dir, "functions.yaml", "functions:\n  - name: ping\n   category: network\n")
+
+	issues, err := ValidateFile(filepath.Join(dir, "functions.yaml"))
+	if err != nil {
+		t.Fatalf("ValidateFile returned error: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Line == 0 {
+		t.Errorf("expected one line-anchored syntax issue, got %v", issues)
+	}
+}
@@ -37,6 +37,22 @@ type ChatRequest struct {
 	Messages    []ChatMessage `json:"messages"`
 	Temperature float32       `json:"temperature"`
 	MaxTokens   int           `json:"max_tokens"`
+	TopP        *float32      `json:"top_p,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+}
+
+// GenerateOptions overrides the client's sampling defaults for a single call.
+// Nil pointers and zero values leave the corresponding default in place.
+type GenerateOptions struct {
+	Temperature *float32
+	TopP        *float32
+	MaxTokens   int
+	Stop        []string
+}
+
+// Float32 returns a pointer to v, for populating GenerateOptions.
+func Float32(v float32) *float32 {
+	return &v
 }
 
 type ChatResponse struct {
@@ -47,7 +63,10 @@ type ChatResponse struct {
 	} `json:"choices"`
 }
 
-func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+// Generate sends prompt as a single user message. An optional GenerateOptions
+// overrides the client's temperature, max_tokens, top_p, and stop sequences
+// for this call only.
+func (c *Client) Generate(ctx context.Context, prompt string, opts ...GenerateOptions) (string, error) {
 	req := ChatRequest{
 		Model: c.model,
 		Messages: []ChatMessage{
@@ -56,6 +75,20 @@ func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
 		Temperature: c.temperature,
 		MaxTokens:   c.maxTokens,
 	}
+	for _, o := range opts {
+		if o.Temperature != nil {
+			req.Temperature = *o.Temperature
+		}
+		if o.TopP != nil {
+			req.TopP = o.TopP
+		}
+		if o.MaxTokens > 0 {
+			req.MaxTokens = o.MaxTokens
+		}
+		if len(o.Stop) > 0 {
+			req.Stop = o.Stop
+		}
+	}
 
 	jsonData, err := json.Marshal(req)
 	if err != nil {
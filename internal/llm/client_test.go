@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// captureServer records the decoded body of the last chat completion request.
+func captureServer(t *testing.T, got *ChatRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGenerate_ClientDefaults(t *testing.T) {
+	var got ChatRequest
+	srv := captureServer(t, &got)
+	c := NewClient(srv.URL, "m", 5*time.Second, 0.1, 512)
+
+	if _, err := c.Generate(context.Background(), "hello"); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	if got.Temperature != 0.1 || got.MaxTokens != 512 {
+		t.Errorf("expected defaults temperature=0.1 max_tokens=512, got %v/%d", got.Temperature, got.MaxTokens)
+	}
+	if got.TopP != nil || got.Stop != nil {
+		t.Errorf("expected top_p and stop to be omitted, got %v/%v", got.TopP, got.Stop)
+	}
+}
+
+func TestGenerate_OptionsOverrideDefaults(t *testing.T) {
+	var got ChatRequest
+	srv := captureServer(t, &got)
+	c := NewClient(srv.URL, "m", 5*time.Second, 0.1, 512)
+
+	_, err := c.Generate(context.Background(), "hello", GenerateOptions{
+		Temperature: Float32(0),
+		TopP:        Float32(0.9),
+		MaxTokens:   64,
+		Stop:        []string{"\n\n"},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	if got.Temperature != 0 {
+		t.Errorf("expected temperature override 0, got %v", got.Temperature)
+	}
+	if got.MaxTokens != 64 {
+		t.Errorf("expected max_tokens override 64, got %d", got.MaxTokens)
+	}
+	if got.TopP == nil || *got.TopP != 0.9 {
+		t.Errorf("expected top_p 0.9, got %v", got.TopP)
+	}
+	if !reflect.DeepEqual(got.Stop, []string{"\n\n"}) {
+		t.Errorf("expected stop sequences to be sent, got %v", got.Stop)
+	}
+}
+
+func TestGenerate_PartialOptionsKeepDefaults(t *testing.T) {
+	var got ChatRequest
+	srv := captureServer(t, &got)
+	c := NewClient(srv.URL, "m", 5*time.Second, 0.1, 512)
+
+	if _, err := c.Generate(context.Background(), "hello", GenerateOptions{MaxTokens: 5}); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	if got.MaxTokens != 5 {
+		t.Errorf("expected max_tokens override 5, got %d", got.MaxTokens)
+	}
+	if got.Temperature != 0.1 {
+		t.Errorf("expected default temperature 0.1 to be kept, got %v", got.Temperature)
+	}
+}
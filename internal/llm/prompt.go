@@ -3,8 +3,10 @@ package llm
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/friday/internal/truncate"
 	"github.com/friday/internal/types"
 )
 
@@ -60,6 +62,9 @@ func buildFunctionRegistry(functions []types.FunctionDefinition) string {
 				if p.Description != "" {
 					line += ": " + p.Description
 				}
+				if len(p.Enum) > 0 {
+					line += fmt.Sprintf(" [one of: %s]", strings.Join(p.Enum, ", "))
+				}
 				if p.Default != nil {
 					line += fmt.Sprintf(" [default: %v]", p.Default)
 				}
@@ -79,10 +84,7 @@ func buildRetrievedContext(chunks []types.RetrievedChunk) string {
 
 	var sb strings.Builder
 	for i, chunk := range chunks {
-		preview := chunk.Content
-		if len(preview) > 500 {
-			preview = preview[:500] + "..."
-		}
+		preview := truncate.String(chunk.Content, 500)
 		sb.WriteString(fmt.Sprintf("[%d] Source: %s (score: %.2f)\n%s\n\n",
 			i+1, chunk.Source, chunk.Score, preview))
 	}
@@ -108,7 +110,7 @@ func buildConversationHistory(history []types.Message) string {
 				if !fn.Success {
 					status = "✗"
 				}
-				output := truncateHistory(fn.Output, 200)
+				output := truncate.Output(fn.Output, 200)
 				sb.WriteString(fmt.Sprintf("    %s %s: %s\n", status, fn.Function.Name, output))
 			}
 		}
@@ -117,6 +119,67 @@ func buildConversationHistory(history []types.Message) string {
 	return sb.String()
 }
 
+// followUpOutputLimit caps each prior tool output repeated in the follow-up
+// hint; far more generous than the history's 200 chars so field values the
+// user asks about are not cut off.
+const followUpOutputLimit = 2000
+
+// BuildFollowUpHint returns a prompt section telling the model the query asks
+// about results already gathered, repeating those results in full so it can
+// answer without proposing new functions. Returns "" if history holds no
+// tool results.
+func BuildFollowUpHint(history []types.Message) string {
+	var results strings.Builder
+	for _, msg := range history {
+		for _, fn := range msg.Functions {
+			if fn.Success {
+				results.WriteString(fmt.Sprintf("- %s: %s\n", fn.Function.Name, truncate.Output(fn.Output, followUpOutputLimit)))
+			} else {
+				results.WriteString(fmt.Sprintf("- %s (failed): %s\n", fn.Function.Name, fn.Error))
+			}
+		}
+	}
+	if results.Len() == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\n## FOLLOW-UP ON PRIOR RESULTS\n\n")
+	sb.WriteString("The current query asks about results that have already been gathered. ")
+	sb.WriteString("Answer it from the tool results below and the documentation context: ")
+	sb.WriteString("return an empty \"functions\" array and put the answer in \"explanation\", ")
+	sb.WriteString("interpreting the specific values (what they mean, whether they are normal, what to do next). ")
+	sb.WriteString("Only propose functions if the answer genuinely needs data that is not below.\n\n")
+	sb.WriteString("Prior tool results:\n")
+	sb.WriteString(results.String())
+	return sb.String()
+}
+
+// BuildSessionHint returns a prompt section listing results the user saved
+// under labels earlier in the session, given as label -> JSON, so the model
+// can compare against them and pass their fields to functions as
+// ${session.LABEL.field}. Returns "" if nothing is saved.
+func BuildSessionHint(saved map[string]string) string {
+	if len(saved) == 0 {
+		return ""
+	}
+	labels := make([]string, 0, len(saved))
+	for label := range saved {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var sb strings.Builder
+	sb.WriteString("\n\n## SAVED RESULTS\n\n")
+	sb.WriteString("The user saved these results earlier in the session. ")
+	sb.WriteString("When the query names a label, compare against its values; ")
+	sb.WriteString("a function parameter can take a saved value as ${session.LABEL.field}.\n\n")
+	for _, label := range labels {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", label, truncate.Output(saved[label], followUpOutputLimit)))
+	}
+	return sb.String()
+}
+
 // buildFallbackPrompt is used when master_prompt.txt cannot be read.
 // It produces a compact but still structured prompt so the agent stays functional.
 func buildFallbackPrompt(
@@ -154,10 +217,7 @@ func buildFallbackPrompt(
 
 	sb.WriteString("Retrieved context:\n")
 	for i, chunk := range chunks {
-		preview := chunk.Content
-		if len(preview) > 200 {
-			preview = preview[:200] + "..."
-		}
+		preview := truncate.String(chunk.Content, 200)
 		sb.WriteString(fmt.Sprintf("[%d] %s (%.2f)\n%s\n\n", i+1, chunk.Source, chunk.Score, preview))
 	}
 
@@ -173,10 +233,3 @@ func capitalize(s string) string {
 	}
 	return strings.ToUpper(s[:1]) + s[1:]
 }
-
-func truncateHistory(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen] + "..."
-}
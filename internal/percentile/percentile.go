@@ -0,0 +1,26 @@
+// Package percentile computes the percentiles reported by the latency probes
+// and the query benchmark, so both rank samples the same way.
+package percentile
+
+import (
+	"cmp"
+	"math"
+)
+
+// NearestRank returns the nearest-rank p-th percentile of sorted, which
+// must be in ascending order: the smallest sample no less than p percent
+// of the samples. It returns the zero value when sorted is empty.
+func NearestRank[T cmp.Ordered](sorted []T, p float64) T {
+	if len(sorted) == 0 {
+		var zero T
+		return zero
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,24 @@
+package percentile
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNearestRank(t *testing.T) {
+	sorted := []float64{15, 20, 35, 40, 50}
+	for _, tc := range []struct {
+		p    float64
+		want float64
+	}{{0, 15}, {30, 20}, {40, 20}, {50, 35}, {100, 50}, {150, 50}} {
+		if got := NearestRank(sorted, tc.p); got != tc.want {
+			t.Errorf("p%v: expected %v, got %v", tc.p, tc.want, got)
+		}
+	}
+	if got := NearestRank([]time.Duration{time.Millisecond, 2 * time.Millisecond}, 50); got != time.Millisecond {
+		t.Errorf("expected 1ms for durations, got %v", got)
+	}
+	if got := NearestRank([]time.Duration(nil), 50); got != 0 {
+		t.Errorf("expected 0 for no samples, got %v", got)
+	}
+}
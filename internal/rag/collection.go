@@ -0,0 +1,91 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// ErrCollectionMismatch marks an existing collection whose vector settings
+// do not match the embedding model, so searches against it cannot succeed.
+var ErrCollectionMismatch = errors.New("collection does not match embedding model")
+
+// CollectionAdmin is the subset of Qdrant collection management needed to
+// bootstrap the retrieval collection. *qdrant.Client satisfies this.
+type CollectionAdmin interface {
+	CollectionExists(ctx context.Context, collectionName string) (bool, error)
+	GetCollectionInfo(ctx context.Context, collectionName string) (*qdrant.CollectionInfo, error)
+	CreateCollection(ctx context.Context, request *qdrant.CreateCollection) error
+}
+
+// ParseDistance maps a distance name such as "cosine" or "dot" to its Qdrant
+// metric. An empty name means cosine.
+func ParseDistance(name string) (qdrant.Distance, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "cosine":
+		return qdrant.Distance_Cosine, nil
+	case "euclid", "euclidean":
+		return qdrant.Distance_Euclid, nil
+	case "dot":
+		return qdrant.Distance_Dot, nil
+	case "manhattan":
+		return qdrant.Distance_Manhattan, nil
+	default:
+		return qdrant.Distance_UnknownDistance, fmt.Errorf("unknown distance '%s'", name)
+	}
+}
+
+// EnsureCollection creates collection with dim-sized vectors and the given
+// distance if it does not exist. An existing collection is left untouched when
+// its settings match and reported as ErrCollectionMismatch when they do not.
+// It reports whether the collection was created.
+func EnsureCollection(ctx context.Context, admin CollectionAdmin, collection string, dim int, distance string) (bool, error) {
+	if dim <= 0 {
+		return false, fmt.Errorf("invalid embedding dimension %d", dim)
+	}
+	metric, err := ParseDistance(distance)
+	if err != nil {
+		return false, err
+	}
+
+	exists, err := admin.CollectionExists(ctx, collection)
+	if err != nil {
+		return false, fmt.Errorf("failed to check collection '%s': %w", collection, err)
+	}
+
+	if !exists {
+		err := admin.CreateCollection(ctx, &qdrant.CreateCollection{
+			CollectionName: collection,
+			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
+				Size:     uint64(dim),
+				Distance: metric,
+			}),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to create collection '%s': %w", collection, err)
+		}
+		return true, nil
+	}
+
+	info, err := admin.GetCollectionInfo(ctx, collection)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect collection '%s': %w", collection, err)
+	}
+	params := info.GetConfig().GetParams().GetVectorsConfig().GetParams()
+	if params == nil {
+		return false, fmt.Errorf("%w: '%s' uses named vectors, expected a single unnamed vector",
+			ErrCollectionMismatch, collection)
+	}
+	if params.GetSize() != uint64(dim) {
+		return false, fmt.Errorf("%w: '%s' has dimension %d but the embedding model produces %d; recreate the collection or fix onnx.embedding_dim",
+			ErrCollectionMismatch, collection, params.GetSize(), dim)
+	}
+	if params.GetDistance() != metric {
+		return false, fmt.Errorf("%w: '%s' uses %s distance, expected %s",
+			ErrCollectionMismatch, collection, params.GetDistance(), metric)
+	}
+	return false, nil
+}
@@ -0,0 +1,94 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/qdrant/go-client/qdrant"
+	"go.uber.org/zap"
+)
+
+// mockAdmin is an in-memory CollectionAdmin holding at most one collection.
+type mockAdmin struct {
+	params  *qdrant.VectorParams
+	creates []*qdrant.CreateCollection
+}
+
+func (m *mockAdmin) CollectionExists(ctx context.Context, name string) (bool, error) {
+	return m.params != nil, nil
+}
+
+func (m *mockAdmin) GetCollectionInfo(ctx context.Context, name string) (*qdrant.CollectionInfo, error) {
+	if m.params == nil {
+		return nil, errors.New("collection not found")
+	}
+	return &qdrant.CollectionInfo{
+		Config: &qdrant.CollectionConfig{
+			Params: &qdrant.CollectionParams{VectorsConfig: qdrant.NewVectorsConfig(m.params)},
+		},
+	}, nil
+}
+
+func (m *mockAdmin) CreateCollection(ctx context.Context, req *qdrant.CreateCollection) error {
+	m.creates = append(m.creates, req)
+	m.params = req.GetVectorsConfig().GetParams()
+	return nil
+}
+
+func testPipeline(admin CollectionAdmin) *Pipeline {
+	retriever := &Retriever{admin: admin, collectionName: "runbooks", logger: zap.NewNop()}
+	return NewPipelineWithRetriever(retriever, 5, 0.7, nil)
+}
+
+func TestEnsureCollection_CreatesWhenMissing(t *testing.T) {
+	admin := &mockAdmin{}
+	if err := testPipeline(admin).EnsureCollection(context.Background(), 384, "cosine"); err != nil {
+		t.Fatalf("EnsureCollection returned error: %v", err)
+	}
+
+	if len(admin.creates) != 1 {
+		t.Fatalf("expected 1 create, got %d", len(admin.creates))
+	}
+	req := admin.creates[0]
+	params := req.GetVectorsConfig().GetParams()
+	if req.CollectionName != "runbooks" || params.GetSize() != 384 || params.GetDistance() != qdrant.Distance_Cosine {
+		t.Errorf("unexpected create request: %s size=%d distance=%s",
+			req.CollectionName, params.GetSize(), params.GetDistance())
+	}
+}
+
+func TestEnsureCollection_NoOpWhenMatching(t *testing.T) {
+	admin := &mockAdmin{params: &qdrant.VectorParams{Size: 384, Distance: qdrant.Distance_Cosine}}
+	if err := testPipeline(admin).EnsureCollection(context.Background(), 384, ""); err != nil {
+		t.Fatalf("EnsureCollection returned error: %v", err)
+	}
+	if len(admin.creates) != 0 {
+		t.Errorf("expected no create for matching collection, got %d", len(admin.creates))
+	}
+}
+
+func TestEnsureCollection_ConflictingDimension(t *testing.T) {
+	admin := &mockAdmin{params: &qdrant.VectorParams{Size: 768, Distance: qdrant.Distance_Cosine}}
+	err := testPipeline(admin).EnsureCollection(context.Background(), 384, "cosine")
+	if !errors.Is(err, ErrCollectionMismatch) {
+		t.Fatalf("expected ErrCollectionMismatch, got %v", err)
+	}
+	if len(admin.creates) != 0 {
+		t.Errorf("expected existing collection left alone, got %d creates", len(admin.creates))
+	}
+}
+
+func TestEnsureCollection_ConflictingDistance(t *testing.T) {
+	admin := &mockAdmin{params: &qdrant.VectorParams{Size: 384, Distance: qdrant.Distance_Dot}}
+	err := testPipeline(admin).EnsureCollection(context.Background(), 384, "cosine")
+	if !errors.Is(err, ErrCollectionMismatch) {
+		t.Fatalf("expected ErrCollectionMismatch, got %v", err)
+	}
+}
+
+func TestParseDistance_Unknown(t *testing.T) {
+	if _, err := ParseDistance("hamming"); err == nil {
+		t.Error("expected error for unknown distance")
+	}
+}
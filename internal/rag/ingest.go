@@ -0,0 +1,358 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+	"go.uber.org/zap"
+)
+
+// Chunking parameters mirror scripts/02_process_docs.py so documents indexed
+// at startup look the same to retrieval as those from the offline pipeline.
+const (
+	chunkSizeWords    = 512
+	chunkOverlapWords = 50
+	minChunkChars     = 50
+	upsertBatchSize   = 100
+)
+
+// Document is a single embedded chunk ready to be written to the vector store.
+type Document struct {
+	// ID is a UUID derived from Source and ChunkIndex (see pointID), so it
+	// cannot collide with the numeric IDs of the offline pipeline.
+	ID         string
+	Content    string
+	Source     string
+	Category   string
+	ChunkIndex int
+	Vector     []float32
+	// Modified is when Source was last changed, recorded as the chunk's
+	// ingest time so recency ranking tells old runbooks from new ones.
+	Modified time.Time
+}
+
+// Embedder produces embeddings for a batch of texts.
+// *EmbeddingClient satisfies this.
+type Embedder interface {
+	EmbedBatch(texts []string) ([][]float32, error)
+}
+
+// VectorStore is the subset of vector database operations needed to keep a
+// knowledge directory indexed.
+type VectorStore interface {
+	// StoredHash returns the knowledge hash recorded with the ingested
+	// documents, or "" when the collection is missing or holds none.
+	StoredHash(ctx context.Context) (string, error)
+
+	// Replace discards the documents written by earlier Replace calls and
+	// writes docs, recording hash alongside them. Documents indexed by the
+	// offline pipeline, which carry no hash, are kept.
+	Replace(ctx context.Context, docs []Document, hash string) error
+}
+
+// SyncKnowledgeDir indexes the markdown files under dir into store unless the
+// store already holds documents built from identical content. It reports
+// whether ingestion ran.
+func SyncKnowledgeDir(ctx context.Context, dir string, store VectorStore, embedder Embedder, logger *zap.Logger) (bool, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	hash, err := HashDirectory(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash knowledge directory: %w", err)
+	}
+
+	stored, err := store.StoredHash(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read stored knowledge hash: %w", err)
+	}
+
+	if stored == hash {
+		logger.Info("Knowledge directory unchanged, skipping ingestion",
+			zap.String("dir", dir),
+			zap.String("hash", hash[:12]))
+		return false, nil
+	}
+
+	logger.Info("Knowledge directory changed, re-ingesting",
+		zap.String("dir", dir),
+		zap.Bool("collection_empty", stored == ""))
+
+	if err := IngestDirectory(ctx, dir, hash, store, embedder, logger); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IngestDirectory chunks and embeds every *.md file under dir and replaces the
+// contents of store with the result, tagged with hash.
+func IngestDirectory(ctx context.Context, dir, hash string, store VectorStore, embedder Embedder, logger *zap.Logger) error {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	files, err := markdownFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	var docs []Document
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		chunks := chunkText(string(content))
+		if len(chunks) == 0 {
+			continue
+		}
+
+		vectors, err := embedder.EmbedBatch(chunks)
+		if err != nil {
+			return fmt.Errorf("failed to embed %s: %w", path, err)
+		}
+
+		category := categorize(path)
+		for i, chunk := range chunks {
+			docs = append(docs, Document{
+				ID:         pointID(path, i),
+				Content:    chunk,
+				Source:     path,
+				Category:   category,
+				ChunkIndex: i,
+				Vector:     vectors[i],
+				Modified:   info.ModTime(),
+			})
+		}
+
+		logger.Info("Ingested knowledge file",
+			zap.String("file", path),
+			zap.Int("chunks", len(chunks)))
+	}
+
+	if err := store.Replace(ctx, docs, hash); err != nil {
+		return fmt.Errorf("failed to write knowledge to vector store: %w", err)
+	}
+
+	logger.Info("Knowledge ingestion completed",
+		zap.Int("files", len(files)),
+		zap.Int("chunks", len(docs)))
+
+	return nil
+}
+
+// HashDirectory returns a content hash over every *.md file under dir. Both
+// file paths and contents contribute, so renames, edits, additions, and
+// deletions all change the hash.
+func HashDirectory(dir string) (string, error) {
+	files, err := markdownFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, path := range files {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return "", err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(content))
+		h.Write(content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pointID derives a stable UUID for chunk of source, so re-ingesting a file
+// overwrites its own points and never those of another file.
+func pointID(source string, chunk int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", source, chunk)))
+	b := sum[:16]
+	b[6] = b[6]&0x0f | 0x80 // version 8: custom
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// markdownFiles lists *.md files under dir recursively in lexical order.
+func markdownFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".md") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan knowledge directory %s: %w", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// chunkText splits text into overlapping word windows, dropping fragments too
+// short to be useful for retrieval.
+func chunkText(text string) []string {
+	words := strings.Fields(text)
+	var chunks []string
+	for i := 0; i < len(words); i += chunkSizeWords - chunkOverlapWords {
+		end := min(i+chunkSizeWords, len(words))
+		chunk := strings.Join(words[i:end], " ")
+		if len(chunk) > minChunkChars {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(words) {
+			break
+		}
+	}
+	return chunks
+}
+
+// categorize derives a document category from its path.
+func categorize(path string) string {
+	p := strings.ToLower(path)
+	switch {
+	case strings.Contains(p, "grpc"):
+		return "grpc"
+	case strings.Contains(p, "gnmi"):
+		return "gnmi"
+	case strings.Contains(p, "yang"), strings.Contains(p, "openconfig"):
+		return "yang"
+	case strings.Contains(p, "debug"):
+		return "debugging"
+	case strings.Contains(p, "network"), strings.Contains(p, "tcp"):
+		return "network"
+	}
+	return "general"
+}
+
+// ============================================================================
+// Qdrant-backed store
+// ============================================================================
+
+// knowledgeHashKey is the payload field that records which knowledge
+// directory contents a point was built from. Points without it come from the
+// offline pipeline and are left alone.
+const knowledgeHashKey = "knowledge_hash"
+
+// knowledgePoints selects the points written by qdrantStore.Replace.
+func knowledgePoints() *qdrant.Filter {
+	return &qdrant.Filter{MustNot: []*qdrant.Condition{qdrant.NewIsEmpty(knowledgeHashKey)}}
+}
+
+// qdrantStore implements VectorStore on a single Qdrant collection.
+type qdrantStore struct {
+	client     *qdrant.Client
+	collection string
+	dimension  int
+}
+
+// docPayload is the payload stored with doc's point.
+func docPayload(doc Document, hash string) map[string]any {
+	return map[string]any{
+		"content":        doc.Content,
+		"source":         doc.Source,
+		"category":       doc.Category,
+		"chunk_index":    doc.ChunkIndex,
+		knowledgeHashKey: hash,
+		ingestedAtKey:    doc.Modified.Unix(),
+	}
+}
+
+func (s *qdrantStore) StoredHash(ctx context.Context) (string, error) {
+	exists, err := s.client.CollectionExists(ctx, s.collection)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+
+	limit := uint32(1)
+	points, err := s.client.Scroll(ctx, &qdrant.ScrollPoints{
+		CollectionName: s.collection,
+		Filter:         knowledgePoints(),
+		Limit:          &limit,
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(points) == 0 {
+		return "", nil
+	}
+
+	hash, _ := getPayloadString(points[0].Payload, knowledgeHashKey)
+	return hash, nil
+}
+
+func (s *qdrantStore) Replace(ctx context.Context, docs []Document, hash string) error {
+	exists, err := s.client.CollectionExists(ctx, s.collection)
+	if err != nil {
+		return err
+	}
+	wait := true
+	if exists {
+		if _, err := s.client.Delete(ctx, &qdrant.DeletePoints{
+			CollectionName: s.collection,
+			Wait:           &wait,
+			Points:         qdrant.NewPointsSelectorFilter(knowledgePoints()),
+		}); err != nil {
+			return fmt.Errorf("failed to delete previously ingested points: %w", err)
+		}
+	} else {
+		err = s.client.CreateCollection(ctx, &qdrant.CreateCollection{
+			CollectionName: s.collection,
+			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
+				Size:     uint64(s.dimension),
+				Distance: qdrant.Distance_Cosine,
+			}),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create collection: %w", err)
+		}
+	}
+
+	for start := 0; start < len(docs); start += upsertBatchSize {
+		batch := docs[start:min(start+upsertBatchSize, len(docs))]
+		points := make([]*qdrant.PointStruct, 0, len(batch))
+		for _, doc := range batch {
+			points = append(points, &qdrant.PointStruct{
+				Id:      qdrant.NewID(doc.ID),
+				Vectors: qdrant.NewVectors(doc.Vector...),
+				Payload: qdrant.NewValueMap(docPayload(doc, hash)),
+			})
+		}
+
+		if _, err := s.client.Upsert(ctx, &qdrant.UpsertPoints{
+			CollectionName: s.collection,
+			Wait:           &wait,
+			Points:         points,
+		}); err != nil {
+			return fmt.Errorf("failed to upsert points: %w", err)
+		}
+	}
+
+	return nil
+}
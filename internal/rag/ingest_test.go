@@ -0,0 +1,161 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// mockStore is an in-memory VectorStore that records each Replace call.
+type mockStore struct {
+	hash     string
+	docs     []Document
+	replaces int
+}
+
+func (m *mockStore) StoredHash(ctx context.Context) (string, error) {
+	return m.hash, nil
+}
+
+func (m *mockStore) Replace(ctx context.Context, docs []Document, hash string) error {
+	m.docs = docs
+	m.hash = hash
+	m.replaces++
+	return nil
+}
+
+// mockEmbedder returns a fixed-size zero vector per text.
+type mockEmbedder struct {
+	calls int
+}
+
+func (m *mockEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	m.calls++
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = make([]float32, 4)
+	}
+	return out, nil
+}
+
+const runbookText = "When gRPC streams stall, check the TCP send queue with ss -ti and compare cwnd against the queued bytes before tuning buffers."
+
+func writeRunbook(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir for %s: %v", name, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func TestSyncKnowledgeDir_EmptyStoreIngests(t *testing.T) {
+	dir := t.TempDir()
+	writeRunbook(t, dir, "grpc/stalls.md", runbookText)
+	writeRunbook(t, dir, "notes.txt", "ignored: not markdown")
+
+	store := &mockStore{}
+	ingested, err := SyncKnowledgeDir(context.Background(), dir, store, &mockEmbedder{}, nil)
+	if err != nil {
+		t.Fatalf("SyncKnowledgeDir returned error: %v", err)
+	}
+	if !ingested || store.replaces != 1 {
+		t.Fatalf("expected ingestion into empty store, ingested=%v replaces=%d", ingested, store.replaces)
+	}
+	if len(store.docs) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(store.docs))
+	}
+	if store.docs[0].Category != "grpc" {
+		t.Errorf("expected category grpc, got %q", store.docs[0].Category)
+	}
+	if store.hash == "" {
+		t.Error("expected knowledge hash to be recorded")
+	}
+}
+
+func TestSyncKnowledgeDir_UnchangedSkipped(t *testing.T) {
+	dir := t.TempDir()
+	writeRunbook(t, dir, "tcp.md", runbookText)
+
+	store := &mockStore{}
+	embedder := &mockEmbedder{}
+	if _, err := SyncKnowledgeDir(context.Background(), dir, store, embedder, nil); err != nil {
+		t.Fatalf("first sync returned error: %v", err)
+	}
+
+	ingested, err := SyncKnowledgeDir(context.Background(), dir, store, embedder, nil)
+	if err != nil {
+		t.Fatalf("second sync returned error: %v", err)
+	}
+	if ingested {
+		t.Error("expected unchanged directory to skip ingestion")
+	}
+	if store.replaces != 1 || embedder.calls != 1 {
+		t.Errorf("expected a single ingestion, got replaces=%d embed calls=%d", store.replaces, embedder.calls)
+	}
+}
+
+func TestSyncKnowledgeDir_ChangedFileReingests(t *testing.T) {
+	dir := t.TempDir()
+	writeRunbook(t, dir, "tcp.md", runbookText)
+
+	store := &mockStore{}
+	if _, err := SyncKnowledgeDir(context.Background(), dir, store, &mockEmbedder{}, nil); err != nil {
+		t.Fatalf("first sync returned error: %v", err)
+	}
+	firstHash := store.hash
+
+	writeRunbook(t, dir, "tcp.md", runbookText+" Raise net.core.wmem_max if the queue keeps growing.")
+
+	ingested, err := SyncKnowledgeDir(context.Background(), dir, store, &mockEmbedder{}, nil)
+	if err != nil {
+		t.Fatalf("second sync returned error: %v", err)
+	}
+	if !ingested || store.replaces != 2 {
+		t.Fatalf("expected re-ingestion after change, ingested=%v replaces=%d", ingested, store.replaces)
+	}
+	if store.hash == firstHash {
+		t.Error("expected hash to change with file content")
+	}
+	if !strings.Contains(store.docs[0].Content, "wmem_max") {
+		t.Errorf("expected updated content to be indexed, got %q", store.docs[0].Content)
+	}
+}
+
+func TestPointID_StableUUIDPerChunk(t *testing.T) {
+	id := pointID("grpc/stalls.md", 0)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-8[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("expected a UUID, got %q", id)
+	}
+	if pointID("grpc/stalls.md", 0) != id {
+		t.Error("expected the same chunk to keep its ID across ingests")
+	}
+	if pointID("grpc/stalls.md", 1) == id || pointID("tcp.md", 0) == id {
+		t.Error("expected other chunks and files to get other IDs")
+	}
+}
+
+func TestChunkText_Overlap(t *testing.T) {
+	words := make([]string, 1000)
+	for i := range words {
+		words[i] = "word"
+	}
+
+	chunks := chunkText(strings.Join(words, " "))
+	// Windows start at 0, 462, 924.
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if n := len(strings.Fields(chunks[0])); n != chunkSizeWords {
+		t.Errorf("expected first chunk of %d words, got %d", chunkSizeWords, n)
+	}
+
+	if got := chunkText("too short"); len(got) != 0 {
+		t.Errorf("expected short text to be dropped, got %v", got)
+	}
+}
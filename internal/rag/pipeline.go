@@ -2,9 +2,12 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/friday/internal/config"
+	"github.com/friday/internal/truncate"
 	"github.com/friday/internal/types"
 	"go.uber.org/zap"
 )
@@ -14,6 +17,7 @@ type Pipeline struct {
 	retriever     *Retriever
 	topK          int
 	minSimilarity float32
+	ranking       config.RankingConfig
 	logger        *zap.Logger
 }
 
@@ -33,6 +37,11 @@ func NewPipeline(cfg *config.Config, logger *zap.Logger) (*Pipeline, error) {
 			MaxLength:     cfg.ONNX.MaxSequenceLength,
 			Dimension:     cfg.ONNX.EmbeddingDim,
 		},
+		EmbedRetry: EmbedRetryConfig{
+			Retries: cfg.RAG.EmbedRetries,
+			Timeout: time.Duration(cfg.RAG.EmbedTimeoutSeconds) * time.Second,
+			Backoff: DefaultEmbedRetryConfig().Backoff,
+		},
 	}
 
 	retriever, err := NewRetriever(retrieverCfg, logger)
@@ -44,6 +53,7 @@ func NewPipeline(cfg *config.Config, logger *zap.Logger) (*Pipeline, error) {
 		retriever:     retriever,
 		topK:          cfg.RAG.TopK,
 		minSimilarity: cfg.RAG.MinSimilarity,
+		ranking:       cfg.RAG.Ranking,
 		logger:        logger,
 	}, nil
 }
@@ -61,23 +71,46 @@ func NewPipelineWithRetriever(retriever *Retriever, topK int, minSimilarity floa
 	}
 }
 
-// Retrieve performs retrieval for a query.
+// SetSearchParams changes the default topK and minimum similarity used by
+// Retrieve. It must not be called concurrently with Retrieve.
+func (p *Pipeline) SetSearchParams(topK int, minSimilarity float32) {
+	p.topK = topK
+	p.minSimilarity = minSimilarity
+}
+
+// SetRanking changes how Retrieve blends vector scores with document
+// metadata. It must not be called concurrently with Retrieve.
+func (p *Pipeline) SetRanking(ranking config.RankingConfig) {
+	p.ranking = ranking
+}
+
+// Retrieve performs retrieval for a query. If the query cannot be embedded
+// even after retries, it returns an empty slice and an error wrapping
+// ErrEmbedding so callers can carry on without context.
 func (p *Pipeline) Retrieve(ctx context.Context, query string) ([]types.RetrievedChunk, error) {
 	if query == "" {
 		return nil, nil
 	}
 
 	chunks, err := p.retriever.Search(ctx, query, p.topK, p.minSimilarity)
+	if errors.Is(err, ErrEmbedding) {
+		p.logger.Warn("Query embedding failed, retrieving no context",
+			zap.Error(err),
+			zap.String("query_preview", truncate.String(query, 50)))
+		return []types.RetrievedChunk{}, err
+	}
 	if err != nil {
 		p.logger.Error("Retrieval failed",
 			zap.Error(err),
-			zap.String("query_preview", truncateString(query, 50)))
+			zap.String("query_preview", truncate.String(query, 50)))
 		return nil, err
 	}
 
+	chunks = rankChunks(chunks, p.ranking, time.Now())
+
 	p.logger.Info("Retrieval completed",
 		zap.Int("chunks_found", len(chunks)),
-		zap.String("query_preview", truncateString(query, 50)))
+		zap.String("query_preview", truncate.String(query, 50)))
 
 	return chunks, nil
 }
@@ -95,7 +128,33 @@ func (p *Pipeline) RetrieveWithOptions(ctx context.Context, query string, topK i
 		minSimilarity = p.minSimilarity
 	}
 
-	return p.retriever.Search(ctx, query, topK, minSimilarity)
+	chunks, err := p.retriever.Search(ctx, query, topK, minSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	return rankChunks(chunks, p.ranking, time.Now()), nil
+}
+
+// EnsureCollection creates the Qdrant collection with dim-sized vectors and
+// the given distance if it is missing, and returns an error wrapping
+// ErrCollectionMismatch if an existing collection has different settings.
+func (p *Pipeline) EnsureCollection(ctx context.Context, dim int, distance string) error {
+	created, err := p.retriever.EnsureCollection(ctx, dim, distance)
+	if err != nil {
+		return err
+	}
+	if created {
+		p.logger.Info("Created Qdrant collection",
+			zap.String("collection", p.retriever.CollectionName()),
+			zap.Int("dimension", dim))
+	}
+	return nil
+}
+
+// SyncKnowledgeDir ingests the markdown runbooks under dir when the indexed
+// collection is empty or was built from different content.
+func (p *Pipeline) SyncKnowledgeDir(ctx context.Context, dir string) (bool, error) {
+	return p.retriever.SyncKnowledgeDir(ctx, dir)
 }
 
 // Close releases pipeline resources.
@@ -0,0 +1,85 @@
+package rag
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/friday/internal/config"
+	"github.com/friday/internal/types"
+)
+
+// ingestedAtKey is the payload field holding when a chunk's source file was
+// last modified before it was indexed, in Unix seconds.
+const ingestedAtKey = "ingested_at"
+
+// vectorScoreKey is the metadata field rankChunks keeps a chunk's original
+// vector similarity in once Score holds the blended score.
+const vectorScoreKey = "vector_score"
+
+// rankChunks replaces each chunk's Score with the weighted mean of its
+// vector score, its recency and its source priority, leaving out the
+// signals a chunk has no metadata for, and sorts chunks by it, highest
+// first. With only the vector weight set the order is unchanged.
+func rankChunks(chunks []types.RetrievedChunk, cfg config.RankingConfig, now time.Time) []types.RetrievedChunk {
+	if cfg.RecencyWeight == 0 && cfg.PriorityWeight == 0 {
+		return chunks
+	}
+	for i := range chunks {
+		c := &chunks[i]
+		sum, weights := cfg.VectorWeight*c.Score, cfg.VectorWeight
+
+		if cfg.RecencyWeight > 0 {
+			if ingested, ok := ingestedAt(c.Metadata); ok {
+				age := now.Sub(ingested).Hours() / 24
+				sum += cfg.RecencyWeight * math.Pow(0.5, math.Max(age, 0)/cfg.RecencyHalfLifeDays)
+				weights += cfg.RecencyWeight
+			}
+		}
+		if cfg.PriorityWeight > 0 {
+			if priority, ok := sourcePriority(cfg.SourcePriority, c.Source); ok {
+				sum += cfg.PriorityWeight * priority
+				weights += cfg.PriorityWeight
+			}
+		}
+
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]interface{})
+		}
+		c.Metadata[vectorScoreKey] = c.Score
+		if weights > 0 {
+			c.Score = sum / weights
+		}
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].Score > chunks[j].Score })
+	return chunks
+}
+
+// ingestedAt reads a chunk's ingest time, stored as Unix seconds or an
+// RFC 3339 string.
+func ingestedAt(metadata map[string]interface{}) (time.Time, bool) {
+	switch v := metadata[ingestedAtKey].(type) {
+	case int64:
+		return time.Unix(v, 0), v > 0
+	case float64:
+		return time.Unix(int64(v), 0), v > 0
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// sourcePriority returns the priority of the longest key in priorities that
+// source starts with.
+func sourcePriority(priorities map[string]float64, source string) (float64, bool) {
+	best, found := -1, false
+	var priority float64
+	for prefix, p := range priorities {
+		if strings.HasPrefix(source, prefix) && len(prefix) > best {
+			best, priority, found = len(prefix), p, true
+		}
+	}
+	return priority, found
+}
@@ -0,0 +1,149 @@
+package rag
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/config"
+	"github.com/friday/internal/types"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+var rankNow = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func daysAgo(days int) int64 {
+	return rankNow.AddDate(0, 0, -days).Unix()
+}
+
+func rankCandidates() []types.RetrievedChunk {
+	return []types.RetrievedChunk{
+		{Source: "wiki/old-grpc.md", Score: 0.90, Metadata: map[string]interface{}{ingestedAtKey: daysAgo(360)}},
+		{Source: "runbooks/canonical/grpc.md", Score: 0.80, Metadata: map[string]interface{}{ingestedAtKey: daysAgo(0)}},
+		{Source: "runbooks/archive/grpc.md", Score: 0.85, Metadata: map[string]interface{}{ingestedAtKey: daysAgo(30)}},
+		{Source: "notes.md", Score: 0.88},
+	}
+}
+
+func rankedSources(chunks []types.RetrievedChunk) []string {
+	var sources []string
+	for _, c := range chunks {
+		sources = append(sources, c.Source)
+	}
+	return sources
+}
+
+func assertOrder(t *testing.T, got []types.RetrievedChunk, want ...string) {
+	t.Helper()
+	sources := rankedSources(got)
+	if len(sources) != len(want) {
+		t.Fatalf("expected %v, got %v", want, sources)
+	}
+	for i := range want {
+		if sources[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, sources)
+		}
+	}
+}
+
+func TestRankChunks_VectorOnlyKeepsOrder(t *testing.T) {
+	got := rankChunks(rankCandidates(), config.RankingConfig{VectorWeight: 1, RecencyHalfLifeDays: 90}, rankNow)
+	assertOrder(t, got, "wiki/old-grpc.md", "runbooks/canonical/grpc.md", "runbooks/archive/grpc.md", "notes.md")
+	if got[0].Score != 0.90 {
+		t.Errorf("expected the vector score untouched, got %v", got[0].Score)
+	}
+}
+
+func TestRankChunks_Recency(t *testing.T) {
+	got := rankChunks(rankCandidates(), config.RankingConfig{
+		VectorWeight: 1, RecencyWeight: 1, RecencyHalfLifeDays: 30,
+	}, rankNow)
+
+	// canonical (0.80+1)/2=0.90, notes has no ingest time so stays 0.88,
+	// archive (0.85+0.5)/2=0.675, wiki (0.90+0.5^12)/2≈0.45.
+	assertOrder(t, got, "runbooks/canonical/grpc.md", "notes.md", "runbooks/archive/grpc.md", "wiki/old-grpc.md")
+	if math.Abs(got[0].Score-0.90) > 1e-9 || got[1].Score != 0.88 {
+		t.Errorf("unexpected blended scores %v, %v", got[0].Score, got[1].Score)
+	}
+	if got[0].Metadata[vectorScoreKey] != 0.80 {
+		t.Errorf("expected the vector score kept in metadata, got %v", got[0].Metadata)
+	}
+}
+
+func TestRankChunks_SourcePriority(t *testing.T) {
+	got := rankChunks(rankCandidates(), config.RankingConfig{
+		VectorWeight: 1, PriorityWeight: 3, RecencyHalfLifeDays: 90,
+		SourcePriority: map[string]float64{
+			"runbooks/":          0.5,
+			"runbooks/canonical": 1,
+			"wiki/":              0,
+		},
+	}, rankNow)
+
+	// canonical (0.80+3)/4=0.95, notes unmatched 0.88,
+	// archive (0.85+1.5)/4≈0.59, wiki 0.90/4=0.225.
+	assertOrder(t, got, "runbooks/canonical/grpc.md", "notes.md", "runbooks/archive/grpc.md", "wiki/old-grpc.md")
+}
+
+func TestRankChunks_VectorWeightDominates(t *testing.T) {
+	got := rankChunks(rankCandidates(), config.RankingConfig{
+		VectorWeight: 100, RecencyWeight: 1, PriorityWeight: 1, RecencyHalfLifeDays: 30,
+		SourcePriority: map[string]float64{"runbooks/canonical": 1},
+	}, rankNow)
+
+	// Recency and priority only nudge the scores, so the similarity order
+	// holds.
+	assertOrder(t, got, "wiki/old-grpc.md", "notes.md", "runbooks/archive/grpc.md", "runbooks/canonical/grpc.md")
+}
+
+func TestIngestedAt_Formats(t *testing.T) {
+	for _, v := range []interface{}{daysAgo(1), float64(daysAgo(1)), rankNow.AddDate(0, 0, -1).Format(time.RFC3339)} {
+		got, ok := ingestedAt(map[string]interface{}{ingestedAtKey: v})
+		if !ok || !got.Equal(rankNow.AddDate(0, 0, -1)) {
+			t.Errorf("%T %v: got %v, %v", v, v, got, ok)
+		}
+	}
+	if _, ok := ingestedAt(map[string]interface{}{ingestedAtKey: "yesterday"}); ok {
+		t.Error("expected an unparseable ingest time to be ignored")
+	}
+}
+
+func TestRankChunks_RecencyFromIngestedPayloads(t *testing.T) {
+	dir := t.TempDir()
+	writeRunbook(t, dir, "old.md", runbookText)
+	writeRunbook(t, dir, "new.md", runbookText)
+	old := rankNow.AddDate(0, 0, -365)
+	if err := os.Chtimes(filepath.Join(dir, "old.md"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "new.md"), rankNow, rankNow); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &mockStore{}
+	if err := IngestDirectory(context.Background(), dir, "h", store, &mockEmbedder{}, nil); err != nil {
+		t.Fatalf("IngestDirectory returned error: %v", err)
+	}
+
+	// Read each chunk back the way retrieval does, from its stored payload,
+	// with the older file scoring slightly higher on similarity.
+	var chunks []types.RetrievedChunk
+	for _, doc := range store.docs {
+		score := 0.80
+		if strings.HasSuffix(doc.Source, "old.md") {
+			score = 0.85
+		}
+		chunks = append(chunks, types.RetrievedChunk{
+			Source:   doc.Source,
+			Score:    score,
+			Metadata: convertPayload(qdrant.NewValueMap(docPayload(doc, "h"))),
+		})
+	}
+
+	got := rankChunks(chunks, config.RankingConfig{VectorWeight: 1, RecencyWeight: 1, RecencyHalfLifeDays: 30}, rankNow)
+	assertOrder(t, got, filepath.Join(dir, "new.md"), filepath.Join(dir, "old.md"))
+}
@@ -2,18 +2,54 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/friday/internal/truncate"
 	"github.com/friday/internal/types"
 	"github.com/qdrant/go-client/qdrant"
 	"go.uber.org/zap"
 )
 
+// ErrEmbedding marks a search that failed because the query could not be
+// embedded, as opposed to a Qdrant failure.
+var ErrEmbedding = errors.New("query embedding failed")
+
+// QueryEmbedder embeds a single search query.
+// *EmbeddingClient satisfies this.
+type QueryEmbedder interface {
+	EmbedSingle(text string) ([]float32, error)
+}
+
+// EmbedRetryConfig bounds the query embedding call. The embedder runs
+// in-process, so every failure is treated as transient and retried.
+type EmbedRetryConfig struct {
+	// Retries is the number of attempts after the first.
+	Retries int
+	// Timeout limits each attempt; zero means only ctx limits it.
+	Timeout time.Duration
+	// Backoff is the delay before the first retry, doubled for each one after.
+	Backoff time.Duration
+}
+
+// DefaultEmbedRetryConfig returns the retry settings used when none are configured.
+func DefaultEmbedRetryConfig() EmbedRetryConfig {
+	return EmbedRetryConfig{
+		Retries: 2,
+		Timeout: 5 * time.Second,
+		Backoff: 100 * time.Millisecond,
+	}
+}
+
 // Retriever handles document retrieval from Qdrant using ONNX embeddings.
 type Retriever struct {
 	client         *qdrant.Client
+	admin          CollectionAdmin
 	collectionName string
 	embedder       *EmbeddingClient
+	query          QueryEmbedder
+	embedRetry     EmbedRetryConfig
 	logger         *zap.Logger
 }
 
@@ -23,6 +59,7 @@ type RetrieverConfig struct {
 	QdrantPort      int
 	CollectionName  string
 	EmbeddingConfig EmbeddingConfig
+	EmbedRetry      EmbedRetryConfig
 }
 
 // NewRetriever creates a new retriever with ONNX embeddings.
@@ -49,8 +86,11 @@ func NewRetriever(cfg RetrieverConfig, logger *zap.Logger) (*Retriever, error) {
 
 	return &Retriever{
 		client:         client,
+		admin:          client,
 		collectionName: cfg.CollectionName,
 		embedder:       embedder,
+		query:          embedder,
+		embedRetry:     cfg.EmbedRetry,
 		logger:         logger,
 	}, nil
 }
@@ -58,15 +98,18 @@ func NewRetriever(cfg RetrieverConfig, logger *zap.Logger) (*Retriever, error) {
 // Search performs semantic search on the Qdrant collection.
 func (r *Retriever) Search(ctx context.Context, query string, topK int, minScore float32) ([]types.RetrievedChunk, error) {
 	// Generate query embedding using ONNX
-	queryEmbedding, err := r.embedder.EmbedSingle(query)
+	start := time.Now()
+	queryEmbedding, err := embedWithRetry(ctx, r.query, query, r.embedRetry, r.logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to embed query: %w", err)
+		return nil, err
 	}
+	types.ObserveStage(ctx, types.StageRAGEmbed, start)
 
 	// Convert topK to uint64 pointer
 	limit := uint64(topK)
 
 	// Search Qdrant
+	start = time.Now()
 	searchResult, err := r.client.Query(ctx, &qdrant.QueryPoints{
 		CollectionName: r.collectionName,
 		Query:          qdrant.NewQuery(queryEmbedding...),
@@ -77,6 +120,7 @@ func (r *Retriever) Search(ctx context.Context, query string, topK int, minScore
 	if err != nil {
 		return nil, fmt.Errorf("Qdrant search failed: %w", err)
 	}
+	types.ObserveStage(ctx, types.StageRAGSearch, start)
 
 	// Convert results to RetrievedChunk
 	chunks := make([]types.RetrievedChunk, 0, len(searchResult))
@@ -106,12 +150,77 @@ func (r *Retriever) Search(ctx context.Context, query string, topK int, minScore
 
 	r.logger.Info("Search completed",
 		zap.Int("results", len(chunks)),
-		zap.String("query_preview", truncateString(query, 50)),
+		zap.String("query_preview", truncate.String(query, 50)),
 		zap.Float32("min_score", minScore))
 
 	return chunks, nil
 }
 
+// embedWithRetry embeds text, retrying with exponential backoff until it
+// succeeds, cfg.Retries is exhausted, or ctx is done. The returned error wraps
+// ErrEmbedding and the last failure.
+func embedWithRetry(ctx context.Context, e QueryEmbedder, text string, cfg EmbedRetryConfig, logger *zap.Logger) ([]float32, error) {
+	backoff := cfg.Backoff
+	var lastErr error
+
+	for attempt := 0; attempt <= cfg.Retries; attempt++ {
+		if attempt > 0 {
+			logger.Warn("Retrying query embedding",
+				zap.Int("attempt", attempt+1),
+				zap.Duration("backoff", backoff),
+				zap.Error(lastErr))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%w: %v (last error: %v)", ErrEmbedding, ctx.Err(), lastErr)
+			}
+			backoff *= 2
+		}
+
+		vec, err := embedOnce(ctx, e, text, cfg.Timeout)
+		if err == nil {
+			return vec, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("%w after %d attempt(s): %v", ErrEmbedding, cfg.Retries+1, lastErr)
+}
+
+// embedOnce runs a single embedding attempt, giving up after timeout. The
+// embedder is not context-aware, so an abandoned attempt finishes in the
+// background and its result is discarded.
+func embedOnce(ctx context.Context, e QueryEmbedder, text string, timeout time.Duration) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		vec []float32
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		vec, err := e.EmbedSingle(text)
+		done <- result{vec, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.vec, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // Close releases retriever resources.
 func (r *Retriever) Close() error {
 	if r.embedder != nil {
@@ -125,6 +234,23 @@ func (r *Retriever) CollectionName() string {
 	return r.collectionName
 }
 
+// EnsureCollection creates the retriever's collection if it is missing. See
+// the package-level EnsureCollection.
+func (r *Retriever) EnsureCollection(ctx context.Context, dim int, distance string) (bool, error) {
+	return EnsureCollection(ctx, r.admin, r.collectionName, dim, distance)
+}
+
+// SyncKnowledgeDir indexes the markdown files under dir into the retriever's
+// collection when its contents have changed since the last sync.
+func (r *Retriever) SyncKnowledgeDir(ctx context.Context, dir string) (bool, error) {
+	store := &qdrantStore{
+		client:     r.client,
+		collection: r.collectionName,
+		dimension:  r.embedder.config.Dimension,
+	}
+	return SyncKnowledgeDir(ctx, dir, store, r.embedder, r.logger)
+}
+
 // getPayloadString extracts a string value from Qdrant payload.
 func getPayloadString(payload map[string]*qdrant.Value, key string) (string, bool) {
 	if val, ok := payload[key]; ok {
@@ -165,11 +291,3 @@ func convertPayload(payload map[string]*qdrant.Value) map[string]interface{} {
 	}
 	return result
 }
-
-// truncateString truncates a string to maxLen characters.
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen] + "..."
-}
@@ -0,0 +1,106 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// flakyEmbedder fails its first failures calls, then returns a fixed vector.
+// A negative failures fails forever.
+type flakyEmbedder struct {
+	failures int32
+	calls    atomic.Int32
+	delay    time.Duration
+}
+
+func (f *flakyEmbedder) EmbedSingle(text string) ([]float32, error) {
+	n := f.calls.Add(1)
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	if f.failures < 0 || n <= f.failures {
+		return nil, errors.New("onnx session run failed")
+	}
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func testRetryConfig(retries int) EmbedRetryConfig {
+	return EmbedRetryConfig{Retries: retries, Timeout: time.Second, Backoff: time.Millisecond}
+}
+
+func TestEmbedWithRetry_SucceedsAfterFailures(t *testing.T) {
+	e := &flakyEmbedder{failures: 2}
+
+	vec, err := embedWithRetry(context.Background(), e, "query", testRetryConfig(2), zap.NewNop())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vec) != 3 {
+		t.Errorf("expected 3-dim vector, got %v", vec)
+	}
+	if e.calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", e.calls.Load())
+	}
+}
+
+func TestEmbedWithRetry_ExhaustsRetries(t *testing.T) {
+	e := &flakyEmbedder{failures: -1}
+
+	_, err := embedWithRetry(context.Background(), e, "query", testRetryConfig(2), zap.NewNop())
+	if !errors.Is(err, ErrEmbedding) {
+		t.Fatalf("expected ErrEmbedding, got %v", err)
+	}
+	if e.calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", e.calls.Load())
+	}
+}
+
+func TestEmbedWithRetry_AttemptTimeout(t *testing.T) {
+	e := &flakyEmbedder{delay: 200 * time.Millisecond}
+	cfg := EmbedRetryConfig{Retries: 0, Timeout: 20 * time.Millisecond}
+
+	start := time.Now()
+	_, err := embedWithRetry(context.Background(), e, "query", cfg, zap.NewNop())
+	if !errors.Is(err, ErrEmbedding) {
+		t.Fatalf("expected ErrEmbedding, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("attempt should time out quickly, took %v", elapsed)
+	}
+}
+
+func TestEmbedWithRetry_StopsOnContextDone(t *testing.T) {
+	e := &flakyEmbedder{failures: -1}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := embedWithRetry(ctx, e, "query", EmbedRetryConfig{Retries: 5, Backoff: time.Second}, zap.NewNop())
+	if !errors.Is(err, ErrEmbedding) {
+		t.Fatalf("expected ErrEmbedding, got %v", err)
+	}
+	if e.calls.Load() != 0 {
+		t.Errorf("expected no attempts after cancellation, got %d calls", e.calls.Load())
+	}
+}
+
+func TestPipelineRetrieve_EmbeddingFailure_EmptyResult(t *testing.T) {
+	e := &flakyEmbedder{failures: -1}
+	retriever := &Retriever{query: e, embedRetry: testRetryConfig(1), logger: zap.NewNop()}
+	p := NewPipelineWithRetriever(retriever, 5, 0.7, nil)
+
+	chunks, err := p.Retrieve(context.Background(), "why is grpc slow")
+	if !errors.Is(err, ErrEmbedding) {
+		t.Fatalf("expected ErrEmbedding, got %v", err)
+	}
+	if chunks == nil || len(chunks) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", chunks)
+	}
+	if e.calls.Load() != 2 {
+		t.Errorf("expected 2 calls, got %d", e.calls.Load())
+	}
+}
@@ -0,0 +1,224 @@
+// Package truncate shortens text for display and prompts without cutting a
+// UTF-8 character in two, and shortens JSON function output so what is left
+// is still valid, readable JSON.
+package truncate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ellipsis marks text that was cut.
+const Ellipsis = "..."
+
+// moreKey is the field Output adds to an object to say how many fields were
+// left out.
+const moreKey = "..."
+
+// String returns s cut to at most max bytes, on a character boundary, with
+// Ellipsis appended if anything was removed.
+func String(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	if max < 0 {
+		max = 0
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + Ellipsis
+}
+
+// TailLines returns the last n lines of s. n <= 0 means unlimited and
+// returns s unchanged.
+func TailLines(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// Output shortens function output to about max bytes. A JSON object keeps
+// its fields in order while they fit; a field whose value does not fit is
+// replaced by a summary such as "<array of 12 items>", and the fields after
+// the budget runs out are counted in a final "..." field. A JSON array is
+// shortened the same way, item by item. Anything else is cut as by String.
+// The result is valid JSON whenever output was.
+func Output(output string, max int) string {
+	if len(output) <= max {
+		return output
+	}
+	trimmed := strings.TrimSpace(output)
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		if s, ok := object(trimmed, max); ok {
+			return s
+		}
+	case strings.HasPrefix(trimmed, "["):
+		if s, ok := array(trimmed, max); ok {
+			return s
+		}
+	}
+	return String(output, max)
+}
+
+// object shortens a JSON object; ok is false if s is not one.
+func object(s string, max int) (string, bool) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return "", false
+	}
+
+	var (
+		buf     bytes.Buffer
+		kept    int
+		omitted int
+	)
+	buf.WriteByte('{')
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", false
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return "", false
+		}
+		if omitted > 0 {
+			omitted++
+			continue
+		}
+
+		name := quote(key)
+		// Leave room for the closing brace and a separating comma.
+		room := max - buf.Len() - len(name) - 3
+		value, ok := fit(raw, room)
+		if !ok {
+			omitted++
+			continue
+		}
+		if kept > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(name)
+		buf.WriteByte(':')
+		buf.WriteString(value)
+		kept++
+	}
+	if _, err := dec.Token(); err != nil {
+		return "", false
+	}
+
+	if omitted > 0 {
+		if kept > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:%q", moreKey, plural(omitted, "more field"))
+	}
+	buf.WriteByte('}')
+	return buf.String(), true
+}
+
+// array shortens a JSON array; ok is false if s is not one.
+func array(s string, max int) (string, bool) {
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(s), &items); err != nil {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	kept := 0
+	for _, item := range items {
+		value, ok := fit(item, max-buf.Len()-2)
+		if !ok {
+			break
+		}
+		if kept > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(value)
+		kept++
+	}
+	if rest := len(items) - kept; rest > 0 {
+		if kept > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q", Ellipsis+plural(rest, "more item"))
+	}
+	buf.WriteByte(']')
+	return buf.String(), true
+}
+
+// fit returns raw compacted if it is at most room bytes, or else a summary
+// that is: a shortened string, or "<object with N fields>" or "<array of N
+// items>" for a container. ok is false if not even the summary fits.
+func fit(raw json.RawMessage, room int) (string, bool) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return "", false
+	}
+	if compact.Len() <= room {
+		return compact.String(), true
+	}
+
+	var summary string
+	switch compact.Bytes()[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(compact.Bytes(), &s); err != nil {
+			return "", false
+		}
+		// Quoting can lengthen the text, so shrink until it fits.
+		for n := room - 2 - len(Ellipsis); n > 0; n -= 8 {
+			quoted := quote(String(s, n))
+			if len(quoted) <= room {
+				return string(quoted), true
+			}
+		}
+		return "", false
+	case '{':
+		var fields map[string]json.RawMessage
+		_ = json.Unmarshal(compact.Bytes(), &fields)
+		summary = fmt.Sprintf("<object with %s>", plural(len(fields), "field"))
+	case '[':
+		var items []json.RawMessage
+		_ = json.Unmarshal(compact.Bytes(), &items)
+		summary = fmt.Sprintf("<array of %s>", plural(len(items), "item"))
+	default:
+		return "", false // a number or literal cannot be shortened
+	}
+	quoted := quote(summary)
+	if len(quoted) > room {
+		return "", false
+	}
+	return string(quoted), true
+}
+
+// quote encodes s as a JSON string, leaving <, > and & as they are so
+// summaries read naturally.
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,108 @@
+package truncate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestString_NeverSplitsARune(t *testing.T) {
+	s := strings.Repeat("héllo wörld — ✓ 日本語 ", 10)
+	for max := 0; max < len(s); max++ {
+		got := String(s, max)
+		if !utf8.ValidString(got) {
+			t.Fatalf("max %d: cut mid-character: %q", max, got)
+		}
+		if len(got) > max+len(Ellipsis) || !strings.HasSuffix(got, Ellipsis) {
+			t.Fatalf("max %d: unexpected result %q", max, got)
+		}
+	}
+	if got := String("short", 10); got != "short" {
+		t.Errorf("expected short text unchanged, got %q", got)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	if got := TailLines("a\nb\nc", 5); got != "a\nb\nc" {
+		t.Errorf("expected short input unchanged, got %q", got)
+	}
+	if got := TailLines("a\nb\nc\n", 1); got != "c" {
+		t.Errorf("expected last line, got %q", got)
+	}
+	if got := TailLines("a\nb", 0); got != "a\nb" {
+		t.Errorf("expected n <= 0 to keep everything, got %q", got)
+	}
+}
+
+func TestOutput_SummarizesJSONObject(t *testing.T) {
+	output := `{"port": 50051, "state": "ESTABLISHED", "connections": [` +
+		strings.Repeat(`{"local":"10.0.0.1:50051","remote":"10.0.0.2:40000","retransmits":3},`, 40) +
+		`{"local":"10.0.0.1:50051"}], "note": "ok", "tail": "x"}`
+
+	got := Output(output, 120)
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", got, err)
+	}
+	if parsed["port"] != float64(50051) || parsed["state"] != "ESTABLISHED" || parsed["note"] != "ok" {
+		t.Errorf("expected the small fields kept, got %v", parsed)
+	}
+	if parsed["connections"] != "<array of 41 items>" {
+		t.Errorf("expected the large array summarized, got %v", parsed["connections"])
+	}
+	if strings.Contains(got, `\u003c`) {
+		t.Errorf("expected the summary left unescaped, got %s", got)
+	}
+	if len(got) > 120 {
+		t.Errorf("expected at most 120 bytes, got %d: %s", len(got), got)
+	}
+}
+
+func TestOutput_CountsFieldsThatDoNotFit(t *testing.T) {
+	output := `{"a":1,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"i":9,"j":10}`
+	got := Output(output, 30)
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", got, err)
+	}
+	if parsed["a"] != float64(1) || !strings.HasSuffix(got, `"...":"6 more fields"}`) {
+		t.Errorf("expected leading fields then a count of the rest, got %s", got)
+	}
+}
+
+func TestOutput_LongStringValueCutOnRuneBoundary(t *testing.T) {
+	output := `{"error": "` + strings.Repeat("ошибка ", 100) + `"}`
+	got := Output(output, 80)
+	var parsed map[string]string
+	if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", got, err)
+	}
+	if !utf8.ValidString(parsed["error"]) || !strings.HasSuffix(parsed["error"], Ellipsis) {
+		t.Errorf("expected a shortened, valid string, got %q", parsed["error"])
+	}
+}
+
+func TestOutput_Array(t *testing.T) {
+	output := `[` + strings.Repeat(`"item",`, 50) + `"last"]`
+	got := Output(output, 40)
+	var parsed []string
+	if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", got, err)
+	}
+	if last := parsed[len(parsed)-1]; !strings.HasPrefix(last, Ellipsis) || !strings.HasSuffix(last, "more items") {
+		t.Errorf("expected a count of the items left out, got %v", parsed)
+	}
+}
+
+func TestOutput_PlainTextAndInvalidJSON(t *testing.T) {
+	if got := Output("∑ "+strings.Repeat("a", 50), 3); got != "∑..." {
+		t.Errorf("expected plain text cut as by String, got %q", got)
+	}
+	if got := Output(`{"broken": `+strings.Repeat("1", 50), 12); got != `{"broken": 1...` {
+		t.Errorf("expected invalid JSON cut as text, got %q", got)
+	}
+	if got := Output(`{"a":1}`, 100); got != `{"a":1}` {
+		t.Errorf("expected short output unchanged, got %q", got)
+	}
+}
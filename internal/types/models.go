@@ -1,7 +1,10 @@
 // Package types defines shared data structures for the telemetry debugger.
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Query represents a user query with metadata.
 type Query struct {
@@ -44,6 +47,46 @@ type ExecutionResult struct {
 	Error      string
 	Duration   time.Duration
 	RetryCount int
+	// SideEffect is true when the function changed the system rather than
+	// only observing it.
+	SideEffect bool
+	// Unredacted is the output before credentials were masked in it, shown
+	// locally and never sent to the LLM or saved. Empty when nothing was
+	// redacted.
+	Unredacted string `json:"-"`
+}
+
+// Transaction outcomes reported in TransactionSummary.Status.
+const (
+	TxCommitted      = "committed"
+	TxRolledBack     = "rolled_back"
+	TxRollbackFailed = "rollback_failed"
+	TxAborted        = "aborted" // stopped before any change was made
+	TxDeclined       = "declined"
+	TxDryRun         = "dry_run"
+)
+
+// PhaseSummary counts the functions run in one transaction phase.
+type PhaseSummary struct {
+	Phase      string  `json:"phase"`
+	Functions  int     `json:"functions"`
+	Succeeded  int     `json:"succeeded"`
+	Failed     int     `json:"failed"`
+	Skipped    int     `json:"skipped"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// TransactionSummary describes how a transaction ran: each phase that had
+// functions, in execution order, and whether it committed or rolled back.
+type TransactionSummary struct {
+	Status     string         `json:"status"`
+	Phases     []PhaseSummary `json:"phases"`
+	DurationMs float64        `json:"duration_ms"`
+	// RollbackError is set when Status is TxRollbackFailed.
+	RollbackError string `json:"rollback_error,omitempty"`
+	// TimedOut is set when the transaction's deadline stopped it; the
+	// phases then hold only what finished in time.
+	TimedOut bool `json:"timed_out,omitempty"`
 }
 
 // Message represents a message in the conversation history.
@@ -55,6 +98,40 @@ type Message struct {
 	Metadata  map[string]any    `json:"metadata,omitempty"`
 }
 
+// Function phases, matching the phase field values in functions.yaml.
+const (
+	PhaseRead    = "read"
+	PhaseAnalyze = "analyze"
+	PhaseModify  = "modify"
+)
+
+// Function stability levels, matching the stability field values in
+// functions.yaml. Deprecated functions are hidden from the LLM but still run.
+const (
+	StabilityStable       = "stable"
+	StabilityExperimental = "experimental"
+	StabilityDeprecated   = "deprecated"
+)
+
+// ValidStability reports whether stability is one of the declared
+// stability constants.
+func ValidStability(stability string) bool {
+	switch stability {
+	case StabilityStable, StabilityExperimental, StabilityDeprecated:
+		return true
+	}
+	return false
+}
+
+// ValidPhase reports whether phase is one of the declared phase constants.
+func ValidPhase(phase string) bool {
+	switch phase {
+	case PhaseRead, PhaseAnalyze, PhaseModify:
+		return true
+	}
+	return false
+}
+
 type FunctionDefinition struct {
 	Name             string                 `yaml:"name"`
 	Description      string                 `yaml:"description"`
@@ -68,16 +145,20 @@ type FunctionDefinition struct {
 	Parameters       []ParameterDefinition  `yaml:"parameters"`
 	Outputs          map[string]interface{} `yaml:"outputs"`
 	TimeoutSeconds   int                    `yaml:"timeout_seconds"`
+	// Stability is "stable", "experimental", or "deprecated"; empty is
+	// stable.
+	Stability string `yaml:"stability,omitempty"`
 }
 
 // ParameterDefinition describes a function parameter.
 type ParameterDefinition struct {
-	Name        string      `yaml:"name"`
-	Type        string      `yaml:"type"`
-	Required    bool        `yaml:"required"`
-	Default     interface{} `yaml:"default,omitempty"`
-	Description string      `yaml:"description"`
-	Validation  string      `yaml:"validation,omitempty"`
+	Name        string      `yaml:"name" json:"name"`
+	Type        string      `yaml:"type" json:"type"`
+	Required    bool        `yaml:"required" json:"required"`
+	Default     interface{} `yaml:"default,omitempty" json:"default,omitempty"`
+	Description string      `yaml:"description" json:"description"`
+	Validation  string      `yaml:"validation,omitempty" json:"validation,omitempty"`
+	Enum        []string    `yaml:"enum,omitempty" json:"enum,omitempty"`
 }
 
 // AgentState represents the current state of agent processing.
@@ -120,6 +201,35 @@ type AgentEvent struct {
 	FinalAnswer string
 	Error       error
 	ChunksFound int
+	// Sources are the knowledge-base chunks retrieved for the query, in
+	// score order, so the answer can cite what informed it.
+	Sources []RetrievedChunk
+
+	// PlanOnly is set when the agent stopped after validating the LLM's
+	// proposal; ProposedFunctions then holds the calls that were not run.
+	PlanOnly          bool
+	ProposedFunctions []FunctionCall
+
+	// Transaction summarises the phases and outcome when functions ran.
+	Transaction *TransactionSummary
+
+	// Progress is a status line from the function named by ToolCall while
+	// it is still running; such events carry nothing else.
+	Progress string
+
+	// Debug holds the prompt and raw LLM reply behind this event when LLM
+	// debugging is enabled; nil otherwise.
+	Debug *LLMDebug `json:"debug,omitempty"`
+}
+
+// LLMDebug is the exchange with the LLM for one query, with anything that
+// looks like a credential redacted.
+type LLMDebug struct {
+	// Prompt is the full prompt sent, including retrieved chunks and history.
+	Prompt string `json:"prompt"`
+	// RawResponse is the LLM's reply before validation; empty if the call
+	// failed.
+	RawResponse string `json:"raw_response"`
 }
 
 // ToolInfo contains metadata about a tool for display.
@@ -127,5 +237,25 @@ type ToolInfo struct {
 	Name        string                `json:"name"`
 	Description string                `json:"description"`
 	Category    string                `json:"category"`
+	Phase       string                `json:"phase"`
+	Stability   string                `json:"stability"`
 	Parameters  []ParameterDefinition `json:"parameters"`
 }
+
+// ToolFilter narrows a tool listing. Empty fields match every tool; set
+// fields compare case-insensitively.
+type ToolFilter struct {
+	Category string
+	Phase    string
+}
+
+// Matches reports whether tool passes the filter.
+func (f ToolFilter) Matches(tool ToolInfo) bool {
+	if f.Category != "" && !strings.EqualFold(f.Category, tool.Category) {
+		return false
+	}
+	if f.Phase != "" && !strings.EqualFold(f.Phase, tool.Phase) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaIssue is a problem found while validating a YAML file against its
+// schema. Field is a dotted path such as "functions[3].parameters[0].type";
+// Line is 0 when the problem cannot be tied to a line in the file.
+type SchemaIssue struct {
+	Line    int
+	Field   string
+	Message string
+}
+
+func (i SchemaIssue) String() string {
+	msg := i.Message
+	if i.Field != "" {
+		msg = i.Field + " " + msg
+	}
+	if i.Line > 0 {
+		return fmt.Sprintf("line %d: %s", i.Line, msg)
+	}
+	return msg
+}
+
+// yamlLinePattern matches the "line N: message" form yaml.v3 uses for
+// syntax and type errors.
+var yamlLinePattern = regexp.MustCompile(`line (\d+): (.*)`)
+
+// YAMLErrorIssues converts a yaml.v3 decode error into issues, keeping the
+// line numbers it reports. A *yaml.TypeError yields one issue per field.
+func YAMLErrorIssues(err error) []SchemaIssue {
+	msgs := []string{err.Error()}
+	if te, ok := err.(*yaml.TypeError); ok {
+		msgs = te.Errors
+	}
+
+	issues := make([]SchemaIssue, 0, len(msgs))
+	for _, msg := range msgs {
+		issue := SchemaIssue{Message: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+			issue.Message = m[2]
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// YAMLLine returns the line of the node at path (e.g. "llm.endpoint" or
+// "functions[2].parameters[0].type") under root. If the path does not exist
+// in full, it returns the line of the deepest node that does, so a missing
+// field is reported against its parent.
+func YAMLLine(root *yaml.Node, path string) int {
+	node := root
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node == nil {
+		return 0
+	}
+	line := node.Line
+
+	for _, part := range strings.Split(path, ".") {
+		key, indexes := splitIndexes(part)
+
+		if key != "" {
+			next := mappingValue(node, key)
+			if next == nil {
+				return line
+			}
+			node, line = next, next.Line
+		}
+		for _, idx := range indexes {
+			if node.Kind != yaml.SequenceNode || idx >= len(node.Content) {
+				return line
+			}
+			node = node.Content[idx]
+			line = node.Line
+		}
+	}
+	return line
+}
+
+// splitIndexes splits "parameters[0]" into "parameters" and [0].
+func splitIndexes(part string) (string, []int) {
+	key, rest, found := strings.Cut(part, "[")
+	if !found {
+		return part, nil
+	}
+	var indexes []int
+	for _, seg := range strings.Split(rest, "[") {
+		if n, err := strconv.Atoi(strings.TrimSuffix(seg, "]")); err == nil {
+			indexes = append(indexes, n)
+		}
+	}
+	return key, indexes
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// Stages of a query reported to a StageObserver.
+const (
+	StageRAGEmbed    = "rag_embed"
+	StageRAGSearch   = "rag_search"
+	StageLLMGenerate = "llm_generate"
+	StageToolExecute = "tool_execute"
+)
+
+// StageObserver is told how long each stage of a query took.
+type StageObserver func(stage string, elapsed time.Duration)
+
+// stageObserverKey is the context key under which WithStageObserver stores
+// the observer.
+type stageObserverKey struct{}
+
+// WithStageObserver returns a copy of ctx carrying observe, which the
+// retriever and agent call as each stage of a query finishes.
+func WithStageObserver(ctx context.Context, observe StageObserver) context.Context {
+	return context.WithValue(ctx, stageObserverKey{}, observe)
+}
+
+// ObserveStage reports the time since start for stage to the observer set
+// on ctx, if any.
+func ObserveStage(ctx context.Context, stage string, start time.Time) {
+	if observe, ok := ctx.Value(stageObserverKey{}).(StageObserver); ok && observe != nil {
+		observe(stage, time.Since(start))
+	}
+}
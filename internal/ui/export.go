@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/friday/internal/diagnostics"
+	"github.com/friday/internal/types"
+)
+
+// TranscriptEntry is one query of an interactive session and its outcome.
+type TranscriptEntry struct {
+	Time    time.Time          `json:"time"`
+	Query   string             `json:"query"`
+	Answer  string             `json:"answer,omitempty"`
+	Error   string             `json:"error,omitempty"`
+	Results []TranscriptResult `json:"results,omitempty"`
+}
+
+// TranscriptResult is one function run for a query.
+type TranscriptResult struct {
+	Function   string                 `json:"function"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Success    bool                   `json:"success"`
+	Output     json.RawMessage        `json:"output,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+}
+
+// Transcript records the queries of a session for export.
+type Transcript struct {
+	Entries []TranscriptEntry
+}
+
+// Add records query and what the agent returned for it.
+func (t *Transcript) Add(query string, event *types.AgentEvent, err error) {
+	entry := TranscriptEntry{Time: time.Now(), Query: query}
+	switch {
+	case err != nil:
+		entry.Error = err.Error()
+	case event != nil:
+		entry.Answer = event.FinalAnswer
+		if event.Error != nil {
+			entry.Error = event.Error.Error()
+		}
+		for _, r := range event.AllResults {
+			entry.Results = append(entry.Results, TranscriptResult{
+				Function:   r.Function.Name,
+				Params:     r.Function.Params,
+				Success:    r.Success,
+				Output:     rawOutput(r.Output),
+				Error:      r.Error,
+				DurationMs: r.Duration.Milliseconds(),
+			})
+		}
+	}
+	t.Entries = append(t.Entries, entry)
+}
+
+// rawOutput keeps a function's JSON output as JSON in the export, quoting
+// anything else as a string.
+func rawOutput(output string) json.RawMessage {
+	if output == "" {
+		return nil
+	}
+	if json.Valid([]byte(output)) {
+		return json.RawMessage(output)
+	}
+	quoted, _ := json.Marshal(output)
+	return quoted
+}
+
+// ExportTranscript writes the transcript to path, as markdown if path ends
+// in .md and as JSON otherwise. If anon is not nil, hostnames and IP
+// addresses are replaced throughout with its pseudonyms.
+func ExportTranscript(path string, t *Transcript, anon *diagnostics.Anonymizer) error {
+	if len(t.Entries) == 0 {
+		return errors.New("nothing to export yet: run a query first")
+	}
+
+	var out string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		out = FormatTranscriptMarkdown(t.Entries)
+	default:
+		data, err := json.MarshalIndent(t.Entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode transcript: %w", err)
+		}
+		out = string(data) + "\n"
+	}
+	if anon != nil {
+		out = anon.Anonymize(out)
+	}
+
+	if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// FormatTranscriptMarkdown renders entries as a markdown document, one
+// section per query.
+func FormatTranscriptMarkdown(entries []TranscriptEntry) string {
+	var sb strings.Builder
+	sb.WriteString("# friday session\n")
+	for i, e := range entries {
+		fmt.Fprintf(&sb, "\n## %d. %s\n\n", i+1, e.Query)
+		fmt.Fprintf(&sb, "_%s_\n", e.Time.Format(time.RFC3339))
+		for _, r := range e.Results {
+			status := "ok"
+			if !r.Success {
+				status = "failed"
+			}
+			fmt.Fprintf(&sb, "\n### %s (%s, %dms)\n", r.Function, status, r.DurationMs)
+			if len(r.Params) > 0 {
+				params, _ := json.Marshal(r.Params)
+				fmt.Fprintf(&sb, "\nParams: `%s`\n", params)
+			}
+			if r.Error != "" {
+				fmt.Fprintf(&sb, "\nError: %s\n", r.Error)
+			}
+			if len(r.Output) > 0 {
+				fmt.Fprintf(&sb, "\n```json\n%s\n```\n", indentJSON(r.Output))
+			}
+		}
+		if e.Error != "" {
+			fmt.Fprintf(&sb, "\n**Error:** %s\n", e.Error)
+		}
+		if e.Answer != "" {
+			fmt.Fprintf(&sb, "\n%s\n", e.Answer)
+		}
+	}
+	return sb.String()
+}
+
+func indentJSON(raw json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return string(raw)
+	}
+	return string(out)
+}
+
+// exportTranscript handles "/export <file> [--anonymize] [--keep-public]".
+func exportTranscript(t *Transcript, args []string, styles Styles) {
+	usage := "  usage: /export <file.md|file.json> [--anonymize] [--keep-public]"
+	var path string
+	anonymize, keepPublic := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--anonymize":
+			anonymize = true
+		case "--keep-public":
+			keepPublic = true
+		default:
+			if path != "" || strings.HasPrefix(arg, "-") {
+				fmt.Println(styles.ToolError.Render(usage))
+				return
+			}
+			path = arg
+		}
+	}
+	if path == "" || (keepPublic && !anonymize) {
+		fmt.Println(styles.ToolError.Render(usage))
+		return
+	}
+
+	var anon *diagnostics.Anonymizer
+	if anonymize {
+		anon = diagnostics.NewAnonymizer(keepPublic)
+	}
+	if err := ExportTranscript(path, t, anon); err != nil {
+		fmt.Println(styles.ToolError.Render("  " + err.Error()))
+		return
+	}
+	msg := fmt.Sprintf("  Exported %d queries to %s", len(t.Entries), path)
+	if anon != nil {
+		msg += fmt.Sprintf(" with %d hostnames and addresses masked", len(anon.Mapping()))
+	}
+	fmt.Println(styles.SystemMessage.Render(msg))
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+)
+
+// outputSettings controls how formatters render to stdout.
+type outputSettings struct {
+	// color enables ANSI styling, box-drawing dividers, and the spinner.
+	color bool
+	// width wraps long lines to this many columns; 0 disables wrapping.
+	width int
+}
+
+// output holds the settings applied by ConfigureOutput. The zero value of a
+// fresh process is styled and unwrapped, matching the behaviour before
+// ConfigureOutput existed.
+var output = outputSettings{color: true}
+
+// ConfigureOutput decides, once at startup, whether to style output and how
+// wide to wrap it. Styling is off when noColor is set, NO_COLOR is set to any
+// value, or stdout is not a terminal. Lines wrap to the terminal width, or to
+// $COLUMNS when stdout is not a terminal.
+func ConfigureOutput(noColor bool) {
+	fd := os.Stdout.Fd()
+	isTTY := term.IsTerminal(fd)
+	_, noColorEnv := os.LookupEnv("NO_COLOR")
+
+	width := 0
+	if isTTY {
+		if w, _, err := term.GetSize(fd); err == nil {
+			width = w
+		}
+	}
+	if width == 0 {
+		width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+
+	applyOutputSettings(outputSettings{
+		color: colorEnabled(noColor, isTTY, noColorEnv),
+		width: width,
+	})
+}
+
+// colorEnabled reports whether styled output should be used.
+func colorEnabled(noColorFlag, isTTY, noColorEnv bool) bool {
+	return !noColorFlag && !noColorEnv && isTTY
+}
+
+// applyOutputSettings installs s for the ui formatters. With color off it
+// also switches lipgloss to plain ASCII rendering, which covers the styles the
+// cmd package uses directly; with color on, lipgloss keeps the profile it
+// detected for the terminal.
+func applyOutputSettings(s outputSettings) {
+	output = s
+	if !s.color {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// ColorEnabled reports whether output is currently styled.
+func ColorEnabled() bool {
+	return output.color
+}
+
+// divider returns a section rule of up to n columns, box-drawing when styled
+// and plain dashes otherwise. It is shortened to fit the two-column section
+// indent within the configured width.
+func divider(n int) string {
+	if output.width > 0 && n > output.width-2 {
+		n = max(output.width-2, 0)
+	}
+	if output.color {
+		return strings.Repeat("─", n)
+	}
+	return strings.Repeat("-", n)
+}
+
+// wrapLines splits text on newlines and wraps each line to the configured
+// width less indent, so indented output still fits the terminal.
+func wrapLines(text string, indent int) []string {
+	lines := strings.Split(text, "\n")
+	limit := output.width - indent
+	if output.width == 0 || limit < 20 {
+		return lines
+	}
+
+	var out []string
+	for _, line := range lines {
+		out = append(out, strings.Split(ansi.Wrap(line, limit, ""), "\n")...)
+	}
+	return out
+}
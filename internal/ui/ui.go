@@ -5,15 +5,21 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 	"unicode"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/friday/internal/executor"
+	"github.com/friday/internal/truncate"
 	"github.com/friday/internal/types"
 )
 
@@ -22,6 +28,83 @@ type Agent interface {
 	ProcessQuery(ctx context.Context, query string) (*types.AgentEvent, error)
 }
 
+// PlanToggler is implemented by agents that support plan-only mode, where
+// proposed functions are shown but never executed.
+type PlanToggler interface {
+	PlanOnly() bool
+	SetPlanOnly(enabled bool)
+}
+
+// ProgressReporter is implemented by agents that stream status lines from
+// long-running functions while a query is processed.
+type ProgressReporter interface {
+	SetProgressHandler(fn func(types.AgentEvent))
+}
+
+// ToolLister is implemented by agents that can describe their registered
+// tools, filtered by category and phase.
+type ToolLister interface {
+	ListTools(filter types.ToolFilter) []types.ToolInfo
+}
+
+// PlanSaver is implemented by agents that can export the functions from the
+// last executed query as a replayable plan.
+type PlanSaver interface {
+	SavePlan(path string) error
+}
+
+// HistoryClearer is implemented by agents that keep conversation history,
+// which "clear" wipes along with any copy persisted between runs.
+type HistoryClearer interface {
+	ClearHistory() error
+}
+
+// ConfirmerSetter is implemented by agents that ask before running modify
+// operations, letting the front end supply the prompt.
+type ConfirmerSetter interface {
+	SetConfirmer(c executor.Confirmer)
+}
+
+// terminalConfirmer prompts for modify operations on the reader the front
+// end already owns, so a buffered line is never split between two readers.
+// It stops the running query's spinner first so the prompt is not drawn over.
+type terminalConfirmer struct {
+	prompt *executor.PromptConfirmer
+
+	mu           sync.Mutex
+	beforePrompt func()
+}
+
+// useConfirmer installs a terminalConfirmer reading from reader on agents
+// that accept one and returns it, or nil when the agent does not.
+func useConfirmer(agent Agent, reader *bufio.Reader) *terminalConfirmer {
+	setter, ok := agent.(ConfirmerSetter)
+	if !ok {
+		return nil
+	}
+	c := &terminalConfirmer{prompt: &executor.PromptConfirmer{In: reader, Out: os.Stdout}}
+	setter.SetConfirmer(c)
+	return c
+}
+
+// setBeforePrompt sets the function run before the next prompt is shown.
+func (c *terminalConfirmer) setBeforePrompt(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.beforePrompt = fn
+}
+
+// Confirm implements executor.Confirmer.
+func (c *terminalConfirmer) Confirm(ops []executor.OperationPreview) (bool, error) {
+	c.mu.Lock()
+	fn := c.beforePrompt
+	c.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+	return c.prompt.Confirm(ops)
+}
+
 // Run starts the interactive readline loop.
 func Run(agent Agent) {
 	styles := DefaultStyles()
@@ -32,6 +115,8 @@ func Run(agent Agent) {
 	fmt.Println()
 
 	reader := bufio.NewReader(os.Stdin)
+	confirm := useConfirmer(agent, reader)
+	transcript := &Transcript{}
 
 	// Handle Ctrl+C gracefully.
 	sig := make(chan os.Signal, 1)
@@ -56,12 +141,13 @@ func Run(agent Agent) {
 			continue
 		}
 
-		if handled := handleCommand(query, styles); handled {
+		if handled := handleCommand(query, agent, transcript, styles); handled {
 			continue
 		}
 
 		fmt.Println()
-		runQuery(agent, query, styles)
+		event, err := runQuery(agent, query, confirm, styles)
+		transcript.Add(query, event, err)
 		fmt.Println()
 	}
 }
@@ -69,56 +155,232 @@ func Run(agent Agent) {
 // RunOneShot runs a single query and exits -- used by `Friday "query"`.
 func RunOneShot(agent Agent, query string) {
 	styles := DefaultStyles()
+	confirm := useConfirmer(agent, bufio.NewReader(os.Stdin))
 	fmt.Println()
-	runQuery(agent, query, styles)
+	runQuery(agent, query, confirm, styles)
 	fmt.Println()
 }
 
-// runQuery executes a query against the agent and prints the result.
-func runQuery(agent Agent, query string, styles Styles) {
+// runQuery executes a query against the agent, prints the result, and
+// returns it. confirm, if non-nil, stops the spinner before prompting.
+func runQuery(agent Agent, query string, confirm *terminalConfirmer, styles Styles) (*types.AgentEvent, error) {
+	progress := make(chan string, 16)
+	if reporter, ok := agent.(ProgressReporter); ok {
+		reporter.SetProgressHandler(func(event types.AgentEvent) {
+			select {
+			case progress <- formatProgress(event):
+			default:
+				// The spinner is behind; drop the line rather than stall
+				// the function reporting it.
+			}
+		})
+		defer reporter.SetProgressHandler(nil)
+	}
+
 	done := make(chan struct{})
-	go runSpinner(styles, done)
+	stopped := make(chan struct{})
+	go func() {
+		runSpinner(styles, done, progress)
+		close(stopped)
+	}()
+	stopSpinner := sync.OnceFunc(func() {
+		close(done)
+		<-stopped
+		if output.color {
+			fmt.Print("\r\033[K")
+		}
+	})
+	if confirm != nil {
+		confirm.setBeforePrompt(stopSpinner)
+		defer confirm.setBeforePrompt(nil)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
 
 	event, err := agent.ProcessQuery(ctx, query)
-
-	close(done)
-	time.Sleep(15 * time.Millisecond)
-	fmt.Print("\r\033[K")
+	stopSpinner()
 
 	if err != nil {
 		fmt.Println(styles.ToolError.Render("  Error: " + err.Error()))
-		return
+		return nil, err
 	}
 
 	printEvent(event, styles)
+	return event, nil
 }
 
-// runSpinner prints an animated spinner until done is closed.
-func runSpinner(styles Styles, done chan struct{}) {
+// runSpinner prints an animated spinner until done is closed, showing the
+// latest line from progress in place of "Thinking..." once one arrives.
+// Without color the spinner is skipped, since its carriage returns would
+// litter piped output, and each progress line is printed as it arrives.
+func runSpinner(styles Styles, done chan struct{}, progress <-chan string) {
+	if !output.color {
+		for {
+			select {
+			case <-done:
+				return
+			case line := <-progress:
+				fmt.Println(styles.StatusText.Render("  " + line))
+			}
+		}
+	}
 	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	spinStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7C3AED"))
+	status := "Thinking..."
 	i := 0
 	for {
 		select {
 		case <-done:
 			return
+		case status = <-progress:
 		case <-time.After(80 * time.Millisecond):
-			fmt.Printf("\r  %s  %s",
+			line := status
+			// Keep the spinner on one row: "  ⠋  " takes five columns.
+			if output.width > 0 {
+				line = ansi.Truncate(line, max(output.width-6, 0), "…")
+			}
+			fmt.Printf("\r  %s  %s\033[K",
 				spinStyle.Render(frames[i%len(frames)]),
-				styles.StatusText.Render("Thinking..."),
+				styles.StatusText.Render(line),
 			)
 			i++
 		}
 	}
 }
 
+// printTools lists the agent's tools, narrowed by "--category <name>" and
+// "--phase <phase>" arguments.
+func printTools(agent Agent, args []string, styles Styles) {
+	lister, ok := agent.(ToolLister)
+	if !ok {
+		fmt.Println(styles.SystemMessage.Render("  Tool listing is not supported by this agent."))
+		return
+	}
+	filter, err := parseToolFilter(args)
+	if err != nil {
+		fmt.Println(styles.ToolError.Render("  " + err.Error()))
+		return
+	}
+	fmt.Println()
+	fmt.Println(styles.SystemMessage.Render(formatToolList(lister.ListTools(filter))))
+	fmt.Println()
+}
+
+// savePlan writes the agent's last executed plan to the file named in args.
+func savePlan(agent Agent, args []string, styles Styles) {
+	saver, ok := agent.(PlanSaver)
+	if !ok {
+		fmt.Println(styles.SystemMessage.Render("  Saving plans is not supported by this agent."))
+		return
+	}
+	if len(args) != 1 {
+		fmt.Println(styles.ToolError.Render("  usage: /save <file>"))
+		return
+	}
+	if err := saver.SavePlan(args[0]); err != nil {
+		fmt.Println(styles.ToolError.Render("  " + err.Error()))
+		return
+	}
+	fmt.Println(styles.SystemMessage.Render("  Plan saved to " + args[0] + "; run it again with: friday replay " + args[0]))
+}
+
+// parseToolFilter reads "--category X" and "--phase X" (or "--flag=X")
+// from the arguments to the tools command.
+func parseToolFilter(args []string) (types.ToolFilter, error) {
+	usage := errors.New("usage: tools [--category <name>] [--phase read|analyze|modify]")
+	var filter types.ToolFilter
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return filter, usage
+			}
+			i++
+			value = args[i]
+		}
+		switch flag {
+		case "--category":
+			filter.Category = value
+		case "--phase":
+			if !types.ValidPhase(strings.ToLower(value)) {
+				return filter, fmt.Errorf("unknown phase '%s' (want read, analyze, or modify)", value)
+			}
+			filter.Phase = value
+		default:
+			return filter, usage
+		}
+	}
+	return filter, nil
+}
+
+// formatToolList renders tools, which must be sorted by category, as one
+// wrapped row of names per category. Experimental and deprecated tools are
+// marked after their name.
+func formatToolList(tools []types.ToolInfo) string {
+	var sb strings.Builder
+	sb.WriteString("  Available Tools\n")
+	sb.WriteString("  " + divider(44))
+	if len(tools) == 0 {
+		sb.WriteString("\n  No tools match the filter")
+		return sb.String()
+	}
+
+	const nameWidth = 50
+	for i := 0; i < len(tools); {
+		category := tools[i].Category
+		var names []string
+		for ; i < len(tools) && tools[i].Category == category; i++ {
+			name := tools[i].Name
+			if stability := tools[i].Stability; stability == types.StabilityExperimental || stability == types.StabilityDeprecated {
+				name += " (" + stability + ")"
+			}
+			names = append(names, name)
+		}
+
+		line := fmt.Sprintf("\n  %-11s ", category)
+		width := 0
+		for j, name := range names {
+			entry := name
+			if j < len(names)-1 {
+				entry += ","
+			}
+			if width > 0 && width+1+len(entry) > nameWidth {
+				sb.WriteString(line)
+				line = "\n" + strings.Repeat(" ", 14)
+				width = 0
+			}
+			if width > 0 {
+				line += " "
+				width++
+			}
+			line += entry
+			width += len(entry)
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// formatProgress renders a progress event as "function: line".
+func formatProgress(event types.AgentEvent) string {
+	if event.ToolCall == nil {
+		return event.Progress
+	}
+	return event.ToolCall.Name + ": " + event.Progress
+}
+
+// PrintEvent renders an AgentEvent to stdout the way query results are shown,
+// for commands that run functions outside the agent loop.
+func PrintEvent(event *types.AgentEvent) {
+	printEvent(event, DefaultStyles())
+}
+
 // printEvent renders an AgentEvent to stdout.
 func printEvent(event *types.AgentEvent, styles Styles) {
 	if event.Error != nil {
 		fmt.Println(styles.ToolError.Render("  Error: " + event.Error.Error()))
+		printDebug(event.Debug, styles)
 		return
 	}
 	// Show agent message if present (reasoning/status).
@@ -137,17 +399,95 @@ func printEvent(event *types.AgentEvent, styles Styles) {
 		printToolResult(*event.ToolResult, styles)
 	}
 
+	if event.Transaction != nil && len(event.Transaction.Phases) > 0 {
+		style := styles.ToolParams
+		if event.Transaction.Status != types.TxCommitted && event.Transaction.Status != types.TxDryRun {
+			style = styles.ToolError
+		}
+		fmt.Println(style.Render("  " + formatTransaction(event.Transaction)))
+		fmt.Println()
+	}
+
 	// Final answer.
 	if event.FinalAnswer != "" {
-		printSection("Explanation", event.FinalAnswer, styles)
+		title := "Explanation"
+		if event.PlanOnly {
+			title = "Plan (not executed)"
+		}
+		printSection(title, event.FinalAnswer, styles)
+	}
+
+	// Knowledge-base chunks that informed the answer.
+	if len(event.Sources) > 0 {
+		fmt.Println()
+		printSection("Sources", formatSources(event.Sources), styles)
+	}
+
+	printDebug(event.Debug, styles)
+}
+
+// printDebug prints the prompt and raw LLM reply when LLM debugging is on.
+func printDebug(debug *types.LLMDebug, styles Styles) {
+	if debug == nil {
+		return
+	}
+	fmt.Println()
+	printSection("LLM Prompt (debug)", debug.Prompt, styles)
+	fmt.Println()
+	printSection("LLM Raw Response (debug)", debug.RawResponse, styles)
+}
+
+// formatTransaction renders a transaction summary on one line, e.g.
+// "Transaction committed · read 2/2 (410ms) · modify 1/1 (95ms)". Each phase
+// shows succeeded/planned, noting failures and skips when there are any.
+func formatTransaction(s *types.TransactionSummary) string {
+	parts := []string{"Transaction " + strings.ReplaceAll(s.Status, "_", " ")}
+	for _, p := range s.Phases {
+		part := fmt.Sprintf("%s %d/%d", p.Phase, p.Succeeded, p.Functions)
+		if p.Failed > 0 {
+			part += fmt.Sprintf(", %d failed", p.Failed)
+		}
+		if p.Skipped > 0 {
+			part += fmt.Sprintf(", %d skipped", p.Skipped)
+		}
+		elapsed := time.Duration(p.DurationMs * float64(time.Millisecond))
+		parts = append(parts, fmt.Sprintf("%s (%s)", part, elapsed.Round(time.Millisecond)))
+	}
+	if s.TimedOut {
+		parts = append(parts, "deadline reached")
+	}
+	if s.RollbackError != "" {
+		parts = append(parts, "rollback error: "+s.RollbackError)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// sourceSnippetLen bounds the content preview shown for each source.
+const sourceSnippetLen = 80
+
+// formatSources lists retrieved chunks as "n. source (score) — snippet".
+func formatSources(chunks []types.RetrievedChunk) string {
+	var sb strings.Builder
+	for i, c := range chunks {
+		source := c.Source
+		if source == "" {
+			source = "(unknown source)"
+		}
+		snippet := truncate.String(strings.Join(strings.Fields(c.Content), " "), sourceSnippetLen)
+		fmt.Fprintf(&sb, "%d. %s (%.2f)", i+1, source, c.Score)
+		if snippet != "" {
+			fmt.Fprintf(&sb, " — %s", snippet)
+		}
+		sb.WriteString("\n")
 	}
+	return sb.String()
 }
 
 // printSection prints a labeled section with a divider.
 func printSection(title, body string, styles Styles) {
 	fmt.Println(styles.SectionHeader.Render("  " + title))
-	fmt.Println(styles.Divider.Render("  " + strings.Repeat("─", 44)))
-	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+	fmt.Println(styles.Divider.Render("  " + divider(44)))
+	for _, line := range wrapLines(strings.TrimSpace(body), 2) {
 		fmt.Println(styles.AssistantMessage.Render("  " + line))
 	}
 }
@@ -164,11 +504,20 @@ func printToolResult(result types.ExecutionResult, styles Styles) {
 		dur = styles.ToolParams.Render(fmt.Sprintf("  %s", result.Duration.Round(time.Millisecond)))
 	}
 
-	fmt.Printf("  %s  %s%s\n",
+	changed := ""
+	if result.SideEffect {
+		changed = styles.ToolError.Render("  [changed system]")
+	}
+
+	fmt.Printf("  %s  %s%s%s\n",
 		status,
 		styles.ToolName.Render(result.Function.Name),
 		dur,
+		changed,
 	)
+	if params := formatParams(result.Function.Params); params != "" {
+		fmt.Println(styles.ToolParams.Render("    " + params))
+	}
 
 	if !result.Success && result.Error != "" {
 		fmt.Println(styles.ToolError.Render("    " + result.Error))
@@ -176,13 +525,37 @@ func printToolResult(result types.ExecutionResult, styles Styles) {
 		return
 	}
 
-	if result.Output != "" {
+	if result.Unredacted != "" {
+		fmt.Println(styles.ToolError.Render("    ⚠ unredacted output: secrets below were masked from what the model sees"))
+		renderOutput(result.Unredacted, styles)
+	} else if result.Output != "" {
 		renderOutput(result.Output, styles)
 	}
 
 	fmt.Println()
 }
 
+// formatParams renders the params a function ran with as key=value pairs
+// in key order, e.g. "host=db.internal port=5432".
+func formatParams(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := fmt.Sprint(params[k])
+		if _, isString := params[k].(string); !isString {
+			if b, err := json.Marshal(params[k]); err == nil {
+				v = string(b)
+			}
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, " ")
+}
+
 // renderOutput parses tool output and renders it human-readably.
 // JSON objects render as aligned key/value rows.
 // Plain text renders as indented lines.
@@ -207,7 +580,7 @@ func renderOutput(raw string, styles Styles) {
 	}
 
 	// Plain text fallback.
-	for _, line := range strings.Split(raw, "\n") {
+	for _, line := range wrapLines(raw, 4) {
 		if strings.TrimSpace(line) != "" {
 			fmt.Println(styles.ToolOutput.Render("    " + line))
 		}
@@ -312,13 +685,35 @@ func humanKey(s string) string {
 	return strings.Join(words, " ")
 }
 
-// printBanner prints the app banner to stdout.
+// printBanner prints the app banner to stdout, or a one-line title when
+// output is not styled.
 func printBanner(styles Styles) {
+	if !output.color {
+		fmt.Println("friday - AI-Powered DevOps Debugging Assistant")
+		return
+	}
 	fmt.Println(styles.BannerTitle.Render(Banner()))
 }
 
 // handleCommand handles built-in commands. Returns true if handled.
-func handleCommand(input string, styles Styles) bool {
+func handleCommand(input string, agent Agent, transcript *Transcript, styles Styles) bool {
+	// "tools", "/save" and "/export" take arguments, so they cannot be
+	// matched whole. "/save" and "/export" keep their slash so a query
+	// starting with "save" or "export" still runs.
+	if fields := strings.Fields(input); len(fields) > 0 {
+		switch strings.ToLower(fields[0]) {
+		case "tools":
+			printTools(agent, fields[1:], styles)
+			return true
+		case "/save":
+			savePlan(agent, fields[1:], styles)
+			return true
+		case "/export":
+			exportTranscript(transcript, fields[1:], styles)
+			return true
+		}
+	}
+
 	switch strings.ToLower(input) {
 	case "exit", "quit", "q":
 		fmt.Println(styles.SystemMessage.Render("  Goodbye!"))
@@ -328,18 +723,31 @@ func handleCommand(input string, styles Styles) bool {
 		fmt.Print("\033[H\033[2J")
 		printBanner(styles)
 		fmt.Println()
+		if clearer, ok := agent.(HistoryClearer); ok {
+			if err := clearer.ClearHistory(); err != nil {
+				fmt.Println(styles.ToolError.Render("  Could not clear history: " + err.Error()))
+			} else {
+				fmt.Println(styles.SystemMessage.Render("  Conversation history cleared."))
+			}
+		}
 
 	case "help", "?":
 		fmt.Println()
 		fmt.Println(styles.SystemMessage.Render(
 			"  Commands\n" +
-				"  " + strings.Repeat("─", 44) + "\n" +
+				"  " + divider(44) + "\n" +
 				"  help, ?       Show this help\n" +
-				"  clear         Clear the screen\n" +
+				"  clear         Clear the screen and conversation history\n" +
+				"  plan, /plan   Toggle plan-only mode (propose, don't execute)\n" +
+				"  tools         List tools; --category <name>, --phase <phase>\n" +
+				"  /save <file>  Save the last executed plan for 'friday replay'\n" +
+				"  /export <file> [--anonymize] [--keep-public]\n" +
+				"                Export the session as markdown (.md) or JSON;\n" +
+				"                --anonymize masks hostnames and IPs\n" +
 				"  exit, quit    Exit\n" +
 				"\n" +
 				"  Example queries\n" +
-				"  " + strings.Repeat("─", 44) + "\n" +
+				"  " + divider(44) + "\n" +
 				`  "Check if gRPC service on port 50051 is healthy"` + "\n" +
 				`  "Analyze TCP connections on eth0"` + "\n" +
 				`  "Ping google.com"` + "\n" +
@@ -347,19 +755,18 @@ func handleCommand(input string, styles Styles) bool {
 		))
 		fmt.Println()
 
-	case "tools":
-		fmt.Println()
-		fmt.Println(styles.SystemMessage.Render(
-			"  Available Tools\n" +
-				"  " + strings.Repeat("─", 44) + "\n" +
-				"  Network     ping, dns_lookup, port_scan,\n" +
-				"              http_request, traceroute, netinfo\n" +
-				"  TCP/gRPC    check_tcp_health, check_grpc_health,\n" +
-				"              analyze_grpc_stream\n" +
-				"  System      inspect_network_buffers, execute_sysctl_command\n" +
-				"  Debugging   analyze_core_dump, analyze_memory_leak",
-		))
-		fmt.Println()
+	case "plan", "/plan":
+		toggler, ok := agent.(PlanToggler)
+		if !ok {
+			fmt.Println(styles.SystemMessage.Render("  Plan-only mode is not supported by this agent."))
+			return true
+		}
+		toggler.SetPlanOnly(!toggler.PlanOnly())
+		state := "off"
+		if toggler.PlanOnly() {
+			state = "on — functions will be proposed but not executed"
+		}
+		fmt.Println(styles.SystemMessage.Render("  Plan-only mode " + state))
 
 	default:
 		return false
@@ -1,5 +1,186 @@
 package ui
 
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/friday/internal/diagnostics"
+	"github.com/friday/internal/executor"
+	"github.com/friday/internal/types"
+	"github.com/muesli/termenv"
+)
+
+func TestFormatSources(t *testing.T) {
+	chunks := []types.RetrievedChunk{
+		{Source: "runbooks/grpc.md", Score: 0.912, Content: "When   streams\nstall, check the send queue."},
+		{Source: "", Score: 0.7, Content: strings.Repeat("x", 200)},
+	}
+
+	out := formatSources(chunks)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), out)
+	}
+
+	want := "1. runbooks/grpc.md (0.91) — When streams stall, check the send queue."
+	if lines[0] != want {
+		t.Errorf("line 1 = %q, want %q", lines[0], want)
+	}
+	if !strings.HasPrefix(lines[1], "2. (unknown source) (0.70) — ") {
+		t.Errorf("unexpected line 2: %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[1], "...") || len(lines[1]) > 140 {
+		t.Errorf("expected long snippet to be truncated, got %q", lines[1])
+	}
+}
+
+// captureStdout returns everything fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+// withOutputSettings applies s and a lipgloss profile for the duration of a test.
+func withOutputSettings(t *testing.T, s outputSettings, profile termenv.Profile) {
+	t.Helper()
+	prevOutput, prevProfile := output, lipgloss.ColorProfile()
+	t.Cleanup(func() {
+		output = prevOutput
+		lipgloss.SetColorProfile(prevProfile)
+	})
+	lipgloss.SetColorProfile(profile)
+	applyOutputSettings(s)
+}
+
+func sampleEvent() *types.AgentEvent {
+	return &types.AgentEvent{
+		AllResults: []types.ExecutionResult{
+			{Function: types.FunctionCall{Name: "check_tcp_health", Params: map[string]interface{}{"port": 50051, "interface": "eth0"}}, Success: true,
+				Output: `{"retransmits":47,"state":"ESTABLISHED"}`, Duration: 12 * time.Millisecond},
+			{Function: types.FunctionCall{Name: "ping"}, Success: false, Error: "host unreachable"},
+		},
+		FinalAnswer: "Retransmits are high; check for packet loss on the path.",
+		Sources:     []types.RetrievedChunk{{Source: "runbooks/tcp.md", Score: 0.88, Content: "Retransmits above 1% indicate loss."}},
+	}
+}
+
+func TestFormatTransaction(t *testing.T) {
+	got := formatTransaction(&types.TransactionSummary{
+		Status: types.TxRolledBack,
+		Phases: []types.PhaseSummary{
+			{Phase: "read", Functions: 2, Succeeded: 2, DurationMs: 410.2},
+			{Phase: "modify", Functions: 2, Succeeded: 0, Failed: 1, Skipped: 1, DurationMs: 95},
+		},
+	})
+	want := "Transaction rolled back · read 2/2 (410ms) · modify 0/2, 1 failed, 1 skipped (95ms)"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPrintEvent_NoColorHasNoEscapes(t *testing.T) {
+	withOutputSettings(t, outputSettings{color: false}, termenv.TrueColor)
+
+	out := captureStdout(t, func() {
+		printBanner(DefaultStyles())
+		printEvent(sampleEvent(), DefaultStyles())
+	})
+
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no ANSI escape sequences, got %q", out)
+	}
+	if strings.Contains(out, "─") || strings.Contains(out, "╔") {
+		t.Errorf("expected no box drawing, got %q", out)
+	}
+	for _, want := range []string{"check_tcp_health", "interface=eth0 port=50051", "Retransmits", "host unreachable", "runbooks/tcp.md"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestPrintEvent_ColorHasEscapes(t *testing.T) {
+	withOutputSettings(t, outputSettings{color: true}, termenv.TrueColor)
+
+	out := captureStdout(t, func() { printEvent(sampleEvent(), DefaultStyles()) })
+	if !strings.Contains(out, "\x1b[") {
+		t.Error("expected ANSI styling when color is enabled")
+	}
+}
+
+func TestPrintEvent_DebugSections(t *testing.T) {
+	withOutputSettings(t, outputSettings{color: false}, termenv.TrueColor)
+
+	out := captureStdout(t, func() { printEvent(sampleEvent(), DefaultStyles()) })
+	if strings.Contains(out, "LLM Prompt") {
+		t.Errorf("expected no debug sections without debug data, got %q", out)
+	}
+
+	event := sampleEvent()
+	event.Debug = &types.LLMDebug{Prompt: "You are Friday.", RawResponse: `{"functions":[]}`}
+	out = captureStdout(t, func() { printEvent(event, DefaultStyles()) })
+	for _, want := range []string{"LLM Prompt (debug)", "You are Friday.", "LLM Raw Response (debug)", `{"functions":[]}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		flag, tty, env bool
+		want           bool
+	}{
+		{false, true, false, true},
+		{true, true, false, false},
+		{false, false, false, false},
+		{false, true, true, false},
+	}
+	for _, tt := range tests {
+		if got := colorEnabled(tt.flag, tt.tty, tt.env); got != tt.want {
+			t.Errorf("colorEnabled(flag=%v, tty=%v, NO_COLOR=%v) = %v, want %v",
+				tt.flag, tt.tty, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestPrintSection_WrapsToWidth(t *testing.T) {
+	withOutputSettings(t, outputSettings{color: false, width: 40}, termenv.Ascii)
+
+	body := strings.Repeat("retransmits indicate loss ", 6)
+	out := captureStdout(t, func() { printSection("Explanation", body, DefaultStyles()) })
+
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) < 4 {
+		t.Fatalf("expected body to wrap onto several lines, got %q", out)
+	}
+	for _, line := range lines {
+		if len([]rune(line)) > 40 {
+			t.Errorf("line exceeds width 40: %q", line)
+		}
+	}
+}
+
 // import (
 // 	"strings"
 // 	"testing"
@@ -111,3 +292,207 @@ package ui
 // 		t.Error("View should contain banner with 'AI-Powered' text")
 // 	}
 // }
+
+func TestFormatProgress(t *testing.T) {
+	event := types.AgentEvent{
+		State:    types.StateToolExecuting,
+		ToolCall: &types.FunctionCall{Name: "traceroute"},
+		Progress: "hop 5/15: 10.0.0.5",
+	}
+	if got := formatProgress(event); got != "traceroute: hop 5/15: 10.0.0.5" {
+		t.Errorf("unexpected progress line %q", got)
+	}
+}
+
+func TestRunSpinner_PrintsProgressWithoutColor(t *testing.T) {
+	withOutputSettings(t, outputSettings{color: false}, termenv.Ascii)
+
+	// Unbuffered, so each send returns only once the spinner has the line
+	// and will print it before checking done again.
+	progress := make(chan string)
+
+	out := captureStdout(t, func() {
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			runSpinner(DefaultStyles(), done, progress)
+			close(stopped)
+		}()
+		progress <- "traceroute: hop 1/15: 10.0.0.1"
+		progress <- "traceroute: hop 2/15: 10.0.0.5"
+		close(done)
+		<-stopped
+	})
+
+	if !strings.Contains(out, "  traceroute: hop 1/15: 10.0.0.1\n  traceroute: hop 2/15: 10.0.0.5\n") {
+		t.Errorf("expected both progress lines, got %q", out)
+	}
+	if strings.Contains(out, "\r") {
+		t.Error("expected no carriage returns without color")
+	}
+}
+
+func TestParseToolFilter(t *testing.T) {
+	filter, err := parseToolFilter([]string{"--category", "network", "--phase=read"})
+	if err != nil {
+		t.Fatalf("parseToolFilter returned error: %v", err)
+	}
+	if filter.Category != "network" || filter.Phase != "read" {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	for _, args := range [][]string{{"--category"}, {"network"}, {"--owner", "me"}, {"--phase", "write"}} {
+		if _, err := parseToolFilter(args); err == nil {
+			t.Errorf("expected error for %q", args)
+		}
+	}
+}
+
+func TestFormatToolList(t *testing.T) {
+	withOutputSettings(t, outputSettings{color: false}, termenv.Ascii)
+
+	got := formatToolList([]types.ToolInfo{
+		{Name: "analyze_core_dump", Category: "debugging"},
+		{Name: "check_tcp_health", Category: "network"},
+		{Name: "dns_lookup", Category: "network"},
+		{Name: "http_request", Category: "network"},
+		{Name: "ping", Category: "network", Stability: types.StabilityStable},
+		{Name: "port_scan", Category: "network"},
+		{Name: "tls_scan", Category: "network", Stability: types.StabilityExperimental},
+		{Name: "trace_route", Category: "network", Stability: types.StabilityDeprecated},
+	})
+	want := "  Available Tools\n" +
+		"  " + strings.Repeat("-", 44) + "\n" +
+		"  debugging   analyze_core_dump\n" +
+		"  network     check_tcp_health, dns_lookup, http_request, ping,\n" +
+		"              port_scan, tls_scan (experimental),\n" +
+		"              trace_route (deprecated)"
+	if got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	if got := formatToolList(nil); !strings.Contains(got, "No tools match") {
+		t.Errorf("expected empty-listing message, got %q", got)
+	}
+}
+
+func TestExportTranscript_Anonymized(t *testing.T) {
+	transcript := &Transcript{}
+	transcript.Add("is grpc.prod.internal healthy?", &types.AgentEvent{
+		FinalAnswer: "grpc.prod.internal (10.1.2.3) is serving.",
+		AllResults: []types.ExecutionResult{{
+			Function: types.FunctionCall{Name: "check_grpc_health", Params: map[string]interface{}{"host": "grpc.prod.internal"}},
+			Success:  true,
+			Output:   `{"status":"SERVING","address":"10.1.2.3:50051"}`,
+		}},
+	}, nil)
+
+	dir := t.TempDir()
+	for _, name := range []string{"session.md", "session.json"} {
+		path := filepath.Join(dir, name)
+		if err := ExportTranscript(path, transcript, diagnostics.NewAnonymizer(false)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := string(data)
+		if strings.Contains(out, "prod.internal") || strings.Contains(out, "10.1.2.3") {
+			t.Errorf("%s: expected names and addresses masked, got\n%s", name, out)
+		}
+		if strings.Count(out, "host-1") != 3 || !strings.Contains(out, "ip-1:50051") {
+			t.Errorf("%s: expected consistent pseudonyms, got\n%s", name, out)
+		}
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "session.json"))
+	if !json.Valid(data) {
+		t.Errorf("expected the anonymized JSON export to stay valid, got\n%s", data)
+	}
+}
+
+func TestExportTranscript_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := ExportTranscript(path, &Transcript{}, nil); err == nil {
+		t.Error("expected an error exporting an empty transcript")
+	}
+}
+
+// clearingAgent records whether "clear" wiped its history.
+type clearingAgent struct {
+	cleared bool
+	err     error
+}
+
+func (a *clearingAgent) ProcessQuery(ctx context.Context, query string) (*types.AgentEvent, error) {
+	return nil, nil
+}
+
+func (a *clearingAgent) ClearHistory() error {
+	a.cleared = true
+	return a.err
+}
+
+func TestHandleCommand_ClearWipesHistory(t *testing.T) {
+	withOutputSettings(t, outputSettings{}, termenv.Ascii)
+	agent := &clearingAgent{}
+
+	out := captureStdout(t, func() {
+		if !handleCommand("clear", agent, &Transcript{}, DefaultStyles()) {
+			t.Error("expected clear to be handled")
+		}
+	})
+
+	if !agent.cleared {
+		t.Error("expected clear to wipe the agent's history")
+	}
+	if !strings.Contains(out, "Conversation history cleared.") {
+		t.Errorf("expected confirmation, got %q", out)
+	}
+
+	agent.err = errors.New("permission denied")
+	out = captureStdout(t, func() { handleCommand("clear", agent, &Transcript{}, DefaultStyles()) })
+	if !strings.Contains(out, "Could not clear history: permission denied") {
+		t.Errorf("expected the error reported, got %q", out)
+	}
+}
+
+// confirmingAgent asks its confirmer once per query.
+type confirmingAgent struct {
+	confirmer executor.Confirmer
+	approved  bool
+}
+
+func (a *confirmingAgent) ProcessQuery(ctx context.Context, query string) (*types.AgentEvent, error) {
+	ok, err := a.confirmer.Confirm([]executor.OperationPreview{{FunctionName: "execute_sysctl_command"}})
+	a.approved = ok
+	return &types.AgentEvent{FinalAnswer: "done"}, err
+}
+
+func (a *confirmingAgent) SetConfirmer(c executor.Confirmer) {
+	a.confirmer = c
+}
+
+func TestRunQuery_ConfirmsOnSharedReader(t *testing.T) {
+	withOutputSettings(t, outputSettings{color: false}, termenv.Ascii)
+	agent := &confirmingAgent{}
+	reader := bufio.NewReader(strings.NewReader("y\nnext query\n"))
+
+	out := captureStdout(t, func() {
+		confirm := useConfirmer(agent, reader)
+		if _, err := runQuery(agent, "raise rmem_max", confirm, DefaultStyles()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	if !agent.approved {
+		t.Error("expected the operation to be approved")
+	}
+	if !strings.Contains(out, "Proceed with 1 destructive operation(s)?") {
+		t.Errorf("expected the confirmation prompt, got %q", out)
+	}
+	if line, _ := reader.ReadString('\n'); line != "next query\n" {
+		t.Errorf("expected the next line left for the REPL, got %q", line)
+	}
+}
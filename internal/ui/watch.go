@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/friday/internal/types"
+	"github.com/friday/internal/watch"
+)
+
+// RunWatch re-runs query every interval, printing the first run in full and
+// only the changed fields afterwards. It stops after count runs (count <= 0
+// means no limit) or on Ctrl+C.
+func RunWatch(agent Agent, query string, interval time.Duration, count int) {
+	styles := DefaultStyles()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	limit := "until Ctrl+C"
+	if count > 0 {
+		limit = fmt.Sprintf("%d runs", count)
+	}
+	fmt.Println()
+	fmt.Println(styles.SystemMessage.Render(fmt.Sprintf("  Watching every %s (%s): %s", interval, limit, query)))
+
+	var prev []types.ExecutionResult
+	runs := watch.Loop(ctx, watch.RealClock, interval, count, func(ctx context.Context, i int) {
+		fmt.Println()
+		fmt.Println(styles.SectionHeader.Render(fmt.Sprintf("  Run %d — %s", i, time.Now().Format("15:04:05"))))
+
+		qctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+		defer cancel()
+
+		event, err := agent.ProcessQuery(qctx, query)
+		if err != nil {
+			fmt.Println(styles.ToolError.Render("  Error: " + err.Error()))
+			return
+		}
+		// Print in full until there is a successful run to diff against.
+		if event.Error != nil || prev == nil {
+			printEvent(event, styles)
+			if event.Error == nil {
+				prev = event.AllResults
+			}
+			return
+		}
+
+		printChanges(watch.Diff(prev, event.AllResults), styles)
+		prev = event.AllResults
+	})
+
+	fmt.Println()
+	fmt.Println(styles.SystemMessage.Render(fmt.Sprintf("  Watch finished after %d runs.", runs)))
+}
+
+// printChanges lists fields that differ from the previous run.
+func printChanges(changes []watch.Change, styles Styles) {
+	if len(changes) == 0 {
+		fmt.Println(styles.ToolSuccess.Render("  No changes since last run"))
+		return
+	}
+	fmt.Println(styles.ToolError.Render(fmt.Sprintf("  %d change(s) since last run", len(changes))))
+	for _, c := range changes {
+		fmt.Println(styles.ToolOutput.Render("    " + c.String()))
+	}
+}
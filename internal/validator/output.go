@@ -30,15 +30,116 @@ func (v *OutputValidator) Validate(response string, availableFunctions map[strin
 		return nil, fmt.Errorf("missing explanation field")
 	}
 
+	var unknown []UnknownFunction
 	for i, fn := range llmResp.Functions {
 		if _, exists := availableFunctions[fn.Name]; !exists {
-			return nil, fmt.Errorf("unknown function '%s' at index %d", fn.Name, i)
+			unknown = append(unknown, UnknownFunction{
+				Name:       fn.Name,
+				Index:      i,
+				Suggestion: closestFunction(fn.Name, availableFunctions),
+			})
 		}
 	}
+	if len(unknown) > 0 {
+		return nil, &UnknownFunctionError{Functions: unknown}
+	}
 
 	return &llmResp, nil
 }
 
+// UnknownFunction is a proposed function name that is not in the registry.
+// Suggestion is the closest registered name, or "" if none is close.
+type UnknownFunction struct {
+	Name       string
+	Index      int
+	Suggestion string
+}
+
+// UnknownFunctionError is returned by Validate when the LLM proposes one or
+// more functions that do not exist.
+type UnknownFunctionError struct {
+	Functions []UnknownFunction
+}
+
+func (e *UnknownFunctionError) Error() string {
+	parts := make([]string, len(e.Functions))
+	for i, fn := range e.Functions {
+		parts[i] = fmt.Sprintf("unknown function '%s' at index %d", fn.Name, fn.Index)
+		if fn.Suggestion != "" {
+			parts[i] += fmt.Sprintf(" (did you mean '%s'?)", fn.Suggestion)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// UserMessage explains the failure in terms suitable for showing the user.
+func (e *UnknownFunctionError) UserMessage() string {
+	var sb strings.Builder
+	for _, fn := range e.Functions {
+		sb.WriteString(fmt.Sprintf("I tried to use the tool '%s', which doesn't exist", fn.Name))
+		if fn.Suggestion != "" {
+			sb.WriteString(fmt.Sprintf("; did you mean '%s'?", fn.Suggestion))
+		} else {
+			sb.WriteString(".")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Nothing was executed. Please rephrase the request or run 'friday tools' to see what is available.")
+	return sb.String()
+}
+
+// closestFunction returns the registered name nearest to name by edit
+// distance, or "" when nothing is within a third of the name's length (so
+// unrelated names are not suggested).
+func closestFunction(name string, availableFunctions map[string]types.FunctionDefinition) string {
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	candidates := make([]string, 0, len(availableFunctions))
+	for candidate := range availableFunctions {
+		candidates = append(candidates, candidate)
+	}
+	return ClosestName(strings.ToLower(name), candidates, maxDistance)
+}
+
+// ClosestName returns the candidate nearest to name by edit distance, the
+// alphabetically first on a tie, or "" when none is within maxDistance.
+func ClosestName(name string, candidates []string, maxDistance int) string {
+	best := ""
+	bestDistance := maxDistance + 1
+	for _, candidate := range candidates {
+		d := levenshtein(name, candidate)
+		if d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
 // sanitizeJSONString fixes common LLM output issues before unmarshaling:
 // - Strips markdown code fences (```json ... ```)
 // - Escapes bare newlines and tabs inside JSON string values
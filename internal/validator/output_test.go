@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/types"
+)
+
+var testFunctions = map[string]types.FunctionDefinition{
+	"check_tcp_health":        {Name: "check_tcp_health"},
+	"check_grpc_health":       {Name: "check_grpc_health"},
+	"inspect_network_buffers": {Name: "inspect_network_buffers"},
+	"ping":                    {Name: "ping"},
+}
+
+func proposal(names ...string) string {
+	fns := make([]string, len(names))
+	for i, n := range names {
+		fns[i] = `{"name":"` + n + `","params":{}}`
+	}
+	return `{"reasoning":"r","functions":[` + strings.Join(fns, ",") + `],"explanation":"e"}`
+}
+
+func TestValidate_AllKnownFunctions(t *testing.T) {
+	resp, err := NewOutputValidator().Validate(proposal("ping", "check_tcp_health"), testFunctions)
+	if err != nil {
+		t.Fatalf("expected valid response, got %v", err)
+	}
+	if len(resp.Functions) != 2 {
+		t.Errorf("expected 2 functions, got %d", len(resp.Functions))
+	}
+}
+
+func TestValidate_UnknownFunctionSuggestion(t *testing.T) {
+	_, err := NewOutputValidator().Validate(proposal("ping", "check_tcp_helth", "inspect_buffers_network_all"), testFunctions)
+
+	var unknownErr *UnknownFunctionError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("expected UnknownFunctionError, got %v", err)
+	}
+	if len(unknownErr.Functions) != 2 {
+		t.Fatalf("expected both unknown functions reported, got %+v", unknownErr.Functions)
+	}
+
+	typo := unknownErr.Functions[0]
+	if typo.Name != "check_tcp_helth" || typo.Index != 1 || typo.Suggestion != "check_tcp_health" {
+		t.Errorf("unexpected typo entry: %+v", typo)
+	}
+	if !strings.Contains(err.Error(), "did you mean 'check_tcp_health'?") {
+		t.Errorf("expected suggestion in error, got %q", err.Error())
+	}
+	if !strings.Contains(unknownErr.UserMessage(), "'check_tcp_helth', which doesn't exist; did you mean 'check_tcp_health'?") {
+		t.Errorf("unexpected user message: %q", unknownErr.UserMessage())
+	}
+}
+
+func TestValidate_UnknownFunctionNoCloseMatch(t *testing.T) {
+	_, err := NewOutputValidator().Validate(proposal("restart_kubernetes_cluster"), testFunctions)
+
+	var unknownErr *UnknownFunctionError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("expected UnknownFunctionError, got %v", err)
+	}
+	if s := unknownErr.Functions[0].Suggestion; s != "" {
+		t.Errorf("expected no suggestion for an unrelated name, got %q", s)
+	}
+	if strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected no suggestion in error, got %q", err.Error())
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"ping", "ping", 0},
+		{"pign", "ping", 2},
+		{"check_tcp_helth", "check_tcp_health", 1},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
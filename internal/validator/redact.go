@@ -0,0 +1,170 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces a secret in text bound for the LLM or its logs.
+const RedactedValue = "[REDACTED]"
+
+// secretKeyWords are the names that mark a value as a credential, either
+// as "name=value" text or as a JSON key.
+const secretKeyWords = `password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|authorization`
+
+// secretKey matches a JSON object key whose value is a credential.
+var secretKey = regexp.MustCompile(`(?i)^(?:` + secretKeyWords + `)\w*$`)
+
+// secretPatterns match credentials that can reach the prompt through the
+// query, conversation history, or function outputs. Each keeps its first
+// submatch and replaces the rest with RedactedValue.
+var secretPatterns = []*regexp.Regexp{
+	// user:password@ in URLs.
+	regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`),
+	// Authorization: Bearer abc, Basic abc.
+	regexp.MustCompile(`(?i)(\b(?:bearer|basic)\s+)[A-Za-z0-9\-._~+/]+=*`),
+	// password=..., "api_key": "...", token: ...
+	regexp.MustCompile(`(?i)((?:` + secretKeyWords + `)\w*"?\s*[:=]\s*"?)[^\s",}]+`),
+	// AWS access key IDs.
+	regexp.MustCompile(`()\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+}
+
+// Redactor masks credentials in text: those matched by the built-in
+// secret patterns and by any extra patterns it was built with.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor using the built-in patterns plus extra.
+// An extra pattern with a capture group keeps the text its first group
+// matched, as the built-in ones keep a "password=" prefix; otherwise the
+// whole match is replaced.
+func NewRedactor(extra []string) (*Redactor, error) {
+	r := &Redactor{patterns: append([]*regexp.Regexp(nil), secretPatterns...)}
+	for _, p := range extra {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		if re.NumSubexp() == 0 {
+			re = regexp.MustCompile("()(?:" + p + ")")
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns s with anything that looks like a credential replaced
+// by RedactedValue.
+func (r *Redactor) Redact(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, "${1}"+RedactedValue)
+	}
+	return s
+}
+
+// RedactJSON redacts a function's JSON output value by value, so that an
+// escaped quote inside a string cannot hide a secret from the patterns and
+// the result stays valid JSON in the original key order. A string under a
+// credential-like key, such as "api_key", is replaced whole. Output that is
+// not JSON is redacted as plain text.
+func (r *Redactor) RedactJSON(s string) string {
+	if !json.Valid([]byte(s)) {
+		return r.Redact(s)
+	}
+
+	type container struct {
+		object bool
+		n      int
+		key    string
+	}
+	var (
+		out     bytes.Buffer
+		stack   []*container
+		changed bool
+	)
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return r.Redact(s)
+		}
+
+		var parent *container
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteRune(rune(d))
+			continue
+		}
+
+		isKey := false
+		if parent != nil {
+			switch {
+			case parent.object && parent.n%2 == 1:
+				out.WriteByte(':')
+			case parent.n > 0:
+				out.WriteByte(',')
+			}
+			isKey = parent.object && parent.n%2 == 0
+			parent.n++
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			out.WriteRune(rune(t))
+			stack = append(stack, &container{object: t == '{'})
+		case string:
+			v := t
+			switch {
+			case isKey:
+				parent.key = t
+			case parent != nil && parent.object && t != "" && secretKey.MatchString(parent.key):
+				v = RedactedValue
+			default:
+				v = r.Redact(t)
+			}
+			changed = changed || v != t
+			writeJSONString(&out, v)
+		case json.Number:
+			out.WriteString(t.String())
+		case bool:
+			fmt.Fprint(&out, t)
+		case nil:
+			out.WriteString("null")
+		}
+	}
+
+	if !changed {
+		return s
+	}
+	return out.String()
+}
+
+// writeJSONString writes s as a JSON string without escaping HTML
+// characters, which function outputs such as URLs often contain.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // Encode appends a newline.
+}
+
+// defaultRedactor uses only the built-in patterns.
+var defaultRedactor = &Redactor{patterns: secretPatterns}
+
+// RedactSecrets masks anything in s that the built-in patterns take for a
+// credential.
+func RedactSecrets(s string) string {
+	return defaultRedactor.Redact(s)
+}
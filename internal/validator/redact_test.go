@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewRedactor_ExtraPatterns(t *testing.T) {
+	r, err := NewRedactor([]string{`(session=)\w+`, `ghp_[A-Za-z0-9]{8}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := r.Redact("session=abc123 uses ghp_AbCd1234 and password=s3cret")
+	want := "session=[REDACTED] uses [REDACTED] and password=[REDACTED]"
+	if got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+	if plain := "session is healthy"; r.Redact(plain) != plain {
+		t.Errorf("expected text without secrets unchanged, got %q", r.Redact(plain))
+	}
+}
+
+func TestNewRedactor_InvalidPattern(t *testing.T) {
+	_, err := NewRedactor([]string{`([a-z`})
+	if err == nil || !strings.Contains(err.Error(), "invalid redaction pattern") {
+		t.Errorf("expected an invalid pattern error, got %v", err)
+	}
+}
+
+func TestRedactJSON_QuotedSecretInString(t *testing.T) {
+	r, err := NewRedactor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := `{"lines":["login password=\"hunter2\" ok"],"token":"abc","count":2,"url":"https://a.b/?x=1&y=2"}`
+	got := r.RedactJSON(raw)
+
+	if strings.Contains(got, "hunter2") || strings.Contains(got, "abc") {
+		t.Errorf("expected secrets redacted, got %s", got)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %s: %v", got, err)
+	}
+	if line := decoded["lines"].([]interface{})[0]; line != `login password="[REDACTED]" ok` {
+		t.Errorf("unexpected redacted line %q", line)
+	}
+	if decoded["token"] != RedactedValue {
+		t.Errorf("expected token value redacted, got %v", decoded["token"])
+	}
+	if !strings.HasPrefix(got, `{"lines":`) || !strings.Contains(got, `"count":2,"url":"https://a.b/?x=1&y=2"}`) {
+		t.Errorf("expected key order and other values kept, got %s", got)
+	}
+}
+
+func TestRedactJSON_UnchangedAndPlainText(t *testing.T) {
+	r, err := NewRedactor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clean := "{\n  \"status\": \"ok\"\n}"
+	if got := r.RedactJSON(clean); got != clean {
+		t.Errorf("expected output without secrets unchanged, got %q", got)
+	}
+	if got := r.RedactJSON("password=hunter2"); got != "password=[REDACTED]" {
+		t.Errorf("expected non-JSON output redacted as text, got %q", got)
+	}
+}
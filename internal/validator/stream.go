@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/friday/internal/types"
+)
+
+// ErrIncompleteResponse is returned by StreamValidator.Finish when the
+// stream ended before a complete JSON object arrived.
+var ErrIncompleteResponse = errors.New("response ended before the JSON object was complete")
+
+// StreamValidator accumulates a streamed LLM response: any prose the model
+// writes before its JSON is handed back as it arrives, so it can be shown
+// live, and the JSON object is validated once, when its closing brace
+// arrives, rather than on every partial chunk.
+type StreamValidator struct {
+	validate func(response string) (*types.LLMResponse, error)
+
+	// held is a line starting with ``` that may open a fenced JSON block;
+	// it is dropped if the JSON follows and released as prose otherwise.
+	held      strings.Builder
+	lineStart bool
+
+	object   strings.Builder
+	depth    int
+	inString bool
+	escaped  bool
+	done     bool
+
+	resp *types.LLMResponse
+	err  error
+}
+
+// NewStreamValidator returns a StreamValidator that validates the JSON
+// object with v against availableFunctions.
+func NewStreamValidator(v *OutputValidator, availableFunctions map[string]types.FunctionDefinition) *StreamValidator {
+	return &StreamValidator{
+		validate: func(response string) (*types.LLMResponse, error) {
+			return v.Validate(response, availableFunctions)
+		},
+		lineStart: true,
+	}
+}
+
+// Write adds the next chunk of the stream. It returns the prose in chunk to
+// show now, and whether the JSON object is complete, in which case Finish
+// returns its validation result. Text after the object is ignored.
+//
+// The object starts at a '{' that begins a line, after any indentation, or
+// that follows a code fence; a brace inside a sentence is prose.
+func (s *StreamValidator) Write(chunk string) (prose string, done bool) {
+	var out strings.Builder
+	for i := 0; i < len(chunk) && !s.done; i++ {
+		c := chunk[i]
+		if s.depth > 0 {
+			s.writeObject(c)
+			continue
+		}
+
+		if s.held.Len() > 0 {
+			if !strings.Contains(s.held.String(), "\n") || c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+				s.held.WriteByte(c)
+				continue
+			}
+			if c != '{' {
+				out.WriteString(s.held.String())
+				s.lineStart = false
+			} else {
+				s.lineStart = true
+			}
+			s.held.Reset()
+		}
+
+		switch {
+		case c == '{' && s.lineStart:
+			s.writeObject(c)
+		case c == '`' && s.lineStart:
+			s.held.WriteByte(c)
+		default:
+			out.WriteByte(c)
+			s.lineStart = c == '\n' || (s.lineStart && (c == ' ' || c == '\t' || c == '\r'))
+		}
+	}
+	return out.String(), s.done
+}
+
+// writeObject adds c to the JSON object, tracking strings so braces inside
+// them are not counted, and validates the object once it closes.
+func (s *StreamValidator) writeObject(c byte) {
+	s.object.WriteByte(c)
+	switch {
+	case s.escaped:
+		s.escaped = false
+	case s.inString && c == '\\':
+		s.escaped = true
+	case c == '"':
+		s.inString = !s.inString
+	case s.inString:
+	case c == '{':
+		s.depth++
+	case c == '}':
+		s.depth--
+		if s.depth == 0 {
+			s.done = true
+			s.resp, s.err = s.validate(s.object.String())
+		}
+	}
+}
+
+// Finish returns the validated response, or ErrIncompleteResponse if the
+// JSON object has not been completed.
+func (s *StreamValidator) Finish() (*types.LLMResponse, error) {
+	if !s.done {
+		return nil, ErrIncompleteResponse
+	}
+	return s.resp, s.err
+}
@@ -0,0 +1,122 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/types"
+)
+
+// countingStream is a StreamValidator over testFunctions that counts how
+// often it validates.
+func countingStream(calls *int) *StreamValidator {
+	s := NewStreamValidator(NewOutputValidator(), testFunctions)
+	validate := s.validate
+	s.validate = func(response string) (*types.LLMResponse, error) {
+		*calls++
+		return validate(response)
+	}
+	return s
+}
+
+// feed writes stream to s in chunks of size bytes and returns the prose
+// and the chunk index at which the object completed, or -1.
+func feed(s *StreamValidator, stream string, size int) (string, int) {
+	var prose strings.Builder
+	doneAt := -1
+	for i := 0; i*size < len(stream); i++ {
+		end := (i + 1) * size
+		if end > len(stream) {
+			end = len(stream)
+		}
+		text, done := s.Write(stream[i*size : end])
+		prose.WriteString(text)
+		if done && doneAt < 0 {
+			doneAt = i
+		}
+	}
+	return prose.String(), doneAt
+}
+
+func TestStreamValidator_ProseThenJSON(t *testing.T) {
+	reply := `{"reasoning":"check {braces} in \"strings\"","functions":[{"name":"ping","params":{"host":"a"}}],"explanation":"e"}`
+	stream := "Let me check the port first.\n" + reply
+
+	for _, size := range []int{1, 3, 7, len(stream)} {
+		calls := 0
+		s := countingStream(&calls)
+		prose, doneAt := feed(s, stream, size)
+
+		if prose != "Let me check the port first.\n" {
+			t.Errorf("size %d: expected only the prose surfaced, got %q", size, prose)
+		}
+		if want := (len(stream) - 1) / size; doneAt != want {
+			t.Errorf("size %d: expected completion on the last chunk %d, got %d", size, want, doneAt)
+		}
+		if calls != 1 {
+			t.Errorf("size %d: expected exactly one validation, got %d", size, calls)
+		}
+		resp, err := s.Finish()
+		if err != nil || len(resp.Functions) != 1 || resp.Functions[0].Name != "ping" {
+			t.Errorf("size %d: expected the validated proposal, got %+v (%v)", size, resp, err)
+		}
+	}
+}
+
+func TestStreamValidator_FencedJSON(t *testing.T) {
+	calls := 0
+	s := countingStream(&calls)
+	prose, doneAt := feed(s, "Checking.\n```json\n"+proposal("ping")+"\n```\n", 4)
+	if prose != "Checking.\n" {
+		t.Errorf("expected the code fence kept out of the prose, got %q", prose)
+	}
+	if doneAt < 0 || calls != 1 {
+		t.Fatalf("expected one validation of the fenced object, got %d (done at %d)", calls, doneAt)
+	}
+	if _, err := s.Finish(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestStreamValidator_Incomplete(t *testing.T) {
+	calls := 0
+	s := countingStream(&calls)
+	feed(s, "Thinking...\n"+`{"reasoning":"r","functions":[`, 5)
+	if calls != 0 {
+		t.Errorf("expected no validation of a partial object, got %d", calls)
+	}
+	if _, err := s.Finish(); !errors.Is(err, ErrIncompleteResponse) {
+		t.Errorf("expected ErrIncompleteResponse, got %v", err)
+	}
+}
+
+func TestStreamValidator_InvalidObject(t *testing.T) {
+	calls := 0
+	s := countingStream(&calls)
+	feed(s, proposal("no_such_function"), 6)
+	var unknown *UnknownFunctionError
+	if _, err := s.Finish(); !errors.As(err, &unknown) || calls != 1 {
+		t.Errorf("expected one validation failing with UnknownFunctionError, got %v after %d", err, calls)
+	}
+}
+
+func TestStreamValidator_BraceInProse(t *testing.T) {
+	stream := "The config sets {timeout} too low, so I will check it.\n  " + proposal("ping")
+
+	for _, size := range []int{1, 5, len(stream)} {
+		calls := 0
+		s := countingStream(&calls)
+		prose, doneAt := feed(s, stream, size)
+
+		if want := "The config sets {timeout} too low, so I will check it.\n  "; prose != want {
+			t.Errorf("size %d: expected the braced prose surfaced, got %q", size, prose)
+		}
+		if doneAt < 0 || calls != 1 {
+			t.Fatalf("size %d: expected one validation of the indented object, got %d (done at %d)", size, calls, doneAt)
+		}
+		if resp, err := s.Finish(); err != nil || resp.Functions[0].Name != "ping" {
+			t.Errorf("size %d: expected the validated proposal, got %+v (%v)", size, resp, err)
+		}
+	}
+}
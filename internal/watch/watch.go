@@ -0,0 +1,149 @@
+// Package watch re-runs a query on a schedule and reports what changed in
+// the structured results between runs.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/friday/internal/types"
+)
+
+// Clock abstracts waiting so the scheduling loop can be driven by tests.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RealClock waits on wall-clock time.
+var RealClock Clock = realClock{}
+
+// Loop calls run immediately and then once per interval until count runs have
+// completed or ctx is cancelled. count <= 0 means no limit. It returns the
+// number of runs performed.
+func Loop(ctx context.Context, clock Clock, interval time.Duration, count int, run func(ctx context.Context, iteration int)) int {
+	iterations := 0
+	for {
+		if ctx.Err() != nil {
+			return iterations
+		}
+
+		iterations++
+		run(ctx, iterations)
+
+		if count > 0 && iterations >= count {
+			return iterations
+		}
+
+		select {
+		case <-ctx.Done():
+			return iterations
+		case <-clock.After(interval):
+		}
+	}
+}
+
+// Change is a single field whose value differs from the previous run.
+// Before is nil for fields that first appeared; After is nil for fields that
+// disappeared.
+type Change struct {
+	Function string
+	Field    string
+	Before   interface{}
+	After    interface{}
+}
+
+// String renders the change as "function.field: before → after".
+func (c Change) String() string {
+	return fmt.Sprintf("%s.%s: %s → %s", c.Function, c.Field, render(c.Before), render(c.After))
+}
+
+func render(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// Diff compares two runs' results function by function. Each result's
+// success flag, error, and the top-level fields of its JSON output are
+// compared; duration is ignored since it always differs. Results are matched
+// by function name, with repeated calls to the same function matched in order.
+func Diff(prev, curr []types.ExecutionResult) []Change {
+	before := flattenResults(prev)
+	after := flattenResults(curr)
+
+	keys := make(map[[2]string]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+
+	sorted := make([][2]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i][0] != sorted[j][0] {
+			return sorted[i][0] < sorted[j][0]
+		}
+		return sorted[i][1] < sorted[j][1]
+	})
+
+	var changes []Change
+	for _, k := range sorted {
+		b, a := before[k], after[k]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		changes = append(changes, Change{Function: k[0], Field: k[1], Before: b, After: a})
+	}
+	return changes
+}
+
+// flattenResults maps (function label, field) to value for every result.
+func flattenResults(results []types.ExecutionResult) map[[2]string]interface{} {
+	out := make(map[[2]string]interface{})
+	seen := make(map[string]int)
+
+	for _, r := range results {
+		seen[r.Function.Name]++
+		label := r.Function.Name
+		if n := seen[r.Function.Name]; n > 1 {
+			label = fmt.Sprintf("%s#%d", r.Function.Name, n)
+		}
+
+		out[[2]string{label, "success"}] = r.Success
+		if r.Error != "" {
+			out[[2]string{label, "error"}] = r.Error
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(r.Output), &fields); err != nil {
+			if r.Output != "" {
+				out[[2]string{label, "output"}] = r.Output
+			}
+			continue
+		}
+		for field, v := range fields {
+			out[[2]string{label, field}] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/types"
+)
+
+// fakeClock fires After immediately and records each requested interval.
+type fakeClock struct {
+	waits []time.Duration
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.waits = append(f.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func TestLoop_StopsAfterCount(t *testing.T) {
+	clock := &fakeClock{}
+	var seen []int
+
+	n := Loop(context.Background(), clock, 30*time.Second, 3, func(ctx context.Context, i int) {
+		seen = append(seen, i)
+	})
+
+	if n != 3 || len(seen) != 3 || seen[0] != 1 || seen[2] != 3 {
+		t.Fatalf("expected runs 1..3, got n=%d seen=%v", n, seen)
+	}
+	// No wait after the final run.
+	if len(clock.waits) != 2 {
+		t.Errorf("expected 2 waits between 3 runs, got %d", len(clock.waits))
+	}
+	for _, w := range clock.waits {
+		if w != 30*time.Second {
+			t.Errorf("expected 30s interval, got %s", w)
+		}
+	}
+}
+
+func TestLoop_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := &fakeClock{}
+
+	n := Loop(ctx, clock, time.Second, 0, func(ctx context.Context, i int) {
+		if i == 4 {
+			cancel()
+		}
+	})
+
+	if n != 4 {
+		t.Errorf("expected loop to stop after the 4th run, got %d runs", n)
+	}
+}
+
+func TestLoop_CancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	n := Loop(ctx, &fakeClock{}, time.Second, 5, func(ctx context.Context, i int) {
+		t.Error("run should not be called after cancellation")
+	})
+	if n != 0 {
+		t.Errorf("expected 0 runs, got %d", n)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	prev := []types.ExecutionResult{
+		{Function: types.FunctionCall{Name: "check_tcp_health"}, Success: true,
+			Output: `{"state":"ESTAB","retransmits":3,"port":50051}`, Duration: time.Second},
+		{Function: types.FunctionCall{Name: "ping"}, Success: true, Output: `{"packet_loss":0}`},
+	}
+	curr := []types.ExecutionResult{
+		{Function: types.FunctionCall{Name: "check_tcp_health"}, Success: true,
+			Output: `{"state":"ESTAB","retransmits":7,"port":50051}`, Duration: 2 * time.Second},
+		{Function: types.FunctionCall{Name: "ping"}, Success: false, Error: "timeout"},
+	}
+
+	changes := Diff(prev, curr)
+
+	want := []string{
+		"check_tcp_health.retransmits: 3 → 7",
+		"ping.error: (none) → timeout",
+		"ping.packet_loss: 0 → (none)",
+		"ping.success: true → false",
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %d: %v", len(want), len(changes), changes)
+	}
+	for i, c := range changes {
+		if c.String() != want[i] {
+			t.Errorf("change %d = %q, want %q", i, c.String(), want[i])
+		}
+	}
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	run := []types.ExecutionResult{
+		{Function: types.FunctionCall{Name: "netinfo"}, Success: true, Output: `{"interfaces":["eth0"]}`},
+	}
+	if changes := Diff(run, run); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
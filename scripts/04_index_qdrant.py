@@ -1,5 +1,7 @@
 #!/usr/bin/env python3
 import json
+import os
+import time
 from qdrant_client import QdrantClient
 from qdrant_client.models import Distance, VectorParams, PointStruct
 
@@ -34,7 +36,14 @@ def main():
 
     # Create points with explicit vector field
     points = []
+    now = int(time.time())
     for chunk in chunks:
+        # Record when the source file last changed, so recency ranking can
+        # tell older documents from newer ones.
+        try:
+            ingested_at = int(os.path.getmtime(chunk["metadata"]["source"]))
+        except OSError:
+            ingested_at = now
         point = PointStruct(
             id=chunk["id"],
             vector=chunk["embedding"],  # Must be a list of floats
@@ -42,6 +51,7 @@ def main():
                 "content": chunk["content"],
                 "source": chunk["metadata"]["source"],
                 "category": chunk["metadata"]["category"],
+                "ingested_at": ingested_at,
             },
         )
         points.append(point)
@@ -2,6 +2,8 @@ package executor
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -105,3 +107,56 @@ func TestExecute_CheckGRPCHealth_WithDefaults(t *testing.T) {
 		t.Fatalf("missing status in response")
 	}
 }
+
+func sideEffect(t *testing.T, out string) interface{} {
+	t.Helper()
+	var res map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	v, ok := res["side_effect"]
+	if !ok {
+		t.Fatalf("missing side_effect in response: %s", out)
+	}
+	return v
+}
+
+func TestExecute_ReadsReportNoSideEffect(t *testing.T) {
+	ex := executor.NewExecutor(zap.NewNop())
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(path, []byte(`{"a": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := []types.FunctionCall{
+		{Name: "validate_config_file", Params: map[string]interface{}{"path": path}},
+		{Name: "netinfo", Params: map[string]interface{}{"interface": "lo"}},
+	}
+	for _, fn := range calls {
+		out, err := ex.Execute(fn)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", fn.Name, err)
+		}
+		if got := sideEffect(t, out); got != false {
+			t.Errorf("%s: expected side_effect false, got %v", fn.Name, got)
+		}
+	}
+}
+
+func TestExecute_SysctlDryRunReportsNoSideEffect(t *testing.T) {
+	ex := executor.NewExecutor(zap.NewNop())
+	out, err := ex.Execute(types.FunctionCall{
+		Name: "execute_sysctl_command",
+		Params: map[string]interface{}{
+			"parameter": "net.core.rmem_max",
+			"value":     "16777216",
+			"__dry_run": true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sideEffect(t, out); got != false {
+		t.Errorf("expected side_effect false for a dry run, got %v", got)
+	}
+}
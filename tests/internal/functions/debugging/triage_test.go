@@ -0,0 +1,204 @@
+package debugging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/debugging"
+)
+
+// analysis builds a fixture shaped like an AnalyzeCoreDump result.
+func analysis(core, signal string, backtrace []string, patterns []string) map[string]interface{} {
+	return map[string]interface{}{
+		"core_path":      core,
+		"signal":         signal,
+		"backtrace":      backtrace,
+		"crash_patterns": patterns,
+		"crash_reason":   signal + " in " + core,
+	}
+}
+
+var nullDerefBT = []string{
+	"#0  0x0000000000401136 in parse_header (buf=0x0) at parser.c:42",
+	"#1  0x0000000000401190 in read_frame (conn=0x602010) at conn.c:88",
+	"#2  0x00000000004011c2 in main () at main.c:12",
+}
+
+// TestGroupCrashes_SameSignatureGrouped verifies cores crashing in the same
+// place are grouped even when addresses and line numbers differ
+func TestGroupCrashes_SameSignatureGrouped(t *testing.T) {
+	rebuilt := []string{
+		"#0  0x00000000004021a6 in parse_header (buf=0x0) at parser.c:45",
+		"#1  0x0000000000402200 in read_frame (conn=0x9a3010) at conn.c:90",
+		"#2  0x0000000000402232 in main () at main.c:12",
+		"#3  0x00007f0000000000 in __libc_start_main () from /lib/libc.so.6",
+	}
+	groups := debugging.GroupCrashes([]map[string]interface{}{
+		analysis("/cores/core.1", "SIGSEGV", nullDerefBT, []string{"null_pointer_dereference"}),
+		analysis("/cores/core.2", "SIGSEGV", rebuilt, []string{"null_pointer_dereference"}),
+	})
+
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d: %+v", len(groups), groups)
+	}
+	g := groups[0]
+	if g.Count != 2 || g.ExampleCore != "/cores/core.1" || len(g.Cores) != 2 {
+		t.Errorf("unexpected group: %+v", g)
+	}
+	if !strings.Contains(g.Signature, "parse_header < read_frame < main") {
+		t.Errorf("expected top-3 frames in signature, got %q", g.Signature)
+	}
+}
+
+// TestGroupCrashes_DistinctSignaturesSeparated verifies a different signal
+// or crash site each produce their own group, largest first
+func TestGroupCrashes_DistinctSignaturesSeparated(t *testing.T) {
+	abortBT := []string{
+		"#0  0x00007f in raise () from /lib/libc.so.6",
+		"#1  0x00007f in abort () from /lib/libc.so.6",
+		"#2  0x000040 in __assert_fail () from /lib/libc.so.6",
+	}
+	otherSite := []string{
+		"#0  0x0000000000401136 in encode_frame (buf=0x0) at codec.c:10",
+		"#1  0x0000000000401190 in write_frame () at conn.c:120",
+		"#2  0x00000000004011c2 in main () at main.c:12",
+	}
+
+	groups := debugging.GroupCrashes([]map[string]interface{}{
+		analysis("/cores/core.1", "SIGSEGV", nullDerefBT, []string{"null_pointer_dereference"}),
+		analysis("/cores/core.2", "SIGABRT", abortBT, []string{"assertion_failure", "abort_called"}),
+		analysis("/cores/core.3", "SIGSEGV", otherSite, []string{"null_pointer_dereference"}),
+		analysis("/cores/core.4", "SIGABRT", abortBT, []string{"abort_called", "assertion_failure"}),
+		analysis("/cores/core.5", "SIGBUS", nullDerefBT, []string{"bus_error"}),
+	})
+
+	if len(groups) != 4 {
+		t.Fatalf("expected 4 groups, got %d: %+v", len(groups), groups)
+	}
+	// Pattern order must not matter, so both aborts land in the top group.
+	if groups[0].Count != 2 || !strings.HasPrefix(groups[0].Signature, "SIGABRT") {
+		t.Errorf("expected the two SIGABRT cores first, got %+v", groups[0])
+	}
+	for _, g := range groups[1:] {
+		if g.Count != 1 {
+			t.Errorf("expected singleton group, got %+v", g)
+		}
+	}
+}
+
+// TestGroupCrashes_GroupsByCrashSignatureHash verifies cores are grouped by
+// the crash_signature AnalyzeCoreDump reported, not the readable signature,
+// which also lists crash patterns
+func TestGroupCrashes_GroupsByCrashSignatureHash(t *testing.T) {
+	hash := debugging.CrashSignatureHash("SIGSEGV", nullDerefBT)
+	first := analysis("/cores/core.1", "SIGSEGV", nullDerefBT, []string{"null_pointer_dereference"})
+	first["crash_signature"] = hash
+	second := analysis("/cores/core.2", "SIGSEGV", nullDerefBT, []string{"null_pointer_dereference", "stack_overflow"})
+	second["crash_signature"] = hash
+
+	groups := debugging.GroupCrashes([]map[string]interface{}{first, second})
+	if len(groups) != 1 || groups[0].Count != 2 || groups[0].CrashSignature != hash {
+		t.Fatalf("expected one group for crash_signature %s, got %+v", hash, groups)
+	}
+}
+
+// TestCrashSignatureHash_KeepsOperatorNames verifies only a trailing hex
+// offset is stripped from a frame, so C++ operators stay distinct
+func TestCrashSignatureHash_KeepsOperatorNames(t *testing.T) {
+	plus := []string{"#0  0x0000000000401136 in operator+ (a=..., b=...) at vec.cc:12"}
+	minus := []string{"#0  0x0000000000401136 in operator- (a=..., b=...) at vec.cc:20"}
+	if debugging.CrashSignatureHash("SIGSEGV", plus) == debugging.CrashSignatureHash("SIGSEGV", minus) {
+		t.Error("expected operator+ and operator- to hash differently")
+	}
+
+	offset := []string{"#0  0x0000000000401136 in parse_header+0x1a () from libparse.so"}
+	bare := []string{"#0  0x0000000000401136 in parse_header () from libparse.so"}
+	if debugging.CrashSignatureHash("SIGSEGV", offset) != debugging.CrashSignatureHash("SIGSEGV", bare) {
+		t.Error("expected a trailing +0x offset to be ignored")
+	}
+}
+
+// TestCrashSignature_JSONRoundTrip verifies results decoded from JSON
+// ([]interface{} slices) produce the same signature
+func TestCrashSignature_JSONRoundTrip(t *testing.T) {
+	direct := analysis("/cores/core.1", "SIGSEGV", nullDerefBT, []string{"null_pointer_dereference"})
+	decoded := map[string]interface{}{
+		"signal":         "SIGSEGV",
+		"backtrace":      []interface{}{nullDerefBT[0], nullDerefBT[1], nullDerefBT[2]},
+		"crash_patterns": []interface{}{"null_pointer_dereference"},
+	}
+
+	if a, b := debugging.CrashSignature(direct), debugging.CrashSignature(decoded); a != b {
+		t.Errorf("signatures differ: %q vs %q", a, b)
+	}
+}
+
+// TestTriageCoreDir_UnanalyzableCores verifies files that are not valid cores
+// are reported as failures rather than aborting the triage
+func TestTriageCoreDir_UnanalyzableCores(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"core.101", "server.core", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("not a core"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := debugging.TriageCoreDir(dir, "", 2)
+	if err != nil {
+		t.Fatalf("TriageCoreDir error: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("expected 2 core files, got %d", result.Total)
+	}
+	if result.Analyzed+len(result.Failures) != result.Total {
+		t.Errorf("analyzed (%d) + failures (%d) != total (%d)", result.Analyzed, len(result.Failures), result.Total)
+	}
+}
+
+func TestTriageCoreDir_MissingDir(t *testing.T) {
+	if _, err := debugging.TriageCoreDir(filepath.Join(t.TempDir(), "missing"), "", 1); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+// TestCrashSignatureHash_IgnoresAddresses verifies backtraces that differ
+// only in addresses, offsets, arguments, and locations hash alike
+func TestCrashSignatureHash_IgnoresAddresses(t *testing.T) {
+	relocated := []string{
+		"#0  0x00005581c0de1136 in parse_header (buf=0x0) at parser.c:51",
+		"#1  0x00005581c0de1190 in read_frame.isra.0 (conn=0x55d1c2a0) at conn.c:91",
+		"#2  main () at main.c:14",
+	}
+	lldb := []string{
+		"frame #0: 0x0000000100003f36 server`parse_header(buf=0x0) at parser.c:42",
+		"frame #1: 0x0000000100003f90 server`read_frame + 44 at conn.c:88",
+		"frame #2: 0x0000000100003fc2 server`main + 18",
+	}
+
+	want := debugging.CrashSignatureHash("SIGSEGV", nullDerefBT)
+	if want == "" {
+		t.Fatal("expected non-empty signature")
+	}
+	if got := debugging.CrashSignatureHash("SIGSEGV", relocated); got != want {
+		t.Errorf("relocated GDB backtrace: expected %s, got %s", want, got)
+	}
+	if got := debugging.CrashSignatureHash("SIGSEGV", lldb); got != want {
+		t.Errorf("LLDB backtrace: expected %s, got %s", want, got)
+	}
+}
+
+// TestCrashSignatureHash_DistinguishesCrashes verifies a different top frame
+// or signal changes the signature
+func TestCrashSignatureHash_DistinguishesCrashes(t *testing.T) {
+	base := debugging.CrashSignatureHash("SIGSEGV", nullDerefBT)
+
+	otherTop := append([]string{"#0  0x0000000000401136 in parse_body (buf=0x0) at parser.c:42"}, nullDerefBT[1:]...)
+	if debugging.CrashSignatureHash("SIGSEGV", otherTop) == base {
+		t.Error("expected a different top frame to change the signature")
+	}
+	if debugging.CrashSignatureHash("SIGBUS", nullDerefBT) == base {
+		t.Error("expected a different signal to change the signature")
+	}
+}
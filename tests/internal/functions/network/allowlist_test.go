@@ -0,0 +1,153 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// setAllowlist restricts probes to entries until the test ends.
+func setAllowlist(t *testing.T, entries ...string) {
+	t.Helper()
+	if err := network.SetTargetAllowlist(entries); err != nil {
+		t.Fatalf("SetTargetAllowlist: %v", err)
+	}
+	t.Cleanup(func() { network.SetTargetAllowlist(nil) })
+}
+
+func TestTargetAllowlist_InsideCIDRAllowed(t *testing.T) {
+	setAllowlist(t, "127.0.0.0/8")
+	host, portStr, _ := net.SplitHostPort(listen(t))
+	port, _ := strconv.Atoi(portStr)
+
+	scan, err := network.PortScan(host, portStr)
+	if err != nil {
+		t.Fatalf("expected the scan to be allowed, got: %v", err)
+	}
+	if len(scan.OpenPorts) != 1 {
+		t.Errorf("expected port %d open, got %+v", port, scan)
+	}
+
+	if _, err := network.TCPConnectTiming(host, port, 1); err != nil {
+		t.Errorf("expected the timing to be allowed, got: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	if _, err := network.HTTPRequest(srv.URL, "GET", "", 0); err != nil {
+		t.Errorf("expected the request to be allowed, got: %v", err)
+	}
+}
+
+func TestTargetAllowlist_OutsideCIDRBlocked(t *testing.T) {
+	setAllowlist(t, "10.0.0.0/8", "*.internal")
+	addr := listen(t)
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+
+	checks := map[string]func() error{
+		"ping": func() error {
+			_, err := network.Ping(host, 1)
+			return err
+		},
+		"port_scan": func() error {
+			_, err := network.PortScan(host, portStr)
+			return err
+		},
+		"http_request": func() error {
+			_, err := network.HTTPRequest("http://"+addr, "GET", "", 0)
+			return err
+		},
+		"tcp_connect_timing": func() error {
+			_, err := network.TCPConnectTiming(host, port, 1)
+			return err
+		},
+		"grpc_health": func() error {
+			_, err := network.CheckGRPCHealth(host, port, 1)
+			return err
+		},
+		"connectivity_matrix": func() error {
+			_, err := network.ConnectivityMatrix([]string{addr}, 1)
+			return err
+		},
+		"external_reachability_public_ip": func() error {
+			_, err := network.CheckExternalReachabilityContext(t.Context(), port, network.ReachabilityOptions{
+				PublicIP: func(context.Context) (string, error) { return host, nil },
+			})
+			return err
+		},
+		"external_reachability_echo_service": func() error {
+			_, err := network.CheckExternalReachability(port)
+			return err
+		},
+	}
+	for name, check := range checks {
+		t.Run(name, func(t *testing.T) {
+			if err := check(); !errors.Is(err, network.ErrTargetNotAllowed) {
+				t.Errorf("expected ErrTargetNotAllowed, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTargetAllowlist_RedirectOutsideBlocked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://192.0.2.1/", http.StatusFound)
+	}))
+	defer srv.Close()
+	setAllowlist(t, "127.0.0.1")
+
+	_, err := network.HTTPRequest(srv.URL, "GET", "", 5)
+	if !errors.Is(err, network.ErrTargetNotAllowed) {
+		t.Errorf("expected the redirect to be blocked, got: %v", err)
+	}
+}
+
+func TestTargetAllowlist_Allows(t *testing.T) {
+	a, err := network.ParseTargetAllowlist([]string{"10.0.0.0/8", "192.0.2.10", "*.internal", "localhost"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.0.2.10", true},
+		{"192.0.2.11", false},
+		{"db.internal", true},
+		{"DB.Internal.", true},
+		{"localhost", true},
+		{"8.8.8.8", false},
+		{"[::1]", false},
+	}
+	for _, tt := range tests {
+		if got := a.Allows(t.Context(), tt.host); got != tt.want {
+			t.Errorf("Allows(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestTargetAllowlist_EmptyAllowsEverything(t *testing.T) {
+	a, err := network.ParseTargetAllowlist(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !a.Allows(t.Context(), "8.8.8.8") {
+		t.Error("expected an empty allowlist to allow every host")
+	}
+}
+
+func TestTargetAllowlist_InvalidEntries(t *testing.T) {
+	for _, entries := range [][]string{{"10.0.0.0/33"}, {""}, {"[bad"}} {
+		if _, err := network.ParseTargetAllowlist(entries); err == nil {
+			t.Errorf("expected an error for %q", entries)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// timeoutError is a net.Error that reports a timeout without wrapping a
+// deadline sentinel.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "operation timed out" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func dialErr(errno syscall.Errno) error {
+	return &net.OpError{
+		Op:  "dial",
+		Net: "tcp",
+		Err: &os.SyscallError{Syscall: "connect", Err: errno},
+	}
+}
+
+func TestClassifyConnError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"refused", dialErr(syscall.ECONNREFUSED), network.ConnErrRefused},
+		{"host unreachable", dialErr(syscall.EHOSTUNREACH), network.ConnErrNoRoute},
+		{"network unreachable", dialErr(syscall.ENETUNREACH), network.ConnErrNoRoute},
+		{"reset inside url.Error", &url.Error{Op: "Get", URL: "http://x", Err: dialErr(syscall.ECONNRESET)}, network.ConnErrReset},
+		{"dns not found", &net.DNSError{Err: "no such host", Name: "db.invalid", IsNotFound: true}, network.ConnErrDNS},
+		{"dial deadline", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}, network.ConnErrTimeout},
+		{"context deadline", context.DeadlineExceeded, network.ConnErrTimeout},
+		{"net.Error timeout", timeoutError{}, network.ConnErrTimeout},
+		{"gRPC status text", errors.New(`rpc error: code = Unavailable desc = connection error: desc = "transport: Error while dialing: dial tcp 127.0.0.1:1: connect: connection refused"`), network.ConnErrRefused},
+		{"unrecognised", errors.New("boom"), network.ConnErrUnknown},
+		{"nil", nil, network.ConnErrUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := network.ClassifyConnError(tt.err)
+			if got.Category != tt.want {
+				t.Errorf("category = %q, want %q", got.Category, tt.want)
+			}
+			if got.LikelyCause == "" || got.Suggestion == "" {
+				t.Errorf("expected cause and suggestion, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestClassifyConnError_Suggestions(t *testing.T) {
+	refused := network.ClassifyConnError(dialErr(syscall.ECONNREFUSED))
+	if !strings.Contains(refused.LikelyCause, "nothing is listening") {
+		t.Errorf("unexpected refused cause: %q", refused.LikelyCause)
+	}
+	timeout := network.ClassifyConnError(context.DeadlineExceeded)
+	if !strings.Contains(timeout.Suggestion, "firewall") {
+		t.Errorf("unexpected timeout suggestion: %q", timeout.Suggestion)
+	}
+}
+
+// closedPort returns a loopback port with nothing listening on it.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestPortScan_ClosedReasons(t *testing.T) {
+	port := closedPort(t)
+
+	result, err := network.PortScan("127.0.0.1", strconv.Itoa(port))
+	if err != nil {
+		t.Fatalf("PortScan error: %v", err)
+	}
+	if result.ClosedReasons[port] != network.ConnErrRefused {
+		t.Errorf("expected port %d to be refused, got %v", port, result.ClosedReasons)
+	}
+	if _, ok := result.Explanations[network.ConnErrRefused]; !ok {
+		t.Errorf("expected refused explanation, got %v", result.Explanations)
+	}
+}
+
+func TestHTTPRequest_ExplainsRefused(t *testing.T) {
+	port := closedPort(t)
+
+	_, err := network.HTTPRequest("http://127.0.0.1:"+strconv.Itoa(port), "GET", "", network.DefaultMaxRedirects)
+	var connErr *network.ConnError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected ConnError, got %v", err)
+	}
+	if connErr.Explanation.Category != network.ConnErrRefused {
+		t.Errorf("expected refused, got %s", connErr.Explanation.Category)
+	}
+	if !strings.Contains(err.Error(), "suggestion:") {
+		t.Errorf("expected suggestion in error message, got %q", err.Error())
+	}
+}
+
+func TestCheckGRPCHealth_ExplainsRefused(t *testing.T) {
+	port := closedPort(t)
+
+	_, err := network.CheckGRPCHealth("127.0.0.1", port, 2)
+	if err == nil {
+		t.Fatal("expected error for closed port")
+	}
+	if !strings.Contains(err.Error(), "nothing is listening") {
+		t.Errorf("expected refused explanation in error, got %q", err.Error())
+	}
+}
+
+func TestConnectFrom_ExplainsRefused(t *testing.T) {
+	port := closedPort(t)
+
+	result, err := network.ConnectFrom("127.0.0.1", "127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("ConnectFrom error: %v", err)
+	}
+	if result.Explanation == nil || result.Explanation.Category != network.ConnErrRefused {
+		t.Errorf("expected refused explanation, got %+v", result.Explanation)
+	}
+}
@@ -0,0 +1,175 @@
+package network
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mockResolver is a UDP DNS server that answers the nth A query after
+// delays[n] with answers[n], cycling through both. AAAA queries get an
+// empty answer at once, and names other than domain get NXDOMAIN.
+type mockResolver struct {
+	domain  string
+	delays  []time.Duration
+	answers [][4]byte
+
+	mu      sync.Mutex
+	queries int
+}
+
+// start serves m on a local port until the test ends and returns its
+// address.
+func (m *mockResolver) start(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var msg dnsmessage.Message
+			if err := msg.Unpack(buf[:n]); err != nil || len(msg.Questions) != 1 {
+				continue
+			}
+			go m.answer(conn, addr, msg)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func (m *mockResolver) answer(conn net.PacketConn, addr net.Addr, query dnsmessage.Message) {
+	q := query.Questions[0]
+	reply := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+		Questions: query.Questions,
+	}
+	switch {
+	case q.Name.String() != m.domain:
+		reply.RCode = dnsmessage.RCodeNameError
+	case q.Type == dnsmessage.TypeA:
+		m.mu.Lock()
+		i := m.queries
+		m.queries++
+		m.mu.Unlock()
+		time.Sleep(m.delays[i%len(m.delays)])
+		reply.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.AResource{A: m.answers[i%len(m.answers)]},
+		}}
+	}
+	packed, err := reply.Pack()
+	if err != nil {
+		return
+	}
+	conn.WriteTo(packed, addr)
+}
+
+func TestDNSLatency_Percentiles(t *testing.T) {
+	delays := []time.Duration{40, 10, 50, 20, 30}
+	for i := range delays {
+		delays[i] *= time.Millisecond
+	}
+	m := &mockResolver{domain: "app.friday.test.", delays: delays, answers: [][4]byte{{10, 0, 0, 1}}}
+	addr := m.start(t)
+
+	result, err := network.DNSLatency("app.friday.test", 5, addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Resolver != addr {
+		t.Errorf("expected resolver %s, got %s", addr, result.Resolver)
+	}
+	stats := result.Latency
+	if stats.Samples != 5 || stats.Failed != 0 {
+		t.Fatalf("expected 5 successful samples, got %+v", stats)
+	}
+	// Each sample takes at least its delay, plus a little overhead.
+	const slack = 25.0
+	for i, d := range delays {
+		want := float64(d.Milliseconds())
+		if got := stats.SamplesMs[i]; got < want || got > want+slack {
+			t.Errorf("sample %d: expected about %vms, got %vms", i, want, got)
+		}
+	}
+	if stats.MinMs < 10 || stats.MinMs > 10+slack {
+		t.Errorf("expected min about 10ms, got %v", stats.MinMs)
+	}
+	if stats.P50Ms < 30 || stats.P50Ms > 30+slack {
+		t.Errorf("expected p50 about 30ms, got %v", stats.P50Ms)
+	}
+	if stats.AvgMs < 30 || stats.AvgMs > 30+slack {
+		t.Errorf("expected avg about 30ms, got %v", stats.AvgMs)
+	}
+	// With 5 samples the nearest-rank p99 is the slowest.
+	if stats.P99Ms != stats.MaxMs || stats.MaxMs < 50 {
+		t.Errorf("expected p99 to equal a max of at least 50ms, got %+v", stats)
+	}
+	if !result.Consistent || len(result.AnswerSets) != 1 || result.AnswerSets[0].Count != 5 {
+		t.Errorf("expected one answer for every sample, got %+v", result.AnswerSets)
+	}
+}
+
+func TestDNSLatency_DetectsRoundRobin(t *testing.T) {
+	m := &mockResolver{
+		domain:  "lb.friday.test.",
+		delays:  []time.Duration{0},
+		answers: [][4]byte{{10, 0, 0, 1}, {10, 0, 0, 2}},
+	}
+	addr := m.start(t)
+
+	result, err := network.DNSLatency("lb.friday.test", 4, addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Consistent || len(result.AnswerSets) != 2 {
+		t.Fatalf("expected two alternating answers, got %+v", result.AnswerSets)
+	}
+	for i, want := range []string{"10.0.0.1", "10.0.0.2"} {
+		set := result.AnswerSets[i]
+		if len(set.Addresses) != 1 || set.Addresses[0] != want || set.Count != 2 {
+			t.Errorf("answer set %d: expected %s twice, got %+v", i, want, set)
+		}
+	}
+}
+
+func TestDNSLatency_NXDomain(t *testing.T) {
+	m := &mockResolver{domain: "app.friday.test.", delays: []time.Duration{0}, answers: [][4]byte{{10, 0, 0, 1}}}
+	addr := m.start(t)
+
+	if _, err := network.DNSLatency("missing.friday.test", 2, addr); err == nil {
+		t.Error("expected an error when every lookup fails")
+	}
+}
+
+func TestDNSLatency_RejectsBadArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		domain   string
+		samples  int
+		resolver string
+	}{
+		{"empty domain", "", 5, ""},
+		{"IP address", "10.0.0.1", 5, ""},
+		{"zero samples", "example.com", 0, ""},
+		{"too many samples", "example.com", network.MaxLatencySamples + 1, ""},
+		{"hostname resolver", "example.com", 5, "dns.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := network.DNSLatency(tt.domain, tt.samples, tt.resolver); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
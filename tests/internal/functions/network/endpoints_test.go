@@ -0,0 +1,126 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// staticLookup resolves every host to ips.
+func staticLookup(ips ...string) func(context.Context, string) ([]net.IP, error) {
+	return func(context.Context, string) ([]net.IP, error) {
+		out := make([]net.IP, len(ips))
+		for i, ip := range ips {
+			out[i] = net.ParseIP(ip)
+		}
+		return out, nil
+	}
+}
+
+// listenOn accepts connections on ip:port, with port 0 picking one, and
+// returns the port.
+func listenOn(t *testing.T, ip string, port int) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", ip, err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestCheckAllEndpoints_PartialOutage(t *testing.T) {
+	port := listenOn(t, "127.0.0.1", 0)
+	listenOn(t, "127.0.0.2", port)
+	// Nothing listens on 127.0.0.3.
+
+	result, err := network.CheckAllEndpointsContext(context.Background(), "app.example", port, network.EndpointsOptions{
+		LookupIP: staticLookup("127.0.0.1", "127.0.0.2", "127.0.0.3"),
+		Timeout:  time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Resolved != 3 || result.Reachable != 2 || !result.PartialOutage {
+		t.Fatalf("expected 2 of 3 reachable and a partial outage, got %+v", result)
+	}
+	if len(result.Unreachable) != 1 || result.Unreachable[0] != "127.0.0.3" {
+		t.Errorf("expected 127.0.0.3 unreachable, got %v", result.Unreachable)
+	}
+	for i, want := range []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"} {
+		ep := result.Endpoints[i]
+		if ep.IP != want || ep.Family != "ipv4" || ep.Reachable != (i < 2) {
+			t.Errorf("endpoint %d: unexpected %+v", i, ep)
+		}
+	}
+	if bad := result.Endpoints[2]; !strings.Contains(bad.Error, "refused") {
+		t.Errorf("expected a refused connection on the closed address, got %q", bad.Error)
+	}
+	if !strings.Contains(result.Verdict, "partial outage") || !strings.Contains(result.Verdict, "1 in 3") {
+		t.Errorf("expected the verdict to name the partial outage, got %q", result.Verdict)
+	}
+}
+
+func TestCheckAllEndpoints_AllReachable(t *testing.T) {
+	port := listenOn(t, "127.0.0.1", 0)
+
+	result, err := network.CheckAllEndpointsContext(context.Background(), "app.example", port, network.EndpointsOptions{
+		LookupIP: staticLookup("127.0.0.1"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.PartialOutage || result.Reachable != 1 || len(result.Unreachable) != 0 || result.Endpoints[0].LatencyMs <= 0 {
+		t.Errorf("expected the one address reachable, got %+v", result)
+	}
+}
+
+func TestCheckAllEndpoints_HostnamePatternAllowsAddresses(t *testing.T) {
+	setAllowlist(t, "*.example")
+	port := listenOn(t, "127.0.0.1", 0)
+
+	result, err := network.CheckAllEndpointsContext(context.Background(), "app.example", port, network.EndpointsOptions{
+		LookupIP: staticLookup("127.0.0.1"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Reachable != 1 || len(result.Unreachable) != 0 {
+		t.Errorf("expected the address of an allowed host probed and reachable, got %+v", result)
+	}
+
+	if _, err := network.CheckAllEndpoints("db.other", port); !errors.Is(err, network.ErrTargetNotAllowed) {
+		t.Errorf("expected a host outside the allowlist refused, got %v", err)
+	}
+}
+
+func TestCheckAllEndpoints_LookupFailure(t *testing.T) {
+	_, err := network.CheckAllEndpointsContext(context.Background(), "app.example", 80, network.EndpointsOptions{
+		LookupIP: func(context.Context, string) ([]net.IP, error) { return nil, errors.New("no such host") },
+	})
+	if err == nil || !strings.Contains(err.Error(), "no such host") {
+		t.Errorf("expected the lookup error, got %v", err)
+	}
+
+	if _, err := network.CheckAllEndpoints("", 80); err == nil {
+		t.Error("expected an error for an empty host")
+	}
+	if _, err := network.CheckAllEndpoints("localhost", 0); err == nil {
+		t.Error("expected an error for an invalid port")
+	}
+}
@@ -1,7 +1,10 @@
 package network
 
 import (
+	"context"
+	"errors"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -35,7 +38,6 @@ func TestAnalyzeGRPCStream_WithHealthWatch(t *testing.T) {
 		"messages_sent",
 		"messages_received",
 		"dropped_count",
-		"drop_percentage",
 		"flow_control_events",
 		"monitoring_duration_sec",
 		"status",
@@ -65,7 +67,6 @@ func TestAnalyzeGRPCStream_WithHealthWatch(t *testing.T) {
 	t.Logf("  Messages sent: %v", result["messages_sent"])
 	t.Logf("  Messages received: %v", result["messages_received"])
 	t.Logf("  Dropped count: %v", result["dropped_count"])
-	t.Logf("  Drop percentage: %v", result["drop_percentage"])
 	t.Logf("  Flow control events: %v", result["flow_control_events"])
 	t.Logf("  Monitoring duration: %v", result["monitoring_duration_sec"])
 	t.Logf("  Status: %v", result["status"])
@@ -276,6 +277,305 @@ func TestStreamStats_DropPercentageCalculation(t *testing.T) {
 	}
 }
 
+// mockHealthStream replays canned responses, then either returns finalErr or,
+// when finalErr is nil, blocks until ctx is done like a live Watch stream.
+type mockHealthStream struct {
+	ctx       context.Context
+	responses []*grpc_health_v1.HealthCheckResponse
+	finalErr  error
+}
+
+func (m *mockHealthStream) Recv() (*grpc_health_v1.HealthCheckResponse, error) {
+	if len(m.responses) > 0 {
+		resp := m.responses[0]
+		m.responses = m.responses[1:]
+		return resp, nil
+	}
+	if m.finalErr != nil {
+		return nil, m.finalErr
+	}
+	<-m.ctx.Done()
+	return nil, m.ctx.Err()
+}
+
+func servingResponses(n int) []*grpc_health_v1.HealthCheckResponse {
+	out := make([]*grpc_health_v1.HealthCheckResponse, n)
+	for i := range out {
+		out[i] = &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}
+	}
+	return out
+}
+
+func newMonitorStats() *network.StreamStats {
+	return &network.StreamStats{
+		StartTime:        time.Now(),
+		MessagesSent:     1,
+		SequenceNumbers:  make(map[int64]bool),
+		DroppedSequences: []int64{},
+		Errors:           []string{},
+	}
+}
+
+// assertFinalized checks the fields every termination path must populate
+func assertFinalized(t *testing.T, result map[string]interface{}, terminatedBy string, received int) {
+	t.Helper()
+	if got := result["terminated_by"]; got != terminatedBy {
+		t.Errorf("terminated_by: expected %q, got %v", terminatedBy, got)
+	}
+	if got, _ := result["messages_received"].(int); got != received {
+		t.Errorf("messages_received: expected %d, got %v", received, result["messages_received"])
+	}
+	if got, _ := result["dropped_count"].(int); got != 0 {
+		t.Errorf("dropped_count: expected 0, got %v", result["dropped_count"])
+	}
+	if got, ok := result["drop_percentage"]; ok {
+		t.Errorf("drop_percentage: expected none for an event-driven stream, got %v", got)
+	}
+	if got, _ := result["monitoring_duration_sec"].(string); got == "" {
+		t.Error("monitoring_duration_sec should be set")
+	}
+}
+
+// TestMonitorStream_TerminatedByDuration tests the normal stop-signal path
+func TestMonitorStream_TerminatedByDuration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopChan := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() { close(stopChan) })
+
+	stream := &mockHealthStream{ctx: ctx, responses: servingResponses(3)}
+	result := network.MonitorStream(ctx, cancel, stream, stopChan, newMonitorStats())
+
+	assertFinalized(t, result, network.TerminatedByDuration, 3)
+	if result["status"] != "ok" {
+		t.Errorf("status: expected ok, got %v", result["status"])
+	}
+}
+
+// TestMonitorStreamWithProgress_Reports tests that progress is reported while
+// the window is open and the final result is unaffected
+func TestMonitorStreamWithProgress_Reports(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopChan := make(chan struct{})
+	time.AfterFunc(200*time.Millisecond, func() { close(stopChan) })
+
+	var lines []string
+	stream := &mockHealthStream{ctx: ctx, responses: servingResponses(3)}
+	result := network.MonitorStreamWithProgress(ctx, cancel, stream, stopChan, newMonitorStats(),
+		10*time.Second, 20*time.Millisecond, func(line string) { lines = append(lines, line) })
+
+	assertFinalized(t, result, network.TerminatedByDuration, 3)
+	if len(lines) == 0 {
+		t.Fatal("expected at least one progress line")
+	}
+	if !strings.HasPrefix(lines[0], "monitoring 0s/10s") {
+		t.Errorf("unexpected progress line %q", lines[0])
+	}
+}
+
+// TestMonitorStream_TerminatedByError tests a stream error before the window ends
+func TestMonitorStream_TerminatedByError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream := &mockHealthStream{
+		ctx:       ctx,
+		responses: servingResponses(2),
+		finalErr:  errors.New("connection reset by peer"),
+	}
+	result := network.MonitorStream(ctx, cancel, stream, make(chan struct{}), newMonitorStats())
+
+	assertFinalized(t, result, network.TerminatedByError, 2)
+	if result["status"] != "error" {
+		t.Errorf("status: expected error, got %v", result["status"])
+	}
+	if errs, _ := result["errors"].([]string); len(errs) != 1 || errs[0] != "connection reset by peer" {
+		t.Errorf("errors: expected the stream error, got %v", result["errors"])
+	}
+}
+
+// TestMonitorStream_TerminatedByTimeout tests the outer context expiring
+// before the stop signal fires
+func TestMonitorStream_TerminatedByTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	stream := &mockHealthStream{ctx: ctx, responses: servingResponses(1)}
+	result := network.MonitorStream(ctx, cancel, stream, make(chan struct{}), newMonitorStats())
+
+	assertFinalized(t, result, network.TerminatedByTimeout, 1)
+}
+
+// sequencedMockStream delivers messages carrying the given sender sequence
+// numbers, gap milliseconds apart. Missing numbers simulate skipped deliveries.
+type sequencedMockStream struct {
+	ctx  context.Context
+	seqs []int64
+	gap  time.Duration
+	last int64
+}
+
+func (m *sequencedMockStream) Recv() (*grpc_health_v1.HealthCheckResponse, error) {
+	if len(m.seqs) == 0 {
+		<-m.ctx.Done()
+		return nil, m.ctx.Err()
+	}
+	time.Sleep(m.gap)
+	m.last, m.seqs = m.seqs[0], m.seqs[1:]
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (m *sequencedMockStream) LastSequence() int64 { return m.last }
+
+// TestMonitorStream_DropClusters tests that skipped sequences are grouped
+// into contiguous clusters bounded by the neighbouring receive times
+func TestMonitorStream_DropClusters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopChan := make(chan struct{})
+	time.AfterFunc(300*time.Millisecond, func() { close(stopChan) })
+
+	stats := newMonitorStats()
+	stream := &sequencedMockStream{ctx: ctx, seqs: []int64{1, 2, 3, 6, 7, 9, 10}, gap: 10 * time.Millisecond}
+	result := network.MonitorStream(ctx, cancel, stream, stopChan, stats)
+
+	if got, _ := result["dropped_count"].(int); got != 3 {
+		t.Fatalf("dropped_count: expected 3, got %v", result["dropped_count"])
+	}
+	if got, _ := result["messages_received"].(int); got != 7 {
+		t.Errorf("messages_received: expected 7, got %v", result["messages_received"])
+	}
+
+	clusters, _ := result["drop_clusters"].([]network.DropCluster)
+	if len(clusters) != 2 {
+		t.Fatalf("drop_clusters: expected 2, got %+v", result["drop_clusters"])
+	}
+
+	want := []struct{ start, end int64 }{{4, 5}, {8, 8}}
+	for i, c := range clusters {
+		if c.StartSeq != want[i].start || c.EndSeq != want[i].end || c.Count != int(want[i].end-want[i].start+1) {
+			t.Errorf("cluster %d: expected %d-%d, got %+v", i, want[i].start, want[i].end, c)
+		}
+		// The window runs from the last message before the gap to the first after it.
+		before := stats.ReceiveTimes[c.StartSeq-1]
+		after := stats.ReceiveTimes[c.EndSeq+1]
+		if !c.WindowStart.Equal(before) || !c.WindowEnd.Equal(after) {
+			t.Errorf("cluster %d: window %s..%s, expected %s..%s", i, c.WindowStart, c.WindowEnd, before, after)
+		}
+		if c.WindowMs < 0 || c.WindowEnd.Before(stats.StartTime) || c.WindowEnd.After(stats.EndTime) {
+			t.Errorf("cluster %d: implausible window %+v", i, c)
+		}
+	}
+}
+
+// TestMonitorStream_NoDropClusters tests that an unsequenced stream reports
+// an empty cluster list
+func TestMonitorStream_NoDropClusters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopChan := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() { close(stopChan) })
+
+	stream := &mockHealthStream{ctx: ctx, responses: servingResponses(3)}
+	result := network.MonitorStream(ctx, cancel, stream, stopChan, newMonitorStats())
+
+	if clusters, ok := result["drop_clusters"].([]network.DropCluster); !ok || len(clusters) != 0 {
+		t.Errorf("drop_clusters: expected empty list, got %v", result["drop_clusters"])
+	}
+	if result["stream_type"] != network.StreamEventDriven {
+		t.Errorf("stream_type: expected %q, got %v", network.StreamEventDriven, result["stream_type"])
+	}
+}
+
+// TestMonitorStream_QuietHealthWatchIsHealthy tests that a health Watch
+// sending one SERVING status for the whole window is not flagged
+func TestMonitorStream_QuietHealthWatchIsHealthy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopChan := make(chan struct{})
+	time.AfterFunc(200*time.Millisecond, func() { close(stopChan) })
+
+	stream := &mockHealthStream{ctx: ctx, responses: servingResponses(1)}
+	result := network.MonitorStream(ctx, cancel, stream, stopChan, newMonitorStats())
+
+	if result["status"] != "ok" || result["health"] != network.StreamHealthy {
+		t.Errorf("expected a quiet SERVING stream to be ok and healthy, got status %v, health %v", result["status"], result["health"])
+	}
+	if result["stream_type"] != network.StreamEventDriven {
+		t.Errorf("stream_type: expected %q, got %v", network.StreamEventDriven, result["stream_type"])
+	}
+	if got, ok := result["drop_percentage"]; ok {
+		t.Errorf("drop_percentage: expected none for an event-driven stream, got %v", got)
+	}
+}
+
+// TestMonitorStream_NotServingIsUnhealthy tests that health follows the
+// last status, not the message count
+func TestMonitorStream_NotServingIsUnhealthy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopChan := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() { close(stopChan) })
+
+	stream := &mockHealthStream{ctx: ctx, responses: []*grpc_health_v1.HealthCheckResponse{
+		{Status: grpc_health_v1.HealthCheckResponse_SERVING},
+		{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+	}}
+	result := network.MonitorStream(ctx, cancel, stream, stopChan, newMonitorStats())
+
+	if result["health"] != network.StreamUnhealthy {
+		t.Errorf("health: expected %q, got %v", network.StreamUnhealthy, result["health"])
+	}
+}
+
+// TestMonitorStream_ContinuousDropPercentage tests that drops on a
+// sequenced stream are a share of the messages the sender sent, not of the
+// single Watch request
+func TestMonitorStream_ContinuousDropPercentage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopChan := make(chan struct{})
+	time.AfterFunc(300*time.Millisecond, func() { close(stopChan) })
+
+	stream := &sequencedMockStream{ctx: ctx, seqs: []int64{1, 2, 3, 6, 7, 9, 10}, gap: 10 * time.Millisecond}
+	result := network.MonitorStream(ctx, cancel, stream, stopChan, newMonitorStats())
+
+	if result["stream_type"] != network.StreamContinuous {
+		t.Errorf("stream_type: expected %q, got %v", network.StreamContinuous, result["stream_type"])
+	}
+	if result["drop_percentage"] != "30.00" {
+		t.Errorf("drop_percentage: expected 3 of 10 as 30.00, got %v", result["drop_percentage"])
+	}
+	if result["status"] != "warning" || result["health"] != network.StreamDegraded {
+		t.Errorf("expected a warning and degraded health, got status %v, health %v", result["status"], result["health"])
+	}
+}
+
+// TestStreamStats_ToMap_EventDrivenIgnoresDropPercentage tests that a drop
+// percentage on an event-driven stream does not raise a warning
+func TestStreamStats_ToMap_EventDrivenIgnoresDropPercentage(t *testing.T) {
+	stats := &network.StreamStats{
+		MessagesSent:   1,
+		DropPercentage: 100,
+		LastStatus:     "SERVING",
+		Errors:         []string{},
+	}
+
+	result := stats.ToMap()
+
+	if result["status"] != "ok" || result["health"] != network.StreamHealthy {
+		t.Errorf("expected ok and healthy, got status %v, health %v", result["status"], result["health"])
+	}
+}
+
 // BenchmarkAnalyzeGRPCStream benchmarks stream analysis
 func BenchmarkAnalyzeGRPCStream(b *testing.B) {
 	hostPort, cleanup := startMockGRPCServerWithWatch(&testing.T{}, grpc_health_v1.HealthCheckResponse_SERVING)
@@ -0,0 +1,134 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startHealthServer serves hs on a local port until the test ends and
+// returns the host and port.
+func startHealthServer(t *testing.T, hs grpc_health_v1.HealthServer) (string, int) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, hs)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	addr := lis.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+// lyingHealthServer answers Check with SERVING but streams NOT_SERVING
+// from Watch.
+type lyingHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (lyingHealthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (lyingHealthServer) Watch(_ *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestGRPCHealthProfile_Flapping(t *testing.T) {
+	hs := health.NewServer()
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	host, port := startHealthServer(t, hs)
+
+	// Flap after the watch has started.
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		time.Sleep(300 * time.Millisecond)
+		hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	}()
+
+	result, err := network.GRPCHealthProfile(host, port, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Verdict != network.HealthFlapped || result.Transitions != 2 {
+		t.Fatalf("expected 2 transitions reported as flapped, got %+v", result)
+	}
+	if result.CheckStatus != "SERVING" || result.Diverged {
+		t.Errorf("expected the Check to match the first Watch status, got %+v", result)
+	}
+	want := []string{"SERVING", "NOT_SERVING", "SERVING"}
+	for i, s := range want {
+		if result.WatchStatuses[i].Status != s {
+			t.Errorf("watch status %d: expected %s, got %+v", i, s, result.WatchStatuses[i])
+		}
+	}
+	if result.WatchStatuses[1].AtMs <= result.WatchStatuses[0].AtMs {
+		t.Errorf("expected status times to increase, got %+v", result.WatchStatuses)
+	}
+}
+
+func TestGRPCHealthProfile_Stable(t *testing.T) {
+	hs := health.NewServer()
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	host, port := startHealthServer(t, hs)
+
+	result, err := network.GRPCHealthProfile(host, port, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Verdict != network.HealthStable || result.Transitions != 0 || result.Diverged {
+		t.Errorf("expected a stable profile, got %+v", result)
+	}
+}
+
+func TestGRPCHealthProfile_CheckDiverges(t *testing.T) {
+	host, port := startHealthServer(t, lyingHealthServer{})
+
+	result, err := network.GRPCHealthProfile(host, port, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Verdict != network.HealthDiverged || !result.Diverged {
+		t.Errorf("expected the Check and Watch to diverge, got %+v", result)
+	}
+}
+
+func TestGRPCHealthProfile_WatchUnimplemented(t *testing.T) {
+	host, port := startHealthServer(t, checkOnlyHealthServer{})
+
+	result, err := network.GRPCHealthProfile(host, port, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Verdict != network.HealthInconclusive || len(result.Warnings) == 0 {
+		t.Errorf("expected an inconclusive profile with a warning, got %+v", result)
+	}
+}
+
+func TestGRPCHealthProfile_ConnectionRefused(t *testing.T) {
+	if _, err := network.GRPCHealthProfile("127.0.0.1", closedPort(t), 1); err == nil {
+		t.Error("expected an error when nothing is listening")
+	}
+}
+
+// checkOnlyHealthServer implements Check but not Watch.
+type checkOnlyHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (checkOnlyHealthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
@@ -0,0 +1,102 @@
+package network
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+func TestHTTPProbeMulti_DetectsAlternatingBackends(t *testing.T) {
+	var n atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every other request reaches a broken backend.
+		if n.Add(1)%2 == 0 {
+			w.Header().Set("Server", "backend-b")
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Server", "backend-a")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result, err := network.HTTPProbeMulti(server.URL, 6)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Count != 6 || len(result.Probes) != 6 || result.Succeeded != 6 {
+		t.Fatalf("expected 6 responses, got %+v", result)
+	}
+	if result.Consistent {
+		t.Fatal("expected alternating responses to be reported inconsistent")
+	}
+	if len(result.DistinctStatusCodes) != 2 || result.DistinctStatusCodes[0] != 200 || result.DistinctStatusCodes[1] != 502 {
+		t.Errorf("expected status codes 200 and 502, got %v", result.DistinctStatusCodes)
+	}
+	if result.StatusCodes[200] != 3 || result.StatusCodes[502] != 3 {
+		t.Errorf("expected 3 of each status, got %v", result.StatusCodes)
+	}
+	if result.Servers["backend-a"] != 3 || result.Servers["backend-b"] != 3 {
+		t.Errorf("expected responses from both backends, got %v", result.Servers)
+	}
+	joined := strings.Join(result.Inconsistencies, "; ")
+	if !strings.Contains(joined, "200 x3, 502 x3") || !strings.Contains(joined, "Server headers") {
+		t.Errorf("expected status and server inconsistencies, got %q", joined)
+	}
+	if result.Latency == nil || result.Latency.Samples != 6 || result.LatencySpreadMs < 0 {
+		t.Errorf("expected latency over 6 responses, got %+v", result.Latency)
+	}
+}
+
+func TestHTTPProbeMulti_Consistent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result, err := network.HTTPProbeMulti(server.URL, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Consistent || len(result.Inconsistencies) != 0 {
+		t.Errorf("expected consistent responses, got %v", result.Inconsistencies)
+	}
+	if len(result.DistinctStatusCodes) != 1 || result.DistinctStatusCodes[0] != 200 {
+		t.Errorf("expected only 200, got %v", result.DistinctStatusCodes)
+	}
+}
+
+func TestHTTPProbeMulti_FailedRequests(t *testing.T) {
+	result, err := network.HTTPProbeMulti("http://127.0.0.1:"+strconv.Itoa(closedPort(t)), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Failed != 3 || result.Consistent || result.Latency != nil {
+		t.Errorf("expected 3 failures and no latency, got %+v", result)
+	}
+	if result.Probes[0].Error == "" {
+		t.Error("expected the probe error recorded")
+	}
+}
+
+func TestHTTPProbeMulti_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := network.HTTPProbeMultiContext(ctx, "http://127.0.0.1:1", 2); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
+
+func TestHTTPProbeMulti_RejectsBadCount(t *testing.T) {
+	for _, count := range []int{0, network.MaxHTTPProbeCount + 1} {
+		if _, err := network.HTTPProbeMulti("http://127.0.0.1:1", count); err == nil {
+			t.Errorf("expected error for count %d", count)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package network
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNewLatencyStats_Percentiles(t *testing.T) {
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		// Shuffled order: 100 samples of 1..100ms.
+		durations[i] = time.Duration((i*37)%100+1) * time.Millisecond
+	}
+
+	stats := network.NewLatencyStats(durations)
+
+	if stats.Samples != 100 || len(stats.SamplesMs) != 100 {
+		t.Fatalf("expected 100 samples, got %d (%d listed)", stats.Samples, len(stats.SamplesMs))
+	}
+	if stats.SamplesMs[0] != 1 || stats.SamplesMs[1] != 38 {
+		t.Errorf("expected samples in the order taken, got %v", stats.SamplesMs[:2])
+	}
+	want := map[string][2]float64{
+		"min": {stats.MinMs, 1},
+		"p50": {stats.P50Ms, 50},
+		"p90": {stats.P90Ms, 90},
+		"p99": {stats.P99Ms, 99},
+		"max": {stats.MaxMs, 100},
+	}
+	for name, v := range want {
+		if v[0] != v[1] {
+			t.Errorf("expected %s %.0fms, got %.3fms", name, v[1], v[0])
+		}
+	}
+}
+
+func TestNewLatencyStats_FewSamples(t *testing.T) {
+	stats := network.NewLatencyStats([]time.Duration{
+		30 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond,
+		20 * time.Millisecond, 1500 * time.Microsecond,
+	})
+
+	// Nearest rank over [1.5 10 20 30 50]: p50 is the 3rd, p90 and p99 the 5th.
+	if stats.MinMs != 1.5 || stats.P50Ms != 20 || stats.P90Ms != 50 || stats.P99Ms != 50 || stats.MaxMs != 50 {
+		t.Errorf("unexpected distribution: %+v", stats)
+	}
+}
+
+func TestHTTPRequestSampled_Distribution(t *testing.T) {
+	delays := []time.Duration{5, 5, 5, 5, 5, 5, 5, 5, 5, 60}
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(n.Add(1)) - 1
+		time.Sleep(delays[i%len(delays)] * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	result, err := network.HTTPRequestSampled(context.Background(), srv.URL, "GET", "", 0, len(delays), network.HTTPRequestOptions{})
+	if err != nil {
+		t.Fatalf("HTTPRequestSampled failed: %v", err)
+	}
+
+	if int(n.Load()) != len(delays) {
+		t.Errorf("expected %d requests, got %d", len(delays), n.Load())
+	}
+	stats := result.Latency
+	if stats == nil {
+		t.Fatal("expected latency distribution for multiple samples")
+	}
+	if stats.Samples != len(delays) || stats.Failed != 0 {
+		t.Errorf("expected %d successful samples, got %d (%d failed)", len(delays), stats.Samples, stats.Failed)
+	}
+	if stats.MinMs < 5 || stats.P50Ms >= 60 || stats.MaxMs < 60 {
+		t.Errorf("expected one slow outlier above a fast median, got %+v", stats)
+	}
+	if !(stats.MinMs <= stats.P50Ms && stats.P50Ms <= stats.P90Ms && stats.P90Ms <= stats.P99Ms && stats.P99Ms <= stats.MaxMs) {
+		t.Errorf("percentiles out of order: %+v", stats)
+	}
+}
+
+func TestHTTPRequestSampled_SingleSample(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	result, err := network.HTTPRequestSampled(context.Background(), srv.URL, "GET", "", 0, 1, network.HTTPRequestOptions{})
+	if err != nil {
+		t.Fatalf("HTTPRequestSampled failed: %v", err)
+	}
+	if result.Latency != nil {
+		t.Errorf("expected no distribution for a single sample, got %+v", result.Latency)
+	}
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", result.StatusCode)
+	}
+}
+
+func TestHTTPRequestSampled_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Add(1) == 2 {
+			cancel()
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := network.HTTPRequestSampled(ctx, srv.URL, "GET", "", 0, 50, network.HTTPRequestOptions{}); err == nil {
+		t.Fatal("expected error after cancellation")
+	}
+	if got := n.Load(); got != 2 {
+		t.Errorf("expected sampling to stop after 2 requests, got %d", got)
+	}
+}
+
+func TestHTTPRequestSampled_InvalidSamples(t *testing.T) {
+	for _, samples := range []int{0, network.MaxLatencySamples + 1} {
+		if _, err := network.HTTPRequestSampled(context.Background(), "http://127.0.0.1:1", "GET", "", 0, samples, network.HTTPRequestOptions{}); err == nil {
+			t.Errorf("expected error for samples=%d", samples)
+		}
+	}
+}
+
+func TestCheckGRPCHealthSampled(t *testing.T) {
+	hostPort, cleanup := startMockGRPCServer(t, grpc_health_v1.HealthCheckResponse_SERVING)
+	defer cleanup()
+
+	addr, err := net.ResolveTCPAddr("tcp", hostPort)
+	if err != nil {
+		t.Fatalf("Failed to parse host:port: %v", err)
+	}
+
+	result, err := network.CheckGRPCHealthSampled(context.Background(), addr.IP.String(), addr.Port, 5, 5, false)
+	if err != nil {
+		t.Fatalf("CheckGRPCHealthSampled failed: %v", err)
+	}
+	if result["status"] != "SERVING" {
+		t.Errorf("expected SERVING, got %v", result["status"])
+	}
+	stats, ok := result["latency"].(*network.LatencyStats)
+	if !ok || stats.Samples != 5 || len(stats.SamplesMs) != 5 {
+		t.Fatalf("expected 5 latency samples, got %v", result["latency"])
+	}
+	if stats.MinMs > stats.MaxMs {
+		t.Errorf("expected min <= max, got %+v", stats)
+	}
+}
+
+func TestHTTPRequestSampled_ColdStartPenalty(t *testing.T) {
+	var n atomic.Int32
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Add(1) == 1 {
+			time.Sleep(80 * time.Millisecond)
+		}
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	result, err := network.HTTPRequestSampled(context.Background(), srv.URL, "GET", "", 0, 5,
+		network.HTTPRequestOptions{ColdStart: true})
+	if err != nil {
+		t.Fatalf("HTTPRequestSampled failed: %v", err)
+	}
+	cs := result.Latency.ColdStart
+	if cs == nil || !cs.ColdStartPenalty {
+		t.Fatalf("expected a cold start penalty, got %+v", cs)
+	}
+	if cs.FirstMs < 80 || cs.SteadyStateMs >= 80 || cs.PenaltyMs < 60 {
+		t.Errorf("expected the slow first request set apart from the steady state, got %+v", cs)
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("expected the samples to share one connection, got %d", got)
+	}
+}
+
+func TestHTTPRequestSampled_ColdStartReusesConnectionForLargeBody(t *testing.T) {
+	body := strings.Repeat("x", 512<<10)
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	if _, err := network.HTTPRequestSampled(context.Background(), srv.URL, "GET", "", 0, 5,
+		network.HTTPRequestOptions{ColdStart: true}); err != nil {
+		t.Fatalf("HTTPRequestSampled failed: %v", err)
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("expected the samples to share one connection despite a large body, got %d", got)
+	}
+}
+
+func TestHTTPRequestSampled_NoColdStartPenalty(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	result, err := network.HTTPRequestSampled(context.Background(), srv.URL, "GET", "", 0, 4,
+		network.HTTPRequestOptions{ColdStart: true})
+	if err != nil {
+		t.Fatalf("HTTPRequestSampled failed: %v", err)
+	}
+	if cs := result.Latency.ColdStart; cs == nil || cs.ColdStartPenalty || cs.SteadyStateMs < 20 {
+		t.Errorf("expected a steady state with no penalty, got %+v", cs)
+	}
+
+	plain, err := network.HTTPRequestSampled(context.Background(), srv.URL, "GET", "", 0, 2, network.HTTPRequestOptions{})
+	if err != nil {
+		t.Fatalf("HTTPRequestSampled failed: %v", err)
+	}
+	if plain.Latency.ColdStart != nil {
+		t.Errorf("expected no cold start comparison unless asked for, got %+v", plain.Latency.ColdStart)
+	}
+}
+
+func TestHTTPRequestSampled_ColdStartNeedsTwoSamples(t *testing.T) {
+	_, err := network.HTTPRequestSampled(context.Background(), "http://127.0.0.1:1", "GET", "", 0, 1,
+		network.HTTPRequestOptions{ColdStart: true})
+	if err == nil || !strings.Contains(err.Error(), "at least 2 samples") {
+		t.Errorf("expected an error for a single sample, got %v", err)
+	}
+}
+
+func TestCheckGRPCHealthSampled_ColdStart(t *testing.T) {
+	hostPort, cleanup := startMockGRPCServer(t, grpc_health_v1.HealthCheckResponse_SERVING)
+	defer cleanup()
+	addr, err := net.ResolveTCPAddr("tcp", hostPort)
+	if err != nil {
+		t.Fatalf("Failed to parse host:port: %v", err)
+	}
+
+	result, err := network.CheckGRPCHealthSampled(context.Background(), addr.IP.String(), addr.Port, 5, 4, true)
+	if err != nil {
+		t.Fatalf("CheckGRPCHealthSampled failed: %v", err)
+	}
+	stats := result["latency"].(*network.LatencyStats)
+	cs := stats.ColdStart
+	if cs == nil || cs.Note != "" || cs.FirstMs != stats.SamplesMs[0] {
+		t.Errorf("expected the first check compared with the rest, got %+v", cs)
+	}
+}
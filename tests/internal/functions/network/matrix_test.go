@@ -0,0 +1,114 @@
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// listen starts a TCP listener that accepts and closes connections until the
+// test ends, and returns its address.
+func listen(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// closedAddr returns a local address with nothing listening on it.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestConnectivityMatrix_MixedTargets(t *testing.T) {
+	open1, open2 := listen(t), listen(t)
+	closed := closedAddr(t)
+	unresolvable := "no-such-host.invalid:443"
+
+	result, err := network.ConnectivityMatrix([]string{open1, closed, " " + open2 + " ", unresolvable}, 2)
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix returned error: %v", err)
+	}
+
+	want := []struct {
+		target     string
+		reachable  bool
+		errorClass string
+	}{
+		{open1, true, ""},
+		{closed, false, network.ConnErrRefused},
+		{open2, true, ""},
+		{unresolvable, false, network.ConnErrDNS},
+	}
+	if len(result.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), result.Results)
+	}
+	for i, w := range want {
+		got := result.Results[i]
+		if got.Target != w.target || got.Reachable != w.reachable || got.ErrorClass != w.errorClass {
+			t.Errorf("result %d: expected %s reachable=%v class=%q, got %+v",
+				i, w.target, w.reachable, w.errorClass, got)
+		}
+		if !got.Reachable && got.Error == "" {
+			t.Errorf("result %d: expected error text for unreachable target", i)
+		}
+	}
+
+	if result.Total != 4 || result.Reachable != 2 || result.Unreachable != 2 {
+		t.Errorf("expected 2 of 4 reachable, got %d of %d (unreachable %d)",
+			result.Reachable, result.Total, result.Unreachable)
+	}
+	if _, ok := result.Explanations[network.ConnErrRefused]; !ok {
+		t.Errorf("expected refused explanation, got %v", result.Explanations)
+	}
+}
+
+func TestConnectivityMatrix_ManyTargets(t *testing.T) {
+	addr := listen(t)
+	targets := make([]string, 40)
+	for i := range targets {
+		targets[i] = addr
+	}
+
+	result, err := network.ConnectivityMatrix(targets, 2)
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix returned error: %v", err)
+	}
+	if result.Reachable != len(targets) {
+		t.Errorf("expected all %d targets reachable, got %d", len(targets), result.Reachable)
+	}
+}
+
+func TestConnectivityMatrix_InvalidTargets(t *testing.T) {
+	for _, targets := range [][]string{
+		nil,
+		{"db.internal"},
+		{"db.internal:0"},
+		{":5432"},
+		{"127.0.0.1:http"},
+	} {
+		if _, err := network.ConnectivityMatrix(targets, 1); err == nil {
+			t.Errorf("expected error for targets %q", targets)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// tunnelPing fakes ping on a path with a 1400-byte MTU: plain pings and
+// don't-fragment pings of up to 1372 payload bytes are answered, larger
+// ones fail locally with "message too long".
+func tunnelPing(ctx context.Context, name string, args ...string) ([]byte, error) {
+	size := -1
+	for i, arg := range args {
+		if (arg == "-s" || arg == "-l") && i+1 < len(args) {
+			size, _ = strconv.Atoi(args[i+1])
+		}
+	}
+	if size < 0 || size+28 <= 1400 {
+		return []byte(fmt.Sprintf(`PING 10.8.0.1 (10.8.0.1) %d(%d) bytes of data.
+%d bytes from 10.8.0.1: icmp_seq=1 ttl=64 time=21.3 ms
+%d bytes from 10.8.0.1: icmp_seq=2 ttl=64 time=20.9 ms
+
+--- 10.8.0.1 ping statistics ---
+2 packets transmitted, 2 received, 0%% packet loss, time 1001ms
+rtt min/avg/max/mdev = 20.9/21.1/21.3/0.2 ms
+`, size, size+28, size+8, size+8)), nil
+	}
+	return []byte(fmt.Sprintf(`PING 10.8.0.1 (10.8.0.1) %d(%d) bytes of data.
+ping: local error: message too long, mtu=1400
+ping: local error: message too long, mtu=1400
+
+--- 10.8.0.1 ping statistics ---
+2 packets transmitted, 0 received, +2 errors, 100%% packet loss, time 1001ms
+`, size, size+28)), errors.New("exit status 1")
+}
+
+func TestPingWithOptions_PayloadSweepFindsMTU(t *testing.T) {
+	result, err := network.PingWithOptions("10.8.0.1", 2, network.PingOptions{
+		PayloadSizes: []int{1472, 500, 1300, 1372, 1400},
+		Run:          tunnelPing,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Reachable {
+		t.Errorf("expected the plain ping to succeed, got %+v", result)
+	}
+
+	want := []network.PayloadSizeResult{
+		{Size: 500, Reachable: true},
+		{Size: 1300, Reachable: true},
+		{Size: 1372, Reachable: true},
+		{Size: 1400, TooLarge: true, ReportedMTU: 1400},
+		{Size: 1472, TooLarge: true, ReportedMTU: 1400},
+	}
+	if len(result.PayloadSweep) != len(want) {
+		t.Fatalf("expected %d sweep results, got %+v", len(want), result.PayloadSweep)
+	}
+	for i, w := range want {
+		if result.PayloadSweep[i] != w {
+			t.Errorf("size %d: got %+v, want %+v", w.Size, result.PayloadSweep[i], w)
+		}
+	}
+	if result.PathMTU != 1400 {
+		t.Errorf("expected path MTU 1400, got %d", result.PathMTU)
+	}
+	if result.LikelyCause == "" {
+		t.Error("expected the MTU boundary explained in likely_cause")
+	}
+}
+
+func TestPingWithOptions_NoSweepBoundary(t *testing.T) {
+	result, err := network.PingWithOptions("10.8.0.1", 2, network.PingOptions{
+		PayloadSizes: []int{56, 1000},
+		Run:          tunnelPing,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.PathMTU != 0 || result.LikelyCause != "" {
+		t.Errorf("expected no MTU without a failing size, got %d %q", result.PathMTU, result.LikelyCause)
+	}
+	for _, p := range result.PayloadSweep {
+		if !p.Reachable {
+			t.Errorf("expected size %d reachable", p.Size)
+		}
+	}
+}
+
+func TestPingWithOptions_RejectsBadPayloadSizes(t *testing.T) {
+	for _, sizes := range [][]int{{-1}, {70000}, make([]int, network.MaxPayloadSizes+1)} {
+		if _, err := network.PingWithOptions("10.8.0.1", 1, network.PingOptions{PayloadSizes: sizes, Run: tunnelPing}); err == nil {
+			t.Errorf("expected error for sizes %v", sizes)
+		}
+	}
+}
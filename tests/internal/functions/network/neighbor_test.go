@@ -0,0 +1,107 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// fakeIP answers ip commands from canned output keyed by their arguments.
+func fakeIP(outputs map[string]string) network.NeighborOptions {
+	return network.NeighborOptions{Run: func(_ context.Context, name string, args ...string) ([]byte, error) {
+		out, ok := outputs[strings.Join(args, " ")]
+		if name != "ip" || !ok {
+			return nil, errors.New("unexpected command")
+		}
+		return []byte(out), nil
+	}}
+}
+
+func findingCodes(result *network.NeighborTableResult) []string {
+	var codes []string
+	for _, f := range result.Findings {
+		codes = append(codes, f.Code)
+	}
+	return codes
+}
+
+func TestParseNeighbors(t *testing.T) {
+	entries := network.ParseNeighbors(`fe80::1 dev eth0 lladdr 00:11:22:33:44:55 router STALE
+2001:db8::5 dev eth0  FAILED
+fe80::7 dev eth1 INCOMPLETE
+`)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if e := entries[0]; e.Address != "fe80::1" || e.Interface != "eth0" || e.LLAddr != "00:11:22:33:44:55" || !e.Router || e.State != "STALE" {
+		t.Errorf("unexpected router entry %+v", e)
+	}
+	if e := entries[1]; e.LLAddr != "" || e.Router || e.State != "FAILED" {
+		t.Errorf("unexpected failed entry %+v", e)
+	}
+}
+
+func TestParseDefaultRoutes(t *testing.T) {
+	routes := network.ParseDefaultRoutes("default via fe80::1 dev eth0 proto ra metric 1024 expires 1795sec hoplimit 64 pref medium\n")
+	if len(routes) != 1 || routes[0].Via != "fe80::1" || routes[0].Interface != "eth0" || routes[0].Proto != "ra" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+	if routes := network.ParseDefaultRoutes(""); len(routes) != 0 {
+		t.Errorf("expected no routes from empty output, got %+v", routes)
+	}
+}
+
+func TestNeighborTable_NoIPv6DefaultRoute(t *testing.T) {
+	result, err := network.NeighborTableContext(context.Background(), "", fakeIP(map[string]string{
+		"-4 neigh show":         "192.168.1.1 dev eth0 lladdr 00:11:22:33:44:55 REACHABLE\n",
+		"-6 neigh show":         "fe80::1 dev eth0 lladdr 00:11:22:33:44:55 router REACHABLE\n",
+		"-6 route show default": "",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if codes := findingCodes(result); len(codes) != 1 || codes[0] != network.FindingNoIPv6DefaultRoute {
+		t.Fatalf("expected only no_ipv6_default_route, got %+v", result.Findings)
+	}
+	// The router is a neighbor but its advertisements did not install a route.
+	if !strings.Contains(result.Findings[0].Detail, "accept_ra") {
+		t.Errorf("expected accept_ra advice with a router present, got %q", result.Findings[0].Detail)
+	}
+	if len(result.IPv4Neighbors) != 1 || result.IPv6StateCounts["REACHABLE"] != 1 {
+		t.Errorf("expected both tables read, got %+v", result)
+	}
+}
+
+func TestNeighborTable_NDPFailures(t *testing.T) {
+	result, err := network.NeighborTableContext(context.Background(), "eth0", fakeIP(map[string]string{
+		"-4 neigh show dev eth0": "",
+		// Limited to one device, ip leaves out the dev field.
+		"-6 neigh show dev eth0": "fe80::1 lladdr 00:11:22:33:44:55 router STALE\n2001:db8::5 FAILED\n2001:db8::6 INCOMPLETE\n",
+		"-6 route show default":  "default via fe80::1 dev eth0 proto ra metric 1024 expires 1795sec\n",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if codes := findingCodes(result); len(codes) != 1 || codes[0] != network.FindingNDPFailures {
+		t.Fatalf("expected only ndp_resolution_failures, got %+v", result.Findings)
+	}
+	if detail := result.Findings[0].Detail; !strings.Contains(detail, "2001:db8::5, 2001:db8::6") {
+		t.Errorf("expected both unresolved neighbors named, got %q", detail)
+	}
+	counts := result.IPv6StateCounts
+	if counts[network.NeighborStale] != 1 || counts[network.NeighborFailed] != 1 || counts[network.NeighborIncomplete] != 1 {
+		t.Errorf("unexpected state counts %v", counts)
+	}
+	if result.IPv6Neighbors[0].Interface != "eth0" {
+		t.Errorf("expected the filtered interface filled in, got %+v", result.IPv6Neighbors[0])
+	}
+}
+
+func TestNeighborTable_IPFails(t *testing.T) {
+	if _, err := network.NeighborTableContext(context.Background(), "", fakeIP(nil)); err == nil {
+		t.Error("expected an error when ip neigh cannot run")
+	}
+}
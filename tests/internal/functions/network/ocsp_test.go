@@ -0,0 +1,386 @@
+package network
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// testPKI is a CA and a server certificate it issued.
+type testPKI struct {
+	ca      *x509.Certificate
+	caKey   *ecdsa.PrivateKey
+	leaf    *x509.Certificate
+	leafKey *ecdsa.PrivateKey
+}
+
+// newTestPKI issues a certificate for 127.0.0.1 naming responder as its
+// OCSP server.
+func newTestPKI(t *testing.T, responder string) *testPKI {
+	t.Helper()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(4242),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		OCSPServer:   []string{responder},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create leaf: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(leafDER)
+	return &testPKI{ca: ca, caKey: caKey, leaf: leaf, leafKey: leafKey}
+}
+
+// The ASN.1 of an OCSP response, for building one signed by the test CA.
+type testCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type testRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+type testSingleResponse struct {
+	CertID     testCertID
+	Good       asn1.Flag       `asn1:"tag:0,optional"`
+	Revoked    testRevokedInfo `asn1:"tag:1,optional"`
+	ThisUpdate time.Time       `asn1:"generalized"`
+	NextUpdate time.Time       `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type testResponseData struct {
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []testSingleResponse
+}
+
+type testBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type testResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type testOCSPResponse struct {
+	Status   asn1.Enumerated
+	Response testResponseBytes `asn1:"explicit,tag:0"`
+}
+
+// ocspResponse returns a DER OCSP response from the CA saying the leaf is
+// good, or revoked as of revokedAt when it is non-zero.
+func (p *testPKI) ocspResponse(t *testing.T, revokedAt time.Time) []byte {
+	t.Helper()
+	return p.signedOCSPResponse(t, revokedAt, p.caKey, nil)
+}
+
+// delegatedOCSPResponse returns a DER OCSP response saying the leaf is
+// good, signed by a responder certificate the CA issued for OCSP signing,
+// valid from notBefore to notAfter, and carried in the response.
+func (p *testPKI) delegatedOCSPResponse(t *testing.T, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: "Test OCSP Responder"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatalf("failed to create responder certificate: %v", err)
+	}
+	return p.signedOCSPResponse(t, time.Time{}, key, [][]byte{der})
+}
+
+// signedOCSPResponse builds the response ocspResponse describes, signed by
+// key and carrying certs.
+func (p *testPKI) signedOCSPResponse(t *testing.T, revokedAt time.Time, key *ecdsa.PrivateKey, certs [][]byte) []byte {
+	t.Helper()
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	asn1.Unmarshal(p.ca.RawSubjectPublicKeyInfo, &spki)
+	nameHash := sha1.Sum(p.ca.RawSubject)
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+
+	single := testSingleResponse{
+		CertID: testCertID{
+			HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, Parameters: asn1.NullRawValue},
+			IssuerNameHash: nameHash[:],
+			IssuerKeyHash:  keyHash[:],
+			SerialNumber:   p.leaf.SerialNumber,
+		},
+		ThisUpdate: time.Now().Add(-time.Minute).UTC(),
+		NextUpdate: time.Now().Add(time.Hour).UTC(),
+	}
+	if revokedAt.IsZero() {
+		single.Good = true
+	} else {
+		single.Revoked = testRevokedInfo{RevocationTime: revokedAt.UTC(), Reason: 1}
+	}
+	keyHashID, _ := asn1.Marshal(keyHash[:])
+	tbs, err := asn1.Marshal(testResponseData{
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: keyHashID},
+		ProducedAt:  time.Now().UTC(),
+		Responses:   []testSingleResponse{single},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode response data: %v", err)
+	}
+
+	digest := sha256.Sum256(tbs)
+	sig, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to sign response: %v", err)
+	}
+	var raw []asn1.RawValue
+	for _, c := range certs {
+		raw = append(raw, asn1.RawValue{FullBytes: c})
+	}
+	basic, err := asn1.Marshal(testBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: len(sig) * 8},
+		Certificates:       raw,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode basic response: %v", err)
+	}
+	der, err := asn1.Marshal(testOCSPResponse{Response: testResponseBytes{
+		ResponseType: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1},
+		Response:     basic,
+	}})
+	if err != nil {
+		t.Fatalf("failed to encode OCSP response: %v", err)
+	}
+	return der
+}
+
+// tlsServer completes handshakes on a loopback port with the leaf and CA
+// certificates, stapling staple when it is non-nil, and returns the port.
+func (p *testPKI) tlsServer(t *testing.T, staple []byte) int {
+	t.Helper()
+	cert := tls.Certificate{
+		Certificate: [][]byte{p.leaf.Raw, p.ca.Raw},
+		PrivateKey:  p.leafKey,
+		OCSPStaple:  staple,
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// closedURL is an http URL on a loopback port nothing listens on.
+func closedURL(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return "http://" + addr + "/ocsp"
+}
+
+func TestCheckCertRevocation_StapledGood(t *testing.T) {
+	pki := newTestPKI(t, closedURL(t))
+	port := pki.tlsServer(t, pki.ocspResponse(t, time.Time{}))
+
+	result, err := network.CheckCertRevocation("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RevocationStatus != network.RevocationGood || !result.OCSPStapled {
+		t.Fatalf("expected a stapled good status, got %+v", result)
+	}
+	if result.Responder != "" || result.Error != "" || result.NextUpdate == "" {
+		t.Errorf("expected no responder query and a next update, got %+v", result)
+	}
+	if result.Serial != "1092" || result.Subject != "CN=127.0.0.1" {
+		t.Errorf("expected the leaf's serial and subject, got %q %q", result.Serial, result.Subject)
+	}
+}
+
+func TestCheckCertRevocation_ResponderUnreachable(t *testing.T) {
+	responder := closedURL(t)
+	pki := newTestPKI(t, responder)
+	port := pki.tlsServer(t, nil)
+
+	result, err := network.CheckCertRevocation("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RevocationStatus != network.RevocationUnavailable || result.OCSPStapled {
+		t.Fatalf("expected an unavailable status, got %+v", result)
+	}
+	if result.Responder != responder || !strings.Contains(result.Error, "unreachable") {
+		t.Errorf("expected the unreachable responder named in the error, got %+v", result)
+	}
+}
+
+func TestCheckCertRevocation_ResponderSaysRevoked(t *testing.T) {
+	var pki *testPKI
+	revokedAt := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	var gotUA, gotID string
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		gotUA, gotID = r.Header.Get("User-Agent"), r.Header.Get(network.RequestIDHeader)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/ocsp-request" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(pki.ocspResponse(t, revokedAt))
+	}))
+	defer responder.Close()
+	pki = newTestPKI(t, responder.URL)
+	port := pki.tlsServer(t, nil)
+
+	result, err := network.CheckCertRevocation("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RevocationStatus != network.RevocationRevoked || result.OCSPStapled {
+		t.Fatalf("expected a revoked status from the responder, got %+v", result)
+	}
+	if result.RevocationReason != "key_compromise" || result.RevokedAt != revokedAt.UTC().Format(time.RFC3339) {
+		t.Errorf("expected the revocation time and reason, got %+v", result)
+	}
+	if gotUA != network.UserAgent() || gotID == "" {
+		t.Errorf("expected probe headers on the OCSP request, got User-Agent %q, %s %q", gotUA, network.RequestIDHeader, gotID)
+	}
+}
+
+func TestCheckCertRevocation_ForgedStapleIgnored(t *testing.T) {
+	pki := newTestPKI(t, closedURL(t))
+	forger := newTestPKI(t, "")
+	forger.leaf = pki.leaf // same certificate, signed by someone else
+	port := pki.tlsServer(t, forger.ocspResponse(t, time.Time{}))
+
+	result, err := network.CheckCertRevocation("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RevocationStatus != network.RevocationUnavailable || result.OCSPStapled {
+		t.Fatalf("expected a forged staple to be rejected, got %+v", result)
+	}
+	if !strings.Contains(result.Error, "stapled OCSP response rejected") {
+		t.Errorf("expected the rejected staple in the error, got %q", result.Error)
+	}
+}
+
+func TestCheckCertRevocation_DelegatedResponder(t *testing.T) {
+	pki := newTestPKI(t, closedURL(t))
+	staple := pki.delegatedOCSPResponse(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	port := pki.tlsServer(t, staple)
+
+	result, err := network.CheckCertRevocation("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RevocationStatus != network.RevocationGood || !result.OCSPStapled {
+		t.Fatalf("expected a good status from the delegated responder, got %+v", result)
+	}
+}
+
+func TestCheckCertRevocation_ExpiredDelegatedResponderRejected(t *testing.T) {
+	pki := newTestPKI(t, closedURL(t))
+	staple := pki.delegatedOCSPResponse(t, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+	port := pki.tlsServer(t, staple)
+
+	result, err := network.CheckCertRevocation("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RevocationStatus != network.RevocationUnavailable || result.OCSPStapled {
+		t.Fatalf("expected a response from an expired responder certificate to be rejected, got %+v", result)
+	}
+	if !strings.Contains(result.Error, "responder certificate") {
+		t.Errorf("expected the expired responder certificate in the error, got %q", result.Error)
+	}
+}
+
+func TestCheckCertRevocation_InvalidInput(t *testing.T) {
+	if _, err := network.CheckCertRevocation("", 443); err == nil {
+		t.Error("expected an error for an empty host")
+	}
+	if _, err := network.CheckCertRevocation("127.0.0.1", 70000); err == nil {
+		t.Error("expected an error for an invalid port")
+	}
+}
+
+func TestHTTPRequestSampled_RevocationOnlyForHTTPS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	result, err := network.HTTPRequestSampled(context.Background(), srv.URL, "GET", "", 0, 1, network.HTTPRequestOptions{CheckRevocation: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Revocation != nil {
+		t.Errorf("expected no revocation check over plain HTTP, got %+v", result.Revocation)
+	}
+}
@@ -0,0 +1,224 @@
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// testdata/handshake.pcap and handshake.pcapng hold the same ten Ethernet
+// frames, 100ms apart:
+//
+//	1-3  10.0.0.1:40000 <-> 10.0.0.2:443 three-way handshake
+//	4-5  100-byte segment from .1, then the same segment again
+//	6    1000-byte reply from .2
+//	7-8  SYN from 10.0.0.1:40001 to 10.0.0.3:8080, sent twice, never answered
+//	9    UDP 10.0.0.1:5353 -> 10.0.0.4:53
+//	10   ICMP echo request 10.0.0.1 -> 10.0.0.2
+var pcapFixtures = []string{"testdata/handshake.pcap", "testdata/handshake.pcapng"}
+
+func TestAnalyzePcap_Summary(t *testing.T) {
+	for _, path := range pcapFixtures {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			s, err := network.AnalyzePcap(path, "", 0)
+			if err != nil {
+				t.Fatalf("AnalyzePcap returned error: %v", err)
+			}
+
+			if s.PacketsRead != 10 || s.PacketsMatched != 10 {
+				t.Errorf("expected 10 packets read and matched, got %d/%d", s.PacketsRead, s.PacketsMatched)
+			}
+			if s.Truncated {
+				t.Error("expected Truncated=false")
+			}
+			if s.DurationSeconds != 0.9 {
+				t.Errorf("expected duration 0.9s, got %v", s.DurationSeconds)
+			}
+			if s.Protocols["tcp"] != 8 || s.Protocols["udp"] != 1 || s.Protocols["icmp"] != 1 {
+				t.Errorf("unexpected protocol breakdown: %v", s.Protocols)
+			}
+			if s.TCPRetransmissions != 2 {
+				t.Errorf("expected 2 retransmissions (data segment and SYN), got %d", s.TCPRetransmissions)
+			}
+			if s.ConnectionAttempts != 2 || s.UnansweredSYNs != 1 {
+				t.Errorf("expected 1 of 2 connection attempts unanswered, got %d of %d",
+					s.UnansweredSYNs, s.ConnectionAttempts)
+			}
+			if len(s.UnansweredTargets) != 1 || s.UnansweredTargets[0] != "10.0.0.3:8080" {
+				t.Errorf("expected unanswered target 10.0.0.3:8080, got %v", s.UnansweredTargets)
+			}
+		})
+	}
+}
+
+func TestAnalyzePcap_TopTalkers(t *testing.T) {
+	s, err := network.AnalyzePcap(pcapFixtures[0], "", 0)
+	if err != nil {
+		t.Fatalf("AnalyzePcap returned error: %v", err)
+	}
+
+	want := []network.PcapTalker{
+		{Src: "10.0.0.2", Dst: "10.0.0.1", Packets: 2, Bytes: 1108},
+		{Src: "10.0.0.1", Dst: "10.0.0.2", Packets: 5, Bytes: 514},
+		{Src: "10.0.0.1", Dst: "10.0.0.3", Packets: 2, Bytes: 108},
+		{Src: "10.0.0.1", Dst: "10.0.0.4", Packets: 1, Bytes: 72},
+	}
+	if len(s.TopTalkers) != len(want) {
+		t.Fatalf("expected %d talkers, got %+v", len(want), s.TopTalkers)
+	}
+	for i := range want {
+		if s.TopTalkers[i] != want[i] {
+			t.Errorf("talker %d: expected %+v, got %+v", i, want[i], s.TopTalkers[i])
+		}
+	}
+}
+
+func TestAnalyzePcap_Filter(t *testing.T) {
+	tests := []struct {
+		filter     string
+		matched    int
+		retrans    int
+		unanswered int
+	}{
+		{"port 443", 6, 1, 0},
+		{"tcp and not port 443", 2, 1, 1},
+		{"udp", 1, 0, 0},
+		{"dst host 10.0.0.3", 2, 1, 1},
+		{"icmp and host 10.0.0.2", 1, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			s, err := network.AnalyzePcap(pcapFixtures[0], tt.filter, 0)
+			if err != nil {
+				t.Fatalf("AnalyzePcap returned error: %v", err)
+			}
+			if s.PacketsRead != 10 {
+				t.Errorf("expected all 10 packets read, got %d", s.PacketsRead)
+			}
+			if s.PacketsMatched != tt.matched {
+				t.Errorf("expected %d matched, got %d", tt.matched, s.PacketsMatched)
+			}
+			if s.TCPRetransmissions != tt.retrans {
+				t.Errorf("expected %d retransmissions, got %d", tt.retrans, s.TCPRetransmissions)
+			}
+			if s.UnansweredSYNs != tt.unanswered {
+				t.Errorf("expected %d unanswered SYNs, got %d", tt.unanswered, s.UnansweredSYNs)
+			}
+		})
+	}
+}
+
+func TestAnalyzePcap_InvalidFilter(t *testing.T) {
+	for _, filter := range []string{"tcp or udp", "port http", "host not-an-ip", "tcp and", "vlan 10"} {
+		if _, err := network.AnalyzePcap(pcapFixtures[0], filter, 0); err == nil {
+			t.Errorf("expected error for filter %q", filter)
+		}
+	}
+}
+
+func TestAnalyzePcap_MaxPackets(t *testing.T) {
+	s, err := network.AnalyzePcap(pcapFixtures[1], "", 3)
+	if err != nil {
+		t.Fatalf("AnalyzePcap returned error: %v", err)
+	}
+	if !s.Truncated || s.PacketsRead != 3 {
+		t.Errorf("expected truncation after 3 packets, got read=%d truncated=%v", s.PacketsRead, s.Truncated)
+	}
+	if len(s.Warnings) == 0 || !strings.Contains(s.Warnings[0], "max_packets") {
+		t.Errorf("expected max_packets warning, got %v", s.Warnings)
+	}
+}
+
+func TestAnalyzePcap_MaxPacketsEqualsFileLength(t *testing.T) {
+	s, err := network.AnalyzePcap(pcapFixtures[0], "", 10)
+	if err != nil {
+		t.Fatalf("AnalyzePcap returned error: %v", err)
+	}
+	if s.Truncated || s.PacketsRead != 10 {
+		t.Errorf("expected all 10 packets read without truncation, got read=%d truncated=%v", s.PacketsRead, s.Truncated)
+	}
+	for _, w := range s.Warnings {
+		if strings.Contains(w, "max_packets") {
+			t.Errorf("expected no max_packets warning, got %v", s.Warnings)
+		}
+	}
+}
+
+func TestAnalyzePcap_LinuxSLL2IPv6(t *testing.T) {
+	// One "tcpdump -i any" frame: a Linux cooked v2 header, then an IPv6
+	// SYN from [2001:db8::1]:40000 to [2001:db8::2]:443.
+	frame := make([]byte, 20+40+20)
+	binary.BigEndian.PutUint16(frame[0:], 0x86dd) // protocol
+	ip6 := frame[20:]
+	ip6[0] = 0x60
+	binary.BigEndian.PutUint16(ip6[4:], 20) // payload length
+	ip6[6], ip6[7] = 6, 64                  // next header TCP, hop limit
+	copy(ip6[8:], net.ParseIP("2001:db8::1"))
+	copy(ip6[24:], net.ParseIP("2001:db8::2"))
+	tcp := ip6[40:]
+	binary.BigEndian.PutUint16(tcp[0:], 40000)
+	binary.BigEndian.PutUint16(tcp[2:], 443)
+	tcp[12], tcp[13] = 5<<4, 0x02 // data offset, SYN
+
+	file := make([]byte, 24+16)
+	binary.LittleEndian.PutUint32(file[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(file[4:], 2)
+	binary.LittleEndian.PutUint16(file[6:], 4)
+	binary.LittleEndian.PutUint32(file[16:], 65535)
+	binary.LittleEndian.PutUint32(file[20:], 276) // LINKTYPE_LINUX_SLL2
+	binary.LittleEndian.PutUint32(file[24+8:], uint32(len(frame)))
+	binary.LittleEndian.PutUint32(file[24+12:], uint32(len(frame)))
+	path := filepath.Join(t.TempDir(), "any.pcap")
+	if err := os.WriteFile(path, append(file, frame...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := network.AnalyzePcap(path, "ip6 and dst port 443", 0)
+	if err != nil {
+		t.Fatalf("AnalyzePcap returned error: %v", err)
+	}
+	if s.PacketsMatched != 1 || s.Protocols["tcp"] != 1 {
+		t.Fatalf("expected the IPv6 SYN decoded and matched, got %+v", s)
+	}
+	if len(s.UnansweredTargets) != 1 || s.UnansweredTargets[0] != "[2001:db8::2]:443" {
+		t.Errorf("expected the unanswered IPv6 target, got %v", s.UnansweredTargets)
+	}
+}
+
+func TestAnalyzePcap_NotACapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("this is not a capture file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.AnalyzePcap(path, "", 0); err == nil {
+		t.Error("expected error for non-pcap file")
+	}
+}
+
+func TestAnalyzePcap_TruncatedFile(t *testing.T) {
+	data, err := os.ReadFile(pcapFixtures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cut.pcap")
+	// Cut the file partway through the fourth packet.
+	if err := os.WriteFile(path, data[:24+3*(16+54)+30], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := network.AnalyzePcap(path, "", 0)
+	if err != nil {
+		t.Fatalf("expected partial summary, got error: %v", err)
+	}
+	if s.PacketsRead != 3 {
+		t.Errorf("expected the 3 complete packets, got %d", s.PacketsRead)
+	}
+	if len(s.Warnings) == 0 || !strings.Contains(s.Warnings[0], "truncated") {
+		t.Errorf("expected truncation warning, got %v", s.Warnings)
+	}
+}
@@ -0,0 +1,149 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// flakyDialer refuses the first failures[addr] dials to each address, then
+// dials for real.
+type flakyDialer struct {
+	mu       sync.Mutex
+	failures map[string]int
+	dials    map[string]int
+}
+
+func newFlakyDialer(failures map[string]int) *flakyDialer {
+	return &flakyDialer{failures: failures, dials: make(map[string]int)}
+}
+
+func (d *flakyDialer) Dial(network, address string, timeout time.Duration) (net.Conn, error) {
+	d.mu.Lock()
+	d.dials[address]++
+	n := d.dials[address]
+	d.mu.Unlock()
+
+	if n <= d.failures[address] {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNREFUSED}
+	}
+	return net.DialTimeout(network, address, timeout)
+}
+
+func (d *flakyDialer) count(address string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dials[address]
+}
+
+func TestPortScanWithOptions_RetriesRecoverOpenPort(t *testing.T) {
+	flaky := listen(t)
+	steady := listen(t)
+	_, flakyPort, _ := net.SplitHostPort(flaky)
+	_, steadyPort, _ := net.SplitHostPort(steady)
+	dialer := newFlakyDialer(map[string]int{flaky: 2})
+
+	result, err := network.PortScanWithOptions("127.0.0.1", flakyPort+","+steadyPort, network.PortScanOptions{
+		Retries:    3,
+		RetryDelay: time.Millisecond,
+		Dial:       dialer.Dial,
+	})
+	if err != nil {
+		t.Fatalf("PortScanWithOptions error: %v", err)
+	}
+
+	if result.OpenCount != 2 || len(result.ClosedPorts) != 0 {
+		t.Fatalf("expected both ports open, got open=%v closed=%v", result.OpenPorts, result.ClosedPorts)
+	}
+	fp, _ := strconv.Atoi(flakyPort)
+	sp, _ := strconv.Atoi(steadyPort)
+	if result.Retried[fp] != 2 {
+		t.Errorf("expected flaky port %d open after 2 retries, got %v", fp, result.Retried)
+	}
+	if _, ok := result.Retried[sp]; ok {
+		t.Errorf("expected no retry for steady port %d, got %v", sp, result.Retried)
+	}
+	if got := dialer.count(flaky); got != 3 {
+		t.Errorf("expected 3 dials to the flaky port, got %d", got)
+	}
+}
+
+func TestPortScanWithOptions_ClosedPortStaysClosed(t *testing.T) {
+	port := closedPort(t)
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	dialer := newFlakyDialer(nil)
+
+	result, err := network.PortScanWithOptions("127.0.0.1", strconv.Itoa(port), network.PortScanOptions{
+		Retries:    3,
+		RetryDelay: time.Millisecond,
+		Dial:       dialer.Dial,
+	})
+	if err != nil {
+		t.Fatalf("PortScanWithOptions error: %v", err)
+	}
+
+	if result.OpenCount != 0 || len(result.ClosedPorts) != 1 {
+		t.Fatalf("expected port %d closed, got open=%v closed=%v", port, result.OpenPorts, result.ClosedPorts)
+	}
+	if result.Retried[port] != 3 {
+		t.Errorf("expected 3 retries before giving up, got %v", result.Retried)
+	}
+	if got := dialer.count(addr); got != 4 {
+		t.Errorf("expected 4 dials, got %d", got)
+	}
+	if result.ClosedReasons[port] != network.ConnErrRefused {
+		t.Errorf("expected refused reason, got %v", result.ClosedReasons)
+	}
+}
+
+func TestPortScanWithOptions_NoRetriesByDefault(t *testing.T) {
+	flaky := listen(t)
+	_, flakyPort, _ := net.SplitHostPort(flaky)
+	dialer := newFlakyDialer(map[string]int{flaky: 1})
+
+	result, err := network.PortScanWithOptions("127.0.0.1", flakyPort, network.PortScanOptions{Dial: dialer.Dial})
+	if err != nil {
+		t.Fatalf("PortScanWithOptions error: %v", err)
+	}
+	if result.OpenCount != 0 || len(result.Retried) != 0 {
+		t.Errorf("expected a single failed dial without retries, got %+v", result)
+	}
+}
+
+func TestPortScanWithOptions_InvalidRetries(t *testing.T) {
+	for _, retries := range []int{-1, network.MaxPortScanRetries + 1} {
+		if _, err := network.PortScanWithOptions("127.0.0.1", "80", network.PortScanOptions{Retries: retries}); err == nil {
+			t.Errorf("expected error for retries=%d", retries)
+		}
+	}
+}
+
+func TestPortScanWithOptions_TimeoutIsNotRetried(t *testing.T) {
+	dials := 0
+	timeoutDial := func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dials++
+		return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+	}
+
+	result, err := network.PortScanWithOptions("127.0.0.1", "81", network.PortScanOptions{
+		Retries:    network.MaxPortScanRetries,
+		RetryDelay: time.Millisecond,
+		Dial:       timeoutDial,
+	})
+	if err != nil {
+		t.Fatalf("PortScanWithOptions error: %v", err)
+	}
+	if dials != 1 {
+		t.Errorf("expected a single dial for a timed-out port, got %d", dials)
+	}
+	if len(result.ClosedPorts) != 1 || len(result.Retried) != 0 {
+		t.Errorf("expected port closed without retries, got %+v", result)
+	}
+}
+
@@ -0,0 +1,94 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// publicIP is a ReachabilityOptions that reports ip as the public address.
+func publicIP(ip string) network.ReachabilityOptions {
+	return network.ReachabilityOptions{
+		PublicIP: func(context.Context) (string, error) { return ip + "\n", nil },
+		Timeout:  300 * time.Millisecond,
+	}
+}
+
+func listenPort(t *testing.T) int {
+	t.Helper()
+	_, portStr, _ := net.SplitHostPort(listen(t))
+	port, _ := strconv.Atoi(portStr)
+	return port
+}
+
+func TestCheckExternalReachability_NoNAT(t *testing.T) {
+	port := listenPort(t)
+
+	result, err := network.CheckExternalReachabilityContext(context.Background(), port, publicIP("127.0.0.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.BehindNAT {
+		t.Error("expected no NAT when the public IP is a local address")
+	}
+	// Connecting to one of the host's own addresses says nothing about
+	// reachability from outside, so no verdict on it is given.
+	if result.Reachable != nil || !result.ListeningLocally || result.PublicIP != "127.0.0.1" {
+		t.Errorf("expected the port listening locally and reachable unset, got %+v", result)
+	}
+	if !strings.Contains(result.Verdict, "no external check") {
+		t.Errorf("expected the verdict to say no external check was done, got %q", result.Verdict)
+	}
+}
+
+func TestCheckExternalReachability_BehindNAT(t *testing.T) {
+	port := listenPort(t)
+
+	// 127.0.0.2 is not an interface address, so it stands in for a router's
+	// public IP, and the listener on 127.0.0.1 does not answer on it, as
+	// if no port was forwarded.
+	result, err := network.CheckExternalReachabilityContext(context.Background(), port, publicIP("127.0.0.2"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.BehindNAT {
+		t.Error("expected a NAT when the public IP is not a local address")
+	}
+	if result.Reachable == nil || *result.Reachable || !result.ListeningLocally || result.Error == "" {
+		t.Errorf("expected listening locally but unreachable outside, got %+v", result)
+	}
+	if !strings.Contains(result.Verdict, "port forward") || len(result.Warnings) != 1 {
+		t.Errorf("expected port-forward advice and a hairpin warning, got %q %v", result.Verdict, result.Warnings)
+	}
+}
+
+func TestCheckExternalReachability_NotListening(t *testing.T) {
+	result, err := network.CheckExternalReachabilityContext(context.Background(), closedPort(t), publicIP("127.0.0.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Reachable != nil || result.ListeningLocally || !strings.Contains(result.Verdict, "nothing is listening") {
+		t.Errorf("expected nothing listening, got %+v", result)
+	}
+}
+
+func TestCheckExternalReachability_LookupFails(t *testing.T) {
+	failing := network.ReachabilityOptions{PublicIP: func(context.Context) (string, error) {
+		return "", errors.New("echo service down")
+	}}
+	if _, err := network.CheckExternalReachabilityContext(context.Background(), 8080, failing); err == nil {
+		t.Error("expected an error when the public IP cannot be found")
+	}
+	if _, err := network.CheckExternalReachabilityContext(context.Background(), 8080, publicIP("<html>")); err == nil {
+		t.Error("expected an error when the lookup does not return an IP")
+	}
+	if _, err := network.CheckExternalReachabilityContext(context.Background(), 0, publicIP("127.0.0.1")); err == nil {
+		t.Error("expected an error for port 0")
+	}
+}
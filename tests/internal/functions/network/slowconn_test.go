@@ -0,0 +1,77 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+)
+
+func TestDiagnoseSlowConnection_SlowServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	result, err := network.DiagnoseSlowConnection(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Dominant != network.PhaseServer || result.Phases[0].Phase != network.PhaseServer {
+		t.Fatalf("expected server time to dominate, got %+v", result.Phases)
+	}
+	if result.Phases[0].Ms < 150 || result.TotalMs < result.Phases[0].Ms {
+		t.Errorf("expected at least 150ms of server time within the total, got %+v", result)
+	}
+	if result.StatusCode != http.StatusOK || result.Samples != 3 || result.Failed != 0 {
+		t.Errorf("expected 3 successful requests, got %+v", result)
+	}
+	if !strings.Contains(result.Verdict, "waiting for the first byte") {
+		t.Errorf("unexpected verdict: %s", result.Verdict)
+	}
+	// A plain HTTP request to an IP address has no DNS or TLS time.
+	for _, p := range result.Phases {
+		if (p.Phase == network.PhaseDNS || p.Phase == network.PhaseTLS) && p.Ms != 0 {
+			t.Errorf("expected no %s time, got %+v", p.Phase, p)
+		}
+	}
+}
+
+func TestDiagnoseSlowConnection_SlowTLSHandshake(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			time.Sleep(150 * time.Millisecond)
+			return nil, nil
+		},
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	result, err := network.DiagnoseSlowConnectionContext(context.Background(), server.URL, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Dominant != network.PhaseTLS {
+		t.Fatalf("expected the TLS handshake to dominate, got %+v", result.Phases)
+	}
+	if !strings.Contains(result.Verdict, "OCSP stapling") {
+		t.Errorf("unexpected verdict: %s", result.Verdict)
+	}
+}
+
+func TestDiagnoseSlowConnection_Refused(t *testing.T) {
+	_, err := network.DiagnoseSlowConnectionContext(context.Background(), "http://127.0.0.1:"+strconv.Itoa(closedPort(t)), 1)
+	if err == nil {
+		t.Error("expected an error when nothing is listening")
+	}
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/friday/internal/functions/network"
@@ -90,6 +91,106 @@ TIME-WAIT 0      0     127.0.0.1:50051     127.0.0.1:54321`
 	}
 }
 
+// TestParseSSOutput_NoInfoLine tests ss -t output, which has no info line
+func TestParseSSOutput_NoInfoLine(t *testing.T) {
+	ssOutput := `State    Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+ESTAB    0      24     10.0.0.1:50051      10.0.0.2:54321
+ESTAB    0      0      10.0.0.1:50051      10.0.0.3:41000`
+
+	stats, err := network.ParseSSOutput(ssOutput, 50051)
+	if err != nil {
+		t.Fatalf("parseSSOutput failed: %v", err)
+	}
+	if stats.State != "ESTAB" || stats.Connections != 2 {
+		t.Errorf("expected 2 ESTAB connections, got %d in %s", stats.Connections, stats.State)
+	}
+	if stats.Latency != 0 || stats.Retransmits != 0 || stats.Cwnd != 0 {
+		t.Errorf("expected zeroed info fields, got rtt=%v retrans=%d cwnd=%d",
+			stats.Latency, stats.Retransmits, stats.Cwnd)
+	}
+}
+
+// TestParseSSOutput_ExtendedFields tests ss -tie output, where -e appends
+// timer, uid, inode, and socket fields to the connection line
+func TestParseSSOutput_ExtendedFields(t *testing.T) {
+	ssOutput := `State  Recv-Q Send-Q   Local Address:Port   Peer Address:Port Process
+ESTAB  0      512      10.0.0.1:50051       10.0.0.2:54321     timer:(on,204ms,2) uid:1000 ino:3675412 sk:1001 cgroup:/user.slice <->
+	 cubic wscale:7,7 rto:408 rtt:1.25/0.5 ato:40 mss:1448 cwnd:10 bytes_retrans:4344 retrans:1/3 minrtt:0.9`
+
+	stats, err := network.ParseSSOutput(ssOutput, 50051)
+	if err != nil {
+		t.Fatalf("parseSSOutput failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected interface{}
+		actual   interface{}
+	}{
+		{"State", "ESTAB", stats.State},
+		{"SendQueueBytes", 512, stats.SendQueueBytes},
+		{"Latency", 1.25, stats.Latency},
+		{"Retransmits", 1, stats.Retransmits},
+		{"RTO", 408.0, stats.RTO},
+		{"Cwnd", 10, stats.Cwnd},
+		{"BytesRetrans", int64(4344), stats.BytesRetrans},
+	}
+	for _, tt := range tests {
+		if tt.expected != tt.actual {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.actual)
+		}
+	}
+}
+
+// TestParseSSOutput_OneLine tests ss -tiO output, where the info fields
+// share the connection line
+func TestParseSSOutput_OneLine(t *testing.T) {
+	ssOutput := `Netid State Recv-Q Send-Q Local Address:Port Peer Address:Port Process
+tcp   ESTAB 0      0      10.0.0.1:50051     10.0.0.2:54321     cubic wscale:7,7 rto:204 rtt:0.75/0.3 mss:1448 cwnd:12 retrans:0/4`
+
+	stats, err := network.ParseSSOutput(ssOutput, 50051)
+	if err != nil {
+		t.Fatalf("parseSSOutput failed: %v", err)
+	}
+	if stats.Latency != 0.75 || stats.Cwnd != 12 || stats.MSS != 1448 {
+		t.Errorf("expected rtt=0.75 cwnd=12 mss=1448, got rtt=%v cwnd=%d mss=%d",
+			stats.Latency, stats.Cwnd, stats.MSS)
+	}
+}
+
+// TestParseSSOutput_InfoLineOwnership tests that an info line only describes
+// the connection above it: a leading one is ignored, and a connection
+// without one does not inherit its predecessor's
+func TestParseSSOutput_InfoLineOwnership(t *testing.T) {
+	ssOutput := `	 cubic rto:1000 rtt:99/9 retrans:9
+State    Recv-Q Send-Q Local Address:Port  Peer Address:Port
+ESTAB    0      0      10.0.0.1:50051      10.0.0.2:54321
+         cubic wscale:7,7 rto:204 rtt:2.5/1.2 cwnd:10 retrans:7
+CLOSE-WAIT 1    0      10.0.0.1:50051      10.0.0.3:41000`
+
+	stats, err := network.ParseSSOutput(ssOutput, 50051)
+	if err != nil {
+		t.Fatalf("parseSSOutput failed: %v", err)
+	}
+	if stats.State != "CLOSE-WAIT" || stats.RecvQueueBytes != 1 {
+		t.Errorf("expected the last connection to be CLOSE-WAIT with Recv-Q 1, got %s/%d",
+			stats.State, stats.RecvQueueBytes)
+	}
+	if stats.Latency != 0 || stats.Retransmits != 0 || stats.Cwnd != 0 || stats.RTO != 0 {
+		t.Errorf("expected no info for the last connection, got rtt=%v retrans=%d cwnd=%d rto=%v",
+			stats.Latency, stats.Retransmits, stats.Cwnd, stats.RTO)
+	}
+
+	first, err := network.ParseSSOutput(strings.Join(strings.Split(ssOutput, "\n")[:4], "\n"), 50051)
+	if err != nil {
+		t.Fatalf("parseSSOutput failed: %v", err)
+	}
+	if first.Latency != 2.5 || first.Retransmits != 7 {
+		t.Errorf("expected the ESTAB info line, not the leading one, got rtt=%v retrans=%d",
+			first.Latency, first.Retransmits)
+	}
+}
+
 func TestParseSSOutput_InvalidInput(t *testing.T) {
 	ssOutput := "invalid output"
 
@@ -269,3 +370,130 @@ ESTAB    0      0      127.0.0.1:50051      127.0.0.1:54321
 			stats.Retransmits, stats.SendQueueBytes, stats.RecvQueueBytes)
 	}
 }
+
+// TestParseSSOutput_FlowControl tests extraction of cwnd, ssthresh, wscale,
+// rto, mss, and bytes_retrans from a full ss -ti detail line
+func TestParseSSOutput_FlowControl(t *testing.T) {
+	ssOutput := `Netid State Recv-Q Send-Q Local Address:Port  Peer Address:Port
+tcp   ESTAB 0      0      10.0.0.1:50051      10.0.0.2:54321
+	 cubic wscale:9,7 rto:216.5 rtt:15.2/3.1 ato:40 mss:1448 pmtu:1500 rcvmss:536 advmss:1448 cwnd:10 ssthresh:7 bytes_sent:1048576 bytes_retrans:2896 bytes_acked:1045681 segs_out:730 segs_in:420 send 7.6Mbps lastsnd:12 retrans:0/2 reordering:3`
+
+	stats, err := network.ParseSSOutput(ssOutput, 50051)
+	if err != nil {
+		t.Fatalf("parseSSOutput failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected interface{}
+		actual   interface{}
+	}{
+		{"Cwnd", 10, stats.Cwnd},
+		{"Ssthresh", 7, stats.Ssthresh},
+		{"SndWscale", 9, stats.SndWscale},
+		{"RcvWscale", 7, stats.RcvWscale},
+		{"RTO", 216.5, stats.RTO},
+		{"MSS", 1448, stats.MSS},
+		{"BytesRetrans", int64(2896), stats.BytesRetrans},
+		{"Retransmits", 0, stats.Retransmits},
+		{"Latency", 15.2, stats.Latency},
+		{"CongestionLimited", false, stats.CongestionLimited},
+	}
+
+	for _, tt := range tests {
+		if tt.expected != tt.actual {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.actual)
+		}
+	}
+}
+
+// TestParseSSOutput_CongestionLimited tests the heuristic that flags a send
+// queue larger than the congestion window can carry
+func TestParseSSOutput_CongestionLimited(t *testing.T) {
+	tests := []struct {
+		name     string
+		sendQ    int
+		cwnd     int
+		expected bool
+	}{
+		{"queue exceeds cwnd", 65536, 4, true},
+		{"queue fits in cwnd", 4096, 10, false},
+		{"empty queue", 0, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssOutput := fmt.Sprintf(`State    Recv-Q Send-Q Local Address:Port  Peer Address:Port
+ESTAB    0      %d     10.0.0.1:50051      10.0.0.2:54321
+         cubic wscale:7,7 rto:204 rtt:20/5 mss:1448 cwnd:%d ssthresh:2 retrans:0/9`, tt.sendQ, tt.cwnd)
+
+			stats, err := network.ParseSSOutput(ssOutput, 50051)
+			if err != nil {
+				t.Fatalf("parseSSOutput failed: %v", err)
+			}
+			if stats.CongestionLimited != tt.expected {
+				t.Errorf("CongestionLimited: expected %v, got %v (send_q=%d cwnd=%d)",
+					tt.expected, stats.CongestionLimited, tt.sendQ, tt.cwnd)
+			}
+		})
+	}
+}
+
+// closeWaitSSOutput builds ss -ti output for a server on port with the given
+// number of ESTAB and CLOSE-WAIT connections, in the Netid-column format.
+func closeWaitSSOutput(port, estab, closeWait int) string {
+	var sb strings.Builder
+	sb.WriteString("Netid State      Recv-Q Send-Q Local Address:Port  Peer Address:Port\n")
+	for i := 0; i < estab; i++ {
+		fmt.Fprintf(&sb, "tcp   ESTAB      0      0      10.0.0.1:%d      10.0.0.2:%d\n", port, 40000+i)
+		sb.WriteString("         cubic wscale:7,7 rto:204 rtt:0.5/0.25 mss:1448 cwnd:10\n")
+	}
+	for i := 0; i < closeWait; i++ {
+		fmt.Fprintf(&sb, "tcp   CLOSE-WAIT 1      0      10.0.0.1:%d      10.0.0.3:%d\n", port, 50000+i)
+		sb.WriteString("         cubic wscale:7,7 rto:204 rtt:0.4/0.2 mss:1448 cwnd:10\n")
+	}
+	return sb.String()
+}
+
+func TestParseSSOutput_CloseWaitLeak(t *testing.T) {
+	stats, err := network.ParseSSOutput(closeWaitSSOutput(8080, 3, 42), 8080)
+	if err != nil {
+		t.Fatalf("ParseSSOutput failed: %v", err)
+	}
+
+	if stats.Connections != 45 {
+		t.Errorf("Connections: expected 45, got %d", stats.Connections)
+	}
+	if stats.StateCounts["CLOSE-WAIT"] != 42 || stats.StateCounts["ESTAB"] != 3 {
+		t.Errorf("StateCounts: expected 42 CLOSE-WAIT and 3 ESTAB, got %v", stats.StateCounts)
+	}
+	if !stats.CloseWaitLeakSuspected {
+		t.Fatal("expected CloseWaitLeakSuspected")
+	}
+	if len(stats.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", stats.Warnings)
+	}
+	w := stats.Warnings[0]
+	if !strings.HasPrefix(w, "close_wait_leak_suspected:") || !strings.Contains(w, "42 sockets") {
+		t.Errorf("expected close_wait_leak_suspected warning with the count, got %q", w)
+	}
+}
+
+func TestParseSSOutput_CloseWaitHealthy(t *testing.T) {
+	// A handful of CLOSE-WAIT sockets is normal while the application is
+	// mid-way through closing them.
+	stats, err := network.ParseSSOutput(closeWaitSSOutput(8080, 20, network.CloseWaitLeakThreshold), 8080)
+	if err != nil {
+		t.Fatalf("ParseSSOutput failed: %v", err)
+	}
+
+	if stats.StateCounts["CLOSE-WAIT"] != network.CloseWaitLeakThreshold {
+		t.Errorf("expected %d CLOSE-WAIT, got %v", network.CloseWaitLeakThreshold, stats.StateCounts)
+	}
+	if stats.CloseWaitLeakSuspected {
+		t.Error("expected no leak suspected at the threshold")
+	}
+	if len(stats.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", stats.Warnings)
+	}
+}
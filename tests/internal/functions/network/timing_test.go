@@ -0,0 +1,100 @@
+package network
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+func TestTCPConnectTiming_LocalListener(t *testing.T) {
+	host, portStr, _ := net.SplitHostPort(listen(t))
+	port, _ := strconv.Atoi(portStr)
+
+	result, err := network.TCPConnectTiming(host, port, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Samples != 4 || len(result.SampleResults) != 4 {
+		t.Fatalf("expected 4 samples, got %d with %d results", result.Samples, len(result.SampleResults))
+	}
+	if result.Succeeded != 4 || result.Failed != 0 {
+		t.Fatalf("expected all samples to connect, got %+v", result.SampleResults)
+	}
+	for i, s := range result.SampleResults {
+		if s.Sample != i+1 {
+			t.Errorf("expected sample %d, got %d", i+1, s.Sample)
+		}
+		if s.DNSMs != 0 {
+			t.Errorf("expected no DNS time for an IP address, got %v", s.DNSMs)
+		}
+		if s.HandshakeMs < 0 || s.TotalMs < s.HandshakeMs {
+			t.Errorf("expected non-negative handshake within total, got %+v", s)
+		}
+	}
+	if result.Handshake == nil || result.Handshake.Samples != 4 || result.Handshake.MinMs < 0 {
+		t.Errorf("expected handshake stats over 4 samples, got %+v", result.Handshake)
+	}
+	if result.SlowestPhase != network.PhaseHandshake {
+		t.Errorf("expected handshake as slowest phase, got %q", result.SlowestPhase)
+	}
+}
+
+func TestTCPConnectTiming_ResolvesHostname(t *testing.T) {
+	_, portStr, _ := net.SplitHostPort(listen(t))
+	port, _ := strconv.Atoi(portStr)
+
+	result, err := network.TCPConnectTiming("localhost", port, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// localhost may resolve to ::1 first while the listener is IPv4 only.
+	for _, s := range result.SampleResults {
+		if s.DNSMs < 0 {
+			t.Errorf("expected non-negative DNS time, got %+v", s)
+		}
+		if s.Address == "" {
+			t.Errorf("expected the resolved address, got %+v", s)
+		}
+	}
+}
+
+func TestTCPConnectTiming_ClosedPort(t *testing.T) {
+	result, err := network.TCPConnectTiming("127.0.0.1", closedPort(t), 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Failed != 2 || result.Succeeded != 0 {
+		t.Fatalf("expected both samples to fail, got %+v", result.SampleResults)
+	}
+	if result.Handshake != nil || result.SlowestPhase != "" {
+		t.Errorf("expected no stats without a successful sample, got %+v", result)
+	}
+	s := result.SampleResults[0]
+	if s.Explanation == nil || s.Explanation.Category != network.ConnErrRefused {
+		t.Errorf("expected a refused explanation, got %+v", s.Explanation)
+	}
+}
+
+func TestTCPConnectTiming_RejectsBadArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		port    int
+		samples int
+	}{
+		{"zero samples", "127.0.0.1", 80, 0},
+		{"too many samples", "127.0.0.1", 80, network.MaxLatencySamples + 1},
+		{"bad port", "127.0.0.1", 0, 1},
+		{"empty host", "", 80, 1},
+		{"unix socket", "unix:///run/app.sock", 80, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := network.TCPConnectTiming(tt.host, tt.port, tt.samples); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
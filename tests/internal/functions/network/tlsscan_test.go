@@ -0,0 +1,116 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// startTLSServer serves HTTPS with config until the test ends and returns
+// its host and port.
+func startTLSServer(t *testing.T, config *tls.Config) (string, int) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = config
+	// Rejected handshakes are expected; keep them out of the test output.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+func TestTLSScan_OnlyTLS12WithOneCipher(t *testing.T) {
+	host, port := startTLSServer(t, &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+	})
+
+	result, err := network.TLSScan(host, port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.SupportedVersions) != 1 || result.SupportedVersions[0] != "TLS 1.2" {
+		t.Fatalf("expected only TLS 1.2, got %v", result.SupportedVersions)
+	}
+	if len(result.Versions) != 4 {
+		t.Fatalf("expected 4 versions tried, got %+v", result.Versions)
+	}
+	for _, v := range result.Versions {
+		if v.Version == "TLS 1.2" {
+			if len(v.Ciphers) != 1 || v.Ciphers[0] != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+				t.Errorf("expected only the configured cipher, got %v", v.Ciphers)
+			}
+			continue
+		}
+		if v.Supported || v.Error == "" {
+			t.Errorf("expected %s to be rejected with an error, got %+v", v.Version, v)
+		}
+	}
+	if len(result.WeakProtocols) != 0 || len(result.WeakCiphers) != 0 {
+		t.Errorf("expected nothing weak, got protocols %v and ciphers %v", result.WeakProtocols, result.WeakCiphers)
+	}
+}
+
+func TestTLSScan_FlagsWeakProtocolAndCipher(t *testing.T) {
+	host, port := startTLSServer(t, &tls.Config{
+		MinVersion:   tls.VersionTLS10,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_RSA_WITH_AES_128_CBC_SHA, tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA},
+	})
+
+	result, err := network.TLSScan(host, port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.WeakProtocols) != 2 || result.WeakProtocols[0] != "TLS 1.0" || result.WeakProtocols[1] != "TLS 1.1" {
+		t.Errorf("expected TLS 1.0 and 1.1 flagged, got %v", result.WeakProtocols)
+	}
+	if len(result.WeakCiphers) != 1 || result.WeakCiphers[0] != "TLS_RSA_WITH_AES_128_CBC_SHA" {
+		t.Errorf("expected the RSA key exchange cipher flagged, got %v", result.WeakCiphers)
+	}
+}
+
+func TestTLSScan_TLS13(t *testing.T) {
+	host, port := startTLSServer(t, &tls.Config{MinVersion: tls.VersionTLS13})
+
+	result, err := network.TLSScan(host, port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.SupportedVersions) != 1 || result.SupportedVersions[0] != "TLS 1.3" {
+		t.Fatalf("expected only TLS 1.3, got %v", result.SupportedVersions)
+	}
+	if v := result.Versions[3]; len(v.Ciphers) != 1 {
+		t.Errorf("expected the negotiated TLS 1.3 suite, got %+v", v)
+	}
+}
+
+func TestTLSScan_ConnectionRefused(t *testing.T) {
+	if _, err := network.TLSScan("127.0.0.1", closedPort(t)); err == nil {
+		t.Error("expected an error when nothing is listening")
+	}
+}
+
+func TestTLSScan_Cancelled(t *testing.T) {
+	host, port := startTLSServer(t, &tls.Config{MinVersion: tls.VersionTLS12})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := network.TLSScanContext(ctx, host, port); err == nil {
+		t.Error("expected an error from a cancelled scan")
+	}
+}
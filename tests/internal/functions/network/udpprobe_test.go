@@ -0,0 +1,160 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/network"
+)
+
+// udpResponder answers each datagram on a loopback port with reply(req),
+// or not at all when reply returns nil, and returns the port.
+func udpResponder(t *testing.T, reply func(req []byte) []byte) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := reply(append([]byte(nil), buf[:n]...)); resp != nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// dnsAnswer turns a query into a NOERROR response with one answer.
+func dnsAnswer(req []byte) []byte {
+	resp := append([]byte(nil), req...)
+	binary.BigEndian.PutUint16(resp[2:], 0x8180) // QR, RD, RA
+	binary.BigEndian.PutUint16(resp[6:], 1)      // ANCOUNT
+	return resp
+}
+
+func probeUDP(t *testing.T, port int, service string) *network.UDPServiceProbeResult {
+	t.Helper()
+	result, err := network.ProbeUDPServiceContext(context.Background(), "127.0.0.1", port, service,
+		network.UDPProbeOptions{Timeout: 300 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result
+}
+
+func TestProbeUDPService_DNS(t *testing.T) {
+	port := udpResponder(t, dnsAnswer)
+
+	result := probeUDP(t, port, "dns")
+	if !result.Responding || result.State != network.UDPResponding {
+		t.Fatalf("expected a responding DNS server, got %+v", result)
+	}
+	if result.Details["rcode"] != "NOERROR" || result.Details["answers"] != 1 || result.Details["recursion_available"] != true {
+		t.Errorf("unexpected details %v", result.Details)
+	}
+}
+
+func TestProbeUDPService_WrongProtocolIsInvalid(t *testing.T) {
+	// An echo server replies, but its reply is the query, not an answer.
+	port := udpResponder(t, func(req []byte) []byte { return req })
+
+	result := probeUDP(t, port, "dns")
+	if result.Responding || result.State != network.UDPInvalidResponse {
+		t.Errorf("expected an echo to be an invalid DNS response, got %+v", result)
+	}
+}
+
+func TestProbeUDPService_Timeout(t *testing.T) {
+	port := udpResponder(t, func([]byte) []byte { return nil })
+
+	start := time.Now()
+	result := probeUDP(t, port, "ntp")
+	if result.Responding || result.State != network.UDPNoResponse || result.Error == "" {
+		t.Errorf("expected no_response, got %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the probe to give up after its timeout, took %s", elapsed)
+	}
+}
+
+func TestProbeUDPService_ContextCancelled(t *testing.T) {
+	port := udpResponder(t, func([]byte) []byte { return nil })
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := network.ProbeUDPServiceContext(ctx, "127.0.0.1", port, "dns", network.UDPProbeOptions{Timeout: 5 * time.Second}); err == nil {
+		t.Error("expected an error when the context ends before a reply")
+	}
+}
+
+func TestProbeUDPService_STUN(t *testing.T) {
+	port := udpResponder(t, func(req []byte) []byte {
+		resp := make([]byte, 32)
+		binary.BigEndian.PutUint16(resp[0:], 0x0101) // Binding success
+		binary.BigEndian.PutUint16(resp[2:], 12)
+		copy(resp[4:20], req[4:20]) // cookie and transaction ID
+		// XOR-MAPPED-ADDRESS 192.0.2.1:54321.
+		binary.BigEndian.PutUint16(resp[20:], 0x0020)
+		binary.BigEndian.PutUint16(resp[22:], 8)
+		resp[25] = 0x01
+		binary.BigEndian.PutUint16(resp[26:], 54321^0x2112)
+		binary.BigEndian.PutUint32(resp[28:], 0xC0000201^0x2112A442)
+		return resp
+	})
+
+	result := probeUDP(t, port, "stun")
+	if !result.Responding || result.Details["mapped_address"] != "192.0.2.1:54321" {
+		t.Errorf("expected a STUN success with the mapped address, got %+v", result)
+	}
+}
+
+func TestProbeUDPService_STUNTruncatedPadding(t *testing.T) {
+	// One 5-byte attribute whose padding would run past the end of the reply.
+	port := udpResponder(t, func(req []byte) []byte {
+		resp := make([]byte, 29)
+		binary.BigEndian.PutUint16(resp[0:], 0x0101) // Binding success
+		binary.BigEndian.PutUint16(resp[2:], 9)
+		copy(resp[4:20], req[4:20])
+		binary.BigEndian.PutUint16(resp[20:], 0x8022) // SOFTWARE
+		binary.BigEndian.PutUint16(resp[22:], 5)
+		copy(resp[24:], "stun1")
+		return resp
+	})
+
+	result := probeUDP(t, port, "stun")
+	if !result.Responding || result.Details["result"] != "success" {
+		t.Errorf("expected the malformed reply to be read without a panic, got %+v", result)
+	}
+}
+
+func TestProbeUDPService_InvalidInput(t *testing.T) {
+	if _, err := network.ProbeUDPService("127.0.0.1", 53, "snmp"); err == nil {
+		t.Error("expected an error for an unsupported service")
+	}
+	if _, err := network.ProbeUDPService("", 53, "dns"); err == nil {
+		t.Error("expected an error without a host")
+	}
+}
+
+func TestProbeUDPService_ClosedPort(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	if result := probeUDP(t, port, "dns"); result.State != network.UDPClosed {
+		t.Errorf("expected ICMP port unreachable to report closed, got %+v", result)
+	}
+}
@@ -0,0 +1,170 @@
+package network
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// socketPath returns a path for a unix socket in a fresh temp directory.
+// The directory is kept short because socket paths are limited to about
+// 100 bytes.
+func socketPath(t *testing.T, name string) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "friday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, name)
+}
+
+// listenUnix starts a unix socket listener that accepts and closes
+// connections until the test ends, and returns its path.
+func listenUnix(t *testing.T) string {
+	t.Helper()
+	path := socketPath(t, "app.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", path, err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return path
+}
+
+func TestCheckUnixSocket_Accepting(t *testing.T) {
+	path := listenUnix(t)
+
+	for _, arg := range []string{path, "unix://" + path} {
+		result, err := network.CheckUnixSocket(arg, 2)
+		if err != nil {
+			t.Fatalf("CheckUnixSocket(%q) returned error: %v", arg, err)
+		}
+		if !result.Exists || !result.IsSocket || !result.Accepting {
+			t.Errorf("expected accepting socket for %q, got %+v", arg, result)
+		}
+		if result.Path != path || result.Error != "" {
+			t.Errorf("unexpected result for %q: %+v", arg, result)
+		}
+	}
+}
+
+func TestCheckUnixSocket_Failures(t *testing.T) {
+	// A socket file left behind by a listener that is gone.
+	stale := socketPath(t, "stale.sock")
+	ln, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	regular := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(regular, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		exists   bool
+		isSocket bool
+		category string
+	}{
+		{"missing", filepath.Join(t.TempDir(), "gone.sock"), false, false, network.ConnErrNoSocket},
+		{"regular file", regular, true, false, ""},
+		{"stale socket", stale, true, true, network.ConnErrRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := network.CheckUnixSocket(tt.path, 2)
+			if err != nil {
+				t.Fatalf("CheckUnixSocket returned error: %v", err)
+			}
+			if result.Accepting || result.Error == "" {
+				t.Errorf("expected a failed check with an error, got %+v", result)
+			}
+			if result.Exists != tt.exists || result.IsSocket != tt.isSocket {
+				t.Errorf("expected exists=%v is_socket=%v, got %+v", tt.exists, tt.isSocket, result)
+			}
+			if tt.category != "" && (result.Explanation == nil || result.Explanation.Category != tt.category) {
+				t.Errorf("expected %s explanation, got %+v", tt.category, result.Explanation)
+			}
+		})
+	}
+
+	if _, err := network.CheckUnixSocket("", 2); err == nil {
+		t.Error("expected error for empty path")
+	}
+}
+
+func TestConnectivityMatrix_UnixTargets(t *testing.T) {
+	open := "unix://" + listenUnix(t)
+	missing := "unix://" + filepath.Join(t.TempDir(), "missing.sock")
+
+	result, err := network.ConnectivityMatrix([]string{open, missing, listen(t)}, 2)
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix returned error: %v", err)
+	}
+
+	if !result.Results[0].Reachable {
+		t.Errorf("expected unix socket reachable, got %+v", result.Results[0])
+	}
+	if result.Results[1].Reachable || result.Results[1].ErrorClass != network.ConnErrNoSocket {
+		t.Errorf("expected missing socket classified %s, got %+v", network.ConnErrNoSocket, result.Results[1])
+	}
+	if !result.Results[2].Reachable {
+		t.Errorf("expected TCP target reachable alongside unix targets, got %+v", result.Results[2])
+	}
+
+	if _, err := network.ConnectivityMatrix([]string{"unix://"}, 1); err == nil {
+		t.Error("expected error for unix target without a path")
+	}
+}
+
+func TestConnectFrom_RejectsUnixTarget(t *testing.T) {
+	if _, err := network.ConnectFrom("127.0.0.1", "unix:///run/app.sock", 0); err == nil {
+		t.Error("expected error binding a source IP for a unix socket")
+	}
+}
+
+func TestCheckGRPCHealth_UnixSocket(t *testing.T) {
+	path := socketPath(t, "grpc.sock")
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	result, err := network.CheckGRPCHealth("unix://"+path, 0, 5)
+	if err != nil {
+		t.Fatalf("CheckGRPCHealth over unix socket failed: %v", err)
+	}
+	if result["status"] != "SERVING" {
+		t.Errorf("expected SERVING, got %v", result["status"])
+	}
+
+	if _, err := network.CheckGRPCHealth("unix://"+filepath.Join(t.TempDir(), "none.sock"), 0, 1); err == nil {
+		t.Error("expected error for a missing gRPC socket")
+	}
+}
@@ -0,0 +1,111 @@
+package system
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/system"
+)
+
+func TestCaptureNetworkBaseline_RoundTrip(t *testing.T) {
+	procRoot := writeSysctlFixture(t, map[string]string{
+		"net.core.rmem_max":               "212992",
+		"net.ipv4.tcp_rmem":               "4096\t131072\t6291456",
+		"net.ipv4.tcp_congestion_control": "cubic",
+	})
+
+	captured, err := system.CaptureNetworkBaselineFrom(procRoot)
+	if err != nil {
+		t.Fatalf("CaptureNetworkBaselineFrom returned error: %v", err)
+	}
+	if len(captured.Sysctls) != 3 {
+		t.Fatalf("expected 3 captured sysctls, got %v", captured.Sysctls)
+	}
+	if captured.Sysctls["net.ipv4.tcp_rmem"] != "4096 131072 6291456" {
+		t.Errorf("expected tuple normalized to spaces, got %q", captured.Sysctls["net.ipv4.tcp_rmem"])
+	}
+	// Everything else in the set is missing from the fixture.
+	if want := len(system.BaselineSysctls) - 3; len(captured.Warnings) != want {
+		t.Errorf("expected %d not-captured warnings, got %d", want, len(captured.Warnings))
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := system.WriteNetworkBaseline(path, captured); err != nil {
+		t.Fatalf("WriteNetworkBaseline returned error: %v", err)
+	}
+	loaded, err := system.LoadNetworkBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadNetworkBaseline returned error: %v", err)
+	}
+
+	if !loaded.CapturedAt.Equal(captured.CapturedAt) {
+		t.Errorf("expected captured_at %v, got %v", captured.CapturedAt, loaded.CapturedAt)
+	}
+	for param, value := range captured.Sysctls {
+		if loaded.Sysctls[param] != value {
+			t.Errorf("%s: expected %q after round trip, got %q", param, value, loaded.Sysctls[param])
+		}
+	}
+}
+
+func TestCaptureNetworkBaseline_NothingReadable(t *testing.T) {
+	if _, err := system.CaptureNetworkBaselineFrom(t.TempDir()); err == nil {
+		t.Fatal("expected error when no sysctls are readable")
+	}
+}
+
+func TestDiffNetworkBaselines_ChangedValues(t *testing.T) {
+	before := &system.NetworkBaseline{
+		CapturedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Sysctls: map[string]string{
+			"net.core.rmem_max":     "212992",
+			"net.core.somaxconn":    "4096",
+			"net.ipv4.tcp_rmem":     "4096 131072 6291456",
+			"net.ipv4.tcp_tw_reuse": "2",
+		},
+	}
+	after := &system.NetworkBaseline{
+		CapturedAt: time.Date(2025, 3, 1, 13, 0, 0, 0, time.UTC),
+		Sysctls: map[string]string{
+			"net.core.rmem_max":  "134217728",
+			"net.core.somaxconn": "4096",
+			"net.ipv4.tcp_rmem":  "4096 131072 67108864",
+			"net.core.wmem_max":  "134217728",
+		},
+	}
+
+	diff := system.DiffNetworkBaselines(before, after)
+
+	if diff.Unchanged {
+		t.Fatal("expected changes")
+	}
+	if diff.Checked != 5 {
+		t.Errorf("expected 5 parameters checked, got %d", diff.Checked)
+	}
+
+	want := []system.BaselineChange{
+		{Parameter: "net.core.rmem_max", Before: "212992", After: "134217728"},
+		{Parameter: "net.core.wmem_max", Before: "", After: "134217728"},
+		{Parameter: "net.ipv4.tcp_rmem", Before: "4096 131072 6291456", After: "4096 131072 67108864"},
+		{Parameter: "net.ipv4.tcp_tw_reuse", Before: "2", After: ""},
+	}
+	if len(diff.Changed) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), diff.Changed)
+	}
+	for i, c := range want {
+		if diff.Changed[i] != c {
+			t.Errorf("change %d: expected %+v, got %+v", i, c, diff.Changed[i])
+		}
+	}
+}
+
+func TestDiffNetworkBaselines_Unchanged(t *testing.T) {
+	snap := &system.NetworkBaseline{Sysctls: map[string]string{"net.core.somaxconn": "4096"}}
+	other := &system.NetworkBaseline{Sysctls: map[string]string{"net.core.somaxconn": "4096"}}
+
+	diff := system.DiffNetworkBaselines(snap, other)
+	if !diff.Unchanged || len(diff.Changed) != 0 {
+		t.Errorf("expected no changes, got %+v", diff.Changed)
+	}
+}
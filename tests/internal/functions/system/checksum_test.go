@@ -0,0 +1,46 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+func TestFileChecksum_KnownContent(t *testing.T) {
+	path := writeConfig(t, "nginx.conf", "hello\n")
+
+	tests := []struct {
+		algo, want string
+	}{
+		{"", "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"},
+		{"SHA256", "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"},
+		{"md5", "b1946ac92492d2347c6235b4d2611184"},
+		{"sha1", "f572d396fae9206628714fb2ce00f72e94f2258f"},
+	}
+	for _, tt := range tests {
+		result, err := system.FileChecksum(path, tt.algo)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.algo, err)
+		}
+		if result.Checksum != tt.want {
+			t.Errorf("%q: expected %s, got %s", tt.algo, tt.want, result.Checksum)
+		}
+		if result.SizeBytes != 6 || result.ModTime.IsZero() {
+			t.Errorf("%q: expected size 6 and a modification time, got %+v", tt.algo, result)
+		}
+	}
+}
+
+func TestFileChecksum_Invalid(t *testing.T) {
+	path := writeConfig(t, "app.conf", "a")
+	for name, args := range map[string][2]string{
+		"empty path":  {"", ""},
+		"bad algo":    {path, "crc32"},
+		"missing":     {path + ".missing", ""},
+		"a directory": {t.TempDir(), ""},
+	} {
+		if _, err := system.FileChecksum(args[0], args[1]); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateConfigFile_Valid(t *testing.T) {
+	tests := []struct {
+		name, content, format, want string
+	}{
+		{"app.json", `{"listen": ":8080", "upstreams": [{"host": "10.0.0.5"}]}`, "", system.ConfigFormatJSON},
+		{"app.yaml", "listen: \":8080\"\nupstreams:\n  - host: 10.0.0.5\n---\nsecond: doc\n", "", system.ConfigFormatYAML},
+		{"app.yml", "a: 1\n", "", system.ConfigFormatYAML},
+		{"app.conf", `{"a": 1}`, "json", system.ConfigFormatJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := system.ValidateConfigFile(writeConfig(t, tt.name, tt.content), tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.Valid || result.Error != "" || result.Format != tt.want {
+				t.Errorf("expected valid %s, got %+v", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestValidateConfigFile_JSONSyntaxError(t *testing.T) {
+	content := "{\n  \"listen\": \":8080\",\n  \"timeout\": 30,\n}\n"
+	result, err := system.ValidateConfigFile(writeConfig(t, "app.json", content), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected the trailing comma to be invalid")
+	}
+	// The parser fails at the "}" after the trailing comma.
+	if result.Line != 4 || result.Column != 1 {
+		t.Errorf("expected the error at line 4 column 1, got line %d column %d: %s", result.Line, result.Column, result.Error)
+	}
+}
+
+func TestValidateConfigFile_JSONUnclosedAndTrailing(t *testing.T) {
+	unclosed, err := system.ValidateConfigFile(writeConfig(t, "a.json", "{\n  \"a\": [1, 2\n"), "")
+	if err != nil || unclosed.Valid || !strings.Contains(unclosed.Error, "not closed") || unclosed.Line != 3 {
+		t.Errorf("expected an unclosed-array error at the end, got %+v (%v)", unclosed, err)
+	}
+
+	trailing, err := system.ValidateConfigFile(writeConfig(t, "b.json", "{\"a\": 1}\n{\"b\": 2}\n"), "")
+	if err != nil || trailing.Valid || trailing.Line != 2 {
+		t.Errorf("expected an error for the second value on line 2, got %+v (%v)", trailing, err)
+	}
+}
+
+func TestValidateConfigFile_YAMLIndentationError(t *testing.T) {
+	content := "server:\n  listen: :8080\n   timeout: 30\n"
+	result, err := system.ValidateConfigFile(writeConfig(t, "app.yaml", content), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid || result.Line == 0 || !strings.Contains(result.Error, "line") {
+		t.Errorf("expected an indentation error with a line, got %+v", result)
+	}
+}
+
+func TestValidateConfigFile_YAMLTabs(t *testing.T) {
+	result, err := system.ValidateConfigFile(writeConfig(t, "app.yaml", "server:\n\tlisten: :8080\n"), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid || result.Line != 2 || len(result.Warnings) != 1 {
+		t.Errorf("expected a tab error on line 2 with a warning, got %+v", result)
+	}
+}
+
+func TestValidateConfigFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, path, format string
+	}{
+		{"unknown extension", writeConfig(t, "app.conf", "{}"), ""},
+		{"unknown format", writeConfig(t, "app.json", "{}"), "toml"},
+		{"missing file", filepath.Join(dir, "missing.json"), ""},
+		{"directory", dir, "json"},
+		{"empty path", "", "json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := system.ValidateConfigFile(tt.path, tt.format); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// writeSysctlFixture writes proc/sys values under a temporary proc root.
+func writeSysctlFixture(t *testing.T, values map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for param, value := range values {
+		path := filepath.Join(root, "sys", strings.ReplaceAll(param, ".", "/"))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func writeBaseline(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "baseline.conf")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckSysctlDrift_MixedResults(t *testing.T) {
+	procRoot := writeSysctlFixture(t, map[string]string{
+		"net.core.rmem_max":           "6291456",
+		"net.core.wmem_max":           "212992",
+		"net.ipv4.tcp_rmem":           "4096\t131072\t6291456",
+		"net.ipv4.tcp_tw_reuse":       "0",
+		"net.core.somaxconn":          "4096",
+		"net.ipv4.tcp_keepalive_time": "7200",
+	})
+	baseline := writeBaseline(t, `# tuning baseline
+net.core.rmem_max = 6291456
+net.core.wmem_max=6291456
+net.ipv4.tcp_rmem = 4096 131072 6291456
+; zero is a valid desired value
+-net.ipv4.tcp_tw_reuse = 0
+net.ipv4.tcp_keepalive_time = 300
+net.ipv4.tcp_fin_timeout = 30
+`)
+
+	report, err := system.CheckSysctlDriftFrom(baseline, procRoot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]bool{
+		"net.core.rmem_max":           false,
+		"net.core.wmem_max":           true,
+		"net.ipv4.tcp_rmem":           false,
+		"net.ipv4.tcp_tw_reuse":       false,
+		"net.ipv4.tcp_keepalive_time": true,
+		"net.ipv4.tcp_fin_timeout":    true,
+	}
+	if report.Checked != len(want) {
+		t.Fatalf("Checked = %d, want %d", report.Checked, len(want))
+	}
+	for _, p := range report.Parameters {
+		drifted, ok := want[p.Parameter]
+		if !ok {
+			t.Errorf("unexpected parameter %s", p.Parameter)
+			continue
+		}
+		if p.Drifted != drifted {
+			t.Errorf("%s: drifted = %v, want %v (desired %q, current %q)", p.Parameter, p.Drifted, drifted, p.Desired, p.Current)
+		}
+	}
+
+	if report.DriftedCount != 3 || report.InSync {
+		t.Errorf("DriftedCount = %d, InSync = %v; want 3, false", report.DriftedCount, report.InSync)
+	}
+
+	for _, p := range report.Parameters {
+		switch p.Parameter {
+		case "net.core.wmem_max":
+			if p.Desired != "6291456" || p.Current != "212992" {
+				t.Errorf("wmem_max = %+v", p)
+			}
+		case "net.ipv4.tcp_rmem":
+			if p.Current != "4096 131072 6291456" {
+				t.Errorf("tcp_rmem current should be whitespace-normalized, got %q", p.Current)
+			}
+		case "net.ipv4.tcp_fin_timeout":
+			if p.Error == "" {
+				t.Error("unreadable parameter should report an error")
+			}
+		}
+	}
+}
+
+func TestCheckSysctlDrift_InSync(t *testing.T) {
+	procRoot := writeSysctlFixture(t, map[string]string{"net.core.rmem_max": "6291456"})
+	baseline := writeBaseline(t, "net.core.rmem_max = 6291456\n")
+
+	report, err := system.CheckSysctlDriftFrom(baseline, procRoot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.InSync || report.DriftedCount != 0 {
+		t.Errorf("expected in sync, got %+v", report)
+	}
+}
+
+func TestCheckSysctlDrift_InvalidEntriesSkipped(t *testing.T) {
+	procRoot := writeSysctlFixture(t, map[string]string{"net.core.rmem_max": "6291456"})
+	baseline := writeBaseline(t, `kernel.hostname = myhost
+net.core.rmem_max = 6291456
+net.core.wmem_max = $(reboot)
+not a sysctl line
+`)
+
+	report, err := system.CheckSysctlDriftFrom(baseline, procRoot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Checked != 1 {
+		t.Errorf("Checked = %d, want 1", report.Checked)
+	}
+	if len(report.Warnings) != 3 {
+		t.Errorf("expected 3 warnings, got %v", report.Warnings)
+	}
+}
+
+func TestCheckSysctlDrift_MissingBaseline(t *testing.T) {
+	_, err := system.CheckSysctlDriftFrom(filepath.Join(t.TempDir(), "nope.conf"), t.TempDir())
+	if err == nil {
+		t.Fatal("expected error for missing baseline file")
+	}
+}
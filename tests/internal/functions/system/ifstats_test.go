@@ -0,0 +1,133 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friday/internal/functions/system"
+)
+
+const procNetDevFixture = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 1048576    2048    0    0    0     0          0         0  1048576    2048    0    0    0     0       0          0
+  eth0: 987654321 812345    3  120    7     2          0       410 123456789  654321    0    5    0     0       1          0
+`
+
+func writeNetDevFixture(t *testing.T, content string) string {
+	t.Helper()
+	root := t.TempDir()
+	path := filepath.Join(root, "net/dev")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestParseProcNetDev(t *testing.T) {
+	counters, err := system.ParseProcNetDev(strings.NewReader(procNetDevFixture))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(counters) != 2 {
+		t.Fatalf("expected 2 interfaces, got %d", len(counters))
+	}
+
+	eth0 := counters[1]
+	want := system.InterfaceCounters{
+		Interface: "eth0",
+		RxBytes:   987654321, RxPackets: 812345, RxErrors: 3, RxDropped: 120, RxFIFO: 7, RxFrame: 2,
+		TxBytes: 123456789, TxPackets: 654321, TxErrors: 0, TxDropped: 5, TxFIFO: 0, TxCollisions: 0, TxCarrier: 1,
+	}
+	if eth0 != want {
+		t.Errorf("eth0 counters:\n got  %+v\n want %+v", eth0, want)
+	}
+}
+
+func TestParseProcNetDev_Malformed(t *testing.T) {
+	_, err := system.ParseProcNetDev(strings.NewReader("  eth0: 1 2 3\n"))
+	if err == nil {
+		t.Fatal("expected error for truncated line")
+	}
+}
+
+func TestInterfaceStatsFrom_WarnsOnDrops(t *testing.T) {
+	root := writeNetDevFixture(t, procNetDevFixture)
+
+	result, err := system.InterfaceStatsFrom(root, "", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Interfaces) != 2 {
+		t.Fatalf("expected 2 interfaces, got %d", len(result.Interfaces))
+	}
+	if result.Interfaces[0].Rates != nil {
+		t.Error("rates should be omitted without sampling")
+	}
+
+	joined := strings.Join(result.Warnings, "\n")
+	for _, want := range []string{"eth0: 120 RX drops", "eth0: 7 RX FIFO errors", "eth0: 2 RX frame errors", "eth0: 5 TX drops", "eth0: 1 TX carrier errors"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing warning %q in %v", want, result.Warnings)
+		}
+	}
+	if strings.Contains(joined, "lo:") {
+		t.Errorf("loopback has clean counters and should not warn: %v", result.Warnings)
+	}
+}
+
+func TestInterfaceStatsFrom_SingleInterface(t *testing.T) {
+	root := writeNetDevFixture(t, procNetDevFixture)
+
+	result, err := system.InterfaceStatsFrom(root, "lo", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Interfaces) != 1 || result.Interfaces[0].Interface != "lo" {
+		t.Fatalf("expected only lo, got %+v", result.Interfaces)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", result.Warnings)
+	}
+
+	if _, err := system.InterfaceStatsFrom(root, "wlan9", 0); err == nil {
+		t.Error("expected error for unknown interface")
+	}
+}
+
+func TestInterfaceStatsFrom_Sampled(t *testing.T) {
+	root := writeNetDevFixture(t, procNetDevFixture)
+
+	result, err := system.InterfaceStatsFrom(root, "eth0", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IntervalSeconds <= 0 {
+		t.Errorf("expected positive interval, got %v", result.IntervalSeconds)
+	}
+	rates := result.Interfaces[0].Rates
+	if rates == nil {
+		t.Fatal("expected rates when sampling")
+	}
+	if rates.RxBytesPerSec != 0 || rates.TxPacketsPerSec != 0 {
+		t.Errorf("unchanged counters should give zero rates, got %+v", rates)
+	}
+}
+
+func TestInterfaceRatesBetween(t *testing.T) {
+	before := system.InterfaceCounters{RxBytes: 1000, TxBytes: 500, RxPackets: 10, TxPackets: 5}
+	after := system.InterfaceCounters{RxBytes: 3000, TxBytes: 400, RxPackets: 30, TxPackets: 9}
+
+	rates := system.InterfaceRatesBetween(before, after, 2*time.Second)
+	if rates.RxBytesPerSec != 1000 || rates.RxPacketsPerSec != 10 || rates.TxPacketsPerSec != 2 {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+	if rates.TxBytesPerSec != 0 {
+		t.Errorf("counter reset should give 0, got %v", rates.TxBytesPerSec)
+	}
+}
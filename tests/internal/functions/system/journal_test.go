@@ -0,0 +1,84 @@
+package system
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// journalOutput is journalctl -u grpc-server -o json output, trimmed to the
+// fields ServiceLogs reads, with a binary MESSAGE and a truncated line.
+const journalOutput = `{"__REALTIME_TIMESTAMP":"1714557600000000","PRIORITY":"6","_SYSTEMD_UNIT":"grpc-server.service","MESSAGE":"Started gRPC server."}
+{"__REALTIME_TIMESTAMP":"1714557601500000","PRIORITY":"4","_SYSTEMD_UNIT":"grpc-server.service","MESSAGE":"slow handler: 1.2s"}
+{"__REALTIME_TIMESTAMP":"1714557602000000","PRIORITY":"3","_SYSTEMD_UNIT":"grpc-server.service","MESSAGE":"listen tcp :50051: bind: address already in use"}
+{"__REALTIME_TIMESTAMP":"1714557603000000","PRIORITY":"2","_SYSTEMD_UNIT":"grpc-server.service","MESSAGE":[112,97,110,105,99,255]}
+{"__REALTIME_TIMESTAMP":"1714557604000000","_SYSTEMD_UNIT":"grpc-server.service","MESSAGE":"no priority field"}
+{"__REALTIME_TIMESTAMP":"1714557605000000","PRIORITY":"6","MESS
+`
+
+func TestParseJournalJSON(t *testing.T) {
+	entries, warnings := system.ParseJournalJSON([]byte(journalOutput))
+
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d: %+v", len(entries), entries)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "line 6") {
+		t.Errorf("expected a warning for the truncated line 6, got %v", warnings)
+	}
+
+	errCount := 0
+	for _, e := range entries {
+		if e.Error {
+			errCount++
+		}
+	}
+	if errCount != 2 {
+		t.Errorf("expected 2 error entries (err and crit), got %d", errCount)
+	}
+
+	bind := entries[2]
+	if bind.Priority != 3 || bind.PriorityName != "err" || !bind.Error {
+		t.Errorf("expected an err-priority error entry, got %+v", bind)
+	}
+	if bind.Message != "listen tcp :50051: bind: address already in use" {
+		t.Errorf("unexpected message %q", bind.Message)
+	}
+	if bind.Timestamp != "2024-05-01T10:00:02Z" {
+		t.Errorf("expected RFC 3339 timestamp, got %q", bind.Timestamp)
+	}
+	if entries[1].Timestamp != "2024-05-01T10:00:01.5Z" || entries[1].Error {
+		t.Errorf("expected a non-error warning entry with sub-second time, got %+v", entries[1])
+	}
+	if entries[3].Message != "panic�" || entries[3].PriorityName != "crit" {
+		t.Errorf("expected the byte-array message decoded, got %+v", entries[3])
+	}
+	if entries[4].Priority != 6 || entries[4].PriorityName != "info" {
+		t.Errorf("expected a missing priority to read as info, got %+v", entries[4])
+	}
+}
+
+func TestParseJournalJSON_Empty(t *testing.T) {
+	entries, warnings := system.ParseJournalJSON(nil)
+	if entries == nil || len(entries) != 0 || len(warnings) != 0 {
+		t.Errorf("expected no entries and no warnings, got %v %v", entries, warnings)
+	}
+}
+
+func TestServiceLogs_RejectsBadArguments(t *testing.T) {
+	tests := []struct {
+		service string
+		since   string
+	}{
+		{"", ""},
+		{"--all", ""},
+		{"nginx; rm -rf /", ""},
+		{"nginx", "$(reboot)"},
+		{"nginx", "yesterday; reboot"},
+	}
+	for _, tt := range tests {
+		if _, err := system.ServiceLogs(tt.service, 10, tt.since); err == nil {
+			t.Errorf("expected error for service %q since %q", tt.service, tt.since)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// writeTCPTuningFixture writes the given sys/net/ipv4 values under a
+// temporary proc root.
+func writeTCPTuningFixture(t *testing.T, values map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "sys/net/ipv4")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, val := range values {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(val+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// TestInspectTCPTuning_DefaultKeepaliveWarns verifies the stock 7200s
+// keepalive time is flagged
+func TestInspectTCPTuning_DefaultKeepaliveWarns(t *testing.T) {
+	root := writeTCPTuningFixture(t, map[string]string{
+		"tcp_keepalive_time":   "7200",
+		"tcp_keepalive_intvl":  "75",
+		"tcp_keepalive_probes": "9",
+		"tcp_syn_retries":      "6",
+	})
+
+	tuning := system.InspectTCPTuningFrom(root)
+
+	if tuning.KeepaliveTime != 7200 || tuning.KeepaliveIntvl != 75 || tuning.KeepaliveProbes != 9 || tuning.SynRetries != 6 {
+		t.Errorf("unexpected values: %+v", tuning)
+	}
+	if tuning.KeepaliveDetectionSeconds != 7875 {
+		t.Errorf("expected detection time 7875s, got %d", tuning.KeepaliveDetectionSeconds)
+	}
+	if tuning.SynTimeoutSeconds != 127 {
+		t.Errorf("expected SYN timeout 127s, got %d", tuning.SynTimeoutSeconds)
+	}
+	if len(tuning.Warnings) != 1 || !strings.Contains(tuning.Warnings[0], "tcp_keepalive_time") {
+		t.Errorf("expected keepalive warning, got %v", tuning.Warnings)
+	}
+}
+
+// TestInspectTCPTuning_TunedKeepaliveNoWarning verifies a lowered keepalive
+// time produces no warning
+func TestInspectTCPTuning_TunedKeepaliveNoWarning(t *testing.T) {
+	root := writeTCPTuningFixture(t, map[string]string{
+		"tcp_keepalive_time":   "120",
+		"tcp_keepalive_intvl":  "10",
+		"tcp_keepalive_probes": "3",
+		"tcp_syn_retries":      "3",
+	})
+
+	tuning := system.InspectTCPTuningFrom(root)
+
+	if len(tuning.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", tuning.Warnings)
+	}
+	if tuning.KeepaliveDetectionSeconds != 150 || tuning.SynTimeoutSeconds != 15 {
+		t.Errorf("unexpected derived values: %+v", tuning)
+	}
+}
+
+// TestInspectTCPTuning_MissingValues verifies unreadable settings are -1 and
+// do not produce a warning
+func TestInspectTCPTuning_MissingValues(t *testing.T) {
+	root := writeTCPTuningFixture(t, map[string]string{"tcp_syn_retries": "2"})
+
+	tuning := system.InspectTCPTuningFrom(root)
+
+	if tuning.KeepaliveTime != -1 || tuning.KeepaliveDetectionSeconds != -1 {
+		t.Errorf("expected missing keepalive values to be -1, got %+v", tuning)
+	}
+	if tuning.SynRetries != 2 || tuning.SynTimeoutSeconds != 7 {
+		t.Errorf("expected syn_retries to be read, got %+v", tuning)
+	}
+	if len(tuning.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", tuning.Warnings)
+	}
+}
@@ -0,0 +1,82 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+const ssListeners = `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      4096   127.0.0.53%lo:53        0.0.0.0:*     users:(("systemd-resolve",pid=612,fd=14))
+LISTEN 0      128    0.0.0.0:22              0.0.0.0:*     users:(("sshd",pid=900,fd=3))
+LISTEN 0      511    10.0.0.5:8080           0.0.0.0:*     users:(("nginx",pid=1200,fd=6))
+LISTEN 0      128    [::]:22                 [::]:*        users:(("sshd",pid=900,fd=4))
+LISTEN 0      128    [::1]:631               [::]:*
+LISTEN 0      4096   *:9090                  *:*           users:(("prometheus",pid=1500,fd=7))
+`
+
+func TestParseListeningSockets_Classifies(t *testing.T) {
+	sockets, err := system.ParseListeningSockets(ssListeners)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		addr    string
+		port    int
+		bind    string
+		process string
+	}{
+		{"127.0.0.53", 53, system.BindLoopbackOnly, "systemd-resolve"},
+		{"0.0.0.0", 22, system.BindAllInterfaces, "sshd"},
+		{"10.0.0.5", 8080, system.BindSpecificIP, "nginx"},
+		{"::", 22, system.BindAllInterfaces, "sshd"},
+		{"::1", 631, system.BindLoopbackOnly, ""},
+		{"*", 9090, system.BindAllInterfaces, "prometheus"},
+	}
+	if len(sockets) != len(want) {
+		t.Fatalf("expected %d listeners, got %+v", len(want), sockets)
+	}
+	for i, w := range want {
+		s := sockets[i]
+		if s.Address != w.addr || s.Port != w.port || s.Bind != w.bind || s.Process != w.process {
+			t.Errorf("listener %d: expected %+v, got %+v", i, w, s)
+		}
+		if s.PotentialExposure != (w.bind == system.BindAllInterfaces) {
+			t.Errorf("listener %d: expected exposure only on all interfaces, got %+v", i, s)
+		}
+	}
+	if sockets[1].PID != 900 {
+		t.Errorf("expected sshd's pid, got %d", sockets[1].PID)
+	}
+}
+
+func TestParseListeningSockets_NetidColumn(t *testing.T) {
+	sockets, err := system.ParseListeningSockets("Netid State  Recv-Q Send-Q Local Address:Port Peer Address:Port\ntcp   LISTEN 0      128    127.0.0.1:5432     0.0.0.0:*\n")
+	if err != nil || len(sockets) != 1 || sockets[0].Port != 5432 || sockets[0].Bind != system.BindLoopbackOnly {
+		t.Errorf("expected one loopback listener on 5432, got %+v (%v)", sockets, err)
+	}
+}
+
+func TestListeningSocketsFrom_FilterPort(t *testing.T) {
+	result, err := system.ListeningSocketsFrom(ssListeners, 22)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Sockets) != 2 {
+		t.Fatalf("expected the two port 22 listeners, got %+v", result.Sockets)
+	}
+	if len(result.Exposed) != 2 || result.Exposed[0] != "sshd 0.0.0.0:22" || result.Exposed[1] != "sshd [::]:22" {
+		t.Errorf("expected both sshd listeners flagged, got %v", result.Exposed)
+	}
+
+	result, err = system.ListeningSocketsFrom(ssListeners, 631)
+	if err != nil || len(result.Exposed) != 0 || len(result.Warnings) != 1 {
+		t.Errorf("expected a loopback listener without exposure but a missing-process warning, got %+v (%v)", result, err)
+	}
+}
+
+func TestParseListeningSockets_BadPort(t *testing.T) {
+	if _, err := system.ParseListeningSockets("LISTEN 0 128 0.0.0.0:ssh 0.0.0.0:*\n"); err == nil {
+		t.Error("expected an error for a non-numeric port")
+	}
+}
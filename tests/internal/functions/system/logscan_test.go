@@ -0,0 +1,158 @@
+package system
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// writeLog writes lines to path, gzipped if path ends in ".gz".
+func writeLog(t *testing.T, path string, lines []string) {
+	t.Helper()
+	content := strings.Join(lines, "\n") + "\n"
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !strings.HasSuffix(path, ".gz") {
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rotatedLogs lays out syslog (current), syslog.1 and syslog.2.gz, with one
+// OOM kill in each.
+func rotatedLogs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "syslog")
+	writeLog(t, path+".2.gz", []string{
+		"Jan 1 kernel: eth0 link up",
+		"Jan 1 kernel: Out of memory: Killed process 100 (java)",
+		"Jan 1 kernel: eth0 link down",
+	})
+	writeLog(t, path+".1", []string{
+		"Jan 2 sshd: session opened",
+		"Jan 2 kernel: Out of memory: Killed process 200 (java)",
+	})
+	writeLog(t, path, []string{
+		"Jan 3 kernel: Out of memory: Killed process 300 (python)",
+		"Jan 3 systemd: Started app.service",
+		"Jan 3 app: connection refused",
+	})
+	return path
+}
+
+func TestScanLog_CurrentFileOnly(t *testing.T) {
+	path := rotatedLogs(t)
+
+	result, err := system.ScanLog(path, []string{"Out of memory", "refused"}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0] != path || result.LinesScanned != 3 {
+		t.Errorf("expected only the current file to be read, got %+v", result)
+	}
+	if result.Counts["Out of memory"] != 1 || result.Counts["refused"] != 1 || result.TotalMatches != 2 {
+		t.Errorf("unexpected counts: %v", result.Counts)
+	}
+	m := result.Matches[0]
+	if m.Line != 1 || len(m.Before) != 0 || len(m.After) != 2 || m.After[0] != "Jan 3 systemd: Started app.service" {
+		t.Errorf("unexpected first match: %+v", m)
+	}
+}
+
+func TestScanLog_SpansRotations(t *testing.T) {
+	path := rotatedLogs(t)
+
+	result, err := system.ScanLog(path, []string{`Out of memory: Killed process \d+`}, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantFiles := []string{path + ".2.gz", path + ".1", path}
+	if fmt.Sprint(result.Files) != fmt.Sprint(wantFiles) {
+		t.Errorf("expected files %v, got %v", wantFiles, result.Files)
+	}
+	if result.LinesScanned != 8 {
+		t.Errorf("expected all 8 lines scanned, got %d", result.LinesScanned)
+	}
+	if got := result.Counts[`Out of memory: Killed process \d+`]; got != 3 {
+		t.Fatalf("expected 3 OOM kills across the rotations, got %d", got)
+	}
+	// Matches are oldest first, each from its own file.
+	for i, file := range wantFiles {
+		if result.Matches[i].File != file {
+			t.Errorf("match %d: expected it in %s, got %+v", i, file, result.Matches[i])
+		}
+	}
+	// Context crosses the file boundary.
+	if before := result.Matches[2].Before; len(before) != 2 || !strings.Contains(before[1], "process 200") {
+		t.Errorf("expected context from syslog.1 before the current file's match, got %v", before)
+	}
+}
+
+func TestScanLog_PartialRotation(t *testing.T) {
+	path := rotatedLogs(t)
+
+	// The 3 current lines plus the last line of syslog.1.
+	result, err := system.ScanLog(path, []string{"Out of memory"}, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.LinesScanned != 4 || result.Counts["Out of memory"] != 2 || len(result.Files) != 2 {
+		t.Errorf("expected 2 matches in the last 4 lines, got %+v", result)
+	}
+	if result.Matches[0].File != path+".1" || result.Matches[0].Line != 2 {
+		t.Errorf("expected the first match on line 2 of syslog.1, got %+v", result.Matches[0])
+	}
+}
+
+func TestScanLog_GzipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	writeLog(t, path, []string{"ok", "panic: nil map", "ok"})
+
+	result, err := system.ScanLog(path, []string{"^panic"}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Counts["^panic"] != 1 || result.Matches[0].Text != "panic: nil map" {
+		t.Errorf("expected the gzipped log to be decompressed, got %+v", result)
+	}
+}
+
+func TestScanLog_Errors(t *testing.T) {
+	path := rotatedLogs(t)
+	tests := []struct {
+		name     string
+		path     string
+		patterns []string
+	}{
+		{"invalid pattern", path, []string{"Out of (memory"}},
+		{"no patterns", path, nil},
+		{"missing file", filepath.Join(t.TempDir(), "missing.log"), []string{"x"}},
+		{"directory", t.TempDir(), []string{"x"}},
+		{"empty path", "", []string{"x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := system.ScanLog(tt.path, tt.patterns, 10); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// writeProcFixture lays out the proc files DiagnoseEphemeralPortsFrom reads
+// under a temporary root. An empty twReuse omits tcp_tw_reuse.
+func writeProcFixture(t *testing.T, portRange, sockstat, twReuse string) string {
+	t.Helper()
+	root := t.TempDir()
+
+	files := map[string]string{
+		"sys/net/ipv4/ip_local_port_range": portRange,
+		"net/sockstat":                     sockstat,
+	}
+	if twReuse != "" {
+		files["sys/net/ipv4/tcp_tw_reuse"] = twReuse
+	}
+
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// TestDiagnoseEphemeralPorts_Healthy verifies a lightly used default range
+// reports ok with nothing to change
+func TestDiagnoseEphemeralPorts_Healthy(t *testing.T) {
+	root := writeProcFixture(t,
+		"32768\t60999\n",
+		"sockets: used 210\nTCP: inuse 12 orphan 0 tw 140 alloc 15 mem 2\nUDP: inuse 3 mem 1\n",
+		"2\n")
+
+	d, err := system.DiagnoseEphemeralPortsFrom(root)
+	if err != nil {
+		t.Fatalf("DiagnoseEphemeralPortsFrom failed: %v", err)
+	}
+
+	if d.RangeSize != 28232 || d.TimeWait != 140 || d.InUse != 12 {
+		t.Errorf("unexpected counts: %+v", d)
+	}
+	if d.Status != "ok" {
+		t.Errorf("expected status ok, got %s", d.Status)
+	}
+	if len(d.Warnings) != 0 || len(d.Recommendations) != 0 {
+		t.Errorf("expected no warnings or recommendations, got %v / %+v", d.Warnings, d.Recommendations)
+	}
+}
+
+// TestDiagnoseEphemeralPorts_NearExhaustion verifies a narrow range mostly
+// held in TIME-WAIT is critical and both remediations are proposed
+func TestDiagnoseEphemeralPorts_NearExhaustion(t *testing.T) {
+	root := writeProcFixture(t,
+		"50000 59999\n",
+		"sockets: used 9800\nTCP: inuse 320 orphan 4 tw 9500 alloc 330 mem 40\n",
+		"0\n")
+
+	d, err := system.DiagnoseEphemeralPortsFrom(root)
+	if err != nil {
+		t.Fatalf("DiagnoseEphemeralPortsFrom failed: %v", err)
+	}
+
+	if d.Status != "critical" {
+		t.Errorf("expected status critical, got %s", d.Status)
+	}
+	if d.UsedFraction < 0.94 || d.UsedFraction > 0.96 {
+		t.Errorf("expected used fraction ~0.95, got %f", d.UsedFraction)
+	}
+	if len(d.Warnings) == 0 {
+		t.Error("expected an exhaustion warning")
+	}
+
+	recs := map[string]system.BufferRecommendation{}
+	for _, r := range d.Recommendations {
+		recs[r.Parameter] = r
+	}
+	if r, ok := recs["net.ipv4.ip_local_port_range"]; !ok || r.Current != "50000 59999" || r.Recommended != "1024 65535" {
+		t.Errorf("expected port range widening, got %+v", recs)
+	}
+	if r, ok := recs["net.ipv4.tcp_tw_reuse"]; !ok || r.Current != "0" || r.Recommended != "1" {
+		t.Errorf("expected tcp_tw_reuse=1, got %+v", recs)
+	}
+}
+
+// TestDiagnoseEphemeralPorts_SkipsAppliedRemediations verifies settings that
+// are already in place are not recommended again
+func TestDiagnoseEphemeralPorts_SkipsAppliedRemediations(t *testing.T) {
+	root := writeProcFixture(t,
+		"1024 65535\n",
+		"TCP: inuse 10 orphan 0 tw 50000 alloc 10 mem 1\n",
+		"1\n")
+
+	d, err := system.DiagnoseEphemeralPortsFrom(root)
+	if err != nil {
+		t.Fatalf("DiagnoseEphemeralPortsFrom failed: %v", err)
+	}
+	if d.Status != "warning" {
+		t.Errorf("expected status warning, got %s", d.Status)
+	}
+	if len(d.Recommendations) != 0 {
+		t.Errorf("expected no recommendations, got %+v", d.Recommendations)
+	}
+}
+
+// TestDiagnoseEphemeralPorts_MissingTWReuse verifies an unreadable
+// tcp_tw_reuse is reported as unknown rather than failing
+func TestDiagnoseEphemeralPorts_MissingTWReuse(t *testing.T) {
+	root := writeProcFixture(t, "50000 50999\n", "TCP: inuse 1 orphan 0 tw 990 alloc 1 mem 0\n", "")
+
+	d, err := system.DiagnoseEphemeralPortsFrom(root)
+	if err != nil {
+		t.Fatalf("DiagnoseEphemeralPortsFrom failed: %v", err)
+	}
+	if d.TWReuse != -1 {
+		t.Errorf("expected TWReuse -1, got %d", d.TWReuse)
+	}
+	for _, r := range d.Recommendations {
+		if r.Parameter == "net.ipv4.tcp_tw_reuse" && r.Current != "unknown" {
+			t.Errorf("expected current 'unknown', got %q", r.Current)
+		}
+	}
+}
+
+// TestDiagnoseEphemeralPorts_BadFixtures verifies malformed proc files error
+func TestDiagnoseEphemeralPorts_BadFixtures(t *testing.T) {
+	cases := map[string][2]string{
+		"inverted range": {"60000 50000\n", "TCP: inuse 1 orphan 0 tw 0 alloc 1 mem 0\n"},
+		"no TCP line":    {"32768 60999\n", "UDP: inuse 3 mem 1\n"},
+		"bad counter":    {"32768 60999\n", "TCP: inuse x orphan 0 tw 0\n"},
+	}
+	for name, c := range cases {
+		root := writeProcFixture(t, c[0], c[1], "1\n")
+		if _, err := system.DiagnoseEphemeralPortsFrom(root); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
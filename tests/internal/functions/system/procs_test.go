@@ -0,0 +1,146 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+// fixtureProc describes one process in a fixture proc tree.
+type fixtureProc struct {
+	pid   int
+	name  string
+	ticks int // utime; stime is fixed at 0
+	rssKB int // 0 omits VmRSS, as for kernel threads
+}
+
+// writeTopFixture writes /proc/stat for two CPUs with totalTicks of CPU
+// time, and a stat and status file for each process.
+func writeTopFixture(t *testing.T, root string, totalTicks int, procs []fixtureProc) {
+	t.Helper()
+	stat := fmt.Sprintf("cpu  %d 0 0 0 0 0 0 0 0 0\ncpu0 0 0 0 0 0 0 0 0 0 0\ncpu1 0 0 0 0 0 0 0 0 0 0\nintr 0\n", totalTicks)
+	if err := os.WriteFile(filepath.Join(root, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range procs {
+		dir := filepath.Join(root, fmt.Sprint(p.pid))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		pstat := fmt.Sprintf("%d (%s) S 1 %d %d 0 -1 4194560 100 0 0 0 %d 0 0 0 20 0 1 0 100 1000 10\n",
+			p.pid, p.name, p.pid, p.pid, p.ticks)
+		status := fmt.Sprintf("Name:\t%s\nState:\tS (sleeping)\nUid:\t0\t0\t0\t0\n", p.name)
+		if p.rssKB > 0 {
+			status += fmt.Sprintf("VmRSS:\t%8d kB\n", p.rssKB)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(pstat), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "status"), []byte(status), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func pids(procs []system.ProcessInfo) []int {
+	out := make([]int, len(procs))
+	for i, p := range procs {
+		out[i] = p.PID
+	}
+	return out
+}
+
+func TestTopProcessesFrom_SortByMemWithLimit(t *testing.T) {
+	root := t.TempDir()
+	writeTopFixture(t, root, 1000, []fixtureProc{
+		{pid: 1, name: "init", rssKB: 12000},
+		{pid: 2, name: "kthreadd"},
+		{pid: 310, name: "postgres", rssKB: 850000},
+		{pid: 4521, name: "java", rssKB: 2400000},
+		{pid: 7788, name: "my (weird) proc", rssKB: 64000},
+	})
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := system.TopProcessesFrom(root, system.SortByMem, 3, 0)
+	if err != nil {
+		t.Fatalf("TopProcessesFrom returned error: %v", err)
+	}
+
+	if result.TotalProcesses != 5 {
+		t.Errorf("expected 5 processes, got %d", result.TotalProcesses)
+	}
+	if got := fmt.Sprint(pids(result.Processes)); got != "[4521 310 7788]" {
+		t.Fatalf("expected top 3 by RSS [4521 310 7788], got %s", got)
+	}
+	top := result.Processes[0]
+	if top.Name != "java" || top.RSSKB != 2400000 || top.User != "root" {
+		t.Errorf("unexpected top process: %+v", top)
+	}
+	if result.Processes[2].Name != "my (weird) proc" {
+		t.Errorf("expected name with parentheses preserved, got %q", result.Processes[2].Name)
+	}
+}
+
+func TestRankProcesses_SortByCPU(t *testing.T) {
+	root := t.TempDir()
+	writeTopFixture(t, root, 1000, []fixtureProc{
+		{pid: 100, name: "idle-worker", ticks: 50, rssKB: 9000},
+		{pid: 200, name: "encoder", ticks: 400, rssKB: 1000},
+		{pid: 300, name: "api", ticks: 100, rssKB: 5000},
+		{pid: 400, name: "exiting", ticks: 10, rssKB: 500},
+	})
+	before, err := system.SampleProcessesFrom(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 200 ticks pass on 2 CPUs, so 100 ticks is one full core. pid 400
+	// exits and pid 500 starts between the samples.
+	if err := os.RemoveAll(filepath.Join(root, "400")); err != nil {
+		t.Fatal(err)
+	}
+	writeTopFixture(t, root, 1200, []fixtureProc{
+		{pid: 100, name: "idle-worker", ticks: 50, rssKB: 9000},
+		{pid: 200, name: "encoder", ticks: 550, rssKB: 1000},
+		{pid: 300, name: "api", ticks: 125, rssKB: 5000},
+		{pid: 500, name: "new", ticks: 5, rssKB: 100},
+	})
+	after, err := system.SampleProcessesFrom(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	procs, err := system.RankProcesses(before, after, system.SortByCPU, 10)
+	if err != nil {
+		t.Fatalf("RankProcesses returned error: %v", err)
+	}
+
+	// Equal CPU (0 for idle-worker and the new process) falls back to RSS.
+	if got := fmt.Sprint(pids(procs)); got != "[200 300 100 500]" {
+		t.Fatalf("expected order [200 300 100 500], got %s", got)
+	}
+	if procs[0].CPUPercent != 150 || procs[1].CPUPercent != 25 || procs[2].CPUPercent != 0 {
+		t.Errorf("unexpected CPU percentages: %+v", procs)
+	}
+}
+
+func TestTopProcessesFrom_InvalidArgs(t *testing.T) {
+	root := t.TempDir()
+	writeTopFixture(t, root, 1000, nil)
+
+	if _, err := system.TopProcessesFrom(root, "io", 5, 0); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+	if _, err := system.TopProcessesFrom(root, system.SortByCPU, 0, 0); err == nil {
+		t.Error("expected error for zero limit")
+	}
+	if _, err := system.TopProcessesFrom(t.TempDir(), system.SortByCPU, 5, 0); err == nil {
+		t.Error("expected error when /proc/stat is missing")
+	}
+}
@@ -0,0 +1,88 @@
+package system
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/friday/internal/functions/network"
+	"github.com/friday/internal/functions/system"
+)
+
+func lowBuffers() map[string]interface{} {
+	return map[string]interface{}{
+		"rmem_max":         212992,
+		"wmem_max":         212992,
+		"tcp_rmem_min":     4096,
+		"tcp_rmem_default": 131072,
+		"tcp_rmem_max":     212992,
+		"tcp_wmem_min":     4096,
+		"tcp_wmem_default": 16384,
+		"tcp_wmem_max":     212992,
+	}
+}
+
+// TestRecommendBufferTuning_BelowTarget verifies every undersized buffer gets
+// a recommendation that exceeds its current value
+func TestRecommendBufferTuning_BelowTarget(t *testing.T) {
+	recs := system.RecommendBufferTuning(lowBuffers(), 50)
+	if len(recs) != 4 {
+		t.Fatalf("expected 4 recommendations, got %d: %+v", len(recs), recs)
+	}
+
+	for _, rec := range recs {
+		cur := lastField(t, rec.Current)
+		rcm := lastField(t, rec.Recommended)
+		if rcm <= cur {
+			t.Errorf("%s: recommended %d does not exceed current %d", rec.Parameter, rcm, cur)
+		}
+		if !strings.Contains(rec.SysctlCommand, rec.Parameter) || !strings.Contains(rec.SysctlCommand, rec.Recommended) {
+			t.Errorf("%s: sysctl command %q does not set the recommended value", rec.Parameter, rec.SysctlCommand)
+		}
+	}
+}
+
+// TestRecommendBufferTuning_TupleKeepsMinDefault verifies only the max of
+// tcp_rmem is raised
+func TestRecommendBufferTuning_TupleKeepsMinDefault(t *testing.T) {
+	for _, rec := range system.RecommendBufferTuning(lowBuffers(), 50) {
+		if rec.Parameter != "net.ipv4.tcp_rmem" {
+			continue
+		}
+		if !strings.HasPrefix(rec.Recommended, "4096 131072 ") {
+			t.Errorf("expected min/default preserved, got %q", rec.Recommended)
+		}
+		return
+	}
+	t.Fatal("expected a net.ipv4.tcp_rmem recommendation")
+}
+
+// TestRecommendBufferTuning_AlreadyAdequate verifies no change is proposed
+// when buffers already meet the BDP target
+func TestRecommendBufferTuning_AlreadyAdequate(t *testing.T) {
+	big := network.CalculateRecommendedBuffer(50) * 2
+	current := map[string]interface{}{
+		"rmem_max":         big,
+		"wmem_max":         big,
+		"tcp_rmem_min":     4096,
+		"tcp_rmem_default": 131072,
+		"tcp_rmem_max":     big,
+		"tcp_wmem_min":     4096,
+		"tcp_wmem_default": 16384,
+		"tcp_wmem_max":     float64(big), // as decoded from JSON
+	}
+
+	if recs := system.RecommendBufferTuning(current, 50); len(recs) != 0 {
+		t.Errorf("expected no recommendations, got %+v", recs)
+	}
+}
+
+func lastField(t *testing.T, s string) int {
+	t.Helper()
+	fields := strings.Fields(s)
+	n, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		t.Fatalf("cannot parse %q: %v", s, err)
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package system
+
+import (
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/friday/internal/functions/system"
+)
+
+func writeProcFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryUsageFrom(t *testing.T) {
+	root := t.TempDir()
+	writeProcFile(t, root, "meminfo", `MemTotal:        8000000 kB
+MemFree:          500000 kB
+MemAvailable:    2000000 kB
+Buffers:          100000 kB
+Cached:          1500000 kB
+SwapTotal:       1000000 kB
+SwapFree:         250000 kB
+`)
+
+	m, err := system.MemoryUsageFrom(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.TotalKB != 8000000 || m.AvailableKB != 2000000 || m.UsedPercent != 75 {
+		t.Errorf("unexpected memory usage: %+v", m)
+	}
+	if m.SwapUsedKB != 750000 || m.SwapUsedPercent != 75 {
+		t.Errorf("unexpected swap usage: %+v", m)
+	}
+}
+
+func TestMemoryUsageFrom_NoMemAvailable(t *testing.T) {
+	root := t.TempDir()
+	writeProcFile(t, root, "meminfo", "MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 50 kB\nCached: 50 kB\n")
+
+	m, err := system.MemoryUsageFrom(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.AvailableKB != 200 || m.UsedPercent != 80 {
+		t.Errorf("expected available from free, buffers and cache, got %+v", m)
+	}
+}
+
+func TestMemoryUsageFrom_Missing(t *testing.T) {
+	if _, err := system.MemoryUsageFrom(t.TempDir()); err == nil {
+		t.Error("expected an error without meminfo")
+	}
+}
+
+func TestConntrackUsageFrom(t *testing.T) {
+	root := t.TempDir()
+	writeProcFile(t, root, "sys/net/netfilter/nf_conntrack_count", "49152\n")
+	writeProcFile(t, root, "sys/net/netfilter/nf_conntrack_max", "65536\n")
+
+	c, err := system.ConntrackUsageFrom(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Count != 49152 || c.Max != 65536 || c.UsedPercent != 75 {
+		t.Errorf("unexpected conntrack usage: %+v", c)
+	}
+}
+
+func TestConntrackUsageFrom_NotLoaded(t *testing.T) {
+	_, err := system.ConntrackUsageFrom(t.TempDir())
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist without conntrack, got: %v", err)
+	}
+}
+
+func TestDiskUsageOf(t *testing.T) {
+	d, err := system.DiskUsageOf(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.TotalBytes == 0 || d.AvailableBytes > d.TotalBytes || d.UsedPercent < 0 || d.UsedPercent > 100 || math.IsNaN(d.InodesUsedPercent) {
+		t.Errorf("implausible disk usage: %+v", d)
+	}
+
+	if _, err := system.DiskUsageOf(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}